@@ -0,0 +1,242 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/secret"
+)
+
+// Exit codes of call, besides 0 for a 1xx, 2xx or 3xx status and 1 for a failed request.
+const (
+	exitClientError = 4
+	exitServerError = 5
+)
+
+const defaultCallTimeout = 30 * time.Second
+
+// ExitError is returned by the commands exiting with Code rather than 1.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// callOptions are the flags of call.
+type callOptions struct {
+	baseURL      string
+	auth         string
+	token        string
+	apiKeyHeader string
+	data         string
+	contentType  string
+	headers      []string
+	include      bool
+	raw          bool
+	timeout      time.Duration
+}
+
+// NewCallCmd creates a new cobra command calling the API of a running server
+func NewCallCmd(v *config.Viper) *cobra.Command {
+	var opts callOptions
+
+	cmd := &cobra.Command{
+		Use:   "call METHOD PATH",
+		Short: "call the API of a running server",
+		Long: `This command sends a request to the API, e.g. call get /photos/1, and prints the response body, indenting
+JSON, so the API can be exercised without curl. The path is sent to --base-url, by default the server of the
+configuration on localhost; a URL is sent as is, without the credential. --auth bearer, basic or api_key sends
+--token, which is the credential itself, env:NAME or file:PATH; basic credentials are user:password. --data sends a
+body: @PATH reads a file, @- reads stdin, anything else is sent as is.
+
+The command exits 0 for a 1xx, 2xx or 3xx status, 4 for a 4xx status, 5 for a 5xx status and 1 when the request
+fails.`,
+		Args: cobra.ExactArgs(2), //nolint:gomnd // method and path
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			return call(ctx, cmd, v, strings.ToUpper(args[0]), args[1], &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.baseURL, "base-url", "", "URL of the API, empty for the configured server on localhost")
+	cmd.Flags().StringVar(&opts.auth, "auth", "", "authentication of the request, bearer, basic or api_key")
+	cmd.Flags().StringVar(&opts.token, "token", "", "credential of --auth, or env:NAME or file:PATH referencing it")
+	cmd.Flags().StringVar(&opts.apiKeyHeader, "api-key-header", "", "header of the api_key credential, empty for X-Api-Key")
+	cmd.Flags().StringVarP(&opts.data, "data", "d", "", "request body, @PATH to read a file or @- to read stdin")
+	cmd.Flags().StringVar(&opts.contentType, "content-type", "application/json", "content type of the request body")
+	cmd.Flags().StringArrayVarP(&opts.headers, "header", "H", nil, `header of the request, "Name: value", repeatable`)
+	cmd.Flags().BoolVarP(&opts.include, "include", "i", false, "print the status and headers of the response")
+	cmd.Flags().BoolVar(&opts.raw, "raw", false, "print the response body as is, without indenting JSON")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", defaultCallTimeout, "timeout of the request")
+
+	return cmd
+}
+
+// call sends the request of opts and prints the response, failing with an *ExitError for a 4xx or 5xx status.
+func call(ctx context.Context, cmd *cobra.Command, v *config.Viper, method, path string, opts *callOptions) error {
+	baseURL := opts.baseURL
+	if baseURL == "" {
+		u, err := serverURL(v, "/")
+		if err != nil {
+			return err
+		}
+
+		baseURL = u
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	url := path
+	if strings.HasPrefix(path, "/") {
+		url = baseURL + path
+	}
+
+	credential, err := secret.Resolve(opts.token)
+	if err != nil {
+		return fmt.Errorf("error resolving token: %w", err)
+	}
+
+	t, err := client.NewUpstreamTransport(&config.Upstream{BaseURL: baseURL, Auth: opts.auth, APIKeyHeader: opts.apiKeyHeader},
+		client.StaticCredential(credential), http.DefaultTransport)
+	if err != nil {
+		return fmt.Errorf("error creating client: %w", err)
+	}
+
+	body, err := callBody(opts.data, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	if body != http.NoBody {
+		req.Header.Set("Content-Type", opts.contentType)
+	}
+
+	for _, h := range opts.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf(`invalid header %q, expected "Name: value"`, h)
+		}
+
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := client.NewClient(&http.Client{Transport: t}).Do(req, client.WithAnyStatus())
+	if err != nil {
+		return fmt.Errorf("error calling %s %s: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if err = printResponse(cmd.OutOrStdout(), resp, opts); err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &ExitError{Code: exitServerError, Err: fmt.Errorf("%s %s: status %d", method, url, resp.StatusCode)}
+	case resp.StatusCode >= http.StatusBadRequest:
+		return &ExitError{Code: exitClientError, Err: fmt.Errorf("%s %s: status %d", method, url, resp.StatusCode)}
+	default:
+		return nil
+	}
+}
+
+// callBody returns the request body of data: the file of @PATH, stdin for @-, or data itself.
+func callBody(data string, stdin io.Reader) (io.Reader, error) {
+	switch {
+	case data == "":
+		return http.NoBody, nil
+	case data == "@-":
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading body from stdin: %w", err)
+		}
+
+		return bytes.NewReader(b), nil
+	case strings.HasPrefix(data, "@"):
+		b, err := os.ReadFile(strings.TrimPrefix(data, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("error reading body: %w", err)
+		}
+
+		return bytes.NewReader(b), nil
+	default:
+		return strings.NewReader(data), nil
+	}
+}
+
+// printResponse writes the status and headers of resp if asked, and its body, indented if JSON unless raw.
+func printResponse(w io.Writer, resp *http.Response, opts *callOptions) error {
+	if opts.include {
+		fmt.Fprintf(w, "%s %s\n", resp.Proto, resp.Status)
+
+		names := make([]string, 0, len(resp.Header))
+		for name := range resp.Header {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, value := range resp.Header[name] {
+				fmt.Fprintf(w, "%s: %s\n", name, value)
+			}
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !opts.raw && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		var indented bytes.Buffer
+		if json.Indent(&indented, b, "", "  ") == nil {
+			b = indented.Bytes()
+		}
+	}
+
+	if _, err = w.Write(b); err != nil {
+		return err //nolint:wrapcheck // the output is the terminal
+	}
+
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
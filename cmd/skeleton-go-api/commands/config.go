@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// errInvalidConfig is returned by config validate when the configuration has errors, each of them logged.
+var errInvalidConfig = errors.New("invalid configuration")
+
+// NewConfigCmd creates a new cobra command checking and printing the configuration
+func NewConfigCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "check and print the configuration",
+	}
+
+	cmd.AddCommand(newConfigValidateCmd(v, l), newConfigPrintCmd(v))
+
+	return cmd
+}
+
+func newConfigValidateCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	var parseOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "validate the configuration",
+		Long: `This command builds the configuration from the file, the environment and the flags, as serve does, and
+constructs the components of the service and of the other roles without serving, then logs every error found, such
+as unknown or mistyped keys, a strictness mode forbidding a setting, or an unknown backend, and fails if there is any.
+Run it before rolling a deployment out, where the service runs as the components check their files, credentials and
+cluster; --parse-only only checks the keys and the strictness mode, e.g. in CI.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			errs := validateConfig(v, l, parseOnly)
+			for _, err := range errs {
+				l.Error("Invalid configuration", zap.Error(err))
+			}
+
+			if len(errs) > 0 {
+				return fmt.Errorf("%w: %d errors", errInvalidConfig, len(errs))
+			}
+
+			l.Info("Configuration is valid")
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&parseOnly, "parse-only", false, "only check the keys and the strictness mode, without constructing the components")
+
+	return cmd
+}
+
+// validateConfig returns the errors of the configuration, unwrapping the joined ones.
+func validateConfig(v *config.Viper, l *logger.Logger, parseOnly bool) []error {
+	cfg, err := v.Validate()
+	if cfg == nil {
+		return []error{err}
+	}
+
+	errs := unjoin(err)
+
+	st, err := app.Configure(cfg)
+	if err != nil {
+		return append(errs, err)
+	}
+
+	if parseOnly {
+		return errs
+	}
+
+	return append(errs, unjoin(app.Validate(cfg, st, l))...)
+}
+
+// unjoin returns the errors joined in err, recursively, or nil if err is nil.
+func unjoin(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint // only the errors joined at the top are split
+	if !ok {
+		if err == nil {
+			return nil
+		}
+
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, unjoin(e)...)
+	}
+
+	return errs
+}
+
+func newConfigPrintCmd(v *config.Viper) *cobra.Command {
+	var (
+		format string
+		redact bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "print the configuration",
+		Long: `This command prints the configuration built from the file, the environment and the flags, as serve sees
+it, in YAML or JSON. The secrets, such as passwords, signing keys and credentials, are redacted unless --redact=false.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := v.BuildConfig()
+			if err != nil {
+				return fmt.Errorf("error building config: %w", err)
+			}
+
+			m := config.Map(cfg, redact)
+
+			var out []byte
+
+			switch format {
+			case "yaml":
+				out, err = yaml.Marshal(m)
+			case "json":
+				out, err = json.MarshalIndent(m, "", "  ")
+				out = append(out, '\n')
+			default:
+				return fmt.Errorf("unknown format %q, expected yaml or json", format)
+			}
+
+			if err != nil {
+				return fmt.Errorf("error encoding config: %w", err)
+			}
+
+			_, err = cmd.OutOrStdout().Write(out)
+
+			return err //nolint:wrapcheck // the output is the terminal
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "yaml", "output format, yaml or json")
+	cmd.Flags().BoolVar(&redact, "redact", true, "replace the secrets with "+config.Redacted)
+
+	return cmd
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/gen"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// NewGenCmd creates a new cobra command regenerating the generated code
+func NewGenCmd(l *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "regenerate the generated code",
+	}
+
+	cmd.AddCommand(newGenMocksCmd(l))
+
+	return cmd
+}
+
+func newGenMocksCmd(l *logger.Logger) *cobra.Command {
+	var (
+		dir, mockgen string
+		check        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mocks",
+		Short: "regenerate the mocks of the interfaces",
+		Long: `This command regenerates with mockgen the mocks declared by the //go:generate mockgen directives of the
+module, in the mock_<package> package. With --check the files are left as they are, and it fails if any mock is stale,
+e.g. in CI. It also fails on generated mocks which are not declared by any directive.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			stale, err := gen.NewGenerator(dir, mockgen).Mocks(cmd.Context(), check)
+			for _, s := range stale {
+				l.Info("Stale mock", zap.String("file", s), zap.Bool("updated", !check && err == nil))
+			}
+
+			if err != nil {
+				return fmt.Errorf("error generating mocks: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "root of the module")
+	cmd.Flags().StringVar(&mockgen, "mockgen", "mockgen", "mockgen binary, go.uber.org/mock/mockgen of the version in go.mod")
+	cmd.Flags().BoolVar(&check, "check", false, "fail if a mock is stale instead of regenerating it")
+
+	return cmd
+}
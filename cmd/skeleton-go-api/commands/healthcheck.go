@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Defaults of the healthcheck flags.
+const (
+	defaultHealthcheckURL     = "/ready"
+	defaultHealthcheckTimeout = 2 * time.Second
+)
+
+// NewHealthcheckCmd creates a new cobra command checking the readiness of the server, for container probes
+func NewHealthcheckCmd(v *config.Viper) *cobra.Command {
+	var (
+		url     string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "check the readiness of the server",
+		Long: `This command sends a GET request to --url and exits 0 if the server answers with a 2xx status, 1 otherwise,
+so that a Docker HEALTHCHECK or a Kubernetes exec probe can use the binary without curl in the image. A path such as
+the default /ready is sent to the server of the configuration on localhost; a URL is sent as is.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			u, err := serverURL(v, url)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			return healthcheck(ctx, u, timeout)
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", defaultHealthcheckURL, "path on the configured server, or URL, of the readiness endpoint")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultHealthcheckTimeout, "timeout of the request")
+
+	return cmd
+}
+
+// healthcheck fails unless url answers with a 2xx status within timeout.
+func healthcheck(ctx context.Context, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("error creating healthcheck request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error checking health of %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s is not healthy: status %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
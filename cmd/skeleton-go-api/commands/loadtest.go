@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/loadtest"
+)
+
+// Defaults of the loadtest flags.
+const (
+	defaultLoadTestRate        = 10
+	defaultLoadTestDuration    = 10 * time.Second
+	defaultLoadTestMaxInFlight = 100
+	defaultLoadTestTimeout     = 10 * time.Second
+)
+
+// NewLoadTestCmd creates a new cobra command sending requests to a route at a constant rate and reporting their latency
+func NewLoadTestCmd(v *config.Viper) *cobra.Command {
+	var (
+		opts    loadtest.Options
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "loadtest <route>",
+		Short: "send requests to a route at a constant rate and report their latency",
+		Long: `This command sends GET requests to the route at --rps requests per second for --duration, and reports the
+p50, p95 and p99 latencies, the error rate and the distribution of the statuses. A path such as /photos/1 is sent to the
+server of the configuration on localhost; a URL is sent as is. Requests are started on schedule whatever the latency of
+the previous ones, and dropped once --max-in-flight are pending, so compare reports at the same rate, e.g. before and
+after a middleware change.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url, err := serverURL(v, args[0])
+			if err != nil {
+				return err
+			}
+
+			opts.URL = url
+
+			return startLoadTest(cmd, opts, timeout)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Rate, "rps", defaultLoadTestRate, "requests started per second")
+	cmd.Flags().DurationVar(&opts.Duration, "duration", defaultLoadTestDuration, "how long requests are started for")
+	cmd.Flags().IntVar(&opts.MaxInFlight, "max-in-flight", defaultLoadTestMaxInFlight, "requests pending before the next ones are dropped, 0 for no bound")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultLoadTestTimeout, "timeout of each request")
+
+	return cmd
+}
+
+// serverURL returns the URL of the route, on the configured server on localhost if it is a path.
+func serverURL(v *config.Viper, route string) (string, error) {
+	if !strings.HasPrefix(route, "/") {
+		return route, nil
+	}
+
+	cfg, err := v.BuildConfig()
+	if err != nil {
+		return "", fmt.Errorf("error building config: %w", err)
+	}
+
+	return "http://" + net.JoinHostPort("localhost", strconv.Itoa(cfg.Server.Port)) + route, nil
+}
+
+func startLoadTest(cmd *cobra.Command, opts loadtest.Options, timeout time.Duration) error {
+	t := &http.Transport{}
+	if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+		t = dt.Clone()
+	}
+
+	// Keep a connection per request in flight, so connections are reused instead of measuring their setup.
+	t.MaxIdleConnsPerHost = max(opts.MaxInFlight, http.DefaultMaxIdleConnsPerHost)
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r, err := loadtest.Run(ctx, client.NewClient(&http.Client{Transport: t, Timeout: timeout}), opts)
+	if err != nil {
+		return fmt.Errorf("error running load test: %w", err)
+	}
+
+	return r.Print(cmd.OutOrStdout()) //nolint:wrapcheck // wrapped by Print
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/scaffold"
+)
+
+// defaultUpstream is the upstream of the generated resources when none is set, followed by the resource name.
+const defaultUpstream = "https://jsonplaceholder.typicode.com/"
+
+// NewNewCmd creates a new cobra command generating the code of a new resource
+func NewNewCmd(l *logger.Logger) *cobra.Command {
+	var dir, singular, upstream string
+
+	cmd := &cobra.Command{
+		Use:   "new <resource>",
+		Short: "generate the code of a new resource",
+		Long: `This command generates the model, the service and its upstream repository, the handlers, the module of the
+routes and their tests and mocks of a new resource, such as albums, served on /<resource> and /<resource>/:id. The
+module is added to the modules of internal/app. No file is written if one of them exists.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return startNew(dir, args[0], singular, upstream, l)
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "root of the module to generate the resource in")
+	cmd.Flags().StringVar(&singular, "singular", "", "name of one item of the resource, the resource without its trailing s by default")
+	cmd.Flags().StringVar(&upstream, "upstream", "", "upstream URL of the resource, "+defaultUpstream+"<resource> by default")
+
+	return cmd
+}
+
+func startNew(dir, name, singular, upstream string, l *logger.Logger) error {
+	if upstream == "" {
+		upstream = defaultUpstream + name
+	}
+
+	r, err := scaffold.NewResource(dir, name, singular, upstream)
+	if err != nil {
+		return fmt.Errorf("error generating resource: %w", err)
+	}
+
+	files, err := scaffold.Generate(dir, r)
+	if err != nil {
+		return fmt.Errorf("error generating resource: %w", err)
+	}
+
+	for _, f := range files {
+		l.Info("Generated", zap.String("file", f))
+	}
+
+	err = scaffold.Register(dir, r)
+	if errors.Is(err, scaffold.ErrNoModules) {
+		l.Warn("Add the module of the resource to the modules of internal/app", zap.String("module", r.Package+"Module"), zap.Error(err))
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error registering resource: %w", err)
+	}
+
+	return nil
+}
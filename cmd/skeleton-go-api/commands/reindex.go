@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/search/es"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+var (
+	// errNoSearchIndex is returned when reindexing without the elasticsearch search backend.
+	errNoSearchIndex = errors.New("reindex requires the elasticsearch search backend")
+	// errNoReindexTenant is returned when reindexing in multi-tenant mode without a tenant.
+	errNoReindexTenant = errors.New("reindex requires --tenant in multi-tenant mode")
+)
+
+// NewReindexCmd creates a new cobra command rebuilding the search index of the photos from the upstream
+func NewReindexCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	var tenantID string
+
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "rebuild the search index of the photos",
+		Long: `This command indexes all the photos of the upstream, deleted or not, in the Elasticsearch index of the
+photos.search configuration, creating the index if needed. It recovers the changes the running servers could not index.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return startReindex(cmd.Context(), v, tenantID, l)
+		},
+	}
+
+	cmd.Flags().StringVar(&tenantID, "tenant", "", "tenant whose photos are reindexed, required in multi-tenant mode")
+
+	return cmd
+}
+
+func startReindex(ctx context.Context, v *config.Viper, tenantID string, l *logger.Logger) error {
+	cfg, err := v.BuildConfig()
+	if err != nil {
+		return fmt.Errorf("error building config: %w", err)
+	}
+
+	st, err := strictness.New(cfg.Strictness)
+	if err != nil {
+		return fmt.Errorf("error resolving strictness: %w", err)
+	}
+
+	c := app.New(cfg, st, l)
+	// The photos are only read, so there are no changes to index.
+	app.Provide(c, func(*app.Container) (*es.Indexer, error) { return nil, nil })
+
+	esClient, err := app.Get[*es.Client](c)
+	if err != nil {
+		return err //nolint:wrapcheck // errors of the constructors are wrapped
+	}
+
+	if esClient == nil {
+		return errNoSearchIndex
+	}
+
+	tr, err := app.Get[*tenant.Resolver](c)
+	if err != nil {
+		return err //nolint:wrapcheck // errors of the constructors are wrapped
+	}
+
+	switch {
+	case !tr.Multi():
+		tenantID = tr.Default()
+	case tenantID == "":
+		return errNoReindexTenant
+	}
+
+	ps, err := app.Get[*photos.Service](c)
+	if err != nil {
+		return err //nolint:wrapcheck // errors of the constructors are wrapped
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	n, err := es.Reindex(tenant.ContextWithTenant(ctx, tenantID), esClient, ps)
+	if err != nil {
+		return fmt.Errorf("error reindexing photos: %w", err)
+	}
+
+	l.Info("Photos reindexed", zap.String("tenant", tenantID), zap.Int("photos", n))
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// NewWorkerCmd creates a new cobra command running the worker tasks
+func NewWorkerCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	return newRoleCmd(v, l, app.RoleWorker, "run the background workers",
+		`This command runs the tasks the modules contribute to the worker role, without serving the API, until SIGTERM or
+an interrupt. It fails when no task is contributed to the role.`)
+}
+
+// NewSchedulerCmd creates a new cobra command running the scheduled tasks
+func NewSchedulerCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	return newRoleCmd(v, l, app.RoleScheduler, "run the scheduled tasks",
+		`This command runs the tasks the modules contribute to the scheduler role at their interval, on the leader replica
+only when the leader election is enabled, until SIGTERM or an interrupt. It fails when no task is scheduled, e.g.
+without photos.search.elasticsearch.reindex_interval.`)
+}
+
+// NewConsumeCmd creates a new cobra command running the message consumers
+func NewConsumeCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	return newRoleCmd(v, l, app.RoleConsume, "run the message consumers",
+		`This command runs the tasks the modules contribute to the consume role, without serving the API, until SIGTERM
+or an interrupt. It fails when no task is contributed to the role.`)
+}
+
+// newRoleCmd creates the command running the tasks of role, with the configuration of the server.
+func newRoleCmd(v *config.Viper, l *logger.Logger, role, short, long string) *cobra.Command {
+	return &cobra.Command{
+		Use:   role,
+		Short: short,
+		Long:  long,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, st, err := bootstrap(v, l)
+			if err != nil {
+				return err
+			}
+
+			return runRole(app.New(cfg, st, l), v, role)
+		},
+	}
+}
@@ -3,18 +3,12 @@ package commands
 
 import (
 	"fmt"
-	"net/http"
 
-	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"go.uber.org/zap"
 
-	"github.com/twk/skeleton-go-api/internal/api"
-	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/app"
 	"github.com/twk/skeleton-go-api/internal/config"
 	"github.com/twk/skeleton-go-api/internal/logger"
-	"github.com/twk/skeleton-go-api/internal/photos"
-	"github.com/twk/skeleton-go-api/internal/server"
 )
 
 const appName = "skeleton-go-api"
@@ -22,21 +16,24 @@ const appName = "skeleton-go-api"
 // NewRootCommand creates a new cobra command for the root command
 func NewRootCommand(l *logger.Logger) (*cobra.Command, error) {
 	v := config.NewViper()
+	app.RegisterSections(v)
 
 	b := []config.BindDetail{
-		{Flag: config.FlagDetail{Name: "config", Description: fmt.Sprintf("Specifies the path to the configuration file for %s.", appName), DefaultValue: "./config.yaml"}, MapKey: "config_path"},
+		{Flag: config.FlagDetail{Name: "config", Description: fmt.Sprintf("Specifies the path to the configuration file for %s, ./config.yaml by default.", appName), DefaultValue: ""}, MapKey: "config_path"},
+		{Flag: config.FlagDetail{Name: "env-file", Description: "Specifies the path to an env file setting the environment variables which are not set. Without it, .env is loaded in dev strictness mode if it exists.", DefaultValue: ""}, MapKey: "env_file"},
 		{Flag: config.FlagDetail{Name: "log-level", Description: "Determines the logging verbosity level for the application. Available options are 'debug', 'info', 'warn', and 'error'.", DefaultValue: ""}, EnvName: "LOG_LEVEL", MapKey: "log_level"},
 		{Flag: config.FlagDetail{Name: "stacktrace", Description: "Enables or disables the inclusion of stack traces in the log output.", DefaultValue: false}, EnvName: "STACKTRACE", MapKey: "stacktrace"},
+		{Flag: config.FlagDetail{Name: "strictness", Description: "Selects the strictness mode of the environment. Available options are 'dev', 'strict' and 'prod', the default.", DefaultValue: ""}, EnvName: "STRICTNESS", MapKey: "strictness"},
+		{EnvName: "REMEMBER_ME_KEY", MapKey: "session.remember_me.key"},
+		{EnvName: "REMEMBER_ME_TTL", MapKey: "session.remember_me.ttl"},
 	}
 
 	rootCmd := &cobra.Command{
 		Use:   appName,
 		Short: "CLI for the skeleton-go-api application",
 		Long: `CLI for the skeleton-go-api application.
-This CLI is used to interact with the skeleton-go-api application.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return startRoot(v, l)
-		},
+This CLI is used to interact with the skeleton-go-api application: serve starts the HTTP server, and worker,
+scheduler and consume run the background work of the other roles.`,
 		SilenceUsage: true,
 	}
 
@@ -44,31 +41,13 @@ This CLI is used to interact with the skeleton-go-api application.`,
 		return nil, fmt.Errorf("error initializing flags: %w", err)
 	}
 
-	rootCmd.AddCommand(NewPlaceholderCmd(v, l))
-
-	return rootCmd, nil
-}
-
-func startRoot(v *config.Viper, l *logger.Logger) error {
-	cfg, err := v.BuildConfig()
+	serveCmd, err := NewServeCmd(v, l)
 	if err != nil {
-		return fmt.Errorf("error building config: %w", err)
+		return nil, err
 	}
 
-	l.Info("starting", zap.Any("config", cfg))
+	rootCmd.AddCommand(serveCmd, NewWorkerCmd(v, l), NewSchedulerCmd(v, l), NewConsumeCmd(v, l))
+	rootCmd.AddCommand(NewPlaceholderCmd(v, l), NewReindexCmd(v, l), NewNewCmd(l), NewGenCmd(l), NewVerifyUpstreamCmd(v, l), NewLoadTestCmd(v), NewHealthcheckCmd(v), NewConfigCmd(v, l), NewRoutesCmd(v, l), NewCallCmd(v))
 
-	httpClient := &http.Client{}
-	hc := client.NewClient(httpClient)
-	ps := photos.NewService(hc, l)
-	pr := api.Photos(&cfg.Server, ps, l)
-	rp := []server.RouteParam{
-		{Method: http.MethodGet, Path: "/photos/:id", Handler: pr},
-	}
-	s := server.NewServer(&cfg.Server, gin.Default(), rp, l)
-
-	if err := s.Start(); err != nil {
-		return fmt.Errorf("error starting server: %w", err)
-	}
-
-	return nil
+	return rootCmd, nil
 }
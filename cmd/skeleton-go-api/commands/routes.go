@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+// NewRoutesCmd creates a new cobra command listing the routes of the server
+func NewRoutesCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "routes",
+		Short: "list the routes of the server",
+		Long: `This command constructs the server as serve does, without serving, and prints every registered route with
+its method, path, authentication, authorization policy, middleware and handler, to audit the surface of the API. The
+routes depend on the configuration, e.g. the admin routes are only registered when enabled. A running server lists
+them on GET /admin/routes when server.route_listing is enabled.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := v.BuildConfig()
+			if err != nil {
+				return fmt.Errorf("error building config: %w", err)
+			}
+
+			st, err := app.Configure(cfg)
+			if err != nil {
+				return err //nolint:wrapcheck // errors of Configure are wrapped
+			}
+
+			// The debug mode of gin prints the routes to stdout too.
+			gin.SetMode(gin.ReleaseMode)
+
+			s, err := app.Get[*server.Server](app.New(cfg, st, l))
+			if err != nil {
+				return err //nolint:wrapcheck // errors of the constructors are wrapped
+			}
+
+			switch format {
+			case "table":
+				return printRoutes(cmd.OutOrStdout(), s.Routes())
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+
+				return enc.Encode(s.Routes()) //nolint:wrapcheck // the output is the terminal
+			default:
+				return fmt.Errorf("unknown format %q, expected table or json", format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "output format, table or json")
+
+	return cmd
+}
+
+// printRoutes writes routes as a table, the policy followed by its roles and scopes.
+func printRoutes(w io.Writer, routes []server.RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd // padding
+
+	fmt.Fprintln(tw, "METHOD\tPATH\tAUTH\tPOLICY\tMIDDLEWARE\tHANDLER")
+
+	for _, r := range routes {
+		policy := r.Policy
+		if len(r.Roles) > 0 {
+			policy += " roles=" + strings.Join(r.Roles, ",")
+		}
+
+		if len(r.Scopes) > 0 {
+			policy += " scopes=" + strings.Join(r.Scopes, ",")
+		}
+
+		handler := r.Handler
+		if r.Canary != "" {
+			handler += " canary=" + r.Canary
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Auth, strings.TrimSpace(policy),
+			strings.Join(r.Middleware, ","), handler)
+	}
+
+	return tw.Flush() //nolint:wrapcheck // the output is the terminal
+}
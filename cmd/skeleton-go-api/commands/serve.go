@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/selftest"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+)
+
+// defaultSelfTestTimeout bounds the self-test when no timeout is configured.
+const defaultSelfTestTimeout = 30 * time.Second
+
+// NewServeCmd creates a new cobra command starting the HTTP server
+func NewServeCmd(v *config.Viper, l *logger.Logger) (*cobra.Command, error) {
+	b := []config.BindDetail{
+		{Flag: config.FlagDetail{Name: "self-test", Description: "Runs the smoke requests of the self_test configuration against the server once bound, then exits with their outcome.", DefaultValue: false}, EnvName: "SELF_TEST", MapKey: "self_test.enabled"},
+	}
+
+	cmd := &cobra.Command{
+		Use:   app.RoleServe,
+		Short: "start the HTTP server",
+		Long: `This command serves the API with the background work it feeds, such as the photo imports and the search
+indexer, until SIGTERM or an interrupt, then drains and shuts down.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return startServe(v, l)
+		},
+	}
+
+	if err := v.SetFlagAndBind(cmd, b); err != nil {
+		return nil, fmt.Errorf("error initializing flags: %w", err)
+	}
+
+	return cmd, nil
+}
+
+func startServe(v *config.Viper, l *logger.Logger) error {
+	cfg, st, err := bootstrap(v, l)
+	if err != nil {
+		return err
+	}
+
+	if cfg.SelfTest.Enabled && cfg.SelfTest.MockUpstream {
+		cfg.Client.MockUpstream.Enabled = true
+	}
+
+	c := app.New(cfg, st, l)
+	app.Supply(c, v)
+
+	if cfg.SelfTest.Enabled {
+		return runSelfTest(&cfg.SelfTest, c, l)
+	}
+
+	return runRole(c, v, app.RoleServe)
+}
+
+// bootstrap builds the configuration of the processes of the roles and resolves its strictness mode.
+func bootstrap(v *config.Viper, l *logger.Logger) (*config.Config, strictness.Settings, error) {
+	cfg, err := v.BuildConfig()
+	if err != nil {
+		return nil, strictness.Settings{}, fmt.Errorf("error building config: %w", err)
+	}
+
+	l.Info("starting", zap.Any("config", cfg))
+
+	for _, s := range v.Diff(cfg) {
+		l.Debug("Setting differs from its default", zap.String("key", s.Key), zap.Any("value", s.Value),
+			zap.Any("default", s.Default), zap.String("source", s.Source))
+	}
+
+	st, err := app.Configure(cfg)
+	if err != nil {
+		return nil, st, err //nolint:wrapcheck // wrapped by Configure
+	}
+
+	return cfg, st, nil
+}
+
+// runRole runs role with the components of c until SIGTERM or an interrupt, reloading the remote configuration from v
+// if it is watched.
+func runRole(c *app.Container, v *config.Viper, role string) error {
+	app.Supply(c, v)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := app.RunRole(ctx, c, role); err != nil {
+		return fmt.Errorf("error running %s: %w", role, err)
+	}
+
+	return nil
+}
+
+// runSelfTest serves until the smoke requests of the self-test are done, failing if any of them did.
+func runSelfTest(cfg *config.SelfTest, c *app.Container, l *logger.Logger) error {
+	s, err := app.Get[*server.Server](c)
+	if err != nil {
+		return err //nolint:wrapcheck // errors of the constructors are wrapped
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSelfTestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	checks := selftest.ChecksOf(cfg)
+
+	err = s.ServeUntil(ctx, func(ctx context.Context, baseURL string) error {
+		_, err := selftest.Run(ctx, &http.Client{}, baseURL, checks, l)
+		return err //nolint:wrapcheck // wrapped by the caller
+	})
+	if err != nil {
+		return fmt.Errorf("error running self-test: %w", err)
+	}
+
+	l.Info("self-test passed", zap.Int("checks", len(checks)))
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/contract"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/upstream"
+)
+
+// NewVerifyUpstreamCmd creates a new cobra command checking the responses of the upstream against their schemas
+func NewVerifyUpstreamCmd(v *config.Viper, l *logger.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify-upstream",
+		Short: "check the responses of the upstream against their schemas",
+		Long: `This command sends the requests of the photos service to the upstream and validates the responses against
+the JSON Schemas of internal/photos/schemas. It fails when the upstream drifted from the schemas, before the service
+breaks in production.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return startVerifyUpstream(cmd.Context(), v, l)
+		},
+	}
+}
+
+func startVerifyUpstream(ctx context.Context, v *config.Viper, l *logger.Logger) error {
+	cfg, err := v.BuildConfig()
+	if err != nil {
+		return fmt.Errorf("error building config: %w", err)
+	}
+
+	st, err := app.Configure(cfg)
+	if err != nil {
+		return err //nolint:wrapcheck // errors of Configure are wrapped
+	}
+
+	upstreams, err := app.Get[*upstream.Registry](app.New(cfg, st, l))
+	if err != nil {
+		return err //nolint:wrapcheck // errors of the constructors are wrapped
+	}
+
+	photosClient, err := upstreams.Get("photos")
+	if err != nil {
+		return err //nolint:wrapcheck // the error names the upstream
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	results, err := contract.Verify(ctx, photosClient, photos.Schemas(), photos.ContractChecks(upstreams.BaseURL("photos")))
+
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			l.Error("Upstream check failed", zap.String("check", r.Check.Name), zap.String("url", r.Check.URL), zap.Error(r.Err))
+		case len(r.Violations) > 0:
+			for _, violation := range r.Violations {
+				l.Error("Upstream response drifted", zap.String("check", r.Check.Name), zap.String("url", r.Check.URL), zap.Stringer("violation", violation))
+			}
+		default:
+			l.Info("Upstream response matches its schema", zap.String("check", r.Check.Name), zap.String("url", r.Check.URL))
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("error verifying upstream: %w", err)
+	}
+
+	return nil
+}
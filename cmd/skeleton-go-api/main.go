@@ -2,6 +2,9 @@
 package main
 
 import (
+	"errors"
+	"os"
+
 	"go.uber.org/zap"
 
 	"github.com/twk/skeleton-go-api/cmd/skeleton-go-api/commands"
@@ -17,6 +20,15 @@ func main() {
 	}
 
 	err = cmd.Execute()
+
+	var exitErr *commands.ExitError
+	if errors.As(err, &exitErr) {
+		log.Error("Failed to execute command", zap.Error(err))
+		_ = log.Sync()
+
+		os.Exit(exitErr.Code)
+	}
+
 	if err != nil {
 		log.Fatal("Failed to execute command", zap.Error(err))
 	}
@@ -0,0 +1,43 @@
+package api
+
+//go:generate mockgen -source=albums.go -destination=mocks/albums_mock.go
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+type albumService interface {
+	ListAllByAlbum(ctx context.Context, albumID int) ([]photos.Photo, error)
+}
+
+// AlbumPhotos returns a handler for getting all photos of an album
+func AlbumPhotos(cfg *config.Server, as albumService, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
+			return
+		}
+
+		p, err := as.ListAllByAlbum(ctx, id)
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to list album photos", apperror.Upstream(fmt.Errorf("failed to list album photos: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusOK, p)
+	}
+}
@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAlbumPhotos(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		id string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockalbumService)
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{id: "2"},
+			fields: fields{
+				mockOperation: func(m *mock.MockalbumService) {
+					m.EXPECT().ListAllByAlbum(gomock.Any(), 2).Return([]photos.Photo{{AlbumID: 2, ID: 51}}, nil)
+				},
+			},
+			want: want{code: http.StatusOK, body: `[{"albumId":2,"id":51,"title":"","url":"","thumbnailUrl":""}]`},
+		},
+		"invalid id": {
+			args: args{id: "abc"},
+			fields: fields{
+				mockOperation: func(m *mock.MockalbumService) {
+					m.EXPECT().ListAllByAlbum(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid id"}`},
+		},
+		"service error": {
+			args: args{id: "2"},
+			fields: fields{
+				mockOperation: func(m *mock.MockalbumService) {
+					m.EXPECT().ListAllByAlbum(gomock.Any(), 2).Return(nil, assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError, body: `{"error":"failed to list album photos"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := testutil.NewMock(t, mock.NewMockalbumService, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.GET("/albums/:id/photos", api.AlbumPhotos(&config.Server{Timeout: time.Second}, mockService, logger.NewNop()))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/albums/"+tt.args.id+"/photos", http.NoBody))
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
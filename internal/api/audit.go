@@ -0,0 +1,79 @@
+package api
+
+//go:generate mockgen -source=audit.go -destination=mocks/audit_mock.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/audit"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+type auditQuerier interface {
+	Query(ctx context.Context, f audit.Filter) (*audit.Page, error)
+}
+
+// AuditLog returns a handler querying the audit log, newest first, filtered by the actor, method, route, entity_id,
+// since and until (RFC 3339) parameters, and paginated by limit and the cursor of the previous page.
+func AuditLog(aq auditQuerier, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		f, err := parseAuditFilter(c)
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, err.Error(), apperror.Validation(err))
+			return
+		}
+
+		p, err := aq.Query(c.Request.Context(), f)
+		if errors.Is(err, audit.ErrInvalidCursor) {
+			respondError(c, l, http.StatusBadRequest, "invalid cursor", apperror.Validation(err))
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to query audit log", apperror.DB(fmt.Errorf("failed to query audit log: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+func parseAuditFilter(c *gin.Context) (audit.Filter, error) {
+	f := audit.Filter{
+		Actor:    c.Query("actor"),
+		Method:   c.Query("method"),
+		Route:    c.Query("route"),
+		EntityID: c.Query("entity_id"),
+		Cursor:   c.Query("cursor"),
+	}
+
+	var err error
+
+	if s := c.Query("since"); s != "" {
+		if f.Since, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, errors.New("invalid since")
+		}
+	}
+
+	if s := c.Query("until"); s != "" {
+		if f.Until, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, errors.New("invalid until")
+		}
+	}
+
+	if s := c.Query("limit"); s != "" {
+		if f.Limit, err = strconv.Atoi(s); err != nil || f.Limit < 1 {
+			return f, errors.New("invalid limit")
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,114 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/audit"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditLogHandler(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	type args struct {
+		query string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockauditQuerier)
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{query: "?actor=alice&method=PUT&entity_id=1&since=2024-05-01T00:00:00Z&limit=1"},
+			fields: fields{
+				mockOperation: func(m *mock.MockauditQuerier) {
+					f := audit.Filter{Actor: "alice", Method: http.MethodPut, EntityID: "1", Since: at.Add(-12 * time.Hour), Limit: 1}
+					m.EXPECT().Query(gomock.Any(), f).Return(&audit.Page{
+						Entries:    []audit.Entry{{ID: 7, Time: at, Actor: "alice", Method: http.MethodPut, Route: "/photos/:id", Path: "/photos/1", EntityID: "1", Status: http.StatusOK}},
+						NextCursor: "7",
+					}, nil)
+				},
+			},
+			want: want{
+				code: http.StatusOK,
+				body: `{"entries":[{"id":7,"time":"2024-05-01T12:00:00Z","actor":"alice","method":"PUT","route":"/photos/:id","path":"/photos/1","entity_id":"1","status":200}],"next_cursor":"7"}`,
+			},
+		},
+		"invalid since": {
+			args: args{query: "?since=yesterday"},
+			fields: fields{
+				mockOperation: func(m *mock.MockauditQuerier) {
+					m.EXPECT().Query(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid since"}`},
+		},
+		"invalid limit": {
+			args: args{query: "?limit=0"},
+			fields: fields{
+				mockOperation: func(m *mock.MockauditQuerier) {
+					m.EXPECT().Query(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid limit"}`},
+		},
+		"invalid cursor": {
+			args: args{query: "?cursor=abc"},
+			fields: fields{
+				mockOperation: func(m *mock.MockauditQuerier) {
+					m.EXPECT().Query(gomock.Any(), audit.Filter{Cursor: "abc"}).Return(nil, audit.ErrInvalidCursor)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid cursor"}`},
+		},
+		"store error": {
+			fields: fields{
+				mockOperation: func(m *mock.MockauditQuerier) {
+					m.EXPECT().Query(gomock.Any(), audit.Filter{}).Return(nil, assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError, body: `{"error":"failed to query audit log"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockQuerier := testutil.NewMock(t, mock.NewMockauditQuerier, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.GET("/admin/audit", api.AuditLog(mockQuerier, logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.args.query, http.NoBody)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+//go:generate mockgen -source=batch.go -destination=mocks/batch_mock.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// maxBatchSize bounds the number of photos requested at once.
+const maxBatchSize = 100
+
+// errInvalidBatch is returned when no ids or more than maxBatchSize are requested.
+var errInvalidBatch = errors.New("invalid number of ids")
+
+type batchPhotoService interface {
+	GetPhotosBatch(ctx context.Context, ids []int) ([]photos.Photo, error)
+}
+
+// PhotosBatch returns a handler for getting several photos at once, by the repeated id query parameter. Duplicated
+// ids are returned once.
+func PhotosBatch(cfg *config.Server, bs batchPhotoService, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		ids, err := parseIDs(c.QueryArray("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid ids", apperror.Validation(fmt.Errorf("failed to parse ids: %w", err)))
+			return
+		}
+
+		p, err := bs.GetPhotosBatch(ctx, ids)
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "photo not found"})
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to get photos", apperror.Upstream(fmt.Errorf("failed to get photos batch: %w", err)))
+			return
+		}
+
+		setStaleness(c, p...)
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+func parseIDs(values []string) ([]int, error) {
+	ids := make([]int, 0, len(values))
+
+	for _, v := range values {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q: %w", v, err)
+		}
+
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 || len(ids) > maxBatchSize {
+		return nil, fmt.Errorf("%w: %d, expected 1 to %d", errInvalidBatch, len(ids), maxBatchSize)
+	}
+
+	return ids, nil
+}
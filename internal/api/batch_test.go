@@ -0,0 +1,123 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPhotosBatchHandler(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		query string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockbatchPhotoService)
+	}
+
+	type want struct {
+		code int
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{query: "id=2&id=1&id=2"},
+			fields: fields{
+				mockOperation: func(m *mock.MockbatchPhotoService) {
+					m.EXPECT().GetPhotosBatch(gomock.Any(), []int{2, 1}).Return([]photos.Photo{{ID: 2}, {ID: 1}}, nil)
+				},
+			},
+			want: want{code: http.StatusOK},
+		},
+		"no ids": {
+			fields: fields{
+				mockOperation: func(m *mock.MockbatchPhotoService) {
+					m.EXPECT().GetPhotosBatch(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest},
+		},
+		"invalid id": {
+			args: args{query: "id=1&id=abc"},
+			fields: fields{
+				mockOperation: func(m *mock.MockbatchPhotoService) {
+					m.EXPECT().GetPhotosBatch(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest},
+		},
+		"too many ids": {
+			args: args{query: func() string {
+				q := url.Values{}
+				for id := 1; id <= 101; id++ {
+					q.Add("id", strconv.Itoa(id))
+				}
+
+				return q.Encode()
+			}()},
+			fields: fields{
+				mockOperation: func(m *mock.MockbatchPhotoService) {
+					m.EXPECT().GetPhotosBatch(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest},
+		},
+		"upstream not found": {
+			args: args{query: "id=1"},
+			fields: fields{
+				mockOperation: func(m *mock.MockbatchPhotoService) {
+					m.EXPECT().GetPhotosBatch(gomock.Any(), []int{1}).Return(nil, fmt.Errorf("failed to get photos: %w", &client.HTTPError{StatusCode: http.StatusNotFound}))
+				},
+			},
+			want: want{code: http.StatusNotFound},
+		},
+		"service error": {
+			args: args{query: "id=1"},
+			fields: fields{
+				mockOperation: func(m *mock.MockbatchPhotoService) {
+					m.EXPECT().GetPhotosBatch(gomock.Any(), []int{1}).Return(nil, assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := testutil.NewMock(t, mock.NewMockbatchPhotoService, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.GET("/photos", api.PhotosBatch(&config.Server{Timeout: 1 * time.Second}, mockService, logger.NewNop()))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/photos?"+tt.args.query, http.NoBody))
+
+			assert.Equal(t, tt.want.code, resp.Code)
+		})
+	}
+}
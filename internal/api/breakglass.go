@@ -0,0 +1,68 @@
+package api
+
+//go:generate mockgen -source=breakglass.go -destination=mocks/breakglass_mock.go
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/jsonbind"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+type breakGlassIssuer interface {
+	Issue(g auth.BreakGlassGrant, ttl time.Duration) (string, *auth.BreakGlassGrant, error)
+}
+
+type breakGlassRequest struct {
+	Subject string   `json:"subject"`
+	Routes  []string `json:"routes"`
+	TTL     string   `json:"ttl"`
+	Reason  string   `json:"reason"`
+}
+
+// IssueBreakGlass returns a handler issuing break-glass tokens on behalf of the authenticated admin. Every issued
+// token is logged with its subject, routes, expiry and reason for audit.
+func IssueBreakGlass(bg breakGlassIssuer, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id := auth.IdentityFromContext(c.Request.Context())
+		if id == nil {
+			respondError(c, l, http.StatusUnauthorized, "unauthorized", apperror.Auth(auth.ErrNoCredentials))
+			return
+		}
+
+		var req breakGlassRequest
+		if err := jsonbind.Bind(c, &req); err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid request", apperror.Validation(fmt.Errorf("failed to parse request: %w", err)))
+			return
+		}
+
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid ttl", apperror.Validation(fmt.Errorf("failed to parse ttl: %w", err)))
+			return
+		}
+
+		token, g, err := bg.Issue(auth.BreakGlassGrant{Subject: req.Subject, Routes: req.Routes, Reason: req.Reason, Issuer: id.Subject}, ttl)
+		if errors.Is(err, auth.ErrInvalidGrant) {
+			respondError(c, l, http.StatusBadRequest, err.Error(), apperror.Validation(fmt.Errorf("failed to issue break-glass token: %w", err)))
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to issue break-glass token", apperror.Internal(fmt.Errorf("failed to issue break-glass token: %w", err)))
+			return
+		}
+
+		l.Warn("break-glass token issued", zap.String("issuer", g.Issuer), zap.String("subject", g.Subject), zap.Strings("routes", g.Routes), zap.String("reason", g.Reason), zap.Time("expires_at", g.ExpiresAt))
+
+		c.JSON(http.StatusCreated, gin.H{"token": token, "expires_at": g.ExpiresAt})
+	}
+}
@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIssueBreakGlassHandler(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	type args struct {
+		id   *auth.Identity
+		body string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockbreakGlassIssuer)
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{
+				id:   &auth.Identity{Subject: "alice"},
+				body: `{"subject":"bob","routes":["/photos/:id"],"ttl":"30m","reason":"INC-42"}`,
+			},
+			fields: fields{
+				mockOperation: func(m *mock.MockbreakGlassIssuer) {
+					grant := auth.BreakGlassGrant{Subject: "bob", Routes: []string{"/photos/:id"}, Reason: "INC-42", Issuer: "alice"}
+					issued := grant
+					issued.ExpiresAt = expiresAt
+					m.EXPECT().Issue(grant, 30*time.Minute).Return("token", &issued, nil)
+				},
+			},
+			want: want{code: http.StatusCreated, body: `{"expires_at":"2024-05-01T12:00:00Z","token":"token"}`},
+		},
+		"anonymous": {
+			args: args{body: `{}`},
+			fields: fields{
+				mockOperation: func(m *mock.MockbreakGlassIssuer) {
+					m.EXPECT().Issue(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusUnauthorized, body: `{"error":"unauthorized"}`},
+		},
+		"invalid ttl": {
+			args: args{id: &auth.Identity{Subject: "alice"}, body: `{"subject":"bob","routes":["/photos/:id"],"ttl":"soon","reason":"INC-42"}`},
+			fields: fields{
+				mockOperation: func(m *mock.MockbreakGlassIssuer) {
+					m.EXPECT().Issue(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid ttl"}`},
+		},
+		"invalid grant": {
+			args: args{id: &auth.Identity{Subject: "alice"}, body: `{"subject":"bob","routes":["/photos/:id"],"ttl":"30m"}`},
+			fields: fields{
+				mockOperation: func(m *mock.MockbreakGlassIssuer) {
+					m.EXPECT().Issue(gomock.Any(), 30*time.Minute).Return("", nil, fmt.Errorf("reason is required: %w", auth.ErrInvalidGrant))
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"reason is required: invalid break-glass grant"}`},
+		},
+		"issuer error": {
+			args: args{id: &auth.Identity{Subject: "alice"}, body: `{"subject":"bob","routes":["/photos/:id"],"ttl":"30m","reason":"INC-42"}`},
+			fields: fields{
+				mockOperation: func(m *mock.MockbreakGlassIssuer) {
+					m.EXPECT().Issue(gomock.Any(), 30*time.Minute).Return("", nil, assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError, body: `{"error":"failed to issue break-glass token"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockIssuer := testutil.NewMock(t, mock.NewMockbreakGlassIssuer, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.POST("/admin/break-glass", func(c *gin.Context) {
+				if tt.args.id != nil {
+					c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), tt.args.id))
+				}
+			}, api.IssueBreakGlass(mockIssuer, logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/break-glass", strings.NewReader(tt.args.body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
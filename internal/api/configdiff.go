@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// ConfigDiffConfig holds the configuration of GET /admin/config/diff, listing the settings differing from their
+// default with their source, with the secrets redacted.
+type ConfigDiffConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AdminRoles lists the roles allowed to list the settings. It is required when enabled.
+	AdminRoles []string `mapstructure:"admin_roles"`
+}
+
+// ConfigDiffSection is the section of the configuration of GET /admin/config/diff, under config_diff. It has to be
+// registered with the config.Viper building the configuration.
+var ConfigDiffSection = config.NewSection("config_diff", ConfigDiffConfig{}, validateConfigDiff)
+
+func validateConfigDiff(cfg *ConfigDiffConfig) error {
+	if cfg.Enabled && len(cfg.AdminRoles) == 0 {
+		return errors.New("the config diff requires admin roles")
+	}
+
+	return nil
+}
+
+type configDiffer interface {
+	Diff(cfg *config.Config) []config.Setting
+}
+
+// ConfigDiff returns a handler listing the settings of cfg differing from their default, with their source and the
+// secrets redacted.
+func ConfigDiff(d configDiffer, cfg *config.Config) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		settings := d.Diff(cfg)
+		if settings == nil {
+			settings = []config.Setting{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"settings": settings})
+	}
+}
@@ -0,0 +1,52 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+type fakeDiffer []config.Setting
+
+func (d fakeDiffer) Diff(*config.Config) []config.Setting {
+	return d
+}
+
+func TestConfigDiff(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		differ fakeDiffer
+		want   string
+	}{
+		"settings": {
+			differ: fakeDiffer{{Key: "server.port", Value: 9090, Default: 8080, Source: config.SourceEnv}},
+			want:   `{"settings":[{"key":"server.port","value":9090,"default":8080,"source":"env"}]}`,
+		},
+		"defaults": {
+			want: `{"settings":[]}`,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.GET("/admin/config/diff", api.ConfigDiff(tt.differ, &config.Config{}))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/admin/config/diff", http.NoBody))
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, tt.want, resp.Body.String())
+		})
+	}
+}
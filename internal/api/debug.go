@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pprof returns a handler serving the runtime profiles of net/http/pprof on a route ending with the *profile wildcard,
+// such as /debug/pprof/*profile. The index lists the profiles.
+func Pprof() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		switch strings.TrimPrefix(c.Param("profile"), "/") {
+		case "cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			pprof.Index(c.Writer, c.Request)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+)
+
+func TestPprof(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		path     string
+		contains string
+	}{
+		"index":   {path: "/debug/pprof/", contains: "goroutine"},
+		"profile": {path: "/debug/pprof/goroutine?debug=1", contains: "goroutine profile"},
+		"cmdline": {path: "/debug/pprof/cmdline", contains: "api.test"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.GET("/debug/pprof/*profile", api.Pprof())
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.contains)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+//go:generate mockgen -source=deletions.go -destination=mocks/deletions_mock.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// errIncludeDeletedDenied is returned when deleted photos are requested without the admin policy.
+var errIncludeDeletedDenied = errors.New("including deleted photos is not allowed")
+
+type photoDeleter interface {
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+}
+
+// DeletePhoto returns a handler soft-deleting a photo, which is then left out of reads until restored.
+func DeletePhoto(cfg *config.Server, pd photoDeleter, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
+			return
+		}
+
+		err = pd.Delete(ctx, id)
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "photo not found"})
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to delete photo", apperror.DB(fmt.Errorf("failed to delete photo: %w", err)))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RestorePhoto returns a handler undeleting a soft-deleted photo. Photos which are not deleted are a conflict.
+func RestorePhoto(cfg *config.Server, pd photoDeleter, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
+			return
+		}
+
+		err = pd.Restore(ctx, id)
+		if errors.Is(err, photos.ErrNotDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": "photo not deleted"})
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to restore photo", apperror.DB(fmt.Errorf("failed to restore photo: %w", err)))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// IncludeDeleted returns a middleware including the soft-deleted photos in the reads of requests with
+// ?include_deleted=true. It is an admin option: requests not satisfying policy are rejected with 403.
+func IncludeDeleted(policy *auth.Policy, l *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, ok := c.GetQuery("include_deleted")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid include_deleted", apperror.Validation(fmt.Errorf("failed to parse include_deleted: %w", err)))
+			c.Abort()
+
+			return
+		}
+
+		if !include {
+			c.Next()
+			return
+		}
+
+		if !policy.Evaluate(auth.IdentityFromContext(c.Request.Context())).Allowed {
+			respondError(c, l, http.StatusForbidden, "forbidden", apperror.Auth(errIncludeDeletedDenied))
+			c.Abort()
+
+			return
+		}
+
+		c.Request = c.Request.WithContext(photos.IncludeDeleted(c.Request.Context()))
+		c.Next()
+	}
+}
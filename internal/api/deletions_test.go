@@ -0,0 +1,250 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDeletePhoto(t *testing.T) {
+	t.Parallel()
+
+	type fields struct {
+		mockOperation func(m *mock.MockphotoDeleter)
+	}
+
+	type want struct {
+		code int
+	}
+
+	tests := map[string]struct {
+		id     string
+		fields fields
+		want   want
+	}{
+		"success": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Delete(gomock.Any(), 1).Return(nil)
+				},
+			},
+			want: want{code: http.StatusNoContent},
+		},
+		"invalid id": {
+			id: "abc",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest},
+		},
+		"upstream not found": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Delete(gomock.Any(), 1).Return(fmt.Errorf("failed to get photos: %w", &client.HTTPError{StatusCode: http.StatusNotFound}))
+				},
+			},
+			want: want{code: http.StatusNotFound},
+		},
+		"already deleted": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Delete(gomock.Any(), 1).Return(fmt.Errorf("photo 1: %w", photos.ErrDeleted))
+				},
+			},
+			want: want{code: http.StatusNotFound},
+		},
+		"service error": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Delete(gomock.Any(), 1).Return(assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := testutil.NewMock(t, mock.NewMockphotoDeleter, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.DELETE("/photos/:id", api.DeletePhoto(&config.Server{Timeout: time.Second}, mockService, logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodDelete, "/photos/"+tt.id, http.NoBody)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+		})
+	}
+}
+
+func TestRestorePhoto(t *testing.T) {
+	t.Parallel()
+
+	type fields struct {
+		mockOperation func(m *mock.MockphotoDeleter)
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		id     string
+		fields fields
+		want   want
+	}{
+		"success": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Restore(gomock.Any(), 1).Return(nil)
+				},
+			},
+			want: want{code: http.StatusNoContent},
+		},
+		"invalid id": {
+			id: "abc",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Restore(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid id"}`},
+		},
+		"not deleted": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Restore(gomock.Any(), 1).Return(fmt.Errorf("photo 1: %w", photos.ErrNotDeleted))
+				},
+			},
+			want: want{code: http.StatusConflict, body: `{"error":"photo not deleted"}`},
+		},
+		"service error": {
+			id: "1",
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoDeleter) {
+					m.EXPECT().Restore(gomock.Any(), 1).Return(assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError, body: `{"error":"failed to restore photo"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := testutil.NewMock(t, mock.NewMockphotoDeleter, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.POST("/photos/:id/restore", api.RestorePhoto(&config.Server{Timeout: time.Second}, mockService, logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodPost, "/photos/"+tt.id+"/restore", http.NoBody)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
+
+func TestIncludeDeleted(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		query string
+		id    *auth.Identity
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"not requested": {
+			args: args{id: &auth.Identity{Groups: []string{"admins"}}},
+			want: want{code: http.StatusOK, body: "false"},
+		},
+		"requested by admin": {
+			args: args{query: "?include_deleted=true", id: &auth.Identity{Groups: []string{"admins"}}},
+			want: want{code: http.StatusOK, body: "true"},
+		},
+		"false": {
+			args: args{query: "?include_deleted=false"},
+			want: want{code: http.StatusOK, body: "false"},
+		},
+		"requested by non-admin": {
+			args: args{query: "?include_deleted=true", id: &auth.Identity{Groups: []string{"viewers"}}},
+			want: want{code: http.StatusForbidden, body: `{"error":"forbidden"}`},
+		},
+		"requested anonymously": {
+			args: args{query: "?include_deleted=true"},
+			want: want{code: http.StatusForbidden, body: `{"error":"forbidden"}`},
+		},
+		"invalid": {
+			args: args{query: "?include_deleted=maybe", id: &auth.Identity{Groups: []string{"admins"}}},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid include_deleted"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &auth.Policy{Name: "photos-admin", Roles: []string{"admins"}}
+
+			router := gin.New()
+			router.GET("/photos/:id", func(c *gin.Context) {
+				if tt.args.id != nil {
+					c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), tt.args.id))
+				}
+			}, api.IncludeDeleted(policy, logger.NewNop()), func(c *gin.Context) {
+				c.String(http.StatusOK, "%t", photos.IncludesDeleted(c.Request.Context()))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/photos/1"+tt.args.query, http.NoBody)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// problemContentType is the media type of problem details, RFC 9457.
+const problemContentType = "application/problem+json"
+
+// problem is an error response in the problem details format, with the runbook of the error for internal consumers
+// and its cause when errors are verbose.
+type problem struct {
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Status  int               `json:"status"`
+	Detail  string            `json:"detail"`
+	Runbook *apperror.Runbook `json:"runbook,omitempty"`
+	Cause   string            `json:"cause,omitempty"`
+}
+
+// respondError responds with status and msg, and records err on the context. Errors are counted and logged as sampled
+// exemplars by apperror.Middleware, so only the details are logged here, at debug level. Data accessed without a
+// tenant in multi-tenant mode is answered with 400, whatever the failing operation. Consumers accepting
+// application/problem+json get problem details instead of the error message alone, including the runbook of the
+// error when they are internal. Verbose errors add the cause, err itself.
+func respondError(c *gin.Context, l *logger.Logger, status int, msg string, err error) {
+	if errors.Is(err, tenant.ErrMissing) {
+		status, msg = http.StatusBadRequest, tenant.ErrMissing.Error()
+	}
+
+	l.Debug(msg, zap.Error(err))
+	c.Error(err) //nolint:errcheck // returns the same error
+
+	var cause string
+	if apperror.Verbose(c.Request.Context()) && err != nil {
+		cause = err.Error()
+	}
+
+	if !acceptsProblem(c.GetHeader("Accept")) {
+		body := gin.H{"error": msg}
+		if cause != "" {
+			body["cause"] = cause
+		}
+
+		c.JSON(status, body)
+
+		return
+	}
+
+	p := problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: msg, Cause: cause}
+	if rb, ok := apperror.InternalRunbook(c.Request.Context(), err); ok {
+		p.Runbook = &rb
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.JSON(status, p)
+}
+
+// acceptsProblem reports whether the Accept header lists the problem details media type.
+func acceptsProblem(accept string) bool {
+	for _, v := range strings.Split(accept, ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(v)); err == nil && mediaType == problemContentType {
+			return true
+		}
+	}
+
+	return false
+}
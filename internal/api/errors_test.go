@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	runbooks, err := apperror.NewRunbooks(&config.Errors{
+		Runbooks:         map[string]config.Runbook{"upstream": {ID: "RB-UPSTREAM", URL: "https://runbooks.example.com/upstream"}},
+		InternalNetworks: []string{"10.0.0.0/8"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	type args struct {
+		accept  string
+		ip      string
+		verbose bool
+	}
+
+	type want struct {
+		contentType string
+		body        string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"json": {
+			args: args{accept: "application/json", ip: "10.0.0.1"},
+			want: want{contentType: "application/json; charset=utf-8", body: `{"error":"failed to get photos"}`},
+		},
+		"problem internal": {
+			args: args{accept: "application/json, application/problem+json;q=0.9", ip: "10.0.0.1"},
+			want: want{
+				contentType: "application/problem+json",
+				body:        `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"failed to get photos","runbook":{"id":"RB-UPSTREAM","url":"https://runbooks.example.com/upstream"}}`,
+			},
+		},
+		"problem external": {
+			args: args{accept: "application/problem+json", ip: "203.0.113.7"},
+			want: want{
+				contentType: "application/problem+json",
+				body:        `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"failed to get photos"}`,
+			},
+		},
+		"json verbose": {
+			args: args{accept: "application/json", ip: "203.0.113.7", verbose: true},
+			want: want{contentType: "application/json; charset=utf-8", body: `{"error":"failed to get photos","cause":"failed to get photos: ` + assert.AnError.Error() + `"}`},
+		},
+		"problem verbose": {
+			args: args{accept: "application/problem+json", ip: "203.0.113.7", verbose: true},
+			want: want{
+				contentType: "application/problem+json",
+				body:        `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"failed to get photos","cause":"failed to get photos: ` + assert.AnError.Error() + `"}`,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := testutil.NewMock(t, mock.NewMockphotoService)
+			mockService.EXPECT().GetPhotos(gomock.Any(), 1).Return(nil, assert.AnError)
+
+			opts := []apperror.MiddlewareOption{apperror.WithRunbooks(runbooks)}
+			if tt.args.verbose {
+				opts = append(opts, apperror.WithVerboseErrors())
+			}
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(clientip.ContextWithIP(c.Request.Context(), tt.args.ip))
+			}, apperror.Middleware(metrics.New(), logger.NewNop(), 0, opts...))
+			router.GET("/photos/:id", api.Photos(&config.Server{Timeout: time.Second}, mockService, logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodGet, "/photos/1", http.NoBody)
+			req.Header.Set("Accept", tt.args.accept)
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			assert.Equal(t, tt.want.contentType, resp.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
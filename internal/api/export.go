@@ -0,0 +1,277 @@
+package api
+
+//go:generate mockgen -source=export.go -destination=mocks/export_mock.go
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// errInvalidExportFormat is returned for export formats other than csv, ndjson and json.
+var errInvalidExportFormat = errors.New("invalid export format")
+
+// exportCSVHeader is the header row of CSV exports.
+const exportCSVHeader = "albumId id title url thumbnailUrl deletedAt"
+
+type photoExporter interface {
+	Export(ctx context.Context, fn func(p []photos.Photo) error) error
+}
+
+// exportFormat encodes the photos of an export, a page at a time.
+type exportFormat struct {
+	contentType string
+	extension   string
+	newEncoder  func(w io.Writer) exportEncoder
+}
+
+type exportEncoder interface {
+	encode(p []photos.Photo) error
+	// close ends the export, after the last page.
+	close() error
+}
+
+// exportFormatOf returns the format named by the format query parameter.
+func exportFormatOf(name string) (exportFormat, bool) {
+	switch name {
+	case "csv":
+		return exportFormat{contentType: "text/csv; charset=utf-8", extension: "csv", newEncoder: newCSVExport}, true
+	case "ndjson":
+		return exportFormat{contentType: "application/x-ndjson", extension: "ndjson", newEncoder: newNDJSONExport}, true
+	case "json":
+		return exportFormat{contentType: "application/json; charset=utf-8", extension: "json", newEncoder: newJSONExport}, true
+	default:
+		return exportFormat{}, false
+	}
+}
+
+// ExportPhotos returns a handler streaming all photos as an attachment in the format of the format query parameter,
+// csv, ndjson or json, gzipped for consumers accepting it. Each page is flushed as soon as it is fetched from the
+// upstream. The export is not bounded by the server timeout. Once the first page is written, a failure can only
+// truncate the response, which is then logged.
+func ExportPhotos(pe photoExporter, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		format, ok := exportFormatOf(c.Query("format"))
+		if !ok {
+			respondError(c, l, http.StatusBadRequest, "format must be csv, ndjson or json", apperror.Validation(fmt.Errorf("%w: %q", errInvalidExportFormat, c.Query("format"))))
+			return
+		}
+
+		var (
+			w   io.Writer = c.Writer
+			gz  *gzip.Writer
+			enc exportEncoder
+		)
+
+		start := func() {
+			c.Header("Content-Type", format.contentType)
+			c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+				"filename": fmt.Sprintf("photos-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format.extension),
+			}))
+			c.Header("Vary", "Accept-Encoding")
+
+			if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+				c.Header("Content-Encoding", "gzip")
+				gz = gzip.NewWriter(c.Writer)
+				w = gz
+			}
+
+			c.Status(http.StatusOK)
+			enc = format.newEncoder(w)
+		}
+
+		err := pe.Export(c.Request.Context(), func(p []photos.Photo) error {
+			if enc == nil {
+				start()
+			}
+
+			if err := enc.encode(p); err != nil {
+				return err
+			}
+
+			if gz != nil {
+				if err := gz.Flush(); err != nil {
+					return fmt.Errorf("failed to flush gzip: %w", err)
+				}
+			}
+
+			c.Writer.Flush()
+
+			return nil
+		})
+
+		if err != nil && enc == nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to export photos", apperror.Upstream(fmt.Errorf("failed to export photos: %w", err)))
+			return
+		}
+
+		if err != nil {
+			l.Error("Photo export truncated", zap.Error(err))
+			c.Error(apperror.Upstream(fmt.Errorf("photo export truncated: %w", err))) //nolint:errcheck // returns the same error
+
+			return
+		}
+
+		if enc == nil {
+			start()
+		}
+
+		if err = finishExport(enc, gz); err != nil {
+			l.Error("Failed to finish photo export", zap.Error(err))
+			c.Error(apperror.Internal(err)) //nolint:errcheck // returns the same error
+		}
+	}
+}
+
+func finishExport(enc exportEncoder, gz *gzip.Writer) error {
+	if err := enc.close(); err != nil {
+		return err
+	}
+
+	if gz == nil {
+		return nil
+	}
+
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to close gzip: %w", err)
+	}
+
+	return nil
+}
+
+// acceptsGzip reports whether the Accept-Encoding header accepts gzip.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, v := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(v), ";")
+		if strings.TrimSpace(coding) == "gzip" && strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+type csvExport struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVExport(w io.Writer) exportEncoder {
+	return &csvExport{w: csv.NewWriter(w)}
+}
+
+func (e *csvExport) encode(p []photos.Photo) error {
+	if !e.wroteHeader {
+		e.wroteHeader = true
+		if err := e.w.Write(strings.Fields(exportCSVHeader)); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+	}
+
+	for _, photo := range p {
+		deletedAt := ""
+		if photo.DeletedAt != nil {
+			deletedAt = photo.DeletedAt.Format(time.RFC3339)
+		}
+
+		record := []string{strconv.Itoa(photo.AlbumID), strconv.Itoa(photo.ID), photo.Title, photo.URL, photo.ThumbnailURL, deletedAt}
+		if err := e.w.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv record: %w", err)
+		}
+	}
+
+	e.w.Flush()
+
+	return e.w.Error() //nolint:wrapcheck // write errors of the response
+}
+
+func (e *csvExport) close() error {
+	return e.encode(nil)
+}
+
+type ndjsonExport struct {
+	enc *json.Encoder
+}
+
+func newNDJSONExport(w io.Writer) exportEncoder {
+	return &ndjsonExport{enc: json.NewEncoder(w)}
+}
+
+func (e *ndjsonExport) encode(p []photos.Photo) error {
+	for _, photo := range p {
+		if err := e.enc.Encode(photo); err != nil {
+			return fmt.Errorf("failed to write photo: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (e *ndjsonExport) close() error {
+	return nil
+}
+
+// jsonExport writes a JSON array, one element at a time.
+type jsonExport struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONExport(w io.Writer) exportEncoder {
+	return &jsonExport{w: w}
+}
+
+func (e *jsonExport) encode(p []photos.Photo) error {
+	for _, photo := range p {
+		b, err := json.Marshal(photo)
+		if err != nil {
+			return fmt.Errorf("failed to marshal photo: %w", err)
+		}
+
+		sep := ","
+		if e.count == 0 {
+			sep = "["
+		}
+
+		if _, err = io.WriteString(e.w, sep); err != nil {
+			return fmt.Errorf("failed to write photo: %w", err)
+		}
+
+		if _, err = e.w.Write(b); err != nil {
+			return fmt.Errorf("failed to write photo: %w", err)
+		}
+
+		e.count++
+	}
+
+	return nil
+}
+
+func (e *jsonExport) close() error {
+	end := "]"
+	if e.count == 0 {
+		end = "[]"
+	}
+
+	if _, err := io.WriteString(e.w, end); err != nil {
+		return fmt.Errorf("failed to write photos: %w", err)
+	}
+
+	return nil
+}
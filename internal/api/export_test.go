@@ -0,0 +1,177 @@
+package api_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+)
+
+func TestExportPhotos(t *testing.T) {
+	t.Parallel()
+
+	pages := [][]photos.Photo{
+		{{AlbumID: 1, ID: 1, Title: "one, two", URL: "https://example.com/1", ThumbnailURL: "https://example.com/t/1"}},
+		{{AlbumID: 1, ID: 2, Title: "two", URL: "https://example.com/2", ThumbnailURL: "https://example.com/t/2"}},
+	}
+	export := func(_ context.Context, fn func(p []photos.Photo) error) error {
+		for _, p := range pages {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	type args struct {
+		format         string
+		acceptEncoding string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockphotoExporter)
+	}
+
+	type want struct {
+		code               int
+		contentType        string
+		contentDisposition string
+		contentEncoding    string
+		body               string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"csv": {
+			args:   args{format: "csv"},
+			fields: fields{mockOperation: func(m *mock.MockphotoExporter) { m.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(export) }},
+			want: want{
+				code:               http.StatusOK,
+				contentType:        "text/csv; charset=utf-8",
+				contentDisposition: "photos-",
+				body: "albumId,id,title,url,thumbnailUrl,deletedAt\n" +
+					"1,1,\"one, two\",https://example.com/1,https://example.com/t/1,\n" +
+					"1,2,two,https://example.com/2,https://example.com/t/2,\n",
+			},
+		},
+		"ndjson": {
+			args:   args{format: "ndjson"},
+			fields: fields{mockOperation: func(m *mock.MockphotoExporter) { m.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(export) }},
+			want: want{
+				code:               http.StatusOK,
+				contentType:        "application/x-ndjson",
+				contentDisposition: ".ndjson",
+				body: `{"albumId":1,"id":1,"title":"one, two","url":"https://example.com/1","thumbnailUrl":"https://example.com/t/1"}` + "\n" +
+					`{"albumId":1,"id":2,"title":"two","url":"https://example.com/2","thumbnailUrl":"https://example.com/t/2"}` + "\n",
+			},
+		},
+		"json gzipped": {
+			args:   args{format: "json", acceptEncoding: "br, gzip"},
+			fields: fields{mockOperation: func(m *mock.MockphotoExporter) { m.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(export) }},
+			want: want{
+				code:               http.StatusOK,
+				contentType:        "application/json; charset=utf-8",
+				contentDisposition: ".json",
+				contentEncoding:    "gzip",
+				body: `[{"albumId":1,"id":1,"title":"one, two","url":"https://example.com/1","thumbnailUrl":"https://example.com/t/1"},` +
+					`{"albumId":1,"id":2,"title":"two","url":"https://example.com/2","thumbnailUrl":"https://example.com/t/2"}]`,
+			},
+		},
+		"json empty": {
+			args: args{format: "json", acceptEncoding: "gzip;q=0"},
+			fields: fields{mockOperation: func(m *mock.MockphotoExporter) {
+				m.EXPECT().Export(gomock.Any(), gomock.Any()).Return(nil)
+			}},
+			want: want{code: http.StatusOK, contentType: "application/json; charset=utf-8", contentDisposition: ".json", body: "[]"},
+		},
+		"invalid format": {
+			args:   args{format: "xml"},
+			fields: fields{mockOperation: func(*mock.MockphotoExporter) {}},
+			want:   want{code: http.StatusBadRequest, contentType: "application/json; charset=utf-8"},
+		},
+		"upstream error before the first page": {
+			args: args{format: "csv"},
+			fields: fields{mockOperation: func(m *mock.MockphotoExporter) {
+				m.EXPECT().Export(gomock.Any(), gomock.Any()).Return(assert.AnError)
+			}},
+			want: want{code: http.StatusInternalServerError, contentType: "application/json; charset=utf-8"},
+		},
+		"upstream error after the first page": {
+			args: args{format: "ndjson"},
+			fields: fields{mockOperation: func(m *mock.MockphotoExporter) {
+				m.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, fn func(p []photos.Photo) error) error {
+					if err := fn(pages[0]); err != nil {
+						return err
+					}
+
+					return assert.AnError
+				})
+			}},
+			want: want{
+				code:               http.StatusOK,
+				contentType:        "application/x-ndjson",
+				contentDisposition: ".ndjson",
+				body:               `{"albumId":1,"id":1,"title":"one, two","url":"https://example.com/1","thumbnailUrl":"https://example.com/t/1"}` + "\n",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := testutil.NewMock(t, mock.NewMockphotoExporter, tt.fields.mockOperation)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/photos/export?format="+tt.args.format, nil)
+			c.Request.Header.Set("Accept-Encoding", tt.args.acceptEncoding)
+
+			api.ExportPhotos(m, logger.NewNop())(c)
+
+			assert.Equal(t, tt.want.code, w.Code)
+			assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.want.contentEncoding, w.Header().Get("Content-Encoding"))
+
+			if tt.want.code != http.StatusOK {
+				return
+			}
+
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=photos-")
+			assert.Contains(t, w.Header().Get("Content-Disposition"), tt.want.contentDisposition)
+
+			body := w.Body.Bytes()
+			if tt.want.contentEncoding == "gzip" {
+				r, err := gzip.NewReader(bytes.NewReader(body))
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				if body, err = io.ReadAll(r); !assert.NoError(t, err) {
+					return
+				}
+			}
+
+			assert.Equal(t, tt.want.body, string(body))
+		})
+	}
+}
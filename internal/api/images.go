@@ -0,0 +1,151 @@
+package api
+
+//go:generate mockgen -source=images.go -destination=mocks/images_mock.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/storage"
+)
+
+const sniffLen = 512
+
+var errUnsupportedType = errors.New("content type not allowed")
+
+// imageTypes returns the media types accepted as photo images.
+func imageTypes() []string {
+	return []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+}
+
+type blobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// UploadPhotoImage returns a handler for uploading the image of a photo as the "image" multipart form field
+func UploadPhotoImage(cfg *config.Server, scfg *config.Storage, bs blobStore, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
+			return
+		}
+
+		if scfg.MaxImageSize > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(scfg.MaxImageSize))
+		}
+
+		fh, err := c.FormFile("image")
+		if err != nil {
+			respondError(c, l, formErrorStatus(err), "invalid image upload", apperror.Validation(fmt.Errorf("failed to read image: %w", err)))
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid image upload", apperror.Validation(fmt.Errorf("failed to open image: %w", err)))
+			return
+		}
+
+		defer f.Close()
+
+		contentType, err := sniffAllowedType(f, imageTypes())
+		if err != nil {
+			respondError(c, l, http.StatusUnsupportedMediaType, "unsupported image type", apperror.Validation(fmt.Errorf("unsupported image: %w", err)))
+			return
+		}
+
+		key := storage.PhotoImageKey(id)
+
+		if err := bs.Put(ctx, key, f, fh.Size, contentType); err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to store image", apperror.DB(fmt.Errorf("failed to store image: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"id": id, "contentType": contentType, "size": fh.Size})
+	}
+}
+
+// PhotoImage returns a handler redirecting to a presigned URL of the image of a photo
+func PhotoImage(cfg *config.Server, scfg *config.Storage, bs blobStore, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
+			return
+		}
+
+		u, err := bs.PresignGet(ctx, storage.PhotoImageKey(id), scfg.PresignTTL)
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to get image", apperror.DB(fmt.Errorf("failed to presign image url: %w", err)))
+			return
+		}
+
+		c.Redirect(http.StatusTemporaryRedirect, u)
+	}
+}
+
+// sniffContentType detects the content type of the content and rewinds the reader.
+func sniffContentType(f io.ReadSeeker) (string, error) {
+	buf := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read content: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind content: %w", err)
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// sniffAllowedType detects the content type and checks it against the allowed media types.
+func sniffAllowedType(f io.ReadSeeker, allowed []string) (string, error) {
+	contentType, err := sniffContentType(f)
+	if err != nil {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %s: %w", contentType, err)
+	}
+
+	for _, a := range allowed {
+		if a == mediaType {
+			return contentType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnsupportedType, contentType)
+}
+
+// formErrorStatus maps errors from reading a multipart form to a response status.
+func formErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,197 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+)
+
+// pngHeader is the signature of a PNG file, enough for content sniffing.
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func multipartBody(t *testing.T, field, content string) (*bytes.Buffer, string) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	fw, err := w.CreateFormFile(field, "image.png")
+	assert.NoError(t, err)
+
+	_, err = fw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return body, w.FormDataContentType()
+}
+
+func TestUploadPhotoImage(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		id      string
+		field   string
+		content string
+		maxSize config.ByteSize
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockblobStore)
+	}
+
+	type want struct {
+		code int
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{id: "1", field: "image", content: pngHeader + "data"},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().Put(gomock.Any(), "photos/1/image", gomock.Any(), int64(12), "image/png").Return(nil)
+				},
+			},
+			want: want{code: http.StatusCreated},
+		},
+		"invalid id": {
+			args:   args{id: "abc", field: "image", content: pngHeader},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusBadRequest},
+		},
+		"missing field": {
+			args:   args{id: "1", field: "file", content: pngHeader},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusBadRequest},
+		},
+		"not an image": {
+			args:   args{id: "1", field: "image", content: "just some text"},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusUnsupportedMediaType},
+		},
+		"too large": {
+			args:   args{id: "1", field: "image", content: pngHeader + "data", maxSize: 16},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusRequestEntityTooLarge},
+		},
+		"store error": {
+			args: args{id: "1", field: "image", content: pngHeader},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().Put(gomock.Any(), "photos/1/image", gomock.Any(), int64(8), "image/png").Return(assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockStore := testutil.NewMock(t, mock.NewMockblobStore, tt.fields.mockOperation)
+
+			router := gin.Default()
+			router.POST("/photos/:id/image", api.UploadPhotoImage(&config.Server{Timeout: 1 * time.Second}, &config.Storage{MaxImageSize: tt.args.maxSize}, mockStore, logger.NewNop()))
+
+			body, contentType := multipartBody(t, tt.args.field, tt.args.content)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/photos/"+tt.args.id+"/image", body)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", contentType)
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want.code, resp.Code)
+		})
+	}
+}
+
+func TestPhotoImage(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		id string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockblobStore)
+	}
+
+	type want struct {
+		code     int
+		location string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{id: "1"},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().PresignGet(gomock.Any(), "photos/1/image", time.Minute).Return("http://blobs/photos/1/image?sig=x", nil)
+				},
+			},
+			want: want{code: http.StatusTemporaryRedirect, location: "http://blobs/photos/1/image?sig=x"},
+		},
+		"invalid id": {
+			args:   args{id: "abc"},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusBadRequest},
+		},
+		"presign error": {
+			args: args{id: "1"},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().PresignGet(gomock.Any(), "photos/1/image", time.Minute).Return("", assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockStore := testutil.NewMock(t, mock.NewMockblobStore, tt.fields.mockOperation)
+
+			router := gin.Default()
+			router.GET("/photos/:id/image", api.PhotoImage(&config.Server{Timeout: 1 * time.Second}, &config.Storage{PresignTTL: time.Minute}, mockStore, logger.NewNop()))
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/photos/"+tt.args.id+"/image", http.NoBody)
+			assert.NoError(t, err)
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.location, resp.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+//go:generate mockgen -source=imports.go -destination=mocks/imports_mock.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/imports"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// errUnsupportedImport is returned for import files which are neither CSV nor NDJSON.
+var errUnsupportedImport = errors.New("unsupported import format")
+
+type importer interface {
+	Start(ctx context.Context, f imports.Format, r io.Reader) (*imports.Job, error)
+	Get(ctx context.Context, id string) (*imports.Job, error)
+}
+
+// ImportPhotos returns a handler enqueuing the import of the photos of the "file" multipart form field, a CSV or NDJSON
+// file recognized by its content type or extension. It answers 202 with the queued job, whose progress is at the
+// Location of the response.
+func ImportPhotos(icfg *config.Import, im importer, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if icfg.MaxSize > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(icfg.MaxSize))
+		}
+
+		fh, err := c.FormFile("file")
+		if err != nil {
+			respondError(c, l, formErrorStatus(err), "invalid import file", apperror.Validation(fmt.Errorf("failed to read import file: %w", err)))
+			return
+		}
+
+		format, ok := imports.FormatOf(fh.Header.Get("Content-Type"), fh.Filename)
+		if !ok {
+			respondError(c, l, http.StatusUnsupportedMediaType, "import file must be csv or ndjson", apperror.Validation(fmt.Errorf("%w: %s", errUnsupportedImport, fh.Filename)))
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid import file", apperror.Validation(fmt.Errorf("failed to open import file: %w", err)))
+			return
+		}
+
+		defer f.Close()
+
+		j, err := im.Start(c.Request.Context(), format, f)
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to start import", apperror.Internal(fmt.Errorf("failed to start import: %w", err)))
+			return
+		}
+
+		c.Header("Location", "/photos/import/"+j.ID)
+		c.JSON(http.StatusAccepted, j)
+	}
+}
+
+// ImportStatus returns a handler for the progress and status of an import job.
+func ImportStatus(im importer, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		j, err := im.Get(c.Request.Context(), c.Param("jobID"))
+		if errors.Is(err, imports.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "import job not found"})
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to get import job", apperror.Internal(fmt.Errorf("failed to get import job: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusOK, j)
+	}
+}
@@ -0,0 +1,167 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/imports"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+)
+
+func TestImportPhotos(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		fileName string
+		content  string
+		maxSize  config.ByteSize
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.Mockimporter)
+	}
+
+	type want struct {
+		code     int
+		location string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"csv": {
+			args: args{fileName: "photos.csv", content: "albumId,title,url,thumbnailUrl\n"},
+			fields: fields{
+				mockOperation: func(m *mock.Mockimporter) {
+					m.EXPECT().Start(gomock.Any(), imports.FormatCSV, gomock.Any()).Return(&imports.Job{ID: "abc", Status: imports.StatusQueued}, nil)
+				},
+			},
+			want: want{code: http.StatusAccepted, location: "/photos/import/abc"},
+		},
+		"ndjson": {
+			args: args{fileName: "photos.ndjson", content: "{}\n"},
+			fields: fields{
+				mockOperation: func(m *mock.Mockimporter) {
+					m.EXPECT().Start(gomock.Any(), imports.FormatNDJSON, gomock.Any()).Return(&imports.Job{ID: "def", Status: imports.StatusQueued}, nil)
+				},
+			},
+			want: want{code: http.StatusAccepted, location: "/photos/import/def"},
+		},
+		"unsupported format": {
+			args:   args{fileName: "photos.xlsx", content: "PK"},
+			fields: fields{mockOperation: func(*mock.Mockimporter) {}},
+			want:   want{code: http.StatusUnsupportedMediaType},
+		},
+		"too large": {
+			args:   args{fileName: "photos.csv", content: "albumId,title,url,thumbnailUrl\n", maxSize: 32},
+			fields: fields{mockOperation: func(*mock.Mockimporter) {}},
+			want:   want{code: http.StatusRequestEntityTooLarge},
+		},
+		"start error": {
+			args: args{fileName: "photos.csv", content: "albumId,title,url,thumbnailUrl\n"},
+			fields: fields{
+				mockOperation: func(m *mock.Mockimporter) {
+					m.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockImporter := testutil.NewMock(t, mock.NewMockimporter, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.POST("/photos/import", api.ImportPhotos(&config.Import{MaxSize: tt.args.maxSize}, mockImporter, logger.NewNop()))
+
+			body := &bytes.Buffer{}
+			w := multipart.NewWriter(body)
+			fw, err := w.CreateFormFile("file", tt.args.fileName)
+			assert.NoError(t, err)
+			_, err = fw.Write([]byte(tt.args.content))
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/photos/import", body)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.location, resp.Header().Get("Location"))
+		})
+	}
+}
+
+func TestImportStatus(t *testing.T) {
+	t.Parallel()
+
+	type fields struct {
+		mockOperation func(m *mock.Mockimporter)
+	}
+
+	type want struct {
+		code int
+	}
+
+	tests := map[string]struct {
+		fields fields
+		want   want
+	}{
+		"found": {
+			fields: fields{
+				mockOperation: func(m *mock.Mockimporter) {
+					m.EXPECT().Get(gomock.Any(), "abc").Return(&imports.Job{ID: "abc", Status: imports.StatusRunning}, nil)
+				},
+			},
+			want: want{code: http.StatusOK},
+		},
+		"not found": {
+			fields: fields{
+				mockOperation: func(m *mock.Mockimporter) {
+					m.EXPECT().Get(gomock.Any(), "abc").Return(nil, imports.ErrJobNotFound)
+				},
+			},
+			want: want{code: http.StatusNotFound},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockImporter := testutil.NewMock(t, mock.NewMockimporter, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.GET("/photos/import/:jobID", api.ImportStatus(mockImporter, logger.NewNop()))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/photos/import/abc", http.NoBody))
+
+			assert.Equal(t, tt.want.code, resp.Code)
+		})
+	}
+}
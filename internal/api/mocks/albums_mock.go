@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/albums.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/albums.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	reflect "reflect"
+
+	photos "github.com/twk/skeleton-go-api/internal/photos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockalbumService is a mock of albumService interface.
+type MockalbumService struct {
+	ctrl     *gomock.Controller
+	recorder *MockalbumServiceMockRecorder
+}
+
+// MockalbumServiceMockRecorder is the mock recorder for MockalbumService.
+type MockalbumServiceMockRecorder struct {
+	mock *MockalbumService
+}
+
+// NewMockalbumService creates a new mock instance.
+func NewMockalbumService(ctrl *gomock.Controller) *MockalbumService {
+	mock := &MockalbumService{ctrl: ctrl}
+	mock.recorder = &MockalbumServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockalbumService) EXPECT() *MockalbumServiceMockRecorder {
+	return m.recorder
+}
+
+// ListAllByAlbum mocks base method.
+func (m *MockalbumService) ListAllByAlbum(ctx context.Context, albumID int) ([]photos.Photo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllByAlbum", ctx, albumID)
+	ret0, _ := ret[0].([]photos.Photo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllByAlbum indicates an expected call of ListAllByAlbum.
+func (mr *MockalbumServiceMockRecorder) ListAllByAlbum(ctx, albumID any) *MockalbumServiceListAllByAlbumCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllByAlbum", reflect.TypeOf((*MockalbumService)(nil).ListAllByAlbum), ctx, albumID)
+	return &MockalbumServiceListAllByAlbumCall{Call: call}
+}
+
+// MockalbumServiceListAllByAlbumCall wrap *gomock.Call
+type MockalbumServiceListAllByAlbumCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockalbumServiceListAllByAlbumCall) Return(arg0 []photos.Photo, arg1 error) *MockalbumServiceListAllByAlbumCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockalbumServiceListAllByAlbumCall) Do(f func(context.Context, int) ([]photos.Photo, error)) *MockalbumServiceListAllByAlbumCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockalbumServiceListAllByAlbumCall) DoAndReturn(f func(context.Context, int) ([]photos.Photo, error)) *MockalbumServiceListAllByAlbumCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
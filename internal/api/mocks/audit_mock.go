@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/audit.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/audit.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	reflect "reflect"
+
+	audit "github.com/twk/skeleton-go-api/internal/audit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockauditQuerier is a mock of auditQuerier interface.
+type MockauditQuerier struct {
+	ctrl     *gomock.Controller
+	recorder *MockauditQuerierMockRecorder
+}
+
+// MockauditQuerierMockRecorder is the mock recorder for MockauditQuerier.
+type MockauditQuerierMockRecorder struct {
+	mock *MockauditQuerier
+}
+
+// NewMockauditQuerier creates a new mock instance.
+func NewMockauditQuerier(ctrl *gomock.Controller) *MockauditQuerier {
+	mock := &MockauditQuerier{ctrl: ctrl}
+	mock.recorder = &MockauditQuerierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockauditQuerier) EXPECT() *MockauditQuerierMockRecorder {
+	return m.recorder
+}
+
+// Query mocks base method.
+func (m *MockauditQuerier) Query(ctx context.Context, f audit.Filter) (*audit.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, f)
+	ret0, _ := ret[0].(*audit.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockauditQuerierMockRecorder) Query(ctx, f any) *MockauditQuerierQueryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockauditQuerier)(nil).Query), ctx, f)
+	return &MockauditQuerierQueryCall{Call: call}
+}
+
+// MockauditQuerierQueryCall wrap *gomock.Call
+type MockauditQuerierQueryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockauditQuerierQueryCall) Return(arg0 *audit.Page, arg1 error) *MockauditQuerierQueryCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockauditQuerierQueryCall) Do(f func(context.Context, audit.Filter) (*audit.Page, error)) *MockauditQuerierQueryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockauditQuerierQueryCall) DoAndReturn(f func(context.Context, audit.Filter) (*audit.Page, error)) *MockauditQuerierQueryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
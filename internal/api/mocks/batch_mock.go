@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/batch.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/batch.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	reflect "reflect"
+
+	photos "github.com/twk/skeleton-go-api/internal/photos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockbatchPhotoService is a mock of batchPhotoService interface.
+type MockbatchPhotoService struct {
+	ctrl     *gomock.Controller
+	recorder *MockbatchPhotoServiceMockRecorder
+}
+
+// MockbatchPhotoServiceMockRecorder is the mock recorder for MockbatchPhotoService.
+type MockbatchPhotoServiceMockRecorder struct {
+	mock *MockbatchPhotoService
+}
+
+// NewMockbatchPhotoService creates a new mock instance.
+func NewMockbatchPhotoService(ctrl *gomock.Controller) *MockbatchPhotoService {
+	mock := &MockbatchPhotoService{ctrl: ctrl}
+	mock.recorder = &MockbatchPhotoServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbatchPhotoService) EXPECT() *MockbatchPhotoServiceMockRecorder {
+	return m.recorder
+}
+
+// GetPhotosBatch mocks base method.
+func (m *MockbatchPhotoService) GetPhotosBatch(ctx context.Context, ids []int) ([]photos.Photo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPhotosBatch", ctx, ids)
+	ret0, _ := ret[0].([]photos.Photo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPhotosBatch indicates an expected call of GetPhotosBatch.
+func (mr *MockbatchPhotoServiceMockRecorder) GetPhotosBatch(ctx, ids any) *MockbatchPhotoServiceGetPhotosBatchCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhotosBatch", reflect.TypeOf((*MockbatchPhotoService)(nil).GetPhotosBatch), ctx, ids)
+	return &MockbatchPhotoServiceGetPhotosBatchCall{Call: call}
+}
+
+// MockbatchPhotoServiceGetPhotosBatchCall wrap *gomock.Call
+type MockbatchPhotoServiceGetPhotosBatchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockbatchPhotoServiceGetPhotosBatchCall) Return(arg0 []photos.Photo, arg1 error) *MockbatchPhotoServiceGetPhotosBatchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockbatchPhotoServiceGetPhotosBatchCall) Do(f func(context.Context, []int) ([]photos.Photo, error)) *MockbatchPhotoServiceGetPhotosBatchCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockbatchPhotoServiceGetPhotosBatchCall) DoAndReturn(f func(context.Context, []int) ([]photos.Photo, error)) *MockbatchPhotoServiceGetPhotosBatchCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
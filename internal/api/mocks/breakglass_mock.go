@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/breakglass.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/breakglass.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	reflect "reflect"
+	time "time"
+
+	auth "github.com/twk/skeleton-go-api/internal/auth"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockbreakGlassIssuer is a mock of breakGlassIssuer interface.
+type MockbreakGlassIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MockbreakGlassIssuerMockRecorder
+}
+
+// MockbreakGlassIssuerMockRecorder is the mock recorder for MockbreakGlassIssuer.
+type MockbreakGlassIssuerMockRecorder struct {
+	mock *MockbreakGlassIssuer
+}
+
+// NewMockbreakGlassIssuer creates a new mock instance.
+func NewMockbreakGlassIssuer(ctrl *gomock.Controller) *MockbreakGlassIssuer {
+	mock := &MockbreakGlassIssuer{ctrl: ctrl}
+	mock.recorder = &MockbreakGlassIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbreakGlassIssuer) EXPECT() *MockbreakGlassIssuerMockRecorder {
+	return m.recorder
+}
+
+// Issue mocks base method.
+func (m *MockbreakGlassIssuer) Issue(g auth.BreakGlassGrant, ttl time.Duration) (string, *auth.BreakGlassGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", g, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*auth.BreakGlassGrant)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockbreakGlassIssuerMockRecorder) Issue(g, ttl any) *MockbreakGlassIssuerIssueCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockbreakGlassIssuer)(nil).Issue), g, ttl)
+	return &MockbreakGlassIssuerIssueCall{Call: call}
+}
+
+// MockbreakGlassIssuerIssueCall wrap *gomock.Call
+type MockbreakGlassIssuerIssueCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockbreakGlassIssuerIssueCall) Return(arg0 string, arg1 *auth.BreakGlassGrant, arg2 error) *MockbreakGlassIssuerIssueCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockbreakGlassIssuerIssueCall) Do(f func(auth.BreakGlassGrant, time.Duration) (string, *auth.BreakGlassGrant, error)) *MockbreakGlassIssuerIssueCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockbreakGlassIssuerIssueCall) DoAndReturn(f func(auth.BreakGlassGrant, time.Duration) (string, *auth.BreakGlassGrant, error)) *MockbreakGlassIssuerIssueCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
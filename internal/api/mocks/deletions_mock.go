@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/deletions.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/deletions.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockphotoDeleter is a mock of photoDeleter interface.
+type MockphotoDeleter struct {
+	ctrl     *gomock.Controller
+	recorder *MockphotoDeleterMockRecorder
+}
+
+// MockphotoDeleterMockRecorder is the mock recorder for MockphotoDeleter.
+type MockphotoDeleterMockRecorder struct {
+	mock *MockphotoDeleter
+}
+
+// NewMockphotoDeleter creates a new mock instance.
+func NewMockphotoDeleter(ctrl *gomock.Controller) *MockphotoDeleter {
+	mock := &MockphotoDeleter{ctrl: ctrl}
+	mock.recorder = &MockphotoDeleterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockphotoDeleter) EXPECT() *MockphotoDeleterMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockphotoDeleter) Delete(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockphotoDeleterMockRecorder) Delete(ctx, id any) *MockphotoDeleterDeleteCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockphotoDeleter)(nil).Delete), ctx, id)
+	return &MockphotoDeleterDeleteCall{Call: call}
+}
+
+// MockphotoDeleterDeleteCall wrap *gomock.Call
+type MockphotoDeleterDeleteCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockphotoDeleterDeleteCall) Return(arg0 error) *MockphotoDeleterDeleteCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockphotoDeleterDeleteCall) Do(f func(context.Context, int) error) *MockphotoDeleterDeleteCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockphotoDeleterDeleteCall) DoAndReturn(f func(context.Context, int) error) *MockphotoDeleterDeleteCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Restore mocks base method.
+func (m *MockphotoDeleter) Restore(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockphotoDeleterMockRecorder) Restore(ctx, id any) *MockphotoDeleterRestoreCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockphotoDeleter)(nil).Restore), ctx, id)
+	return &MockphotoDeleterRestoreCall{Call: call}
+}
+
+// MockphotoDeleterRestoreCall wrap *gomock.Call
+type MockphotoDeleterRestoreCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockphotoDeleterRestoreCall) Return(arg0 error) *MockphotoDeleterRestoreCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockphotoDeleterRestoreCall) Do(f func(context.Context, int) error) *MockphotoDeleterRestoreCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockphotoDeleterRestoreCall) DoAndReturn(f func(context.Context, int) error) *MockphotoDeleterRestoreCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
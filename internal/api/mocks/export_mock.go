@@ -0,0 +1,178 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/export.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/export.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	reflect "reflect"
+
+	photos "github.com/twk/skeleton-go-api/internal/photos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockphotoExporter is a mock of photoExporter interface.
+type MockphotoExporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockphotoExporterMockRecorder
+}
+
+// MockphotoExporterMockRecorder is the mock recorder for MockphotoExporter.
+type MockphotoExporterMockRecorder struct {
+	mock *MockphotoExporter
+}
+
+// NewMockphotoExporter creates a new mock instance.
+func NewMockphotoExporter(ctrl *gomock.Controller) *MockphotoExporter {
+	mock := &MockphotoExporter{ctrl: ctrl}
+	mock.recorder = &MockphotoExporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockphotoExporter) EXPECT() *MockphotoExporterMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockphotoExporter) Export(ctx context.Context, fn func([]photos.Photo) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockphotoExporterMockRecorder) Export(ctx, fn any) *MockphotoExporterExportCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockphotoExporter)(nil).Export), ctx, fn)
+	return &MockphotoExporterExportCall{Call: call}
+}
+
+// MockphotoExporterExportCall wrap *gomock.Call
+type MockphotoExporterExportCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockphotoExporterExportCall) Return(arg0 error) *MockphotoExporterExportCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockphotoExporterExportCall) Do(f func(context.Context, func([]photos.Photo) error) error) *MockphotoExporterExportCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockphotoExporterExportCall) DoAndReturn(f func(context.Context, func([]photos.Photo) error) error) *MockphotoExporterExportCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockexportEncoder is a mock of exportEncoder interface.
+type MockexportEncoder struct {
+	ctrl     *gomock.Controller
+	recorder *MockexportEncoderMockRecorder
+}
+
+// MockexportEncoderMockRecorder is the mock recorder for MockexportEncoder.
+type MockexportEncoderMockRecorder struct {
+	mock *MockexportEncoder
+}
+
+// NewMockexportEncoder creates a new mock instance.
+func NewMockexportEncoder(ctrl *gomock.Controller) *MockexportEncoder {
+	mock := &MockexportEncoder{ctrl: ctrl}
+	mock.recorder = &MockexportEncoderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockexportEncoder) EXPECT() *MockexportEncoderMockRecorder {
+	return m.recorder
+}
+
+// close mocks base method.
+func (m *MockexportEncoder) close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// close indicates an expected call of close.
+func (mr *MockexportEncoderMockRecorder) close() *MockexportEncodercloseCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "close", reflect.TypeOf((*MockexportEncoder)(nil).close))
+	return &MockexportEncodercloseCall{Call: call}
+}
+
+// MockexportEncodercloseCall wrap *gomock.Call
+type MockexportEncodercloseCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockexportEncodercloseCall) Return(arg0 error) *MockexportEncodercloseCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockexportEncodercloseCall) Do(f func() error) *MockexportEncodercloseCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockexportEncodercloseCall) DoAndReturn(f func() error) *MockexportEncodercloseCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// encode mocks base method.
+func (m *MockexportEncoder) encode(p []photos.Photo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "encode", p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// encode indicates an expected call of encode.
+func (mr *MockexportEncoderMockRecorder) encode(p any) *MockexportEncoderencodeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "encode", reflect.TypeOf((*MockexportEncoder)(nil).encode), p)
+	return &MockexportEncoderencodeCall{Call: call}
+}
+
+// MockexportEncoderencodeCall wrap *gomock.Call
+type MockexportEncoderencodeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockexportEncoderencodeCall) Return(arg0 error) *MockexportEncoderencodeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockexportEncoderencodeCall) Do(f func([]photos.Photo) error) *MockexportEncoderencodeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockexportEncoderencodeCall) DoAndReturn(f func([]photos.Photo) error) *MockexportEncoderencodeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,119 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/images.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/images.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockblobStore is a mock of blobStore interface.
+type MockblobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockblobStoreMockRecorder
+}
+
+// MockblobStoreMockRecorder is the mock recorder for MockblobStore.
+type MockblobStoreMockRecorder struct {
+	mock *MockblobStore
+}
+
+// NewMockblobStore creates a new mock instance.
+func NewMockblobStore(ctrl *gomock.Controller) *MockblobStore {
+	mock := &MockblobStore{ctrl: ctrl}
+	mock.recorder = &MockblobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockblobStore) EXPECT() *MockblobStoreMockRecorder {
+	return m.recorder
+}
+
+// PresignGet mocks base method.
+func (m *MockblobStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PresignGet", ctx, key, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PresignGet indicates an expected call of PresignGet.
+func (mr *MockblobStoreMockRecorder) PresignGet(ctx, key, ttl any) *MockblobStorePresignGetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PresignGet", reflect.TypeOf((*MockblobStore)(nil).PresignGet), ctx, key, ttl)
+	return &MockblobStorePresignGetCall{Call: call}
+}
+
+// MockblobStorePresignGetCall wrap *gomock.Call
+type MockblobStorePresignGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockblobStorePresignGetCall) Return(arg0 string, arg1 error) *MockblobStorePresignGetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockblobStorePresignGetCall) Do(f func(context.Context, string, time.Duration) (string, error)) *MockblobStorePresignGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockblobStorePresignGetCall) DoAndReturn(f func(context.Context, string, time.Duration) (string, error)) *MockblobStorePresignGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Put mocks base method.
+func (m *MockblobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, r, size, contentType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockblobStoreMockRecorder) Put(ctx, key, r, size, contentType any) *MockblobStorePutCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockblobStore)(nil).Put), ctx, key, r, size, contentType)
+	return &MockblobStorePutCall{Call: call}
+}
+
+// MockblobStorePutCall wrap *gomock.Call
+type MockblobStorePutCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockblobStorePutCall) Return(arg0 error) *MockblobStorePutCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockblobStorePutCall) Do(f func(context.Context, string, io.Reader, int64, string) error) *MockblobStorePutCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockblobStorePutCall) DoAndReturn(f func(context.Context, string, io.Reader, int64, string) error) *MockblobStorePutCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,120 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/imports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/imports.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	imports "github.com/twk/skeleton-go-api/internal/imports"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// Mockimporter is a mock of importer interface.
+type Mockimporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockimporterMockRecorder
+}
+
+// MockimporterMockRecorder is the mock recorder for Mockimporter.
+type MockimporterMockRecorder struct {
+	mock *Mockimporter
+}
+
+// NewMockimporter creates a new mock instance.
+func NewMockimporter(ctrl *gomock.Controller) *Mockimporter {
+	mock := &Mockimporter{ctrl: ctrl}
+	mock.recorder = &MockimporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockimporter) EXPECT() *MockimporterMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *Mockimporter) Get(ctx context.Context, id string) (*imports.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*imports.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockimporterMockRecorder) Get(ctx, id any) *MockimporterGetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockimporter)(nil).Get), ctx, id)
+	return &MockimporterGetCall{Call: call}
+}
+
+// MockimporterGetCall wrap *gomock.Call
+type MockimporterGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockimporterGetCall) Return(arg0 *imports.Job, arg1 error) *MockimporterGetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockimporterGetCall) Do(f func(context.Context, string) (*imports.Job, error)) *MockimporterGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockimporterGetCall) DoAndReturn(f func(context.Context, string) (*imports.Job, error)) *MockimporterGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Start mocks base method.
+func (m *Mockimporter) Start(ctx context.Context, f imports.Format, r io.Reader) (*imports.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start", ctx, f, r)
+	ret0, _ := ret[0].(*imports.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockimporterMockRecorder) Start(ctx, f, r any) *MockimporterStartCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*Mockimporter)(nil).Start), ctx, f, r)
+	return &MockimporterStartCall{Call: call}
+}
+
+// MockimporterStartCall wrap *gomock.Call
+type MockimporterStartCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockimporterStartCall) Return(arg0 *imports.Job, arg1 error) *MockimporterStartCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockimporterStartCall) Do(f func(context.Context, imports.Format, io.Reader) (*imports.Job, error)) *MockimporterStartCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockimporterStartCall) DoAndReturn(f func(context.Context, imports.Format, io.Reader) (*imports.Job, error)) *MockimporterStartCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -1,5 +1,10 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: ./internal/api/photos.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/photos.go -package=mock_api -typed
+//
 
 // Package mock_api is a generated GoMock package.
 package mock_api
@@ -8,8 +13,8 @@ import (
 	context "context"
 	reflect "reflect"
 
-	gomock "github.com/golang/mock/gomock"
 	photos "github.com/twk/skeleton-go-api/internal/photos"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockphotoService is a mock of photoService interface.
@@ -45,7 +50,31 @@ func (m *MockphotoService) GetPhotos(ctx context.Context, albumID int) (*photos.
 }
 
 // GetPhotos indicates an expected call of GetPhotos.
-func (mr *MockphotoServiceMockRecorder) GetPhotos(ctx, albumID interface{}) *gomock.Call {
+func (mr *MockphotoServiceMockRecorder) GetPhotos(ctx, albumID any) *MockphotoServiceGetPhotosCall {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhotos", reflect.TypeOf((*MockphotoService)(nil).GetPhotos), ctx, albumID)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhotos", reflect.TypeOf((*MockphotoService)(nil).GetPhotos), ctx, albumID)
+	return &MockphotoServiceGetPhotosCall{Call: call}
+}
+
+// MockphotoServiceGetPhotosCall wrap *gomock.Call
+type MockphotoServiceGetPhotosCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockphotoServiceGetPhotosCall) Return(arg0 *photos.Photo, arg1 error) *MockphotoServiceGetPhotosCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockphotoServiceGetPhotosCall) Do(f func(context.Context, int) (*photos.Photo, error)) *MockphotoServiceGetPhotosCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockphotoServiceGetPhotosCall) DoAndReturn(f func(context.Context, int) (*photos.Photo, error)) *MockphotoServiceGetPhotosCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
 }
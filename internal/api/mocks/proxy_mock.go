@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/proxy.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/proxy.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+
+	client "github.com/twk/skeleton-go-api/internal/client"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// Mockstreamer is a mock of streamer interface.
+type Mockstreamer struct {
+	ctrl     *gomock.Controller
+	recorder *MockstreamerMockRecorder
+}
+
+// MockstreamerMockRecorder is the mock recorder for Mockstreamer.
+type MockstreamerMockRecorder struct {
+	mock *Mockstreamer
+}
+
+// NewMockstreamer creates a new mock instance.
+func NewMockstreamer(ctrl *gomock.Controller) *Mockstreamer {
+	mock := &Mockstreamer{ctrl: ctrl}
+	mock.recorder = &MockstreamerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockstreamer) EXPECT() *MockstreamerMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *Mockstreamer) Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, url}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].(*http.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockstreamerMockRecorder) Get(ctx, url any, opts ...any) *MockstreamerGetCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, url}, opts...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockstreamer)(nil).Get), varargs...)
+	return &MockstreamerGetCall{Call: call}
+}
+
+// MockstreamerGetCall wrap *gomock.Call
+type MockstreamerGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockstreamerGetCall) Return(arg0 *http.Response, arg1 error) *MockstreamerGetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockstreamerGetCall) Do(f func(context.Context, string, ...client.RequestOption) (*http.Response, error)) *MockstreamerGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockstreamerGetCall) DoAndReturn(f func(context.Context, string, ...client.RequestOption) (*http.Response, error)) *MockstreamerGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/search.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/search.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	context "context"
+	reflect "reflect"
+
+	search "github.com/twk/skeleton-go-api/internal/search"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockphotoSearcher is a mock of photoSearcher interface.
+type MockphotoSearcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockphotoSearcherMockRecorder
+}
+
+// MockphotoSearcherMockRecorder is the mock recorder for MockphotoSearcher.
+type MockphotoSearcherMockRecorder struct {
+	mock *MockphotoSearcher
+}
+
+// NewMockphotoSearcher creates a new mock instance.
+func NewMockphotoSearcher(ctrl *gomock.Controller) *MockphotoSearcher {
+	mock := &MockphotoSearcher{ctrl: ctrl}
+	mock.recorder = &MockphotoSearcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockphotoSearcher) EXPECT() *MockphotoSearcherMockRecorder {
+	return m.recorder
+}
+
+// Search mocks base method.
+func (m *MockphotoSearcher) Search(ctx context.Context, q search.Query) (*search.Results, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, q)
+	ret0, _ := ret[0].(*search.Results)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockphotoSearcherMockRecorder) Search(ctx, q any) *MockphotoSearcherSearchCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockphotoSearcher)(nil).Search), ctx, q)
+	return &MockphotoSearcherSearchCall{Call: call}
+}
+
+// MockphotoSearcherSearchCall wrap *gomock.Call
+type MockphotoSearcherSearchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockphotoSearcherSearchCall) Return(arg0 *search.Results, arg1 error) *MockphotoSearcherSearchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockphotoSearcherSearchCall) Do(f func(context.Context, search.Query) (*search.Results, error)) *MockphotoSearcherSearchCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockphotoSearcherSearchCall) DoAndReturn(f func(context.Context, search.Query) (*search.Results, error)) *MockphotoSearcherSearchCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/api/token.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/api/token.go -package=mock_api -typed
+//
+
+// Package mock_api is a generated GoMock package.
+package mock_api
+
+import (
+	reflect "reflect"
+
+	token "github.com/twk/skeleton-go-api/internal/token"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MocktokenIssuer is a mock of tokenIssuer interface.
+type MocktokenIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MocktokenIssuerMockRecorder
+}
+
+// MocktokenIssuerMockRecorder is the mock recorder for MocktokenIssuer.
+type MocktokenIssuerMockRecorder struct {
+	mock *MocktokenIssuer
+}
+
+// NewMocktokenIssuer creates a new mock instance.
+func NewMocktokenIssuer(ctrl *gomock.Controller) *MocktokenIssuer {
+	mock := &MocktokenIssuer{ctrl: ctrl}
+	mock.recorder = &MocktokenIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktokenIssuer) EXPECT() *MocktokenIssuerMockRecorder {
+	return m.recorder
+}
+
+// Exchange mocks base method.
+func (m *MocktokenIssuer) Exchange(creds token.Credentials, scopes []string) (*token.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", creds, scopes)
+	ret0, _ := ret[0].(*token.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MocktokenIssuerMockRecorder) Exchange(creds, scopes any) *MocktokenIssuerExchangeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MocktokenIssuer)(nil).Exchange), creds, scopes)
+	return &MocktokenIssuerExchangeCall{Call: call}
+}
+
+// MocktokenIssuerExchangeCall wrap *gomock.Call
+type MocktokenIssuerExchangeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocktokenIssuerExchangeCall) Return(arg0 *token.Token, arg1 error) *MocktokenIssuerExchangeCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocktokenIssuerExchangeCall) Do(f func(token.Credentials, []string) (*token.Token, error)) *MocktokenIssuerExchangeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocktokenIssuerExchangeCall) DoAndReturn(f func(token.Credentials, []string) (*token.Token, error)) *MocktokenIssuerExchangeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+type operatorResponse struct {
+	Subject string   `json:"subject"`
+	Email   string   `json:"email,omitempty"`
+	Groups  []string `json:"groups"`
+	Method  string   `json:"method"`
+}
+
+// Operator returns a handler describing the authenticated operator, the landing page of the admin routes.
+func Operator(l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id := auth.IdentityFromContext(c.Request.Context())
+		if id == nil {
+			respondError(c, l, http.StatusUnauthorized, "unauthorized", apperror.Auth(auth.ErrNoCredentials))
+			return
+		}
+
+		c.JSON(http.StatusOK, operatorResponse{Subject: id.Subject, Email: id.Email, Groups: id.Groups, Method: id.Method})
+	}
+}
@@ -0,0 +1,56 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func TestOperatorHandler(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		id   *auth.Identity
+		want want
+	}{
+		"operator": {
+			id:   &auth.Identity{Subject: "alice", Email: "alice@example.com", Groups: []string{"admins"}, Method: "session"},
+			want: want{code: http.StatusOK, body: `{"subject":"alice","email":"alice@example.com","groups":["admins"],"method":"session"}`},
+		},
+		"anonymous": {
+			want: want{code: http.StatusUnauthorized, body: `{"error":"unauthorized"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.GET("/admin", func(c *gin.Context) {
+				if tt.id != nil {
+					c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), tt.id))
+				}
+			}, api.Operator(logger.NewNop()))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/admin", http.NoBody))
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
@@ -1,14 +1,20 @@
 // Package api provides the handlers for the API endpoints.
 package api
 
+//go:generate mockgen -source=photos.go -destination=mocks/photos_mock.go
+
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
 
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/client"
 	"github.com/twk/skeleton-go-api/internal/config"
 	"github.com/twk/skeleton-go-api/internal/logger"
 	"github.com/twk/skeleton-go-api/internal/photos"
@@ -26,20 +32,47 @@ func Photos(cfg *config.Server, ps photoService, l *logger.Logger) func(c *gin.C
 
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			l.Error("failed to parse id", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
-
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
 			return
 		}
 
 		p, err := ps.GetPhotos(ctx, id)
-		if err != nil {
-			l.Error("failed to get photos", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get photos"})
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "photo not found"})
+			return
+		}
 
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to get photos", apperror.Upstream(fmt.Errorf("failed to get photos: %w", err)))
 			return
 		}
 
+		setStaleness(c, *p)
 		c.JSON(http.StatusOK, p)
 	}
 }
+
+// setStaleness marks the response stale when photos of p are last known ones served while the upstream fails, with
+// the Warning header of RFC 7234 and the Age of the oldest of them.
+func setStaleness(c *gin.Context, p ...photos.Photo) {
+	var oldest time.Time
+
+	for _, photo := range p {
+		if !photo.LastFetched.IsZero() && (oldest.IsZero() || photo.LastFetched.Before(oldest)) {
+			oldest = photo.LastFetched
+		}
+	}
+
+	if oldest.IsZero() {
+		return
+	}
+
+	c.Header("Warning", `110 - "Response is Stale"`)
+	c.Header("Age", strconv.Itoa(int(time.Since(oldest).Seconds())))
+}
+
+// isNotFound reports whether err is caused by the upstream responding 404 or by the photo being soft-deleted.
+func isNotFound(err error) bool {
+	var httpErr *client.HTTPError
+	return errors.Is(err, photos.ErrDeleted) || (errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound)
+}
@@ -2,19 +2,22 @@ package api_test
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/twk/skeleton-go-api/internal/api"
 	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/client"
 	"github.com/twk/skeleton-go-api/internal/config"
 	"github.com/twk/skeleton-go-api/internal/logger"
 	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
 )
 
 func TestPhotosHandler(t *testing.T) {
@@ -30,7 +33,9 @@ func TestPhotosHandler(t *testing.T) {
 	}
 
 	type want struct {
-		code int
+		code    int
+		warning string
+		age     string
 	}
 
 	tests := map[string]struct {
@@ -52,6 +57,22 @@ func TestPhotosHandler(t *testing.T) {
 				code: http.StatusOK,
 			},
 		},
+		"stale": {
+			args: args{
+				cfg: &config.Server{Timeout: 1 * time.Second},
+				id:  "1",
+			},
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoService) {
+					m.EXPECT().GetPhotos(gomock.Any(), 1).Return(&photos.Photo{ID: 1, LastFetched: time.Now().Add(-time.Minute)}, nil)
+				},
+			},
+			want: want{
+				code:    http.StatusOK,
+				warning: `110 - "Response is Stale"`,
+				age:     "60",
+			},
+		},
 		"invalid id": {
 			args: args{
 				cfg: &config.Server{Timeout: 1 * time.Second},
@@ -66,6 +87,20 @@ func TestPhotosHandler(t *testing.T) {
 				code: http.StatusBadRequest,
 			},
 		},
+		"upstream not found": {
+			args: args{
+				cfg: &config.Server{Timeout: 1 * time.Second},
+				id:  "1",
+			},
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoService) {
+					m.EXPECT().GetPhotos(gomock.Any(), 1).Return(nil, fmt.Errorf("failed to get photos: %w", &client.HTTPError{StatusCode: http.StatusNotFound}))
+				},
+			},
+			want: want{
+				code: http.StatusNotFound,
+			},
+		},
 		"service error": {
 			args: args{
 				cfg: &config.Server{Timeout: 1 * time.Second},
@@ -88,11 +123,7 @@ func TestPhotosHandler(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
-
-			mockService := mock.NewMockphotoService(ctrl)
-			tt.fields.mockOperation(mockService)
+			mockService := testutil.NewMock(t, mock.NewMockphotoService, tt.fields.mockOperation)
 
 			router := gin.Default()
 
@@ -105,6 +136,8 @@ func TestPhotosHandler(t *testing.T) {
 
 			router.ServeHTTP(resp, req)
 			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.warning, resp.Header().Get("Warning"))
+			assert.Equal(t, tt.want.age, resp.Header().Get("Age"))
 		})
 	}
 }
@@ -0,0 +1,107 @@
+package api
+
+//go:generate mockgen -source=proxy.go -destination=mocks/proxy_mock.go
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// proxyHeaders returns the upstream response headers describing the content, which are forwarded when streaming.
+func proxyHeaders() []string {
+	return []string{"Content-Type", "Content-Length", "Content-Encoding", "Etag", "Last-Modified", "Cache-Control"}
+}
+
+type streamer interface {
+	Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error)
+}
+
+// PhotoContent returns a handler streaming the full size content of a photo from the upstream. The content is
+// passed through as it arrives, so large payloads are never held in memory. Only the photo lookup is bound by the
+// server timeout, the transfer runs until it completes or the client goes away.
+func PhotoContent(cfg *config.Server, ps photoService, st streamer, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid id", apperror.Validation(fmt.Errorf("failed to parse id: %w", err)))
+			return
+		}
+
+		lookupCtx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		p, err := ps.GetPhotos(lookupCtx, id)
+
+		cancel()
+
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "photo not found"})
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to get photos", apperror.Upstream(fmt.Errorf("failed to get photos: %w", err)))
+			return
+		}
+
+		resp, err := st.Get(c.Request.Context(), p.URL)
+		if err != nil {
+			respondError(c, l, http.StatusBadGateway, "failed to get photo content", apperror.Upstream(fmt.Errorf("failed to get photo content: %w", err)))
+			return
+		}
+
+		n, err := stream(c, resp)
+		logger.EventFromContext(c.Request.Context()).Add(zap.Int64("proxy_bytes", n))
+
+		if err != nil {
+			// The status is already sent, the client sees a truncated body.
+			c.Error(apperror.Upstream(fmt.Errorf("failed to stream photo content after %d bytes: %w", n, err))) //nolint:errcheck // returns the same error
+		}
+	}
+}
+
+// stream copies the upstream response to the client, flushing after every write so the content is not buffered. It
+// returns the number of bytes copied and stops early when either side fails or the request is cancelled.
+func stream(c *gin.Context, resp *http.Response) (int64, error) {
+	defer resp.Body.Close()
+
+	for _, h := range proxyHeaders() {
+		if v := resp.Header.Get(h); v != "" {
+			c.Header(h, v)
+		}
+	}
+
+	c.Status(resp.StatusCode)
+
+	n, err := io.Copy(&flushWriter{w: c.Writer}, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("failed to copy upstream body: %w", err)
+	}
+
+	return n, nil
+}
+
+// flushWriter flushes every write to the client.
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+func (f *flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	if err != nil {
+		return n, fmt.Errorf("failed to write response: %w", err)
+	}
+
+	f.w.Flush()
+
+	return n, nil
+}
@@ -0,0 +1,132 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPhotoContent(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("x", 100<<10)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("X-Internal", "secret")
+		w.Write([]byte(content))
+	}))
+	// Subtests run in parallel after the test function returns.
+	t.Cleanup(upstream.Close)
+
+	type fields struct {
+		mockOperation func(m *mock.MockphotoService)
+	}
+
+	type want struct {
+		code        int
+		body        string
+		contentType string
+	}
+
+	tests := map[string]struct {
+		fields fields
+		want   want
+	}{
+		"success": {
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoService) {
+					m.EXPECT().GetPhotos(gomock.Any(), 1).Return(&photos.Photo{URL: upstream.URL + "/content"}, nil)
+				},
+			},
+			want: want{code: http.StatusOK, body: content, contentType: "image/png"},
+		},
+		"photo not found": {
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoService) {
+					m.EXPECT().GetPhotos(gomock.Any(), 1).Return(nil, &client.HTTPError{StatusCode: http.StatusNotFound})
+				},
+			},
+			want: want{code: http.StatusNotFound, body: `{"error":"photo not found"}`, contentType: "application/json; charset=utf-8"},
+		},
+		"content upstream error": {
+			fields: fields{
+				mockOperation: func(m *mock.MockphotoService) {
+					m.EXPECT().GetPhotos(gomock.Any(), 1).Return(&photos.Photo{URL: upstream.URL + "/missing"}, nil)
+				},
+			},
+			want: want{code: http.StatusBadGateway, body: `{"error":"failed to get photo content"}`, contentType: "application/json; charset=utf-8"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockService := testutil.NewMock(t, mock.NewMockphotoService, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.GET("/photos/:id/content", api.PhotoContent(&config.Server{Timeout: time.Second}, mockService, client.NewClient(upstream.Client()), logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodGet, "/photos/1/content", http.NoBody)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+			assert.Equal(t, tt.want.contentType, resp.Header().Get("Content-Type"))
+			assert.Empty(t, resp.Header().Get("X-Internal"))
+		})
+	}
+}
+
+func TestPhotoContent_Cancel(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	mockService := testutil.NewMock(t, mock.NewMockphotoService)
+	mockService.EXPECT().GetPhotos(gomock.Any(), 1).Return(&photos.Photo{URL: upstream.URL}, nil)
+
+	router := gin.New()
+	router.GET("/photos/:id/content", api.PhotoContent(&config.Server{Timeout: time.Second}, mockService, client.NewClient(upstream.Client()), logger.NewNop()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/photos/1/content", http.NoBody).WithContext(ctx)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "first chunk", resp.Body.String())
+	assert.True(t, resp.Flushed)
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+type routeLister interface {
+	Routes() []server.RouteInfo
+}
+
+// Routes returns a handler listing the routes of the server with their method, path, authentication and
+// authorization, middleware and handler.
+func Routes(rl routeLister) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"routes": rl.Routes()})
+	}
+}
@@ -0,0 +1,33 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(&config.Server{}, gin.New(), []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: func(c *gin.Context) {}},
+	}, logger.NewNop())
+
+	router := gin.New()
+	router.GET("/admin/routes", api.Routes(s))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/admin/routes", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `{"method":"GET","path":"/health","auth":"none","middleware":["server.(*Server).LoggerMiddleware"],"handler":"server.(*Server).health"}`)
+	assert.Contains(t, resp.Body.String(), `{"method":"GET","path":"/photos/:id","auth":"optional","middleware":["server.(*Server).LoggerMiddleware","auth.Middleware"],"handler":"api_test.TestRoutes"}`)
+}
@@ -0,0 +1,76 @@
+package api
+
+//go:generate mockgen -source=search.go -destination=mocks/search_mock.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/search"
+)
+
+type photoSearcher interface {
+	Search(ctx context.Context, q search.Query) (*search.Results, error)
+}
+
+// SearchPhotos returns a handler searching the photos by the words of the q parameter, optionally in the album of the
+// album_id parameter, paginated by the page and limit parameters.
+func SearchPhotos(cfg *config.Server, ps photoSearcher, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		q, err := parseSearchQuery(c)
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, err.Error(), apperror.Validation(err))
+			return
+		}
+
+		r, err := ps.Search(ctx, q)
+		if errors.Is(err, search.ErrEmptyQuery) {
+			respondError(c, l, http.StatusBadRequest, "q must contain a word", apperror.Validation(err))
+			return
+		}
+
+		if err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to search photos", apperror.Upstream(fmt.Errorf("failed to search photos: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusOK, r)
+	}
+}
+
+func parseSearchQuery(c *gin.Context) (search.Query, error) {
+	q := search.Query{Text: c.Query("q")}
+
+	var err error
+
+	if s := c.Query("album_id"); s != "" {
+		if q.AlbumID, err = strconv.Atoi(s); err != nil || q.AlbumID < 1 {
+			return q, errors.New("invalid album_id")
+		}
+	}
+
+	if s := c.Query("page"); s != "" {
+		if q.Page, err = strconv.Atoi(s); err != nil || q.Page < 1 {
+			return q, errors.New("invalid page")
+		}
+	}
+
+	if s := c.Query("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 1 {
+			return q, errors.New("invalid limit")
+		}
+	}
+
+	return q, nil
+}
@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/search"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+)
+
+func TestSearchPhotos(t *testing.T) {
+	t.Parallel()
+
+	results := &search.Results{
+		Hits:  []search.Hit{{Photo: photos.Photo{ID: 1, Title: "sunset"}, Rank: 1.5, Highlight: "<b>sunset</b>"}},
+		Total: 1,
+		Page:  2,
+		Limit: 10,
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockphotoSearcher)
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		query  string
+		fields fields
+		want   want
+	}{
+		"ok": {
+			query: "?q=sunset&album_id=3&page=2&limit=10",
+			fields: fields{mockOperation: func(m *mock.MockphotoSearcher) {
+				m.EXPECT().Search(gomock.Any(), search.Query{Text: "sunset", AlbumID: 3, Page: 2, Limit: 10}).Return(results, nil)
+			}},
+			want: want{
+				code: http.StatusOK,
+				body: `{"hits":[{"photo":{"albumId":0,"id":1,"title":"sunset","url":"","thumbnailUrl":""},"rank":1.5,"highlight":"<b>sunset</b>"}],"total":1,"page":2,"limit":10}`,
+			},
+		},
+		"empty query": {
+			query: "?q=",
+			fields: fields{mockOperation: func(m *mock.MockphotoSearcher) {
+				m.EXPECT().Search(gomock.Any(), gomock.Any()).Return(nil, search.ErrEmptyQuery)
+			}},
+			want: want{code: http.StatusBadRequest, body: `{"error":"q must contain a word"}`},
+		},
+		"invalid album": {
+			query:  "?q=sunset&album_id=0",
+			fields: fields{mockOperation: func(*mock.MockphotoSearcher) {}},
+			want:   want{code: http.StatusBadRequest, body: `{"error":"invalid album_id"}`},
+		},
+		"invalid page": {
+			query:  "?q=sunset&page=0",
+			fields: fields{mockOperation: func(*mock.MockphotoSearcher) {}},
+			want:   want{code: http.StatusBadRequest, body: `{"error":"invalid page"}`},
+		},
+		"invalid limit": {
+			query:  "?q=sunset&limit=x",
+			fields: fields{mockOperation: func(*mock.MockphotoSearcher) {}},
+			want:   want{code: http.StatusBadRequest, body: `{"error":"invalid limit"}`},
+		},
+		"search error": {
+			query: "?q=sunset",
+			fields: fields{mockOperation: func(m *mock.MockphotoSearcher) {
+				m.EXPECT().Search(gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+			}},
+			want: want{code: http.StatusInternalServerError, body: `{"error":"failed to search photos"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := testutil.NewMock(t, mock.NewMockphotoSearcher, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.GET("/photos/search", api.SearchPhotos(&config.Server{Timeout: time.Second}, m, logger.NewNop()))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/photos/search"+tt.query, http.NoBody))
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.JSONEq(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
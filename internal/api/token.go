@@ -0,0 +1,90 @@
+package api
+
+//go:generate mockgen -source=token.go -destination=mocks/token_mock.go
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/token"
+)
+
+// APIKeyHeader is the request header carrying an API key exchanged for a token.
+const APIKeyHeader = "X-API-Key"
+
+// grantClientCredentials is the only OAuth2 grant type supported by the token endpoint.
+const grantClientCredentials = "client_credentials"
+
+// errUnsupportedGrant is returned for token requests of another grant type than client_credentials.
+var errUnsupportedGrant = errors.New("unsupported grant type")
+
+type tokenIssuer interface {
+	Exchange(creds token.Credentials, scopes []string) (*token.Token, error)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// IssueToken returns a handler exchanging an API key, in the X-API-Key header, or client credentials, in the form or
+// with basic authentication, for a token like an OAuth2 token endpoint. The scope parameter narrows the scopes of the
+// token.
+func IssueToken(ti tokenIssuer, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		creds := token.Credentials{APIKey: c.GetHeader(APIKeyHeader)}
+
+		if creds.APIKey == "" {
+			if grant := c.PostForm("grant_type"); grant != grantClientCredentials {
+				respondError(c, l, http.StatusBadRequest, "unsupported_grant_type", apperror.Validation(errUnsupportedGrant))
+				return
+			}
+
+			var ok bool
+			if creds.ClientID, creds.ClientSecret, ok = c.Request.BasicAuth(); !ok {
+				creds.ClientID, creds.ClientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+			}
+		}
+
+		t, err := ti.Exchange(creds, strings.Fields(c.PostForm("scope")))
+
+		switch {
+		case errors.Is(err, token.ErrInvalidClient):
+			respondError(c, l, http.StatusUnauthorized, "invalid_client", apperror.Auth(err))
+			return
+		case errors.Is(err, token.ErrInvalidScope):
+			respondError(c, l, http.StatusBadRequest, "invalid_scope", apperror.Validation(err))
+			return
+		case err != nil:
+			respondError(c, l, http.StatusInternalServerError, "server_error", apperror.Internal(err))
+			return
+		}
+
+		l.Info("token issued", zap.String("subject", t.Subject), zap.Strings("scopes", t.Scopes), zap.Time("expires_at", t.ExpiresAt))
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, tokenResponse{
+			AccessToken: t.AccessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(math.Round(time.Until(t.ExpiresAt).Seconds())),
+			Scope:       strings.Join(t.Scopes, " "),
+		})
+	}
+}
+
+// JWKS returns a handler publishing the JSON Web Key Set verifying the tokens, for consumers of the tokens such as
+// other services.
+func JWKS(keys map[string]any) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, keys)
+	}
+}
@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"github.com/twk/skeleton-go-api/internal/token"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIssueTokenHandler(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		apiKey    string
+		basicAuth [2]string
+		form      string
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MocktokenIssuer)
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	issued := func(scopes ...string) *token.Token {
+		return &token.Token{Subject: "reporting", AccessToken: "jwt", ExpiresAt: time.Now().Add(15 * time.Minute), Scopes: scopes}
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"client credentials in form": {
+			args: args{form: "grant_type=client_credentials&client_id=reporting&client_secret=s3cret&scope=photos:read"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(token.Credentials{ClientID: "reporting", ClientSecret: "s3cret"}, []string{"photos:read"}).Return(issued("photos:read"), nil)
+				},
+			},
+			want: want{code: http.StatusOK, body: `{"access_token":"jwt","token_type":"Bearer","expires_in":900,"scope":"photos:read"}`},
+		},
+		"client credentials with basic auth": {
+			args: args{basicAuth: [2]string{"reporting", "s3cret"}, form: "grant_type=client_credentials"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(token.Credentials{ClientID: "reporting", ClientSecret: "s3cret"}, gomock.Len(0)).Return(issued(), nil)
+				},
+			},
+			want: want{code: http.StatusOK, body: `{"access_token":"jwt","token_type":"Bearer","expires_in":900}`},
+		},
+		"api key": {
+			args: args{apiKey: "key-1"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(token.Credentials{APIKey: "key-1"}, gomock.Len(0)).Return(issued("photos:read"), nil)
+				},
+			},
+			want: want{code: http.StatusOK, body: `{"access_token":"jwt","token_type":"Bearer","expires_in":900,"scope":"photos:read"}`},
+		},
+		"unsupported grant": {
+			args: args{form: "grant_type=password&username=alice&password=secret"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(gomock.Any(), gomock.Any()).Times(0)
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"unsupported_grant_type"}`},
+		},
+		"invalid client": {
+			args: args{apiKey: "guess"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(gomock.Any(), gomock.Any()).Return(nil, token.ErrInvalidClient)
+				},
+			},
+			want: want{code: http.StatusUnauthorized, body: `{"error":"invalid_client"}`},
+		},
+		"invalid scope": {
+			args: args{apiKey: "key-1", form: "scope=photos:write"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: photos:write", token.ErrInvalidScope))
+				},
+			},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid_scope"}`},
+		},
+		"issuer error": {
+			args: args{apiKey: "key-1"},
+			fields: fields{
+				mockOperation: func(m *mock.MocktokenIssuer) {
+					m.EXPECT().Exchange(gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError, body: `{"error":"server_error"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockIssuer := testutil.NewMock(t, mock.NewMocktokenIssuer, tt.fields.mockOperation)
+
+			router := gin.New()
+			router.POST("/auth/token", api.IssueToken(mockIssuer, logger.NewNop()))
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/token", strings.NewReader(tt.args.form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			if tt.args.apiKey != "" {
+				req.Header.Set(api.APIKeyHeader, tt.args.apiKey)
+			}
+
+			if tt.args.basicAuth[0] != "" {
+				req.SetBasicAuth(tt.args.basicAuth[0], tt.args.basicAuth[1])
+			}
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
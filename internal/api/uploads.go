@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+const uploadIDLen = 16
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// Upload describes a file stored through the uploads endpoint.
+type Upload struct {
+	ID          string `json:"id"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// Uploads returns a handler storing the "file" multipart form field in the blob store.
+// When the optional "sha256" form field is sent, the content is verified against it before being stored.
+func Uploads(cfg *config.Server, ucfg *config.Uploads, bs blobStore, l *logger.Logger) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		if ucfg.MaxSize > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(ucfg.MaxSize))
+		}
+
+		fh, err := c.FormFile("file")
+		if err != nil {
+			respondError(c, l, formErrorStatus(err), "invalid upload", apperror.Validation(fmt.Errorf("failed to read upload: %w", err)))
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			respondError(c, l, http.StatusBadRequest, "invalid upload", apperror.Validation(fmt.Errorf("failed to open upload: %w", err)))
+			return
+		}
+
+		defer f.Close()
+
+		u, err := inspectUpload(f, fh, ucfg.AllowedTypes, c.PostForm("sha256"))
+		if err != nil {
+			status := uploadErrorStatus(err)
+			if status < http.StatusInternalServerError {
+				err = apperror.Validation(err)
+			}
+
+			respondError(c, l, status, err.Error(), fmt.Errorf("rejected upload: %w", err))
+
+			return
+		}
+
+		if err := bs.Put(ctx, "uploads/"+u.ID, f, u.Size, u.ContentType); err != nil {
+			respondError(c, l, http.StatusInternalServerError, "failed to store upload", apperror.DB(fmt.Errorf("failed to store upload: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusCreated, u)
+	}
+}
+
+// inspectUpload checks the content type and checksum of the uploaded file and describes it.
+// The file is rewound so it can be stored afterwards.
+func inspectUpload(f multipart.File, fh *multipart.FileHeader, allowedTypes []string, expectedSum string) (*Upload, error) {
+	contentType, err := sniffAllowedType(f, allowedTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := checksum(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedSum != "" && !strings.EqualFold(expectedSum, sum) {
+		return nil, fmt.Errorf("%w: expected %s, got %s", errChecksumMismatch, expectedSum, sum)
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Upload{ID: id, FileName: fh.Filename, ContentType: contentType, Size: fh.Size, SHA256: sum}, nil
+}
+
+func checksum(f io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind upload: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, uploadIDLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate upload id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errChecksumMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,134 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	mock "github.com/twk/skeleton-go-api/internal/api/mocks"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+)
+
+// helloSum is the SHA-256 checksum of "hello world".
+const helloSum = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+func TestUploads(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		content string
+		sum     string
+		maxSize config.ByteSize
+	}
+
+	type fields struct {
+		mockOperation func(m *mock.MockblobStore)
+	}
+
+	type want struct {
+		code   int
+		upload *api.Upload
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"success": {
+			args: args{content: "hello world"},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), int64(11), "text/plain; charset=utf-8").Return(nil)
+				},
+			},
+			want: want{code: http.StatusCreated, upload: &api.Upload{FileName: "hello.txt", ContentType: "text/plain; charset=utf-8", Size: 11, SHA256: helloSum}},
+		},
+		"checksum verified": {
+			args: args{content: "hello world", sum: helloSum},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), int64(11), gomock.Any()).Return(nil)
+				},
+			},
+			want: want{code: http.StatusCreated, upload: &api.Upload{FileName: "hello.txt", ContentType: "text/plain; charset=utf-8", Size: 11, SHA256: helloSum}},
+		},
+		"checksum mismatch": {
+			args:   args{content: "hello world", sum: "deadbeef"},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusUnprocessableEntity},
+		},
+		"type not allowed": {
+			args:   args{content: "%PDF-1.4"},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusUnsupportedMediaType},
+		},
+		"too large": {
+			args:   args{content: "hello world", maxSize: 32},
+			fields: fields{mockOperation: func(*mock.MockblobStore) {}},
+			want:   want{code: http.StatusRequestEntityTooLarge},
+		},
+		"store error": {
+			args: args{content: "hello world"},
+			fields: fields{
+				mockOperation: func(m *mock.MockblobStore) {
+					m.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError)
+				},
+			},
+			want: want{code: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mockStore := testutil.NewMock(t, mock.NewMockblobStore, tt.fields.mockOperation)
+
+			ucfg := &config.Uploads{MaxSize: tt.args.maxSize, AllowedTypes: []string{"text/plain"}}
+			router := gin.Default()
+			router.POST("/uploads", api.Uploads(&config.Server{Timeout: 1 * time.Second}, ucfg, mockStore, logger.NewNop()))
+
+			body := &bytes.Buffer{}
+			w := multipart.NewWriter(body)
+			assert.NoError(t, w.WriteField("sha256", tt.args.sum))
+			fw, err := w.CreateFormFile("file", "hello.txt")
+			assert.NoError(t, err)
+			_, err = fw.Write([]byte(tt.args.content))
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/uploads", body)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want.code, resp.Code)
+
+			if tt.want.upload != nil {
+				var got api.Upload
+				assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+				assert.Len(t, got.ID, 32)
+
+				got.ID = ""
+				assert.Equal(t, *tt.want.upload, got)
+			}
+		})
+	}
+}
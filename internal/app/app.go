@@ -0,0 +1,122 @@
+// Package app wires the components of the service in a Container. Each subsystem has a module providing the
+// constructors of its components, contributing its routes, server options and authenticators, and registering the
+// lifecycle hooks of its background work, so that a subsystem is added with a module rather than by editing the wiring
+// of the others.
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/election"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/profiling"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+	"github.com/twk/skeleton-go-api/internal/telemetry"
+	"github.com/twk/skeleton-go-api/internal/watchdog"
+)
+
+// modules register the constructors of the subsystems. Their contributions are in the order of the modules, which
+// is the order of the middleware.
+func modules() []func(c *Container) {
+	return []func(c *Container){coreModule, telemetryModule, profilingModule, watchdogModule, photosModule, searchModule, importModule, storageModule, authModule, chaosModule, mirrorModule, serverModule}
+}
+
+// RegisterSections registers the sections of the configuration owned by the subsystems with v, which has to be done
+// before the configuration is built.
+func RegisterSections(v *config.Viper) {
+	v.Register(watchdog.ConfigSection, auth.ConfigSection, metrics.ConfigSection, telemetry.ConfigSection,
+		api.ConfigDiffSection)
+}
+
+// Configure resolves the strictness mode of cfg and applies the settings of the mode which are not passed to the
+// components. The mode only enables response validation, which client.validate_responses can enable in any mode.
+func Configure(cfg *config.Config) (strictness.Settings, error) {
+	st, err := strictness.New(cfg.Strictness)
+	if err != nil {
+		return st, fmt.Errorf("error resolving strictness: %w", err)
+	}
+
+	if cfg.Client.MockUpstream.Enabled && !st.FakeData {
+		return st, fmt.Errorf("client.mock_upstream is not allowed in %s mode", st.Mode)
+	}
+
+	if cfg.Chaos.Enabled && !st.FaultInjection {
+		return st, fmt.Errorf("chaos is not allowed in %s mode", st.Mode)
+	}
+
+	cfg.Client.ValidateResponses = cfg.Client.ValidateResponses || st.ValidateResponses
+	binding.EnableDecoderDisallowUnknownFields = st.StrictJSON
+
+	if st.Mode != strictness.ModeDev {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	return st, nil
+}
+
+// New creates the container of the components of the service configured by cfg, in the strictness mode st.
+func New(cfg *config.Config, st strictness.Settings, l *logger.Logger) *Container {
+	c := NewContainer()
+	Supply(c, cfg)
+	Supply(c, st)
+	Supply(c, l)
+
+	for _, module := range modules() {
+		module(c)
+	}
+
+	return c
+}
+
+// Run starts the background work of c and serves until ctx is done, then shuts the components down.
+func Run(ctx context.Context, c *Container) error {
+	return RunRole(ctx, c, RoleServe)
+}
+
+// start constructs the components shared by the roles, which start their background work.
+func start(c *Container) error {
+	if _, err := Get[*telemetry.Telemetry](c); err != nil {
+		return err
+	}
+
+	if _, err := Get[*profiling.Profiler](c); err != nil {
+		return err
+	}
+
+	if _, err := Get[*watchdog.Watchdog](c); err != nil {
+		return err
+	}
+
+	_, err := Get[*election.Elector](c)
+
+	return err
+}
+
+// serve serves the API until ctx is done.
+func serve(ctx context.Context, c *Container) error {
+	s, err := Get[*server.Server](c)
+	if err != nil {
+		return err
+	}
+
+	lc, err := Get[*lifecycle.Lifecycle](c)
+	if err != nil {
+		return err
+	}
+
+	if err = watchRemote(c); err != nil {
+		return err
+	}
+
+	return lc.Run(ctx, s.Start) //nolint:wrapcheck // wrapped by the caller
+}
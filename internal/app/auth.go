@@ -0,0 +1,339 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/audit"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/auth/oidc"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/csrf"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/secret"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/session"
+	"github.com/twk/skeleton-go-api/internal/signing"
+	"github.com/twk/skeleton-go-api/internal/token"
+)
+
+// authModule provides the authentication mechanisms and the authorizer, with the routes issuing credentials, and the
+// CSRF protection and the audit log.
+func authModule(c *Container) {
+	Provide(c, newSessionManager)
+	Provide(c, newTokenService)
+	Provide(c, newBreakGlass)
+	Provide(c, newAuthorizer)
+
+	Contribute(c, trustedHeaders)
+	Contribute(c, hmacSignatures)
+	Contribute(c, func(c *Container) ([]auth.Authenticator, error) {
+		sm, err := Get[*session.Manager](c)
+		if err != nil || sm == nil {
+			return nil, err
+		}
+
+		return []auth.Authenticator{sm}, nil
+	})
+	Contribute(c, func(c *Container) ([]auth.Authenticator, error) {
+		ts, err := Get[*token.Service](c)
+		if err != nil || ts == nil {
+			return nil, err
+		}
+
+		return []auth.Authenticator{ts}, nil
+	})
+
+	Contribute(c, oidcRoutes)
+	Contribute(c, tokenRoutes)
+	Contribute(c, breakGlassRoutes)
+
+	// The CSRF tokens are bound to the session, whose middleware runs first.
+	Contribute(c, func(c *Container) ([]server.Option, error) {
+		sm, err := Get[*session.Manager](c)
+		if err != nil || sm == nil {
+			return nil, err
+		}
+
+		return []server.Option{server.WithMiddleware(sm.Middleware())}, nil
+	})
+
+	Provide(c, newCSRFProtection)
+	Contribute(c, func(c *Container) ([]server.Option, error) {
+		cp, err := Get[*csrf.Protection](c)
+		if err != nil || cp == nil {
+			return nil, err
+		}
+
+		return []server.Option{server.WithMiddleware(cp.Middleware())}, nil
+	})
+	Contribute(c, func(c *Container) ([]server.RouteParam, error) {
+		cp, err := Get[*csrf.Protection](c)
+		if err != nil || cp == nil {
+			return nil, err
+		}
+
+		return []server.RouteParam{{Method: http.MethodGet, Path: "/csrf", Handler: cp.Issue()}}, nil
+	})
+
+	Provide(c, newAuditor)
+	Contribute(c, func(c *Container) ([]server.Option, error) {
+		a, err := Get[*audit.Auditor](c)
+		if err != nil || a == nil {
+			return nil, err
+		}
+
+		return []server.Option{server.WithMiddleware(a.Middleware())}, nil
+	})
+	Contribute(c, auditRoutes)
+}
+
+// trustedHeaders returns the authenticator of the identity headers set by trusted proxies, or none if no proxy is
+// trusted.
+func trustedHeaders(c *Container) ([]auth.Authenticator, error) {
+	cfg := &auth.ConfigSection.Get(MustGet[*config.Config](c)).TrustedHeaders
+	if len(cfg.ProxyCIDRs) == 0 {
+		return nil, nil
+	}
+
+	th, err := auth.NewTrustedHeaders(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating trusted headers authenticator: %w", err)
+	}
+
+	return []auth.Authenticator{th}, nil
+}
+
+// hmacSignatures returns the verifier of the HMAC signatures of other services, or none if no key is configured.
+func hmacSignatures(c *Container) ([]auth.Authenticator, error) {
+	cfg := &auth.ConfigSection.Get(MustGet[*config.Config](c)).HMAC
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]signing.Key, 0, len(cfg.Keys))
+
+	for _, k := range cfg.Keys {
+		s, err := secret.Resolve(k.SecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving hmac key %q: %w", k.ID, err)
+		}
+
+		keys = append(keys, signing.Key{ID: k.ID, Secret: []byte(s), Groups: k.Groups})
+	}
+
+	return []auth.Authenticator{signing.NewVerifier(signing.StaticKeys(keys...), cfg.MaxSkew, int64(cfg.MaxBodySize), cfg.Headers...)}, nil
+}
+
+// newSessionManager creates the manager of the sessions in the configured store, or nil if sessions are disabled.
+func newSessionManager(c *Container) (*session.Manager, error) {
+	cfg := &MustGet[*config.Config](c).Session
+
+	var store session.Store
+
+	switch cfg.Store {
+	case "":
+		return nil, nil
+	case "memory":
+		store = session.NewMemoryStore()
+	case "redis":
+		rdb, err := Get[*goredis.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		store = session.NewRedisStore(rdb)
+	default:
+		return nil, fmt.Errorf("unknown session store %q", cfg.Store)
+	}
+
+	sm, err := session.New(cfg, store, MustGet[*logger.Logger](c))
+	if err != nil {
+		return nil, fmt.Errorf("error creating session manager: %w", err)
+	}
+
+	return sm, nil
+}
+
+// oidcRoutes returns the routes of the OIDC login of operators and the admin landing page, or none if the login is
+// disabled.
+func oidcRoutes(c *Container) ([]server.RouteParam, error) {
+	cfg := &auth.ConfigSection.Get(MustGet[*config.Config](c)).OIDC
+	if cfg.Issuer == "" {
+		return nil, nil
+	}
+
+	sm, err := Get[*session.Manager](c)
+	if err != nil {
+		return nil, err
+	}
+
+	if sm == nil {
+		return nil, errors.New("oidc login requires sessions")
+	}
+
+	httpClient, err := Get[*http.Client](c)
+	if err != nil {
+		return nil, err
+	}
+
+	l := MustGet[*logger.Logger](c)
+
+	p, err := oidc.New(cfg, httpClient, sm, l)
+	if err != nil {
+		return nil, fmt.Errorf("error creating oidc login: %w", err)
+	}
+
+	policy := &auth.Policy{Name: "admin", Roles: cfg.AdminGroups}
+
+	return []server.RouteParam{
+		{Method: http.MethodGet, Path: "/auth/login", Handler: p.Login(), Auth: auth.ModeNone},
+		{Method: http.MethodGet, Path: "/auth/callback", Handler: p.Callback(), Auth: auth.ModeNone},
+		{Method: http.MethodPost, Path: "/auth/logout", Handler: p.Logout(), Auth: auth.ModeNone},
+		{Method: http.MethodGet, Path: "/admin", Handler: api.Operator(l), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
+
+// newTokenService creates the issuer of access tokens, or nil if tokens are disabled.
+func newTokenService(c *Container) (*token.Service, error) {
+	cfg := &auth.ConfigSection.Get(MustGet[*config.Config](c)).Token
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	ts, err := token.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating token service: %w", err)
+	}
+
+	return ts, nil
+}
+
+// tokenRoutes returns the routes issuing access tokens and publishing their keys, or none if tokens are disabled.
+func tokenRoutes(c *Container) ([]server.RouteParam, error) {
+	ts, err := Get[*token.Service](c)
+	if err != nil || ts == nil {
+		return nil, err
+	}
+
+	rp := []server.RouteParam{{Method: http.MethodPost, Path: "/auth/token", Handler: api.IssueToken(ts, MustGet[*logger.Logger](c)), Auth: auth.ModeNone}}
+
+	if keys := ts.JWKS(); keys != nil {
+		rp = append(rp, server.RouteParam{Method: http.MethodGet, Path: "/.well-known/jwks.json", Handler: api.JWKS(keys), Auth: auth.ModeNone})
+	}
+
+	return rp, nil
+}
+
+// newBreakGlass creates the issuer of break-glass tokens, or nil if break-glass access is disabled.
+func newBreakGlass(c *Container) (*auth.BreakGlass, error) {
+	cfg := &auth.ConfigSection.Get(MustGet[*config.Config](c)).BreakGlass
+	if cfg.SigningKey == "" {
+		return nil, nil
+	}
+
+	bg, err := auth.NewBreakGlass(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating break-glass access: %w", err)
+	}
+
+	return bg, nil
+}
+
+// breakGlassRoutes returns the route issuing break-glass tokens, or none if break-glass access is disabled.
+func breakGlassRoutes(c *Container) ([]server.RouteParam, error) {
+	bg, err := Get[*auth.BreakGlass](c)
+	if err != nil || bg == nil {
+		return nil, err
+	}
+
+	policy := &auth.Policy{Name: "break-glass-admin", Roles: auth.ConfigSection.Get(MustGet[*config.Config](c)).BreakGlass.AdminRoles}
+
+	return []server.RouteParam{
+		{Method: http.MethodPost, Path: "/admin/break-glass", Handler: api.IssueBreakGlass(bg, MustGet[*logger.Logger](c)), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
+
+func newAuthorizer(c *Container) (*auth.Authorizer, error) {
+	cfg := auth.ConfigSection.Get(MustGet[*config.Config](c))
+	l := MustGet[*logger.Logger](c)
+
+	if cfg.ExplainDenials {
+		l.Warn("access denials are explained to API consumers, do not enable it in production")
+	}
+
+	bg, err := Get[*auth.BreakGlass](c)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []auth.AuthorizerOption
+	if bg != nil {
+		opts = append(opts, auth.WithBreakGlass(bg))
+	}
+
+	return auth.NewAuthorizer(l, cfg.ExplainDenials, opts...), nil
+}
+
+// newCSRFProtection creates the CSRF protection, or nil if it is disabled.
+func newCSRFProtection(c *Container) (*csrf.Protection, error) {
+	cfg := &MustGet[*config.Config](c).CSRF
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	cp, err := csrf.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating csrf protection: invalid csrf configuration: %w", err)
+	}
+
+	return cp, nil
+}
+
+// newAuditor creates the auditor of the mutating calls, or nil if auditing is disabled.
+func newAuditor(c *Container) (*audit.Auditor, error) {
+	cfg := &MustGet[*config.Config](c).Audit
+
+	var store audit.Store
+
+	switch cfg.Store {
+	case "":
+		if !cfg.Log {
+			return nil, nil
+		}
+	case "memory":
+		if len(cfg.AdminRoles) == 0 {
+			return nil, errors.New("error creating audit log: the audit log requires admin roles")
+		}
+
+		store = audit.NewMemoryStore(cfg.MaxEntries)
+	default:
+		return nil, fmt.Errorf("error creating audit log: unknown audit store %q", cfg.Store)
+	}
+
+	return audit.New(store, MustGet[*logger.Logger](c), cfg.Log), nil
+}
+
+// auditRoutes returns the route querying the audit log, restricted to the admin roles, or none if the entries are
+// only logged.
+func auditRoutes(c *Container) ([]server.RouteParam, error) {
+	cfg := &MustGet[*config.Config](c).Audit
+	if cfg.Store == "" {
+		return nil, nil
+	}
+
+	a, err := Get[*audit.Auditor](c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &auth.Policy{Name: "audit-admin", Roles: cfg.AdminRoles}
+
+	return []server.RouteParam{
+		{Method: http.MethodGet, Path: "/admin/audit", Handler: api.AuditLog(a, MustGet[*logger.Logger](c)), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
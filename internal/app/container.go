@@ -0,0 +1,138 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrCycle is returned when constructors depend on each other.
+var ErrCycle = errors.New("dependency cycle")
+
+// Container holds the constructors of the components of the service and the components they constructed. Components
+// are constructed on their first Get, with the components they get in turn, so that the disabled ones and the ones
+// nothing depends on are never constructed. A Container is not safe for concurrent use: the service is wired from one
+// goroutine before serving.
+type Container struct {
+	providers     map[reflect.Type]func(c *Container) (any, error)
+	values        map[reflect.Type]any
+	contributions map[reflect.Type][]func(c *Container) (any, error)
+	// resolving are the types being constructed, in order, to report cycles.
+	resolving []reflect.Type
+}
+
+// NewContainer creates an empty Container.
+func NewContainer() *Container {
+	return &Container{
+		providers:     map[reflect.Type]func(c *Container) (any, error){},
+		values:        map[reflect.Type]any{},
+		contributions: map[reflect.Type][]func(c *Container) (any, error){},
+	}
+}
+
+// Provide registers fn as the constructor of T, replacing any previous one, e.g. to disable a component in a command.
+// fn gets its dependencies from c, then registers its lifecycle hooks, so that the components are stopped in the
+// reverse order of their construction.
+func Provide[T any](c *Container, fn func(c *Container) (T, error)) {
+	t := reflect.TypeFor[T]()
+	delete(c.values, t)
+
+	c.providers[t] = func(c *Container) (any, error) {
+		return fn(c)
+	}
+}
+
+// Supply registers v as the T of c.
+func Supply[T any](c *Container, v T) {
+	t := reflect.TypeFor[T]()
+	delete(c.providers, t)
+	c.values[t] = v
+}
+
+// Get returns the T of c, constructing it on the first call.
+func Get[T any](c *Container) (T, error) {
+	var zero T
+
+	t := reflect.TypeFor[T]()
+	if v, ok := c.values[t]; ok {
+		return as[T](v), nil
+	}
+
+	fn, ok := c.providers[t]
+	if !ok {
+		return zero, fmt.Errorf("no provider of %s", t)
+	}
+
+	for i, r := range c.resolving {
+		if r == t {
+			return zero, fmt.Errorf("%w: %s", ErrCycle, cycle(append(c.resolving[i:], t)))
+		}
+	}
+
+	c.resolving = append(c.resolving, t)
+	v, err := fn(c)
+	c.resolving = c.resolving[:len(c.resolving)-1]
+
+	if err != nil {
+		return zero, err
+	}
+
+	c.values[t] = v
+
+	return as[T](v), nil
+}
+
+// as returns v, stored as the T of a Container, as a T. The nil interfaces provided for disabled components are nil Ts.
+func as[T any](v any) T {
+	t, _ := v.(T)
+	return t
+}
+
+// MustGet returns the T of c like Get, and panics when it cannot be constructed. It is meant for the values supplied
+// with the container, such as the configuration.
+func MustGet[T any](c *Container) T {
+	v, err := Get[T](c)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func cycle(types []reflect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+
+	return strings.Join(names, " -> ")
+}
+
+// Contribute registers fn adding values to the group of T, such as the routes of a subsystem, so that subsystems are
+// added without editing the components consuming the group. Contributions registered by constructors are included in
+// the next All.
+func Contribute[T any](c *Container, fn func(c *Container) ([]T, error)) {
+	t := reflect.TypeFor[T]()
+
+	c.contributions[t] = append(c.contributions[t], func(c *Container) (any, error) {
+		return fn(c)
+	})
+}
+
+// All returns the values of the group of T, in the order of their contributions.
+func All[T any](c *Container) ([]T, error) {
+	var all []T
+
+	// Contributions may be registered while the ones of the group are called.
+	for i := 0; i < len(c.contributions[reflect.TypeFor[T]()]); i++ {
+		v, err := c.contributions[reflect.TypeFor[T]()][i](c)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, v.([]T)...) //nolint:forcetypeassert // returned by a contribution of T
+	}
+
+	return all, nil
+}
@@ -0,0 +1,158 @@
+package app_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+)
+
+type (
+	first  struct{ n int }
+	second struct{ f *first }
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	c := app.NewContainer()
+	calls := 0
+
+	app.Provide(c, func(*app.Container) (*first, error) {
+		calls++
+		return &first{n: calls}, nil
+	})
+	app.Provide(c, func(c *app.Container) (*second, error) {
+		f, err := app.Get[*first](c)
+		return &second{f: f}, err
+	})
+
+	assert.Equal(t, 0, calls, "constructors are lazy")
+
+	s, err := app.Get[*second](c)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f, err := app.Get[*first](c)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Same(t, f, s.f)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProvide_Replace(t *testing.T) {
+	t.Parallel()
+
+	c := app.NewContainer()
+	app.Provide(c, func(*app.Container) (*first, error) { return &first{n: 1}, nil })
+	_, _ = app.Get[*first](c)
+	app.Provide(c, func(*app.Container) (*first, error) { return &first{n: 2}, nil })
+
+	f, err := app.Get[*first](c)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 2, f.n)
+}
+
+func TestGet_Errors(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	tests := map[string]struct {
+		setup   func(c *app.Container)
+		wantErr error
+		wantMsg string
+	}{
+		"no provider": {
+			setup:   func(*app.Container) {},
+			wantMsg: "no provider of *app_test.second",
+		},
+		"cycle": {
+			setup: func(c *app.Container) {
+				app.Provide(c, func(c *app.Container) (*first, error) {
+					_, err := app.Get[*second](c)
+					return nil, err
+				})
+				app.Provide(c, func(c *app.Container) (*second, error) {
+					_, err := app.Get[*first](c)
+					return nil, err
+				})
+			},
+			wantErr: app.ErrCycle,
+			wantMsg: "dependency cycle: *app_test.second -> *app_test.first -> *app_test.second",
+		},
+		"constructor error": {
+			setup: func(c *app.Container) {
+				app.Provide(c, func(*app.Container) (*second, error) { return nil, errBoom })
+			},
+			wantErr: errBoom,
+			wantMsg: "boom",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := app.NewContainer()
+			tt.setup(c)
+
+			_, err := app.Get[*second](c)
+			if !assert.Error(t, err) {
+				return
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+
+			assert.EqualError(t, err, tt.wantMsg)
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	c := app.NewContainer()
+	app.Supply(c, 3)
+	app.Contribute(c, func(*app.Container) ([]string, error) { return []string{"a", "b"}, nil })
+	app.Contribute(c, func(c *app.Container) ([]string, error) {
+		// A contribution registered while the group is resolved is included.
+		app.Contribute(c, func(*app.Container) ([]string, error) { return []string{"d"}, nil })
+		return []string{"c"}, nil
+	})
+
+	all, err := app.All[string](c)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, all)
+	assert.Equal(t, 3, app.MustGet[int](c))
+}
+
+func TestGet_NilInterface(t *testing.T) {
+	t.Parallel()
+
+	c := app.NewContainer()
+	app.Provide(c, func(*app.Container) (fmt.Stringer, error) { return nil, nil })
+
+	for range 2 {
+		v, err := app.Get[fmt.Stringer](c)
+
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	}
+}
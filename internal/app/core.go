@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/election"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/redis"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+	"github.com/twk/skeleton-go-api/internal/telemetry"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// coreModule provides the components shared by the subsystems: the outbound transport and its resolver, the metrics
+// recorder, the tenant resolver, the lifecycle, the Redis client and the leader election.
+func coreModule(c *Container) {
+	Provide(c, newResolver)
+	Provide(c, func(c *Container) (*http.Transport, error) {
+		cfg := MustGet[*config.Config](c)
+
+		resolver, err := Get[*client.Resolver](c)
+		if err != nil {
+			return nil, err
+		}
+
+		transport, err := client.NewTransport(&cfg.Client.Transport, resolver)
+		if err != nil {
+			return nil, fmt.Errorf("error creating http transport: %w", err)
+		}
+
+		return transport, nil
+	})
+
+	// The plain client of the other services than the upstreams.
+	Provide(c, func(c *Container) (*http.Client, error) {
+		transport, err := Get[*http.Transport](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Client{Transport: transport}, nil
+	})
+
+	// The Prometheus registry is nil unless it is the backend, exposing the metrics or exporting them over OTLP.
+	Provide(c, func(c *Container) (*metrics.Registry, error) {
+		cfg := MustGet[*config.Config](c)
+		mcfg, tcfg := metrics.ConfigSection.Get(cfg), telemetry.ConfigSection.Get(cfg)
+
+		if mcfg.Backend != "" && mcfg.Backend != metrics.BackendPrometheus {
+			return nil, nil
+		}
+
+		if mcfg.Path == "" && (tcfg.Endpoint == "" || !tcfg.Metrics.Enabled) {
+			return nil, nil
+		}
+
+		return metrics.New(metricsOptions(mcfg)...), nil
+	})
+
+	Provide(c, newRecorder)
+
+	Provide(c, func(c *Container) (*tenant.Resolver, error) {
+		tr, err := tenant.New(&MustGet[*config.Config](c).Tenancy)
+		if err != nil {
+			return nil, fmt.Errorf("error creating tenant resolver: %w", err)
+		}
+
+		return tr, nil
+	})
+
+	Provide(c, func(c *Container) (*lifecycle.Lifecycle, error) {
+		return lifecycle.New(&MustGet[*config.Config](c).Lifecycle, MustGet[*logger.Logger](c)), nil
+	})
+
+	// The Redis client is only created when a subsystem uses it, and then checked by the health endpoint.
+	Provide(c, func(c *Container) (*goredis.Client, error) {
+		mr, err := Get[metrics.Recorder](c)
+		if err != nil {
+			return nil, err
+		}
+
+		rdb, err := redis.New(&MustGet[*config.Config](c).Redis, mr)
+		if err != nil {
+			return nil, fmt.Errorf("error creating redis client: %w", err)
+		}
+
+		Contribute(c, func(*Container) ([]server.HealthCheck, error) {
+			return []server.HealthCheck{{Name: "redis", Check: redis.HealthCheck(rdb)}}, nil
+		})
+
+		return rdb, nil
+	})
+
+	Provide(c, newElector)
+
+	Contribute(c, func(c *Container) ([]server.RouteParam, error) {
+		var rp []server.RouteParam
+
+		if MustGet[strictness.Settings](c).DebugEndpoints {
+			rp = append(rp, server.RouteParam{Method: http.MethodGet, Path: "/debug/pprof/*profile", Handler: api.Pprof(), Auth: auth.ModeNone})
+		}
+
+		path := metrics.ConfigSection.Get(MustGet[*config.Config](c)).Path
+		if path == "" {
+			return rp, nil
+		}
+
+		mr, err := Get[*metrics.Registry](c)
+		if err != nil {
+			return nil, err
+		}
+
+		if mr == nil {
+			return rp, nil
+		}
+
+		return append(rp, server.RouteParam{Method: http.MethodGet, Path: path, Handler: gin.WrapH(mr.Handler()), Auth: auth.ModeNone}), nil
+	})
+}
+
+// newResolver creates the resolver of the host names of the outbound connections, or nil if they are resolved by the
+// system resolver as they are.
+func newResolver(c *Container) (*client.Resolver, error) {
+	cfg := &MustGet[*config.Config](c).Client.Transport.DNS
+	if !cfg.Cache && len(cfg.Servers) == 0 && len(cfg.Hosts) == 0 {
+		return nil, nil
+	}
+
+	mr, err := Get[metrics.Recorder](c)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := client.NewResolver(cfg, mr)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dns resolver: %w", err)
+	}
+
+	return r, nil
+}
+
+// newRecorder creates the recorder of the configured metrics backend, recording nothing when metrics are disabled.
+func newRecorder(c *Container) (metrics.Recorder, error) {
+	cfg := metrics.ConfigSection.Get(MustGet[*config.Config](c))
+
+	switch cfg.Backend {
+	case "", metrics.BackendPrometheus:
+		mr, err := Get[*metrics.Registry](c)
+		if err != nil {
+			return nil, err
+		}
+
+		if mr == nil {
+			return metrics.Nop(), nil
+		}
+
+		return mr, nil
+	case metrics.BackendStatsD:
+		sd, err := metrics.NewStatsD(&cfg.StatsD, metricsOptions(cfg)...)
+		if err != nil {
+			return nil, fmt.Errorf("error creating statsd recorder: %w", err)
+		}
+
+		lc, err := Get[*lifecycle.Lifecycle](c)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Register("statsd", func(context.Context) error { return sd.Close() }) //nolint:wrapcheck // Close wraps its error
+
+		return sd, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", cfg.Backend)
+	}
+}
+
+func metricsOptions(cfg *metrics.Config) []metrics.Option {
+	opts := []metrics.Option{metrics.WithMaxLabelValues(cfg.MaxLabelValues)}
+	if cfg.NativeHistograms {
+		opts = append(opts, metrics.WithNativeHistograms())
+	}
+
+	return opts
+}
+
+// newElector creates the leader election between the replicas, or nil if it is disabled. Background work running on
+// the leader only is registered with election.OnStartedLeading.
+func newElector(c *Container) (*election.Elector, error) {
+	cfg := &MustGet[*config.Config](c).Election
+
+	var lock election.Lock
+
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "kubernetes":
+		transport, err := Get[*http.Transport](c)
+		if err != nil {
+			return nil, err
+		}
+
+		kl, err := election.NewKubernetesLock(&cfg.Kubernetes, transport)
+		if err != nil {
+			return nil, fmt.Errorf("error creating leader election: %w", err)
+		}
+
+		lock = kl
+	default:
+		return nil, fmt.Errorf("unknown election backend %q", cfg.Backend)
+	}
+
+	mr, err := Get[metrics.Recorder](c)
+	if err != nil {
+		return nil, err
+	}
+
+	l := MustGet[*logger.Logger](c)
+
+	e, err := election.New(cfg, lock, mr, l)
+	if err != nil {
+		return nil, fmt.Errorf("error creating leader election: %w", err)
+	}
+
+	lc, err := Get[*lifecycle.Lifecycle](c)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Go("leader-election", e.Run)
+
+	return e, nil
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/imports"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+// importModule provides the photo import jobs, with their routes.
+func importModule(c *Container) {
+	Provide(c, func(c *Container) (*imports.Manager, error) {
+		ps, err := Get[*photos.Service](c)
+		if err != nil {
+			return nil, err
+		}
+
+		mr, err := Get[metrics.Recorder](c)
+		if err != nil {
+			return nil, err
+		}
+
+		im := imports.New(&MustGet[*config.Config](c).Import, ps, mr, MustGet[*logger.Logger](c))
+
+		lc, err := Get[*lifecycle.Lifecycle](c)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Register("photo-import", im.Shutdown)
+
+		return im, nil
+	})
+
+	Contribute(c, func(c *Container) ([]server.RouteParam, error) {
+		cfg := MustGet[*config.Config](c)
+		if !cfg.Import.Enabled {
+			return nil, nil
+		}
+
+		im, err := Get[*imports.Manager](c)
+		if err != nil {
+			return nil, err
+		}
+
+		l := MustGet[*logger.Logger](c)
+
+		return []server.RouteParam{
+			{Method: http.MethodPost, Path: "/photos/import", Handler: api.ImportPhotos(&cfg.Import, im, l)},
+			{Method: http.MethodGet, Path: "/photos/import/:jobID", Handler: api.ImportStatus(im, l)},
+		}, nil
+	})
+}
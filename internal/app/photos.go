@@ -0,0 +1,483 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+	"github.com/twk/skeleton-go-api/internal/upstream"
+)
+
+// photosModule provides the client of the upstream and the photos service, with its routes.
+func photosModule(c *Container) {
+	Provide(c, func(c *Container) (*upstreamHTTPClient, error) {
+		upstreamClient, err := newUpstreamClient(c)
+		if err != nil {
+			return nil, fmt.Errorf("error creating upstream client: %w", err)
+		}
+
+		return &upstreamHTTPClient{upstreamClient}, nil
+	})
+
+	// The client of the other URLs than those of the upstreams, such as those of photo images.
+	Provide(c, func(c *Container) (*client.Client, error) {
+		hc, err := Get[*upstreamHTTPClient](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.NewClient(hc.Client), nil
+	})
+
+	Provide(c, newUpstreams)
+
+	Provide(c, newDeletionStore)
+	Provide(c, newPhotoService)
+	Provide(c, newPhotoFallback)
+
+	Contribute(c, func(c *Container) ([]server.RouteParam, error) {
+		cfg := MustGet[*config.Config](c)
+		l := MustGet[*logger.Logger](c)
+
+		hc, err := Get[*client.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		ps, err := Get[*photos.Service](c)
+		if err != nil {
+			return nil, err
+		}
+
+		fb, err := Get[*photos.Fallback](c)
+		if err != nil {
+			return nil, err
+		}
+
+		// The reads of single photos and batches serve the last known photos while the upstream fails.
+		var reads interface {
+			GetPhotos(ctx context.Context, id int) (*photos.Photo, error)
+			GetPhotosBatch(ctx context.Context, ids []int) ([]photos.Photo, error)
+		} = ps
+		if fb != nil {
+			reads = fb
+		}
+
+		return []server.RouteParam{
+			{Method: http.MethodGet, Path: "/photos", Handler: api.PhotosBatch(&cfg.Server, reads, l)},
+			{Method: http.MethodGet, Path: "/photos/:id", Handler: api.Photos(&cfg.Server, reads, l)},
+			{Method: http.MethodGet, Path: "/photos/:id/content", Handler: api.PhotoContent(&cfg.Server, ps, hc, l)},
+			{Method: http.MethodGet, Path: "/albums/:id/photos", Handler: api.AlbumPhotos(&cfg.Server, ps, l)},
+		}, nil
+	})
+
+	Contribute(c, softDeleteRoutes)
+	Contribute(c, func(c *Container) ([]server.Option, error) {
+		policy, err := softDeletePolicy(c)
+		if err != nil || policy == nil {
+			return nil, err
+		}
+
+		return []server.Option{server.WithRouteMiddleware(api.IncludeDeleted(policy, MustGet[*logger.Logger](c)))}, nil
+	})
+
+	Contribute(c, exportRoutes)
+}
+
+// newPhotoService creates the photos service of the upstream, with soft delete when enabled, notifying the
+// photos.Listener contributions of the changes.
+func newPhotoService(c *Container) (*photos.Service, error) {
+	cfg := MustGet[*config.Config](c)
+
+	upstreams, err := Get[*upstream.Registry](c)
+	if err != nil {
+		return nil, err
+	}
+
+	hc, err := upstreams.Get(photosUpstream)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the error names the upstream
+	}
+
+	mr, err := Get[metrics.Recorder](c)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []photos.Option{
+		photos.WithBaseURL(upstreams.BaseURL(photosUpstream)),
+		photos.WithRecorder(mr),
+		photos.WithConcurrency(cfg.Photos.Concurrency),
+	}
+
+	if cfg.Client.ValidateResponses {
+		opts = append(opts, photos.WithValidator(client.NewValidator(mr)))
+	}
+
+	deletions, err := Get[photos.DeletionStore](c)
+	if err != nil {
+		return nil, fmt.Errorf("error creating soft delete: %w", err)
+	}
+
+	if deletions != nil {
+		opts = append(opts, photos.WithDeletions(deletions))
+	}
+
+	listeners, err := All[photos.Listener](c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(listeners) > 0 {
+		opts = append(opts, photos.WithListeners(listeners...))
+	}
+
+	return photos.NewService(hc, MustGet[*logger.Logger](c), opts...), nil
+}
+
+// photosUpstream is the name of the upstream of the photos service in config.Config.Upstreams.
+const photosUpstream = "photos"
+
+// upstreamHTTPClient is the http client shared by the clients of the upstreams.
+type upstreamHTTPClient struct {
+	*http.Client
+}
+
+// newUpstreams creates the clients of the configured upstreams, the photos upstream defaulting to the public API.
+func newUpstreams(c *Container) (*upstream.Registry, error) {
+	cfg := MustGet[*config.Config](c)
+
+	hc, err := Get[*upstreamHTTPClient](c)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := Get[metrics.Recorder](c)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs := maps.Clone(cfg.Upstreams)
+	if cfgs == nil {
+		cfgs = map[string]config.Upstream{}
+	}
+
+	// The SSRF guard dials the upstreams itself to check their addresses, it would not go through their proxy.
+	if cfg.Client.SSRFGuard.Enabled {
+		for name, u := range cfgs {
+			if u.Proxy.URL != "" {
+				return nil, fmt.Errorf("error creating upstream clients: upstream %s: a proxy is not supported with the SSRF guard", name)
+			}
+		}
+	}
+
+	ps := cfgs[photosUpstream]
+	if ps.BaseURL == "" {
+		ps.BaseURL = photos.DefaultBaseURL
+	}
+
+	cfgs[photosUpstream] = ps
+
+	var opts []upstream.Option
+
+	if cfg.Client.ValidateResponses {
+		schemas, err := photos.ResponseSchemas(ps.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("error loading photo schemas: %w", err)
+		}
+
+		opts = append(opts, upstream.WithClientOptions(photosUpstream, schemas...))
+	}
+
+	r, err := upstream.New(cfgs, hc.Client, mr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating upstream clients: %w", err)
+	}
+
+	return r, nil
+}
+
+// newUpstreamClient creates the http client for the upstream APIs, answering with fake data in mock upstream mode,
+// blocking internal destinations when the SSRF guard is enabled, following redirects as configured, limiting the rate
+// of requests to each upstream, caching responses as allowed by their headers when the cache is enabled, and on disk
+// when the dev cache is enabled, and scoping requests to their tenant in multi-tenant mode. Blob storage keeps using the
+// transport directly.
+func newUpstreamClient(c *Container) (*http.Client, error) {
+	cfg := MustGet[*config.Config](c)
+	l := MustGet[*logger.Logger](c)
+
+	base, err := upstreamTransport(c)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := Get[metrics.Recorder](c)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter, err := client.NewRateLimiter(cfg.Client.RateLimits, base, mr)
+	if err != nil {
+		return nil, fmt.Errorf("error creating rate limiter: %w", err)
+	}
+
+	Contribute(c, func(*Container) ([]Reload, error) {
+		return []Reload{{Name: "rate-limits", Apply: func(cfg *config.Config) error { return limiter.Update(cfg.Client.RateLimits) }}}, nil
+	})
+
+	// Hedges count against the rate limits.
+	rt, err := withHedging(&cfg.Client.Hedging, limiter, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := Get[*tenant.Resolver](c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached responses are neither hedged nor count against the rate limits.
+	rt, err = withHTTPCache(c, rt, tr, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err = withDevCache(&cfg.Client.DevCache, rt, l)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tenant header is set before the caches, so that tenants never share cached responses.
+	if tr.Multi() {
+		rt = tenant.NewTransport(tr.Header(), rt)
+	}
+
+	return &http.Client{Transport: rt, CheckRedirect: client.CheckRedirect(&cfg.Client.Redirects)}, nil
+}
+
+// upstreamTransport returns the fake upstream in mock upstream mode, and the transport otherwise.
+func upstreamTransport(c *Container) (http.RoundTripper, error) {
+	cfg := &MustGet[*config.Config](c).Client
+
+	if cfg.MockUpstream.Enabled {
+		MustGet[*logger.Logger](c).Warn("upstream requests are answered with fake data", zap.Int64("seed", cfg.MockUpstream.Seed))
+		return fake.NewTransport(fake.NewUpstream(fake.NewGenerator(cfg.MockUpstream.Seed))), nil
+	}
+
+	transport, err := Get[*http.Transport](c)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver, err := Get[*client.Resolver](c)
+	if err != nil {
+		return nil, err
+	}
+
+	return withSSRFGuard(&cfg.SSRFGuard, transport, resolver)
+}
+
+func withSSRFGuard(cfg *config.SSRFGuard, transport *http.Transport, resolver *client.Resolver) (http.RoundTripper, error) {
+	if !cfg.Enabled {
+		return transport, nil
+	}
+
+	guard, err := client.NewSSRFGuard(cfg, transport, resolver)
+	if err != nil {
+		return nil, fmt.Errorf("error creating SSRF guard: %w", err)
+	}
+
+	return guard, nil
+}
+
+func withHedging(cfg *config.Hedging, next http.RoundTripper, mr metrics.Recorder) (http.RoundTripper, error) {
+	if !cfg.Enabled {
+		return next, nil
+	}
+
+	h, err := client.NewHedger(cfg, next, mr)
+	if err != nil {
+		return nil, fmt.Errorf("error creating hedger: %w", err)
+	}
+
+	return h, nil
+}
+
+func withHTTPCache(c *Container, next http.RoundTripper, tr *tenant.Resolver, mr metrics.Recorder) (http.RoundTripper, error) {
+	cfg := &MustGet[*config.Config](c).Client.Cache
+	if cfg.Store == "" {
+		return next, nil
+	}
+
+	store, err := newCacheStore(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []client.CacheOption
+	if cfg.RevalidationTTL > 0 {
+		opts = append(opts, client.WithRevalidationTTL(cfg.RevalidationTTL))
+	}
+
+	if tr.Multi() {
+		opts = append(opts, client.WithPartitionHeaders(tr.Header()))
+	}
+
+	return client.NewHTTPCache(store, next, mr, opts...), nil
+}
+
+func withDevCache(cfg *config.DevCache, next http.RoundTripper, l *logger.Logger) (http.RoundTripper, error) {
+	if cfg.Dir == "" {
+		return next, nil
+	}
+
+	cache, err := client.NewDiskCache(cfg.Dir, cfg.TTL, next)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dev cache: %w", err)
+	}
+
+	l.Warn("upstream responses are cached on disk, do not enable the dev cache in production", zap.String("dir", cfg.Dir))
+
+	return cache, nil
+}
+
+func newCacheStore(c *Container, cfg *config.HTTPCache) (client.CacheStore, error) {
+	switch cfg.Store {
+	case "memory":
+		return client.NewMemoryStore(cfg.MaxEntries), nil
+	case "redis":
+		rdb, err := Get[*goredis.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.NewRedisStore(rdb), nil
+	default:
+		return nil, fmt.Errorf("unknown cache store %q", cfg.Store)
+	}
+}
+
+// newDeletionStore creates the store of the soft-deleted photos, or nil if soft delete is disabled.
+func newDeletionStore(c *Container) (photos.DeletionStore, error) {
+	cfg := &MustGet[*config.Config](c).Photos.SoftDelete
+
+	switch cfg.Store {
+	case "":
+		return nil, nil
+	case "memory":
+		return photos.NewMemoryDeletions(), nil
+	case "redis":
+		rdb, err := Get[*goredis.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return photos.NewRedisDeletions(rdb), nil
+	default:
+		return nil, fmt.Errorf("unknown soft delete store %q", cfg.Store)
+	}
+}
+
+// newPhotoFallback creates the fallback of the photos service on upstream failures, or nil if it is disabled.
+func newPhotoFallback(c *Container) (*photos.Fallback, error) {
+	cfg := &MustGet[*config.Config](c).Photos.Fallback
+
+	var store photos.FallbackStore
+
+	switch cfg.Store {
+	case "":
+		return nil, nil
+	case "memory":
+		store = photos.NewMemoryFallback()
+	case "redis":
+		rdb, err := Get[*goredis.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		store = photos.NewRedisFallback(rdb)
+	default:
+		return nil, fmt.Errorf("unknown photo fallback store %q", cfg.Store)
+	}
+
+	ps, err := Get[*photos.Service](c)
+	if err != nil {
+		return nil, err
+	}
+
+	return photos.NewFallback(ps, store, cfg.MaxStaleness), nil
+}
+
+// softDeletePolicy returns the policy of the soft delete routes, or nil if soft delete is disabled.
+func softDeletePolicy(c *Container) (*auth.Policy, error) {
+	cfg := &MustGet[*config.Config](c).Photos.SoftDelete
+	if cfg.Store == "" {
+		return nil, nil
+	}
+
+	if len(cfg.AdminRoles) == 0 {
+		return nil, errors.New("soft delete requires admin roles")
+	}
+
+	return &auth.Policy{Name: "photos-admin", Roles: cfg.AdminRoles}, nil
+}
+
+// softDeleteRoutes returns the routes deleting and restoring photos, restricted to the admin roles, or none if soft
+// delete is disabled.
+func softDeleteRoutes(c *Container) ([]server.RouteParam, error) {
+	policy, err := softDeletePolicy(c)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+
+	ps, err := Get[*photos.Service](c)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := MustGet[*config.Config](c)
+	l := MustGet[*logger.Logger](c)
+
+	return []server.RouteParam{
+		{Method: http.MethodDelete, Path: "/photos/:id", Handler: api.DeletePhoto(&cfg.Server, ps, l), Auth: auth.ModeRequired, Policy: policy},
+		{Method: http.MethodPost, Path: "/photos/:id/restore", Handler: api.RestorePhoto(&cfg.Server, ps, l), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
+
+// exportRoutes returns the photo export route, restricted to the admin roles, or none if export is disabled.
+func exportRoutes(c *Container) ([]server.RouteParam, error) {
+	cfg := &MustGet[*config.Config](c).Photos.Export
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if len(cfg.AdminRoles) == 0 {
+		return nil, errors.New("photo export requires admin roles")
+	}
+
+	ps, err := Get[*photos.Service](c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &auth.Policy{Name: "photos-export", Roles: cfg.AdminRoles}
+
+	return []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/export", Handler: api.ExportPhotos(ps, MustGet[*logger.Logger](c)), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/profiling"
+)
+
+// profilingModule provides the continuous profiling, or nil if it is disabled. It is constructed by Run.
+func profilingModule(c *Container) {
+	Provide(c, func(c *Container) (*profiling.Profiler, error) {
+		cfg := &MustGet[*config.Config](c).Profiling
+		if cfg.Backend == "" {
+			return nil, nil
+		}
+
+		httpClient, err := Get[*http.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := profiling.New(cfg, httpClient, MustGet[*logger.Logger](c))
+		if err != nil {
+			return nil, fmt.Errorf("error creating profiler: %w", err)
+		}
+
+		lc, err := Get[*lifecycle.Lifecycle](c)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Go("profiling", p.Run)
+
+		return p, nil
+	})
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Reload applies a changed configuration to a running component. It is contributed by the constructors of the
+// components whose settings can change without a restart, such as the feature flags.
+type Reload struct {
+	// Name names the component in the logs.
+	Name  string
+	Apply func(cfg *config.Config) error
+}
+
+// watchRemote polls the remote configuration when it is watched, applying its changes with the contributed reloads.
+// It is called once the components of the role are constructed, with the *config.Viper supplied by the command.
+func watchRemote(c *Container) error {
+	cfg := &MustGet[*config.Config](c).Remote
+	if cfg.Provider == "" || !cfg.Watch {
+		return nil
+	}
+
+	v, err := Get[*config.Viper](c)
+	if err != nil {
+		return err
+	}
+
+	reloads, err := All[Reload](c)
+	if err != nil {
+		return err
+	}
+
+	lc, err := Get[*lifecycle.Lifecycle](c)
+	if err != nil {
+		return err
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = config.DefaultRemoteInterval
+	}
+
+	l := MustGet[*logger.Logger](c)
+
+	lc.Go("remote-config", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reload(ctx, v, reloads, l)
+			}
+		}
+	})
+
+	return nil
+}
+
+// reload applies the remote configuration with reloads if it changed. The settings without a reload keep their value
+// until the next restart.
+func reload(ctx context.Context, v *config.Viper, reloads []Reload, l *logger.Logger) {
+	cfg, err := v.ReloadRemote(ctx)
+	if err != nil {
+		l.Warn("Failed to reload remote configuration", zap.Error(err))
+		return
+	}
+
+	if cfg == nil {
+		return
+	}
+
+	for _, r := range reloads {
+		if err = r.Apply(cfg); err != nil {
+			l.Warn("Failed to apply remote configuration", zap.String("component", r.Name), zap.Error(err))
+		}
+	}
+
+	l.Info("Remote configuration applied", zap.Int("components", len(reloads)))
+}
@@ -0,0 +1,78 @@
+package app_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+)
+
+func TestRunRole_RemoteWatch(t *testing.T) {
+	t.Parallel()
+
+	var doc atomic.Value
+
+	doc.Store("features:\n  flags:\n    new_search: false\n")
+
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(doc.Load().(string)))
+	}))
+	t.Cleanup(consul.Close)
+
+	v := config.NewViper()
+	v.Viper.Set("config_path", "testdata/missing.yaml")
+	v.Viper.Set("remote", map[string]any{"provider": config.RemoteConsul, "endpoint": consul.URL, "key": "config", "watch": true, "interval": "5ms"})
+
+	cfg, err := v.BuildConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	st, err := strictness.New("")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := app.New(cfg, st, logger.NewNop())
+	app.Supply(c, v)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	applied := make(chan *config.Config, 1)
+
+	app.Contribute(c, func(*app.Container) ([]app.Reload, error) {
+		return []app.Reload{{Name: "test", Apply: func(cfg *config.Config) error {
+			applied <- cfg
+			cancel()
+
+			return nil
+		}}}, nil
+	})
+	app.Contribute(c, func(*app.Container) ([]app.Task, error) {
+		return []app.Task{{Role: app.RoleWorker, Name: "worker", Run: func(ctx context.Context) error {
+			doc.Store("features:\n  flags:\n    new_search: true\n")
+			<-ctx.Done()
+
+			return nil
+		}}}, nil
+	})
+
+	assert.NoError(t, app.RunRole(ctx, c, app.RoleWorker))
+
+	select {
+	case cfg := <-applied:
+		assert.Equal(t, map[string]bool{"new_search": true}, cfg.Features.Flags)
+	default:
+		assert.Fail(t, "the change was not applied")
+	}
+}
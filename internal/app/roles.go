@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/election"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+)
+
+// Roles of the processes of the service, each run by the subcommand of the same name, so that the same binary serves
+// the API and runs the background work in separate deployments.
+const (
+	RoleServe     = "serve"
+	RoleWorker    = "worker"
+	RoleScheduler = "scheduler"
+	RoleConsume   = "consume"
+)
+
+// ErrNoTasks is returned when running a role no module contributed a task to, as the process would do nothing.
+var ErrNoTasks = errors.New("no tasks")
+
+// Task is background work contributed by a module to the processes of a role other than RoleServe.
+type Task struct {
+	// Role is RoleWorker, RoleScheduler or RoleConsume.
+	Role string
+	// Name names the task in the logs and the lifecycle.
+	Name string
+	// Every runs the task at this interval, on the leader replica only when the leader election is enabled. Zero runs
+	// the task once, until ctx is done.
+	Every time.Duration
+	Run   func(ctx context.Context) error
+}
+
+// RunRole starts the components shared by the roles, then runs role until ctx is done and shuts the components down:
+// RoleServe serves the API, the other roles run the tasks contributed to them.
+func RunRole(ctx context.Context, c *Container, role string) error {
+	// The telemetry is constructed first, so that it exports the logs of the other components until they are stopped,
+	// then the profiling and the watchdog. The leader election is next, so that the replica resigns once the rest is stopped.
+	if err := start(c); err != nil {
+		return err
+	}
+
+	if role == RoleServe {
+		return serve(ctx, c)
+	}
+
+	tasks, err := All[Task](c)
+	if err != nil {
+		return err
+	}
+
+	e, err := Get[*election.Elector](c)
+	if err != nil {
+		return err
+	}
+
+	lc, err := Get[*lifecycle.Lifecycle](c)
+	if err != nil {
+		return err
+	}
+
+	l := MustGet[*logger.Logger](c)
+	n := 0
+
+	for _, t := range tasks {
+		if t.Role != role {
+			continue
+		}
+
+		lc.Go(t.Name, t.loop(e, l))
+
+		n++
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w for role %s", ErrNoTasks, role)
+	}
+
+	if err = watchRemote(c); err != nil {
+		return err
+	}
+
+	l.Info("Running tasks", zap.String("role", role), zap.Int("tasks", n))
+
+	// The tasks are not behind a load balancer, so the components are stopped without draining once ctx is done.
+	return lc.Run(context.WithoutCancel(ctx), func() error { //nolint:wrapcheck // wrapped by the caller
+		<-ctx.Done()
+		return nil
+	})
+}
+
+// loop returns the function running t as a lifecycle component, logging its failures.
+func (t Task) loop(e *election.Elector, l *logger.Logger) func(ctx context.Context) {
+	run := func(ctx context.Context) {
+		start := time.Now()
+
+		if err := t.Run(ctx); err != nil && ctx.Err() == nil {
+			l.Error("Task failed", zap.String("task", t.Name), zap.Duration("duration", time.Since(start)), zap.Error(err))
+		}
+	}
+
+	if t.Every <= 0 {
+		return run
+	}
+
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(t.Every)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if e == nil || e.IsLeader() {
+					run(ctx)
+				}
+			}
+		}
+	}
+}
+
+// Validate constructs the components of the service configured by cfg, as serve and the other roles do, then stops
+// them without serving, returning the errors of the configuration found by their constructors. As the components are
+// constructed, it needs the files, credentials and cluster of the deployment.
+func Validate(cfg *config.Config, st strictness.Settings, l *logger.Logger) error {
+	c := New(cfg, st, l)
+
+	var errs []error
+
+	if err := start(c); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := Get[*server.Server](c); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := All[Task](c); err != nil {
+		errs = append(errs, err)
+	}
+
+	lc, err := Get[*lifecycle.Lifecycle](c)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	// Nothing is served, so the components are stopped at once, without draining.
+	errs = append(errs, lc.Run(context.Background(), func() error { return nil }))
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+)
+
+func TestRunRole(t *testing.T) {
+	t.Parallel()
+
+	st, err := strictness.New("")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := app.New(&config.Config{}, st, logger.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan string, 2)
+
+	app.Contribute(c, func(*app.Container) ([]app.Task, error) {
+		return []app.Task{
+			{Role: app.RoleWorker, Name: "worker", Run: func(context.Context) error {
+				ran <- "worker"
+				cancel()
+
+				return nil
+			}},
+			{Role: app.RoleScheduler, Name: "scheduled", Every: time.Millisecond, Run: func(context.Context) error {
+				ran <- "scheduled"
+				return nil
+			}},
+		}, nil
+	})
+
+	assert.NoError(t, app.RunRole(ctx, c, app.RoleWorker))
+	assert.Equal(t, "worker", <-ran)
+	assert.Empty(t, ran, "the tasks of the other roles are not run")
+
+	assert.ErrorIs(t, app.RunRole(context.Background(), c, app.RoleConsume), app.ErrNoTasks)
+}
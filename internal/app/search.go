@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/search"
+	"github.com/twk/skeleton-go-api/internal/search/es"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// searchModule provides the photo search, in memory or in Elasticsearch, with its route.
+func searchModule(c *Container) {
+	Provide(c, newSearchClient)
+
+	// The indexer keeps the Elasticsearch index up to date with the changes made through the photos service.
+	Provide(c, func(c *Container) (*es.Indexer, error) {
+		esClient, err := Get[*es.Client](c)
+		if err != nil || esClient == nil {
+			return nil, err
+		}
+
+		ix := es.NewIndexer(esClient, &MustGet[*config.Config](c).Photos.Search.Elasticsearch, MustGet[*logger.Logger](c))
+
+		lc, err := Get[*lifecycle.Lifecycle](c)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Go("search-indexer", ix.Run)
+
+		return ix, nil
+	})
+
+	Contribute(c, func(c *Container) ([]photos.Listener, error) {
+		ix, err := Get[*es.Indexer](c)
+		if err != nil || ix == nil {
+			return nil, err
+		}
+
+		return []photos.Listener{ix}, nil
+	})
+
+	Contribute(c, reindexTask)
+
+	Contribute(c, func(c *Container) ([]server.RouteParam, error) {
+		cfg := MustGet[*config.Config](c)
+		if !cfg.Photos.Search.Enabled {
+			return nil, nil
+		}
+
+		searcher, err := newSearcher(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return []server.RouteParam{
+			{Method: http.MethodGet, Path: "/photos/search", Handler: api.SearchPhotos(&cfg.Server, searcher, MustGet[*logger.Logger](c))},
+		}, nil
+	})
+}
+
+// newSearchClient creates the client of the Elasticsearch search backend, or nil with another backend or when search
+// is disabled.
+func newSearchClient(c *Container) (*es.Client, error) {
+	cfg := &MustGet[*config.Config](c).Photos.Search
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Backend {
+	case "", "memory":
+		return nil, nil
+	case "elasticsearch":
+		transport, err := Get[*http.Transport](c)
+		if err != nil {
+			return nil, err
+		}
+
+		esClient, err := es.New(&cfg.Elasticsearch, transport)
+		if err != nil {
+			return nil, fmt.Errorf("error creating search backend: %w", err)
+		}
+
+		return esClient, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", cfg.Backend)
+	}
+}
+
+// newSearcher returns the Elasticsearch client, or an index of the photos built in memory with the other backend.
+func newSearcher(c *Container) (search.Searcher, error) {
+	esClient, err := Get[*es.Client](c)
+	if err != nil {
+		return nil, err
+	}
+
+	if esClient != nil {
+		return esClient, nil
+	}
+
+	ps, err := Get[*photos.Service](c)
+	if err != nil {
+		return nil, err
+	}
+
+	return search.NewIndex(ps, MustGet[*logger.Logger](c), MustGet[*config.Config](c).Photos.Search.Refresh), nil
+}
+
+// reindexTask schedules the reindex of the photos, recovering the changes the servers could not index, when the
+// Elasticsearch backend has a reindex interval.
+func reindexTask(c *Container) ([]Task, error) {
+	cfg := &MustGet[*config.Config](c).Photos.Search.Elasticsearch
+	if cfg.ReindexInterval <= 0 {
+		return nil, nil
+	}
+
+	esClient, err := Get[*es.Client](c)
+	if err != nil || esClient == nil {
+		return nil, err
+	}
+
+	tr, err := Get[*tenant.Resolver](c)
+	if err != nil {
+		return nil, err
+	}
+
+	if tr.Multi() {
+		return nil, errors.New("scheduled reindex is not supported in multi-tenant mode")
+	}
+
+	ps, err := Get[*photos.Service](c)
+	if err != nil {
+		return nil, err
+	}
+
+	l := MustGet[*logger.Logger](c)
+
+	return []Task{{
+		Role:  RoleScheduler,
+		Name:  "search-reindex",
+		Every: cfg.ReindexInterval,
+		Run: func(ctx context.Context) error {
+			n, err := es.Reindex(tenant.ContextWithTenant(ctx, tr.Default()), esClient, ps)
+			if err != nil {
+				return fmt.Errorf("error reindexing photos: %w", err)
+			}
+
+			l.Info("Photos reindexed", zap.Int("photos", n))
+
+			return nil
+		},
+	}}, nil
+}
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/chaos"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/features"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/mirror"
+	"github.com/twk/skeleton-go-api/internal/passthrough"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/strictness"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// chaosModule contributes the middleware injecting faults, refused in prod mode by Configure.
+func chaosModule(c *Container) {
+	Contribute(c, func(c *Container) ([]server.Option, error) {
+		cfg := &MustGet[*config.Config](c).Chaos
+		if !cfg.Enabled {
+			return nil, nil
+		}
+
+		inj, err := chaos.New(cfg, MustGet[*logger.Logger](c))
+		if err != nil {
+			return nil, fmt.Errorf("error creating fault injector: %w", err)
+		}
+
+		return []server.Option{server.WithMiddleware(inj.Middleware())}, nil
+	})
+}
+
+// mirrorModule contributes the middleware mirroring requests to the shadow deployment.
+func mirrorModule(c *Container) {
+	Contribute(c, func(c *Container) ([]server.Option, error) {
+		cfg := &MustGet[*config.Config](c).Mirror
+		if cfg.BaseURL == "" {
+			return nil, nil
+		}
+
+		httpClient, err := Get[*http.Client](c)
+		if err != nil {
+			return nil, err
+		}
+
+		mr, err := Get[metrics.Recorder](c)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := mirror.New(cfg, httpClient, mr, MustGet[*logger.Logger](c))
+		if err != nil {
+			return nil, fmt.Errorf("error creating request mirror: %w", err)
+		}
+
+		return []server.Option{server.WithMiddleware(m.Middleware())}, nil
+	})
+}
+
+// serverModule provides the server of the contributed routes, behind the middleware shared by all routes and the
+// contributed ones.
+func serverModule(c *Container) {
+	Provide(c, newServer)
+	Provide(c, newFeatures)
+	Provide(c, func(*Container) (*routeLister, error) { return &routeLister{}, nil })
+	Contribute(c, routeListingRoutes)
+	Contribute(c, configDiffRoutes)
+}
+
+// newFeatures creates the feature flags, updated when the remote configuration changes.
+func newFeatures(c *Container) (*features.Flags, error) {
+	f := features.New(&MustGet[*config.Config](c).Features, MustGet[*logger.Logger](c))
+
+	Contribute(c, func(*Container) ([]Reload, error) {
+		return []Reload{{Name: "features", Apply: func(cfg *config.Config) error {
+			f.Update(&cfg.Features)
+			return nil
+		}}}, nil
+	})
+
+	return f, nil
+}
+
+// routeLister lists the routes of the server, set once constructed as the server is constructed from the routes.
+type routeLister struct {
+	server *server.Server
+}
+
+func (rl *routeLister) Routes() []server.RouteInfo {
+	if rl.server == nil {
+		return nil
+	}
+
+	return rl.server.Routes()
+}
+
+// routeListingRoutes returns the route listing the routes, restricted to the admin roles, or none if disabled.
+func routeListingRoutes(c *Container) ([]server.RouteParam, error) {
+	cfg := &MustGet[*config.Config](c).Server.RouteListing
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if len(cfg.AdminRoles) == 0 {
+		return nil, errors.New("error creating route listing: the route listing requires admin roles")
+	}
+
+	rl, err := Get[*routeLister](c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &auth.Policy{Name: "routes-admin", Roles: cfg.AdminRoles}
+
+	return []server.RouteParam{
+		{Method: http.MethodGet, Path: "/admin/routes", Handler: api.Routes(rl), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
+
+// configDiffRoutes returns the route listing the settings differing from their default, restricted to the admin
+// roles, or none if disabled. It needs the *config.Viper which built the configuration, supplied by the command.
+func configDiffRoutes(c *Container) ([]server.RouteParam, error) {
+	cfg := MustGet[*config.Config](c)
+
+	cdc := api.ConfigDiffSection.Get(cfg)
+	if !cdc.Enabled {
+		return nil, nil
+	}
+
+	v, err := Get[*config.Viper](c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &auth.Policy{Name: "config-admin", Roles: cdc.AdminRoles}
+
+	return []server.RouteParam{
+		{Method: http.MethodGet, Path: "/admin/config/diff", Handler: api.ConfigDiff(v, cfg), Auth: auth.ModeRequired, Policy: policy},
+	}, nil
+}
+
+func newServer(c *Container) (*server.Server, error) {
+	cfg := MustGet[*config.Config](c)
+	l := MustGet[*logger.Logger](c)
+
+	if err := server.ValidatePaths(&cfg.Server.Paths); err != nil {
+		return nil, fmt.Errorf("error configuring paths: %w", err)
+	}
+
+	opts, err := baseOptions(c)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := All[server.RouteParam](c)
+	if err != nil {
+		return nil, err
+	}
+
+	contributed, err := All[server.Option](c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Health checks are contributed by the components constructed for the routes and options.
+	checks, err := All[server.HealthCheck](c)
+	if err != nil {
+		return nil, err
+	}
+
+	lc, err := Get[*lifecycle.Lifecycle](c)
+	if err != nil {
+		return nil, err
+	}
+
+	canaries, err := canaryOptions(cfg.Server.Canaries, l)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLog, err := accessLogOption(&cfg.Server.AccessLog, lc)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, canaries...)
+	opts = append(opts, accessLog...)
+	opts = append(opts, contributed...)
+	opts = append(opts, server.WithHealthChecks(checks...), server.WithReadiness(lc.Ready))
+
+	rl, err := Get[*routeLister](c)
+	if err != nil {
+		return nil, err
+	}
+
+	s := server.NewServer(&cfg.Server, gin.Default(), rp, l, opts...)
+	rl.server = s
+
+	// The server is constructed last, so it is drained first.
+	lc.Register("http-server", s.Shutdown)
+
+	return s, nil
+}
+
+// accessLogOption returns the configured access log, closing its file once the server is drained.
+func accessLogOption(cfg *config.AccessLog, lc *lifecycle.Lifecycle) ([]server.Option, error) {
+	if cfg.Format == "" {
+		return nil, nil
+	}
+
+	var w io.Writer
+
+	switch cfg.Output {
+	case "", "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	default:
+		f, err := os.OpenFile(cfg.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gomnd // file mode
+		if err != nil {
+			return nil, fmt.Errorf("error opening access log: %w", err)
+		}
+
+		lc.Register("access-log", func(context.Context) error {
+			if closeErr := f.Close(); closeErr != nil {
+				return fmt.Errorf("error closing access log: %w", closeErr)
+			}
+
+			return nil
+		})
+
+		w = f
+	}
+
+	a, err := server.NewAccessLog(w, cfg.Format, cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("error creating access log: %w", err)
+	}
+
+	return []server.Option{server.WithAccessLog(a)}, nil
+}
+
+// canaryOptions returns the configured canaries, proxied to their upstream.
+func canaryOptions(canaries []config.Canary, l *logger.Logger) ([]server.Option, error) {
+	if len(canaries) == 0 {
+		return nil, nil
+	}
+
+	rcs := make([]server.RouteCanary, 0, len(canaries))
+
+	for _, cc := range canaries {
+		if cc.Percent < 0 || cc.Percent > 100 { //nolint:gomnd // percent
+			return nil, fmt.Errorf("canary %s %s: percent %g is not between 0 and 100", cc.Method, cc.Path, cc.Percent)
+		}
+
+		proxy, err := server.Proxy(cc.Upstream.String(), l)
+		if err != nil {
+			return nil, fmt.Errorf("canary %s %s: %w", cc.Method, cc.Path, err)
+		}
+
+		rcs = append(rcs, server.RouteCanary{
+			Method: strings.ToUpper(cc.Method),
+			Path:   cc.Path,
+			Canary: server.Canary{Name: cc.Name, Handler: proxy, Percent: cc.Percent, Header: cc.Header, Cookie: cc.Cookie},
+		})
+	}
+
+	return []server.Option{server.WithCanaries(rcs...)}, nil
+}
+
+// baseOptions returns the middleware resolving the client IP, recording the latencies, sizes and errors, forwarding headers and
+// evaluating feature flags, the contributed authenticators and the authorizer.
+func baseOptions(c *Container) ([]server.Option, error) {
+	cfg := MustGet[*config.Config](c)
+	l := MustGet[*logger.Logger](c)
+
+	ipr, err := clientip.NewResolver(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("error creating client ip resolver: %w", err)
+	}
+
+	runbooks, err := apperror.NewRunbooks(&cfg.Errors)
+	if err != nil {
+		return nil, fmt.Errorf("error creating runbooks: %w", err)
+	}
+
+	errOpts := []apperror.MiddlewareOption{apperror.WithRunbooks(runbooks)}
+	if MustGet[strictness.Settings](c).VerboseErrors {
+		errOpts = append(errOpts, apperror.WithVerboseErrors())
+	}
+
+	mr, err := Get[metrics.Recorder](c)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := Get[*tenant.Resolver](c)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticators, err := All[auth.Authenticator](c)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizer, err := Get[*auth.Authorizer](c)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := Get[*features.Flags](c)
+	if err != nil {
+		return nil, err
+	}
+
+	return []server.Option{
+		server.WithMiddleware(
+			ipr.Middleware(),
+			metrics.DurationMiddleware(mr),
+			metrics.SizeMiddleware(mr),
+			apperror.Middleware(mr, l, metrics.ConfigSection.Get(cfg).ErrorExemplarInterval, errOpts...),
+			passthrough.NewPolicy(&cfg.HeaderPassthrough).Middleware(),
+			flags.Middleware(),
+		),
+		server.WithAuthenticators(authenticators...),
+		server.WithAuthorizer(authorizer),
+		server.WithRouteMiddleware(tr.Middleware()),
+		server.WithMetrics(mr),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/api"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+	"github.com/twk/skeleton-go-api/internal/storage"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// storageModule contributes the routes of the blob store.
+func storageModule(c *Container) {
+	Contribute(c, storageRoutes)
+}
+
+// storageRoutes creates the blob store and returns the routes depending on it, or none if storage is disabled. In
+// multi-tenant mode, the objects are scoped to the tenant of the requests.
+func storageRoutes(c *Container) ([]server.RouteParam, error) {
+	cfg := MustGet[*config.Config](c)
+	if cfg.Storage.Backend == "" {
+		return nil, nil
+	}
+
+	httpClient, err := Get[*http.Client](c)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := Get[*tenant.Resolver](c)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := storage.New(&cfg.Storage, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("error creating blob store: %w", err)
+	}
+
+	bs := backend
+	if tr.Multi() {
+		bs = tenant.NewBlobStore(backend)
+	}
+
+	l := MustGet[*logger.Logger](c)
+	rp := []server.RouteParam{
+		{Method: http.MethodPost, Path: "/photos/:id/image", Handler: api.UploadPhotoImage(&cfg.Server, &cfg.Storage, bs, l)},
+		{Method: http.MethodGet, Path: "/photos/:id/image", Handler: api.PhotoImage(&cfg.Server, &cfg.Storage, bs, l)},
+		{Method: http.MethodPost, Path: "/uploads", Handler: api.Uploads(&cfg.Server, &cfg.Uploads, bs, l)},
+	}
+
+	// The local store serves its own presigned URLs.
+	if local, ok := backend.(*storage.Local); ok {
+		rp = append(rp, server.RouteParam{Method: http.MethodGet, Path: local.Prefix() + "/*key", Handler: gin.WrapH(http.StripPrefix(local.Prefix(), local)), Auth: auth.ModeNone})
+	}
+
+	return rp, nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/telemetry"
+)
+
+// telemetryModule provides the export of the metrics and logs to an OpenTelemetry collector, or nil if it is
+// disabled. It is constructed first by Run, so that the logs of the other components are exported and flushed last.
+func telemetryModule(c *Container) {
+	Provide(c, func(c *Container) (*telemetry.Telemetry, error) {
+		cfg := telemetry.ConfigSection.Get(MustGet[*config.Config](c))
+		if cfg.Endpoint == "" || (!cfg.Metrics.Enabled && !cfg.Logs.Enabled) {
+			return nil, nil
+		}
+
+		lc, err := Get[*lifecycle.Lifecycle](c)
+		if err != nil {
+			return nil, err
+		}
+
+		mr, err := Get[*metrics.Registry](c)
+		if err != nil {
+			return nil, err
+		}
+
+		// A nil registry would not be a nil gatherer.
+		if cfg.Metrics.Enabled && mr == nil {
+			return nil, fmt.Errorf("error creating telemetry: %w", telemetry.ErrNoGatherer)
+		}
+
+		l := MustGet[*logger.Logger](c)
+
+		// Failed exports are reported by the logger as it was, before its entries are exported too.
+		t, err := telemetry.New(cfg, mr, &logger.Logger{Logger: l.Logger})
+		if err != nil {
+			return nil, fmt.Errorf("error creating telemetry: %w", err)
+		}
+
+		if core := t.Core(l.Core()); core != nil {
+			l.Tee(core)
+		}
+
+		lc.Register("telemetry", t.Shutdown)
+
+		return t, nil
+	})
+}
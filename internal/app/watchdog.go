@@ -0,0 +1,34 @@
+package app
+
+import (
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/watchdog"
+)
+
+// watchdogModule provides the resource leak watchdog, or nil if it is disabled. It is constructed by Run.
+func watchdogModule(c *Container) {
+	Provide(c, func(c *Container) (*watchdog.Watchdog, error) {
+		cfg := watchdog.ConfigSection.Get(MustGet[*config.Config](c))
+		if !cfg.Enabled {
+			return nil, nil
+		}
+
+		rec, err := Get[metrics.Recorder](c)
+		if err != nil {
+			return nil, err
+		}
+
+		lc, err := Get[*lifecycle.Lifecycle](c)
+		if err != nil {
+			return nil, err
+		}
+
+		w := watchdog.New(cfg, rec, MustGet[*logger.Logger](c))
+		lc.Go("watchdog", w.Run)
+
+		return w, nil
+	})
+}
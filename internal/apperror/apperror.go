@@ -0,0 +1,74 @@
+// Package apperror classifies the errors returned by the API so they can be counted and logged per class. Handlers
+// wrap errors with the constructor of their class and record them on the gin context, the Middleware counts them per
+// class and route and logs sampled exemplars.
+package apperror
+
+import (
+	"errors"
+)
+
+// Class is the category of an error.
+type Class string
+
+// Error classes.
+const (
+	// ClassValidation is an invalid request from the API consumer.
+	ClassValidation Class = "validation"
+	// ClassAuth is a request failing authentication or authorization.
+	ClassAuth Class = "auth"
+	// ClassUpstream is a failure of an upstream API.
+	ClassUpstream Class = "upstream"
+	// ClassDB is a failure of a database or blob store.
+	ClassDB Class = "db"
+	// ClassInternal is any other failure. Unclassified errors are internal.
+	ClassInternal Class = "internal"
+)
+
+// Error is an error with a Class.
+type Error struct {
+	Class Class
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Validation classifies err as a validation error.
+func Validation(err error) error {
+	return &Error{Class: ClassValidation, Err: err}
+}
+
+// Auth classifies err as an auth error.
+func Auth(err error) error {
+	return &Error{Class: ClassAuth, Err: err}
+}
+
+// Upstream classifies err as an upstream error.
+func Upstream(err error) error {
+	return &Error{Class: ClassUpstream, Err: err}
+}
+
+// DB classifies err as a database error.
+func DB(err error) error {
+	return &Error{Class: ClassDB, Err: err}
+}
+
+// Internal classifies err as an internal error.
+func Internal(err error) error {
+	return &Error{Class: ClassInternal, Err: err}
+}
+
+// ClassOf returns the class of the outermost classified error in the chain of err, or ClassInternal.
+func ClassOf(err error) Class {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Class
+	}
+
+	return ClassInternal
+}
@@ -0,0 +1,41 @@
+package apperror_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/apperror"
+)
+
+func TestClassOf(t *testing.T) {
+	t.Parallel()
+
+	base := errors.New("boom")
+
+	tests := map[string]struct {
+		err  error
+		want apperror.Class
+	}{
+		"validation":   {err: apperror.Validation(base), want: apperror.ClassValidation},
+		"auth":         {err: apperror.Auth(base), want: apperror.ClassAuth},
+		"upstream":     {err: apperror.Upstream(base), want: apperror.ClassUpstream},
+		"db":           {err: apperror.DB(base), want: apperror.ClassDB},
+		"internal":     {err: apperror.Internal(base), want: apperror.ClassInternal},
+		"wrapped":      {err: fmt.Errorf("context: %w", apperror.Upstream(base)), want: apperror.ClassUpstream},
+		"outermost":    {err: apperror.Validation(apperror.Upstream(base)), want: apperror.ClassValidation},
+		"unclassified": {err: base, want: apperror.ClassInternal},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, apperror.ClassOf(tt.err))
+			assert.ErrorIs(t, tt.err, base)
+		})
+	}
+}
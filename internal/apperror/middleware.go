@@ -0,0 +1,123 @@
+package apperror
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// ErrorsMetric counts the errors recorded on requests by class and route.
+const ErrorsMetric = "app_errors_total"
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+}
+
+// MiddlewareOption configures optional behaviour of the Middleware.
+type MiddlewareOption func(*middleware)
+
+type middleware struct {
+	runbooks *Runbooks
+	verbose  bool
+}
+
+// WithRunbooks logs the runbook of their class with the exemplars, and makes the runbooks available to the error
+// responses of internal requests through InternalRunbook.
+func WithRunbooks(r *Runbooks) MiddlewareOption {
+	return func(m *middleware) {
+		m.runbooks = r
+	}
+}
+
+// WithVerboseErrors lets the error responses include the cause of the errors, through Verbose. It discloses internals
+// and is meant for development.
+func WithVerboseErrors() MiddlewareOption {
+	return func(m *middleware) {
+		m.verbose = true
+	}
+}
+
+type verboseKey struct{}
+
+// Verbose reports whether the error responses of the request of ctx include the cause of the errors.
+func Verbose(ctx context.Context) bool {
+	v, _ := ctx.Value(verboseKey{}).(bool)
+	return v
+}
+
+// Middleware counts the errors recorded on the gin context with c.Error by class and route, and logs one exemplar per
+// class every interval with the number of errors suppressed since the previous one. A zero interval logs every error.
+func Middleware(rec recorder, l *logger.Logger, interval time.Duration, opts ...MiddlewareOption) gin.HandlerFunc {
+	s := newSampler(interval, time.Now)
+
+	m := &middleware{}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(c *gin.Context) {
+		if m.runbooks != nil {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), runbooksKey{}, m.runbooks))
+		}
+
+		if m.verbose {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), verboseKey{}, true))
+		}
+
+		c.Next()
+
+		route := metrics.RouteLabel(c)
+
+		for _, ge := range c.Errors {
+			class := ClassOf(ge.Err)
+			rec.Inc(ErrorsMetric, metrics.Labels{"class": string(class), "route": route})
+
+			if ok, suppressed := s.allow(class); ok {
+				fields := []zap.Field{zap.String("class", string(class)), zap.String("route", route),
+					zap.Int("status", c.Writer.Status()), zap.Int("suppressed", suppressed), zap.Error(ge.Err)}
+				if rb, found := m.runbooks.For(ge.Err); found {
+					fields = append(fields, rb.fields()...)
+				}
+
+				l.Error("request error", fields...)
+			}
+		}
+	}
+}
+
+// sampler lets one event per class through every interval and counts the others.
+type sampler struct {
+	mu         sync.Mutex
+	interval   time.Duration
+	now        func() time.Time
+	last       map[Class]time.Time
+	suppressed map[Class]int
+}
+
+func newSampler(interval time.Duration, now func() time.Time) *sampler {
+	return &sampler{interval: interval, now: now, last: map[Class]time.Time{}, suppressed: map[Class]int{}}
+}
+
+// allow reports whether an event of class is let through, and how many were suppressed since the last one.
+func (s *sampler) allow(class Class) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if last, ok := s.last[class]; ok && now.Sub(last) < s.interval {
+		s.suppressed[class]++
+		return false, 0
+	}
+
+	suppressed := s.suppressed[class]
+	s.last[class] = now
+	s.suppressed[class] = 0
+
+	return true, suppressed
+}
@@ -0,0 +1,86 @@
+package apperror_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type counter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *counter) Inc(_ string, labels metrics.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[labels["class"]+" "+labels["route"]]++
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		interval time.Duration
+	}
+
+	type want struct {
+		logs int
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"one exemplar per class per interval": {
+			args: args{interval: time.Hour},
+			want: want{logs: 2},
+		},
+		"zero interval logs every error": {
+			args: args{interval: 0},
+			want: want{logs: 4},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.ErrorLevel)
+			rec := &counter{counts: map[string]int{}}
+
+			router := gin.New()
+			router.Use(apperror.Middleware(rec, &logger.Logger{Logger: zap.New(core)}, tt.args.interval))
+			router.GET("/photos/:id", func(c *gin.Context) {
+				c.Error(apperror.Upstream(errors.New("upstream down")))
+				c.Status(http.StatusBadGateway)
+			})
+			router.GET("/bad", func(c *gin.Context) {
+				c.Error(apperror.Validation(errors.New("invalid id")))
+				c.Status(http.StatusBadRequest)
+			})
+
+			for _, path := range []string{"/photos/1", "/photos/2", "/photos/3", "/bad"} {
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, http.NoBody))
+			}
+
+			assert.Equal(t, map[string]int{"upstream /photos/:id": 3, "validation /bad": 1}, rec.counts)
+			assert.Equal(t, tt.want.logs, logs.Len())
+			assert.Equal(t, "upstream", logs.All()[0].ContextMap()["class"])
+		})
+	}
+}
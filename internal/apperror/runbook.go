@@ -0,0 +1,89 @@
+package apperror
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Runbook points on-call engineers to the remediation steps of a class of errors.
+type Runbook struct {
+	ID  string `json:"id,omitempty"`
+	URL string `json:"url,omitempty"`
+}
+
+func (r Runbook) fields() []zap.Field {
+	return []zap.Field{zap.String("runbook_id", r.ID), zap.String("runbook_url", r.URL)}
+}
+
+// Runbooks holds the runbooks of the error classes, and the internal networks whose requests may see them in error
+// responses. A nil *Runbooks has none.
+type Runbooks struct {
+	byClass  map[Class]Runbook
+	internal []*net.IPNet
+}
+
+// NewRunbooks creates the Runbooks of cfg.
+func NewRunbooks(cfg *config.Errors) (*Runbooks, error) {
+	r := &Runbooks{byClass: make(map[Class]Runbook, len(cfg.Runbooks))}
+
+	for class, rb := range cfg.Runbooks {
+		r.byClass[Class(class)] = Runbook{ID: rb.ID, URL: rb.URL}
+	}
+
+	for _, cidr := range cfg.InternalNetworks {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid internal network cidr %s: %w", cidr, err)
+		}
+
+		r.internal = append(r.internal, n)
+	}
+
+	return r, nil
+}
+
+// For returns the runbook of the class of err.
+func (r *Runbooks) For(err error) (Runbook, bool) {
+	if r == nil {
+		return Runbook{}, false
+	}
+
+	rb, ok := r.byClass[ClassOf(err)]
+
+	return rb, ok
+}
+
+// isInternal reports whether the client IP carried by ctx is in an internal network.
+func (r *Runbooks) isInternal(ctx context.Context) bool {
+	ip := net.ParseIP(clientip.FromContext(ctx))
+	if r == nil || ip == nil {
+		return false
+	}
+
+	for _, n := range r.internal {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type runbooksKey struct{}
+
+// InternalRunbook returns the runbook of the class of err to include in the error response of the request of ctx. It
+// is only returned to requests from the internal networks, through the Middleware, as runbooks disclose internals.
+func InternalRunbook(ctx context.Context, err error) (Runbook, bool) {
+	r, _ := ctx.Value(runbooksKey{}).(*Runbooks)
+	if !r.isInternal(ctx) {
+		return Runbook{}, false
+	}
+
+	return r.For(err)
+}
@@ -0,0 +1,94 @@
+package apperror_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewRunbooks(t *testing.T) {
+	t.Parallel()
+
+	_, err := apperror.NewRunbooks(&config.Errors{InternalNetworks: []string{"10.0.0.0"}})
+	assert.ErrorContains(t, err, "invalid internal network cidr 10.0.0.0")
+
+	r, err := apperror.NewRunbooks(&config.Errors{Runbooks: map[string]config.Runbook{"upstream": {ID: "RB-1", URL: "https://runbooks/upstream"}}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	rb, ok := r.For(apperror.Upstream(errors.New("upstream down")))
+	assert.True(t, ok)
+	assert.Equal(t, apperror.Runbook{ID: "RB-1", URL: "https://runbooks/upstream"}, rb)
+
+	_, ok = r.For(apperror.Validation(errors.New("invalid id")))
+	assert.False(t, ok)
+
+	var none *apperror.Runbooks
+
+	_, ok = none.For(apperror.Upstream(errors.New("upstream down")))
+	assert.False(t, ok)
+}
+
+func TestMiddleware_WithRunbooks(t *testing.T) {
+	t.Parallel()
+
+	r, err := apperror.NewRunbooks(&config.Errors{
+		Runbooks:         map[string]config.Runbook{"upstream": {ID: "RB-1", URL: "https://runbooks/upstream"}},
+		InternalNetworks: []string{"10.0.0.0/8"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := map[string]struct {
+		ip   string
+		want string
+	}{
+		"internal": {ip: "10.1.2.3", want: "RB-1"},
+		"external": {ip: "203.0.113.7"},
+		"unknown":  {},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.ErrorLevel)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(clientip.ContextWithIP(c.Request.Context(), tt.ip))
+			}, apperror.Middleware(&counter{counts: map[string]int{}}, &logger.Logger{Logger: zap.New(core)}, 0, apperror.WithRunbooks(r)))
+			router.GET("/photos/:id", func(c *gin.Context) {
+				err := apperror.Upstream(errors.New("upstream down"))
+				c.Error(err)
+
+				rb, _ := apperror.InternalRunbook(c.Request.Context(), err)
+				c.String(http.StatusBadGateway, rb.ID)
+			})
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/photos/1", http.NoBody))
+
+			assert.Equal(t, tt.want, resp.Body.String())
+
+			if assert.Equal(t, 1, logs.Len()) {
+				assert.Equal(t, "RB-1", logs.All()[0].ContextMap()["runbook_id"])
+				assert.Equal(t, "https://runbooks/upstream", logs.All()[0].ContextMap()["runbook_url"])
+			}
+		})
+	}
+}
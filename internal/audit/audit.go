@@ -0,0 +1,196 @@
+// Package audit records who changed what and when: every mutating API call is recorded with the authenticated actor,
+// the route, the entity it targets and, when the handler provides it, the diff of the entity. Entries go to a
+// queryable Store and/or to the log stream.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// Entry is the record of a mutating API call.
+type Entry struct {
+	ID   int64     `json:"id"`
+	Time time.Time `json:"time"`
+	// Actor is the subject of the authenticated consumer, empty for anonymous calls.
+	Actor      string `json:"actor"`
+	AuthMethod string `json:"auth_method,omitempty"`
+	Method     string `json:"method"`
+	// Route is the route as declared, e.g. "/photos/:id/image".
+	Route    string `json:"route"`
+	Path     string `json:"path"`
+	EntityID string `json:"entity_id,omitempty"`
+	Status   int    `json:"status"`
+	ClientIP string `json:"client_ip,omitempty"`
+	Tenant   string `json:"tenant,omitempty"`
+	// Diff holds the changed fields of the entity, when the handler records it.
+	Diff map[string]Change `json:"diff,omitempty"`
+}
+
+// Change is the change of a field of an entity.
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Diff returns the top-level JSON fields differing between before and after, either of which may be nil for created
+// and deleted entities.
+func Diff(before, after any) map[string]Change {
+	b, a := fields(before), fields(after)
+	diff := map[string]Change{}
+
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			diff[k] = Change{From: v, To: a[k]}
+		}
+	}
+
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			diff[k] = Change{To: v}
+		}
+	}
+
+	return diff
+}
+
+func fields(v any) map[string]any {
+	m := map[string]any{}
+	if v == nil {
+		return m
+	}
+
+	if b, err := json.Marshal(v); err == nil {
+		json.Unmarshal(b, &m) //nolint:errcheck // values which are not objects have no fields
+	}
+
+	return m
+}
+
+// Details are the parts of an Entry only the handler knows, attached to the request context by the Middleware.
+type Details struct {
+	entityID string
+	diff     map[string]Change
+}
+
+type detailsKey struct{}
+
+// DetailsFromContext returns the Details of the call of ctx. All Details methods are safe to call on nil, for calls
+// which are not audited.
+func DetailsFromContext(ctx context.Context) *Details {
+	d, _ := ctx.Value(detailsKey{}).(*Details)
+	return d
+}
+
+// SetEntityID sets the ID of the entity of the call, when it is not the id parameter of the route, e.g. once created.
+func (d *Details) SetEntityID(id string) {
+	if d != nil {
+		d.entityID = id
+	}
+}
+
+// SetDiff records the change of the entity from before to after.
+func (d *Details) SetDiff(before, after any) {
+	if d != nil {
+		d.diff = Diff(before, after)
+	}
+}
+
+// Auditor records the mutating API calls.
+type Auditor struct {
+	store Store
+	log   *logger.Logger
+	// logEntries writes every entry to the log stream.
+	logEntries bool
+	now        func() time.Time
+}
+
+// New creates an Auditor appending entries to store, if not nil, and writing them to the log if logEntries is set.
+func New(store Store, l *logger.Logger, logEntries bool) *Auditor {
+	return &Auditor{store: store, log: l, logEntries: logEntries, now: time.Now}
+}
+
+// Middleware records the calls with a mutating method once handled, whatever their outcome. The actor is the
+// Identity attached by the authentication of the route, and the entity is the id parameter of the route unless the
+// handler sets it.
+func (a *Auditor) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		d := &Details{entityID: c.Param("id")}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), detailsKey{}, d))
+
+		c.Next()
+
+		ctx := c.Request.Context()
+		e := &Entry{
+			Time:     a.now().UTC(),
+			ClientIP: clientip.FromContext(ctx),
+			Tenant:   tenant.FromContext(ctx),
+			Method:   c.Request.Method,
+			Route:    c.FullPath(),
+			Path:     c.Request.URL.Path,
+			EntityID: d.entityID,
+			Status:   c.Writer.Status(),
+			Diff:     d.diff,
+		}
+
+		if id := auth.IdentityFromContext(ctx); id != nil {
+			e.Actor, e.AuthMethod = id.Subject, id.Method
+		}
+
+		a.record(ctx, e)
+	}
+}
+
+func (a *Auditor) record(ctx context.Context, e *Entry) {
+	if a.store != nil {
+		if err := a.store.Append(ctx, e); err != nil {
+			a.log.Error("failed to record audit entry", zap.Error(err))
+		}
+	}
+
+	if a.logEntries {
+		a.log.Info("audit",
+			zap.Int64("id", e.ID),
+			zap.String("actor", e.Actor),
+			zap.String("auth_method", e.AuthMethod),
+			zap.String("method", e.Method),
+			zap.String("route", e.Route),
+			zap.String("path", e.Path),
+			zap.String("entity_id", e.EntityID),
+			zap.Int("status", e.Status),
+			zap.String("client_ip", e.ClientIP),
+			zap.String("tenant", e.Tenant),
+			zap.Any("diff", e.Diff),
+		)
+	}
+}
+
+// Query returns the entries matching f, see Store.
+func (a *Auditor) Query(ctx context.Context, f Filter) (*Page, error) {
+	return a.store.Query(ctx, f) //nolint:wrapcheck // errors of the stores are already wrapped
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,193 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/audit"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	type entity struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+
+	tests := map[string]struct {
+		before any
+		after  any
+		want   map[string]audit.Change
+	}{
+		"updated": {
+			before: entity{ID: 1, Title: "old"},
+			after:  entity{ID: 1, Title: "new"},
+			want:   map[string]audit.Change{"title": {From: "old", To: "new"}},
+		},
+		"created": {
+			after: entity{ID: 1, Title: "new"},
+			want:  map[string]audit.Change{"id": {To: float64(1)}, "title": {To: "new"}},
+		},
+		"deleted": {
+			before: entity{ID: 1, Title: "old"},
+			want:   map[string]audit.Change{"id": {From: float64(1)}, "title": {From: "old"}},
+		},
+		"unchanged": {
+			before: entity{ID: 1, Title: "old"},
+			after:  entity{ID: 1, Title: "old"},
+			want:   map[string]audit.Change{},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, audit.Diff(tt.before, tt.after))
+		})
+	}
+}
+
+func TestAuditor_Middleware(t *testing.T) {
+	t.Parallel()
+
+	store := audit.NewMemoryStore(0)
+	a := audit.New(store, logger.NewNop(), true)
+
+	identify := func(c *gin.Context) {
+		if s := c.GetHeader("X-Subject"); s != "" {
+			c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), &auth.Identity{Subject: s, Method: "apikey"}))
+		}
+	}
+
+	router := gin.New()
+	router.Use(a.Middleware())
+	router.GET("/photos/:id", identify, func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.PUT("/photos/:id", identify, func(c *gin.Context) {
+		audit.DetailsFromContext(c.Request.Context()).SetDiff(map[string]any{"title": "old"}, map[string]any{"title": "new"})
+		c.Status(http.StatusOK)
+	})
+	router.POST("/uploads", identify, func(c *gin.Context) {
+		audit.DetailsFromContext(c.Request.Context()).SetEntityID("42")
+		c.Status(http.StatusCreated)
+	})
+
+	for _, r := range []struct{ method, path, subject string }{
+		{http.MethodGet, "/photos/1", "alice"},
+		{http.MethodPut, "/photos/1", "alice"},
+		{http.MethodPost, "/uploads", ""},
+	} {
+		req := httptest.NewRequest(r.method, r.path, http.NoBody)
+		if r.subject != "" {
+			req.Header.Set("X-Subject", r.subject)
+		}
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	p, err := store.Query(context.Background(), audit.Filter{})
+	if !assert.NoError(t, err) || !assert.Len(t, p.Entries, 2) {
+		return
+	}
+
+	upload, update := p.Entries[0], p.Entries[1]
+
+	assert.Equal(t, int64(2), upload.ID)
+	assert.Equal(t, "", upload.Actor)
+	assert.Equal(t, "/uploads", upload.Route)
+	assert.Equal(t, "42", upload.EntityID)
+	assert.Equal(t, http.StatusCreated, upload.Status)
+
+	assert.Equal(t, int64(1), update.ID)
+	assert.Equal(t, "alice", update.Actor)
+	assert.Equal(t, "apikey", update.AuthMethod)
+	assert.Equal(t, "/photos/:id", update.Route)
+	assert.Equal(t, "/photos/1", update.Path)
+	assert.Equal(t, "1", update.EntityID)
+	assert.Equal(t, map[string]audit.Change{"title": {From: "old", To: "new"}}, update.Diff)
+}
+
+func TestMemoryStore_Query(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := audit.NewMemoryStore(4)
+
+	for i := range 5 {
+		e := &audit.Entry{Time: start.Add(time.Duration(i) * time.Minute), Actor: []string{"alice", "bob"}[i%2], Method: http.MethodPut, EntityID: fmt.Sprint(i)}
+		assert.NoError(t, store.Append(context.Background(), e))
+	}
+
+	ids := func(p *audit.Page) []int64 {
+		ids := []int64{}
+		for _, e := range p.Entries {
+			ids = append(ids, e.ID)
+		}
+
+		return ids
+	}
+
+	tests := map[string]struct {
+		filter     audit.Filter
+		want       []int64
+		wantCursor string
+		wantErr    error
+	}{
+		"all kept entries": {
+			want: []int64{5, 4, 3, 2},
+		},
+		"first page": {
+			filter:     audit.Filter{Limit: 3},
+			want:       []int64{5, 4, 3},
+			wantCursor: "3",
+		},
+		"next page": {
+			filter: audit.Filter{Limit: 3, Cursor: "3"},
+			want:   []int64{2},
+		},
+		"actor": {
+			filter: audit.Filter{Actor: "alice"},
+			want:   []int64{5, 3},
+		},
+		"time range": {
+			filter: audit.Filter{Since: start.Add(2 * time.Minute), Until: start.Add(4 * time.Minute)},
+			want:   []int64{4, 3},
+		},
+		"invalid cursor": {
+			filter:  audit.Filter{Cursor: "abc"},
+			wantErr: audit.ErrInvalidCursor,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := store.Query(context.Background(), tt.filter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, ids(p))
+			assert.Equal(t, tt.wantCursor, p.NextCursor)
+		})
+	}
+}
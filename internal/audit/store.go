@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Bounds of the page size of a query.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrInvalidCursor is returned for a cursor not returned by a previous query.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Filter selects audit entries. Zero fields match everything.
+type Filter struct {
+	Actor    string
+	Method   string
+	Route    string
+	EntityID string
+	Since    time.Time
+	Until    time.Time
+	// Limit is the page size. Zero uses DefaultLimit, and it is capped at MaxLimit.
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+}
+
+func (f *Filter) matches(e *Entry) bool {
+	return (f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Method == "" || e.Method == f.Method) &&
+		(f.Route == "" || e.Route == f.Route) &&
+		(f.EntityID == "" || e.EntityID == f.EntityID) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+func (f *Filter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultLimit
+	}
+
+	return min(f.Limit, MaxLimit)
+}
+
+// Page is a page of entries, newest first.
+type Page struct {
+	Entries []Entry `json:"entries"`
+	// NextCursor fetches the next page, empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Store keeps audit entries.
+type Store interface {
+	// Append stores e and sets its ID, increasing with every entry.
+	Append(ctx context.Context, e *Entry) error
+	// Query returns a page of the entries matching f, newest first.
+	Query(ctx context.Context, f Filter) (*Page, error)
+}
+
+// MemoryStore is a Store keeping the latest entries in memory, for development and single instances. The oldest
+// entries are discarded beyond its capacity.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  []Entry
+	nextID   int64
+}
+
+// NewMemoryStore creates a MemoryStore keeping at most capacity entries.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{capacity: capacity, nextID: 1}
+}
+
+// Append implements Store.
+func (m *MemoryStore) Append(_ context.Context, e *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.ID = m.nextID
+	m.nextID++
+
+	m.entries = append(m.entries, *e)
+	if m.capacity > 0 && len(m.entries) > m.capacity {
+		m.entries = append(m.entries[:0:0], m.entries[len(m.entries)-m.capacity:]...)
+	}
+
+	return nil
+}
+
+// Query implements Store. The cursor is the ID of the last entry of the previous page.
+func (m *MemoryStore) Query(_ context.Context, f Filter) (*Page, error) {
+	before := int64(-1)
+
+	if f.Cursor != "" {
+		id, err := strconv.ParseInt(f.Cursor, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		before = id
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limit := f.limit()
+	p := &Page{Entries: []Entry{}}
+
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		e := &m.entries[i]
+		if (before >= 0 && e.ID >= before) || !f.matches(e) {
+			continue
+		}
+
+		if len(p.Entries) == limit {
+			p.NextCursor = strconv.FormatInt(p.Entries[limit-1].ID, 10)
+			break
+		}
+
+		p.Entries = append(p.Entries, *e)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,106 @@
+// Package auth authenticates API consumers and carries their Identity in the request context. Each supported
+// mechanism is an Authenticator, and all of them map the consumer into the same Identity, so handlers do not depend on
+// how a request was authenticated.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries no credentials it handles.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Identity is the authenticated API consumer.
+type Identity struct {
+	Subject string
+	Email   string
+	Groups  []string
+	// Scopes are the scopes granted to the consumer, e.g. by a token. Trusted headers grant none.
+	Scopes []string
+	// Method is the mechanism which authenticated the consumer, e.g. "proxy".
+	Method string
+	// Claims are the claims of the token which authenticated the consumer, nil for other mechanisms.
+	Claims map[string]any
+}
+
+type identityKey struct{}
+
+// ContextWithIdentity returns a copy of ctx carrying id.
+func ContextWithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the Identity carried by ctx, or nil for anonymous requests.
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// Authenticator authenticates a request. It returns ErrNoCredentials when the request carries none of its
+// credentials, and any other error when the credentials are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Mode is the authentication requirement of a route.
+type Mode string
+
+// Authentication modes.
+const (
+	// ModeOptional authenticates requests carrying credentials and lets anonymous requests through, so handlers can
+	// personalize responses for authenticated consumers. It is the default.
+	ModeOptional Mode = ""
+	// ModeRequired rejects anonymous requests with 401.
+	ModeRequired Mode = "required"
+	// ModeNone does not authenticate, credentials are ignored.
+	ModeNone Mode = "none"
+)
+
+// Middleware authenticates requests with the first Authenticator finding credentials and attaches the Identity to
+// the request context. Requests with invalid credentials are rejected with 401, requests without any are rejected
+// with 401 in ModeRequired and stay anonymous otherwise.
+func Middleware(mode Mode, authenticators ...Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mode == ModeNone {
+			c.Next()
+			return
+		}
+
+		id, err := authenticate(c.Request, authenticators)
+		if err == nil {
+			c.Request = c.Request.WithContext(ContextWithIdentity(c.Request.Context(), id))
+			logger.EventFromContext(c.Request.Context()).Add(zap.String("subject", id.Subject), zap.String("auth_method", id.Method))
+		}
+
+		if err != nil && (mode == ModeRequired || !errors.Is(err, ErrNoCredentials)) {
+			c.Error(apperror.Auth(err)) //nolint:errcheck // returns the same error
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticate returns the Identity from the first Authenticator finding credentials, or ErrNoCredentials.
+func authenticate(r *http.Request, authenticators []Authenticator) (*Identity, error) {
+	for _, a := range authenticators {
+		id, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		return id, err
+	}
+
+	return nil, ErrNoCredentials
+}
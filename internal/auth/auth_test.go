@@ -0,0 +1,92 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		mode       auth.Mode
+		remoteAddr string
+		user       string
+	}
+
+	type want struct {
+		code    int
+		subject string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"authenticated": {
+			args: args{remoteAddr: "10.0.0.5:41000", user: "alice"},
+			want: want{code: http.StatusOK, subject: "alice"},
+		},
+		"anonymous": {
+			args: args{remoteAddr: "192.0.2.1:41000"},
+			want: want{code: http.StatusOK},
+		},
+		"invalid credentials": {
+			args: args{remoteAddr: "192.0.2.1:41000", user: "alice"},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"required authenticated": {
+			args: args{mode: auth.ModeRequired, remoteAddr: "10.0.0.5:41000", user: "alice"},
+			want: want{code: http.StatusOK, subject: "alice"},
+		},
+		"required anonymous": {
+			args: args{mode: auth.ModeRequired, remoteAddr: "10.0.0.5:41000"},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"none ignores credentials": {
+			args: args{mode: auth.ModeNone, remoteAddr: "192.0.2.1:41000", user: "alice"},
+			want: want{code: http.StatusOK},
+		},
+	}
+
+	th, err := auth.NewTrustedHeaders(&auth.TrustedHeadersConfig{ProxyCIDRs: []string{"10.0.0.0/8"}})
+	assert.NoError(t, err)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var subject string
+
+			router := gin.New()
+			router.Use(auth.Middleware(tt.args.mode, th))
+			router.GET("/", func(c *gin.Context) {
+				if id := auth.IdentityFromContext(c.Request.Context()); id != nil {
+					subject = id.Subject
+				}
+
+				c.Status(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.RemoteAddr = tt.args.remoteAddr
+
+			if tt.args.user != "" {
+				r.Header.Set("X-Forwarded-User", tt.args.user)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, r)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.subject, subject)
+		})
+	}
+}
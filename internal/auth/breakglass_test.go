@@ -0,0 +1,134 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+)
+
+func TestBreakGlass_Issue(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		grant auth.BreakGlassGrant
+		ttl   time.Duration
+	}
+
+	tests := map[string]struct {
+		args args
+		want error
+	}{
+		"valid": {
+			args: args{grant: auth.BreakGlassGrant{Subject: "bob", Routes: []string{"/photos/:id"}, Reason: "INC-42", Issuer: "alice"}, ttl: time.Hour},
+		},
+		"missing subject": {
+			args: args{grant: auth.BreakGlassGrant{Routes: []string{"/photos/:id"}, Reason: "INC-42"}, ttl: time.Hour},
+			want: auth.ErrInvalidGrant,
+		},
+		"missing routes": {
+			args: args{grant: auth.BreakGlassGrant{Subject: "bob", Reason: "INC-42"}, ttl: time.Hour},
+			want: auth.ErrInvalidGrant,
+		},
+		"missing reason": {
+			args: args{grant: auth.BreakGlassGrant{Subject: "bob", Routes: []string{"/photos/:id"}, Reason: " "}, ttl: time.Hour},
+			want: auth.ErrInvalidGrant,
+		},
+		"ttl above maximum": {
+			args: args{grant: auth.BreakGlassGrant{Subject: "bob", Routes: []string{"/photos/:id"}, Reason: "INC-42"}, ttl: 2 * time.Hour},
+			want: auth.ErrInvalidGrant,
+		},
+	}
+
+	bg, err := auth.NewBreakGlass(&auth.BreakGlassConfig{SigningKey: "secret", MaxTTL: time.Hour})
+	assert.NoError(t, err)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			token, issued, err := bg.Issue(tt.args.grant, tt.args.ttl)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(tt.args.ttl), issued.ExpiresAt, time.Minute)
+
+			verified, err := bg.Verify(token)
+			assert.NoError(t, err)
+			assert.Equal(t, issued, verified)
+		})
+	}
+}
+
+func TestNewBreakGlass(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		key  string
+		want string
+	}{
+		"missing key":     {key: "", want: "break-glass signing key is required"},
+		"placeholder key": {key: "change-me", want: `invalid break-glass signing key: key is the placeholder "change-me"`},
+		"unset reference": {key: "env:BREAK_GLASS_TEST_UNSET_KEY", want: "failed to resolve break-glass signing key: secret not found: environment variable BREAK_GLASS_TEST_UNSET_KEY"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := auth.NewBreakGlass(&auth.BreakGlassConfig{SigningKey: tt.key, MaxTTL: time.Hour})
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestBreakGlass_Verify(t *testing.T) {
+	t.Parallel()
+
+	bg, err := auth.NewBreakGlass(&auth.BreakGlassConfig{SigningKey: "secret", MaxTTL: time.Hour})
+	assert.NoError(t, err)
+
+	other, err := auth.NewBreakGlass(&auth.BreakGlassConfig{SigningKey: "other", MaxTTL: time.Hour})
+	assert.NoError(t, err)
+
+	grant := auth.BreakGlassGrant{Subject: "bob", Routes: []string{"/photos/:id"}, Reason: "INC-42"}
+
+	token, _, err := bg.Issue(grant, time.Hour)
+	assert.NoError(t, err)
+
+	expired, _, err := bg.Issue(grant, time.Nanosecond)
+	assert.NoError(t, err)
+
+	payload, sig, _ := strings.Cut(token, ".")
+
+	tests := map[string]struct {
+		token string
+		bg    *auth.BreakGlass
+	}{
+		"malformed":     {token: "garbage", bg: bg},
+		"tampered":      {token: payload + "x." + sig, bg: bg},
+		"other key":     {token: token, bg: other},
+		"expired":       {token: expired, bg: bg},
+		"bad signature": {token: payload + ".AAAA", bg: bg},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tt.bg.Verify(tt.token)
+			assert.ErrorIs(t, err, auth.ErrInvalidBreakGlassToken)
+		})
+	}
+}
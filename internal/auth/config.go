@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Config holds the configuration for authenticating and authorizing API consumers.
+type Config struct {
+	TrustedHeaders TrustedHeadersConfig `mapstructure:"trusted_headers"`
+	// ExplainDenials returns the failed policy rule with the present and required roles and scopes in the body of
+	// denied requests. It discloses the policies, only enable it in development.
+	ExplainDenials bool             `mapstructure:"explain_denials"`
+	BreakGlass     BreakGlassConfig `mapstructure:"break_glass"`
+	OIDC           OIDCConfig       `mapstructure:"oidc"`
+	Token          TokenConfig      `mapstructure:"token"`
+	HMAC           HMACConfig       `mapstructure:"hmac"`
+}
+
+// HMACConfig holds the configuration for authenticating services by the HMAC signatures of their requests, with keys
+// shared with each of them.
+type HMACConfig struct {
+	// Keys are the keys of the services. Empty disables signature authentication.
+	Keys []HMACKey `mapstructure:"keys"`
+	// MaxSkew bounds the difference between the Date of a signed request and the time it is received. Zero uses 5
+	// minutes.
+	MaxSkew time.Duration `mapstructure:"max_skew"`
+	// MaxBodySize bounds the bodies of the signed requests, read to verify their digest. Zero uses 10MiB.
+	MaxBodySize config.ByteSize `mapstructure:"max_body_size"`
+	// Headers lists the headers every signature must cover besides the request target, the date and the digest.
+	Headers []string `mapstructure:"headers"`
+}
+
+// HMACKey is the key of a service, whose ID is the subject of its requests.
+type HMACKey struct {
+	ID string `mapstructure:"id" required:"true"`
+	// SecretRef references the secret of the key: "env:NAME", "file:PATH" or the secret itself.
+	SecretRef string `mapstructure:"secret_ref" redact:"true" required:"true"`
+	// Groups are the groups of the service, used as its roles.
+	Groups []string `mapstructure:"groups"`
+}
+
+// TokenConfig holds the configuration of the service tokens minted by POST /auth/token, to act as an identity provider in
+// development.
+type TokenConfig struct {
+	// Enabled serves POST /auth/token and authenticates requests with the Bearer tokens it mints.
+	Enabled bool `mapstructure:"enabled"`
+	// Algorithm is "HS256" or "RS256". Empty uses HS256.
+	Algorithm string `mapstructure:"algorithm"`
+	// SigningKey signs HS256 tokens.
+	SigningKey string `mapstructure:"signing_key" redact:"true"`
+	// PrivateKeyFile is the PEM file of the RSA key signing RS256 tokens.
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	Issuer         string `mapstructure:"issuer"`
+	// Audience is the aud claim of the tokens. Empty omits it.
+	Audience string `mapstructure:"audience"`
+	// TTL is the lifetime of the tokens. Zero uses 15 minutes.
+	TTL time.Duration `mapstructure:"ttl"`
+	// ClockSkew tolerates the difference between the clocks of the replicas minting and verifying the tokens.
+	ClockSkew time.Duration `mapstructure:"clock_skew"`
+	Clients   []TokenClient `mapstructure:"clients"`
+}
+
+// TokenClient is a client allowed to obtain tokens, with an API key or its ID and secret.
+type TokenClient struct {
+	// ID is the subject of the tokens of the client.
+	ID     string `mapstructure:"id"`
+	Secret string `mapstructure:"secret" redact:"true"`
+	APIKey string `mapstructure:"api_key" redact:"true"`
+	// Scopes are the scopes the client may request.
+	Scopes []string `mapstructure:"scopes"`
+	Groups []string `mapstructure:"groups"`
+	// Claims are extra claims of the tokens of the client.
+	Claims map[string]any `mapstructure:"claims"`
+}
+
+// OIDCConfig holds the configuration of the OpenID Connect login of human operators to the admin routes.
+type OIDCConfig struct {
+	// Issuer is the URL of the identity provider, discovered at its /.well-known/openid-configuration. Empty disables
+	// the login. Sessions must be enabled.
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret" redact:"true"`
+	// RedirectURL is the public URL of the /auth/callback route, as registered with the provider.
+	RedirectURL string `mapstructure:"redirect_url"`
+	// Scopes are the scopes requested. Empty requests openid, email and profile.
+	Scopes []string `mapstructure:"scopes"`
+	// GroupsClaim is the ID token claim holding the groups of the operator, used as roles. Empty uses groups.
+	GroupsClaim string `mapstructure:"groups_claim"`
+	// AdminGroups lists the groups allowed to the /admin routes. It is required when the login is enabled.
+	AdminGroups []string `mapstructure:"admin_groups"`
+	// ClockSkew tolerates the difference between the clocks of the provider and the service when checking the exp,
+	// nbf and iat claims of ID tokens. Zero uses 1 minute.
+	ClockSkew time.Duration `mapstructure:"clock_skew"`
+}
+
+// BreakGlassConfig holds the configuration of break-glass tokens, which grant a subject emergency access to routes despite
+// their policies for a limited time.
+type BreakGlassConfig struct {
+	// SigningKey signs the tokens: "env:NAME", "file:PATH" or the key itself. Empty disables break-glass access.
+	SigningKey string `mapstructure:"signing_key" redact:"true"`
+	// MaxTTL bounds the validity of the tokens.
+	MaxTTL time.Duration `mapstructure:"max_ttl"`
+	// AdminRoles lists the roles allowed to issue tokens. It is required when break-glass access is enabled.
+	AdminRoles []string `mapstructure:"admin_roles"`
+}
+
+// TrustedHeadersConfig holds the configuration for authenticating API consumers by the identity headers of an auth proxy.
+type TrustedHeadersConfig struct {
+	// ProxyCIDRs lists the networks the auth proxies connect from. Empty disables trusted header authentication.
+	ProxyCIDRs []string `mapstructure:"proxy_cidrs"`
+	// UserHeader is the header holding the user name. Empty uses X-Forwarded-User.
+	UserHeader string `mapstructure:"user_header"`
+	// EmailHeader is the header holding the email. Empty uses X-Forwarded-Email.
+	EmailHeader string `mapstructure:"email_header"`
+	// GroupsHeader is the header holding the comma separated groups. Empty uses X-Forwarded-Groups.
+	GroupsHeader string `mapstructure:"groups_header"`
+}
+
+// ConfigSection is the section of the configuration of the authentication and authorization, under auth. It has to be
+// registered with the config.Viper building the configuration.
+var ConfigSection = config.NewSection("auth", Config{}, validateConfig)
+
+func validateConfig(cfg *Config) error {
+	var errs []error
+
+	if cfg.BreakGlass.SigningKey != "" && (len(cfg.BreakGlass.AdminRoles) == 0 || cfg.BreakGlass.MaxTTL <= 0) {
+		errs = append(errs, errors.New("break-glass access requires admin roles and a max ttl"))
+	}
+
+	if cfg.OIDC.Issuer != "" && len(cfg.OIDC.AdminGroups) == 0 {
+		errs = append(errs, errors.New("oidc login requires admin groups"))
+	}
+
+	if cfg.HMAC.MaxSkew < 0 || cfg.Token.TTL < 0 || cfg.Token.ClockSkew < 0 || cfg.OIDC.ClockSkew < 0 {
+		errs = append(errs, errors.New("durations must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestConfigSection_Set(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg  auth.Config
+		want string
+	}{
+		"disabled": {},
+		"break-glass": {
+			cfg: auth.Config{BreakGlass: auth.BreakGlassConfig{SigningKey: "secret", AdminRoles: []string{"admin"}, MaxTTL: time.Hour}},
+		},
+		"break-glass without admin roles": {
+			cfg:  auth.Config{BreakGlass: auth.BreakGlassConfig{SigningKey: "secret", MaxTTL: time.Hour}},
+			want: "auth: break-glass access requires admin roles and a max ttl",
+		},
+		"oidc without admin groups": {
+			cfg:  auth.Config{OIDC: auth.OIDCConfig{Issuer: "https://idp.example.com"}},
+			want: "auth: oidc login requires admin groups",
+		},
+		"negative duration": {
+			cfg:  auth.Config{Token: auth.TokenConfig{TTL: -time.Minute}},
+			want: "auth: durations must not be negative",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := auth.ConfigSection.Set(&config.Config{}, tt.cfg)
+			if tt.want != "" {
+				assert.EqualError(t, err, tt.want)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
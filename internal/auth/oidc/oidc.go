@@ -0,0 +1,346 @@
+// Package oidc logs human operators in with the OpenID Connect authorization code flow of an identity provider, and
+// keeps them logged in with a session. The provider is discovered from its issuer, the code is bound to the login with
+// PKCE, and the ID token is verified against the keys, issuer, audience and nonce expected before the session is
+// issued with the identity of its claims.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/session"
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+// MethodOIDC is the authentication method of identities logged in with OpenID Connect.
+const MethodOIDC = "oidc"
+
+// Defaults of the configuration.
+const (
+	DefaultGroupsClaim = "groups"
+	// DefaultScopes are the space separated scopes requested when none are configured.
+	DefaultScopes = "openid email profile"
+	// DefaultRedirect is where operators land after login when the login did not ask for a page.
+	DefaultRedirect = "/admin"
+	// DefaultClockSkew is the tolerated difference between the clocks of the provider and this service.
+	DefaultClockSkew = time.Minute
+)
+
+// Session values keeping the login in progress.
+const (
+	stateKey    = "oidc_state"
+	nonceKey    = "oidc_nonce"
+	verifierKey = "oidc_verifier"
+	redirectKey = "oidc_redirect"
+	rememberKey = "oidc_remember"
+)
+
+// randomSize is the number of random bytes of the state, nonce and PKCE verifier.
+const randomSize = 32
+
+var (
+	// errInvalidState is returned by the callback for a state not matching the login of the session.
+	errInvalidState = errors.New("invalid login state")
+	// errProviderError is returned by the callback when the provider reports an error instead of a code.
+	errProviderError = errors.New("identity provider error")
+)
+
+// discovery is the part of the provider metadata used by the flow.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider runs the login flow with one identity provider.
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	groupsClaim  string
+	client       *client.Client
+	sessions     *session.Manager
+	log          *logger.Logger
+	times        *timestamp.Validator
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      keySet
+}
+
+// New creates a Provider from cfg, issuing the sessions of logged in operators with sessions. The provider is
+// discovered on the first login, so it does not need to be reachable at startup.
+func New(cfg *auth.OIDCConfig, hc *http.Client, sessions *session.Manager, l *logger.Logger) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc requires an issuer, a client id and a redirect url")
+	}
+
+	p := &Provider{
+		issuer:       cfg.Issuer,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		scopes:       cfg.Scopes,
+		groupsClaim:  cfg.GroupsClaim,
+		client:       client.NewClient(hc),
+		sessions:     sessions,
+		log:          l,
+	}
+
+	skew := cfg.ClockSkew
+	if skew <= 0 {
+		skew = DefaultClockSkew
+	}
+
+	p.times = timestamp.NewValidator(skew)
+
+	if len(p.scopes) == 0 {
+		p.scopes = strings.Fields(DefaultScopes)
+	}
+
+	if p.groupsClaim == "" {
+		p.groupsClaim = DefaultGroupsClaim
+	}
+
+	return p, nil
+}
+
+// Login returns a handler starting a login: it keeps a new state, nonce and PKCE verifier in the session and
+// redirects to the authorization endpoint of the provider. The redirect parameter is the local page to return to
+// after login, and remember=true asks for a remember-me token.
+func (p *Provider) Login() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d, err := p.discover(c.Request.Context())
+		if err != nil {
+			p.fail(c, http.StatusInternalServerError, apperror.Upstream(err))
+			return
+		}
+
+		authURL, err := p.startLogin(c, d)
+		if err != nil {
+			p.fail(c, http.StatusInternalServerError, apperror.Internal(err))
+			return
+		}
+
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+func (p *Provider) startLogin(c *gin.Context, d *discovery) (string, error) {
+	s, err := p.sessions.Start(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to start session: %w", err)
+	}
+
+	var state, nonce, verifier string
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = random(); err != nil {
+			return "", err
+		}
+	}
+
+	if s.Values == nil {
+		s.Values = map[string]string{}
+	}
+
+	s.Values[stateKey] = state
+	s.Values[nonceKey] = nonce
+	s.Values[verifierKey] = verifier
+	s.Values[redirectKey] = localRedirect(c.Query("redirect"))
+	s.Values[rememberKey] = strconv.FormatBool(c.Query("remember") == "true")
+
+	if err = p.sessions.Save(c.Request.Context(), s); err != nil {
+		return "", fmt.Errorf("failed to save session: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Callback returns the handler of the redirect URL: it checks the state against the login of the session, exchanges
+// the code for tokens, verifies the ID token and logs the operator in with a new session before redirecting to the
+// page the login asked for.
+func (p *Provider) Callback() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := session.FromContext(c.Request.Context())
+
+		if e := c.Query("error"); e != "" {
+			p.fail(c, http.StatusUnauthorized, apperror.Auth(fmt.Errorf("%w: %s: %s", errProviderError, e, c.Query("error_description"))))
+			return
+		}
+
+		if s == nil || s.Values[stateKey] == "" || subtle.ConstantTimeCompare([]byte(s.Values[stateKey]), []byte(c.Query("state"))) != 1 {
+			p.fail(c, http.StatusBadRequest, apperror.Auth(errInvalidState))
+			return
+		}
+
+		nonce, verifier, redirect, remember := s.Values[nonceKey], s.Values[verifierKey], s.Values[redirectKey], s.Values[rememberKey] == "true"
+		// The login can only be completed once.
+		for _, k := range []string{stateKey, nonceKey, verifierKey, redirectKey, rememberKey} {
+			delete(s.Values, k)
+		}
+
+		id, err := p.exchange(c.Request.Context(), c.Query("code"), verifier, nonce)
+		if err != nil {
+			p.fail(c, http.StatusUnauthorized, apperror.Auth(err))
+			return
+		}
+
+		if _, err = p.sessions.Login(c, id); err != nil {
+			p.fail(c, http.StatusInternalServerError, apperror.Internal(fmt.Errorf("failed to issue session: %w", err)))
+			return
+		}
+
+		// The operator is logged in either way, only for shorter.
+		if remember {
+			if err = p.sessions.Remember(c); err != nil {
+				p.log.Warn("failed to issue remember-me token", zap.String("subject", id.Subject), zap.Error(err))
+			}
+		}
+
+		p.log.Info("operator logged in", zap.String("subject", id.Subject), zap.String("email", id.Email), zap.Strings("groups", id.Groups))
+		c.Redirect(http.StatusFound, redirect)
+	}
+}
+
+// Logout returns a handler ending the session of the operator.
+func (p *Provider) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := p.sessions.Logout(c); err != nil {
+			p.fail(c, http.StatusInternalServerError, apperror.Internal(fmt.Errorf("failed to end session: %w", err)))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// exchange redeems code with the PKCE verifier and returns the identity of the verified ID token.
+func (p *Provider) exchange(ctx context.Context, code, verifier, nonce string) (*auth.Identity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"code_verifier": {verifier},
+	}
+
+	var resp *http.Response
+	if resp, err = p.client.Post(ctx, d.TokenEndpoint, "application/x-www-form-urlencoded", strings.NewReader(form.Encode())); err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+
+	if err = (client.JSONCodec{}).Decode(resp.Body, &tokens); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	resp.Body.Close()
+
+	claims, err := p.verify(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Identity{
+		Subject: claims.String("sub"),
+		Email:   claims.String("email"),
+		Groups:  claims.Strings(p.groupsClaim),
+		Method:  MethodOIDC,
+	}, nil
+}
+
+// discover returns the metadata of the provider, fetched once.
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	d, err := client.GetAs[discovery](ctx, p.client, strings.TrimSuffix(p.issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+
+	if d == nil || d.Issuer != p.issuer || d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("invalid oidc provider metadata of %s", p.issuer)
+	}
+
+	p.discovery = d
+
+	return d, nil
+}
+
+func (p *Provider) fail(c *gin.Context, status int, err error) {
+	p.log.Warn("oidc login failed", zap.Error(err))
+	c.Error(err) //nolint:errcheck // returns the same error
+	c.AbortWithStatusJSON(status, gin.H{"error": "login failed"})
+}
+
+// localRedirect returns target if it is a path of this service, and DefaultRedirect otherwise so the login cannot
+// redirect operators to another site.
+func localRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return DefaultRedirect
+	}
+
+	return target
+}
+
+func random() (string, error) {
+	b := make([]byte, randomSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
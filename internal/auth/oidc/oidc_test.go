@@ -0,0 +1,293 @@
+package oidc_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/auth/oidc"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/session"
+)
+
+// idp is a fake identity provider issuing ID tokens signed by signer with the claims of the authorization request,
+// modified by mutate, for the code "code" redeemed with the verifier of the PKCE challenge.
+type idp struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	signer    *rsa.PrivateKey
+	mutate    func(claims map[string]any)
+	challenge string
+	nonce     string
+}
+
+func newIDP(t *testing.T, signer func(key *rsa.PrivateKey) *rsa.PrivateKey, mutate func(claims map[string]any)) *idp {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	p := &idp{key: key, signer: signer(key), mutate: mutate}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "code" || r.FormValue("client_secret") != "secret" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken(t)})
+	})
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *idp) idToken(t *testing.T) string {
+	t.Helper()
+
+	claims := map[string]any{
+		"iss":    p.URL,
+		"aud":    "client",
+		"sub":    "alice",
+		"email":  "alice@example.com",
+		"groups": []string{"admins"},
+		"nonce":  p.nonce,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	p.mutate(claims)
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	assert.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.signer, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func sessionCookie(resp *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range resp.Result().Cookies() {
+		if c.Name == session.DefaultCookieName {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func serve(router http.Handler, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestProvider_Flow(t *testing.T) {
+	t.Parallel()
+
+	sameKey := func(key *rsa.PrivateKey) *rsa.PrivateKey { return key }
+
+	type args struct {
+		signer   func(key *rsa.PrivateKey) *rsa.PrivateKey
+		mutate   func(claims map[string]any)
+		state    func(state string) string
+		query    string
+		redirect string
+	}
+
+	type want struct {
+		code     int
+		location string
+		operator string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"success": {
+			args: args{redirect: "/admin/break-glass"},
+			want: want{code: http.StatusFound, location: "/admin/break-glass", operator: "alice admins session"},
+		},
+		"redirect to another site": {
+			args: args{redirect: "//evil.example.com"},
+			want: want{code: http.StatusFound, location: "/admin", operator: "alice admins session"},
+		},
+		"state mismatch": {
+			args: args{state: func(string) string { return "forged" }},
+			want: want{code: http.StatusBadRequest},
+		},
+		"provider error": {
+			args: args{query: "&error=access_denied"},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"nonce mismatch": {
+			args: args{mutate: func(c map[string]any) { c["nonce"] = "replayed" }},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"other audience": {
+			args: args{mutate: func(c map[string]any) { c["aud"] = []string{"other"} }},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"other issuer": {
+			args: args{mutate: func(c map[string]any) { c["iss"] = "https://evil.example.com" }},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"expired": {
+			args: args{mutate: func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"expired within skew": {
+			args: args{mutate: func(c map[string]any) { c["exp"] = time.Now().Add(-30 * time.Second).Unix() }},
+			want: want{code: http.StatusFound, location: "/admin", operator: "alice admins session"},
+		},
+		"not yet valid": {
+			args: args{mutate: func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() }},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"issued in the future": {
+			args: args{mutate: func(c map[string]any) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+			want: want{code: http.StatusUnauthorized},
+		},
+		"bad signature": {
+			args: args{signer: func(*rsa.PrivateKey) *rsa.PrivateKey {
+				other, err := rsa.GenerateKey(rand.Reader, 2048)
+				assert.NoError(t, err)
+
+				return other
+			}},
+			want: want{code: http.StatusUnauthorized},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.args.signer == nil {
+				tt.args.signer = sameKey
+			}
+
+			if tt.args.mutate == nil {
+				tt.args.mutate = func(map[string]any) {}
+			}
+
+			if tt.args.state == nil {
+				tt.args.state = func(state string) string { return state }
+			}
+
+			provider := newIDP(t, tt.args.signer, tt.args.mutate)
+
+			sm, err := session.New(&config.Session{}, session.NewMemoryStore(), logger.NewNop())
+			assert.NoError(t, err)
+
+			p, err := oidc.New(&auth.OIDCConfig{
+				Issuer:       provider.URL,
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURL:  "https://api.example.com/auth/callback",
+			}, provider.Client(), sm, logger.NewNop())
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(sm.Middleware())
+			router.GET("/auth/login", p.Login())
+			router.GET("/auth/callback", p.Callback())
+			router.GET("/me", auth.Middleware(auth.ModeRequired, sm), func(c *gin.Context) {
+				id := auth.IdentityFromContext(c.Request.Context())
+				c.String(http.StatusOK, id.Subject+" "+id.Groups[0]+" "+id.Method)
+			})
+
+			login := serve(router, "/auth/login?redirect="+url.QueryEscape(tt.args.redirect), nil)
+			if !assert.Equal(t, http.StatusFound, login.Code) {
+				return
+			}
+
+			authURL, err := url.Parse(login.Header().Get("Location"))
+			assert.NoError(t, err)
+
+			q := authURL.Query()
+			assert.Equal(t, provider.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+			assert.Equal(t, "S256", q.Get("code_challenge_method"))
+			assert.Equal(t, "openid email profile", q.Get("scope"))
+
+			provider.challenge, provider.nonce = q.Get("code_challenge"), q.Get("nonce")
+
+			callback := serve(router, "/auth/callback?code=code&state="+url.QueryEscape(tt.args.state(q.Get("state")))+tt.args.query, sessionCookie(login))
+			assert.Equal(t, tt.want.code, callback.Code)
+
+			if tt.want.operator == "" {
+				return
+			}
+
+			assert.Equal(t, tt.want.location, callback.Header().Get("Location"))
+
+			cookie := sessionCookie(callback)
+			if !assert.NotNil(t, cookie) {
+				return
+			}
+
+			assert.NotEqual(t, sessionCookie(login).Value, cookie.Value, "the session is rotated on login")
+
+			me := serve(router, "/me", cookie)
+			assert.Equal(t, tt.want.operator, me.Body.String())
+
+			replay := serve(router, "/auth/callback?code=code&state="+url.QueryEscape(q.Get("state")), cookie)
+			assert.Equal(t, http.StatusBadRequest, replay.Code, "a login completes once")
+		})
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := oidc.New(&auth.OIDCConfig{ClientID: "client"}, http.DefaultClient, nil, logger.NewNop())
+	assert.EqualError(t, err, "oidc requires an issuer, a client id and a redirect url")
+}
@@ -0,0 +1,137 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/jwt"
+)
+
+// errInvalidIDToken is returned for ID tokens which are malformed, not signed by the provider or not issued for this
+// login.
+var errInvalidIDToken = errors.New("invalid id token")
+
+// jwk is an RSA JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// keySet holds the signing keys of the provider by key ID.
+type keySet map[string]*rsa.PublicKey
+
+// verify checks the signature of the ID token raw and that it was issued by the provider to this client for the login
+// of nonce, and returns its claims. Only RS256, which providers must support, is accepted.
+func (p *Provider) verify(ctx context.Context, raw, nonce string) (jwt.Claims, error) {
+	t, err := jwt.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidIDToken, err)
+	}
+
+	if t.Header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", errInvalidIDToken, t.Header.Alg)
+	}
+
+	key, err := p.key(ctx, t.Header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(t.Signed))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], t.Signature); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", errInvalidIDToken)
+	}
+
+	return t.Claims, p.validate(t.Claims, nonce)
+}
+
+func (p *Provider) validate(c jwt.Claims, nonce string) error {
+	if err := c.ValidateTimes(p.times); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidIDToken, err)
+	}
+
+	switch {
+	case c.String("iss") != p.issuer:
+		return fmt.Errorf("%w: issued by %q", errInvalidIDToken, c.String("iss"))
+	case !c.HasAudience(p.clientID):
+		return fmt.Errorf("%w: not issued for this client", errInvalidIDToken)
+	case subtle.ConstantTimeCompare([]byte(c.String("nonce")), []byte(nonce)) != 1:
+		return fmt.Errorf("%w: nonce mismatch", errInvalidIDToken)
+	case c.String("sub") == "":
+		return fmt.Errorf("%w: no subject", errInvalidIDToken)
+	default:
+		return nil
+	}
+}
+
+// key returns the signing key kid of the provider. The keys are fetched again for an unknown key ID, since providers
+// rotate their keys.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	k := p.keys[kid]
+	p.mu.Unlock()
+
+	if k != nil {
+		return k, nil
+	}
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := client.GetAs[struct {
+		Keys []jwk `json:"keys"`
+	}](ctx, p.client, d.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oidc provider keys: %w", err)
+	}
+
+	keys := keySet{}
+
+	if set != nil {
+		for _, j := range set.Keys {
+			if pk, ok := j.publicKey(); ok {
+				keys[j.Kid] = pk
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if k = keys[kid]; k == nil {
+		return nil, fmt.Errorf("%w: unknown key %q", errInvalidIDToken, kid)
+	}
+
+	return k, nil
+}
+
+func (j *jwk) publicKey() (*rsa.PublicKey, bool) {
+	if j.Kty != "RSA" {
+		return nil, false
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(j.N)
+	if err != nil {
+		return nil, false
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(j.E)
+	if err != nil || len(e) == 0 {
+		return nil, false
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, true
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// MethodProxy is the Identity.Method of consumers authenticated by an auth proxy.
+const MethodProxy = "proxy"
+
+// errUntrustedProxy is returned when identity headers are sent from outside the trusted proxy networks, which is an
+// attempt to spoof an identity.
+var errUntrustedProxy = errors.New("identity headers sent from an untrusted address")
+
+// TrustedHeaders authenticates requests by the identity headers set by an authenticating reverse proxy such as
+// oauth2-proxy or Pomerium. The headers are only trusted when the request comes directly from a proxy network.
+type TrustedHeaders struct {
+	proxies      []*net.IPNet
+	userHeader   string
+	emailHeader  string
+	groupsHeader string
+}
+
+// NewTrustedHeaders creates a TrustedHeaders authenticator.
+func NewTrustedHeaders(cfg *TrustedHeadersConfig) (*TrustedHeaders, error) {
+	t := &TrustedHeaders{
+		userHeader:   headerOrDefault(cfg.UserHeader, "X-Forwarded-User"),
+		emailHeader:  headerOrDefault(cfg.EmailHeader, "X-Forwarded-Email"),
+		groupsHeader: headerOrDefault(cfg.GroupsHeader, "X-Forwarded-Groups"),
+	}
+
+	for _, cidr := range cfg.ProxyCIDRs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy cidr %s: %w", cidr, err)
+		}
+
+		t.proxies = append(t.proxies, n)
+	}
+
+	return t, nil
+}
+
+func headerOrDefault(h, def string) string {
+	if h == "" {
+		return def
+	}
+
+	return h
+}
+
+// Authenticate implements Authenticator. The subject is the user header, or the email header when the proxy only
+// sends the email.
+func (t *TrustedHeaders) Authenticate(r *http.Request) (*Identity, error) {
+	user := r.Header.Get(t.userHeader)
+	email := r.Header.Get(t.emailHeader)
+
+	if user == "" && email == "" {
+		return nil, ErrNoCredentials
+	}
+
+	if !t.trusted(r.RemoteAddr) {
+		return nil, fmt.Errorf("%w: %s", errUntrustedProxy, r.RemoteAddr)
+	}
+
+	id := &Identity{Subject: user, Email: email, Method: MethodProxy}
+	if id.Subject == "" {
+		id.Subject = email
+	}
+
+	for _, g := range strings.Split(r.Header.Get(t.groupsHeader), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			id.Groups = append(id.Groups, g)
+		}
+	}
+
+	return id, nil
+}
+
+// trusted reports whether the direct peer is an auth proxy. Forwarded addresses are ignored on purpose, they are
+// controlled by the client.
+func (t *TrustedHeaders) trusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range t.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
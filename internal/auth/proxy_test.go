@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+)
+
+func TestTrustedHeaders_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		remoteAddr string
+		header     map[string]string
+	}
+
+	type want struct {
+		id  *auth.Identity
+		err string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"trusted proxy": {
+			args: args{
+				remoteAddr: "10.0.0.5:41000",
+				header:     map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-Email": "alice@example.com", "X-Forwarded-Groups": "admins, editors,"},
+			},
+			want: want{id: &auth.Identity{Subject: "alice", Email: "alice@example.com", Groups: []string{"admins", "editors"}, Method: auth.MethodProxy}},
+		},
+		"email only": {
+			args: args{remoteAddr: "10.0.0.5:41000", header: map[string]string{"X-Forwarded-Email": "bob@example.com"}},
+			want: want{id: &auth.Identity{Subject: "bob@example.com", Email: "bob@example.com", Method: auth.MethodProxy}},
+		},
+		"ipv6 proxy": {
+			args: args{remoteAddr: "[fd00::1]:41000", header: map[string]string{"X-Forwarded-User": "alice"}},
+			want: want{id: &auth.Identity{Subject: "alice", Method: auth.MethodProxy}},
+		},
+		"untrusted address": {
+			args: args{remoteAddr: "192.0.2.1:41000", header: map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-For": "10.0.0.5"}},
+			want: want{err: "identity headers sent from an untrusted address: 192.0.2.1:41000"},
+		},
+		"no headers": {
+			args: args{remoteAddr: "10.0.0.5:41000"},
+			want: want{err: auth.ErrNoCredentials.Error()},
+		},
+	}
+
+	th, err := auth.NewTrustedHeaders(&auth.TrustedHeadersConfig{ProxyCIDRs: []string{"10.0.0.0/8", "fd00::/8"}})
+	assert.NoError(t, err)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.RemoteAddr = tt.args.remoteAddr
+
+			for k, v := range tt.args.header {
+				r.Header.Set(k, v)
+			}
+
+			id, err := th.Authenticate(r)
+			if tt.want.err != "" {
+				assert.EqualError(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.id, id)
+		})
+	}
+}
+
+func TestNewTrustedHeaders_InvalidCIDR(t *testing.T) {
+	t.Parallel()
+
+	_, err := auth.NewTrustedHeaders(&auth.TrustedHeadersConfig{ProxyCIDRs: []string{"10.0.0.0"}})
+	assert.ErrorContains(t, err, "invalid proxy cidr 10.0.0.0")
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// errAccessDenied is the error recorded for requests denied by a Policy.
+var errAccessDenied = errors.New("access denied")
+
+// Rules of a Policy reported by a Decision.
+const (
+	RuleAuthenticated = "authenticated"
+	RuleRoles         = "roles"
+	RuleScopes        = "scopes"
+)
+
+// Policy is the authorization policy of a route. A request is allowed when its Identity holds one of Roles, if any,
+// and all of Scopes. The roles of an Identity are its groups.
+type Policy struct {
+	// Name identifies the policy in logs and explanations.
+	Name   string
+	Roles  []string
+	Scopes []string
+}
+
+// Decision is the outcome of evaluating a Policy, with what the Identity presented against what the Policy requires.
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Policy  string `json:"policy"`
+	// Rule is the rule which denied the request, empty when allowed.
+	Rule           string   `json:"rule,omitempty"`
+	RequiredRoles  []string `json:"required_roles,omitempty"`
+	PresentRoles   []string `json:"present_roles,omitempty"`
+	RequiredScopes []string `json:"required_scopes,omitempty"`
+	PresentScopes  []string `json:"present_scopes,omitempty"`
+	MissingScopes  []string `json:"missing_scopes,omitempty"`
+}
+
+// Evaluate decides whether id satisfies the policy. A nil id is an anonymous request, denied by the authenticated rule.
+func (p *Policy) Evaluate(id *Identity) Decision {
+	d := Decision{Policy: p.Name, RequiredRoles: p.Roles, RequiredScopes: p.Scopes}
+
+	if id == nil {
+		d.Rule = RuleAuthenticated
+		return d
+	}
+
+	d.PresentRoles = id.Groups
+	d.PresentScopes = id.Scopes
+
+	for _, s := range p.Scopes {
+		if !slices.Contains(id.Scopes, s) {
+			d.MissingScopes = append(d.MissingScopes, s)
+		}
+	}
+
+	switch {
+	case len(p.Roles) > 0 && !slices.ContainsFunc(p.Roles, func(r string) bool { return slices.Contains(id.Groups, r) }):
+		d.Rule = RuleRoles
+	case len(d.MissingScopes) > 0:
+		d.Rule = RuleScopes
+	default:
+		d.Allowed = true
+	}
+
+	return d
+}
+
+func (d *Decision) fields() []zap.Field {
+	return []zap.Field{
+		zap.String("policy", d.Policy),
+		zap.String("rule", d.Rule),
+		zap.Strings("required_roles", d.RequiredRoles),
+		zap.Strings("present_roles", d.PresentRoles),
+		zap.Strings("required_scopes", d.RequiredScopes),
+		zap.Strings("present_scopes", d.PresentScopes),
+		zap.Strings("missing_scopes", d.MissingScopes),
+	}
+}
+
+// Authorizer enforces the policies of the routes.
+type Authorizer struct {
+	log        *logger.Logger
+	explain    bool
+	breakGlass *BreakGlass
+}
+
+// AuthorizerOption configures optional behaviour of an Authorizer.
+type AuthorizerOption func(*Authorizer)
+
+// WithBreakGlass lets requests carrying a break-glass token issued by b through the policies of the routes it grants,
+// for the subject it grants them to. Every use is logged with the reason of the grant.
+func WithBreakGlass(b *BreakGlass) AuthorizerOption {
+	return func(a *Authorizer) {
+		a.breakGlass = b
+	}
+}
+
+// NewAuthorizer creates an Authorizer. When explain is true, denied requests get the Decision in the response body,
+// which discloses the policies and must only be enabled in development.
+func NewAuthorizer(l *logger.Logger, explain bool, opts ...AuthorizerOption) *Authorizer {
+	a := &Authorizer{log: l, explain: explain}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Middleware enforces p on the Identity attached by the authentication Middleware. Anonymous requests are rejected
+// with 401 and requests without the required roles or scopes with 403. Every denial is logged with its Decision.
+func (a *Authorizer) Middleware(p *Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := IdentityFromContext(c.Request.Context())
+
+		d := p.Evaluate(id)
+		if d.Allowed || (id != nil && a.breakGlassAllows(c, id, &d)) {
+			c.Next()
+			return
+		}
+
+		subject := ""
+		if id != nil {
+			subject = id.Subject
+		}
+
+		a.log.Info("access denied", append([]zap.Field{zap.String("subject", subject), zap.String("path", c.FullPath())}, d.fields()...)...)
+		logger.EventFromContext(c.Request.Context()).Add(zap.String("access_policy", d.Policy), zap.String("access_rule", d.Rule))
+
+		status, msg := http.StatusForbidden, "forbidden"
+		if id == nil {
+			status, msg = http.StatusUnauthorized, "unauthorized"
+		}
+
+		c.Error(apperror.Auth(fmt.Errorf("policy %s denied %s: %w", d.Policy, d.Rule, errAccessDenied))) //nolint:errcheck // returns the same error
+
+		body := gin.H{"error": msg}
+		if a.explain {
+			body["decision"] = d
+		}
+
+		c.AbortWithStatusJSON(status, body)
+	}
+}
+
+// breakGlassAllows reports whether the request carries a valid break-glass token granting id access to the route
+// denied by d.
+func (a *Authorizer) breakGlassAllows(c *gin.Context, id *Identity, d *Decision) bool {
+	token := c.GetHeader(BreakGlassHeader)
+	if a.breakGlass == nil || token == "" {
+		return false
+	}
+
+	fields := append([]zap.Field{zap.String("path", c.FullPath())}, d.fields()...)
+
+	g, err := a.breakGlass.Verify(token)
+	if err != nil {
+		a.log.Warn("break-glass token rejected", append(fields, zap.String("subject", id.Subject), zap.Error(err))...)
+		return false
+	}
+
+	if g.Subject != id.Subject || !slices.Contains(g.Routes, c.FullPath()) {
+		a.log.Warn("break-glass token not granted for request", append(fields, g.fields()...)...)
+		return false
+	}
+
+	a.log.Warn("break-glass access", append(fields, g.fields()...)...)
+	logger.EventFromContext(c.Request.Context()).Add(zap.String("break_glass_reason", g.Reason), zap.String("break_glass_issuer", g.Issuer))
+
+	return true
+}
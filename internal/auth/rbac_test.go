@@ -0,0 +1,161 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	policy := &auth.Policy{Name: "photos-admin", Roles: []string{"admin", "editor"}, Scopes: []string{"photos:read", "photos:write"}}
+
+	tests := map[string]struct {
+		id   *auth.Identity
+		want auth.Decision
+	}{
+		"allowed": {
+			id: &auth.Identity{Groups: []string{"editor"}, Scopes: []string{"photos:read", "photos:write"}},
+			want: auth.Decision{
+				Allowed: true, Policy: "photos-admin",
+				RequiredRoles: policy.Roles, PresentRoles: []string{"editor"},
+				RequiredScopes: policy.Scopes, PresentScopes: []string{"photos:read", "photos:write"},
+			},
+		},
+		"anonymous": {
+			want: auth.Decision{Policy: "photos-admin", Rule: auth.RuleAuthenticated, RequiredRoles: policy.Roles, RequiredScopes: policy.Scopes},
+		},
+		"missing role": {
+			id: &auth.Identity{Groups: []string{"viewer"}, Scopes: []string{"photos:read", "photos:write"}},
+			want: auth.Decision{
+				Policy: "photos-admin", Rule: auth.RuleRoles,
+				RequiredRoles: policy.Roles, PresentRoles: []string{"viewer"},
+				RequiredScopes: policy.Scopes, PresentScopes: []string{"photos:read", "photos:write"},
+			},
+		},
+		"missing scope": {
+			id: &auth.Identity{Groups: []string{"admin"}, Scopes: []string{"photos:read"}},
+			want: auth.Decision{
+				Policy: "photos-admin", Rule: auth.RuleScopes,
+				RequiredRoles: policy.Roles, PresentRoles: []string{"admin"},
+				RequiredScopes: policy.Scopes, PresentScopes: []string{"photos:read"}, MissingScopes: []string{"photos:write"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, policy.Evaluate(tt.id))
+		})
+	}
+}
+
+func TestAuthorizer_Middleware(t *testing.T) {
+	t.Parallel()
+
+	bg, err := auth.NewBreakGlass(&auth.BreakGlassConfig{SigningKey: "secret", MaxTTL: time.Hour})
+	assert.NoError(t, err)
+
+	issue := func(subject, route string) string {
+		token, _, err := bg.Issue(auth.BreakGlassGrant{Subject: subject, Routes: []string{route}, Reason: "INC-42", Issuer: "alice"}, time.Hour)
+		assert.NoError(t, err)
+
+		return token
+	}
+
+	type args struct {
+		explain    bool
+		id         *auth.Identity
+		breakGlass string
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"allowed": {
+			args: args{id: &auth.Identity{Subject: "alice", Groups: []string{"admin"}}},
+			want: want{code: http.StatusOK, body: "ok"},
+		},
+		"anonymous": {
+			want: want{code: http.StatusUnauthorized, body: `{"error":"unauthorized"}`},
+		},
+		"forbidden": {
+			args: args{id: &auth.Identity{Subject: "bob", Groups: []string{"viewer"}}},
+			want: want{code: http.StatusForbidden, body: `{"error":"forbidden"}`},
+		},
+		"forbidden explained": {
+			args: args{explain: true, id: &auth.Identity{Subject: "bob", Groups: []string{"viewer"}}},
+			want: want{
+				code: http.StatusForbidden,
+				body: `{"decision":{"allowed":false,"policy":"admin","rule":"roles","required_roles":["admin"],"present_roles":["viewer"]},"error":"forbidden"}`,
+			},
+		},
+		"break-glass": {
+			args: args{id: &auth.Identity{Subject: "bob", Groups: []string{"viewer"}}, breakGlass: issue("bob", "/admin")},
+			want: want{code: http.StatusOK, body: "ok"},
+		},
+		"break-glass of another subject": {
+			args: args{id: &auth.Identity{Subject: "bob", Groups: []string{"viewer"}}, breakGlass: issue("carol", "/admin")},
+			want: want{code: http.StatusForbidden, body: `{"error":"forbidden"}`},
+		},
+		"break-glass of another route": {
+			args: args{id: &auth.Identity{Subject: "bob", Groups: []string{"viewer"}}, breakGlass: issue("bob", "/photos/:id")},
+			want: want{code: http.StatusForbidden, body: `{"error":"forbidden"}`},
+		},
+		"break-glass invalid": {
+			args: args{id: &auth.Identity{Subject: "bob", Groups: []string{"viewer"}}, breakGlass: "forged.token"},
+			want: want{code: http.StatusForbidden, body: `{"error":"forbidden"}`},
+		},
+		"break-glass anonymous": {
+			args: args{breakGlass: issue("bob", "/admin")},
+			want: want{code: http.StatusUnauthorized, body: `{"error":"unauthorized"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a := auth.NewAuthorizer(logger.NewNop(), tt.args.explain, auth.WithBreakGlass(bg))
+
+			router := gin.New()
+			router.GET("/admin", func(c *gin.Context) {
+				if tt.args.id != nil {
+					c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), tt.args.id))
+				}
+			}, a.Middleware(&auth.Policy{Name: "admin", Roles: []string{"admin"}}), func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", http.NoBody)
+			if tt.args.breakGlass != "" {
+				req.Header.Set(auth.BreakGlassHeader, tt.args.breakGlass)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package chaos injects faults into requests, latency, error responses and connection resets, so that the retries and
+// circuit breakers of the clients can be exercised in staging. Faults are configured by route, and each request may
+// ask for its own with the X-Chaos header when allowed. It is never enabled in production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Header is the request header asking for a fault, as a comma separated list of latency=<duration>,
+// jitter=<duration>, status=<code>, reset and percent=<0-100>, e.g. "latency=500ms,status=503".
+const Header = "X-Chaos"
+
+// percent is the scale of the fault percentage.
+const percent = 100
+
+// maxStatus is the greatest error status.
+const maxStatus = 599
+
+// errInvalidFault is returned for faults with an unknown parameter or an out of range value.
+var errInvalidFault = errors.New("invalid fault")
+
+// Fault is the fault injected into a request.
+type Fault struct {
+	// Percent is the percentage of the requests faulted. Zero faults every request.
+	Percent float64
+	// Latency delays the request, by up to Jitter more.
+	Latency time.Duration
+	Jitter  time.Duration
+	// Status answers the request with this error status instead of handling it. Zero handles it.
+	Status int
+	// Reset closes the connection without answering.
+	Reset bool
+}
+
+// String returns the fault in the syntax of the header.
+func (f Fault) String() string {
+	var params []string
+
+	if f.Percent > 0 {
+		params = append(params, "percent="+strconv.FormatFloat(f.Percent, 'g', -1, 64))
+	}
+
+	if f.Latency > 0 {
+		params = append(params, "latency="+f.Latency.String())
+	}
+
+	if f.Jitter > 0 {
+		params = append(params, "jitter="+f.Jitter.String())
+	}
+
+	if f.Status != 0 {
+		params = append(params, "status="+strconv.Itoa(f.Status))
+	}
+
+	if f.Reset {
+		params = append(params, "reset")
+	}
+
+	return strings.Join(params, ",")
+}
+
+func (f Fault) validate() error {
+	switch {
+	case f.Percent < 0 || f.Percent > percent:
+		return fmt.Errorf("%w: percent %g is not between 0 and 100", errInvalidFault, f.Percent)
+	case f.Latency < 0 || f.Jitter < 0:
+		return fmt.Errorf("%w: negative latency", errInvalidFault)
+	case f.Status != 0 && (f.Status < http.StatusBadRequest || f.Status > maxStatus):
+		return fmt.Errorf("%w: status %d is not an error status", errInvalidFault, f.Status)
+	default:
+		return nil
+	}
+}
+
+// ParseFault parses a fault in the syntax of the header.
+func ParseFault(s string) (Fault, error) {
+	var f Fault
+
+	for _, param := range strings.Split(s, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+
+		var err error
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "percent":
+			f.Percent, err = strconv.ParseFloat(value, 64)
+		case "latency":
+			f.Latency, err = time.ParseDuration(value)
+		case "jitter":
+			f.Jitter, err = time.ParseDuration(value)
+		case "status":
+			f.Status, err = strconv.Atoi(value)
+		case "reset":
+			f.Reset = true
+			if value != "" {
+				f.Reset, err = strconv.ParseBool(value)
+			}
+		default:
+			return Fault{}, fmt.Errorf("%w: unknown parameter %q", errInvalidFault, param)
+		}
+
+		if err != nil {
+			return Fault{}, fmt.Errorf("%w: %s: %w", errInvalidFault, param, err)
+		}
+	}
+
+	return f, f.validate()
+}
+
+// Injector injects the faults of the routes and of the header into requests.
+type Injector struct {
+	routes      map[string]Fault
+	allowHeader bool
+	log         *logger.Logger
+	rand        func() float64
+}
+
+// New creates an Injector from cfg.
+func New(cfg *config.Chaos, l *logger.Logger) (*Injector, error) {
+	routes := make(map[string]Fault, len(cfg.Routes))
+
+	for _, r := range cfg.Routes {
+		if r.Path == "" {
+			return nil, errors.New("chaos route path is required")
+		}
+
+		f := Fault{Percent: r.Percent, Latency: r.Latency, Jitter: r.Jitter, Status: r.Status, Reset: r.Reset}
+		if err := f.validate(); err != nil {
+			return nil, fmt.Errorf("chaos route %s %s: %w", r.Method, r.Path, err)
+		}
+
+		routes[routeKey(r.Method, r.Path)] = f
+	}
+
+	return &Injector{routes: routes, allowHeader: cfg.AllowHeader, log: l, rand: rand.Float64}, nil //nolint:gosec // sampling does not need a secure source
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// Middleware injects the fault of the header, if allowed and valid, or else the one of the route. Invalid headers are
+// ignored, so a request always reaches the handler unless it is faulted.
+func (i *Injector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := i.fault(c)
+		if !ok || (f.Percent > 0 && i.rand()*percent >= f.Percent) {
+			c.Next()
+			return
+		}
+
+		logger.EventFromContext(c.Request.Context()).Add(zap.Stringer("chaos", f))
+
+		if !delay(c.Request.Context(), f.Latency+i.jitter(f.Jitter)) {
+			c.Abort()
+			return
+		}
+
+		switch {
+		case f.Reset:
+			reset(c)
+		case f.Status != 0:
+			c.AbortWithStatusJSON(f.Status, gin.H{"error": "injected fault"})
+		default:
+			c.Next()
+		}
+	}
+}
+
+func (i *Injector) fault(c *gin.Context) (Fault, bool) {
+	if header := c.GetHeader(Header); header != "" && i.allowHeader {
+		f, err := ParseFault(header)
+		if err == nil {
+			return f, true
+		}
+
+		i.log.Debug("ignoring chaos header", zap.String("header", header), zap.Error(err))
+	}
+
+	if f, ok := i.routes[routeKey(c.Request.Method, c.FullPath())]; ok {
+		return f, true
+	}
+
+	f, ok := i.routes[routeKey("", c.FullPath())]
+
+	return f, ok
+}
+
+func (i *Injector) jitter(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+
+	return time.Duration(i.rand() * float64(limit))
+}
+
+// delay waits for d, and reports whether it did before ctx was done.
+func delay(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reset closes the connection of the request without answering, with a TCP reset rather than a graceful close.
+// Connections which cannot be taken over, such as HTTP/2 ones, are answered 503 instead.
+func reset(c *gin.Context) {
+	c.Abort()
+
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "injected fault"})
+		return
+	}
+
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0) //nolint:errcheck // best effort, a graceful close still fails the request
+	}
+
+	conn.Close()
+}
@@ -0,0 +1,186 @@
+package chaos_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/chaos"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func newRouter(t *testing.T, cfg *config.Chaos) *gin.Engine {
+	t.Helper()
+
+	inj, err := chaos.New(cfg, logger.NewNop())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	router := gin.New()
+	router.Use(inj.Middleware())
+	router.GET("/photos/:id", func(c *gin.Context) { c.String(http.StatusOK, "photo") })
+	router.POST("/photos", func(c *gin.Context) { c.String(http.StatusCreated, "created") })
+
+	return router
+}
+
+func TestInjector_Middleware(t *testing.T) {
+	t.Parallel()
+
+	routes := []config.ChaosRoute{
+		{Method: http.MethodGet, Path: "/photos/:id", Status: http.StatusServiceUnavailable},
+		{Path: "/photos", Latency: 50 * time.Millisecond},
+	}
+
+	type args struct {
+		cfg    *config.Chaos
+		method string
+		path   string
+		header string
+	}
+
+	type want struct {
+		status     int
+		body       string
+		minLatency time.Duration
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"route status": {
+			args: args{cfg: &config.Chaos{Routes: routes}, method: http.MethodGet, path: "/photos/1"},
+			want: want{status: http.StatusServiceUnavailable, body: `{"error":"injected fault"}`},
+		},
+		"route latency for any method": {
+			args: args{cfg: &config.Chaos{Routes: routes}, method: http.MethodPost, path: "/photos"},
+			want: want{status: http.StatusCreated, body: "created", minLatency: 50 * time.Millisecond},
+		},
+		"no fault": {
+			args: args{cfg: &config.Chaos{}, method: http.MethodGet, path: "/photos/1"},
+			want: want{status: http.StatusOK, body: "photo"},
+		},
+		"header": {
+			args: args{cfg: &config.Chaos{AllowHeader: true}, method: http.MethodGet, path: "/photos/1", header: "latency=20ms, status=502"},
+			want: want{status: http.StatusBadGateway, body: `{"error":"injected fault"}`, minLatency: 20 * time.Millisecond},
+		},
+		"header overrides route": {
+			args: args{cfg: &config.Chaos{AllowHeader: true, Routes: routes}, method: http.MethodGet, path: "/photos/1", header: "status=500"},
+			want: want{status: http.StatusInternalServerError, body: `{"error":"injected fault"}`},
+		},
+		"header not allowed": {
+			args: args{cfg: &config.Chaos{}, method: http.MethodGet, path: "/photos/1", header: "status=500"},
+			want: want{status: http.StatusOK, body: "photo"},
+		},
+		"invalid header": {
+			args: args{cfg: &config.Chaos{AllowHeader: true, Routes: routes}, method: http.MethodGet, path: "/photos/1", header: "status=200"},
+			want: want{status: http.StatusServiceUnavailable, body: `{"error":"injected fault"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			router := newRouter(t, tt.args.cfg)
+
+			req := httptest.NewRequest(tt.args.method, tt.args.path, http.NoBody)
+			if tt.args.header != "" {
+				req.Header.Set(chaos.Header, tt.args.header)
+			}
+
+			resp := httptest.NewRecorder()
+			start := time.Now()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.status, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+			assert.GreaterOrEqual(t, time.Since(start), tt.want.minLatency)
+		})
+	}
+}
+
+func TestInjector_Middleware_Reset(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(newRouter(t, &config.Chaos{Routes: []config.ChaosRoute{{Path: "/photos/:id", Reset: true}}}))
+	t.Cleanup(srv.Close)
+
+	resp, err := srv.Client().Get(srv.URL + "/photos/1")
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	assert.Error(t, err)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]config.ChaosRoute{
+		"no path":        {Status: http.StatusServiceUnavailable},
+		"percent":        {Path: "/", Percent: 101},
+		"negative delay": {Path: "/", Latency: -time.Second},
+		"success status": {Path: "/", Status: http.StatusOK},
+	}
+
+	for name, route := range tests {
+		_, err := chaos.New(&config.Chaos{Routes: []config.ChaosRoute{route}}, logger.NewNop())
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseFault(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header  string
+		want    chaos.Fault
+		wantErr bool
+	}{
+		"all": {
+			header: "percent=25, latency=1s, jitter=100ms, status=503, reset",
+			want:   chaos.Fault{Percent: 25, Latency: time.Second, Jitter: 100 * time.Millisecond, Status: http.StatusServiceUnavailable, Reset: true},
+		},
+		"reset false": {
+			header: "Reset=false,latency=5ms",
+			want:   chaos.Fault{Latency: 5 * time.Millisecond},
+		},
+		"unknown parameter": {header: "drop", wantErr: true},
+		"invalid duration":  {header: "latency=soon", wantErr: true},
+		"not an error":      {header: "status=204", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := chaos.ParseFault(tt.header)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+
+			again, err := chaos.ParseFault(got.String())
+			assert.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type getter interface {
+	Get(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error)
+}
+
+type poster interface {
+	Post(ctx context.Context, url, contentType string, body io.Reader, opts ...RequestOption) (*http.Response, error)
+}
+
+// GetAs performs a GET request and decodes the response into a T, as JSON unless another codec is set with WithCodec.
+// A 204 No Content response returns a nil T.
+func GetAs[T any](ctx context.Context, c getter, url string, opts ...RequestOption) (*T, error) {
+	codec := codecOf(opts)
+
+	resp, err := c.Get(ctx, url, append(opts, WithCodec(codec))...)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors of the client helpers are already wrapped
+	}
+
+	return decode[T](resp, codec)
+}
+
+// PostAs performs a POST request with body encoded, and decodes the response into a T, as JSON unless another codec
+// is set with WithCodec. A 204 No Content response returns a nil T.
+func PostAs[T any](ctx context.Context, c poster, url string, body any, opts ...RequestOption) (*T, error) {
+	codec := codecOf(opts)
+
+	b, err := codec.Encode(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	resp, err := c.Post(ctx, url, codec.ContentType(), bytes.NewReader(b), append(opts, WithCodec(codec))...)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors of the client helpers are already wrapped
+	}
+
+	return decode[T](resp, codec)
+}
+
+// codecOf returns the codec set with WithCodec, or JSON.
+func codecOf(opts []RequestOption) Codec {
+	if codec := newRequestOptions(opts).codec; codec != nil {
+		return codec
+	}
+
+	return JSONCodec{}
+}
+
+func decode[T any](resp *http.Response, codec Codec) (*T, error) {
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var v T
+	if err := codec.Decode(resp.Body, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return &v, nil
+}
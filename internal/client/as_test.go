@@ -0,0 +1,112 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetAs(t *testing.T) {
+	type args struct {
+		opts []client.RequestOption
+	}
+
+	type fields struct {
+		status int
+		body   string
+	}
+
+	type want struct {
+		item *item
+		err  error
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"200": {
+			fields: fields{status: http.StatusOK, body: `{"id":1,"name":"a"}`},
+			want:   want{item: &item{ID: 1, Name: "a"}},
+		},
+		"202 accepted by default": {
+			fields: fields{status: http.StatusAccepted, body: `{"id":2,"name":"b"}`},
+			want:   want{item: &item{ID: 2, Name: "b"}},
+		},
+		"204 returns nil": {
+			fields: fields{status: http.StatusNoContent},
+			want:   want{},
+		},
+		"304 is an error": {
+			fields: fields{status: http.StatusNotModified},
+			want:   want{err: errors.New("received non-OK HTTP status: 304")},
+		},
+		"status not in configured codes": {
+			args:   args{opts: []client.RequestOption{client.WithStatusCodes(http.StatusOK)}},
+			fields: fields{status: http.StatusAccepted, body: `{}`},
+			want:   want{err: errors.New("received non-OK HTTP status: 202: {}")},
+		},
+		"configured non 2xx code": {
+			args:   args{opts: []client.RequestOption{client.WithStatusCodes(http.StatusOK, http.StatusConflict)}},
+			fields: fields{status: http.StatusConflict, body: `{"id":3}`},
+			want:   want{item: &item{ID: 3}},
+		},
+		"invalid body": {
+			fields: fields{status: http.StatusOK, body: `{`},
+			want:   want{err: errors.New("failed to decode response body: invalid json: unexpected EOF")},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.fields.status)
+				w.Write([]byte(tt.fields.body))
+			}))
+			defer server.Close()
+
+			got, err := client.GetAs[item](context.Background(), client.NewClient(server.Client()), server.URL, tt.args.opts...)
+			if tt.want.err != nil {
+				assert.ErrorContains(t, err, tt.want.err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.item, got)
+		})
+	}
+}
+
+func TestPostAs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var in item
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(item{ID: 10, Name: in.Name})
+	}))
+	defer server.Close()
+
+	got, err := client.PostAs[item](context.Background(), client.NewClient(server.Client()), server.URL, item{Name: "new"})
+	assert.NoError(t, err)
+	assert.Equal(t, &item{ID: 10, Name: "new"}, got)
+}
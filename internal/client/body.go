@@ -0,0 +1,29 @@
+package client
+
+import (
+	"io"
+)
+
+// maxDrainSize bounds how much of an unread body is discarded to allow reusing the connection. Draining larger bodies
+// costs more than opening a new connection.
+const maxDrainSize = 256 << 10
+
+// drainAndClose discards the rest of body, up to maxDrainSize, and closes it. A connection is only reused once the
+// body of its response is read to the end, which decoders stopping at the end of a value or at an error do not do.
+func drainAndClose(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainSize) //nolint:errcheck // best effort, a connection failing to drain is not reused
+	body.Close()
+}
+
+// drainingBody drains the body of a response when closed, for the callers of Get, Post, Put and Do closing it
+// without reading it to the end.
+type drainingBody struct {
+	io.ReadCloser
+}
+
+// Close implements io.Closer.
+func (b drainingBody) Close() error {
+	io.CopyN(io.Discard, b.ReadCloser, maxDrainSize) //nolint:errcheck // best effort, as drainAndClose
+
+	return b.ReadCloser.Close() //nolint:wrapcheck // the body is transparent
+}
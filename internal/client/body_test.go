@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+// TestClient_ConnectionReuse checks that the connection of a response is reused whatever the outcome of its request,
+// as it is only when the body was read to the end before being closed. The end of the bodies is sent late, as the
+// transport drains the bodies sent at once itself.
+func TestClient_ConnectionReuse(t *testing.T) {
+	t.Parallel()
+
+	// padding is left unread by decoders stopping at the end of a value, or at an error.
+	padding := strings.Repeat(" ", 64<<10)
+	long := strings.Repeat("a", 2<<10)
+
+	type fields struct {
+		status int
+		body   string
+		opts   []client.Option
+	}
+
+	tests := map[string]struct {
+		fields fields
+		call   func(ctx context.Context, c *client.Client, url string) error
+	}{
+		"error status": {
+			fields: fields{status: http.StatusInternalServerError, body: `{"error":"boom"}`},
+			call: func(ctx context.Context, c *client.Client, url string) error {
+				_, err := client.GetAs[item](ctx, c, url)
+				return err
+			},
+		},
+		"decode error": {
+			fields: fields{status: http.StatusOK, body: `{"id":"1"}`},
+			call: func(ctx context.Context, c *client.Client, url string) error {
+				_, err := client.GetAs[item](ctx, c, url)
+				return err
+			},
+		},
+		"data after the value": {
+			fields: fields{status: http.StatusOK, body: `{"id":1}`},
+			call: func(ctx context.Context, c *client.Client, url string) error {
+				_, err := client.GetAs[item](ctx, c, url)
+				return err
+			},
+		},
+		"body closed unread": {
+			fields: fields{status: http.StatusOK, body: `{"id":1}`},
+			call: func(ctx context.Context, c *client.Client, url string) error {
+				resp, err := c.Get(ctx, url)
+				if err != nil {
+					return err
+				}
+
+				return resp.Body.Close()
+			},
+		},
+		"stream closed early": {
+			fields: fields{status: http.StatusOK, body: `[{"id":1},{"id":2}]`},
+			call: func(ctx context.Context, c *client.Client, url string) error {
+				s := client.GetStream[item](ctx, c, url)
+				s.Next()
+				s.Close()
+
+				return s.Err()
+			},
+		},
+		"response too large": {
+			fields: fields{status: http.StatusOK, body: `{"id":1,"name":"` + long + `"}`, opts: []client.Option{client.WithMaxResponseSize(1 << 10)}},
+			call: func(ctx context.Context, c *client.Client, url string) error {
+				_, err := client.GetAs[item](ctx, c, url)
+				return err
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var conns atomic.Int32
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.fields.status)
+				io.WriteString(w, tt.fields.body)
+				w.(http.Flusher).Flush()
+
+				time.Sleep(100 * time.Millisecond)
+				io.WriteString(w, padding)
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns.Add(1)
+				}
+			}
+
+			server.Start()
+			defer server.Close()
+
+			c := client.NewClient(server.Client(), tt.fields.opts...)
+
+			for i := 0; i < 3; i++ {
+				tt.call(context.Background(), c, server.URL) //nolint:errcheck // the outcome of the requests does not matter
+			}
+
+			assert.Equal(t, int32(1), conns.Load())
+		})
+	}
+}
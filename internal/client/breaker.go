@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+const (
+	// CircuitStateMetric is the state of the circuit of a base URL: 0 closed, 1 open and 2 half-open.
+	CircuitStateMetric = "http_client_circuit_state"
+	// CircuitRejectedMetric counts the requests failed while the circuit of a base URL is open, by base URL.
+	CircuitRejectedMetric = "http_client_circuit_rejected_total"
+)
+
+// defaultOpenTimeout is how long a circuit stays open when none is configured.
+const defaultOpenTimeout = 30 * time.Second
+
+// ErrCircuitOpen is returned by a CircuitBreaker for the requests failed without being sent while its circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// States of a circuit.
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type gaugeRecorder interface {
+	recorder
+	Set(name string, v float64, labels metrics.Labels)
+}
+
+// CircuitBreaker is an http.RoundTripper failing fast while an upstream is down: after a number of consecutive
+// failures, transport errors or 5xx statuses, its circuit opens and requests fail with ErrCircuitOpen for the open
+// timeout. Then a single trial request is sent, closing the circuit if it succeeds and opening it again otherwise.
+type CircuitBreaker struct {
+	baseURL     string
+	failures    int
+	openTimeout time.Duration
+	next        http.RoundTripper
+	rec         gaugeRecorder
+	now         func() time.Time
+
+	mu          sync.Mutex
+	state       int
+	consecutive int
+	openedAt    time.Time
+	// trial is set while the trial request of the half-open circuit is in flight.
+	trial bool
+}
+
+// NewCircuitBreaker creates the CircuitBreaker of cfg for the upstream at baseURL, delegating to next.
+func NewCircuitBreaker(baseURL string, cfg *config.CircuitBreaker, next http.RoundTripper, rec gaugeRecorder) (*CircuitBreaker, error) {
+	if cfg.Failures <= 0 {
+		return nil, fmt.Errorf("circuit breaker of %s: failures must be positive", baseURL)
+	}
+
+	openTimeout := cfg.OpenTimeout
+	if openTimeout <= 0 {
+		openTimeout = defaultOpenTimeout
+	}
+
+	return &CircuitBreaker{baseURL: baseURL, failures: cfg.Failures, openTimeout: openTimeout, next: next, rec: rec, now: time.Now}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (b *CircuitBreaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !b.allow() {
+		b.rec.Inc(CircuitRejectedMetric, metrics.Labels{"base_url": b.baseURL})
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, b.baseURL)
+	}
+
+	resp, err := b.next.RoundTrip(req)
+
+	// A canceled request says nothing of the upstream.
+	b.record(err == nil && resp.StatusCode < http.StatusInternalServerError, req.Context().Err() != nil)
+
+	return resp, err //nolint:wrapcheck // the circuit breaker is transparent
+}
+
+// allow reports whether a request may be sent, making it the trial request once the open timeout elapsed.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+
+		b.setState(circuitHalfOpen)
+		b.trial = true
+
+		return true
+	case circuitHalfOpen:
+		if b.trial {
+			return false
+		}
+
+		b.trial = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the circuit with the outcome of a request.
+func (b *CircuitBreaker) record(ok, canceled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasTrial := b.state == circuitHalfOpen
+	if wasTrial {
+		b.trial = false
+	}
+
+	switch {
+	case canceled && !ok:
+	case ok:
+		b.consecutive = 0
+		if wasTrial {
+			b.setState(circuitClosed)
+		}
+	default:
+		b.consecutive++
+		if wasTrial || b.consecutive >= b.failures {
+			b.openedAt = b.now()
+			b.setState(circuitOpen)
+		}
+	}
+}
+
+func (b *CircuitBreaker) setState(state int) {
+	b.state = state
+	b.rec.Set(CircuitStateMetric, float64(state), metrics.Labels{"base_url": b.baseURL})
+}
@@ -0,0 +1,93 @@
+package client_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls atomic.Int32
+		down  atomic.Bool
+	)
+
+	down.Store(true)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	b, err := client.NewCircuitBreaker(srv.URL, &config.CircuitBreaker{Failures: 2, OpenTimeout: 50 * time.Millisecond}, http.DefaultTransport, metrics.Nop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	send := func() (int, error) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, http.NoBody) //nolint:noctx // the test does not cancel
+
+		resp, err := b.RoundTrip(req)
+		if err != nil {
+			return 0, err
+		}
+
+		resp.Body.Close()
+
+		return resp.StatusCode, nil
+	}
+
+	// The circuit opens after 2 failures.
+	for i := 0; i < 2; i++ {
+		status, err := send()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, status)
+	}
+
+	_, err = send()
+	assert.ErrorIs(t, err, client.ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// The failed trial request opens the circuit again.
+	time.Sleep(60 * time.Millisecond)
+
+	status, err := send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	_, err = send()
+	assert.ErrorIs(t, err, client.ErrCircuitOpen)
+
+	// The successful trial request closes the circuit.
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		status, err = send()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	}
+
+	assert.Equal(t, int32(5), calls.Load())
+}
+
+func TestNewCircuitBreaker_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := client.NewCircuitBreaker("http://upstream", &config.CircuitBreaker{}, http.DefaultTransport, metrics.Nop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheControl holds the directives of a Cache-Control header. Directives without a value map to "".
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+
+			cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// seconds returns the value of a delta-seconds directive such as max-age.
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	v, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	return time.Duration(n) * time.Second, true
+}
+
+// freshnessLifetime returns how long a response is fresh for a shared cache, from s-maxage, max-age or Expires.
+func freshnessLifetime(h http.Header, cc cacheControl) time.Duration {
+	if cc.has("no-cache") {
+		return 0
+	}
+
+	if d, ok := cc.seconds("s-maxage"); ok {
+		return d
+	}
+
+	if d, ok := cc.seconds("max-age"); ok {
+		return d
+	}
+
+	expires, err := http.ParseTime(h.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+
+	date, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return 0
+	}
+
+	if d := expires.Sub(date); d > 0 {
+		return d
+	}
+
+	return 0
+}
+
+// initialAge returns the age the response already had when it was received, from the Age header.
+func initialAge(h http.Header) time.Duration {
+	n, err := strconv.ParseInt(h.Get("Age"), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return time.Duration(n) * time.Second
+}
+
+func hasValidator(h http.Header) bool {
+	return h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
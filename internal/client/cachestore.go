@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryStore is a CacheStore keeping entries in memory. It holds at most maxEntries entries, evicting expired
+// entries first and arbitrary ones when none expired.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]memoryEntry
+	now        func() time.Time
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore creates a MemoryStore holding at most maxEntries entries. Zero means no limit.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{maxEntries: maxEntries, entries: map[string]memoryEntry{}, now: time.Now}
+}
+
+// Get implements CacheStore.
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !m.now().Before(e.expires) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	return e.value, true, nil
+}
+
+// Set implements CacheStore.
+func (m *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[key]; !ok && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evict()
+	}
+
+	m.entries[key] = memoryEntry{value: value, expires: m.now().Add(ttl)}
+
+	return nil
+}
+
+// evict removes the expired entries, or an arbitrary one if none expired.
+func (m *MemoryStore) evict() {
+	now := m.now()
+	evicted := false
+
+	for key, e := range m.entries {
+		if !now.Before(e.expires) {
+			delete(m.entries, key)
+
+			evicted = true
+		}
+	}
+
+	if evicted {
+		return
+	}
+
+	for key := range m.entries {
+		delete(m.entries, key)
+		return
+	}
+}
+
+// RedisStore is a CacheStore keeping entries in Redis, so they are shared between instances and survive restarts.
+type RedisStore struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisStore creates a RedisStore.
+func NewRedisStore(rdb redis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+// Get implements CacheStore.
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := r.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+
+	return b, true, nil
+}
+
+// Set implements CacheStore.
+func (r *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -4,34 +4,243 @@ package client
 import (
 	"context"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"slices"
+	"sort"
 )
 
 type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// Client is a wrapper around the http client.
+// Client is a wrapper around the http client. The bodies of the responses returned by its methods are drained when
+// closed, and the ones of the errors it returns are closed already, so that their connections are reused.
 type Client struct {
 	httpClient httpClient
+	// jar holds the cookies when httpClient is not an *http.Client handling them itself.
+	jar http.CookieJar
+	// schemas validate the responses of the paths they are registered for.
+	schemas []responseSchema
+	// maxResponseSize bounds the bodies of the successful responses, zero for none.
+	maxResponseSize int64
+	// timingRec records the latency breakdown of the requests to upstream, nil for none.
+	timingRec recorder
+	upstream  string
 }
 
 // NewClient creates a new Client.
-func NewClient(httpClient httpClient) *Client {
-	return &Client{httpClient: httpClient}
+func NewClient(httpClient httpClient, opts ...Option) *Client {
+	c := &Client{httpClient: httpClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// RequestOption configures a single request made by Get, Post and Put.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	statusCodes []int
+	anyStatus   bool
+	codec       Codec
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithStatusCodes sets the response statuses accepted as success. By default any 2xx status is accepted.
+func WithStatusCodes(codes ...int) RequestOption {
+	return func(o *requestOptions) {
+		o.statusCodes = codes
+	}
+}
+
+// WithAnyStatus accepts any response status as success, for callers handling the status themselves.
+func WithAnyStatus() RequestOption {
+	return func(o *requestOptions) {
+		o.anyStatus = true
+	}
+}
+
+// WithCodec sets the codec of the request and response bodies of GetAs and PostAs, and the Accept header of the
+// request. GetAs and PostAs default to JSON.
+func WithCodec(codec Codec) RequestOption {
+	return func(o *requestOptions) {
+		o.codec = codec
+	}
+}
+
+func (o *requestOptions) success(code int) bool {
+	if o.anyStatus {
+		return true
+	}
+
+	if len(o.statusCodes) == 0 {
+		return code >= http.StatusOK && code < http.StatusMultipleChoices
+	}
+
+	return slices.Contains(o.statusCodes, code)
 }
 
-// Get performs a GET request.
-func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+// Get performs a GET request. A response with an unexpected status is returned as *HTTPError, and one violating the
+// schema of its path as *SchemaViolationError.
+func (c *Client) Get(ctx context.Context, url string, opts ...RequestOption) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	return c.doExpect(req, newRequestOptions(opts))
+}
+
+// Post performs a POST request with the given body. A response with an unexpected status is returned as *HTTPError.
+func (c *Client) Post(ctx context.Context, url, contentType string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	return c.doExpect(req, newRequestOptions(opts))
+}
+
+// Put performs a PUT request with the given body. A response with an unexpected status is returned as *HTTPError.
+func (c *Client) Put(ctx context.Context, url, contentType string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	return c.doExpect(req, newRequestOptions(opts))
+}
+
+// Do performs req, for the methods without a helper such as DELETE. A response with an unexpected status is returned
+// as *HTTPError.
+func (c *Client) Do(req *http.Request, opts ...RequestOption) (*http.Response, error) {
+	return c.doExpect(req, newRequestOptions(opts))
+}
+
+func (c *Client) doExpect(req *http.Request, o *requestOptions) (*http.Response, error) {
+	if o.codec != nil {
+		req.Header.Set("Accept", o.codec.ContentType())
+	}
+
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform request: %w", err)
+		return nil, err
+	}
+
+	if !o.success(resp.StatusCode) {
+		return nil, newHTTPError(resp)
+	}
+
+	resp.Body = drainingBody{resp.Body}
+
+	if err = c.limitBody(req, resp); err != nil {
+		return nil, err
+	}
+
+	if err = c.validateSchema(req, resp); err != nil {
+		return nil, err
 	}
 
 	return resp, nil
 }
+
+// FormFile is a file sent as part of a multipart request.
+type FormFile struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Content     io.Reader
+}
+
+// PostMultipart performs a POST request with a multipart/form-data body built from fields and files.
+// The body is streamed to the server while it is written, so file contents are never buffered in memory.
+// A response with an unexpected status is returned as *HTTPError.
+func (c *Client) PostMultipart(ctx context.Context, url string, fields map[string]string, files []FormFile, opts ...RequestOption) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
+	if err != nil {
+		pr.Close()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := c.doExpect(req, newRequestOptions(opts))
+	// Unblock the writer in case the body was not fully consumed.
+	pr.Close()
+
+	return resp, err
+}
+
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files []FormFile) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := mw.WriteField(name, fields[name]); err != nil {
+			return fmt.Errorf("failed to write field %s: %w", name, err)
+		}
+	}
+
+	for _, f := range files {
+		if err := writeFormFile(mw, f); err != nil {
+			return err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	return nil
+}
+
+func writeFormFile(mw *multipart.Writer, f FormFile) error {
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": f.FieldName, "filename": f.FileName}))
+	h.Set("Content-Type", contentType)
+
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("failed to create part for %s: %w", f.FieldName, err)
+	}
+
+	if _, err := io.Copy(w, f.Content); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", f.FileName, err)
+	}
+
+	return nil
+}
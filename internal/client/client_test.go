@@ -2,9 +2,13 @@ package client_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/twk/skeleton-go-api/internal/client"
@@ -45,14 +49,13 @@ func TestClient_Get(t *testing.T) {
 				setup: func() *httptest.Server {
 					return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 						w.WriteHeader(http.StatusInternalServerError)
+						w.Write([]byte(`{"error":"boom"}`))
 					}))
 				},
 				context: context.Background,
 			},
 			want: want{
-				resp: &http.Response{
-					StatusCode: http.StatusInternalServerError,
-				},
+				err: errors.New(`received non-OK HTTP status: 500: {"error":"boom"}`),
 			},
 		},
 		"Request timeout": {
@@ -91,3 +94,178 @@ func TestClient_Get(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_PostMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, int64(-1), r.ContentLength)
+
+		err := r.ParseMultipartForm(1 << 20)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "hello", r.FormValue("note"))
+
+		f, fh, err := r.FormFile("file")
+		assert.NoError(t, err)
+
+		defer f.Close()
+
+		b, _ := io.ReadAll(f)
+		assert.Equal(t, "file content", string(b))
+		assert.Equal(t, "a.txt", fh.Filename)
+		assert.Equal(t, "text/plain", fh.Header.Get("Content-Type"))
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	resp, err := c.PostMultipart(context.Background(), server.URL, map[string]string{"note": "hello"}, []client.FormFile{
+		{FieldName: "file", FileName: "a.txt", ContentType: "text/plain", Content: strings.NewReader("file content")},
+	})
+	assert.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestClient_PostMultipart_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	_, err := c.PostMultipart(context.Background(), server.URL+"/uploads", nil, []client.FormFile{
+		{FieldName: "file", FileName: "a.txt", Content: strings.NewReader("file content")},
+	})
+
+	var httpErr *client.HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.StatusCode)
+	assert.Equal(t, http.MethodPost, httpErr.Method)
+	assert.Equal(t, server.URL+"/uploads", httpErr.URL)
+
+	resp, err := c.PostMultipart(context.Background(), server.URL+"/uploads", nil, nil, client.WithAnyStatus())
+	assert.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestClient_PostMultipart_ReaderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		assert.Error(t, err)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	_, err := c.PostMultipart(context.Background(), server.URL, nil, []client.FormFile{
+		{FieldName: "file", FileName: "a.txt", Content: iotest.ErrReader(assert.AnError)},
+	})
+	assert.ErrorContains(t, err, assert.AnError.Error())
+}
+
+func TestClient_Put(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		b, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"a":1}`, string(b))
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	resp, err := c.Put(context.Background(), server.URL, "application/json", strings.NewReader(`{"a":1}`), client.WithStatusCodes(http.StatusCreated))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestClient_Do(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, server.URL+"/photos/1", http.NoBody)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = c.Do(req)
+
+	var httpErr *client.HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodDelete, server.URL+"/photos/1", http.NoBody)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp, err := c.Do(req, client.WithAnyStatus())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestClient_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(strings.Repeat("x", 10<<10)))
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	_, err := c.Post(context.Background(), server.URL+"/photos", "application/json", strings.NewReader("{}"))
+
+	var httpErr *client.HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, http.MethodPost, httpErr.Method)
+	assert.Equal(t, server.URL+"/photos", httpErr.URL)
+	assert.Equal(t, "abc", httpErr.Header.Get("X-Request-Id"))
+	assert.Len(t, httpErr.Body, 4<<10)
+}
+
+func TestClient_HTTPError_Redacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.Client())
+
+	_, err := c.Get(context.Background(), strings.Replace(server.URL, "://", "://user:pass@", 1)+"/photos")
+
+	var httpErr *client.HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, strings.Replace(server.URL, "://", "://user:xxxxx@", 1)+"/photos", httpErr.URL)
+	assert.NotContains(t, err.Error(), "pass")
+}
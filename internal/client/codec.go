@@ -0,0 +1,146 @@
+package client
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Codec encodes request bodies and decodes response bodies of one media type.
+type Codec interface {
+	ContentType() string
+	Encode(v any) ([]byte, error)
+	Decode(r io.Reader, v any) error
+}
+
+// JSONCodec encodes and decodes application/json. The zero value decodes like encoding/json, the fields adapt decoding
+// to the formats of an upstream per call, e.g. WithCodec(JSONCodec{TimeLayouts: []string{time.DateTime}}), instead of
+// implementing UnmarshalJSON on every type.
+type JSONCodec struct {
+	// UseNumber decodes numbers into interface values as json.Number instead of float64, keeping large integers exact.
+	UseNumber bool
+	// TimeLayouts are tried in order to parse strings decoded into time.Time which are not in RFC 3339.
+	TimeLayouts []string
+	// CaseSensitive only matches object keys to the fields of exactly the same name, while encoding/json ignores case.
+	CaseSensitive bool
+	// DisallowUnknownFields fails decoding objects with keys matching no field of the decoded struct, so that fields
+	// renamed by the upstream or misspelled in the struct surface as errors rather than zero values.
+	DisallowUnknownFields bool
+}
+
+// ContentType implements Codec.
+func (JSONCodec) ContentType() string { return "application/json" }
+
+// Encode implements Codec.
+func (JSONCodec) Encode(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json: %w", err)
+	}
+
+	return b, nil
+}
+
+// Decode implements Codec.
+func (c JSONCodec) Decode(r io.Reader, v any) error {
+	if len(c.TimeLayouts) > 0 || c.CaseSensitive {
+		return c.decodeNormalized(r, v)
+	}
+
+	dec := json.NewDecoder(r)
+	if c.UseNumber {
+		dec.UseNumber()
+	}
+
+	if c.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	return nil
+}
+
+// XMLCodec encodes and decodes application/xml.
+type XMLCodec struct{}
+
+// ContentType implements Codec.
+func (XMLCodec) ContentType() string { return "application/xml" }
+
+// Encode implements Codec.
+func (XMLCodec) Encode(v any) ([]byte, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal xml: %w", err)
+	}
+
+	return b, nil
+}
+
+// Decode implements Codec.
+func (XMLCodec) Decode(r io.Reader, v any) error {
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("invalid xml: %w", err)
+	}
+
+	return nil
+}
+
+// FormCodec encodes and decodes application/x-www-form-urlencoded. It supports url.Values, map[string]string and
+// map[string][]string.
+type FormCodec struct{}
+
+// ContentType implements Codec.
+func (FormCodec) ContentType() string { return "application/x-www-form-urlencoded" }
+
+// Encode implements Codec.
+func (FormCodec) Encode(v any) ([]byte, error) {
+	switch f := v.(type) {
+	case url.Values:
+		return []byte(f.Encode()), nil
+	case map[string][]string:
+		return []byte(url.Values(f).Encode()), nil
+	case map[string]string:
+		values := url.Values{}
+		for k, s := range f {
+			values.Set(k, s)
+		}
+
+		return []byte(values.Encode()), nil
+	default:
+		return nil, fmt.Errorf("unsupported form type %T", v)
+	}
+}
+
+// Decode implements Codec.
+func (FormCodec) Decode(r io.Reader, v any) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read form: %w", err)
+	}
+
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return fmt.Errorf("invalid form: %w", err)
+	}
+
+	switch f := v.(type) {
+	case *url.Values:
+		*f = values
+	case *map[string][]string:
+		*f = values
+	case *map[string]string:
+		*f = make(map[string]string, len(values))
+		for k := range values {
+			(*f)[k] = values.Get(k)
+		}
+	default:
+		return fmt.Errorf("unsupported form type %T", v)
+	}
+
+	return nil
+}
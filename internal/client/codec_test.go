@@ -0,0 +1,179 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+type xmlItem struct {
+	XMLName xml.Name `xml:"item"`
+	ID      int      `xml:"id"`
+	Name    string   `xml:"name"`
+}
+
+func TestPostAs_Codecs(t *testing.T) {
+	type want struct {
+		contentType string
+		body        string
+	}
+
+	tests := map[string]struct {
+		call func(ctx context.Context, c *client.Client, u string) (any, error)
+		resp string
+		want want
+		got  any
+	}{
+		"xml": {
+			call: func(ctx context.Context, c *client.Client, u string) (any, error) {
+				return client.PostAs[xmlItem](ctx, c, u, xmlItem{ID: 1, Name: "a"}, client.WithCodec(client.XMLCodec{}))
+			},
+			resp: `<item><id>2</id><name>b</name></item>`,
+			want: want{contentType: "application/xml", body: `<item><id>1</id><name>a</name></item>`},
+			got:  &xmlItem{XMLName: xml.Name{Local: "item"}, ID: 2, Name: "b"},
+		},
+		"form values": {
+			call: func(ctx context.Context, c *client.Client, u string) (any, error) {
+				return client.PostAs[url.Values](ctx, c, u, url.Values{"grant_type": {"client_credentials"}, "scope": {"a b"}}, client.WithCodec(client.FormCodec{}))
+			},
+			resp: `access_token=abc&expires_in=60`,
+			want: want{contentType: "application/x-www-form-urlencoded", body: `grant_type=client_credentials&scope=a+b`},
+			got:  &url.Values{"access_token": {"abc"}, "expires_in": {"60"}},
+		},
+		"form map": {
+			call: func(ctx context.Context, c *client.Client, u string) (any, error) {
+				return client.PostAs[map[string]string](ctx, c, u, map[string]string{"a": "1"}, client.WithCodec(client.FormCodec{}))
+			},
+			resp: `b=2`,
+			want: want{contentType: "application/x-www-form-urlencoded", body: `a=1`},
+			got:  &map[string]string{"b": "2"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+
+				assert.Equal(t, tt.want.contentType, r.Header.Get("Content-Type"))
+				assert.Equal(t, tt.want.contentType, r.Header.Get("Accept"))
+				assert.Equal(t, tt.want.body, string(b))
+
+				w.Write([]byte(tt.resp))
+			}))
+			defer server.Close()
+
+			got, err := tt.call(context.Background(), client.NewClient(server.Client()), server.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.got, got)
+		})
+	}
+}
+
+func TestFormCodec_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := client.FormCodec{}.Encode(struct{}{})
+	assert.EqualError(t, err, "unsupported form type struct {}")
+}
+
+type event struct {
+	ID    int       `json:"id"`
+	Name  string    `json:"name"`
+	At    time.Time `json:"at"`
+	Extra any       `json:"extra"`
+}
+
+func TestJSONCodec_Decode(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	type args struct {
+		codec client.JSONCodec
+		body  string
+	}
+
+	type want struct {
+		got []event
+		err string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"default": {
+			args: args{body: `[{"ID":1,"name":"a","at":"2024-03-01T12:30:00Z","extra":12345678901234567890}]`},
+			want: want{got: []event{{ID: 1, Name: "a", At: at, Extra: 12345678901234567890.0}}},
+		},
+		"use number": {
+			args: args{codec: client.JSONCodec{UseNumber: true}, body: `[{"id":1,"extra":12345678901234567890}]`},
+			want: want{got: []event{{ID: 1, Extra: json.Number("12345678901234567890")}}},
+		},
+		"time layouts": {
+			args: args{
+				codec: client.JSONCodec{TimeLayouts: []string{time.DateOnly, time.DateTime}},
+				body:  `[{"id":1,"at":"2024-03-01 12:30:00","extra":{"at":"not a time"}},{"id":2,"at":"2024-03-01T12:30:00Z"}]`,
+			},
+			want: want{got: []event{{ID: 1, At: at, Extra: map[string]any{"at": "not a time"}}, {ID: 2, At: at}}},
+		},
+		"time not matching layouts": {
+			args: args{codec: client.JSONCodec{TimeLayouts: []string{time.DateOnly}}, body: `[{"at":"01/03/2024"}]`},
+			want: want{err: `invalid json: parsing time "01/03/2024"`},
+		},
+		"case sensitive": {
+			args: args{codec: client.JSONCodec{CaseSensitive: true}, body: `[{"ID":1,"Name":"a","name":"b"}]`},
+			want: want{got: []event{{Name: "b"}}},
+		},
+		"case sensitive with numbers": {
+			args: args{codec: client.JSONCodec{CaseSensitive: true, UseNumber: true}, body: `[{"id":1,"extra":12345678901234567890}]`},
+			want: want{got: []event{{ID: 1, Extra: json.Number("12345678901234567890")}}},
+		},
+		"disallow unknown fields": {
+			args: args{codec: client.JSONCodec{DisallowUnknownFields: true}, body: `[{"id":1,"nmae":"a"}]`},
+			want: want{err: `invalid json: json: unknown field "nmae"`},
+		},
+		"disallow unknown fields case sensitive": {
+			args: args{codec: client.JSONCodec{CaseSensitive: true, DisallowUnknownFields: true}, body: `[{"id":1,"Name":"a"}]`},
+			want: want{err: `invalid json: unknown field "Name"`},
+		},
+		"disallow unknown fields known": {
+			args: args{codec: client.JSONCodec{TimeLayouts: []string{time.DateOnly}, DisallowUnknownFields: true}, body: `[{"id":1,"at":"2024-03-01"}]`},
+			want: want{got: []event{{ID: 1, At: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []event
+
+			err := tt.args.codec.Decode(strings.NewReader(tt.args.body), &got)
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.got, got)
+		})
+	}
+}
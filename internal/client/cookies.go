@@ -0,0 +1,252 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithCookieJar makes the client store the cookies of upstream responses and send them back on later requests, so
+// multi-step flows relying on a session cookie work. When the client wraps an *http.Client, a copy of it is given the
+// jar, so cookies set by redirect responses are kept too. Other clients only see the final response of a request.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) {
+		if hc, ok := c.httpClient.(*http.Client); ok {
+			withJar := *hc
+			withJar.Jar = jar
+			c.httpClient = &withJar
+
+			return
+		}
+
+		c.jar = jar
+	}
+}
+
+// addCookies adds the cookies of the jar to a request when the wrapped client does not handle them itself.
+func (c *Client) addCookies(req *http.Request) {
+	if c.jar == nil {
+		return
+	}
+
+	for _, cookie := range c.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+}
+
+// storeCookies stores the cookies of a response when the wrapped client does not handle them itself.
+func (c *Client) storeCookies(req *http.Request, resp *http.Response) {
+	if c.jar == nil {
+		return
+	}
+
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		c.jar.SetCookies(req.URL, cookies)
+	}
+}
+
+// SavedCookie is a cookie kept by a CookieJar with the URL of the response that set it.
+type SavedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// CookiePersistence saves the cookies of a CookieJar and loads them back when the jar is created, e.g. to keep an
+// upstream session across restarts.
+type CookiePersistence interface {
+	Load() ([]SavedCookie, error)
+	Save(cookies []SavedCookie) error
+}
+
+// CookieJar is an http.CookieJar whose cookies can be inspected and cleared per host, and saved to a
+// CookiePersistence. Cookies are matched to requests by a net/http/cookiejar.Jar without a public suffix list.
+type CookieJar struct {
+	mu          sync.Mutex
+	jar         *cookiejar.Jar
+	saved       map[string]SavedCookie
+	persistence CookiePersistence
+	now         func() time.Time
+}
+
+// NewCookieJar creates a CookieJar holding the cookies loaded from persistence, or an empty one if persistence is nil.
+func NewCookieJar(persistence CookiePersistence) (*CookieJar, error) {
+	j := &CookieJar{saved: map[string]SavedCookie{}, persistence: persistence, now: time.Now}
+
+	var saved []SavedCookie
+
+	if persistence != nil {
+		var err error
+		if saved, err = persistence.Load(); err != nil {
+			return nil, fmt.Errorf("failed to load cookies: %w", err)
+		}
+	}
+
+	for _, s := range saved {
+		u, err := url.Parse(s.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie url %q: %w", s.URL, err)
+		}
+
+		j.record(u, s.Cookie)
+	}
+
+	if err := j.rebuild(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// SetCookies implements http.CookieJar.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, cookie := range cookies {
+		j.record(u, cookie)
+	}
+
+	j.jar.SetCookies(u, cookies)
+}
+
+// Cookies implements http.CookieJar.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.jar.Cookies(u)
+}
+
+// HostCookies returns the unexpired cookies set by responses from host, with all their attributes.
+func (j *CookieJar) HostCookies(host string) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var cookies []*http.Cookie
+
+	for _, s := range j.unexpired() {
+		if cookieHost(s.URL) == strings.ToLower(host) {
+			cookies = append(cookies, s.Cookie)
+		}
+	}
+
+	sort.Slice(cookies, func(a, b int) bool {
+		if cookies[a].Name != cookies[b].Name {
+			return cookies[a].Name < cookies[b].Name
+		}
+
+		return cookies[a].Path < cookies[b].Path
+	})
+
+	return cookies
+}
+
+// Clear removes the cookies set by responses from host.
+func (j *CookieJar) Clear(host string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for key, s := range j.saved {
+		if cookieHost(s.URL) == strings.ToLower(host) {
+			delete(j.saved, key)
+		}
+	}
+
+	// cookiejar.Jar cannot remove cookies, it is rebuilt from the remaining ones.
+	return j.rebuild()
+}
+
+// Save saves the unexpired cookies to the persistence of the jar. It does nothing without persistence.
+func (j *CookieJar) Save() error {
+	if j.persistence == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	saved := j.unexpired()
+	j.mu.Unlock()
+
+	if err := j.persistence.Save(saved); err != nil {
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+
+	return nil
+}
+
+// record keeps a copy of cookie, replacing the cookie of the same host, name, domain and path. Max-Age is turned into
+// an absolute expiry so the cookie expires at the same time once reloaded.
+func (j *CookieJar) record(u *url.URL, cookie *http.Cookie) {
+	c := *cookie
+	if c.MaxAge > 0 {
+		c.Expires = j.now().Add(time.Duration(c.MaxAge) * time.Second)
+		c.MaxAge = 0
+	}
+
+	ref := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	key := strings.Join([]string{strings.ToLower(u.Hostname()), c.Name, strings.ToLower(c.Domain), c.Path}, "|")
+
+	if c.MaxAge < 0 || (!c.Expires.IsZero() && !c.Expires.After(j.now())) {
+		delete(j.saved, key)
+		return
+	}
+
+	j.saved[key] = SavedCookie{URL: ref.String(), Cookie: &c}
+}
+
+func (j *CookieJar) rebuild() error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+
+	for _, s := range j.unexpired() {
+		if err = restoreCookie(jar, s); err != nil {
+			return err
+		}
+	}
+
+	j.jar = jar
+
+	return nil
+}
+
+func restoreCookie(jar http.CookieJar, s SavedCookie) error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("invalid cookie url %q: %w", s.URL, err)
+	}
+
+	jar.SetCookies(u, []*http.Cookie{s.Cookie})
+
+	return nil
+}
+
+func (j *CookieJar) unexpired() []SavedCookie {
+	now := j.now()
+	saved := make([]SavedCookie, 0, len(j.saved))
+
+	for _, s := range j.saved {
+		if s.Cookie.Expires.IsZero() || s.Cookie.Expires.After(now) {
+			saved = append(saved, s)
+		}
+	}
+
+	return saved
+}
+
+func cookieHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
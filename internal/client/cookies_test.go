@@ -0,0 +1,174 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+type doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// sessionServer sets a session cookie on /login, redirecting to the next parameter if any, and only serves /home to
+// requests carrying the cookie.
+func sessionServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+
+		if next := r.URL.Query().Get("next"); next != "" {
+			http.Redirect(w, r, next, http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestClient_WithCookieJar(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		httpClient func() doer
+		paths      []string
+	}
+
+	tests := map[string]struct {
+		args args
+	}{
+		"http client keeps cookies of redirects": {
+			args: args{
+				httpClient: func() doer {
+					return &http.Client{}
+				},
+				paths: []string{"/login?next=/home", "/home"},
+			},
+		},
+		"other client keeps cookies of responses": {
+			args: args{
+				httpClient: func() doer {
+					hc := &http.Client{}
+					return doerFunc(hc.Do)
+				},
+				paths: []string{"/login", "/home"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := sessionServer()
+			t.Cleanup(server.Close)
+
+			jar, err := client.NewCookieJar(nil)
+			assert.NoError(t, err)
+
+			c := client.NewClient(tt.args.httpClient(), client.WithCookieJar(jar))
+
+			for _, path := range tt.args.paths {
+				resp, err := c.Get(context.Background(), server.URL+path)
+				if !assert.NoError(t, err, path) {
+					return
+				}
+
+				resp.Body.Close()
+			}
+
+			cookies := jar.HostCookies("127.0.0.1")
+			if !assert.Len(t, cookies, 1) {
+				return
+			}
+
+			assert.Equal(t, "session", cookies[0].Name)
+			assert.Equal(t, "abc", cookies[0].Value)
+
+			assert.NoError(t, jar.Clear("127.0.0.1"))
+			assert.Empty(t, jar.HostCookies("127.0.0.1"))
+
+			_, err = c.Get(context.Background(), server.URL+"/home")
+			assert.ErrorContains(t, err, "received non-OK HTTP status: 401")
+		})
+	}
+}
+
+func TestClient_WithoutCookieJar(t *testing.T) {
+	t.Parallel()
+
+	server := sessionServer()
+	t.Cleanup(server.Close)
+
+	_, err := client.NewClient(&http.Client{}).Get(context.Background(), server.URL+"/login?next=/home")
+	assert.ErrorContains(t, err, "received non-OK HTTP status: 401")
+}
+
+type memoryPersistence struct {
+	saved []client.SavedCookie
+}
+
+func (p *memoryPersistence) Load() ([]client.SavedCookie, error) {
+	return p.saved, nil
+}
+
+func (p *memoryPersistence) Save(cookies []client.SavedCookie) error {
+	p.saved = cookies
+	return nil
+}
+
+func TestCookieJar_Persistence(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("https://api.example.com/login")
+	assert.NoError(t, err)
+
+	p := &memoryPersistence{}
+
+	jar, err := client.NewCookieJar(p)
+	assert.NoError(t, err)
+
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "abc", Path: "/", MaxAge: 3600},
+		{Name: "expired", Value: "old", Path: "/", Expires: time.Now().Add(-time.Hour)},
+	})
+	assert.NoError(t, jar.Save())
+
+	if !assert.Len(t, p.saved, 1) {
+		return
+	}
+
+	assert.Equal(t, "https://api.example.com/login", p.saved[0].URL)
+	assert.Equal(t, "session", p.saved[0].Cookie.Name)
+	assert.Zero(t, p.saved[0].Cookie.MaxAge)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), p.saved[0].Cookie.Expires, time.Minute)
+
+	reloaded, err := client.NewCookieJar(p)
+	assert.NoError(t, err)
+
+	cookies := reloaded.Cookies(&url.URL{Scheme: "https", Host: "api.example.com", Path: "/photos"})
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+
+	assert.Equal(t, "abc", cookies[0].Value)
+	assert.Empty(t, reloaded.Cookies(&url.URL{Scheme: "https", Host: "other.example.com", Path: "/"}))
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotRefreshable is returned by the Refresh of the credentials which cannot be replaced, such as StaticCredential.
+var ErrNotRefreshable = errors.New("credential cannot be refreshed")
+
+// CredentialProvider provides the credential of an upstream to the UpstreamTransport, which refreshes it when the
+// upstream answers 401 Unauthorized, e.g. for tokens rotated before the service is restarted.
+type CredentialProvider interface {
+	// Credential returns the current credential.
+	Credential(ctx context.Context) (string, error)
+	// Refresh replaces the credential rejected by the upstream, e.g. by reading a rotated secret again or requesting
+	// a new token. It returns nil without refreshing if the credential was already replaced since rejected was
+	// returned, as the requests sent with it concurrently are rejected together, and fails if there is no other
+	// credential.
+	Refresh(ctx context.Context, rejected string) error
+}
+
+// StaticCredential is a credential which never changes.
+type StaticCredential string
+
+// Credential implements CredentialProvider.
+func (c StaticCredential) Credential(context.Context) (string, error) {
+	return string(c), nil
+}
+
+// Refresh implements CredentialProvider, failing with ErrNotRefreshable.
+func (StaticCredential) Refresh(context.Context, string) error {
+	return ErrNotRefreshable
+}
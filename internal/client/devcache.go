@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	devCacheDirPerm = 0o750
+	// DevCacheHeader is set to "hit" on responses served by the DiskCache.
+	DevCacheHeader = "X-Dev-Cache"
+)
+
+// DiskCache is an http.RoundTripper persisting successful GET responses on disk, keyed by URL and request headers,
+// so they survive restarts. It is meant for development against slow or rate limited upstreams and works offline
+// once the responses are cached. It does not honour Cache-Control and must not be used in production.
+type DiskCache struct {
+	dir  string
+	ttl  time.Duration
+	next http.RoundTripper
+	now  func() time.Time
+}
+
+// NewDiskCache creates a DiskCache storing responses under dir and delegating misses to next. Cached responses older
+// than ttl are fetched again, a zero ttl keeps them until they are removed from dir.
+func NewDiskCache(dir string, ttl time.Duration, next http.RoundTripper) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, devCacheDirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	return &DiskCache{dir: dir, ttl: ttl, next: next, now: time.Now}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (d *DiskCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return d.next.RoundTrip(req) //nolint:wrapcheck // the cache is transparent
+	}
+
+	p := filepath.Join(d.dir, cacheKey(req))
+
+	if resp, ok := d.load(p, req); ok {
+		return resp, nil
+	}
+
+	resp, err := d.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp, err //nolint:wrapcheck // the cache is transparent
+	}
+
+	return d.store(p, resp)
+}
+
+// load returns the cached response for req if there is a fresh one.
+func (d *DiskCache) load(p string, req *http.Request) (*http.Response, bool) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, false
+	}
+
+	info, err := f.Stat()
+	if err != nil || (d.ttl > 0 && d.now().Sub(info.ModTime()) > d.ttl) {
+		f.Close()
+		return nil, false
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(f), req)
+	if err != nil {
+		f.Close()
+		return nil, false
+	}
+
+	resp.Body = readCloser{Reader: resp.Body, closers: []io.Closer{resp.Body, f}}
+	resp.Header.Set(DevCacheHeader, "hit")
+
+	return resp, true
+}
+
+// store writes resp to p and returns a response replaying it. The body is read fully, which is acceptable for
+// development but makes the cache unsuitable for large downloads.
+func (d *DiskCache) store(p string, resp *http.Response) (*http.Response, error) {
+	dump, err := httputil.DumpResponse(resp, true)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if err := writeFileAtomic(p, dump); err != nil {
+		return nil, err
+	}
+
+	cached, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), resp.Request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay response: %w", err)
+	}
+
+	return cached, nil
+}
+
+// cacheKey derives the file name of a request from its URL and headers.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String()) //nolint:errcheck // hash writes never fail
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			fmt.Fprintf(h, "\n%s: %s", name, v)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeFileAtomic(p string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("failed to rename cache file: %w", err)
+	}
+
+	return nil
+}
+
+// readCloser closes several closers, e.g. a response body and the file it is read from.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r readCloser) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+
+	return errors.Join(errs...)
+}
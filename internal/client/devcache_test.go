@@ -0,0 +1,119 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+func TestDiskCache(t *testing.T) {
+	type args struct {
+		ttl     time.Duration
+		method  string
+		status  int
+		header  [2]string
+		restart bool
+		wait    time.Duration
+	}
+
+	type want struct {
+		calls int32
+		hit   string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"second GET is served from disk": {
+			args: args{method: http.MethodGet, status: http.StatusOK},
+			want: want{calls: 1, hit: "hit"},
+		},
+		"cache survives restarts": {
+			args: args{method: http.MethodGet, status: http.StatusOK, restart: true},
+			want: want{calls: 1, hit: "hit"},
+		},
+		"different headers are different entries": {
+			args: args{method: http.MethodGet, status: http.StatusOK, header: [2]string{"Accept", "text/plain"}},
+			want: want{calls: 2},
+		},
+		"expired entries are fetched again": {
+			args: args{method: http.MethodGet, status: http.StatusOK, ttl: time.Millisecond, wait: 20 * time.Millisecond},
+			want: want{calls: 2},
+		},
+		"errors are not cached": {
+			args: args{method: http.MethodGet, status: http.StatusInternalServerError},
+			want: want{calls: 2},
+		},
+		"POST is not cached": {
+			args: args{method: http.MethodPost, status: http.StatusOK},
+			want: want{calls: 2},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.args.status)
+				w.Write([]byte(`{"id":1}`))
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+
+			newClient := func() *http.Client {
+				cache, err := client.NewDiskCache(dir, tt.args.ttl, server.Client().Transport)
+				assert.NoError(t, err)
+
+				return &http.Client{Transport: cache}
+			}
+
+			c := newClient()
+			do := func(header [2]string) *http.Response {
+				req, _ := http.NewRequestWithContext(context.Background(), tt.args.method, server.URL+"/photos/1", http.NoBody)
+				if header[0] != "" {
+					req.Header.Set(header[0], header[1])
+				}
+
+				resp, err := c.Do(req)
+				assert.NoError(t, err)
+
+				b, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				assert.Equal(t, `{"id":1}`, string(b))
+				assert.Equal(t, tt.args.status, resp.StatusCode)
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+				return resp
+			}
+
+			do([2]string{})
+			time.Sleep(tt.args.wait)
+
+			if tt.args.restart {
+				c = newClient()
+			}
+
+			resp := do(tt.args.header)
+
+			assert.Equal(t, tt.want.calls, calls.Load())
+			assert.Equal(t, tt.want.hit, resp.Header.Get(client.DevCacheHeader))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+type dialKey struct{}
+
+// DialTransport dials the connections of the requests of an upstream with its own IP family, source address or
+// interface and timeouts, e.g. on a multi-homed host where the upstream is reached through another network than the
+// other destinations. As with ProxyTransport, the settings travel to the transport of NewTransport at the bottom of
+// the chain in the request context.
+type DialTransport struct {
+	dial *config.Dial
+	next http.RoundTripper
+}
+
+// NewDialTransport creates the DialTransport of cfg, delegating to next, which must end in a transport of
+// NewTransport. The settings of cfg which are not set keep those of the transport.
+func NewDialTransport(cfg *config.Dial, next http.RoundTripper) (*DialTransport, error) {
+	// The settings are checked at once rather than by the first connection.
+	if _, err := (&dialers{}).build(&config.Transport{
+		IPFamily:        cfg.IPFamily,
+		SourceAddress:   cfg.SourceAddress,
+		SourceInterface: cfg.SourceInterface,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &DialTransport{dial: cfg, next: next}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *DialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(context.WithValue(req.Context(), dialKey{}, t.dial))) //nolint:wrapcheck // the transport is transparent
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned by Download when the downloaded content does not match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrRangeMismatch is returned by Download when a resumed download receives partial content not starting at the
+// resume offset, which cannot be appended to the bytes downloaded previously.
+var ErrRangeMismatch = errors.New("content range does not start at the resume offset")
+
+// DownloadOption configures a Download.
+type DownloadOption func(*download)
+
+// DownloadResult describes a completed download.
+type DownloadResult struct {
+	// Written is the number of bytes written to the writer by this call.
+	Written int64
+	// Total is the full size of the content, or -1 when the server did not report it.
+	Total int64
+	// Checksum is the hex encoded checksum when WithChecksum was used.
+	Checksum string
+}
+
+type download struct {
+	offset   int64
+	progress func(written, total int64)
+	hash     hash.Hash
+	expected string
+}
+
+// WithProgress registers a callback invoked after every write with the number of bytes downloaded so far, including
+// the resume offset, and the total size or -1 if unknown.
+func WithProgress(fn func(downloaded, total int64)) DownloadOption {
+	return func(d *download) {
+		d.progress = fn
+	}
+}
+
+// WithChecksum verifies the content against the expected hex encoded checksum using h. When resuming, h has to
+// already contain the bytes downloaded previously.
+func WithChecksum(h hash.Hash, expected string) DownloadOption {
+	return func(d *download) {
+		d.hash = h
+		d.expected = expected
+	}
+}
+
+// WithResume resumes a previous download from offset using a Range request. The writer is expected to already hold
+// the first offset bytes.
+func WithResume(offset int64) DownloadOption {
+	return func(d *download) {
+		d.offset = offset
+	}
+}
+
+// Download streams the response body of a GET request to w without buffering it in memory.
+func (c *Client) Download(ctx context.Context, url string, w io.Writer, opts ...DownloadOption) (*DownloadResult, error) {
+	d := &download{}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if d.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer drainAndClose(resp.Body)
+
+	return d.copy(resp, w)
+}
+
+func (d *download) copy(resp *http.Response, w io.Writer) (*DownloadResult, error) {
+	body, err := d.resumeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	total := contentTotal(resp)
+	pw := &progressWriter{downloaded: d.offset, total: total, progress: d.progress}
+
+	dst := io.MultiWriter(w, pw)
+	if d.hash != nil {
+		dst = io.MultiWriter(w, d.hash, pw)
+	}
+
+	written, err := io.Copy(dst, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download after %d bytes: %w", written, err)
+	}
+
+	result := &DownloadResult{Written: written, Total: total}
+
+	return result, d.verify(result)
+}
+
+// resumeBody checks the response status. Partial content has to start at the resume offset. If the server ignored
+// the Range request and sent the full content, the part already downloaded is skipped.
+func (d *download) resumeBody(resp *http.Response) (io.Reader, error) {
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && d.offset > 0:
+		start, ok := contentStart(resp)
+		if !ok || start != d.offset {
+			return nil, fmt.Errorf("%w: requested %d, got %q", ErrRangeMismatch, d.offset, resp.Header.Get("Content-Range"))
+		}
+
+		return resp.Body, nil
+	case resp.StatusCode == http.StatusOK:
+		if _, err := io.CopyN(io.Discard, resp.Body, d.offset); err != nil {
+			return nil, fmt.Errorf("failed to skip %d bytes already downloaded: %w", d.offset, err)
+		}
+
+		return resp.Body, nil
+	default:
+		return nil, newHTTPError(resp)
+	}
+}
+
+func (d *download) verify(result *DownloadResult) error {
+	if d.hash == nil {
+		return nil
+	}
+
+	result.Checksum = hex.EncodeToString(d.hash.Sum(nil))
+	if d.expected != "" && !strings.EqualFold(result.Checksum, d.expected) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, d.expected, result.Checksum)
+	}
+
+	return nil
+}
+
+// contentStart returns the first byte position of the Content-Range of resp, "bytes start-end/total", and whether it
+// has a valid one.
+func contentStart(resp *http.Response) (int64, bool) {
+	r, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return 0, false
+	}
+
+	first, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+
+	return start, err == nil && start >= 0
+}
+
+// contentTotal returns the full size of the content from Content-Range or Content-Length, or -1 if unknown.
+func contentTotal(resp *http.Response) int64 {
+	if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return total
+			}
+		}
+
+		return -1
+	}
+
+	return resp.ContentLength
+}
+
+type progressWriter struct {
+	downloaded int64
+	total      int64
+	progress   func(downloaded, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.downloaded += int64(len(b))
+	if p.progress != nil {
+		p.progress(p.downloaded, p.total)
+	}
+
+	return len(b), nil
+}
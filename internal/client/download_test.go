@@ -0,0 +1,144 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+const downloadContent = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func TestClient_Download(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		handler  http.HandlerFunc
+		offset   int64
+		expected string
+	}
+
+	type want struct {
+		body    string
+		written int64
+		err     string
+	}
+
+	rangeHandler := func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(downloadContent))
+	}
+	fullHandler := func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(downloadContent))
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"full download": {
+			args: args{handler: rangeHandler, expected: sum(downloadContent)},
+			want: want{body: downloadContent, written: 36},
+		},
+		"resume with range": {
+			args: args{handler: rangeHandler, offset: 10, expected: sum(downloadContent)},
+			want: want{body: downloadContent[10:], written: 26},
+		},
+		"resume without range support": {
+			args: args{handler: fullHandler, offset: 10, expected: sum(downloadContent)},
+			want: want{body: downloadContent[10:], written: 26},
+		},
+		"resume with mismatching range": {
+			args: args{handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Range", "bytes 0-35/36")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(downloadContent))
+			}, offset: 10},
+			want: want{err: client.ErrRangeMismatch.Error()},
+		},
+		"resume without content range": {
+			args: args{handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(downloadContent[10:]))
+			}, offset: 10},
+			want: want{err: client.ErrRangeMismatch.Error()},
+		},
+		"checksum mismatch": {
+			args: args{handler: rangeHandler, expected: sum("other")},
+			want: want{err: client.ErrChecksumMismatch.Error()},
+		},
+		"not found": {
+			args: args{handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) }},
+			want: want{err: "received non-OK HTTP status: 404"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(tt.args.handler)
+			defer server.Close()
+
+			h := sha256.New()
+			h.Write([]byte(downloadContent[:tt.args.offset]))
+
+			var progress []int64
+
+			buf := &bytes.Buffer{}
+			c := client.NewClient(server.Client())
+
+			result, err := c.Download(context.Background(), server.URL, buf,
+				client.WithResume(tt.args.offset),
+				client.WithChecksum(h, tt.args.expected),
+				client.WithProgress(func(downloaded, total int64) {
+					progress = append(progress, downloaded)
+					assert.Equal(t, int64(len(downloadContent)), total)
+				}),
+			)
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.body, buf.String())
+			assert.Equal(t, tt.want.written, result.Written)
+			assert.Equal(t, int64(len(downloadContent)), result.Total)
+			assert.Equal(t, sum(downloadContent), result.Checksum)
+			assert.Equal(t, int64(len(downloadContent)), progress[len(progress)-1])
+		})
+	}
+}
+
+func TestClient_Download_Cancel(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(downloadContent))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := client.NewClient(server.Client())
+
+	_, err := c.Download(ctx, server.URL, &bytes.Buffer{}, client.WithProgress(func(int64, int64) { cancel() }))
+	assert.ErrorIs(t, err, context.Canceled)
+}
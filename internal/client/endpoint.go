@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+var errEndpointPath = errors.New("upstream endpoint path must start with /")
+
+type endpointKey struct{}
+
+// contextWithEndpoint returns a copy of ctx carrying the endpoint of its request, for the retrier.
+func contextWithEndpoint(ctx context.Context, e *config.Endpoint) context.Context {
+	return context.WithValue(ctx, endpointKey{}, e)
+}
+
+// endpointFromContext returns the endpoint carried by ctx, or nil.
+func endpointFromContext(ctx context.Context) *config.Endpoint {
+	e, _ := ctx.Value(endpointKey{}).(*config.Endpoint)
+	return e
+}
+
+// validateEndpoints checks the paths of endpoints.
+func validateEndpoints(endpoints []config.Endpoint) error {
+	for _, e := range endpoints {
+		if !strings.HasPrefix(e.Path, "/") {
+			return fmt.Errorf("%w: %q", errEndpointPath, e.Path)
+		}
+	}
+
+	return nil
+}
+
+// endpointOf returns the first of endpoints matching req, whose path is relative to basePath, or nil.
+func endpointOf(endpoints []config.Endpoint, basePath string, req *http.Request) *config.Endpoint {
+	p := strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(basePath, "/"))
+
+	for i, e := range endpoints {
+		if (e.Method == "" || strings.EqualFold(e.Method, req.Method)) && matchPath(e.Path, p) {
+			return &endpoints[i]
+		}
+	}
+
+	return nil
+}
+
+// matchPath reports whether p matches pattern, whose :name segments match any segment, e.g. /photos/:id matching
+// /photos/1. Trailing slashes are ignored.
+func matchPath(pattern, p string) bool {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+
+	if len(ps) != len(segments) {
+		return false
+	}
+
+	for i, s := range ps {
+		if strings.HasPrefix(s, ":") && segments[i] != "" {
+			continue
+		}
+
+		if s != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxErrorBodySize bounds how much of an upstream error body is kept on an HTTPError.
+const maxErrorBodySize = 4 << 10
+
+// HTTPError is returned when the upstream responds with an unexpected status. It keeps the status, the headers and
+// the beginning of the body so callers can map upstream errors and log what the upstream said.
+type HTTPError struct {
+	Method string
+	// URL is the request URL with its password redacted.
+	URL        string
+	StatusCode int
+	Header     http.Header
+	// Body holds at most the first 4KiB of the response body.
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("%s %s: received non-OK HTTP status: %d", e.Method, e.URL, e.StatusCode)
+	if len(e.Body) > 0 {
+		msg += ": " + string(e.Body)
+	}
+
+	return msg
+}
+
+// newHTTPError captures the response into an HTTPError and closes the body. The remainder of the body is drained so
+// the connection can be reused.
+func newHTTPError(resp *http.Response) *HTTPError {
+	defer drainAndClose(resp.Body)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		body = append(body, fmt.Sprintf(" (failed to read body: %v)", err)...)
+	}
+
+	e := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.Redacted()
+	}
+
+	return e
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// HedgeMetric counts the hedged requests by result: "sent" when a duplicate request was sent, "won" when its response
+// was used, and "throttled" when the hedge budget did not allow one.
+const HedgeMetric = "http_client_hedged_requests_total"
+
+const (
+	defaultHedgeRatio = 0.05
+	// hedgeBudgetCap bounds the hedges saved up while the upstream is fast, so a burst of slow responses does not send
+	// a burst of hedges.
+	hedgeBudgetCap = 10
+)
+
+var errHedgeDelay = errors.New("hedging delay must be positive")
+
+// Hedger is an http.RoundTripper hedging the GET and HEAD requests: when no response arrived after a delay, a
+// duplicate request is sent and whichever response arrives first is used, the other request being canceled. It trims
+// the latency tail of an upstream at the cost of extra requests, which are capped to a share of the requests.
+type Hedger struct {
+	next  http.RoundTripper
+	delay time.Duration
+	ratio float64
+	rec   recorder
+
+	mu sync.Mutex
+	// budget is the number of hedges allowed, earning ratio per request up to hedgeBudgetCap.
+	budget float64
+}
+
+// NewHedger creates a Hedger of cfg delegating to next.
+func NewHedger(cfg *config.Hedging, next http.RoundTripper, rec recorder) (*Hedger, error) {
+	if cfg.Delay <= 0 {
+		return nil, errHedgeDelay
+	}
+
+	ratio := cfg.MaxRatio
+	if ratio <= 0 {
+		ratio = defaultHedgeRatio
+	}
+
+	return &Hedger{next: next, delay: cfg.Delay, ratio: min(ratio, 1), rec: rec}, nil
+}
+
+// hedgeAttempt is the outcome of one of the requests of a hedged request.
+type hedgeAttempt struct {
+	resp   *http.Response
+	err    error
+	hedge  bool
+	cancel context.CancelFunc
+}
+
+// RoundTrip implements http.RoundTripper.
+func (h *Hedger) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || (req.Body != nil && req.Body != http.NoBody) {
+		return h.next.RoundTrip(req) //nolint:wrapcheck // the hedger is transparent
+	}
+
+	h.earn()
+
+	attempts := make(chan hedgeAttempt, 2)
+	// cancels cancel the requests in flight, by whether they are the hedge.
+	cancels := map[bool]context.CancelFunc{false: h.send(req, false, attempts)}
+
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+
+	pending := 1
+
+	var err error
+
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if !h.spend() {
+				h.rec.Inc(HedgeMetric, metrics.Labels{"result": "throttled"})
+				continue
+			}
+
+			h.rec.Inc(HedgeMetric, metrics.Labels{"result": "sent"})
+			cancels[true] = h.send(req, true, attempts)
+
+			pending++
+		case a := <-attempts:
+			pending--
+
+			if a.err != nil {
+				// A failure is not retried: the other request is waited for if it was sent, but none is sent anymore.
+				a.cancel()
+				err = a.err
+				timer.Stop()
+
+				continue
+			}
+
+			if a.hedge {
+				h.rec.Inc(HedgeMetric, metrics.Labels{"result": "won"})
+			}
+
+			for hedge, cancel := range cancels {
+				if hedge != a.hedge {
+					cancel()
+				}
+			}
+
+			go discard(attempts, pending)
+
+			// The request stays alive until its body is closed.
+			a.resp.Body = &cancelBody{ReadCloser: a.resp.Body, cancel: a.cancel}
+
+			return a.resp, nil
+		}
+	}
+
+	return nil, err
+}
+
+// send sends a copy of req with its own context, reporting its outcome to attempts. It returns the cancellation of
+// the copy.
+func (h *Hedger) send(req *http.Request, hedge bool, attempts chan<- hedgeAttempt) context.CancelFunc {
+	ctx, cancel := context.WithCancel(req.Context())
+
+	go func() {
+		resp, err := h.next.RoundTrip(req.Clone(ctx))
+		attempts <- hedgeAttempt{resp: resp, err: err, hedge: hedge, cancel: cancel}
+	}()
+
+	return cancel
+}
+
+// discard releases the responses of the pending requests which lost, once canceled.
+func discard(attempts <-chan hedgeAttempt, pending int) {
+	for ; pending > 0; pending-- {
+		if a := <-attempts; a.err == nil {
+			drainAndClose(a.resp.Body)
+		}
+	}
+}
+
+// earn adds the share of a hedge earned by a request to the budget.
+func (h *Hedger) earn() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.budget = min(h.budget+h.ratio, hedgeBudgetCap)
+}
+
+// spend takes a hedge from the budget, reporting whether one was left.
+func (h *Hedger) spend() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.budget < 1 {
+		return false
+	}
+
+	h.budget--
+
+	return true
+}
+
+// cancelBody cancels the context of its request once closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err //nolint:wrapcheck // the body is transparent
+}
@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestHedger(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		status int
+		body   string
+		counts map[string]int
+	}
+
+	tests := map[string]struct {
+		ratio float64
+		// slow is how long the first request takes, the others answering at once.
+		slow   time.Duration
+		status int
+		want   want
+	}{
+		"fast response is not hedged": {
+			ratio: 1,
+			want:  want{status: http.StatusOK, body: "1", counts: map[string]int{}},
+		},
+		"slow response is hedged": {
+			ratio: 1,
+			slow:  time.Second,
+			want:  want{status: http.StatusOK, body: "2", counts: map[string]int{client.HedgeMetric + " sent": 1, client.HedgeMetric + " won": 1}},
+		},
+		"hedges are capped": {
+			ratio: 0.5,
+			slow:  100 * time.Millisecond,
+			want:  want{status: http.StatusOK, body: "1", counts: map[string]int{client.HedgeMetric + " throttled": 1}},
+		},
+		"error responses are used as is": {
+			ratio:  1,
+			status: http.StatusServiceUnavailable,
+			want:   want{status: http.StatusServiceUnavailable, body: "1", counts: map[string]int{}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var n atomic.Int32
+
+			canceled := make(chan struct{}, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := n.Add(1)
+				if i == 1 && tt.slow > 0 {
+					select {
+					case <-time.After(tt.slow):
+					case <-r.Context().Done():
+						canceled <- struct{}{}
+						return
+					}
+				}
+
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+
+				_, _ = w.Write([]byte{byte('0' + i)})
+			}))
+			t.Cleanup(srv.Close)
+
+			rec := &countRecorder{counts: map[string]int{}}
+
+			h, err := client.NewHedger(&config.Hedging{Delay: 20 * time.Millisecond, MaxRatio: tt.ratio}, http.DefaultTransport, rec)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, http.NoBody) //nolint:noctx // the test does not cancel
+			resp, err := h.RoundTrip(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+			assert.Equal(t, tt.want.body, string(body))
+
+			if tt.want.body == "2" {
+				select {
+				case <-canceled:
+				case <-time.After(time.Second):
+					t.Error("the slow request was not canceled")
+				}
+			}
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+
+			assert.Equal(t, tt.want.counts, rec.counts)
+		})
+	}
+}
+
+func TestHedger_PostIsNotHedged(t *testing.T) {
+	t.Parallel()
+
+	var n atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n.Add(1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	t.Cleanup(srv.Close)
+
+	h, err := client.NewHedger(&config.Hedging{Delay: time.Millisecond, MaxRatio: 1}, http.DefaultTransport, &countRecorder{counts: map[string]int{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, http.NoBody) //nolint:noctx // the test does not cancel
+
+	resp, err := h.RoundTrip(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, int32(1), n.Load())
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+const (
+	// CacheMetric counts cacheable requests by host and result: "hit", "revalidated" or "miss". The hit ratio is the
+	// rate of hits and revalidations over the rate of all requests.
+	CacheMetric = "http_client_cache_requests_total"
+	// CacheErrorsMetric counts failures of the cache store. A failing store never fails requests.
+	CacheErrorsMetric = "http_client_cache_errors_total"
+	// CacheHeader is set on responses served by the HTTPCache, "HIT" or "REVALIDATED".
+	CacheHeader = "X-Cache"
+
+	defaultRevalidationTTL = time.Hour
+)
+
+// CacheStore stores cached responses. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the value of key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+	Observe(name string, v float64, labels metrics.Labels)
+}
+
+// CacheOption configures an HTTPCache.
+type CacheOption func(*HTTPCache)
+
+// WithRevalidationTTL sets how long responses with an ETag or Last-Modified validator are kept after they become
+// stale, to be revalidated with a conditional request instead of fetched again. It defaults to one hour.
+func WithRevalidationTTL(ttl time.Duration) CacheOption {
+	return func(h *HTTPCache) {
+		h.revalidationTTL = ttl
+	}
+}
+
+// WithPartitionHeaders partitions the cache by the values of the request headers names, whatever the Vary header of
+// the responses, e.g. to never share responses between tenants.
+func WithPartitionHeaders(names ...string) CacheOption {
+	return func(h *HTTPCache) {
+		h.partitionHeaders = names
+	}
+}
+
+// HTTPCache is an http.RoundTripper caching GET responses as a shared cache according to their Cache-Control,
+// Expires and Vary headers. Fresh responses are served from the store, stale responses with a validator are
+// revalidated with If-None-Match or If-Modified-Since.
+type HTTPCache struct {
+	store           CacheStore
+	next            http.RoundTripper
+	rec             recorder
+	revalidationTTL time.Duration
+	// partitionHeaders are the request headers whose values are part of the cache keys.
+	partitionHeaders []string
+	now              func() time.Time
+}
+
+// cacheEntry is the stored form of a response.
+type cacheEntry struct {
+	Response []byte    `json:"response"`
+	StoredAt time.Time `json:"storedAt"`
+	// Vary holds the values of the request headers listed by the Vary header of the response.
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// NewHTTPCache creates an HTTPCache storing responses in store and delegating to next.
+func NewHTTPCache(store CacheStore, next http.RoundTripper, rec recorder, opts ...CacheOption) *HTTPCache {
+	h := &HTTPCache{store: store, next: next, rec: rec, revalidationTTL: defaultRevalidationTTL, now: time.Now}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// RoundTrip implements http.RoundTripper.
+func (h *HTTPCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheableRequest(req) {
+		return h.next.RoundTrip(req) //nolint:wrapcheck // the cache is transparent
+	}
+
+	key := "httpcache:" + req.URL.String()
+	for _, name := range h.partitionHeaders {
+		key += " " + name + "=" + req.Header.Get(name)
+	}
+
+	entry, cached := h.lookup(req, key)
+	if cached != nil && h.fresh(req, entry, cached) {
+		h.count(req, "hit")
+		cached.Header.Set(CacheHeader, "HIT")
+
+		return cached, nil
+	}
+
+	if cached != nil && hasValidator(cached.Header) {
+		return h.revalidate(req, key, cached)
+	}
+
+	h.count(req, "miss")
+
+	resp, err := h.next.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the cache is transparent
+	}
+
+	return h.save(req, key, resp)
+}
+
+func cacheableRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet &&
+		req.Header.Get("Authorization") == "" &&
+		req.Header.Get("Range") == "" &&
+		!parseCacheControl(req.Header).has("no-store")
+}
+
+// lookup returns the stored response for req, or nil.
+func (h *HTTPCache) lookup(req *http.Request, key string) (*cacheEntry, *http.Response) {
+	b, ok, err := h.store.Get(req.Context(), key)
+	if err != nil {
+		h.rec.Inc(CacheErrorsMetric, metrics.Labels{"op": "get"})
+		return nil, nil
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, nil
+	}
+
+	for name, v := range entry.Vary {
+		if req.Header.Get(name) != v {
+			return nil, nil
+		}
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry.Response)), req)
+	if err != nil {
+		return nil, nil
+	}
+
+	return &entry, resp
+}
+
+func (h *HTTPCache) fresh(req *http.Request, entry *cacheEntry, resp *http.Response) bool {
+	if parseCacheControl(req.Header).has("no-cache") {
+		return false
+	}
+
+	age := h.now().Sub(entry.StoredAt) + initialAge(resp.Header)
+
+	return age < freshnessLifetime(resp.Header, parseCacheControl(resp.Header))
+}
+
+// revalidate sends a conditional request for the stale response cached. On 304 Not Modified the cached response is
+// refreshed with the new headers and served, otherwise the new response replaces it.
+func (h *HTTPCache) revalidate(req *http.Request, key string, cached *http.Response) (*http.Response, error) {
+	cond := req.Clone(req.Context())
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		cond.Header.Set("If-None-Match", etag)
+	}
+
+	if lm := cached.Header.Get("Last-Modified"); lm != "" {
+		cond.Header.Set("If-Modified-Since", lm)
+	}
+
+	resp, err := h.next.RoundTrip(cond)
+	if err != nil {
+		cached.Body.Close()
+		return nil, err //nolint:wrapcheck // the cache is transparent
+	}
+
+	if resp.StatusCode != http.StatusNotModified {
+		cached.Body.Close()
+		h.count(req, "miss")
+
+		return h.save(req, key, resp)
+	}
+
+	resp.Body.Close()
+	h.count(req, "revalidated")
+
+	for name, values := range resp.Header {
+		if name != "Content-Length" {
+			cached.Header[name] = values
+		}
+	}
+
+	cached.Header.Del("Age")
+
+	refreshed, err := h.save(req, key, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed.Header.Set(CacheHeader, "REVALIDATED")
+
+	return refreshed, nil
+}
+
+// save stores resp if it is cacheable and returns a response replaying it.
+func (h *HTTPCache) save(req *http.Request, key string, resp *http.Response) (*http.Response, error) {
+	ttl := h.storeTTL(resp)
+	if ttl <= 0 {
+		return resp, nil
+	}
+
+	dump, err := httputil.DumpResponse(resp, true)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	b, err := json.Marshal(cacheEntry{Response: dump, StoredAt: h.now(), Vary: varyValues(req, resp.Header)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := h.store.Set(req.Context(), key, b, ttl); err != nil {
+		h.rec.Inc(CacheErrorsMetric, metrics.Labels{"op": "set"})
+	}
+
+	replay, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay response: %w", err)
+	}
+
+	return replay, nil
+}
+
+// storeTTL returns how long resp is kept, or zero if it must not be stored.
+func (h *HTTPCache) storeTTL(resp *http.Response) time.Duration {
+	cc := parseCacheControl(resp.Header)
+	if resp.StatusCode != http.StatusOK || cc.has("no-store") || cc.has("private") || resp.Header.Get("Vary") == "*" {
+		return 0
+	}
+
+	ttl := freshnessLifetime(resp.Header, cc) - initialAge(resp.Header)
+	if hasValidator(resp.Header) {
+		ttl = max(ttl, 0) + h.revalidationTTL
+	}
+
+	return ttl
+}
+
+func varyValues(req *http.Request, h http.Header) map[string]string {
+	var vary map[string]string
+
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+
+			if vary == nil {
+				vary = map[string]string{}
+			}
+
+			vary[http.CanonicalHeaderKey(name)] = req.Header.Get(name)
+		}
+	}
+
+	return vary
+}
+
+func (h *HTTPCache) count(req *http.Request, result string) {
+	h.rec.Inc(CacheMetric, metrics.Labels{"host": req.URL.Host, "result": result})
+}
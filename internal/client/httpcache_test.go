@@ -0,0 +1,201 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+type countRecorder struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *countRecorder) Inc(name string, labels metrics.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[name+" "+labels["result"]]++
+}
+
+func (c *countRecorder) Observe(name string, _ float64, labels metrics.Labels) {
+	c.Inc(name, labels)
+}
+
+func TestHTTPCache(t *testing.T) {
+	type args struct {
+		header        map[string]string
+		requestHeader [2]string
+		secondHeader  [2]string
+		partition     []string
+	}
+
+	type want struct {
+		calls       int32
+		conditional int32
+		cacheHeader string
+		results     map[string]int
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"fresh response is served from cache": {
+			args: args{header: map[string]string{"Cache-Control": "public, max-age=60"}},
+			want: want{calls: 1, cacheHeader: "HIT", results: map[string]int{"miss": 1, "hit": 1}},
+		},
+		"expires is honoured": {
+			args: args{header: map[string]string{"Date": "Mon, 02 Jan 2006 15:04:05 GMT", "Expires": "Mon, 02 Jan 2006 15:04:05 GMT"}},
+			want: want{calls: 2, results: map[string]int{"miss": 2}},
+		},
+		"no-store is not cached": {
+			args: args{header: map[string]string{"Cache-Control": "no-store, max-age=60"}},
+			want: want{calls: 2, results: map[string]int{"miss": 2}},
+		},
+		"private is not cached": {
+			args: args{header: map[string]string{"Cache-Control": "private, max-age=60"}},
+			want: want{calls: 2, results: map[string]int{"miss": 2}},
+		},
+		"stale response is revalidated with its ETag": {
+			args: args{header: map[string]string{"Cache-Control": "max-age=0", "ETag": `"v1"`}},
+			want: want{calls: 2, conditional: 1, cacheHeader: "REVALIDATED", results: map[string]int{"miss": 1, "revalidated": 1}},
+		},
+		"no-cache is always revalidated": {
+			args: args{header: map[string]string{"Cache-Control": "no-cache, max-age=60", "ETag": `"v1"`}},
+			want: want{calls: 2, conditional: 1, cacheHeader: "REVALIDATED", results: map[string]int{"miss": 1, "revalidated": 1}},
+		},
+		"vary mismatch is a miss": {
+			args: args{
+				header:        map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Language"},
+				requestHeader: [2]string{"Accept-Language", "en"},
+				secondHeader:  [2]string{"Accept-Language", "fr"},
+			},
+			want: want{calls: 2, results: map[string]int{"miss": 2}},
+		},
+		"vary match is a hit": {
+			args: args{
+				header:        map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Language"},
+				requestHeader: [2]string{"Accept-Language", "en"},
+				secondHeader:  [2]string{"Accept-Language", "en"},
+			},
+			want: want{calls: 1, cacheHeader: "HIT", results: map[string]int{"miss": 1, "hit": 1}},
+		},
+		"partition mismatch is a miss": {
+			args: args{
+				header:        map[string]string{"Cache-Control": "max-age=60"},
+				requestHeader: [2]string{"X-Tenant-ID", "acme"},
+				secondHeader:  [2]string{"X-Tenant-ID", "globex"},
+				partition:     []string{"X-Tenant-ID"},
+			},
+			want: want{calls: 2, results: map[string]int{"miss": 2}},
+		},
+		"partition match is a hit": {
+			args: args{
+				header:        map[string]string{"Cache-Control": "max-age=60"},
+				requestHeader: [2]string{"X-Tenant-ID", "acme"},
+				secondHeader:  [2]string{"X-Tenant-ID", "acme"},
+				partition:     []string{"X-Tenant-ID"},
+			},
+			want: want{calls: 1, cacheHeader: "HIT", results: map[string]int{"miss": 1, "hit": 1}},
+		},
+		"authorized requests bypass the cache": {
+			args: args{
+				header:        map[string]string{"Cache-Control": "max-age=60"},
+				requestHeader: [2]string{"Authorization", "Bearer token"},
+				secondHeader:  [2]string{"Authorization", "Bearer token"},
+			},
+			want: want{calls: 2, results: map[string]int{}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls, conditional atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+
+				for k, v := range tt.args.header {
+					w.Header().Set(k, v)
+				}
+
+				if r.Header.Get("If-None-Match") != "" && r.Header.Get("If-None-Match") == tt.args.header["ETag"] {
+					conditional.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+
+					return
+				}
+
+				w.Write([]byte(`{"id":1}`))
+			}))
+			defer server.Close()
+
+			rec := &countRecorder{counts: map[string]int{}}
+			c := &http.Client{Transport: client.NewHTTPCache(client.NewMemoryStore(0), server.Client().Transport, rec, client.WithPartitionHeaders(tt.args.partition...))}
+
+			do := func(header [2]string) *http.Response {
+				req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, http.NoBody)
+				if header[0] != "" {
+					req.Header.Set(header[0], header[1])
+				}
+
+				resp, err := c.Do(req)
+				assert.NoError(t, err)
+
+				b, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, `{"id":1}`, string(b))
+
+				return resp
+			}
+
+			do(tt.args.requestHeader)
+			resp := do(tt.args.secondHeader)
+
+			results := map[string]int{}
+			for k, v := range rec.counts {
+				results[k[len(client.CacheMetric)+1:]] = v
+			}
+
+			assert.Equal(t, tt.want.calls, calls.Load())
+			assert.Equal(t, tt.want.conditional, conditional.Load())
+			assert.Equal(t, tt.want.cacheHeader, resp.Header.Get(client.CacheHeader))
+			assert.Equal(t, tt.want.results, results)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := client.NewMemoryStore(2)
+
+	assert.NoError(t, s.Set(ctx, "a", []byte("1"), 0))
+	assert.NoError(t, s.Set(ctx, "b", []byte("2"), time.Minute))
+	assert.NoError(t, s.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ := s.Get(ctx, "a")
+	assert.False(t, ok, "expired entry is evicted first")
+
+	v, ok, err := s.Get(ctx, "c")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), v)
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeNormalized decodes r into a generic value, rewrites it against the type of v so encoding/json accepts it,
+// and decodes the result into v: times in c.TimeLayouts are converted to RFC 3339, and with c.CaseSensitive the keys
+// not matching a field exactly are dropped, or rejected with c.DisallowUnknownFields.
+func (c JSONCodec) decodeNormalized(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	// Numbers are kept as written, so re-encoding them does not lose precision.
+	dec.UseNumber()
+
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	normalized, err := c.normalize(generic, reflect.TypeOf(v))
+	if err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	b, err := json.Marshal(normalized)
+	if err != nil {
+		return fmt.Errorf("failed to normalize json: %w", err)
+	}
+
+	dec = json.NewDecoder(bytes.NewReader(b))
+	if c.UseNumber {
+		dec.UseNumber()
+	}
+
+	if c.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err = dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	return nil
+}
+
+// normalize rewrites the generic JSON value x to be decoded into a t.
+func (c JSONCodec) normalize(x any, t reflect.Type) (any, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil {
+		return x, nil
+	}
+
+	if t == timeType {
+		return c.normalizeTime(x), nil
+	}
+
+	var err error
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := x.(map[string]any); ok {
+			return c.normalizeObject(m, t)
+		}
+	case reflect.Slice, reflect.Array:
+		if a, ok := x.([]any); ok {
+			for i := range a {
+				if a[i], err = c.normalize(a[i], t.Elem()); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case reflect.Map:
+		if m, ok := x.(map[string]any); ok {
+			for k := range m {
+				if m[k], err = c.normalize(m[k], t.Elem()); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return x, nil
+}
+
+func (c JSONCodec) normalizeTime(x any) any {
+	s, ok := x.(string)
+	if !ok {
+		return x
+	}
+
+	if _, err := time.Parse(time.RFC3339, s); err == nil {
+		return s
+	}
+
+	for _, layout := range c.TimeLayouts {
+		if tm, err := time.Parse(layout, s); err == nil {
+			return tm.Format(time.RFC3339Nano)
+		}
+	}
+
+	return s
+}
+
+func (c JSONCodec) normalizeObject(m map[string]any, t reflect.Type) (map[string]any, error) {
+	fields := jsonFields(t)
+	out := make(map[string]any, len(m))
+
+	for k, val := range m {
+		ft, ok := fields[k]
+		if !ok && !c.CaseSensitive {
+			for name, f := range fields {
+				if strings.EqualFold(name, k) {
+					ft, ok = f, true
+					break
+				}
+			}
+		}
+
+		switch {
+		case ok:
+			v, err := c.normalize(val, ft)
+			if err != nil {
+				return nil, err
+			}
+
+			out[k] = v
+		case !c.CaseSensitive:
+			out[k] = val
+		case c.DisallowUnknownFields:
+			return nil, fmt.Errorf("unknown field %q", k)
+		}
+	}
+
+	return out, nil
+}
+
+// jsonFields returns the types of the fields of struct t by JSON name, including the fields of embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		switch {
+		case name == "-":
+			continue
+		case f.Anonymous && name == "" && ft.Kind() == reflect.Struct:
+			for n, embedded := range jsonFields(ft) {
+				if _, ok := fields[n]; !ok {
+					fields[n] = embedded
+				}
+			}
+
+			continue
+		case !f.IsExported():
+			continue
+		case name == "":
+			name = f.Name
+		}
+
+		fields[name] = f.Type
+	}
+
+	return fields
+}
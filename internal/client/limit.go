@@ -0,0 +1,86 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrResponseTooLarge is matched by the *ResponseTooLargeError of a response over the maximum size of its client.
+var ErrResponseTooLarge = errors.New("upstream response too large")
+
+// ResponseTooLargeError is returned when the body of a response exceeds the size set with WithMaxResponseSize, either
+// as declared by its Content-Length or once read past it, so that an upstream answering far more than expected fails
+// the request rather than exhausting the memory of the service.
+type ResponseTooLargeError struct {
+	Method string
+	URL    string
+	// Limit is the maximum size of the body, in bytes.
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("%s %s: %s: over %d bytes", e.Method, e.URL, ErrResponseTooLarge, e.Limit)
+}
+
+// Is matches ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// WithMaxResponseSize bounds the bodies of the successful responses of Get, Post, Put and Do, and the ones decoded by
+// their helpers, to size bytes. Reading past it fails with a *ResponseTooLargeError. Zero does not bound them, and
+// Download is never bounded.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = size
+	}
+}
+
+// limitBody fails resp if it declares a body larger than the maximum size of c, and otherwise bounds the reads of its
+// body.
+func (c *Client) limitBody(req *http.Request, resp *http.Response) error {
+	if c.maxResponseSize <= 0 {
+		return nil
+	}
+
+	tooLarge := &ResponseTooLargeError{Method: req.Method, URL: req.URL.Redacted(), Limit: c.maxResponseSize}
+
+	if resp.ContentLength > c.maxResponseSize {
+		drainAndClose(resp.Body)
+		return tooLarge
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: c.maxResponseSize, err: tooLarge}
+
+	return nil
+}
+
+// limitedBody fails the reads past remaining bytes with err.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+
+	// One more byte than remaining tells a body of exactly the maximum size from a larger one.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+
+	n, b.remaining = int(b.remaining), -1
+
+	return n, b.err
+}
@@ -0,0 +1,90 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+func TestWithMaxResponseSize(t *testing.T) {
+	t.Parallel()
+
+	type fields struct {
+		body          string
+		contentLength bool
+	}
+
+	type want struct {
+		item *item
+		err  bool
+	}
+
+	tests := map[string]struct {
+		fields fields
+		want   want
+	}{
+		"under the limit": {
+			fields: fields{body: `{"id":1}`},
+			want:   want{item: &item{ID: 1}},
+		},
+		"exactly the limit": {
+			fields: fields{body: `{"id":1,"name":"abcdef"}`},
+			want:   want{item: &item{ID: 1, Name: "abcdef"}},
+		},
+		"declared over the limit": {
+			fields: fields{body: `{"id":1,"name":"abcdefg"}`, contentLength: true},
+			want:   want{err: true},
+		},
+		"read over the limit": {
+			fields: fields{body: `{"id":1,"name":"abcdefg"}`},
+			want:   want{err: true},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.fields.contentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(len(tt.fields.body)))
+				} else {
+					// Flushing before writing the body sends it chunked, without a length.
+					w.(http.Flusher).Flush()
+				}
+
+				io.WriteString(w, tt.fields.body)
+			}))
+			defer server.Close()
+
+			c := client.NewClient(server.Client(), client.WithMaxResponseSize(int64(len(`{"id":1,"name":"abcdef"}`))))
+
+			got, err := client.GetAs[item](context.Background(), c, server.URL)
+			if tt.want.err {
+				var tooLarge *client.ResponseTooLargeError
+				if !assert.ErrorAs(t, err, &tooLarge) {
+					return
+				}
+
+				assert.ErrorIs(t, err, client.ErrResponseTooLarge)
+				assert.Equal(t, http.MethodGet, tooLarge.Method)
+				assert.True(t, strings.HasSuffix(err.Error(), "upstream response too large: over 24 bytes"), err.Error())
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.item, got)
+		})
+	}
+}
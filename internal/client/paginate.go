@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PageOption selects how Paginate finds the next page.
+type PageOption func(*pagination)
+
+type pagination struct {
+	// first prepares the URL of the first page.
+	first func(u *url.URL)
+	// next returns the URL of the page after u, given the response header and the number of items of the page, or nil
+	// when u is the last page.
+	next func(u *url.URL, header http.Header, n int) *url.URL
+}
+
+// WithLinkHeader follows the rel="next" URL of the Link header until a page has none. This is the default.
+func WithLinkHeader() PageOption {
+	return func(p *pagination) {
+		p.first = func(*url.URL) {}
+		p.next = func(u *url.URL, header http.Header, _ int) *url.URL {
+			next, ok := nextLink(header)
+			if !ok {
+				return nil
+			}
+
+			ref, err := url.Parse(next)
+			if err != nil {
+				return nil
+			}
+
+			return u.ResolveReference(ref)
+		}
+	}
+}
+
+// WithPageNumbers requests pages of limit items by page number, starting at 1, until a page is not full.
+func WithPageNumbers(pageParam, limitParam string, limit int) PageOption {
+	return func(p *pagination) {
+		p.first = func(u *url.URL) {
+			setQuery(u, pageParam, 1)
+			setQuery(u, limitParam, limit)
+		}
+		p.next = func(u *url.URL, _ http.Header, n int) *url.URL {
+			if n < limit {
+				return nil
+			}
+
+			page, _ := strconv.Atoi(u.Query().Get(pageParam))
+			next := *u
+			setQuery(&next, pageParam, page+1)
+
+			return &next
+		}
+	}
+}
+
+// WithOffsets requests pages of limit items by offset, starting at 0, until a page is not full.
+func WithOffsets(offsetParam, limitParam string, limit int) PageOption {
+	return func(p *pagination) {
+		p.first = func(u *url.URL) {
+			setQuery(u, offsetParam, 0)
+			setQuery(u, limitParam, limit)
+		}
+		p.next = func(u *url.URL, _ http.Header, n int) *url.URL {
+			if n < limit {
+				return nil
+			}
+
+			offset, _ := strconv.Atoi(u.Query().Get(offsetParam))
+			next := *u
+			setQuery(&next, offsetParam, offset+n)
+
+			return &next
+		}
+	}
+}
+
+// Paginator iterates over the items of a paginated list endpoint, fetching pages as they are needed.
+//
+//	p := client.Paginate[Photo](ctx, c, url)
+//	for p.Next() {
+//		photo := p.Item()
+//	}
+//	if err := p.Err(); err != nil {
+//	}
+type Paginator[T any] struct {
+	ctx        context.Context
+	c          getter
+	pagination *pagination
+	next       *url.URL
+	items      []T
+	pos        int
+	err        error
+}
+
+// Paginate returns a Paginator over the JSON arrays returned by the pages starting at rawURL.
+func Paginate[T any](ctx context.Context, c getter, rawURL string, opts ...PageOption) *Paginator[T] {
+	p := &Paginator[T]{ctx: ctx, c: c, pagination: &pagination{}}
+
+	WithLinkHeader()(p.pagination)
+
+	for _, opt := range opts {
+		opt(p.pagination)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		p.err = fmt.Errorf("failed to parse url: %w", err)
+		return p
+	}
+
+	p.pagination.first(u)
+	p.next = u
+
+	return p
+}
+
+// Next advances to the next item, fetching the next page when needed. It returns false when all pages are exhausted
+// or an error occurred.
+func (p *Paginator[T]) Next() bool {
+	for p.err == nil {
+		if p.pos < len(p.items) {
+			p.pos++
+			return true
+		}
+
+		if p.next == nil {
+			return false
+		}
+
+		p.fetch()
+	}
+
+	return false
+}
+
+// Item returns the current item.
+func (p *Paginator[T]) Item() T {
+	return p.items[p.pos-1]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// All collects the remaining items.
+func (p *Paginator[T]) All() ([]T, error) {
+	all := make([]T, 0)
+	for p.Next() {
+		all = append(all, p.Item())
+	}
+
+	return all, p.Err()
+}
+
+func (p *Paginator[T]) fetch() {
+	u := p.next
+
+	resp, err := p.c.Get(p.ctx, u.String())
+	if err != nil {
+		p.err = fmt.Errorf("failed to get page %s: %w", u, err)
+		return
+	}
+
+	header := resp.Header
+
+	items, err := decode[[]T](resp, JSONCodec{})
+	if err != nil {
+		p.err = fmt.Errorf("failed to read page %s: %w", u, err)
+		return
+	}
+
+	p.items, p.pos = nil, 0
+	if items != nil {
+		p.items = *items
+	}
+
+	p.next = p.pagination.next(u, header, len(p.items))
+	// An empty page ends the iteration even if the upstream claims there are more.
+	if len(p.items) == 0 {
+		p.next = nil
+	}
+}
+
+// nextLink returns the target of the rel="next" link of the Link header.
+func nextLink(header http.Header) (string, bool) {
+	for _, v := range header.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			target, params, ok := strings.Cut(link, ";")
+			if !ok {
+				continue
+			}
+
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if name == "rel" && hasRel(strings.Trim(value, `"`), "next") {
+					return strings.Trim(strings.TrimSpace(target), "<>"), true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+func hasRel(rels, rel string) bool {
+	for _, r := range strings.Fields(rels) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setQuery(u *url.URL, key string, value int) {
+	q := u.Query()
+	q.Set(key, strconv.Itoa(value))
+	u.RawQuery = q.Encode()
+}
@@ -0,0 +1,126 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+// pagedServer serves the numbers 1 to total, limit per page, addressed by either "page" or "offset".
+func pagedServer(total int, link bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if limit == 0 {
+			limit = 2
+		}
+
+		start, _ := strconv.Atoi(q.Get("offset"))
+		if page, err := strconv.Atoi(q.Get("page")); err == nil {
+			start = (page - 1) * limit
+		}
+
+		items := []int{}
+		for i := start + 1; i <= total && i <= start+limit; i++ {
+			items = append(items, i)
+		}
+
+		if link && start+limit < total {
+			w.Header().Set("Link", fmt.Sprintf(`</items?page=%d&limit=%d>; rel="next", </items?page=1>; rel="first"`, start/limit+2, limit))
+		}
+
+		json.NewEncoder(w).Encode(items)
+	}))
+}
+
+func TestPaginate(t *testing.T) {
+	type args struct {
+		total int
+		link  bool
+		path  string
+		opts  []client.PageOption
+	}
+
+	type want struct {
+		items []int
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"link header": {
+			args: args{total: 5, link: true, path: "/items"},
+			want: want{items: []int{1, 2, 3, 4, 5}},
+		},
+		"link header single page": {
+			args: args{total: 1, link: true, path: "/items"},
+			want: want{items: []int{1}},
+		},
+		"page numbers": {
+			args: args{total: 7, path: "/items", opts: []client.PageOption{client.WithPageNumbers("page", "limit", 3)}},
+			want: want{items: []int{1, 2, 3, 4, 5, 6, 7}},
+		},
+		"page numbers ending on a full page": {
+			args: args{total: 6, path: "/items", opts: []client.PageOption{client.WithPageNumbers("page", "limit", 3)}},
+			want: want{items: []int{1, 2, 3, 4, 5, 6}},
+		},
+		"offsets": {
+			args: args{total: 5, path: "/items?filter=x", opts: []client.PageOption{client.WithOffsets("offset", "limit", 2)}},
+			want: want{items: []int{1, 2, 3, 4, 5}},
+		},
+		"empty": {
+			args: args{total: 0, path: "/items", opts: []client.PageOption{client.WithOffsets("offset", "limit", 2)}},
+			want: want{items: []int{}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := pagedServer(tt.args.total, tt.args.link)
+			defer server.Close()
+
+			got, err := client.Paginate[int](context.Background(), client.NewClient(server.Client()), server.URL+tt.args.path, tt.args.opts...).All()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.items, got)
+		})
+	}
+}
+
+func TestPaginate_Error(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Link", `</items?page=2>; rel="next"`)
+		w.Write([]byte(`[1,2]`))
+	}))
+	defer server.Close()
+
+	p := client.Paginate[int](context.Background(), client.NewClient(server.Client()), server.URL+"/items")
+
+	var got []int
+	for p.Next() {
+		got = append(got, p.Item())
+	}
+
+	assert.Equal(t, []int{1, 2}, got)
+	assert.ErrorContains(t, p.Err(), "failed to get page "+server.URL+"/items?page=2")
+	assert.ErrorContains(t, p.Err(), "received non-OK HTTP status: 500")
+}
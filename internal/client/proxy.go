@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// proxySchemes are the schemes of the proxies supported by http.Transport.
+var proxySchemes = []string{"http", "https", "socks5"}
+
+var errProxyCredential = errors.New(`proxy credential must be "user:password"`)
+
+// proxyFunc returns the proxy of a request, nil for none, as http.Transport.Proxy.
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+type proxyKey struct{}
+
+// contextWithProxy returns a copy of ctx whose requests are sent through the proxy of p by the transports of
+// NewTransport.
+func contextWithProxy(ctx context.Context, p proxyFunc) context.Context {
+	return context.WithValue(ctx, proxyKey{}, p)
+}
+
+// contextProxy returns the proxy of the context of req, set by a ProxyTransport, or the one of fallback, e.g. from
+// the environment.
+func contextProxy(fallback proxyFunc) proxyFunc {
+	return func(req *http.Request) (*url.URL, error) {
+		if p, ok := req.Context().Value(proxyKey{}).(proxyFunc); ok {
+			return p(req)
+		}
+
+		if fallback == nil {
+			return nil, nil
+		}
+
+		return fallback(req)
+	}
+}
+
+// ProxyTransport sends the requests of an upstream through its proxy, rather than the proxy of the environment. As
+// the connections are pooled by the http.Transport at the bottom of the chain, shared by the upstreams, the proxy
+// travels to it in the request context, and the connections through different proxies are kept apart.
+type ProxyTransport struct {
+	proxy proxyFunc
+	next  http.RoundTripper
+}
+
+// NewProxyTransport creates the ProxyTransport of cfg, delegating to next, which must end in a transport of
+// NewTransport. The proxy is authenticated with credential, "user:password", if set, or the user of its URL.
+func NewProxyTransport(cfg *config.Proxy, credential string, next http.RoundTripper) (*ProxyTransport, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+
+	if !slices.Contains(proxySchemes, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q: expected %s://host:port", u.Redacted(), strings.Join(proxySchemes, ", "))
+	}
+
+	if credential != "" {
+		user, password, ok := strings.Cut(credential, ":")
+		if !ok {
+			return nil, errProxyCredential
+		}
+
+		u.User = url.UserPassword(user, password)
+	}
+
+	// Requests to localhost are never proxied.
+	proxy := (&httpproxy.Config{HTTPProxy: u.String(), HTTPSProxy: u.String(), NoProxy: strings.Join(cfg.NoProxy, ",")}).ProxyFunc()
+
+	return &ProxyTransport{
+		proxy: func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL) //nolint:wrapcheck // the proxy url is valid
+		},
+		next: next,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ProxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(contextWithProxy(req.Context(), t.proxy))) //nolint:wrapcheck // the transport is transparent
+}
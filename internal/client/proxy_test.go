@@ -0,0 +1,143 @@
+package client_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestProxyTransport(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		body          string
+		authorization string
+	}
+
+	tests := map[string]struct {
+		url        string
+		credential string
+		noProxy    []string
+		want       want
+	}{
+		"proxied": {
+			url:  "http://photos.example/photos/1",
+			want: want{body: "proxy photos.example"},
+		},
+		"proxy credential": {
+			url:        "http://photos.example/photos/1",
+			credential: "user:p@ss",
+			want:       want{body: "proxy photos.example", authorization: "Basic " + base64.StdEncoding.EncodeToString([]byte("user:p@ss"))},
+		},
+		"no proxy host": {
+			url:     "http://photos.example/photos/1",
+			noProxy: []string{"other.example", "photos.example"},
+			want:    want{body: "direct"},
+		},
+		"no proxy domain": {
+			url:     "http://photos.example/photos/1",
+			noProxy: []string{".example"},
+			want:    want{body: "direct"},
+		},
+		"no proxy other port": {
+			url:     "http://photos.example/photos/1",
+			noProxy: []string{"photos.example:8443"},
+			want:    want{body: "proxy photos.example"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// A forward proxy receives the absolute URL of the requests.
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.want.authorization, r.Header.Get("Proxy-Authorization"))
+				w.Write([]byte("proxy " + r.URL.Host))
+			}))
+			t.Cleanup(proxy.Close)
+
+			direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte("direct"))
+			}))
+			t.Cleanup(direct.Close)
+
+			base, err := client.NewTransport(&config.Transport{}, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// The hosts of the test resolve to the direct server.
+			dial := base.DialContext
+			base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if addr == "photos.example:80" {
+					addr = direct.Listener.Addr().String()
+				}
+
+				return dial(ctx, network, addr)
+			}
+
+			rt, err := client.NewProxyTransport(&config.Proxy{URL: proxy.URL, NoProxy: tt.noProxy}, tt.credential, base)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, tt.url, http.NoBody)
+
+			resp, err := rt.RoundTrip(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			b, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.want.body, string(b))
+
+			// Requests sent without the ProxyTransport are not proxied.
+			resp, err = (&http.Client{Transport: base}).Get(tt.url)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			b, _ = io.ReadAll(resp.Body)
+			assert.Equal(t, "direct", string(b))
+		})
+	}
+}
+
+func TestNewProxyTransport_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		url        string
+		credential string
+		want       string
+	}{
+		"scheme":     {url: "ftp://proxy:21", want: `invalid proxy url "ftp://proxy:21": expected http, https, socks5://host:port`},
+		"no host":    {url: "proxy:3128", want: `invalid proxy url "proxy:3128"`},
+		"credential": {url: "socks5://proxy:1080", credential: "user", want: `proxy credential must be "user:password"`},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := client.NewProxyTransport(&config.Proxy{URL: tt.url}, tt.credential, http.DefaultTransport)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// Rate limit modes accepted by config.RateLimit.Mode.
+const (
+	RateLimitModeBlock    = "block"
+	RateLimitModeFailFast = "fail_fast"
+)
+
+const (
+	// RateLimitWaitMetric observes how long requests waited for the rate limiter, in seconds, by base URL.
+	RateLimitWaitMetric = "http_client_rate_limit_wait_seconds"
+	// RateLimitedMetric counts the requests rejected by a fail fast rate limiter, by base URL.
+	RateLimitedMetric = "http_client_rate_limited_total"
+)
+
+var (
+	// ErrRateLimited is returned by a fail fast RateLimiter when the quota of the upstream is exhausted.
+	ErrRateLimited      = errors.New("rate limited")
+	errRateLimitRestart = errors.New("needs a restart")
+)
+
+// RateLimiter is an http.RoundTripper limiting the rate of requests to upstreams with a token bucket per base URL.
+// Requests not matching any base URL are not limited.
+type RateLimiter struct {
+	limits []*hostLimit
+	next   http.RoundTripper
+	rec    recorder
+}
+
+type hostLimit struct {
+	baseURL  string
+	limiter  *rate.Limiter
+	failFast bool
+}
+
+// NewRateLimiter creates a RateLimiter delegating to next.
+func NewRateLimiter(cfg []config.RateLimit, next http.RoundTripper, rec recorder) (*RateLimiter, error) {
+	r := &RateLimiter{next: next, rec: rec}
+
+	for _, l := range cfg {
+		if err := validateRateLimit(l); err != nil {
+			return nil, err
+		}
+
+		r.limits = append(r.limits, &hostLimit{
+			baseURL:  l.BaseURL,
+			limiter:  rate.NewLimiter(rate.Limit(l.RPS), l.Burst),
+			failFast: l.Mode == RateLimitModeFailFast,
+		})
+	}
+
+	// The longest base URL is the most specific match.
+	sort.Slice(r.limits, func(i, j int) bool { return len(r.limits[i].baseURL) > len(r.limits[j].baseURL) })
+
+	return r, nil
+}
+
+func validateRateLimit(l config.RateLimit) error {
+	if l.RPS <= 0 || l.Burst <= 0 {
+		return fmt.Errorf("rate limit of %s: rps and burst must be positive", l.BaseURL)
+	}
+
+	if l.Mode != "" && l.Mode != RateLimitModeBlock && l.Mode != RateLimitModeFailFast {
+		return fmt.Errorf("rate limit of %s: unknown mode %q", l.BaseURL, l.Mode)
+	}
+
+	return nil
+}
+
+// Update applies the rates and bursts of cfg to the running limiters, e.g. once changed remotely. Adding or removing a
+// base URL, or changing its mode, needs a new RateLimiter: these changes are returned as errors, and the others are
+// applied.
+func (r *RateLimiter) Update(cfg []config.RateLimit) error {
+	var errs []error
+
+	seen := map[string]bool{}
+
+	for _, l := range cfg {
+		seen[l.BaseURL] = true
+
+		if err := validateRateLimit(l); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		i := slices.IndexFunc(r.limits, func(hl *hostLimit) bool { return hl.baseURL == l.BaseURL })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("rate limit of %s: %w", l.BaseURL, errRateLimitRestart))
+			continue
+		}
+
+		if r.limits[i].failFast != (l.Mode == RateLimitModeFailFast) {
+			errs = append(errs, fmt.Errorf("rate limit mode of %s: %w", l.BaseURL, errRateLimitRestart))
+		}
+
+		r.limits[i].limiter.SetLimit(rate.Limit(l.RPS))
+		r.limits[i].limiter.SetBurst(l.Burst)
+	}
+
+	for _, hl := range r.limits {
+		if !seen[hl.baseURL] {
+			errs = append(errs, fmt.Errorf("rate limit of %s removed: %w", hl.baseURL, errRateLimitRestart))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if l := r.match(req); l != nil {
+		if err := r.wait(req, l); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.next.RoundTrip(req) //nolint:wrapcheck // the limiter is transparent
+}
+
+func (r *RateLimiter) match(req *http.Request) *hostLimit {
+	u := req.URL.String()
+	for _, l := range r.limits {
+		if strings.HasPrefix(u, l.baseURL) {
+			return l
+		}
+	}
+
+	return nil
+}
+
+// wait takes a token, failing immediately when none is left in fail fast mode, or waiting for one otherwise.
+func (r *RateLimiter) wait(req *http.Request, l *hostLimit) error {
+	labels := metrics.Labels{"base_url": l.baseURL}
+
+	if l.failFast {
+		if !l.limiter.Allow() {
+			r.rec.Inc(RateLimitedMetric, labels)
+			return fmt.Errorf("%w: %s", ErrRateLimited, l.baseURL)
+		}
+
+		return nil
+	}
+
+	start := time.Now()
+	err := l.limiter.Wait(req.Context())
+
+	r.rec.Observe(RateLimitWaitMetric, time.Since(start).Seconds(), labels)
+
+	if err != nil {
+		return fmt.Errorf("failed to wait for rate limit of %s: %w", l.baseURL, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestRateLimiter(t *testing.T) {
+	type args struct {
+		limit    config.RateLimit
+		path     string
+		requests int
+		timeout  time.Duration
+	}
+
+	type want struct {
+		ok       int
+		err      string
+		minTotal time.Duration
+		metric   string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"block waits for tokens": {
+			args: args{limit: config.RateLimit{RPS: 20, Burst: 1, Mode: client.RateLimitModeBlock}, requests: 3, timeout: time.Second},
+			want: want{ok: 3, minTotal: 90 * time.Millisecond, metric: client.RateLimitWaitMetric},
+		},
+		"block gives up when the context ends": {
+			args: args{limit: config.RateLimit{RPS: 1, Burst: 1}, requests: 2, timeout: 50 * time.Millisecond},
+			want: want{ok: 1, err: "would exceed context deadline", metric: client.RateLimitWaitMetric},
+		},
+		"fail fast rejects when the bucket is empty": {
+			args: args{limit: config.RateLimit{RPS: 1, Burst: 2, Mode: client.RateLimitModeFailFast}, requests: 3, timeout: time.Second},
+			want: want{ok: 2, err: client.ErrRateLimited.Error(), metric: client.RateLimitedMetric},
+		},
+		"other base urls are not limited": {
+			args: args{limit: config.RateLimit{RPS: 1, Burst: 1, Mode: client.RateLimitModeFailFast}, path: "/other", requests: 3, timeout: time.Second},
+			want: want{ok: 3},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			tt.args.limit.BaseURL = server.URL + "/photos"
+			rec := &countRecorder{counts: map[string]int{}}
+
+			rl, err := client.NewRateLimiter([]config.RateLimit{tt.args.limit}, server.Client().Transport, rec)
+			assert.NoError(t, err)
+
+			c := &http.Client{Transport: rl}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.args.timeout)
+			defer cancel()
+
+			path := tt.args.path
+			if path == "" {
+				path = "/photos/1"
+			}
+
+			start := time.Now()
+			ok := 0
+
+			var lastErr error
+
+			for i := 0; i < tt.args.requests; i++ {
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, http.NoBody)
+
+				resp, err := c.Do(req)
+				if err != nil {
+					lastErr = err
+					continue
+				}
+
+				resp.Body.Close()
+				ok++
+			}
+
+			assert.Equal(t, tt.want.ok, ok)
+			assert.GreaterOrEqual(t, time.Since(start), tt.want.minTotal)
+
+			if tt.want.err != "" {
+				assert.ErrorContains(t, lastErr, tt.want.err)
+			}
+
+			if tt.want.metric != "" {
+				assert.Positive(t, rec.counts[tt.want.metric+" "])
+			}
+		})
+	}
+}
+
+func TestNewRateLimiter_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := client.NewRateLimiter([]config.RateLimit{{BaseURL: "http://a", RPS: 1, Burst: 1, Mode: "drop"}}, http.DefaultTransport, nil)
+	assert.ErrorContains(t, err, `unknown mode "drop"`)
+
+	_, err = client.NewRateLimiter([]config.RateLimit{{BaseURL: "http://a", RPS: 0, Burst: 1}}, http.DefaultTransport, nil)
+	assert.ErrorContains(t, err, "rps and burst must be positive")
+}
+
+func TestRateLimiter_Update(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limit := config.RateLimit{BaseURL: server.URL, RPS: 0.1, Burst: 1, Mode: client.RateLimitModeFailFast}
+
+	rl, err := client.NewRateLimiter([]config.RateLimit{limit}, server.Client().Transport, &countRecorder{counts: map[string]int{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	limit.RPS = 1000
+	err = rl.Update([]config.RateLimit{limit, {BaseURL: "http://other", RPS: 1, Burst: 1}})
+	assert.ErrorContains(t, err, "rate limit of http://other: needs a restart")
+
+	c := &http.Client{Transport: rl}
+	ok := 0
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, http.NoBody)
+
+		resp, doErr := c.Do(req)
+		if doErr != nil {
+			continue
+		}
+
+		resp.Body.Close()
+		ok++
+	}
+
+	assert.Equal(t, 3, ok, "the new rate applies")
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// defaultMaxRedirects is the number of redirects followed when config.Redirects.MaxHops is zero, as net/http does.
+const defaultMaxRedirects = 10
+
+var (
+	// ErrTooManyRedirects is returned when a request is redirected more than config.Redirects.MaxHops times.
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrCrossHostRedirect is returned when a request is redirected to another host and cross host redirects are
+	// forbidden.
+	ErrCrossHostRedirect = errors.New("cross host redirect")
+)
+
+// CheckRedirect returns the http.Client.CheckRedirect function applying cfg. When redirects are disabled, the 3xx
+// response is returned to the caller, which Get and Post report as *HTTPError unless accepted by WithStatusCodes.
+func CheckRedirect(cfg *config.Redirects) func(req *http.Request, via []*http.Request) error {
+	maxHops := cfg.MaxHops
+	if maxHops == 0 {
+		maxHops = defaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if cfg.Disabled {
+			return http.ErrUseLastResponse
+		}
+
+		if len(via) > maxHops {
+			return fmt.Errorf("stopped after %d redirects: %w", maxHops, ErrTooManyRedirects)
+		}
+
+		// Every redirect is built from the headers of the original request, so hosts are compared to it.
+		if strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			return nil
+		}
+
+		if cfg.ForbidCrossHost {
+			return fmt.Errorf("redirected from %s to %s: %w", via[0].URL.Host, req.URL.Host, ErrCrossHostRedirect)
+		}
+
+		// net/http keeps credentials on redirects to subdomains, they are only sent to the original host.
+		if !cfg.ForwardAuthorization {
+			req.Header.Del("Authorization")
+		}
+
+		return nil
+	}
+}
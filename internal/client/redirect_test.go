@@ -0,0 +1,111 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		cfg  config.Redirects
+		path string
+	}
+
+	type want struct {
+		body   string
+		status int
+		err    error
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"same host keeps authorization": {
+			args: args{path: "/same"},
+			want: want{body: "Bearer token"},
+		},
+		"cross host strips authorization": {
+			args: args{path: "/cross"},
+			want: want{body: ""},
+		},
+		"cross host forwards authorization": {
+			args: args{cfg: config.Redirects{ForwardAuthorization: true}, path: "/cross"},
+			want: want{body: "Bearer token"},
+		},
+		"cross host forbidden": {
+			args: args{cfg: config.Redirects{ForbidCrossHost: true}, path: "/cross"},
+			want: want{err: client.ErrCrossHostRedirect},
+		},
+		"within max hops": {
+			args: args{cfg: config.Redirects{MaxHops: 2}, path: "/twice"},
+			want: want{body: "Bearer token"},
+		},
+		"too many redirects": {
+			args: args{cfg: config.Redirects{MaxHops: 1}, path: "/twice"},
+			want: want{err: client.ErrTooManyRedirects},
+		},
+		"disabled": {
+			args: args{cfg: config.Redirects{Disabled: true}, path: "/same"},
+			want: want{status: http.StatusFound},
+		},
+	}
+
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(echo.Close)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	})
+	mux.Handle("/same", http.RedirectHandler("/echo", http.StatusFound))
+	mux.Handle("/twice", http.RedirectHandler("/same", http.StatusFound))
+	mux.Handle("/cross", http.RedirectHandler(echo.URL, http.StatusFound))
+
+	origin := httptest.NewServer(mux)
+	t.Cleanup(origin.Close)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hc := &http.Client{CheckRedirect: client.CheckRedirect(&tt.args.cfg)}
+			c := client.NewClient(doerFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("Authorization", "Bearer token")
+				return hc.Do(req)
+			}))
+
+			resp, err := c.Get(context.Background(), origin.URL+tt.args.path)
+
+			var httpErr *client.HTTPError
+
+			switch {
+			case tt.want.err != nil:
+				assert.ErrorIs(t, err, tt.want.err)
+			case tt.want.status != 0:
+				if assert.ErrorAs(t, err, &httpErr) {
+					assert.Equal(t, tt.want.status, httpErr.StatusCode)
+					assert.Equal(t, "/echo", httpErr.Header.Get("Location"))
+				}
+			case assert.NoError(t, err):
+				defer resp.Body.Close()
+
+				b, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.want.body, string(b))
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+const (
+	// DNSLookupMetric counts the lookups of host names by host and result: "static" for the static hosts, "hit" and
+	// "negative_hit" for the cached addresses and failures, "miss" and "error" for the lookups of the resolver.
+	DNSLookupMetric = "http_client_dns_lookups_total"
+	// DNSLookupDurationMetric observes the duration of the lookups of the resolver, by host.
+	DNSLookupDurationMetric = "http_client_dns_lookup_seconds"
+
+	defaultDNSMaxTTL = 5 * time.Minute
+)
+
+// hostResolver resolves host names, as net.Resolver.
+type hostResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// Resolver resolves the host names of the outbound connections with static hosts, then with the name servers of the
+// system or the configured ones, caching their answers when enabled. Concurrent lookups of a host are merged, so that
+// a burst of new connections queries the name servers once. Addresses are cached for the TTL of their records,
+// bounded by the maximum TTL. Host names which do not exist are cached for the negative TTL, other failures are not
+// cached.
+type Resolver struct {
+	resolver    *net.Resolver
+	cache       bool
+	maxTTL      time.Duration
+	negativeTTL time.Duration
+	hosts       map[string][]netip.Addr
+	rec         recorder
+	group       singleflight.Group
+	mu          sync.Mutex
+	entries     map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []netip.Addr
+	err     error
+	expires time.Time
+}
+
+// NewResolver creates the Resolver of cfg, recording its lookups with rec.
+func NewResolver(cfg *config.DNS, rec recorder) (*Resolver, error) {
+	r := &Resolver{
+		cache:       cfg.Cache,
+		maxTTL:      cfg.MaxTTL,
+		negativeTTL: cfg.NegativeTTL,
+		hosts:       make(map[string][]netip.Addr, len(cfg.Hosts)),
+		rec:         rec,
+		entries:     map[string]dnsEntry{},
+	}
+
+	if r.maxTTL <= 0 {
+		r.maxTTL = defaultDNSMaxTTL
+	}
+
+	for _, h := range cfg.Hosts {
+		for _, a := range h.Addresses {
+			addr, err := netip.ParseAddr(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address of host %s: %w", h.Name, err)
+			}
+
+			name := strings.ToLower(h.Name)
+			r.hosts[name] = append(r.hosts[name], addr)
+		}
+	}
+
+	for _, server := range cfg.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return nil, fmt.Errorf("invalid name server %q: %w", server, err)
+		}
+	}
+
+	var next atomic.Uint32
+
+	// The pure Go resolver dials the name servers with Dial, which reads the TTLs of the answers.
+	dialer := &net.Dialer{}
+	r.resolver = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		if len(cfg.Servers) > 0 {
+			address = cfg.Servers[int(next.Add(1)-1)%len(cfg.Servers)]
+		}
+
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // the resolver wraps the errors of the name servers
+		}
+
+		return withTTLs(ctx, conn), nil
+	}}
+
+	return r, nil
+}
+
+// LookupNetIP returns the addresses of host of network, "ip", "ip4" or "ip6", as net.Resolver.LookupNetIP.
+func (r *Resolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	addrs, ok := r.hosts[host]
+	if ok {
+		r.rec.Inc(DNSLookupMetric, metrics.Labels{"host": host, "result": "static"})
+	} else {
+		var err error
+
+		addrs, err = r.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	addrs = addrsOf(network, addrs)
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no " + network + " address", Name: host, IsNotFound: true}
+	}
+
+	return addrs, nil
+}
+
+// lookup returns the addresses of host from the cache or the resolver.
+func (r *Resolver) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	if r.cache {
+		r.mu.Lock()
+		e, ok := r.entries[host]
+		r.mu.Unlock()
+
+		if ok && time.Now().Before(e.expires) {
+			if e.err != nil {
+				r.rec.Inc(DNSLookupMetric, metrics.Labels{"host": host, "result": "negative_hit"})
+				return nil, e.err
+			}
+
+			r.rec.Inc(DNSLookupMetric, metrics.Labels{"host": host, "result": "hit"})
+
+			return e.addrs, nil
+		}
+	}
+
+	// The lookup is shared by the concurrent callers, so it is not canceled with the context of the first one.
+	ch := r.group.DoChan(host, func() (any, error) {
+		return r.resolve(context.WithoutCancel(ctx), host)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err //nolint:wrapcheck // the errors are *net.DNSError
+		}
+
+		return res.Val.([]netip.Addr), nil //nolint:forcetypeassert // resolve returns addresses
+	case <-ctx.Done():
+		return nil, ctx.Err() //nolint:wrapcheck // the context error is expected by callers
+	}
+}
+
+// resolve looks host up with the resolver, caching the answer.
+func (r *Resolver) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	ttls := &ttlRecorder{}
+
+	start := time.Now()
+	addrs, err := r.resolver.LookupNetIP(context.WithValue(ctx, ttlKey{}, ttls), "ip", host)
+	r.rec.Observe(DNSLookupDurationMetric, time.Since(start).Seconds(), metrics.Labels{"host": host})
+
+	if err != nil {
+		r.rec.Inc(DNSLookupMetric, metrics.Labels{"host": host, "result": "error"})
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound && r.negativeTTL > 0 {
+			r.store(host, dnsEntry{err: err, expires: time.Now().Add(r.negativeTTL)})
+		}
+
+		return nil, err //nolint:wrapcheck // the errors are *net.DNSError
+	}
+
+	r.rec.Inc(DNSLookupMetric, metrics.Labels{"host": host, "result": "miss"})
+
+	ttl := r.maxTTL
+	if t, ok := ttls.min(); ok && t < ttl {
+		ttl = t
+	}
+
+	r.store(host, dnsEntry{addrs: addrs, expires: time.Now().Add(ttl)})
+
+	return addrs, nil
+}
+
+// store caches e for host, unless caching is disabled, and removes the expired entries.
+func (r *Resolver) store(host string, e dnsEntry) {
+	if !r.cache {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for h, old := range r.entries {
+		if !now.Before(old.expires) {
+			delete(r.entries, h)
+		}
+	}
+
+	r.entries[host] = e
+}
+
+// dialer wraps dial so that host names are resolved by r. As net.Dialer does, the addresses of the family of the
+// first one are dialed in turn, and raced with those of the other family once fallbackDelay elapsed (Happy Eyeballs),
+// unless it is negative. The attempts share timeout, so that an unreachable address does not take all of it.
+func (r *Resolver) dialer(dial dialFunc, timeout, fallbackDelay time.Duration) dialFunc {
+	if fallbackDelay == 0 {
+		fallbackDelay = defaultFallbackDelay
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dial(ctx, network, addr)
+		}
+
+		if _, err := netip.ParseAddr(host); err == nil {
+			return dial(ctx, network, addr)
+		}
+
+		// The timeout includes the lookup, as the one of net.Dialer.
+		if timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		ips, err := r.LookupNetIP(ctx, ipNetwork(network), host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+
+		primaries, fallbacks := splitFamilies(ips)
+		if len(fallbacks) == 0 || fallbackDelay < 0 {
+			return dialSerial(ctx, network, append(primaries, fallbacks...), port, dial)
+		}
+
+		// The families are raced as the networks of their addresses.
+		primary, fallback := familyNetwork(network, primaries[0]), familyNetwork(network, fallbacks[0])
+		byNetwork := map[string][]netip.Addr{primary: primaries, fallback: fallbacks}
+
+		return raceDial(ctx, primary, fallback, fallbackDelay, addr, func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialSerial(ctx, network, byNetwork[network], port, dial)
+		})
+	}
+}
+
+// minDialAttempt is the least time given to the dial of an address, as by net.Dialer.
+const minDialAttempt = 2 * time.Second
+
+// dialSerial dials ips in turn until one connects, returning the error of the first one otherwise. Each attempt is
+// given its share of the time left before the deadline of ctx, at least minDialAttempt.
+func dialSerial(ctx context.Context, network string, ips []netip.Addr, port string, dial dialFunc) (net.Conn, error) {
+	var firstErr error
+
+	for i, ip := range ips {
+		conn, err := dialAttempt(ctx, network, net.JoinHostPort(ip.Unmap().String(), port), len(ips)-i, dial)
+		if err == nil {
+			return conn, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, firstErr
+}
+
+// dialAttempt dials addr within its share of the time left before the deadline of ctx, among remaining attempts.
+func dialAttempt(ctx context.Context, network, addr string, remaining int, dial dialFunc) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		share := max(time.Until(deadline)/time.Duration(remaining), minDialAttempt)
+
+		var cancel context.CancelFunc
+
+		// The context only bounds the dial, the connection outlives it.
+		ctx, cancel = context.WithTimeout(ctx, share)
+		defer cancel()
+	}
+
+	return dial(ctx, network, addr)
+}
+
+// splitFamilies splits ips between the family of the first one and the other, keeping their order.
+func splitFamilies(ips []netip.Addr) (primaries, fallbacks []netip.Addr) {
+	for _, ip := range ips {
+		if ip.Unmap().Is4() == ips[0].Unmap().Is4() {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+
+	return primaries, fallbacks
+}
+
+// familyNetwork returns the network of the family of ip for network, e.g. tcp6 for tcp and an IPv6 address.
+func familyNetwork(network string, ip netip.Addr) string {
+	network = strings.TrimRight(network, "46")
+	if ip.Unmap().Is4() {
+		return network + "4"
+	}
+
+	return network + "6"
+}
+
+// ipNetwork returns the IP network of the dial network, e.g. ip4 for tcp4.
+func ipNetwork(network string) string {
+	switch network {
+	case "tcp4", "udp4":
+		return "ip4"
+	case "tcp6", "udp6":
+		return "ip6"
+	default:
+		return "ip"
+	}
+}
+
+// addrsOf returns the addresses of network among addrs.
+func addrsOf(network string, addrs []netip.Addr) []netip.Addr {
+	if network == "ip" {
+		return addrs
+	}
+
+	var of []netip.Addr
+
+	for _, a := range addrs {
+		if a.Unmap().Is4() == (network == "ip4") {
+			of = append(of, a)
+		}
+	}
+
+	return of
+}
+
+type ttlKey struct{}
+
+// ttlRecorder records the lowest TTL of the answers to the queries of a lookup.
+type ttlRecorder struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen bool
+}
+
+func (t *ttlRecorder) observe(ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.seen || ttl < t.ttl {
+		t.ttl, t.seen = ttl, true
+	}
+}
+
+func (t *ttlRecorder) min() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.ttl, t.seen
+}
+
+// withTTLs returns conn reading the TTLs of the answers it receives into the ttlRecorder of ctx, if any. The pure Go
+// resolver sends a message per datagram over UDP, and prefixes them with their length over TCP.
+func withTTLs(ctx context.Context, conn net.Conn) net.Conn {
+	ttls, ok := ctx.Value(ttlKey{}).(*ttlRecorder)
+	if !ok {
+		return conn
+	}
+
+	// The resolver tells datagrams from streams by net.PacketConn.
+	if udp, ok := conn.(*net.UDPConn); ok {
+		return &ttlPacketConn{UDPConn: udp, ttls: ttls}
+	}
+
+	return &ttlStreamConn{Conn: conn, ttls: ttls}
+}
+
+type ttlPacketConn struct {
+	*net.UDPConn
+	ttls *ttlRecorder
+}
+
+func (c *ttlPacketConn) Read(b []byte) (int, error) {
+	n, err := c.UDPConn.Read(b)
+	if n > 0 {
+		observeTTLs(b[:n], c.ttls)
+	}
+
+	return n, err //nolint:wrapcheck // the connection is transparent
+}
+
+type ttlStreamConn struct {
+	net.Conn
+	ttls *ttlRecorder
+	buf  []byte
+}
+
+func (c *ttlStreamConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.buf = append(c.buf, b[:n]...)
+
+	for len(c.buf) >= 2 {
+		size := int(c.buf[0])<<8 | int(c.buf[1])
+		if len(c.buf) < 2+size {
+			break
+		}
+
+		observeTTLs(c.buf[2:2+size], c.ttls)
+		c.buf = c.buf[2+size:]
+	}
+
+	return n, err //nolint:wrapcheck // the connection is transparent
+}
+
+// observeTTLs records the TTLs of the answers of msg, ignoring the messages which do not parse.
+func observeTTLs(msg []byte, ttls *ttlRecorder) {
+	var p dnsmessage.Parser
+
+	h, err := p.Start(msg)
+	if err != nil || !h.Response || h.RCode != dnsmessage.RCodeSuccess {
+		return
+	}
+
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	for {
+		a, err := p.AnswerHeader()
+		if err != nil {
+			return
+		}
+
+		ttls.observe(time.Duration(a.TTL) * time.Second)
+
+		if err := p.SkipAnswer(); err != nil {
+			return
+		}
+	}
+}
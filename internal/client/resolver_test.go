@@ -0,0 +1,300 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// dnsServer is a name server answering the A queries of photos.example with 127.0.0.1 and ttl, and any other name
+// with NXDOMAIN, after delay.
+type dnsServer struct {
+	conn    net.PacketConn
+	ttl     uint32
+	delay   time.Duration
+	queries atomic.Int32
+}
+
+func newDNSServer(t *testing.T, ttl uint32, delay time.Duration) *dnsServer {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	s := &dnsServer{conn: conn, ttl: ttl, delay: delay}
+	go s.serve()
+
+	return s
+}
+
+func (s *dnsServer) serve() {
+	buf := make([]byte, 512)
+
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var p dnsmessage.Parser
+
+		h, err := p.Start(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		q, err := p.Question()
+		if err != nil {
+			continue
+		}
+
+		go s.answer(addr, h.ID, q)
+	}
+}
+
+func (s *dnsServer) answer(addr net.Addr, id uint16, q dnsmessage.Question) {
+	time.Sleep(s.delay)
+
+	header := dnsmessage.Header{ID: id, Response: true, Authoritative: true}
+
+	known := q.Name.String() == "photos.example."
+	if known {
+		s.queries.Add(1)
+	} else {
+		header.RCode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(nil, header)
+	b.EnableCompression()
+	_ = b.StartQuestions()
+	_ = b.Question(q)
+	_ = b.StartAnswers()
+
+	if known && q.Type == dnsmessage.TypeA {
+		_ = b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: s.ttl}, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}})
+	}
+
+	msg, err := b.Finish()
+	if err == nil {
+		_, _ = s.conn.WriteTo(msg, addr)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		err     string
+		queries int32
+		results map[string]int
+	}
+
+	tests := map[string]struct {
+		cfg    config.DNS
+		ttl    uint32
+		host   string
+		lookup int
+		want   want
+	}{
+		"cached for the ttl": {
+			cfg:    config.DNS{Cache: true},
+			ttl:    60,
+			host:   "photos.example",
+			lookup: 3,
+			want:   want{queries: 2, results: map[string]int{"miss": 1, "hit": 2}},
+		},
+		"zero ttl": {
+			cfg:    config.DNS{Cache: true},
+			ttl:    0,
+			host:   "photos.example",
+			lookup: 2,
+			want:   want{queries: 4, results: map[string]int{"miss": 2}},
+		},
+		"max ttl": {
+			cfg:    config.DNS{Cache: true, MaxTTL: time.Nanosecond},
+			ttl:    60,
+			host:   "photos.example",
+			lookup: 2,
+			want:   want{queries: 4, results: map[string]int{"miss": 2}},
+		},
+		"not cached": {
+			ttl:    60,
+			host:   "photos.example",
+			lookup: 2,
+			want:   want{queries: 4, results: map[string]int{"miss": 2}},
+		},
+		"negative cache": {
+			cfg:    config.DNS{Cache: true, NegativeTTL: time.Minute},
+			host:   "missing.example",
+			lookup: 2,
+			want:   want{err: "no such host", results: map[string]int{"error": 1, "negative_hit": 1}},
+		},
+		"no negative cache": {
+			cfg:    config.DNS{Cache: true},
+			host:   "missing.example",
+			lookup: 2,
+			want:   want{err: "no such host", results: map[string]int{"error": 2}},
+		},
+		"static host": {
+			cfg: config.DNS{Hosts: []config.StaticHost{
+				{Name: "Photos.Example", Addresses: []string{"127.0.0.2", "::1"}},
+			}},
+			host:   "photos.example",
+			lookup: 2,
+			want:   want{results: map[string]int{"static": 2}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newDNSServer(t, tt.ttl, 0)
+			tt.cfg.Servers = []string{srv.conn.LocalAddr().String()}
+
+			rec := &countRecorder{counts: map[string]int{}}
+
+			r, err := client.NewResolver(&tt.cfg, rec)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for range tt.lookup {
+				addrs, err := r.LookupNetIP(context.Background(), "ip4", tt.host)
+				if tt.want.err != "" {
+					assert.ErrorContains(t, err, tt.want.err)
+					continue
+				}
+
+				if assert.NoError(t, err) && assert.Len(t, addrs, 1) {
+					assert.True(t, addrs[0].IsLoopback())
+				}
+			}
+
+			assert.Equal(t, tt.want.queries, srv.queries.Load())
+
+			for result, n := range tt.want.results {
+				assert.Equal(t, n, rec.counts[client.DNSLookupMetric+" "+result], result)
+			}
+		})
+	}
+}
+
+func TestResolver_ConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	srv := newDNSServer(t, 60, 50*time.Millisecond)
+
+	r, err := client.NewResolver(&config.DNS{Cache: true, Servers: []string{srv.conn.LocalAddr().String()}}, &countRecorder{counts: map[string]int{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			addrs, err := r.LookupNetIP(context.Background(), "ip", "photos.example")
+			assert.NoError(t, err)
+			assert.Equal(t, []netip.Addr{netip.MustParseAddr("127.0.0.1")}, addrs)
+		}()
+	}
+
+	wg.Wait()
+
+	// One A and one AAAA query.
+	assert.Equal(t, int32(2), srv.queries.Load())
+}
+
+func TestNewTransport_Resolver(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	t.Cleanup(server.Close)
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	r, err := client.NewResolver(&config.DNS{Hosts: []config.StaticHost{{Name: "photos.example", Addresses: []string{"127.0.0.1"}}}}, &countRecorder{counts: map[string]int{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	transport, err := client.NewTransport(&config.Transport{IPFamily: client.IPFamilyPreferIPv6}, r)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://photos.example:" + port + "/")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "photos.example:"+port, string(b))
+
+	_, err = client.NewResolver(&config.DNS{Hosts: []config.StaticHost{{Name: "photos.example", Addresses: []string{"localhost"}}}}, nil)
+	assert.ErrorContains(t, err, "invalid address of host photos.example")
+}
+
+func TestNewTransport_ResolverDualStack(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	t.Cleanup(server.Close)
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// Nothing listens on the IPv6 address, so the connection is made over the other family without a preference.
+	r, err := client.NewResolver(&config.DNS{Hosts: []config.StaticHost{{Name: "photos.example", Addresses: []string{"::1", "127.0.0.1"}}}}, &countRecorder{counts: map[string]int{}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for name, cfg := range map[string]config.Transport{
+		"raced":    {DialTimeout: 5 * time.Second},
+		"in turn":  {DialTimeout: 5 * time.Second, FallbackDelay: -1},
+		"no limit": {},
+	} {
+		transport, err := client.NewTransport(&cfg, r)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+
+		resp, err := (&http.Client{Transport: transport}).Get("http://photos.example:" + port + "/")
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "photos.example:"+port, string(b), name)
+	}
+}
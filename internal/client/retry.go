@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// RetryMetric counts the retried requests, by base URL.
+const RetryMetric = "http_client_retries_total"
+
+// RetrySkippedMetric counts the failed requests not retried although attempts were left, by base URL and reason:
+// "budget" when the retry budget is exhausted, "deadline" when the deadline of the request cannot fit another attempt.
+const RetrySkippedMetric = "http_client_retries_skipped_total"
+
+// Reasons of RetrySkippedMetric.
+const (
+	retrySkippedBudget   = "budget"
+	retrySkippedDeadline = "deadline"
+)
+
+// Defaults of the retries.
+const (
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultRetryMaxBackoff = 2 * time.Second
+	// retryBudgetReserve is the number of retries the budget allows at once, and to upstreams with little traffic.
+	retryBudgetReserve = 10
+)
+
+// Retrier is an http.RoundTripper retrying the idempotent requests which failed with a transport error or a 429, 502,
+// 503 or 504 status, with an exponential backoff and full jitter. Requests with a body are retried only if it can be
+// read again. The endpoint of the request set by the UpstreamTransport may change its number of attempts. A request is
+// not retried when its deadline would expire before another attempt as long as the last one
+// completes, nor beyond the retry budget.
+type Retrier struct {
+	baseURL     string
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	// budget caps the retries, nil for no cap.
+	budget *retryBudget
+	next   http.RoundTripper
+	rec    recorder
+	rand   func() float64
+}
+
+// NewRetrier creates the Retrier of cfg for the upstream at baseURL, delegating to next.
+func NewRetrier(baseURL string, cfg *config.Retry, next http.RoundTripper, rec recorder) *Retrier {
+	r := &Retrier{
+		baseURL:     baseURL,
+		maxAttempts: max(cfg.MaxAttempts, 1),
+		backoff:     cfg.Backoff,
+		maxBackoff:  cfg.MaxBackoff,
+		next:        next,
+		rec:         rec,
+		rand:        rand.Float64, //nolint:gosec // jitter does not need a secure source
+	}
+
+	if r.backoff <= 0 {
+		r.backoff = defaultRetryBackoff
+	}
+
+	if r.maxBackoff <= 0 {
+		r.maxBackoff = defaultRetryMaxBackoff
+	}
+
+	if cfg.BudgetPercent > 0 {
+		r.budget = &retryBudget{ratio: cfg.BudgetPercent / 100, tokens: retryBudgetReserve}
+	}
+
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Retrier) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return r.next.RoundTrip(req) //nolint:wrapcheck // the retrier is transparent
+	}
+
+	if r.budget != nil {
+		r.budget.deposit()
+	}
+
+	maxAttempts := r.maxAttempts
+	if e := endpointFromContext(req.Context()); e != nil && e.MaxAttempts > 0 {
+		maxAttempts = e.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+
+		resp, err := r.next.RoundTrip(req)
+		if attempt >= maxAttempts || !r.shouldRetry(req.Context(), resp, err) {
+			return resp, err //nolint:wrapcheck // the retrier is transparent
+		}
+
+		delay := r.delay(attempt)
+
+		if reason := r.skip(req.Context(), delay+time.Since(start)); reason != "" {
+			r.rec.Inc(RetrySkippedMetric, metrics.Labels{"base_url": r.baseURL, "reason": reason})
+			return resp, err //nolint:wrapcheck // the retrier is transparent
+		}
+
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+
+		if !wait(req.Context(), delay) {
+			return nil, req.Context().Err() //nolint:wrapcheck // the retrier is transparent
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr //nolint:wrapcheck // the retrier is transparent
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		r.rec.Inc(RetryMetric, metrics.Labels{"base_url": r.baseURL})
+	}
+}
+
+// retryable reports whether req may be sent again: it is idempotent and its body, if any, can be read again.
+func retryable(req *http.Request) bool {
+	idempotent := []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete}
+
+	return slices.Contains(idempotent, req.Method) && replayable(req)
+}
+
+func (r *Retrier) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// The request was abandoned, or rejected without reaching the upstream.
+		return ctx.Err() == nil && !errors.Is(err, ErrCircuitOpen) && !errors.Is(err, ErrRateLimited)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// skip returns why the request of ctx is not retried, if the retry would take next, or an empty string. The retries
+// skipped for their deadline do not spend the budget.
+func (r *Retrier) skip(ctx context.Context, next time.Duration) string {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < next {
+		return retrySkippedDeadline
+	}
+
+	if r.budget != nil && !r.budget.withdraw() {
+		return retrySkippedBudget
+	}
+
+	return ""
+}
+
+// delay returns the backoff of attempt, with its jitter.
+func (r *Retrier) delay(attempt int) time.Duration {
+	d := min(r.backoff<<(attempt-1), r.maxBackoff)
+
+	return time.Duration(r.rand() * float64(d))
+}
+
+// wait sleeps for d, reporting whether ctx is still running.
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryBudget caps the retries at a ratio of the requests: each request deposits ratio of a retry, up to
+// retryBudgetReserve, and each retry withdraws one.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+b.ratio, retryBudgetReserve)
+}
+
+// withdraw reports whether a retry is within the budget, spending it.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
@@ -0,0 +1,194 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestRetrier(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		status int
+		calls  int32
+	}
+
+	tests := map[string]struct {
+		method string
+		body   string
+		// failures is the number of requests answered with status before the upstream recovers.
+		failures int32
+		status   int
+		want     want
+	}{
+		"success is not retried": {
+			method: http.MethodGet,
+			want:   want{status: http.StatusOK, calls: 1},
+		},
+		"unavailable upstream is retried": {
+			method:   http.MethodGet,
+			failures: 2,
+			status:   http.StatusServiceUnavailable,
+			want:     want{status: http.StatusOK, calls: 3},
+		},
+		"attempts are bounded": {
+			method:   http.MethodGet,
+			failures: 5,
+			status:   http.StatusBadGateway,
+			want:     want{status: http.StatusBadGateway, calls: 3},
+		},
+		"client errors are not retried": {
+			method:   http.MethodGet,
+			failures: 1,
+			status:   http.StatusNotFound,
+			want:     want{status: http.StatusNotFound, calls: 1},
+		},
+		"put body is sent again": {
+			method:   http.MethodPut,
+			body:     "photo",
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			want:     want{status: http.StatusOK, calls: 2},
+		},
+		"post is not retried": {
+			method:   http.MethodPost,
+			body:     "photo",
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			want:     want{status: http.StatusServiceUnavailable, calls: 1},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				buf := new(strings.Builder)
+				_, _ = io.Copy(buf, r.Body)
+
+				if buf.String() != tt.body {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			rec := &countRecorder{counts: map[string]int{}}
+			r := client.NewRetrier(srv.URL, &config.Retry{MaxAttempts: 3, Backoff: time.Millisecond}, http.DefaultTransport, rec)
+
+			var body io.Reader = http.NoBody
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+
+			req, _ := http.NewRequest(tt.method, srv.URL, body) //nolint:noctx // the test does not cancel
+
+			resp, err := r.RoundTrip(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp.Body.Close()
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+			assert.Equal(t, tt.want.calls, calls.Load())
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+
+			assert.Equal(t, int(tt.want.calls-1), rec.counts[client.RetryMetric+" "])
+		})
+	}
+}
+
+func TestRetrier_Budget(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	rec := &countRecorder{counts: map[string]int{}}
+	r := client.NewRetrier(srv.URL, &config.Retry{MaxAttempts: 3, Backoff: time.Millisecond, BudgetPercent: 10}, http.DefaultTransport, rec)
+
+	const requests = 30
+
+	for i := 0; i < requests; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, http.NoBody) //nolint:noctx // the test does not cancel
+
+		resp, err := r.RoundTrip(req)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		resp.Body.Close()
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	// The reserve of 10 retries, and one more per 10 requests once it is spent.
+	retries := rec.counts[client.RetryMetric+" "]
+	assert.InDelta(t, 12, retries, 1)
+	assert.Equal(t, int32(requests+retries), calls.Load())
+	assert.Positive(t, rec.counts[client.RetrySkippedMetric+" "])
+}
+
+func TestRetrier_Deadline(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		time.Sleep(60 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	rec := &countRecorder{counts: map[string]int{}}
+	r := client.NewRetrier(srv.URL, &config.Retry{MaxAttempts: 3, Backoff: time.Millisecond}, http.DefaultTransport, rec)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, http.NoBody)
+
+	resp, err := r.RoundTrip(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp.Body.Close()
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, 1, rec.counts[client.RetrySkippedMetric+" "])
+}
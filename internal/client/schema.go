@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/jsonschema"
+)
+
+// ErrSchemaViolation is matched by the *SchemaViolationError of a response violating the schema of its path.
+var ErrSchemaViolation = errors.New("upstream response violates its schema")
+
+// SchemaViolationError is returned when a successful JSON response violates the schema registered for its path with
+// WithResponseSchema, so that an upstream changing its payloads fails at the client rather than as zero fields of the
+// decoded values.
+type SchemaViolationError struct {
+	Method     string
+	URL        string
+	Violations []jsonschema.Violation
+}
+
+func (e *SchemaViolationError) Error() string {
+	v := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		v = append(v, violation.String())
+	}
+
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, ErrSchemaViolation, strings.Join(v, ", "))
+}
+
+// Is matches ErrSchemaViolation.
+func (e *SchemaViolationError) Is(target error) bool {
+	return target == ErrSchemaViolation
+}
+
+// responseSchema is the schema of the responses of the paths matching pattern.
+type responseSchema struct {
+	pattern string
+	schema  *jsonschema.Schema
+}
+
+// WithResponseSchema validates the successful JSON responses of the requests whose path matches pattern against s,
+// failing them with a *SchemaViolationError. pattern has the syntax of path.Match, e.g. /photos/*; a path matching
+// several patterns is validated against the schema of the first one registered.
+func WithResponseSchema(pattern string, s *jsonschema.Schema) Option {
+	return func(c *Client) {
+		c.schemas = append(c.schemas, responseSchema{pattern: pattern, schema: s})
+	}
+}
+
+// schemaOf returns the schema of the responses of req, or nil if there is none.
+func (c *Client) schemaOf(req *http.Request) *jsonschema.Schema {
+	for _, rs := range c.schemas {
+		if ok, _ := path.Match(rs.pattern, req.URL.Path); ok {
+			return rs.schema
+		}
+	}
+
+	return nil
+}
+
+// validateSchema validates the JSON response of req against the schema of its path, if any. The body is read and
+// replaced, so the caller decodes it as usual.
+func (c *Client) validateSchema(req *http.Request, resp *http.Response) error {
+	s := c.schemaOf(req)
+	if s == nil || resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices ||
+		resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc any
+	if err = dec.Decode(&doc); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	if violations := s.Validate(doc); len(violations) > 0 {
+		return &SchemaViolationError{Method: req.Method, URL: req.URL.Redacted(), Violations: violations}
+	}
+
+	return nil
+}
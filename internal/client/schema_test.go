@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/jsonschema"
+)
+
+func TestWithResponseSchema(t *testing.T) {
+	t.Parallel()
+
+	s, err := jsonschema.LoadSchema(fstest.MapFS{"item.schema.json": {Data: []byte(`{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"id": {"type": "integer"}}
+	}`)}}, "item.schema.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/items/text":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("not json"))
+		case "/items/error":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(r.URL.Query().Get("body")))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	type want struct {
+		body       string
+		violations []jsonschema.Violation
+		err        bool
+	}
+
+	tests := map[string]struct {
+		path string
+		opts []client.RequestOption
+		want want
+	}{
+		"valid": {
+			path: `/items/1?body={"id":1}`,
+			want: want{body: `{"id":1}`},
+		},
+		"violation": {
+			path: `/items/1?body={"id":"1","name":"a"}`,
+			want: want{violations: []jsonschema.Violation{{Path: "/id", Message: "expected integer, got string"}}},
+		},
+		"missing property": {
+			path: `/items/1?body={}`,
+			want: want{violations: []jsonschema.Violation{{Path: "", Message: "missing required property id"}}},
+		},
+		"invalid json": {
+			path: `/items/1?body={`,
+			want: want{err: true},
+		},
+		"unregistered path": {
+			path: `/other?body="text"`,
+			want: want{body: `"text"`},
+		},
+		"not json": {
+			path: "/items/text",
+			want: want{body: "not json"},
+		},
+		"error status": {
+			path: "/items/error",
+			opts: []client.RequestOption{client.WithAnyStatus()},
+			want: want{body: `{"error": "not found"}`},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := client.NewClient(srv.Client(), client.WithResponseSchema("/items/*", s))
+
+			resp, err := c.Get(context.Background(), srv.URL+tt.path, tt.opts...)
+
+			if tt.want.violations != nil {
+				var serr *client.SchemaViolationError
+				if !assert.ErrorAs(t, err, &serr) {
+					return
+				}
+
+				assert.True(t, errors.Is(err, client.ErrSchemaViolation))
+				assert.Equal(t, tt.want.violations, serr.Violations)
+				assert.Equal(t, http.MethodGet, serr.Method)
+
+				return
+			}
+
+			if tt.want.err {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			b, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.body, string(b))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// ErrBlockedDestination is returned by an SSRFGuard for requests to a scheme, port or address which is not allowed.
+var ErrBlockedDestination = errors.New("blocked destination")
+
+// Default ports of the http and https schemes, allowed by an SSRFGuard without configured ports.
+const (
+	httpPort  = 80
+	httpsPort = 443
+)
+
+// SSRFGuard is an http.RoundTripper protecting against server side request forgery, for requests to URLs influenced
+// by API consumers or stored data. It only allows the configured schemes and ports, and refuses to connect to
+// loopback, private, link-local (including cloud metadata endpoints), shared, multicast and unspecified addresses.
+// Addresses are checked once resolved, right before dialing, so DNS names pointing to internal addresses are blocked too.
+type SSRFGuard struct {
+	next     http.RoundTripper
+	schemes  []string
+	ports    []int
+	allowed  []netip.Prefix
+	resolver hostResolver
+}
+
+// NewSSRFGuard creates an SSRFGuard sending requests with a clone of transport. The clone does not use the proxy of
+// the environment, which would connect to destinations on behalf of the guard. Host names are resolved by resolver,
+// the one of transport, or the system resolver if nil.
+func NewSSRFGuard(cfg *config.SSRFGuard, transport *http.Transport, resolver *Resolver) (*SSRFGuard, error) {
+	g := &SSRFGuard{schemes: cfg.AllowedSchemes, ports: cfg.AllowedPorts, resolver: net.DefaultResolver}
+	if resolver != nil {
+		g.resolver = resolver
+	}
+
+	if len(g.schemes) == 0 {
+		g.schemes = []string{"http", "https"}
+	}
+
+	if len(g.ports) == 0 {
+		g.ports = []int{httpPort, httpsPort}
+	}
+
+	for _, cidr := range cfg.AllowedCIDRs {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR %q: %w", cidr, err)
+		}
+
+		g.allowed = append(g.allowed, p)
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	t := transport.Clone()
+	t.Proxy = nil
+	t.DialContext = g.dialer(dial)
+	g.next = t
+
+	return g, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (g *SSRFGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := g.checkURL(req); err != nil {
+		return nil, err
+	}
+
+	return g.next.RoundTrip(req) //nolint:wrapcheck // the guard is transparent to callers
+}
+
+func (g *SSRFGuard) checkURL(req *http.Request) error {
+	scheme := strings.ToLower(req.URL.Scheme)
+	if !slices.Contains(g.schemes, scheme) {
+		return fmt.Errorf("scheme %q is not allowed: %w", scheme, ErrBlockedDestination)
+	}
+
+	port, err := urlPort(scheme, req.URL.Port())
+	if err != nil || !slices.Contains(g.ports, port) {
+		return fmt.Errorf("port of %s is not allowed: %w", req.URL.Host, ErrBlockedDestination)
+	}
+
+	return nil
+}
+
+func urlPort(scheme, port string) (int, error) {
+	if port != "" {
+		return strconv.Atoi(port) //nolint:wrapcheck // reported as a blocked port
+	}
+
+	if scheme == "https" {
+		return httpsPort, nil
+	}
+
+	return httpPort, nil
+}
+
+// dialer resolves the host itself and dials the checked addresses, so the name cannot resolve to another address
+// between the check and the connection.
+func (g *SSRFGuard) dialer(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+
+		ips, err := g.resolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+
+		// A name resolving to any internal address is refused, rather than dialing its other addresses.
+		for _, ip := range ips {
+			if !g.allowedAddr(ip) {
+				return nil, fmt.Errorf("%s resolves to %s: %w", host, ip, ErrBlockedDestination)
+			}
+		}
+
+		var dialErr error
+
+		for _, ip := range ips {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+			if err == nil {
+				return conn, nil
+			}
+
+			dialErr = err
+		}
+
+		return nil, dialErr
+	}
+}
+
+func (g *SSRFGuard) allowedAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	for _, p := range g.allowed {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return !internalAddr(ip)
+}
+
+// internalAddr reports whether ip is not a public unicast address.
+func internalAddr(ip netip.Addr) bool {
+	// Shared address space of carrier-grade NAT, also used by some cloud metadata endpoints, and "this network".
+	reserved := []netip.Prefix{netip.MustParsePrefix("100.64.0.0/10"), netip.MustParsePrefix("0.0.0.0/8")}
+
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() ||
+		slices.ContainsFunc(reserved, func(p netip.Prefix) bool { return p.Contains(ip) })
+}
@@ -0,0 +1,102 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestSSRFGuard(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	port, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+
+	type args struct {
+		cfg config.SSRFGuard
+		url string
+	}
+
+	type want struct {
+		err     error
+		initErr string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"loopback blocked": {
+			args: args{cfg: config.SSRFGuard{AllowedPorts: []int{port}}, url: server.URL},
+			want: want{err: client.ErrBlockedDestination},
+		},
+		"name resolving to loopback blocked": {
+			args: args{cfg: config.SSRFGuard{AllowedPorts: []int{port}}, url: "http://localhost:" + u.Port()},
+			want: want{err: client.ErrBlockedDestination},
+		},
+		"metadata endpoint blocked": {
+			args: args{url: "http://169.254.169.254/latest/meta-data/"},
+			want: want{err: client.ErrBlockedDestination},
+		},
+		"private address blocked": {
+			args: args{url: "https://10.0.0.1/"},
+			want: want{err: client.ErrBlockedDestination},
+		},
+		"port not allowed": {
+			args: args{cfg: config.SSRFGuard{AllowedCIDRs: []string{"127.0.0.0/8"}}, url: server.URL},
+			want: want{err: client.ErrBlockedDestination},
+		},
+		"scheme not allowed": {
+			args: args{url: "ftp://example.com/file"},
+			want: want{err: client.ErrBlockedDestination},
+		},
+		"allowed internal network": {
+			args: args{cfg: config.SSRFGuard{AllowedPorts: []int{port}, AllowedCIDRs: []string{"127.0.0.0/8"}}, url: server.URL},
+		},
+		"invalid allowed cidr": {
+			args: args{cfg: config.SSRFGuard{AllowedCIDRs: []string{"not-a-cidr"}}},
+			want: want{initErr: `invalid allowed CIDR "not-a-cidr"`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			guard, err := client.NewSSRFGuard(&tt.args.cfg, &http.Transport{}, nil)
+			if tt.want.initErr != "" {
+				assert.ErrorContains(t, err, tt.want.initErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			resp, err := client.NewClient(&http.Client{Transport: guard}).Get(context.Background(), tt.args.url)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Stream iterates over the items of a JSON array response as they are read, rather than decoding the whole array in
+// memory, for endpoints returning thousands of items.
+//
+//	s := client.GetStream[Photo](ctx, c, url)
+//	defer s.Close()
+//	for s.Next() {
+//		photo := s.Item()
+//	}
+//	if err := s.Err(); err != nil {
+//	}
+type Stream[T any] struct {
+	body  io.ReadCloser
+	dec   *json.Decoder
+	codec JSONCodec
+	item  T
+	err   error
+}
+
+// GetStream performs a GET request and returns a Stream over the items of the JSON array of the response, decoded
+// with the JSONCodec set with WithCodec, if any. A 204 No Content or null response is an empty stream.
+func GetStream[T any](ctx context.Context, c getter, url string, opts ...RequestOption) *Stream[T] {
+	s := &Stream[T]{}
+
+	codec, ok := codecOf(opts).(JSONCodec)
+	if !ok {
+		s.err = fmt.Errorf("failed to stream %s: only JSON arrays are streamed", url)
+		return s
+	}
+
+	s.codec = codec
+
+	resp, err := c.Get(ctx, url, append(opts, WithCodec(codec))...)
+	if err != nil {
+		s.err = err
+		return s
+	}
+
+	if resp.StatusCode == http.StatusNoContent {
+		drainAndClose(resp.Body)
+		return s
+	}
+
+	s.body = resp.Body
+	s.dec = json.NewDecoder(resp.Body)
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		s.fail(fmt.Errorf("failed to decode response body: invalid json: %w", err))
+		return s
+	}
+
+	if tok == nil {
+		s.Close()
+		return s
+	}
+
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		s.fail(fmt.Errorf("failed to decode response body: expected a JSON array, got %v", tok))
+	}
+
+	return s
+}
+
+// Next decodes the next item. It returns false at the end of the array or when an error occurred, closing the body.
+func (s *Stream[T]) Next() bool {
+	if s.err != nil || s.dec == nil {
+		return false
+	}
+
+	if !s.dec.More() {
+		if _, err := s.dec.Token(); err != nil {
+			s.fail(fmt.Errorf("failed to decode response body: invalid json: %w", err))
+			return false
+		}
+
+		s.Close()
+
+		return false
+	}
+
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		s.fail(fmt.Errorf("failed to decode response body: invalid json: %w", err))
+		return false
+	}
+
+	var item T
+	if err := s.codec.Decode(bytes.NewReader(raw), &item); err != nil {
+		s.fail(fmt.Errorf("failed to decode response body: %w", err))
+		return false
+	}
+
+	s.item = item
+
+	return true
+}
+
+// Item returns the current item.
+func (s *Stream[T]) Item() T {
+	return s.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (s *Stream[T]) Err() error {
+	return s.err
+}
+
+// Close closes the body of the response, for callers stopping before the end of the array, draining what is left of
+// it so the connection is reused. It is safe to call more than once.
+func (s *Stream[T]) Close() {
+	if s.body != nil {
+		drainAndClose(s.body)
+		s.body, s.dec = nil, nil
+	}
+}
+
+func (s *Stream[T]) fail(err error) {
+	s.err = err
+	s.Close()
+}
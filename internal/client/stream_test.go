@@ -0,0 +1,136 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+func TestGetStream(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		opts []client.RequestOption
+	}
+
+	type fields struct {
+		status int
+		body   string
+	}
+
+	type want struct {
+		items []item
+		err   string
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"array": {
+			fields: fields{status: http.StatusOK, body: `[{"id":1,"name":"a"}, {"id":2,"name":"b"}]`},
+			want:   want{items: []item{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}},
+		},
+		"empty array": {
+			fields: fields{status: http.StatusOK, body: `[]`},
+		},
+		"null": {
+			fields: fields{status: http.StatusOK, body: `null`},
+		},
+		"204": {
+			fields: fields{status: http.StatusNoContent},
+		},
+		"codec": {
+			args:   args{opts: []client.RequestOption{client.WithCodec(client.JSONCodec{DisallowUnknownFields: true})}},
+			fields: fields{status: http.StatusOK, body: `[{"id":1},{"id":2,"nmae":"b"}]`},
+			want:   want{items: []item{{ID: 1}}, err: `failed to decode response body: invalid json: json: unknown field "nmae"`},
+		},
+		"not a JSON codec": {
+			args:   args{opts: []client.RequestOption{client.WithCodec(client.XMLCodec{})}},
+			fields: fields{status: http.StatusOK, body: `[]`},
+			want:   want{err: "only JSON arrays are streamed"},
+		},
+		"not an array": {
+			fields: fields{status: http.StatusOK, body: `{"id":1}`},
+			want:   want{err: "failed to decode response body: expected a JSON array, got {"},
+		},
+		"truncated": {
+			fields: fields{status: http.StatusOK, body: `[{"id":1},{"id":`},
+			want:   want{items: []item{{ID: 1}}, err: "failed to decode response body: invalid json: unexpected EOF"},
+		},
+		"error status": {
+			fields: fields{status: http.StatusBadGateway},
+			want:   want{err: "received non-OK HTTP status: 502"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.fields.status)
+				io.WriteString(w, tt.fields.body)
+			}))
+			defer server.Close()
+
+			s := client.GetStream[item](context.Background(), client.NewClient(server.Client()), server.URL, tt.args.opts...)
+			defer s.Close()
+
+			var got []item
+			for s.Next() {
+				got = append(got, s.Item())
+			}
+
+			if tt.want.err != "" {
+				assert.ErrorContains(t, s.Err(), tt.want.err)
+			} else {
+				assert.NoError(t, s.Err())
+			}
+
+			assert.Equal(t, tt.want.items, got)
+		})
+	}
+}
+
+func TestGetStream_Close(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		enc := json.NewEncoder(w)
+
+		io.WriteString(w, "[")
+
+		for i := 0; i < 1000; i++ {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+
+			enc.Encode(item{ID: i})
+		}
+
+		io.WriteString(w, "]")
+	}))
+	defer server.Close()
+
+	s := client.GetStream[item](context.Background(), client.NewClient(server.Client()), server.URL)
+
+	assert.True(t, s.Next())
+	assert.Equal(t, item{ID: 0}, s.Item())
+
+	s.Close()
+	s.Close()
+
+	assert.False(t, s.Next())
+	assert.NoError(t, s.Err())
+}
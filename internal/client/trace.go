@@ -0,0 +1,185 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/passthrough"
+)
+
+// UpstreamLatencyKey is the key of the time spent on upstream requests in the canonical event of a request.
+const UpstreamLatencyKey = "upstream_latency"
+
+const (
+	// DNSPhaseMetric observes the DNS lookups of the requests, in seconds, by upstream.
+	DNSPhaseMetric = "http_client_request_dns_seconds"
+	// ConnectPhaseMetric observes the connections dialed for the requests, in seconds, by upstream.
+	ConnectPhaseMetric = "http_client_request_connect_seconds"
+	// TLSPhaseMetric observes the TLS handshakes of the requests, in seconds, by upstream.
+	TLSPhaseMetric = "http_client_request_tls_seconds"
+	// TTFBPhaseMetric observes the time to the first response byte of the requests, in seconds, by upstream.
+	TTFBPhaseMetric = "http_client_request_ttfb_seconds"
+	// TotalPhaseMetric observes the time until the response headers of the requests were received or they failed, in
+	// seconds, by upstream.
+	TotalPhaseMetric = "http_client_request_seconds"
+)
+
+// WithTimingMetrics records the latency breakdown of the requests in histograms of rec, one per phase, labelled with
+// upstream. Phases that did not happen are not observed.
+func WithTimingMetrics(rec recorder, upstream string) Option {
+	return func(c *Client) {
+		c.timingRec, c.upstream = rec, upstream
+	}
+}
+
+// Timing is the latency breakdown of an outbound request collected with httptrace.
+// Phases that did not happen, e.g. DNS and connect on a reused connection, are zero.
+type Timing struct {
+	Method     string
+	Host       string
+	Status     int
+	ConnReused bool
+	DNS        time.Duration
+	Connect    time.Duration
+	TLS        time.Duration
+	// TTFB is the time from writing the request to receiving the first response byte.
+	TTFB  time.Duration
+	Total time.Duration
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (t *Timing) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", t.Method)
+	enc.AddString("host", t.Host)
+	enc.AddInt("status", t.Status)
+	enc.AddBool("conn_reused", t.ConnReused)
+	enc.AddDuration("dns", t.DNS)
+	enc.AddDuration("connect", t.Connect)
+	enc.AddDuration("tls", t.TLS)
+	enc.AddDuration("ttfb", t.TTFB)
+	enc.AddDuration("total", t.Total)
+
+	return nil
+}
+
+// observe records the phases of t which happened in rec.
+func (t *Timing) observe(rec recorder, upstream string) {
+	labels := metrics.Labels{"upstream": upstream}
+
+	for name, d := range map[string]time.Duration{
+		DNSPhaseMetric:     t.DNS,
+		ConnectPhaseMetric: t.Connect,
+		TLSPhaseMetric:     t.TLS,
+		TTFBPhaseMetric:    t.TTFB,
+	} {
+		if d > 0 {
+			rec.Observe(name, d.Seconds(), labels)
+		}
+	}
+
+	rec.Observe(TotalPhaseMetric, t.Total.Seconds(), labels)
+}
+
+// tracer records the timestamps of the phases of a request. Callbacks may be invoked from different goroutines.
+type tracer struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	timing       Timing
+}
+
+func newTracer(req *http.Request) *tracer {
+	return &tracer{
+		start:  time.Now(),
+		timing: Timing{Method: req.Method, Host: req.URL.Host},
+	}
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.since(&t.dnsStart, &t.timing.DNS) },
+		ConnectStart:         func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.since(&t.connectStart, &t.timing.Connect) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.since(&t.tlsStart, &t.timing.TLS) },
+		GotConn:              t.gotConn,
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.since(&t.wroteRequest, &t.timing.TTFB) },
+	}
+}
+
+func (t *tracer) gotConn(info httptrace.GotConnInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timing.ConnReused = info.Reused
+}
+
+func (t *tracer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*at = time.Now()
+}
+
+func (t *tracer) since(start *time.Time, d *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*d = time.Since(*start)
+}
+
+// finish completes the timing once the response headers were received or the request failed.
+func (t *tracer) finish(resp *http.Response) *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timing.Total = time.Since(t.start)
+	if resp != nil {
+		t.timing.Status = resp.StatusCode
+	}
+
+	timing := t.timing
+
+	return &timing
+}
+
+// do performs the request collecting its latency breakdown, which is appended to the request's canonical event and
+// recorded in the timing metrics if enabled, and records the response headers for the header pass-through policy.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	t := newTracer(req)
+
+	c.addCookies(req)
+
+	resp, err := c.httpClient.Do(req.WithContext(httptrace.WithClientTrace(ctx, t.clientTrace())))
+
+	timing := t.finish(resp)
+	logger.EventFromContext(ctx).Append("upstream", timing)
+	logger.EventFromContext(ctx).AddDuration(UpstreamLatencyKey, timing.Total)
+
+	if c.timingRec != nil {
+		timing.observe(c.timingRec, c.upstream)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+
+	c.storeCookies(req, resp)
+	passthrough.CollectorFromContext(ctx).Record(resp.Header)
+
+	return resp, nil
+}
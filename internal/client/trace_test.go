@@ -0,0 +1,90 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+func TestClient_TimingOnEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := logger.NewEvent()
+	ctx := logger.ContextWithEvent(context.Background(), event)
+	c := client.NewClient(server.Client())
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(ctx, server.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range event.Fields() {
+		f.AddTo(enc)
+	}
+
+	calls, ok := enc.Fields["upstream"].([]interface{})
+	assert.True(t, ok)
+	assert.Len(t, calls, 2)
+
+	first, _ := calls[0].(map[string]interface{})
+	second, _ := calls[1].(map[string]interface{})
+
+	assert.Equal(t, http.MethodGet, first["method"])
+	assert.Equal(t, http.StatusOK, first["status"])
+	assert.Equal(t, false, first["conn_reused"])
+	assert.Positive(t, first["connect"])
+	assert.Positive(t, first["total"])
+	assert.Equal(t, true, second["conn_reused"])
+}
+
+type observeRecorder struct {
+	mu       sync.Mutex
+	observed map[string]int
+}
+
+func (r *observeRecorder) Inc(string, metrics.Labels) {}
+
+func (r *observeRecorder) Observe(name string, _ float64, labels metrics.Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observed[name+" "+labels["upstream"]]++
+}
+
+func TestClient_TimingMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	rec := &observeRecorder{observed: map[string]int{}}
+	c := client.NewClient(server.Client(), client.WithTimingMetrics(rec, "photos"))
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(context.Background(), server.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The second request reuses the connection, so only the first one is observed dialing. The server is plain
+	// HTTP, so no TLS handshake is observed.
+	assert.Equal(t, map[string]int{
+		client.ConnectPhaseMetric + " photos": 1,
+		client.TTFBPhaseMetric + " photos":    2,
+		client.TotalPhaseMetric + " photos":   2,
+	}, rec.observed)
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// IP families accepted by config.Transport.IPFamily.
+const (
+	IPFamilyIPv4       = "ipv4"
+	IPFamilyIPv6       = "ipv6"
+	IPFamilyPreferIPv4 = "prefer_ipv4"
+	IPFamilyPreferIPv6 = "prefer_ipv6"
+)
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// defaultFallbackDelay is the delay of the net package before racing the other IP family.
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// NewTransport creates an http.Transport dialing outbound connections as configured, or as overridden for the
+// requests of an upstream by a DialTransport, through the proxy of the environment or the one set by a
+// ProxyTransport. Host names are resolved by resolver, or the system resolver if nil.
+func NewTransport(cfg *config.Transport, resolver *Resolver) (*http.Transport, error) {
+	d := &dialers{base: *cfg, resolver: resolver, upstreams: map[*config.Dial]dialFunc{}}
+
+	dial, err := d.build(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	d.dial = dial
+
+	t := &http.Transport{}
+	if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+		t = dt.Clone()
+	}
+
+	t.DialContext = d.dialContext
+	t.Proxy = contextProxy(t.Proxy)
+
+	return t, nil
+}
+
+// dialers dials the connections of a transport with its settings, or those of the upstream of the request. As the
+// connections are pooled by host, the upstreams dialed differently must not share their host, which the upstream
+// registry checks at startup.
+type dialers struct {
+	base     config.Transport
+	resolver *Resolver
+	dial     dialFunc
+
+	mu        sync.Mutex
+	upstreams map[*config.Dial]dialFunc
+}
+
+func (d *dialers) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	o, ok := ctx.Value(dialKey{}).(*config.Dial)
+	if !ok {
+		return d.dial(ctx, network, addr)
+	}
+
+	dial, err := d.upstream(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return dial(ctx, network, addr)
+}
+
+// upstream returns the dialer of the settings o of an upstream, built on its first connection.
+func (d *dialers) upstream(o *config.Dial) (dialFunc, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if dial, ok := d.upstreams[o]; ok {
+		return dial, nil
+	}
+
+	cfg := mergeDial(d.base, o)
+
+	dial, err := d.build(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	d.upstreams[o] = dial
+
+	return dial, nil
+}
+
+// build returns the dialer of cfg, resolving host names with the resolver of d.
+func (d *dialers) build(cfg *config.Transport) (dialFunc, error) {
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dial := dialer.DialContext
+	if d.resolver != nil {
+		dial = d.resolver.dialer(dial, cfg.DialTimeout, cfg.FallbackDelay)
+	}
+
+	return familyDialer(cfg.IPFamily, cfg.FallbackDelay, dial)
+}
+
+// mergeDial returns cfg overridden by the settings of o which are set. A source address or interface replaces both.
+func mergeDial(cfg config.Transport, o *config.Dial) config.Transport {
+	if o.IPFamily != "" {
+		cfg.IPFamily = o.IPFamily
+	}
+
+	if o.SourceAddress != "" || o.SourceInterface != "" {
+		cfg.SourceAddress, cfg.SourceInterface = o.SourceAddress, o.SourceInterface
+	}
+
+	if o.DialTimeout != 0 {
+		cfg.DialTimeout = o.DialTimeout
+	}
+
+	if o.FallbackDelay != 0 {
+		cfg.FallbackDelay = o.FallbackDelay
+	}
+
+	return cfg
+}
+
+func newDialer(cfg *config.Transport) (*net.Dialer, error) {
+	if cfg.SourceAddress != "" && cfg.SourceInterface != "" {
+		return nil, errors.New("source address and source interface are mutually exclusive")
+	}
+
+	dialer := &net.Dialer{
+		Timeout:       cfg.DialTimeout,
+		FallbackDelay: cfg.FallbackDelay,
+		KeepAlive:     cfg.KeepAlive,
+	}
+
+	ip, err := sourceIP(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	return dialer, nil
+}
+
+func sourceIP(cfg *config.Transport) (net.IP, error) {
+	if cfg.SourceAddress != "" {
+		ip := net.ParseIP(cfg.SourceAddress)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source address %q", cfg.SourceAddress)
+		}
+
+		return ip, nil
+	}
+
+	if cfg.SourceInterface != "" {
+		return interfaceIP(cfg.SourceInterface, cfg.IPFamily)
+	}
+
+	return nil, nil
+}
+
+// interfaceIP returns the first address of the interface matching the wanted family, IPv4 unless IPv6 is selected.
+func interfaceIP(name, family string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses of interface %s: %w", name, err)
+	}
+
+	wantV6 := family == IPFamilyIPv6 || family == IPFamilyPreferIPv6
+
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if ok && (ipNet.IP.To4() == nil) == wantV6 {
+			return ipNet.IP, nil
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s has no address for the configured IP family", name)
+}
+
+// familyDialer wraps dial so that connections are made over the configured IP family.
+func familyDialer(family string, fallbackDelay time.Duration, dial dialFunc) (dialFunc, error) {
+	switch family {
+	case "":
+		return dial, nil
+	case IPFamilyIPv4:
+		return fixedNetworkDialer("tcp4", dial), nil
+	case IPFamilyIPv6:
+		return fixedNetworkDialer("tcp6", dial), nil
+	case IPFamilyPreferIPv4:
+		return preferredNetworkDialer("tcp4", "tcp6", fallbackDelay, dial), nil
+	case IPFamilyPreferIPv6:
+		return preferredNetworkDialer("tcp6", "tcp4", fallbackDelay, dial), nil
+	default:
+		return nil, fmt.Errorf("unsupported IP family %q", family)
+	}
+}
+
+func fixedNetworkDialer(network string, dial dialFunc) dialFunc {
+	return func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dial(ctx, network, addr)
+	}
+}
+
+// preferredNetworkDialer dials the preferred network, and races the fallback one once the preferred dial failed or
+// took longer than fallbackDelay (Happy Eyeballs). The first connection wins and the other dial is canceled. A
+// negative fallbackDelay only dials the fallback network once the preferred dial failed.
+func preferredNetworkDialer(preferred, fallback string, fallbackDelay time.Duration, dial dialFunc) dialFunc {
+	if fallbackDelay == 0 {
+		fallbackDelay = defaultFallbackDelay
+	}
+
+	return func(ctx context.Context, _, addr string) (net.Conn, error) {
+		if fallbackDelay < 0 {
+			conn, err := dial(ctx, preferred, addr)
+			if err == nil || ctx.Err() != nil {
+				return conn, err
+			}
+
+			return dial(ctx, fallback, addr)
+		}
+
+		return raceDial(ctx, preferred, fallback, fallbackDelay, addr, dial)
+	}
+}
+
+type dialResult struct {
+	conn    net.Conn
+	err     error
+	primary bool
+}
+
+func raceDial(ctx context.Context, preferred, fallback string, fallbackDelay time.Duration, addr string, dial dialFunc) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	start := func(network string, primary bool) {
+		go func() {
+			conn, err := dial(ctx, network, addr)
+			results <- dialResult{conn: conn, err: err, primary: primary}
+		}()
+	}
+
+	start(preferred, true)
+
+	timer := time.NewTimer(fallbackDelay)
+	defer timer.Stop()
+
+	pending, racing := 1, false
+
+	var primaryErr, fallbackErr error
+
+	for {
+		select {
+		case <-timer.C:
+			if !racing {
+				start(fallback, false)
+				pending, racing = pending+1, true
+			}
+		case r := <-results:
+			pending--
+
+			if r.err == nil {
+				// The connection of the losing dial, if it succeeds before being canceled, is closed.
+				if pending > 0 {
+					go func() {
+						if lost := <-results; lost.conn != nil {
+							lost.conn.Close()
+						}
+					}()
+				}
+
+				return r.conn, nil
+			}
+
+			if r.primary {
+				primaryErr = r.err
+			} else {
+				fallbackErr = r.err
+			}
+
+			if !racing && ctx.Err() == nil {
+				start(fallback, false)
+				pending, racing = pending+1, true
+			}
+
+			if pending == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+
+				return nil, fallbackErr
+			}
+		}
+	}
+}
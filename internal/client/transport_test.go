@@ -0,0 +1,146 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		cfg *config.Transport
+	}
+
+	type want struct {
+		err     string
+		dialErr bool
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"default":                    {args: args{cfg: &config.Transport{}}},
+		"ipv4 only":                  {args: args{cfg: &config.Transport{IPFamily: client.IPFamilyIPv4}}},
+		"ipv6 only to ipv4 server":   {args: args{cfg: &config.Transport{IPFamily: client.IPFamilyIPv6}}, want: want{dialErr: true}},
+		"prefer ipv6 falls back":     {args: args{cfg: &config.Transport{IPFamily: client.IPFamilyPreferIPv6}}},
+		"prefer ipv6 without race":   {args: args{cfg: &config.Transport{IPFamily: client.IPFamilyPreferIPv6, FallbackDelay: -1}}},
+		"prefer ipv4":                {args: args{cfg: &config.Transport{IPFamily: client.IPFamilyPreferIPv4}}},
+		"source address":             {args: args{cfg: &config.Transport{SourceAddress: "127.0.0.1"}}},
+		"source interface":           {args: args{cfg: &config.Transport{SourceInterface: "lo", IPFamily: client.IPFamilyIPv4}}},
+		"invalid source address":     {args: args{cfg: &config.Transport{SourceAddress: "not-an-ip"}}, want: want{err: `invalid source address "not-an-ip"`}},
+		"unknown interface":          {args: args{cfg: &config.Transport{SourceInterface: "does-not-exist0"}}, want: want{err: "failed to find interface does-not-exist0"}},
+		"address and interface":      {args: args{cfg: &config.Transport{SourceAddress: "127.0.0.1", SourceInterface: "lo"}}, want: want{err: "mutually exclusive"}},
+		"unsupported ip family":      {args: args{cfg: &config.Transport{IPFamily: "ipx"}}, want: want{err: `unsupported IP family "ipx"`}},
+		"ipv6 source with ipv4 only": {args: args{cfg: &config.Transport{SourceAddress: "::1", IPFamily: client.IPFamilyIPv4}}, want: want{dialErr: true}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			transport, err := client.NewTransport(tt.args.cfg, nil)
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			c := client.NewClient(&http.Client{Transport: transport})
+
+			resp, err := c.Get(context.Background(), server.URL)
+			if tt.want.dialErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestNewTransport_DialTimeout(t *testing.T) {
+	t.Parallel()
+
+	transport, err := client.NewTransport(&config.Transport{DialTimeout: time.Nanosecond, FallbackDelay: -1, KeepAlive: -1}, nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = transport.DialContext(context.Background(), "tcp", "10.255.255.1:80")
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDialTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	// The server only listens on IPv4, which the transport does not dial.
+	transport, err := client.NewTransport(&config.Transport{IPFamily: client.IPFamilyIPv6}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Requests sent without a DialTransport keep the settings of the transport.
+	_, err = client.NewClient(&http.Client{Transport: transport}).Get(context.Background(), server.URL)
+	assert.Error(t, err)
+
+	tests := map[string]struct {
+		dial config.Dial
+		err  string
+	}{
+		"ipv4":                   {dial: config.Dial{IPFamily: client.IPFamilyIPv4}},
+		"source address":         {dial: config.Dial{IPFamily: client.IPFamilyPreferIPv4, SourceAddress: "127.0.0.1"}},
+		"source interface":       {dial: config.Dial{IPFamily: client.IPFamilyIPv4, SourceInterface: "lo"}},
+		"invalid family":         {dial: config.Dial{IPFamily: "ipx"}, err: `unsupported IP family "ipx"`},
+		"invalid source address": {dial: config.Dial{SourceAddress: "not-an-ip"}, err: `invalid source address "not-an-ip"`},
+		"address and interface":  {dial: config.Dial{SourceAddress: "127.0.0.1", SourceInterface: "lo"}, err: "mutually exclusive"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rt, err := client.NewDialTransport(&tt.dial, transport)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp, err := client.NewClient(&http.Client{Transport: rt}).Get(context.Background(), server.URL)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Authentications of config.Upstream.Auth.
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+	AuthAPIKey = "api_key"
+	// AuthGCP and AuthAzure send the Bearer tokens of a WorkloadIdentity.
+	AuthGCP   = "gcp"
+	AuthAzure = "azure"
+)
+
+// defaultAPIKeyHeader is the header of API keys when none is configured.
+const defaultAPIKeyHeader = "X-Api-Key"
+
+var (
+	errNoBaseURL       = errors.New("upstream base url is required")
+	errNoCredential    = errors.New("upstream credential is required")
+	errBasicCredential = errors.New(`basic upstream credential must be "user:password"`)
+)
+
+// UpstreamTransport is an http.RoundTripper authenticating the requests to the base URL of an upstream and bounding
+// them with its timeout, or the one of their endpoint. A request answered 401 Unauthorized is sent once more if its
+// credential could be refreshed and its body can be read again. Requests to other URLs, such as images linked by the
+// upstream, are sent as they are.
+type UpstreamTransport struct {
+	baseURL string
+	// basePath is the path of baseURL, which the paths of the endpoints are relative to.
+	basePath string
+	// credentials provide the credential of the requests, nil for none.
+	credentials CredentialProvider
+	// authenticate adds a credential to a request.
+	authenticate func(req *http.Request, credential string) error
+	timeout      time.Duration
+	endpoints    []config.Endpoint
+	next         http.RoundTripper
+}
+
+// NewUpstreamTransport creates the UpstreamTransport of cfg, authenticating with the credential of credentials, which
+// may be nil when cfg has no authentication, and delegating to next.
+func NewUpstreamTransport(cfg *config.Upstream, credentials CredentialProvider, next http.RoundTripper) (*UpstreamTransport, error) {
+	if cfg.BaseURL == "" {
+		return nil, errNoBaseURL
+	}
+
+	if err := validateEndpoints(cfg.Endpoints); err != nil {
+		return nil, err
+	}
+
+	t := &UpstreamTransport{baseURL: strings.TrimSuffix(cfg.BaseURL, "/"), timeout: cfg.Timeout, endpoints: cfg.Endpoints, next: next}
+
+	if u, err := url.Parse(t.baseURL); err == nil {
+		t.basePath = u.Path
+	}
+
+	if cfg.Auth != "" && (credentials == nil || credentials == StaticCredential("")) {
+		return nil, fmt.Errorf("%w for %s auth", errNoCredential, cfg.Auth)
+	}
+
+	switch cfg.Auth {
+	case "":
+		return t, nil
+	case AuthBearer, AuthGCP, AuthAzure:
+		t.authenticate = func(req *http.Request, credential string) error {
+			req.Header.Set("Authorization", "Bearer "+credential)
+			return nil
+		}
+	case AuthBasic:
+		t.authenticate = func(req *http.Request, credential string) error {
+			user, password, ok := strings.Cut(credential, ":")
+			if !ok {
+				return errBasicCredential
+			}
+
+			req.SetBasicAuth(user, password)
+
+			return nil
+		}
+	case AuthAPIKey:
+		header := cfg.APIKeyHeader
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+
+		t.authenticate = func(req *http.Request, credential string) error {
+			req.Header.Set(header, credential)
+			return nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown upstream auth %q", cfg.Auth)
+	}
+
+	// A static credential is checked at once rather than by the first request.
+	if static, ok := credentials.(StaticCredential); ok {
+		if err := t.authenticate(&http.Request{Header: http.Header{}}, string(static)); err != nil {
+			return nil, err
+		}
+	}
+
+	t.credentials = credentials
+
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *UpstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.matches(req) {
+		return t.next.RoundTrip(req) //nolint:wrapcheck // the transport is transparent
+	}
+
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+
+	timeout := t.timeout
+	if e := endpointOf(t.endpoints, t.basePath, req); e != nil {
+		ctx = contextWithEndpoint(ctx, e)
+
+		if e.Timeout > 0 {
+			timeout = e.Timeout
+		}
+	}
+
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	resp, err := t.send(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout covers the read of the body.
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// matches reports whether req is sent to the base URL, and not to a host merely starting like it.
+func (t *UpstreamTransport) matches(req *http.Request) bool {
+	rest, ok := strings.CutPrefix(req.URL.String(), t.baseURL)
+	return ok && (rest == "" || strings.ContainsAny(rest[:1], "/?#"))
+}
+
+// send sends req authenticated with the current credential, and once more with a refreshed one if it is answered
+// 401 Unauthorized.
+func (t *UpstreamTransport) send(req *http.Request) (*http.Response, error) {
+	if t.credentials == nil {
+		return t.next.RoundTrip(req) //nolint:wrapcheck // the transport is transparent
+	}
+
+	credential, err := t.authenticateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !replayable(req) {
+		return resp, err //nolint:wrapcheck // the transport is transparent
+	}
+
+	if err = t.credentials.Refresh(req.Context(), credential); err != nil {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+
+	drainAndClose(resp.Body)
+
+	if _, err = t.authenticateRequest(retry); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(retry) //nolint:wrapcheck // the transport is transparent
+}
+
+// authenticateRequest adds the current credential to req, returning it.
+func (t *UpstreamTransport) authenticateRequest(req *http.Request) (string, error) {
+	credential, err := t.credentials.Credential(req.Context())
+	if err != nil {
+		return "", fmt.Errorf("failed to get upstream credential: %w", err)
+	}
+
+	if err = t.authenticate(req, credential); err != nil {
+		return "", err
+	}
+
+	return credential, nil
+}
+
+// replayable reports whether the body of req, if any, can be read again.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
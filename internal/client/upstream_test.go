@@ -0,0 +1,360 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestUpstreamTransport(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		header string
+		value  string
+		err    bool
+	}
+
+	tests := map[string]struct {
+		cfg        config.Upstream
+		credential string
+		path       string
+		want       want
+	}{
+		"bearer": {
+			cfg:        config.Upstream{Auth: client.AuthBearer},
+			credential: "token",
+			path:       "/photos/1",
+			want:       want{header: "Authorization", value: "Bearer token"},
+		},
+		"basic": {
+			cfg:        config.Upstream{Auth: client.AuthBasic},
+			credential: "user:pass",
+			path:       "/photos",
+			want:       want{header: "Authorization", value: "Basic dXNlcjpwYXNz"},
+		},
+		"api key": {
+			cfg:        config.Upstream{Auth: client.AuthAPIKey, APIKeyHeader: "X-Key"},
+			credential: "key",
+			path:       "/photos?id=1",
+			want:       want{header: "X-Key", value: "key"},
+		},
+		// The base URL of the test is .../api.
+		"other urls are not authenticated": {
+			cfg:        config.Upstream{Auth: client.AuthBearer},
+			credential: "token",
+			path:       "v2/photos",
+			want:       want{header: "Authorization"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got http.Header
+
+			srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg := tt.cfg
+			cfg.BaseURL = srv.URL + "/api"
+
+			tr, err := client.NewUpstreamTransport(&cfg, client.StaticCredential(tt.credential), http.DefaultTransport)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, cfg.BaseURL+tt.path, http.NoBody)
+
+			resp, err := tr.RoundTrip(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp.Body.Close()
+
+			assert.Equal(t, tt.want.value, got.Get(tt.want.header))
+			assert.Empty(t, req.Header.Get(tt.want.header), "the request of the caller is not modified")
+		})
+	}
+}
+
+func TestUpstreamTransport_Timeout(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	tr, err := client.NewUpstreamTransport(&config.Upstream{BaseURL: srv.URL, Timeout: 20 * time.Millisecond}, nil, http.DefaultTransport)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+"/photos/1", http.NoBody)
+
+	_, err = tr.RoundTrip(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNewUpstreamTransport_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg        config.Upstream
+		credential string
+	}{
+		"no base url":   {cfg: config.Upstream{}},
+		"no credential": {cfg: config.Upstream{BaseURL: "http://upstream", Auth: client.AuthBearer}},
+		"invalid basic": {cfg: config.Upstream{BaseURL: "http://upstream", Auth: client.AuthBasic}, credential: "user"},
+		"unknown auth":  {cfg: config.Upstream{BaseURL: "http://upstream", Auth: "digest"}, credential: "x"},
+		"relative endpoint path": {
+			cfg: config.Upstream{BaseURL: "http://upstream", Endpoints: []config.Endpoint{{Path: "photos/:id"}}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := client.NewUpstreamTransport(&tt.cfg, client.StaticCredential(tt.credential), http.DefaultTransport)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUpstreamTransport_Endpoints(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		status int
+		calls  int32
+		err    error
+	}
+
+	tests := map[string]struct {
+		method string
+		path   string
+		want   want
+	}{
+		"default": {
+			method: http.MethodGet,
+			path:   "/api/albums",
+			want:   want{status: http.StatusServiceUnavailable, calls: 3},
+		},
+		"endpoint without retries": {
+			method: http.MethodGet,
+			path:   "/api/photos/1",
+			want:   want{status: http.StatusServiceUnavailable, calls: 1},
+		},
+		"endpoint of another method": {
+			method: http.MethodDelete,
+			path:   "/api/photos/1",
+			want:   want{status: http.StatusServiceUnavailable, calls: 3},
+		},
+		"endpoint timeout": {
+			method: http.MethodGet,
+			path:   "/api/photos/1/slow",
+			want:   want{err: context.DeadlineExceeded, calls: 1},
+		},
+		"endpoint keeping the timeout": {
+			method: http.MethodGet,
+			path:   "/api/photos/",
+			want:   want{status: http.StatusServiceUnavailable, calls: 2},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+
+				if strings.HasSuffix(r.URL.Path, "/slow") {
+					<-r.Context().Done()
+					return
+				}
+
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg := &config.Upstream{
+				BaseURL: srv.URL + "/api",
+				Timeout: time.Second,
+				Retry:   config.Retry{MaxAttempts: 3, Backoff: time.Millisecond},
+				Endpoints: []config.Endpoint{
+					{Method: http.MethodGet, Path: "/photos/:id", MaxAttempts: 1},
+					{Path: "/photos/:id/slow", Timeout: 20 * time.Millisecond},
+					{Path: "/photos", MaxAttempts: 2},
+				},
+			}
+
+			rec := &countRecorder{counts: map[string]int{}}
+
+			tr, err := client.NewUpstreamTransport(cfg, nil, client.NewRetrier(cfg.BaseURL, &cfg.Retry, http.DefaultTransport, rec))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req, _ := http.NewRequestWithContext(context.Background(), tt.method, srv.URL+tt.path, http.NoBody)
+
+			resp, err := tr.RoundTrip(req)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+			} else if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, tt.want.status, resp.StatusCode)
+			}
+
+			assert.Equal(t, tt.want.calls, calls.Load())
+		})
+	}
+}
+
+// rotatingCredential is refreshed to the next of its credentials.
+type rotatingCredential struct {
+	mu        sync.Mutex
+	current   string
+	next      []string
+	refreshes int
+}
+
+func (c *rotatingCredential) Credential(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.current, nil
+}
+
+func (c *rotatingCredential) Refresh(_ context.Context, rejected string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rejected != c.current {
+		return nil
+	}
+
+	if len(c.next) == 0 {
+		return client.ErrNotRefreshable
+	}
+
+	c.current, c.next = c.next[0], c.next[1:]
+	c.refreshes++
+
+	return nil
+}
+
+func TestUpstreamTransport_Refresh(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		credentials client.CredentialProvider
+		body        io.Reader
+	}
+
+	type want struct {
+		status int
+		calls  int32
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"valid credential": {
+			args: args{credentials: &rotatingCredential{current: "new"}},
+			want: want{status: http.StatusOK, calls: 1},
+		},
+		"refreshed credential": {
+			args: args{credentials: &rotatingCredential{current: "old", next: []string{"new"}}},
+			want: want{status: http.StatusOK, calls: 2},
+		},
+		"refreshed credential with a body": {
+			args: args{credentials: &rotatingCredential{current: "old", next: []string{"new"}}, body: strings.NewReader("photo")},
+			want: want{status: http.StatusOK, calls: 2},
+		},
+		"body not replayable": {
+			args: args{credentials: &rotatingCredential{current: "old", next: []string{"new"}}, body: io.NopCloser(strings.NewReader("photo"))},
+			want: want{status: http.StatusUnauthorized, calls: 1},
+		},
+		"static credential": {
+			args: args{credentials: client.StaticCredential("old")},
+			want: want{status: http.StatusUnauthorized, calls: 1},
+		},
+		"retried once": {
+			args: args{credentials: &rotatingCredential{current: "old", next: []string{"older", "new"}}},
+			want: want{status: http.StatusUnauthorized, calls: 2},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+
+				if r.Header.Get("Authorization") != "Bearer new" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				if b, _ := io.ReadAll(r.Body); tt.args.body != nil && string(b) != "photo" {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			tr, err := client.NewUpstreamTransport(&config.Upstream{BaseURL: srv.URL, Auth: client.AuthBearer}, tt.args.credentials, http.DefaultTransport)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			method, body := http.MethodGet, tt.args.body
+			if body != nil {
+				method = http.MethodPut
+			}
+
+			req, _ := http.NewRequestWithContext(context.Background(), method, srv.URL+"/photos/1", body)
+
+			resp, err := tr.RoundTrip(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp.Body.Close()
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+			assert.Equal(t, tt.want.calls, calls.Load())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// ValidationMetric counts upstream payloads violating their declared constraints, by host.
+const ValidationMetric = "http_client_validation_errors_total"
+
+// Violation is a constraint of a payload field not met by the upstream.
+type Violation struct {
+	// Field is the JSON path of the field, e.g. "[3].thumbnailUrl" for an element of a list.
+	Field string
+	// Rule is the violated constraint, e.g. "required" or "url".
+	Rule string
+}
+
+// ValidationError is returned when the upstream returns well-formed JSON violating the constraints declared by the
+// `validate` tags of the payload type.
+type ValidationError struct {
+	URL        string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	v := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		v = append(v, violation.Field+" "+violation.Rule)
+	}
+
+	return fmt.Sprintf("invalid upstream response from %s: %s", e.URL, strings.Join(v, ", "))
+}
+
+// Validator validates decoded upstream payloads against the constraints of their `validate` struct tags, see
+// github.com/go-playground/validator. Payloads are structs or lists of structs. A nil *Validator accepts everything,
+// so validation is optional for callers.
+type Validator struct {
+	validate *validator.Validate
+	rec      recorder
+}
+
+// NewValidator creates a Validator counting invalid payloads in rec.
+func NewValidator(rec recorder) *Validator {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	return &Validator{validate: v, rec: rec}
+}
+
+// Validate returns a *ValidationError when payload, decoded from the response of rawURL, violates its constraints.
+func (v *Validator) Validate(rawURL string, payload any) error {
+	if v == nil {
+		return nil
+	}
+
+	var violations []Violation
+
+	rv := reflect.Indirect(reflect.ValueOf(payload))
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			violations = append(violations, v.violations(fmt.Sprintf("[%d].", i), rv.Index(i).Interface())...)
+		}
+	} else {
+		violations = v.violations("", payload)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	v.rec.Inc(ValidationMetric, metrics.Labels{"host": host})
+
+	return &ValidationError{URL: rawURL, Violations: violations}
+}
+
+func (v *Validator) violations(prefix string, s any) []Violation {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(v.validate.Struct(s), &fieldErrs) {
+		return nil
+	}
+
+	violations := make([]Violation, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		// The namespace starts with the name of the payload type.
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		violations = append(violations, Violation{Field: prefix + field, Rule: fe.Tag()})
+	}
+
+	return violations
+}
@@ -0,0 +1,81 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+type validatedItem struct {
+	ID   int    `json:"id"   validate:"required"`
+	Link string `json:"link" validate:"omitempty,url"`
+}
+
+func TestValidator_Validate(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		violations []client.Violation
+		count      int
+	}
+
+	tests := map[string]struct {
+		payload any
+		want    want
+	}{
+		"valid struct": {
+			payload: &validatedItem{ID: 1, Link: "https://example.com/1"},
+		},
+		"invalid struct": {
+			payload: &validatedItem{Link: "example"},
+			want: want{
+				violations: []client.Violation{{Field: "id", Rule: "required"}, {Field: "link", Rule: "url"}},
+				count:      1,
+			},
+		},
+		"invalid list element": {
+			payload: &[]validatedItem{{ID: 1}, {ID: 2, Link: "example"}},
+			want: want{
+				violations: []client.Violation{{Field: "[1].link", Rule: "url"}},
+				count:      1,
+			},
+		},
+		"not a struct": {
+			payload: "text",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &countRecorder{counts: map[string]int{}}
+
+			err := client.NewValidator(rec).Validate("https://example.com/items", tt.payload)
+			assert.Equal(t, tt.want.count, rec.counts[client.ValidationMetric+" "])
+
+			if tt.want.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *client.ValidationError
+			if !assert.ErrorAs(t, err, &validationErr) {
+				return
+			}
+
+			assert.Equal(t, "https://example.com/items", validationErr.URL)
+			assert.Equal(t, tt.want.violations, validationErr.Violations)
+		})
+	}
+}
+
+func TestValidator_Nil(t *testing.T) {
+	t.Parallel()
+
+	var v *client.Validator
+	assert.NoError(t, v.Validate("https://example.com/items", &validatedItem{}))
+}
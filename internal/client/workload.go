@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Metadata servers of the platforms.
+const (
+	gcpMetadataURL   = "http://metadata.google.internal"
+	azureMetadataURL = "http://169.254.169.254"
+)
+
+const (
+	// metadataTimeout bounds the requests to a metadata server, which answers at once on its platform.
+	metadataTimeout = 5 * time.Second
+	// tokenRefreshMargin is how long before its expiry a token is replaced, so that it does not expire in flight.
+	tokenRefreshMargin = time.Minute
+)
+
+var (
+	errNoResource     = errors.New("azure workload identity requires an audience")
+	errEmptyToken     = errors.New("empty token")
+	errInvalidIDToken = errors.New("invalid id token")
+)
+
+// WorkloadIdentity is the CredentialProvider of the "gcp" and "azure" auth, whose tokens are those of the identity
+// of the workload, obtained from the metadata server of its platform, e.g. the service account of a Cloud Run
+// service or the managed identity of an Azure container app. A token is cached until shortly before it expires, and
+// replaced at once when the upstream rejects it.
+type WorkloadIdentity struct {
+	fetch  func(ctx context.Context) (token string, expiry time.Time, err error)
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewWorkloadIdentity creates the WorkloadIdentity of auth, AuthGCP or AuthAzure, requesting its tokens with hc, or a
+// client without proxy if nil.
+func NewWorkloadIdentity(auth string, cfg *config.WorkloadIdentity, hc *http.Client) (*WorkloadIdentity, error) {
+	if hc == nil {
+		hc = &http.Client{Transport: &http.Transport{}, Timeout: metadataTimeout}
+	}
+
+	m := &metadataServer{client: hc, baseURL: strings.TrimSuffix(cfg.MetadataURL, "/")}
+
+	switch auth {
+	case AuthGCP:
+		if m.baseURL == "" {
+			m.baseURL = gcpMetadataURL
+		}
+
+		if cfg.Audience != "" {
+			return &WorkloadIdentity{fetch: func(ctx context.Context) (string, time.Time, error) {
+				return m.gcpIDToken(ctx, cfg.Audience)
+			}}, nil
+		}
+
+		return &WorkloadIdentity{fetch: m.gcpAccessToken}, nil
+	case AuthAzure:
+		if cfg.Audience == "" {
+			return nil, errNoResource
+		}
+
+		if m.baseURL == "" {
+			m.baseURL = azureMetadataURL
+		}
+
+		return &WorkloadIdentity{fetch: func(ctx context.Context) (string, time.Time, error) {
+			return m.azureToken(ctx, cfg.Audience, cfg.ClientID)
+		}}, nil
+	default:
+		return nil, fmt.Errorf("no workload identity for upstream auth %q", auth)
+	}
+}
+
+// Credential implements CredentialProvider, requesting a token if the cached one expires soon.
+func (w *WorkloadIdentity) Credential(ctx context.Context) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.token != "" && time.Until(w.expiry) > tokenRefreshMargin {
+		return w.token, nil
+	}
+
+	return w.refresh(ctx)
+}
+
+// Refresh implements CredentialProvider, requesting a new token unless rejected was already replaced.
+func (w *WorkloadIdentity) Refresh(ctx context.Context, rejected string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.token != rejected {
+		return nil
+	}
+
+	_, err := w.refresh(ctx)
+
+	return err
+}
+
+func (w *WorkloadIdentity) refresh(ctx context.Context) (string, error) {
+	token, expiry, err := w.fetch(ctx)
+	if err == nil && token == "" {
+		err = errEmptyToken
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain workload identity token: %w", err)
+	}
+
+	w.token, w.expiry = token, expiry
+
+	return token, nil
+}
+
+// metadataServer requests the tokens of the workload from the metadata server at baseURL.
+type metadataServer struct {
+	client  *http.Client
+	baseURL string
+}
+
+// gcpIDToken returns an ID token of the service account of the workload for audience, expiring as its exp claim.
+func (m *metadataServer) gcpIDToken(ctx context.Context, audience string) (string, time.Time, error) {
+	q := url.Values{"audience": {audience}, "format": {"full"}}
+
+	b, err := m.get(ctx, "/computeMetadata/v1/instance/service-accounts/default/identity?"+q.Encode(), "Metadata-Flavor", "Google")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := strings.TrimSpace(string(b))
+
+	expiry, err := jwtExpiry(token)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiry, nil
+}
+
+// gcpAccessToken returns an OAuth access token of the service account of the workload.
+func (m *metadataServer) gcpAccessToken(ctx context.Context) (string, time.Time, error) {
+	b, err := m.get(ctx, "/computeMetadata/v1/instance/service-accounts/default/token", "Metadata-Flavor", "Google")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token response: %w", err)
+	}
+
+	return resp.AccessToken, time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second), nil
+}
+
+// azureToken returns an access token for resource of the managed identity of the workload, the user-assigned one of
+// clientID if set.
+func (m *metadataServer) azureToken(ctx context.Context, resource, clientID string) (string, time.Time, error) {
+	q := url.Values{"api-version": {"2018-02-01"}, "resource": {resource}}
+	if clientID != "" {
+		q.Set("client_id", clientID)
+	}
+
+	b, err := m.get(ctx, "/metadata/identity/oauth2/token?"+q.Encode(), "Metadata", "true")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// IMDS encodes the expiry as a string of seconds since the epoch.
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}
+
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token response: %w", err)
+	}
+
+	sec, err := strconv.ParseInt(resp.ExpiresOn, 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token expiry %q: %w", resp.ExpiresOn, err)
+	}
+
+	return resp.AccessToken, time.Unix(sec, 0), nil
+}
+
+// get returns the body of the metadata at path, requested with the header which proves the request is not forwarded
+// from outside the workload.
+func (m *metadataServer) get(ctx context.Context, path, header, value string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+path, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set(header, value)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request metadata server: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata response: %w", err)
+	}
+
+	return b, nil
+}
+
+// jwtExpiry returns the time of the exp claim of token. Its signature is not verified, the token is only forwarded
+// to the upstream, which verifies it.
+func jwtExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errInvalidIDToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", errInvalidIDToken, err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", errInvalidIDToken, err)
+	}
+
+	return time.Unix(claims.Exp, 0), nil
+}
@@ -0,0 +1,188 @@
+package client_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// idToken returns an unsigned JWT expiring at exp, numbered n.
+func idToken(n int32, exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"n":%d,"exp":%d}`, n, exp.Unix())))
+	return "eyJhbGciOiJSUzI1NiJ9." + payload + ".sig"
+}
+
+func TestWorkloadIdentity(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		path  string
+		query string
+		token string
+	}
+
+	tests := map[string]struct {
+		auth    string
+		cfg     config.WorkloadIdentity
+		header  [2]string
+		respond func(w http.ResponseWriter, n int32, exp time.Time)
+		want    want
+	}{
+		"gcp id token": {
+			auth:   client.AuthGCP,
+			cfg:    config.WorkloadIdentity{Audience: "https://photos.example.com"},
+			header: [2]string{"Metadata-Flavor", "Google"},
+			respond: func(w http.ResponseWriter, n int32, exp time.Time) {
+				w.Write([]byte(idToken(n, exp)))
+			},
+			want: want{
+				path:  "/computeMetadata/v1/instance/service-accounts/default/identity",
+				query: "audience=https%3A%2F%2Fphotos.example.com&format=full",
+			},
+		},
+		"gcp access token": {
+			auth:   client.AuthGCP,
+			header: [2]string{"Metadata-Flavor", "Google"},
+			respond: func(w http.ResponseWriter, n int32, exp time.Time) {
+				fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d,"token_type":"Bearer"}`, n, int(time.Until(exp).Seconds()))
+			},
+			want: want{path: "/computeMetadata/v1/instance/service-accounts/default/token"},
+		},
+		"azure": {
+			auth:   client.AuthAzure,
+			cfg:    config.WorkloadIdentity{Audience: "api://photos", ClientID: "abc"},
+			header: [2]string{"Metadata", "true"},
+			respond: func(w http.ResponseWriter, n int32, exp time.Time) {
+				fmt.Fprintf(w, `{"access_token":"token-%d","expires_on":"%s","token_type":"Bearer"}`, n, strconv.FormatInt(exp.Unix(), 10))
+			},
+			want: want{
+				path:  "/metadata/identity/oauth2/token",
+				query: "api-version=2018-02-01&client_id=abc&resource=api%3A%2F%2Fphotos",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			// The first token expires within the refresh margin, the next ones in an hour.
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.header[1], r.Header.Get(tt.header[0]))
+				assert.Equal(t, tt.want.path, r.URL.Path)
+				assert.Equal(t, tt.want.query, r.URL.RawQuery)
+
+				n := calls.Add(1)
+
+				exp := time.Now().Add(time.Hour)
+				if n == 1 {
+					exp = time.Now().Add(30 * time.Second)
+				}
+
+				tt.respond(w, n, exp)
+			}))
+			t.Cleanup(srv.Close)
+
+			tt.cfg.MetadataURL = srv.URL
+
+			wi, err := client.NewWorkloadIdentity(tt.auth, &tt.cfg, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			ctx := context.Background()
+
+			first, err := wi.Credential(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// The first token expires soon and is replaced, the second is cached.
+			second, _ := wi.Credential(ctx)
+			assert.NotEqual(t, first, second)
+
+			cached, _ := wi.Credential(ctx)
+			assert.Equal(t, second, cached)
+			assert.Equal(t, int32(2), calls.Load())
+
+			// A token rejected by the upstream is replaced once, however many requests were rejected with it.
+			assert.NoError(t, wi.Refresh(ctx, second))
+			assert.NoError(t, wi.Refresh(ctx, second))
+			assert.Equal(t, int32(3), calls.Load())
+
+			third, _ := wi.Credential(ctx)
+			assert.NotEqual(t, second, third)
+		})
+	}
+}
+
+func TestWorkloadIdentity_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("audience") {
+		case "forbidden":
+			http.Error(w, "no service account", http.StatusNotFound)
+		case "empty":
+		default:
+			w.Write([]byte("not a jwt"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		auth string
+		cfg  config.WorkloadIdentity
+		want string
+	}{
+		"status": {
+			auth: client.AuthGCP,
+			cfg:  config.WorkloadIdentity{Audience: "forbidden", MetadataURL: srv.URL},
+			want: "received non-OK HTTP status: 404",
+		},
+		"empty": {
+			auth: client.AuthGCP,
+			cfg:  config.WorkloadIdentity{Audience: "empty", MetadataURL: srv.URL},
+			want: "invalid id token",
+		},
+		"invalid id token": {
+			auth: client.AuthGCP,
+			cfg:  config.WorkloadIdentity{Audience: "https://photos.example.com", MetadataURL: srv.URL},
+			want: "invalid id token",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			wi, err := client.NewWorkloadIdentity(tt.auth, &tt.cfg, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = wi.Credential(context.Background())
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	_, err := client.NewWorkloadIdentity(client.AuthAzure, &config.WorkloadIdentity{}, nil)
+	assert.EqualError(t, err, "azure workload identity requires an audience")
+}
@@ -0,0 +1,136 @@
+// Package clientip resolves the IP address of API consumers behind reverse proxies. The X-Forwarded-For and
+// X-Real-IP headers are only believed when set by a trusted proxy, as any client can send them.
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+type ipKey struct{}
+
+// ContextWithIP returns a copy of ctx carrying the client IP.
+func ContextWithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ipKey{}, ip)
+}
+
+// FromContext returns the client IP carried by ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ipKey{}).(string)
+	return ip
+}
+
+// Resolver resolves the client IP of requests, trusting the forwarding headers of the configured proxy networks.
+type Resolver struct {
+	proxies []*net.IPNet
+}
+
+// NewResolver creates a Resolver trusting the proxies in the given CIDRs. Without any, the client IP is always the
+// remote address of the connection.
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	r := &Resolver{}
+
+	for _, cidr := range trustedProxies {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy cidr %s: %w", cidr, err)
+		}
+
+		r.proxies = append(r.proxies, n)
+	}
+
+	return r, nil
+}
+
+// Resolve returns the client IP of req. When the connection comes from a trusted proxy, X-Forwarded-For is walked
+// from the right, the address appended by the nearest proxy, and the first address which is not a trusted proxy is
+// the client. Without X-Forwarded-For, X-Real-IP is used.
+func (r *Resolver) Resolve(req *http.Request) string {
+	ip := remoteIP(req.RemoteAddr)
+	if !r.trusted(ip) {
+		return ip
+	}
+
+	forwarded := forwardedFor(req.Header)
+	if len(forwarded) == 0 {
+		if realIP := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); realIP != nil {
+			return realIP.String()
+		}
+
+		return ip
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(forwarded[i])
+		if hop == nil {
+			// The header was tampered with beyond this point, the last proxy seen is the best known client.
+			return ip
+		}
+
+		ip = hop.String()
+		if !r.trusted(ip) {
+			return ip
+		}
+	}
+
+	return ip
+}
+
+// Middleware attaches the client IP to the request context and the canonical log event.
+func (r *Resolver) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := r.Resolve(c.Request)
+
+		c.Request = c.Request.WithContext(ContextWithIP(c.Request.Context(), ip))
+		logger.EventFromContext(c.Request.Context()).Add(zap.String("client_ip", ip))
+
+		c.Next()
+	}
+}
+
+func (r *Resolver) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, n := range r.proxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
+
+// forwardedFor returns the addresses of all X-Forwarded-For headers, in order.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	return hops
+}
@@ -0,0 +1,116 @@
+package clientip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		remoteAddr string
+		headers    map[string][]string
+	}
+
+	tests := map[string]struct {
+		args args
+		want string
+	}{
+		"direct client": {
+			args: args{remoteAddr: "203.0.113.7:41000"},
+			want: "203.0.113.7",
+		},
+		"untrusted forwarded for": {
+			args: args{remoteAddr: "203.0.113.7:41000", headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1"}}},
+			want: "203.0.113.7",
+		},
+		"trusted proxy": {
+			args: args{remoteAddr: "10.0.0.5:41000", headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1"}}},
+			want: "198.51.100.1",
+		},
+		"spoofed hops before the client": {
+			args: args{remoteAddr: "10.0.0.5:41000", headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1, 10.0.0.9"}}},
+			want: "198.51.100.1",
+		},
+		"several headers": {
+			args: args{remoteAddr: "10.0.0.5:41000", headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1", "10.0.0.9"}}},
+			want: "198.51.100.1",
+		},
+		"only proxies": {
+			args: args{remoteAddr: "10.0.0.5:41000", headers: map[string][]string{"X-Forwarded-For": {"10.0.0.8, 10.0.0.9"}}},
+			want: "10.0.0.8",
+		},
+		"invalid hop": {
+			args: args{remoteAddr: "10.0.0.5:41000", headers: map[string][]string{"X-Forwarded-For": {"198.51.100.1, garbage, 10.0.0.9"}}},
+			want: "10.0.0.9",
+		},
+		"real ip": {
+			args: args{remoteAddr: "10.0.0.5:41000", headers: map[string][]string{"X-Real-Ip": {"198.51.100.1"}}},
+			want: "198.51.100.1",
+		},
+		"untrusted real ip": {
+			args: args{remoteAddr: "203.0.113.7:41000", headers: map[string][]string{"X-Real-Ip": {"198.51.100.1"}}},
+			want: "203.0.113.7",
+		},
+		"ipv6": {
+			args: args{remoteAddr: "[fd00::1]:41000", headers: map[string][]string{"X-Forwarded-For": {"2001:db8::1"}}},
+			want: "2001:db8::1",
+		},
+	}
+
+	r, err := clientip.NewResolver([]string{"10.0.0.0/8", "fd00::/8"})
+	assert.NoError(t, err)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.RemoteAddr = tt.args.remoteAddr
+			req.Header = tt.args.headers
+
+			assert.Equal(t, tt.want, r.Resolve(req))
+		})
+	}
+}
+
+func TestNewResolver_InvalidCIDR(t *testing.T) {
+	t.Parallel()
+
+	_, err := clientip.NewResolver([]string{"10.0.0.0"})
+	assert.ErrorContains(t, err, "invalid trusted proxy cidr 10.0.0.0")
+}
+
+func TestResolver_Middleware(t *testing.T) {
+	t.Parallel()
+
+	r, err := clientip.NewResolver([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+
+	event := logger.NewEvent()
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.ContextWithEvent(c.Request.Context(), event))
+	}, r.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, clientip.FromContext(c.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.RemoteAddr = "10.0.0.5:41000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, "198.51.100.1", resp.Body.String())
+	assert.Equal(t, "198.51.100.1", event.Fields()[0].String)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // SetFlagAndBind sets flags and binds them to the viper configuration. Use this most of the time.
@@ -65,10 +66,17 @@ func (vc *Viper) Binds(cmd *cobra.Command, binds []BindDetail) error {
 // bindFlag binds flags based on provided details.
 func (vc *Viper) bindFlag(cmd *cobra.Command, mapKey string, flag FlagDetail) error {
 	// Bind the current flag to a configuration key in viper.
-	if err := vc.Viper.BindPFlag(mapKey, cmd.PersistentFlags().Lookup(flag.Name)); err != nil {
+	f := cmd.PersistentFlags().Lookup(flag.Name)
+	if err := vc.Viper.BindPFlag(mapKey, f); err != nil {
 		return fmt.Errorf("failed to bind flag %s: %w", flag.Name, err)
 	}
 
+	if vc.flags == nil {
+		vc.flags = map[string]*pflag.Flag{}
+	}
+
+	vc.flags[mapKey] = f
+
 	return nil
 }
 
@@ -78,5 +86,11 @@ func (vc *Viper) bindEnvDetails(mapKey, envName string) error {
 		return fmt.Errorf("failed to bind environment variable: %w", err)
 	}
 
+	if vc.envs == nil {
+		vc.envs = map[string][]string{}
+	}
+
+	vc.envs[mapKey] = append(vc.envs[mapKey], envName)
+
 	return nil
 }
@@ -1,15 +1,51 @@
 // Package config provides the configuration for the application. It contains the configuration schema and the default values for the configuration.
 package config
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 // Config represents the configuration for the application.
 type Config struct {
-	ConfigPath  string      `mapstructure:"config_path"`
+	ConfigPath string `mapstructure:"config_path" default:"./config.yaml"`
+	// EnvFile is the env file setting the environment variables which are not set. Empty loads .env, if it exists, in
+	// dev mode only.
+	EnvFile     string      `mapstructure:"env_file"`
 	LogLevel    string      `mapstructure:"log_level"`
 	Stacktrace  bool        `mapstructure:"stacktrace"`
 	Placeholder Placeholder `mapstructure:"placeholder"`
 	Server      Server      `mapstructure:"server"`
+	Client      Client      `mapstructure:"client"`
+	Storage     Storage     `mapstructure:"storage"`
+	Uploads     Uploads     `mapstructure:"uploads"`
+	// HeaderPassthrough selects the upstream response headers surfaced to API consumers.
+	HeaderPassthrough HeaderPassthrough `mapstructure:"header_passthrough"`
+	Redis             Redis             `mapstructure:"redis"`
+	CSRF              CSRF              `mapstructure:"csrf"`
+	Session           Session           `mapstructure:"session"`
+	Features          Features          `mapstructure:"features"`
+	Mirror            Mirror            `mapstructure:"mirror"`
+	Audit             Audit             `mapstructure:"audit"`
+	Tenancy           Tenancy           `mapstructure:"tenancy"`
+	Photos            Photos            `mapstructure:"photos"`
+	SelfTest          SelfTest          `mapstructure:"self_test"`
+	Import            Import            `mapstructure:"import"`
+	Errors            Errors            `mapstructure:"errors"`
+	Election          Election          `mapstructure:"election"`
+	Lifecycle         Lifecycle         `mapstructure:"lifecycle"`
+	Chaos             Chaos             `mapstructure:"chaos"`
+	Profiling         Profiling         `mapstructure:"profiling"`
+	// Upstreams holds the upstream APIs called by the service, by name, such as "photos".
+	Upstreams map[string]Upstream `mapstructure:"upstreams"`
+	// Remote merges a document of Consul or etcd over the config file, to change settings fleet-wide.
+	Remote Remote `mapstructure:"remote"`
+	// Strictness is the strictness mode of the environment, "dev", "strict" or "prod". It toggles strict JSON binding,
+	// response validation, verbose errors, debug endpoints, fake data and fault injection at once. Empty is "prod".
+	Strictness string `mapstructure:"strictness"`
+
+	// sections are the decoded sections registered by the subsystems, by key, read with Section.Get.
+	sections map[string]any
 }
 
 // Placeholder represents the configuration for the Placeholder command.
@@ -20,6 +56,790 @@ type Placeholder struct {
 // Server holds the configuration for the server.
 type Server struct {
 	Host    string        `mapstructure:"host"`
-	Port    int           `mapstructure:"port"`
+	Port    int           `mapstructure:"port" default:"8080"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	// TrustedProxies lists the networks of the reverse proxies whose X-Forwarded-For and X-Real-IP headers are
+	// believed to resolve the client IP. Empty uses the remote address of the connection.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Canaries send a share of the requests of routes to a canary deployment.
+	Canaries []Canary `mapstructure:"canaries"`
+	// Paths normalizes the paths of requests before routing.
+	Paths Paths `mapstructure:"paths"`
+	// AccessLog writes one line per request, apart from the application log.
+	AccessLog AccessLog `mapstructure:"access_log"`
+	// RouteListing serves the registered routes on GET /admin/routes.
+	RouteListing RouteListing `mapstructure:"route_listing"`
+	// JSONRoutes set the decoding of the JSON request bodies of routes, regardless of the strictness mode.
+	JSONRoutes []JSONRoute `mapstructure:"json_routes"`
+}
+
+// JSONRoute sets the decoding of the JSON request bodies of a route, e.g. to accept unknown fields from a consumer
+// migrating to a new version of a payload in strict mode.
+type JSONRoute struct {
+	// Method and Path are the route as registered, e.g. POST and /admin/break-glass.
+	Method string `mapstructure:"method" required:"true"`
+	Path   string `mapstructure:"path" required:"true"`
+	// DisallowUnknownFields rejects the bodies with fields the route does not know of, such as typos.
+	DisallowUnknownFields bool `mapstructure:"disallow_unknown_fields"`
+	// UseNumber keeps the numbers of the fields of any type exact, rather than float64 which rounds large integers.
+	UseNumber bool `mapstructure:"use_number"`
+}
+
+// RouteListing holds the configuration of GET /admin/routes, listing the routes with their authentication,
+// middleware and handler.
+type RouteListing struct {
+	Enabled bool `mapstructure:"enabled"`
+	// AdminRoles lists the roles allowed to list the routes. It is required when enabled.
+	AdminRoles []string `mapstructure:"admin_roles"`
+}
+
+// AccessLog holds the format and destination of the access log.
+type AccessLog struct {
+	// Format is "json", "combined" for the Apache combined log format, "template" for Template, or empty for no access
+	// log, leaving requests to the debug line of the application log.
+	Format string `mapstructure:"format"`
+	// Template is the text/template of the lines of the "template" format, executed with the fields of the entry, e.g.
+	// {{.Method}} {{.Path}} {{.Status}} {{.Latency}}.
+	Template string `mapstructure:"template"`
+	// Output is "stdout", "stderr" or the path of a file to append to. Empty is stdout.
+	Output string `mapstructure:"output"`
+}
+
+// Paths holds the normalization of request paths, so that variants of the path of a route reach it.
+type Paths struct {
+	// TrailingSlash is "redirect" to redirect paths ending with a slash to the path without it, "rewrite" to serve them
+	// as the path without it, or empty to leave them to the router, which redirects GET requests.
+	TrailingSlash string `mapstructure:"trailing_slash"`
+	// CaseInsensitive matches the static segments of the routes regardless of case, serving /Photos/1 as /photos/1.
+	// Parameters keep their case.
+	CaseInsensitive bool `mapstructure:"case_insensitive"`
+}
+
+// Canary sends a share of the requests of a route to the same path on another upstream.
+type Canary struct {
+	// Method and Path are the route as registered, e.g. GET and /photos/:id.
+	Method string `mapstructure:"method" required:"true"`
+	Path   string `mapstructure:"path" required:"true"`
+	// Name is the variant of the requests sent to Upstream in the metrics. Empty is "canary".
+	Name string `mapstructure:"name"`
+	// Upstream is the base URL the requests are proxied to.
+	Upstream *url.URL `mapstructure:"upstream" required:"true"`
+	// Percent is the percentage of the requests sent to Upstream, from 0 to 100.
+	Percent float64 `mapstructure:"percent"`
+	// Header and Cookie pin a request to the variant they name, "stable" or Name, whatever Percent.
+	Header string `mapstructure:"header"`
+	Cookie string `mapstructure:"cookie"`
+}
+
+// Client holds the configuration for the outbound HTTP client.
+type Client struct {
+	Transport Transport `mapstructure:"transport"`
+	Cache     HTTPCache `mapstructure:"cache"`
+	DevCache  DevCache  `mapstructure:"dev_cache"`
+	// RateLimits limits the rate of requests to upstreams, per base URL.
+	RateLimits []RateLimit `mapstructure:"rate_limits"`
+	// Hedging sends a duplicate of the slow GET and HEAD requests, using whichever response arrives first.
+	Hedging   Hedging   `mapstructure:"hedging"`
+	Redirects Redirects `mapstructure:"redirects"`
+	SSRFGuard SSRFGuard `mapstructure:"ssrf_guard"`
+	// MockUpstream answers upstream requests with generated fake data instead of calling the upstreams.
+	MockUpstream MockUpstream `mapstructure:"mock_upstream"`
+	// ValidateResponses fails upstream responses violating the constraints declared on the decoded payloads, such as
+	// required fields and URL formats, or the JSON Schemas of their paths.
+	ValidateResponses bool `mapstructure:"validate_responses"`
+}
+
+// Upstream holds where an upstream API is, how requests to it are authenticated and how its failures are handled.
+// Credentials are only sent to the base URL, not to the other URLs fetched with the upstream client, such as those of
+// photo images.
+type Upstream struct {
+	// BaseURL is the URL of the upstream, e.g. a mock or a gateway. Empty uses the public API.
+	BaseURL string `mapstructure:"base_url"`
+	// Auth is how requests are authenticated: "bearer", "basic", "api_key", "gcp" or "azure" for the tokens of the
+	// identity of the workload, or empty for none.
+	Auth string `mapstructure:"auth"`
+	// CredentialRef references the credential of Auth: "env:NAME" for an environment variable, "file:PATH" for a
+	// file such as a mounted secret, or the credential itself. Basic credentials are "user:password".
+	CredentialRef string `mapstructure:"credential_ref" redact:"true"`
+	// APIKeyHeader is the header of the "api_key" credential. Empty uses X-Api-Key.
+	APIKeyHeader string `mapstructure:"api_key_header"`
+	// WorkloadIdentity configures the "gcp" and "azure" auth, whose tokens are obtained from the metadata server of
+	// the platform instead of a credential_ref.
+	WorkloadIdentity WorkloadIdentity `mapstructure:"workload_identity"`
+	// Timeout bounds each request, including its retries and the read of its response. Zero leaves requests bounded
+	// by their context.
+	Timeout time.Duration `mapstructure:"timeout"`
+	Retry   Retry         `mapstructure:"retry"`
+	// MaxResponseSize bounds the bodies of the successful responses of the upstream, e.g. 10MiB, failing the requests
+	// of larger ones rather than reading them in memory. Zero does not bound them.
+	MaxResponseSize ByteSize `mapstructure:"max_response_size"`
+	// RateLimit limits the rate of requests to the upstream, its BaseURL is ignored. Zero RPS disables it.
+	RateLimit      RateLimit      `mapstructure:"rate_limit"`
+	CircuitBreaker CircuitBreaker `mapstructure:"circuit_breaker"`
+	// Endpoints override the timeout and the retries of the requests to some paths of the upstream, e.g. a longer
+	// timeout for a slow listing and none of the retries for a lookup expected to answer at once. A request takes the
+	// settings of the first endpoint it matches.
+	Endpoints []Endpoint `mapstructure:"endpoints"`
+	Signing   Signing    `mapstructure:"signing"`
+	Proxy     Proxy      `mapstructure:"proxy"`
+	Dial      Dial       `mapstructure:"dial"`
+}
+
+// Dial overrides how the connections to an upstream are dialed, for the multi-homed hosts where it is reached through
+// another network path than the other destinations. The settings which are not set keep those of client.transport.
+// The upstreams dialed differently must not share their host, whose connections are pooled, or the configuration is
+// rejected at startup.
+type Dial struct {
+	// IPFamily is "ipv4", "ipv6", "prefer_ipv4" or "prefer_ipv6", as client.transport.ip_family.
+	IPFamily string `mapstructure:"ip_family"`
+	// SourceAddress binds the connections to the given local IP address.
+	SourceAddress string `mapstructure:"source_address"`
+	// SourceInterface binds the connections to the first address of the given network interface.
+	SourceInterface string `mapstructure:"source_interface"`
+	// DialTimeout bounds establishing a connection, including name resolution.
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	// FallbackDelay is how long to wait for the preferred IP family before racing the other one. A negative value
+	// disables the race.
+	FallbackDelay time.Duration `mapstructure:"fallback_delay"`
+}
+
+// Proxy holds the proxy the requests to an upstream are sent through, instead of the one of the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment, for the upstreams reached through another egress path. It is not supported
+// with the SSRF guard.
+type Proxy struct {
+	// URL is the proxy, e.g. http://proxy:3128, https://proxy:3129 or socks5://proxy:1080. Empty uses the proxy of
+	// the environment.
+	URL string `mapstructure:"url"`
+	// CredentialRef references the "user:password" of a proxy requiring authentication: "env:NAME", "file:PATH" or
+	// the credential itself.
+	CredentialRef string `mapstructure:"credential_ref" redact:"true"`
+	// NoProxy lists the hosts reached directly, as NO_PROXY: host names, domains such as .example.com, IPs and CIDRs,
+	// with an optional port.
+	NoProxy []string `mapstructure:"no_proxy"`
+}
+
+// WorkloadIdentity holds how the tokens of the identity of the workload are obtained on GCP or Azure.
+type WorkloadIdentity struct {
+	// Audience is the audience of the GCP ID tokens, empty for OAuth access tokens, or the resource of the Azure
+	// tokens, e.g. api://photos, which they require.
+	Audience string `mapstructure:"audience"`
+	// ClientID selects a user-assigned managed identity on Azure. Empty uses the system-assigned identity.
+	ClientID string `mapstructure:"client_id"`
+	// MetadataURL is the URL of the metadata server, e.g. of an emulator. Empty uses the server of the platform.
+	MetadataURL string `mapstructure:"metadata_url"`
+}
+
+// Signing holds the key signing the requests to an upstream with HMAC, for upstreams authenticating services by the
+// signatures of their requests rather than a credential.
+type Signing struct {
+	// KeyID is the ID of the key known to the upstream. Empty does not sign the requests.
+	KeyID string `mapstructure:"key_id"`
+	// SecretRef references the secret of the key: "env:NAME", "file:PATH" or the secret itself.
+	SecretRef string `mapstructure:"secret_ref" redact:"true"`
+	// Headers lists the headers signed besides the request target, the date and the digest, e.g. X-Request-ID.
+	Headers []string `mapstructure:"headers"`
+}
+
+// Endpoint overrides the settings of an upstream for the requests to a path.
+type Endpoint struct {
+	// Method is the method of the requests, empty for any.
+	Method string `mapstructure:"method"`
+	// Path is the path of the requests relative to the base URL, whose :name segments match any segment, e.g.
+	// /photos/:id.
+	Path string `mapstructure:"path" required:"true"`
+	// Timeout replaces the timeout of the upstream. Zero keeps it.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAttempts replaces retry.max_attempts, one disabling the retries. Zero keeps it.
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+// Retry holds the retries of the idempotent requests to an upstream failing with a transport error or a 429, 502,
+// 503 or 504 status.
+type Retry struct {
+	// MaxAttempts is the number of attempts of a request, including the first. Zero or one disables the retries.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Backoff bounds the random wait before the first retry, doubled at each retry. Zero uses 100ms.
+	Backoff time.Duration `mapstructure:"backoff"`
+	// MaxBackoff caps the wait before a retry. Zero uses 2s.
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+	// BudgetPercent caps the retries at this percentage of the requests, e.g. 20, so that retries add at most that
+	// much load to an upstream failing all of them, with a reserve of 10 retries for upstreams with little traffic.
+	// Zero does not cap them.
+	BudgetPercent float64 `mapstructure:"budget_percent"`
+}
+
+// CircuitBreaker holds the circuit breaker failing the requests to an upstream fast while it is down.
+type CircuitBreaker struct {
+	// Failures is the number of consecutive failures, transport errors or 5xx statuses, opening the circuit. Zero
+	// disables the circuit breaker.
+	Failures int `mapstructure:"failures"`
+	// OpenTimeout is how long the circuit stays open before a trial request. Zero uses 30s.
+	OpenTimeout time.Duration `mapstructure:"open_timeout"`
+}
+
+// Hedging holds the hedging of the upstream requests.
+type Hedging struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Delay is how long a request waits for its response before the duplicate is sent, e.g. the p95 latency of the
+	// upstream so that about 5% of the requests are hedged.
+	Delay time.Duration `mapstructure:"delay"`
+	// MaxRatio caps the hedges to this share of the requests, to protect the upstream when it slows down as a whole.
+	// Zero uses 0.05.
+	MaxRatio float64 `mapstructure:"max_ratio"`
+}
+
+// MockUpstream holds the configuration of the fake upstream used in development.
+type MockUpstream struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Seed selects the generated data set, the same seed always generates the same data.
+	Seed int64 `mapstructure:"seed"`
+}
+
+// SSRFGuard holds the protection of upstream requests against server side request forgery.
+type SSRFGuard struct {
+	// Enabled blocks requests to schemes and ports not allowed and to internal addresses.
+	Enabled bool `mapstructure:"enabled"`
+	// AllowedSchemes lists the URL schemes allowed. Empty allows http and https.
+	AllowedSchemes []string `mapstructure:"allowed_schemes"`
+	// AllowedPorts lists the ports allowed. Empty allows 80 and 443.
+	AllowedPorts []int `mapstructure:"allowed_ports"`
+	// AllowedCIDRs lists internal networks reachable despite the guard, e.g. an internal upstream.
+	AllowedCIDRs []string `mapstructure:"allowed_cidrs"`
+}
+
+// Redirects holds the policy for following upstream redirects.
+type Redirects struct {
+	// Disabled returns 3xx responses to the caller instead of following them.
+	Disabled bool `mapstructure:"disabled"`
+	// MaxHops is the number of redirects followed before failing. Zero follows up to 10.
+	MaxHops int `mapstructure:"max_hops"`
+	// ForbidCrossHost fails requests redirected to another host.
+	ForbidCrossHost bool `mapstructure:"forbid_cross_host"`
+	// ForwardAuthorization keeps the Authorization header on redirects to another host. By default it is stripped.
+	ForwardAuthorization bool `mapstructure:"forward_authorization"`
+}
+
+// RateLimit holds the token bucket limiting the requests to one upstream.
+type RateLimit struct {
+	// BaseURL selects the limited requests by prefix of their URL. The longest matching base URL applies.
+	BaseURL string `mapstructure:"base_url"`
+	// RPS is the sustained number of requests per second.
+	RPS float64 `mapstructure:"rps"`
+	// Burst is the number of requests allowed at once.
+	Burst int `mapstructure:"burst"`
+	// Mode is "block" to wait for the quota or "fail_fast" to fail immediately when it is exhausted. Empty blocks.
+	Mode string `mapstructure:"mode"`
+}
+
+// HTTPCache holds the configuration for caching upstream responses according to their Cache-Control headers.
+type HTTPCache struct {
+	// Store selects where responses are cached, "memory" or "redis". Empty disables the cache.
+	Store string `mapstructure:"store"`
+	// MaxEntries bounds the number of responses kept by the memory store. Zero means no limit.
+	MaxEntries int `mapstructure:"max_entries"`
+	// RevalidationTTL is how long stale responses with an ETag or Last-Modified are kept to be revalidated. Zero uses
+	// one hour.
+	RevalidationTTL time.Duration `mapstructure:"revalidation_ttl"`
+}
+
+// DevCache holds the configuration for persisting upstream responses on disk between runs. For development only.
+type DevCache struct {
+	// Dir is the directory holding the cached responses. Empty disables the cache.
+	Dir string `mapstructure:"dir"`
+	// TTL is how long a cached response is served before it is fetched again. Zero keeps responses until removed.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// Transport holds the configuration for dialing outbound connections.
+type Transport struct {
+	// IPFamily selects the address family used to dial: "ipv4" or "ipv6" restrict dialing to that family,
+	// "prefer_ipv4" or "prefer_ipv6" try that family first and fall back to the other. Empty uses the system default.
+	IPFamily string `mapstructure:"ip_family"`
+	// SourceAddress binds outbound connections to the given local IP address.
+	SourceAddress string `mapstructure:"source_address"`
+	// SourceInterface binds outbound connections to the first address of the given network interface.
+	SourceInterface string `mapstructure:"source_interface"`
+	// DialTimeout bounds establishing a connection, including name resolution. Zero means no timeout.
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	// FallbackDelay is how long to wait for the primary address family before racing the other one (Happy Eyeballs),
+	// the preferred one with "prefer_ipv4" and "prefer_ipv6". Zero uses the net package default of 300ms, a negative
+	// value disables the race.
+	FallbackDelay time.Duration `mapstructure:"fallback_delay"`
+	// KeepAlive is the interval between TCP keep-alive probes. Zero uses the net package default, a negative value
+	// disables keep-alive probes.
+	KeepAlive time.Duration `mapstructure:"keep_alive"`
+	DNS       DNS           `mapstructure:"dns"`
+}
+
+// DNS holds how the host names of the outbound connections are resolved.
+type DNS struct {
+	// Cache caches the addresses of the host names for the TTL of their records, so that bursts of new connections do
+	// not each query the resolver.
+	Cache bool `mapstructure:"cache"`
+	// MaxTTL bounds how long addresses are cached, and is their TTL when the resolver does not report it. Zero uses 5
+	// minutes.
+	MaxTTL time.Duration `mapstructure:"max_ttl"`
+	// NegativeTTL is how long the host names which do not exist are cached. Zero does not cache them.
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+	// Servers are the name servers queried in turn, as host:port, e.g. a DNS cache of the node. Empty uses those of
+	// the system.
+	Servers []string `mapstructure:"servers"`
+	// Hosts resolve host names to static addresses instead of the resolver, as /etc/hosts, e.g. to point a hermetic
+	// test environment at local servers. It is a list as host names are not valid keys.
+	Hosts []StaticHost `mapstructure:"hosts"`
+}
+
+// StaticHost is a host name resolved to static addresses.
+type StaticHost struct {
+	Name      string   `mapstructure:"name" required:"true"`
+	Addresses []string `mapstructure:"addresses" required:"true"`
+}
+
+// Storage holds the configuration for blob storage of binary assets such as photo images.
+type Storage struct {
+	// Backend selects the BlobStore implementation, "local" or "s3". Empty disables blob storage.
+	Backend      string        `mapstructure:"backend"`
+	PresignTTL   time.Duration `mapstructure:"presign_ttl"`
+	MaxImageSize ByteSize      `mapstructure:"max_image_size"`
+	Local        LocalStorage  `mapstructure:"local"`
+	S3           S3Storage     `mapstructure:"s3"`
+}
+
+// LocalStorage holds the configuration for the local filesystem blob store.
+type LocalStorage struct {
+	Root string `mapstructure:"root"`
+	// BaseURL is the externally reachable URL under which stored objects are served.
+	BaseURL    string `mapstructure:"base_url"`
+	SigningKey string `mapstructure:"signing_key" redact:"true"`
+}
+
+// S3Storage holds the configuration for an S3 compatible blob store such as AWS S3 or MinIO.
+type S3Storage struct {
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key" redact:"true"`
+	// UsePathStyle addresses objects as endpoint/bucket/key instead of bucket.endpoint/key, as required by MinIO.
+	UsePathStyle bool `mapstructure:"use_path_style"`
+}
+
+// Uploads holds the configuration for the file upload endpoint.
+type Uploads struct {
+	MaxSize ByteSize `mapstructure:"max_size"`
+	// AllowedTypes lists the media types accepted, as detected from the content of the upload.
+	AllowedTypes []string `mapstructure:"allowed_types"`
+}
+
+// HeaderPassthrough holds the policy for surfacing upstream response headers to API consumers.
+type HeaderPassthrough struct {
+	// Allow lists the header names passed through. A name ending with "*" matches every header with that prefix.
+	Allow []string `mapstructure:"allow"`
+}
+
+// Redis holds the configuration for connecting to Redis.
+type Redis struct {
+	Addr     string `mapstructure:"addr"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" redact:"true"`
+	DB       int    `mapstructure:"db"`
+	// PoolSize bounds the open connections. Zero uses 10 per CPU.
+	PoolSize int `mapstructure:"pool_size"`
+	// MinIdleConns is the number of idle connections kept open for bursts.
+	MinIdleConns int `mapstructure:"min_idle_conns"`
+	// DialTimeout bounds establishing a connection. Zero uses 5s.
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+	// ReadTimeout and WriteTimeout bound each command. Zero uses 3s.
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	TLS          RedisTLS      `mapstructure:"tls"`
+}
+
+// RedisTLS holds the TLS configuration of the connections to Redis.
+type RedisTLS struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CAFile is the PEM file of the certificate authorities of the server. Empty uses the system ones.
+	CAFile string `mapstructure:"ca_file"`
+	// ServerName is the name verified in the server certificate. Empty uses the host of Addr.
+	ServerName string `mapstructure:"server_name"`
+	// InsecureSkipVerify accepts any server certificate, for development only.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// CSRF holds the configuration of the protection of browser requests against cross-site request forgery.
+type CSRF struct {
+	// Enabled requires a token issued by GET /csrf on state-changing requests without an Authorization header.
+	Enabled bool `mapstructure:"enabled"`
+	// SigningKey signs the tokens. It is required when the protection is enabled.
+	SigningKey string `mapstructure:"signing_key" redact:"true"`
+	// CookieName is the cookie holding the token. Empty uses csrf_token.
+	CookieName string `mapstructure:"cookie_name"`
+	// HeaderName is the request header echoing the token. Empty uses X-CSRF-Token.
+	HeaderName string `mapstructure:"header_name"`
+	// SameSite is the SameSite attribute of the cookie: "lax", "strict" or "none". Empty uses lax.
+	SameSite string `mapstructure:"same_site"`
+	// Secure restricts the cookie to HTTPS.
+	Secure bool `mapstructure:"secure"`
+}
+
+// Session holds the configuration of the cookie-based sessions of interactive users.
+type Session struct {
+	// Store selects where sessions are kept, "memory" or "redis". Empty disables sessions.
+	Store string `mapstructure:"store"`
+	// CookieName is the cookie holding the session ID. Empty uses session.
+	CookieName string `mapstructure:"cookie_name"`
+	// IdleTimeout ends sessions without requests for that long. Zero uses 30 minutes.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// AbsoluteTimeout ends sessions that long after login, however active. Zero uses 12 hours.
+	AbsoluteTimeout time.Duration `mapstructure:"absolute_timeout"`
+	// SameSite is the SameSite attribute of the cookie: "lax", "strict" or "none". Empty uses lax.
+	SameSite string `mapstructure:"same_site"`
+	// Secure restricts the cookie to HTTPS.
+	Secure bool `mapstructure:"secure"`
+	// RememberMe configures the remember-me tokens logging users back in once their session expired.
+	RememberMe RememberMe `mapstructure:"remember_me"`
+}
+
+// RememberMe holds the configuration of the remember-me tokens. The key and lifetime usually differ per environment,
+// they are also read from the REMEMBER_ME_KEY and REMEMBER_ME_TTL environment variables.
+type RememberMe struct {
+	// Key encrypts the remember-me cookies. Empty disables remember-me tokens.
+	Key string `mapstructure:"key" redact:"true"`
+	// CookieName is the cookie holding the remember-me token. Empty uses remember_me.
+	CookieName string `mapstructure:"cookie_name"`
+	// TTL is how long a login is remembered, however often the token is used. Zero uses 30 days.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// Features holds the feature flags and who may override them per request.
+type Features struct {
+	// Flags are the feature flags by name and whether they are enabled.
+	Flags     map[string]bool  `mapstructure:"flags"`
+	Overrides FeatureOverrides `mapstructure:"overrides"`
+}
+
+// FeatureOverrides holds who may override feature flags for their requests with the X-Feature-Overrides header.
+// Anonymous requests never may.
+type FeatureOverrides struct {
+	// AnyAuthenticated lets every authenticated consumer override flags. Only enable it outside production.
+	AnyAuthenticated bool `mapstructure:"any_authenticated"`
+	// AllowedSubjects lists the subjects allowed to override flags, e.g. the testers of a dark launch in production.
+	AllowedSubjects []string `mapstructure:"allowed_subjects"`
+}
+
+// Mirror holds the configuration of the mirroring of sampled requests to a shadow deployment.
+type Mirror struct {
+	// BaseURL is the URL of the shadow deployment. Empty disables mirroring.
+	BaseURL string `mapstructure:"base_url"`
+	// Percent is the percentage of the requests mirrored, from 0 to 100.
+	Percent float64 `mapstructure:"percent"`
+	// MaxBodySize is the size above which requests are not mirrored. Zero uses 64KiB.
+	MaxBodySize ByteSize `mapstructure:"max_body_size"`
+	// MaxInFlight bounds the mirrored requests in progress, further ones are dropped. Zero uses 16.
+	MaxInFlight int `mapstructure:"max_in_flight"`
+	// Timeout bounds each mirrored request. Zero uses 5 seconds.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// RedactFields are the JSON fields, form fields and query parameters whose values are redacted, case-insensitively.
+	// Empty redacts email, phone, password, name, address, token and secret.
+	RedactFields []string `mapstructure:"redact_fields"`
+	// Compare configures the comparison of the responses of the shadow to the primary ones.
+	Compare MirrorCompare `mapstructure:"compare"`
+}
+
+// MirrorCompare holds the configuration of the comparison of shadow responses.
+type MirrorCompare struct {
+	// Enabled compares the status and body of the responses of the shadow to the primary ones.
+	Enabled bool `mapstructure:"enabled"`
+	// IgnoreFields are the JSON fields expected to differ, such as timestamps, ignored case-insensitively at any depth.
+	// The redacted fields are always ignored.
+	IgnoreFields []string `mapstructure:"ignore_fields"`
+	// LogPercent is the percentage of the mismatches logged with their differences, from 0 to 100.
+	LogPercent float64 `mapstructure:"log_percent"`
+}
+
+// Audit holds the configuration of the audit log of mutating API calls.
+type Audit struct {
+	// Store selects where entries are kept for GET /admin/audit, "memory" or empty for none.
+	Store string `mapstructure:"store"`
+	// MaxEntries bounds the entries kept in memory, the oldest are discarded. Zero keeps all of them.
+	MaxEntries int `mapstructure:"max_entries"`
+	// Log writes every entry to the log stream.
+	Log bool `mapstructure:"log"`
+	// AdminRoles lists the roles allowed to query the audit log. It is required with a store.
+	AdminRoles []string `mapstructure:"admin_roles"`
+}
+
+// Tenancy holds the configuration of the tenant resolution.
+type Tenancy struct {
+	// Mode is "single", the default, where every request belongs to DefaultTenant, or "multi" where the tenant is
+	// resolved from each request and the data is scoped to it.
+	Mode string `mapstructure:"mode"`
+	// DefaultTenant is the tenant of single-tenant mode. Empty uses "default".
+	DefaultTenant string `mapstructure:"default_tenant"`
+	// Sources are where the tenant is resolved from in multi-tenant mode: "header", "subdomain" and "claim". All the
+	// sources present in a request must name the same tenant.
+	Sources []string `mapstructure:"sources"`
+	// Header is the request header of the header source, also sent to the upstream. Empty uses X-Tenant-ID.
+	Header string `mapstructure:"header"`
+	// Domain is the domain under which the subdomain source finds the tenant, e.g. "api.example.com" for
+	// "acme.api.example.com".
+	Domain string `mapstructure:"domain"`
+	// Claim is the token claim of the claim source. Empty uses "tenant".
+	Claim string `mapstructure:"claim"`
+	// Tenants lists the allowed tenants. Empty allows any.
+	Tenants []string `mapstructure:"tenants"`
+}
+
+// Import holds the configuration of the bulk import of photos.
+type Import struct {
+	// Enabled serves POST /photos/import and GET /photos/import/:jobID.
+	Enabled bool `mapstructure:"enabled"`
+	// MaxSize bounds the size of the import files, e.g. 100MiB. Zero does not bound it.
+	MaxSize ByteSize `mapstructure:"max_size"`
+	// MaxConcurrentJobs bounds the jobs running at once, the others wait queued. Zero uses 2.
+	MaxConcurrentJobs int `mapstructure:"max_concurrent_jobs"`
+	// MaxRowErrors bounds the row errors reported per job. Zero uses 100.
+	MaxRowErrors int `mapstructure:"max_row_errors"`
+	// Retention is how long finished jobs can be queried. Zero uses 24h.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// Photos holds the configuration of the photos API.
+type Photos struct {
+	// Concurrency bounds the concurrent upstream requests when getting several photos one by one. Zero uses 8.
+	Concurrency int         `mapstructure:"concurrency"`
+	SoftDelete  SoftDelete  `mapstructure:"soft_delete"`
+	Export      PhotoExport `mapstructure:"export"`
+	Search      PhotoSearch `mapstructure:"search"`
+	// Fallback serves the last known photos while the upstream fails.
+	Fallback PhotoFallback `mapstructure:"fallback"`
+}
+
+// PhotoFallback holds the configuration of the last known photos served while the upstream fails.
+type PhotoFallback struct {
+	// Store selects where the last known photos are kept, "memory" or "redis". Empty disables the fallback.
+	Store string `mapstructure:"store"`
+	// MaxStaleness is how long after they were fetched photos may be served. Zero serves them at any age.
+	MaxStaleness time.Duration `mapstructure:"max_staleness"`
+}
+
+// SoftDelete holds the configuration of the soft delete of photos.
+type SoftDelete struct {
+	// Store selects where the deletion marks are kept, "memory" or "redis". Empty disables soft delete.
+	Store string `mapstructure:"store"`
+	// AdminRoles lists the roles allowed to delete and restore photos and to include the deleted ones in reads. It is
+	// required with a store.
+	AdminRoles []string `mapstructure:"admin_roles"`
+}
+
+// PhotoExport holds the configuration of the export of the photo dataset.
+type PhotoExport struct {
+	// Enabled serves GET /photos/export.
+	Enabled bool `mapstructure:"enabled"`
+	// AdminRoles lists the roles allowed to export. It is required when enabled.
+	AdminRoles []string `mapstructure:"admin_roles"`
+}
+
+// PhotoSearch holds the configuration of the full-text search of photos.
+type PhotoSearch struct {
+	// Enabled serves GET /photos/search.
+	Enabled bool `mapstructure:"enabled"`
+	// Backend is "memory", the default, to search an index of the photos built in memory, or "elasticsearch" to
+	// search an Elasticsearch or OpenSearch index kept up to date with the changes made through the API.
+	Backend string `mapstructure:"backend"`
+	// Refresh is how long the memory index is used before it is rebuilt from the upstream. Zero uses 10 minutes.
+	Refresh       time.Duration `mapstructure:"refresh"`
+	Elasticsearch Elasticsearch `mapstructure:"elasticsearch"`
+}
+
+// Elasticsearch holds the configuration of the Elasticsearch or OpenSearch search backend.
+type Elasticsearch struct {
+	// URL is the base URL of the cluster, e.g. http://localhost:9200.
+	URL string `mapstructure:"url"`
+	// Index is the index of the photo documents. Empty uses "photos".
+	Index    string `mapstructure:"index"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" redact:"true"`
+	// BatchSize bounds the changes sent in one bulk request. Zero uses 100.
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval is how long changes wait for a batch to fill before being sent. Zero uses 1s.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// QueueSize bounds the changes waiting to be indexed, further ones are dropped until the next reindex. Zero uses
+	// 10000.
+	QueueSize int `mapstructure:"queue_size"`
+	// ReindexInterval is how often the scheduler reindexes all the photos, recovering the changes the servers could
+	// not index. Zero disables the scheduled reindex, which is not supported in multi-tenant mode.
+	ReindexInterval time.Duration `mapstructure:"reindex_interval"`
+}
+
+// Election holds the configuration of the leader election between the replicas, so that background work runs on one
+// of them only.
+type Election struct {
+	// Backend holds the leadership, "kubernetes" for a coordination.k8s.io Lease. Empty disables the election, each
+	// replica leading.
+	Backend string `mapstructure:"backend"`
+	// Identity identifies the replica as the holder of the leadership. Empty uses the hostname, the pod name in
+	// Kubernetes.
+	Identity string `mapstructure:"identity"`
+	// LeaseDuration is how long the leadership is held without being renewed before another replica takes it over.
+	// Zero uses 15s.
+	LeaseDuration time.Duration `mapstructure:"lease_duration"`
+	// RenewDeadline is how long the leader keeps leading while failing to renew. It must be shorter than the lease
+	// duration. Zero uses 10s.
+	RenewDeadline time.Duration `mapstructure:"renew_deadline"`
+	// RetryPeriod is the interval between attempts to take or renew the leadership. Zero uses 2s.
+	RetryPeriod time.Duration   `mapstructure:"retry_period"`
+	Kubernetes  KubernetesLease `mapstructure:"kubernetes"`
+}
+
+// KubernetesLease holds the configuration of the Lease holding the leadership with the kubernetes election backend.
+type KubernetesLease struct {
+	// APIServer is the URL of the Kubernetes API. Empty uses the in-cluster https://kubernetes.default.svc.
+	APIServer string `mapstructure:"api_server"`
+	// Namespace of the Lease. Empty uses the namespace of the service account.
+	Namespace string `mapstructure:"namespace"`
+	// Name of the Lease.
+	Name string `mapstructure:"name"`
+	// TokenFile and CAFile authenticate the API. Empty use the ones of the in-cluster service account.
+	TokenFile string `mapstructure:"token_file"`
+	CAFile    string `mapstructure:"ca_file"`
+}
+
+// Lifecycle holds the configuration of the graceful shutdown on SIGTERM.
+type Lifecycle struct {
+	// DrainDelay is how long the service keeps serving once it reports itself not ready, for the load balancers to
+	// deregister it. It should exceed the period of the readiness probe. Zero does not wait.
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	// ShutdownTimeout bounds the shutdown of each component. Zero uses 10s.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// Timeouts overrides the shutdown timeout by component, e.g. http-server, photo-import, search-indexer or
+	// leader-election.
+	Timeouts map[string]time.Duration `mapstructure:"timeouts"`
+}
+
+// Profiling holds the continuous profiling of the service, pushed to Pyroscope or Google Cloud Profiler.
+type Profiling struct {
+	// Backend is "pyroscope", "cloud-profiler" or empty to disable profiling.
+	Backend string `mapstructure:"backend"`
+	// Service is the application name of the profiles. Empty uses skeleton-go-api.
+	Service string `mapstructure:"service"`
+	// Version tags the profiles. Empty uses the VCS revision or module version of the binary.
+	Version string `mapstructure:"version"`
+	// Region tags the profiles, e.g. eu-west-1.
+	Region string `mapstructure:"region"`
+	// Tags are added to the tags of the profiles.
+	Tags map[string]string `mapstructure:"tags"`
+	// Profiles are the profile types collected among "cpu", "heap" and "goroutine". Empty collects all of them.
+	Profiles []string `mapstructure:"profiles"`
+	// Interval is the period of the uploads to Pyroscope, covered by the CPU profiles. Zero uses 15s. Cloud Profiler
+	// picks its own schedule.
+	Interval time.Duration `mapstructure:"interval"`
+	// Pyroscope is the server of the "pyroscope" backend.
+	Pyroscope Pyroscope `mapstructure:"pyroscope"`
+	// CloudProfiler is the project of the "cloud-profiler" backend.
+	CloudProfiler CloudProfiler `mapstructure:"cloud_profiler"`
+}
+
+// Pyroscope holds the Pyroscope server receiving the profiles.
+type Pyroscope struct {
+	// ServerAddress is the base URL of the server, e.g. http://pyroscope:4040.
+	ServerAddress string `mapstructure:"server_address"`
+	// AuthToken is sent as a bearer token, e.g. for Grafana Cloud.
+	AuthToken string `mapstructure:"auth_token" redact:"true"`
+	// TenantID is sent in the X-Scope-OrgID header of multi-tenant servers.
+	TenantID string `mapstructure:"tenant_id"`
+}
+
+// CloudProfiler holds the Google Cloud project receiving the profiles. The credentials are those of the default
+// service account of the metadata server.
+type CloudProfiler struct {
+	// ProjectID is the project of the profiles. Empty uses the project of the metadata server.
+	ProjectID string `mapstructure:"project_id"`
+}
+
+// Chaos holds the configuration of the fault injection, to exercise the retries and circuit breakers of the clients
+// in staging. It is refused in prod mode.
+type Chaos struct {
+	// Enabled injects the faults of the routes, and of the X-Chaos header if AllowHeader is set.
+	Enabled bool `mapstructure:"enabled"`
+	// AllowHeader lets each request ask for faults with the X-Chaos header, e.g. "latency=500ms,status=503,percent=50".
+	AllowHeader bool `mapstructure:"allow_header"`
+	// Routes are the faults injected into the requests of routes.
+	Routes []ChaosRoute `mapstructure:"routes"`
+}
+
+// ChaosRoute is the fault injected into the requests of a route.
+type ChaosRoute struct {
+	// Method is the method of the route. Empty matches any method.
+	Method string `mapstructure:"method"`
+	// Path is the path of the route as registered, e.g. /photos/:id.
+	Path string `mapstructure:"path" required:"true"`
+	// Percent is the percentage of the requests faulted, from 0 to 100. Zero faults every request.
+	Percent float64 `mapstructure:"percent"`
+	// Latency delays the requests, by up to Jitter more.
+	Latency time.Duration `mapstructure:"latency"`
+	Jitter  time.Duration `mapstructure:"jitter"`
+	// Status answers the requests with this error status instead of handling them. Zero handles them.
+	Status int `mapstructure:"status"`
+	// Reset closes the connection of the requests without answering them.
+	Reset bool `mapstructure:"reset"`
+}
+
+// SelfTest holds the configuration of the self-test mode, in which the server runs smoke requests against itself once
+// bound and exits with their outcome.
+type SelfTest struct {
+	// Enabled runs the self-test instead of serving, set by the --self-test flag.
+	Enabled bool `mapstructure:"enabled"`
+	// MockUpstream answers upstream requests with fake data during the self-test, like client.mock_upstream.
+	MockUpstream bool `mapstructure:"mock_upstream"`
+	// Timeout bounds the whole self-test. Zero uses 30s.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Checks are the smoke requests, in order. Empty checks the health endpoint and fetches a sample photo.
+	Checks []SelfTestCheck `mapstructure:"checks"`
+}
+
+// SelfTestCheck is a smoke request of the self-test.
+type SelfTestCheck struct {
+	Name string `mapstructure:"name"`
+	// Method is the request method. Empty uses GET.
+	Method string `mapstructure:"method"`
+	Path   string `mapstructure:"path"`
+	// Status is the expected response status. Zero expects 200.
+	Status int `mapstructure:"status"`
+}
+
+// Errors holds the configuration of the error reporting.
+type Errors struct {
+	// Runbooks are the runbooks of the error classes (validation, auth, upstream, db and internal), logged with the
+	// errors of their class.
+	Runbooks map[string]Runbook `mapstructure:"runbooks"`
+	// InternalNetworks lists the networks of the internal consumers, such as on-call engineers, whose error responses
+	// include the runbook when they accept application/problem+json. Empty includes it in no response.
+	InternalNetworks []string `mapstructure:"internal_networks"`
+}
+
+// Runbook points to the remediation steps of a class of errors.
+type Runbook struct {
+	ID  string `mapstructure:"id"`
+	URL string `mapstructure:"url"`
+}
+
+// Remote holds the key of Consul or etcd holding a configuration document, in YAML or JSON, merged over the config file
+// and under the environment and the flags. It is read with the config file, so it cannot configure itself.
+type Remote struct {
+	// Provider is "consul", "etcd" or empty for none.
+	Provider string `mapstructure:"provider"`
+	// Endpoint is the URL of the HTTP API, e.g. http://127.0.0.1:8500 for Consul or http://127.0.0.1:2379 for etcd.
+	Endpoint *url.URL `mapstructure:"endpoint"`
+	// Key is the key of the document, e.g. config/skeleton-go-api.
+	Key string `mapstructure:"key"`
+	// TokenRef references the ACL token of Consul or the auth token of etcd, as CredentialRef. Empty sends none.
+	TokenRef string `mapstructure:"token_ref" redact:"true"`
+	// Timeout bounds each read of the key. Zero uses 5s.
 	Timeout time.Duration `mapstructure:"timeout"`
+	// Watch polls the key every Interval, applying the changes of the reloadable settings, such as the feature flags
+	// and the upstream rate limits, to the running service. The other settings need a restart.
+	Watch bool `mapstructure:"watch"`
+	// Interval is the period of the polls of Watch. Zero uses 30s.
+	Interval time.Duration `mapstructure:"interval"`
 }
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ByteSize is a size in bytes, set in the configuration as a number of bytes or with a unit, e.g. 512KB or 1.5GiB.
+// The units are powers of 1024 with or without the i: B, KB or KiB, MB or MiB, GB or GiB, TB or TiB.
+type ByteSize int64
+
+// Byte sizes.
+const (
+	Byte     ByteSize = 1
+	KiB               = 1024 * Byte
+	MiB               = 1024 * KiB
+	GiB               = 1024 * MiB
+	TiB               = 1024 * GiB
+	kibShift          = 10
+)
+
+var errInvalidByteSize = errors.New("invalid byte size")
+
+// ParseByteSize parses a size such as 10MB, 64KiB or 1048576.
+func ParseByteSize(s string) (ByteSize, error) {
+	num := strings.TrimSpace(s)
+	unit := ""
+
+	if i := strings.IndexFunc(num, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i >= 0 {
+		num, unit = strings.TrimSpace(num[:i]), strings.ToUpper(strings.TrimSpace(num[i:]))
+	}
+
+	var mult ByteSize
+
+	switch strings.Replace(unit, "IB", "B", 1) {
+	case "", "B":
+		mult = Byte
+	case "KB":
+		mult = KiB
+	case "MB":
+		mult = MiB
+	case "GB":
+		mult = GiB
+	case "TB":
+		mult = TiB
+	default:
+		return 0, fmt.Errorf("%w %q: unknown unit %q", errInvalidByteSize, s, unit)
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%w %q", errInvalidByteSize, s)
+	}
+
+	size := f * float64(mult)
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("%w %q: too large", errInvalidByteSize, s)
+	}
+
+	return ByteSize(size), nil
+}
+
+// String returns the size in the largest unit dividing it, e.g. 10MiB.
+func (b ByteSize) String() string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	i := 0
+	for ; i < len(units)-1 && b != 0 && b%KiB == 0; i++ {
+		b >>= kibShift
+	}
+
+	return strconv.FormatInt(int64(b), 10) + units[i]
+}
+
+// parseURL parses an absolute URL, with a scheme and a host.
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: a scheme and a host are required", u.Redacted())
+	}
+
+	return u, nil
+}
+
+// stringHookFunc decodes the strings of the configuration into the durations, the byte sizes and the URLs.
+func stringHookFunc() mapstructure.DecodeHookFuncType {
+	return func(_, to reflect.Type, data any) (any, error) {
+		s, ok := data.(string)
+		if !ok {
+			return data, nil
+		}
+
+		switch to {
+		case reflect.TypeOf(time.Duration(0)):
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration %q: expected e.g. 2s or 1m30s", s)
+			}
+
+			return d, nil
+		case reflect.TypeOf(ByteSize(0)):
+			return ParseByteSize(s)
+		case reflect.TypeOf(&url.URL{}):
+			if s == "" {
+				return (*url.URL)(nil), nil
+			}
+
+			return parseURL(s)
+		default:
+			return data, nil
+		}
+	}
+}
+
+// decode decodes input into out as viper does, with the durations, byte sizes and URLs of stringHookFunc and the
+// comma separated lists of the environment. The errors name the key of the offending value.
+func decode(input, out any, errorUnused bool) error {
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			stringHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		ErrorUnused:      errorUnused,
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating decoder: %w", err)
+	}
+
+	return d.Decode(input) //nolint:wrapcheck // the errors name the keys
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of the settings of Diff, in their order of precedence.
+const (
+	SourceFlag   = "flag"
+	SourceEnv    = "env"
+	SourceRemote = "remote"
+	SourceFile   = "file"
+	// SourceOverride is a value set by the code, e.g. with viper's Set or by the strictness mode.
+	SourceOverride = "override"
+)
+
+// Setting is a setting of the configuration differing from its default, with the source of its value.
+type Setting struct {
+	Key     string `json:"key"`
+	Value   any    `json:"value"`
+	Default any    `json:"default"`
+	Source  string `json:"source"`
+}
+
+// Diff returns the settings of cfg, built by vc, which differ from their default, sorted by key, telling whether the
+// flags, the environment, the remote configuration or the config file set them. The values are those of Map with the
+// secrets redacted; the settings of the elements of lists are reported with their list.
+func (vc *Viper) Diff(cfg *Config) []Setting {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	values := map[string]any{}
+	flatten("", Map(cfg, true), values)
+
+	defaults := map[string]any{}
+	flatten("", Map(vc.defaultConfig(), true), defaults)
+
+	for k := range defaults {
+		if _, ok := values[k]; !ok {
+			values[k] = nil
+		}
+	}
+
+	remote := map[string]any{}
+	if vc.remote != nil {
+		_ = yaml.Unmarshal(vc.remote, &remote) // the document was merged, it parses
+	}
+
+	var settings []Setting
+
+	for k, v := range values {
+		if reflect.DeepEqual(v, defaults[k]) {
+			continue
+		}
+
+		settings = append(settings, Setting{Key: k, Value: v, Default: defaults[k], Source: vc.source(k, remote)})
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+
+	return settings
+}
+
+// defaultConfig returns the configuration of the defaults alone.
+func (vc *Viper) defaultConfig() *Config {
+	d := viper.New()
+	for k, v := range vc.defaults {
+		d.SetDefault(k, v)
+	}
+
+	settings := d.AllSettings()
+
+	// The defaults may leave required settings empty.
+	sections, _ := vc.decodeSections(settings, false)
+	cfg := Config{sections: sections}
+	_ = decode(settings, &cfg, false)
+
+	return &cfg
+}
+
+// source returns the source of the value of key.
+func (vc *Viper) source(key string, remote map[string]any) string {
+	if f, ok := vc.flags[key]; ok && f.Changed {
+		return SourceFlag
+	}
+
+	for _, env := range vc.envs[key] {
+		if _, ok := os.LookupEnv(env); ok {
+			return SourceEnv
+		}
+	}
+
+	if inDocument(remote, strings.Split(key, ".")) {
+		return SourceRemote
+	}
+
+	if vc.Viper.InConfig(key) {
+		return SourceFile
+	}
+
+	return SourceOverride
+}
+
+// inDocument reports whether the path of keys is set in the document m, whose keys may have any case.
+func inDocument(m map[string]any, path []string) bool {
+	for k, v := range m {
+		if !strings.EqualFold(k, path[0]) {
+			continue
+		}
+
+		if len(path) == 1 {
+			return true
+		}
+
+		if sub, ok := v.(map[string]any); ok && inDocument(sub, path[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flatten sets the leaves of the nested maps of v in out, keyed by their path. Empty maps have no leaves.
+func flatten(prefix string, v any, out map[string]any) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		out[prefix] = v
+		return
+	}
+
+	for k, sv := range m {
+		flatten(joinKey(prefix, k), sv, out)
+	}
+}
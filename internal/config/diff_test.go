@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+type diffSection struct {
+	Size int `mapstructure:"size"`
+}
+
+func TestViper_Diff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(path, []byte("log_level: info\nserver:\n  port: 8080\n  timeout: 5s\n"+
+		"redis:\n  password: secret\nsection:\n  size: 3\n"), 0o600)) {
+		return
+	}
+
+	t.Setenv("DIFF_TEST_TIMEOUT", "10s")
+
+	cmd := &cobra.Command{}
+
+	v := config.NewViper()
+	v.Register(config.NewSection("section", diffSection{Size: 1}, nil))
+
+	err := v.SetFlagAndBind(cmd, []config.BindDetail{
+		{Flag: config.FlagDetail{Name: "config", DefaultValue: ""}, MapKey: "config_path"},
+		{Flag: config.FlagDetail{Name: "log-level", DefaultValue: ""}, MapKey: "log_level"},
+		{EnvName: "DIFF_TEST_TIMEOUT", MapKey: "server.timeout"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if !assert.NoError(t, cmd.PersistentFlags().Set("config", path)) || !assert.NoError(t, cmd.PersistentFlags().Set("log-level", "debug")) {
+		return
+	}
+
+	v.Viper.Set("server.host", "localhost")
+
+	cfg, err := v.BuildConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 10*time.Second, cfg.Server.Timeout)
+	assert.Equal(t, []config.Setting{
+		{Key: "config_path", Value: path, Default: "./config.yaml", Source: config.SourceFlag},
+		{Key: "log_level", Value: "debug", Default: "", Source: config.SourceFlag},
+		{Key: "redis.password", Value: config.Redacted, Default: "", Source: config.SourceFile},
+		{Key: "section.size", Value: 3, Default: 1, Source: config.SourceFile},
+		{Key: "server.host", Value: "localhost", Default: "", Source: config.SourceOverride},
+		{Key: "server.timeout", Value: "10s", Default: "0s", Source: config.SourceEnv},
+	}, v.Diff(cfg))
+}
+
+func TestViper_DiffRemote(t *testing.T) {
+	t.Parallel()
+
+	store := &kvStore{key: "config/skeleton-go-api", doc: "log_level: warn\n"}
+	srv := httptest.NewServer(store)
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(path, []byte("log_level: info\nremote:\n  provider: consul\n  endpoint: "+srv.URL+
+		"\n  key: config/skeleton-go-api\n"), 0o600)) {
+		return
+	}
+
+	v := config.NewViper()
+	v.Viper.Set("config_path", path)
+
+	cfg, err := v.BuildConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	settings := v.Diff(cfg)
+	assert.Contains(t, settings, config.Setting{Key: "log_level", Value: "warn", Default: "", Source: config.SourceRemote})
+	assert.Contains(t, settings, config.Setting{Key: "remote.key", Value: "config/skeleton-go-api", Default: "", Source: config.SourceFile})
+}
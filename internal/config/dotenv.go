@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/strictness"
+)
+
+// DefaultEnvFile is the env file loaded in dev mode when no env file is configured.
+const DefaultEnvFile = ".env"
+
+// LoadEnvFile sets the environment variables of the env file at path which are not set already, so that the real
+// environment takes precedence. The file has a NAME=value assignment per line, optionally prefixed with export; blank
+// lines and lines starting with # are ignored. Values may be quoted: single quotes keep the value as is, double quotes
+// expand \n, \", and \\. Unquoted values end at a # preceded by a space.
+func LoadEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening env file: %w", err)
+	}
+	defer f.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(f)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("error parsing env file %s: line %d: expected NAME=value", path, n)
+		}
+
+		if vars[name], err = envValue(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("error parsing env file %s: line %d: %w", path, n, err)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("error reading env file: %w", err)
+	}
+
+	for name, value := range vars {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+
+		if err = os.Setenv(name, value); err != nil {
+			return fmt.Errorf("error setting %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// envValue returns the value of an assignment of an env file, unquoted.
+func envValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+
+		return s[1 : end+1], nil
+	case strings.HasPrefix(s, `"`):
+		var b strings.Builder
+
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(s):
+				i++
+
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case '"', '\\':
+					b.WriteByte(s[i])
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+
+		return "", errors.New("unterminated double quote")
+	default:
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = s[:i]
+		}
+
+		return strings.TrimSpace(s), nil
+	}
+}
+
+// loadEnvFile loads the configured env file, which has to exist, or the default one if it exists in dev mode.
+func (vc *Viper) loadEnvFile() error {
+	if path := vc.Viper.GetString("env_file"); path != "" {
+		return LoadEnvFile(path)
+	}
+
+	if vc.Viper.GetString("strictness") != string(strictness.ModeDev) {
+		return nil
+	}
+
+	err := LoadEnvFile(DefaultEnvFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,100 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// The tests set environment variables, so they are not parallel.
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# local development
+export DOTENV_TEST_PLAIN=plain value # comment
+DOTENV_TEST_SINGLE='keep \n and #1'
+DOTENV_TEST_DOUBLE="line\nnext \"quoted\""
+DOTENV_TEST_EMPTY=
+DOTENV_TEST_SET=from file
+`
+
+	if !assert.NoError(t, os.WriteFile(path, []byte(content), 0o600)) {
+		return
+	}
+
+	t.Setenv("DOTENV_TEST_SET", "from env")
+
+	for _, name := range []string{"DOTENV_TEST_PLAIN", "DOTENV_TEST_SINGLE", "DOTENV_TEST_DOUBLE", "DOTENV_TEST_EMPTY"} {
+		name := name
+		t.Cleanup(func() { os.Unsetenv(name) })
+	}
+
+	if !assert.NoError(t, config.LoadEnvFile(path)) {
+		return
+	}
+
+	assert.Equal(t, "plain value", os.Getenv("DOTENV_TEST_PLAIN"))
+	assert.Equal(t, `keep \n and #1`, os.Getenv("DOTENV_TEST_SINGLE"))
+	assert.Equal(t, "line\nnext \"quoted\"", os.Getenv("DOTENV_TEST_DOUBLE"))
+
+	_, set := os.LookupEnv("DOTENV_TEST_EMPTY")
+	assert.True(t, set)
+	assert.Equal(t, "from env", os.Getenv("DOTENV_TEST_SET"), "the environment takes precedence")
+}
+
+func TestLoadEnvFile_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no assignment":       "DOTENV_TEST_INVALID\n",
+		"unterminated quote":  "DOTENV_TEST_INVALID=\"value\n",
+		"space in the name":   "DOTENV TEST=value\n",
+		"unterminated single": "DOTENV_TEST_INVALID='value\n",
+	}
+
+	for name, content := range tests {
+		content := content
+
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if !assert.NoError(t, os.WriteFile(path, []byte(content), 0o600)) {
+				return
+			}
+
+			assert.ErrorContains(t, config.LoadEnvFile(path), "line 1")
+		})
+	}
+
+	assert.ErrorIs(t, config.LoadEnvFile(filepath.Join(t.TempDir(), ".env")), os.ErrNotExist)
+}
+
+func TestViper_BuildConfig_EnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "local.env")
+	if !assert.NoError(t, os.WriteFile(path, []byte("DOTENV_TEST_LOG_LEVEL=debug\n"), 0o600)) {
+		return
+	}
+
+	t.Cleanup(func() { os.Unsetenv("DOTENV_TEST_LOG_LEVEL") })
+
+	v := config.NewViper()
+	v.Viper.Set("config_path", "test/not-existing.yaml")
+	v.Viper.Set("env_file", path)
+
+	if !assert.NoError(t, v.Viper.BindEnv("log_level", "DOTENV_TEST_LOG_LEVEL")) {
+		return
+	}
+
+	cfg, err := v.BuildConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "debug", cfg.LogLevel)
+
+	v.Viper.Set("env_file", filepath.Join(t.TempDir(), "missing.env"))
+
+	_, err = v.BuildConfig()
+	assert.ErrorIs(t, err, os.ErrNotExist, "a configured env file is required")
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// Redacted replaces the values of the fields holding secrets in the maps of Map.
+const Redacted = "[REDACTED]"
+
+// Map returns cfg as a map keyed by the names of the configuration file, with the durations, byte sizes and URLs as
+// strings such as "10s", "64KiB" and "https://example.com", e.g. to print the configuration of a deployment. With
+// redact, the values of the fields tagged redact:"true", which hold secrets, are replaced with Redacted unless empty,
+// as are the passwords of the URLs. The registered sections are included under their keys.
+func Map(cfg *Config, redact bool) map[string]any {
+	m, _ := toMap(reflect.ValueOf(cfg).Elem(), redact).(map[string]any)
+
+	for k, v := range cfg.sections {
+		m[k] = toMap(reflect.ValueOf(v), redact)
+	}
+
+	return m
+}
+
+func toMap(v reflect.Value, redact bool) any {
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		return x.String()
+	case ByteSize:
+		return x.String()
+	case url.URL:
+		if redact {
+			return x.Redacted()
+		}
+
+		return x.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+
+			name := f.Tag.Get("mapstructure")
+			if name == "" || !f.IsExported() {
+				continue
+			}
+
+			if redact && f.Tag.Get("redact") == "true" && !v.Field(i).IsZero() {
+				m[name] = Redacted
+				continue
+			}
+
+			m[name] = toMap(v.Field(i), redact)
+		}
+
+		return m
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = toMap(iter.Value(), redact)
+		}
+
+		return m
+	case reflect.Slice, reflect.Array:
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = toMap(v.Index(i), redact)
+		}
+
+		return s
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return toMap(v.Elem(), redact)
+	default:
+		return v.Interface()
+	}
+}
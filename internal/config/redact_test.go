@@ -0,0 +1,58 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Server:    config.Server{Port: 8080, Timeout: 5 * time.Second},
+		Redis:     config.Redis{Addr: "redis:6379", Password: "hunter2"},
+		Upstreams: map[string]config.Upstream{"photos": {BaseURL: "http://photos", CredentialRef: "token"}},
+	}
+
+	tests := map[string]struct {
+		redact bool
+		want   map[string]any
+	}{
+		"redacted": {
+			redact: true,
+			want:   map[string]any{"password": config.Redacted, "credential_ref": config.Redacted},
+		},
+		"not redacted": {
+			want: map[string]any{"password": "hunter2", "credential_ref": "token"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := config.Map(cfg, tt.redact)
+
+			server := m["server"].(map[string]any)
+			assert.Equal(t, 8080, server["port"])
+			assert.Equal(t, "5s", server["timeout"])
+
+			redis := m["redis"].(map[string]any)
+			assert.Equal(t, "redis:6379", redis["addr"])
+			assert.Equal(t, tt.want["password"], redis["password"])
+
+			es := m["photos"].(map[string]any)["search"].(map[string]any)["elasticsearch"].(map[string]any)
+			assert.Equal(t, "", es["password"], "empty secrets are left empty")
+
+			photos := m["upstreams"].(map[string]any)["photos"].(map[string]any)
+			assert.Equal(t, "http://photos", photos["base_url"])
+			assert.Equal(t, tt.want["credential_ref"], photos["credential_ref"])
+		})
+	}
+}
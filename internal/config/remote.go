@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/twk/skeleton-go-api/internal/secret"
+)
+
+// Providers of Remote.Provider.
+const (
+	RemoteConsul = "consul"
+	RemoteEtcd   = "etcd"
+)
+
+// Defaults of the remote configuration.
+const (
+	defaultRemoteTimeout = 5 * time.Second
+	// DefaultRemoteInterval is the period of the polls of the remote configuration when none is configured.
+	DefaultRemoteInterval = 30 * time.Second
+	maxRemoteErrorBody    = 512
+)
+
+// ErrRemoteKeyNotFound is returned when the key of the remote configuration does not exist.
+var ErrRemoteKeyNotFound = errors.New("remote config key not found")
+
+// remoteStore reads the document of the key of the remote configuration.
+type remoteStore interface {
+	get(ctx context.Context) ([]byte, error)
+}
+
+// newRemoteStore returns the store of cfg, or nil if there is no remote configuration.
+func newRemoteStore(cfg *Remote) (remoteStore, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+
+	if cfg.Endpoint == nil || cfg.Key == "" {
+		return nil, fmt.Errorf("remote config %s requires an endpoint and a key", cfg.Provider)
+	}
+
+	token, err := secret.Resolve(cfg.TokenRef)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving remote config token: %w", err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+	endpoint := strings.TrimSuffix(cfg.Endpoint.String(), "/")
+
+	switch cfg.Provider {
+	case RemoteConsul:
+		return &consulStore{endpoint: endpoint, key: strings.TrimPrefix(cfg.Key, "/"), token: token, httpClient: httpClient}, nil
+	case RemoteEtcd:
+		return &etcdStore{endpoint: endpoint, key: cfg.Key, token: token, httpClient: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("unknown remote config provider %q", cfg.Provider)
+	}
+}
+
+// consulStore reads the key of the KV store of Consul.
+type consulStore struct {
+	endpoint   string
+	key        string
+	token      string
+	httpClient *http.Client
+}
+
+func (s *consulStore) get(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/v1/kv/"+s.key+"?raw", http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul request: %w", err)
+	}
+
+	if s.token != "" {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+
+	return readRemote(s.httpClient, req)
+}
+
+// etcdStore reads the key of etcd through the JSON gateway of its v3 API.
+type etcdStore struct {
+	endpoint   string
+	key        string
+	token      string
+	httpClient *http.Client
+}
+
+func (s *etcdStore) get(ctx context.Context) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(s.key))})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode etcd request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.token != "" {
+		req.Header.Set("Authorization", s.token)
+	}
+
+	b, err := readRemote(s.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		KVs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+
+	if err = json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+
+	if len(resp.KVs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRemoteKeyNotFound, s.key)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(resp.KVs[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode etcd value: %w", err)
+	}
+
+	return value, nil
+}
+
+// readRemote sends req and returns the response body, failing unless the status is 200.
+func readRemote(httpClient *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrRemoteKeyNotFound, req.URL.Redacted())
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteErrorBody))
+		return nil, fmt.Errorf("failed to read remote config: %s: status %d: %s", req.URL.Redacted(), resp.StatusCode,
+			strings.TrimSpace(string(msg)))
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote config: %w", err)
+	}
+
+	return b, nil
+}
+
+// remoteStore returns the store of the remote configuration set by the config file or the environment, or nil if
+// there is none.
+func (vc *Viper) remoteStore() (remoteStore, error) {
+	var cfg Remote
+	if err := decode(vc.Viper.AllSettings()["remote"], &cfg, false); err != nil {
+		return nil, fmt.Errorf("error unmarshalling remote config: %w", err)
+	}
+
+	return newRemoteStore(&cfg)
+}
+
+// mergeRemote merges the remote document over the config file, keeping it to detect its changes.
+func (vc *Viper) mergeRemote(ctx context.Context) error {
+	store, err := vc.remoteStore()
+	if store == nil {
+		return err
+	}
+
+	doc, err := store.get(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading remote config: %w", err)
+	}
+
+	if err = vc.mergeDocument(doc); err != nil {
+		return err
+	}
+
+	vc.remote = doc
+
+	return nil
+}
+
+// mergeDocument merges the YAML or JSON document doc over the config file.
+func (vc *Viper) mergeDocument(doc []byte) error {
+	m := map[string]any{}
+	if err := yaml.Unmarshal(doc, &m); err != nil {
+		return fmt.Errorf("error parsing remote config: %w", err)
+	}
+
+	if err := vc.Viper.MergeConfigMap(m); err != nil {
+		return fmt.Errorf("error merging remote config: %w", err)
+	}
+
+	return nil
+}
+
+// ReloadRemote reads the remote configuration and, if it changed since the configuration was last built, rebuilds the
+// configuration with it. It returns nil without error when it did not change.
+func (vc *Viper) ReloadRemote(ctx context.Context) (*Config, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	store, err := vc.remoteStore()
+	if store == nil {
+		return nil, err
+	}
+
+	doc, err := store.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading remote config: %w", err)
+	}
+
+	if bytes.Equal(doc, vc.remote) {
+		return nil, nil
+	}
+
+	// The document is merged over the config file read again, so that the keys it no longer sets are reverted.
+	if err = vc.readFile(); err != nil {
+		return nil, err
+	}
+
+	if err = vc.mergeDocument(doc); err != nil {
+		return nil, err
+	}
+
+	vc.remote = doc
+
+	return vc.unmarshall()
+}
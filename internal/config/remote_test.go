@@ -0,0 +1,141 @@
+package config_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// kvStore is a fake Consul and etcd holding one document.
+type kvStore struct {
+	mu    sync.Mutex
+	key   string
+	doc   string
+	token string
+}
+
+func (s *kvStore) set(doc string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.doc = doc
+}
+
+func (s *kvStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/kv/" + s.key:
+		if r.Header.Get("X-Consul-Token") != s.token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Write([]byte(s.doc))
+	case "/v3/kv/range":
+		var req struct {
+			Key string `json:"key"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Key != base64.StdEncoding.EncodeToString([]byte(s.key)) {
+			w.Write([]byte(`{"header":{}}`))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"kvs": []map[string]string{{"value": base64.StdEncoding.EncodeToString([]byte(s.doc))}}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestViper_Remote(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"consul": config.RemoteConsul,
+		"etcd":   config.RemoteEtcd,
+	}
+
+	for name, provider := range tests {
+		provider := provider
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &kvStore{key: "config/skeleton-go-api", doc: "log_level: debug\nfeatures:\n  flags:\n    new_search: true\n", token: "secret"}
+			srv := httptest.NewServer(store)
+			t.Cleanup(srv.Close)
+
+			v := config.NewViper()
+			v.Viper.Set("config_path", "test/config.yaml")
+			v.Viper.Set("remote.provider", provider)
+			v.Viper.Set("remote.endpoint", srv.URL)
+			v.Viper.Set("remote.key", "config/skeleton-go-api")
+			v.Viper.Set("remote.token_ref", "secret")
+
+			cfg, err := v.BuildConfig()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, "debug", cfg.LogLevel, "the remote configuration is merged over the file")
+			assert.True(t, cfg.Stacktrace, "the keys of the file are kept")
+			assert.Equal(t, map[string]bool{"new_search": true}, cfg.Features.Flags)
+
+			cfg, err = v.ReloadRemote(context.Background())
+			assert.NoError(t, err)
+			assert.Nil(t, cfg, "unchanged")
+
+			store.set(`{"features": {"flags": {"new_search": false}}}`)
+
+			cfg, err = v.ReloadRemote(context.Background())
+			if !assert.NoError(t, err) || !assert.NotNil(t, cfg) {
+				return
+			}
+
+			assert.Equal(t, "info", cfg.LogLevel, "the keys no longer set remotely are reverted")
+			assert.Equal(t, map[string]bool{"new_search": false}, cfg.Features.Flags)
+		})
+	}
+}
+
+func TestViper_Remote_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(&kvStore{key: "config/skeleton-go-api", doc: "log_level: debug\n"})
+	t.Cleanup(srv.Close)
+
+	tests := map[string]map[string]string{
+		"unknown provider": {"remote.provider": "zookeeper", "remote.endpoint": srv.URL, "remote.key": "config/skeleton-go-api"},
+		"no key":           {"remote.provider": config.RemoteConsul, "remote.endpoint": srv.URL},
+		"missing key":      {"remote.provider": config.RemoteConsul, "remote.endpoint": srv.URL, "remote.key": "config/other"},
+		"missing etcd key": {"remote.provider": config.RemoteEtcd, "remote.endpoint": srv.URL, "remote.key": "config/other"},
+	}
+
+	for name, settings := range tests {
+		settings := settings
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v := config.NewViper()
+			v.Viper.Set("config_path", "test/config.yaml")
+
+			for k, val := range settings {
+				v.Viper.Set(k, val)
+			}
+
+			_, err := v.BuildConfig()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Section is a section of the configuration owned by a subsystem, decoded into its own type T rather than a field of
+// Config. The subsystem declares it with NewSection and registers it with Viper.Register before the configuration is
+// built; it then reads its settings from the built configuration with Get.
+type Section[T any] struct {
+	key      string
+	defaults T
+	validate func(*T) error
+}
+
+// NewSection declares the section under key of the configuration file, e.g. "watchdog". The fields of T are named by
+// their mapstructure tags, as those of Config, and may be tagged required. defaults are the values of the settings set
+// nowhere else, rather than default tags, and validate, which may be nil, checks the decoded section.
+func NewSection[T any](key string, defaults T, validate func(*T) error) *Section[T] {
+	return &Section[T]{key: key, defaults: defaults, validate: validate}
+}
+
+// Key returns the key of the section in the configuration file.
+func (s *Section[T]) Key() string {
+	return s.key
+}
+
+// Get returns the section of cfg. A configuration not built by a Viper the section is registered with, such as one
+// of a test, has the defaults.
+func (s *Section[T]) Get(cfg *Config) *T {
+	if v, ok := cfg.sections[s.key].(*T); ok {
+		return v
+	}
+
+	d := s.defaults
+
+	return &d
+}
+
+// Set validates v and sets it as the section of cfg, for a configuration built in code rather than by a Viper, e.g.
+// one passed to the application as is. Its error is prefixed with the key of the section.
+func (s *Section[T]) Set(cfg *Config, v T) error {
+	if err := s.check(&v); err != nil {
+		return fmt.Errorf("%s: %w", s.key, err)
+	}
+
+	if cfg.sections == nil {
+		cfg.sections = map[string]any{}
+	}
+
+	cfg.sections[s.key] = &v
+
+	return nil
+}
+
+func (s *Section[T]) defaultSettings() map[string]any {
+	m, _ := toMap(reflect.ValueOf(&s.defaults).Elem(), false).(map[string]any)
+	return m
+}
+
+// decode decodes and validates the section of input, returning it with the valid settings on error.
+func (s *Section[T]) decode(input any, errorUnused bool) (any, error) {
+	var v T
+
+	if err := decode(input, &v, errorUnused); err != nil {
+		return &v, err
+	}
+
+	return &v, s.check(&v)
+}
+
+// check checks the required settings of v and validates it.
+func (s *Section[T]) check(v *T) error {
+	if err := checkRequired(v); err != nil {
+		return err
+	}
+
+	if s.validate != nil {
+		return s.validate(v)
+	}
+
+	return nil
+}
+
+// section is a Section of any type.
+type section interface {
+	Key() string
+	defaultSettings() map[string]any
+	decode(input any, errorUnused bool) (any, error)
+}
+
+// Register registers the sections s of the subsystems, setting their defaults. The sections are decoded and validated
+// each on their own when the configuration is built, and are left out of Config. It has to be called before the
+// configuration is built, and panics if a key is registered twice or is a field of Config.
+func (vc *Viper) Register(s ...section) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	if vc.sections == nil {
+		vc.sections = map[string]section{}
+	}
+
+	for _, sec := range s {
+		if _, ok := vc.sections[sec.Key()]; ok {
+			panic(fmt.Sprintf("config section %q registered twice", sec.Key()))
+		}
+
+		if isConfigKey(sec.Key()) {
+			panic(fmt.Sprintf("config section %q is a field of Config", sec.Key()))
+		}
+
+		vc.sections[sec.Key()] = sec
+
+		for k, d := range sec.defaultSettings() {
+			vc.setDefault(sec.Key()+"."+k, d)
+		}
+	}
+}
+
+// decodeSections decodes the registered sections of settings, removing them from settings. The errors of the sections
+// are prefixed with their key and joined.
+func (vc *Viper) decodeSections(settings map[string]any, errorUnused bool) (map[string]any, error) {
+	if len(vc.sections) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(vc.sections))
+	for k := range vc.sections {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	sections := make(map[string]any, len(keys))
+
+	var errs []error
+
+	for _, k := range keys {
+		v, err := vc.sections[k].decode(settings[k], errorUnused)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", k, err))
+		}
+
+		sections[k] = v
+
+		delete(settings, k)
+	}
+
+	return sections, errors.Join(errs...)
+}
+
+// isConfigKey reports whether key is the key of a field of Config.
+func isConfigKey(key string) bool {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("mapstructure") == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+type cacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	TTL     time.Duration `mapstructure:"ttl"`
+	Size    int           `mapstructure:"size"`
+	Token   string        `mapstructure:"token" redact:"true"`
+}
+
+func newCacheSection() *config.Section[cacheConfig] {
+	return config.NewSection("cache", cacheConfig{TTL: time.Minute, Size: 100}, func(c *cacheConfig) error {
+		if c.Size < 0 {
+			return errors.New("size must not be negative")
+		}
+
+		return nil
+	})
+}
+
+func TestSection_Get(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		cache *cacheConfig
+		err   string
+	}
+
+	tests := map[string]struct {
+		file     string
+		settings map[string]any
+		want     want
+	}{
+		"defaults": {
+			want: want{cache: &cacheConfig{TTL: time.Minute, Size: 100}},
+		},
+		"file": {
+			file: "cache:\n  enabled: true\n  ttl: 5m\n",
+			want: want{cache: &cacheConfig{Enabled: true, TTL: 5 * time.Minute, Size: 100}},
+		},
+		"override": {
+			file:     "cache:\n  size: 10\n",
+			settings: map[string]any{"cache.size": "20"},
+			want:     want{cache: &cacheConfig{TTL: time.Minute, Size: 20}},
+		},
+		"invalid": {
+			file: "cache:\n  size: -1\n",
+			want: want{err: "cache: size must not be negative"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if !assert.NoError(t, os.WriteFile(path, []byte(tt.file), 0o600)) {
+				return
+			}
+
+			s := newCacheSection()
+
+			v := config.NewViper()
+			v.Register(s)
+			v.Viper.Set("config_path", path)
+
+			for k, val := range tt.settings {
+				v.Viper.Set(k, val)
+			}
+
+			cfg, err := v.BuildConfig()
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want.cache, s.Get(cfg))
+		})
+	}
+}
+
+func TestSection_GetUnregistered(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, &cacheConfig{TTL: time.Minute, Size: 100}, newCacheSection().Get(&config.Config{}))
+}
+
+func TestSection_Set(t *testing.T) {
+	t.Parallel()
+
+	s := newCacheSection()
+	cfg := &config.Config{}
+
+	assert.EqualError(t, s.Set(cfg, cacheConfig{Size: -1}), "cache: size must not be negative")
+	assert.Equal(t, &cacheConfig{TTL: time.Minute, Size: 100}, s.Get(cfg))
+
+	assert.NoError(t, s.Set(cfg, cacheConfig{Enabled: true, Size: 10}))
+	assert.Equal(t, &cacheConfig{Enabled: true, Size: 10}, s.Get(cfg))
+	assert.Equal(t, map[string]any{"enabled": true, "ttl": "0s", "size": 10, "token": ""}, config.Map(cfg, true)["cache"])
+}
+
+func TestViper_ValidateSections(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(path, []byte("log_level: info\ncache:\n  sise: 10\n  token: secret\n"), 0o600)) {
+		return
+	}
+
+	s := newCacheSection()
+
+	v := config.NewViper()
+	v.Register(s)
+	v.Viper.Set("config_path", path)
+
+	cfg, err := v.Validate()
+	if !assert.Error(t, err) {
+		return
+	}
+
+	assert.ErrorContains(t, err, "cache: ")
+	assert.ErrorContains(t, err, "sise")
+	assert.NotContains(t, err.Error(), "invalid keys: cache", "the section is not a key of Config")
+	assert.Equal(t, "info", cfg.LogLevel)
+
+	m := config.Map(cfg, true)
+	assert.Equal(t, map[string]any{"enabled": false, "ttl": "1m0s", "size": 100, "token": config.Redacted}, m["cache"])
+}
+
+func TestViper_Register(t *testing.T) {
+	t.Parallel()
+
+	v := config.NewViper()
+	v.Register(newCacheSection())
+
+	assert.Panics(t, func() { v.Register(newCacheSection()) }, "registered twice")
+	assert.Panics(t, func() { v.Register(config.NewSection("server", struct{}{}, nil)) }, "field of Config")
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The fields of Config are tagged default:"value" to be value when set nowhere else, rather than defaulting both
+// their flag and the code using them, and required:"true" to fail the build of the configuration when left empty.
+// Defaults apply to the fields of structs only: the elements of maps and lists, such as the upstreams, have none, but
+// their required fields are checked.
+
+// setTagDefaults sets the defaults of the fields of t tagged default with set, under the key prefix.
+func setTagDefaults(set func(key string, value any), prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := f.Tag.Get("mapstructure")
+		if name == "" || !f.IsExported() {
+			continue
+		}
+
+		key := joinKey(prefix, name)
+
+		if d, ok := f.Tag.Lookup("default"); ok {
+			set(key, d)
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			setTagDefaults(set, key, f.Type)
+		}
+	}
+}
+
+// missingRequired returns the keys of the fields of v tagged required which are empty, under the key prefix. The
+// elements of lists are keyed by their index, e.g. server.canaries[0].path.
+func missingRequired(prefix string, v reflect.Value) []string {
+	switch v.Kind() {
+	case reflect.Struct:
+		var missing []string
+
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+
+			name := f.Tag.Get("mapstructure")
+			if name == "" || !f.IsExported() {
+				continue
+			}
+
+			key := joinKey(prefix, name)
+
+			if f.Tag.Get("required") == "true" && v.Field(i).IsZero() {
+				missing = append(missing, key)
+				continue
+			}
+
+			missing = append(missing, missingRequired(key, v.Field(i))...)
+		}
+
+		return missing
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, k)
+			values[k] = iter.Value()
+		}
+
+		sort.Strings(keys)
+
+		var missing []string
+		for _, k := range keys {
+			missing = append(missing, missingRequired(joinKey(prefix, k), values[k])...)
+		}
+
+		return missing
+	case reflect.Slice, reflect.Array:
+		var missing []string
+		for i := 0; i < v.Len(); i++ {
+			missing = append(missing, missingRequired(fmt.Sprintf("%s[%d]", prefix, i), v.Index(i))...)
+		}
+
+		return missing
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return missingRequired(prefix, v.Elem())
+	default:
+		return nil
+	}
+}
+
+// checkRequired fails with the keys of the required settings of v, a pointer to a struct, which are empty.
+func checkRequired(v any) error {
+	missing := missingRequired("", reflect.ValueOf(v))
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+func TestViper_BuildConfigTags(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		port int
+		err  string
+	}
+
+	tests := map[string]struct {
+		file string
+		want want
+	}{
+		"default": {
+			file: "log_level: info\n",
+			want: want{port: 8080},
+		},
+		"set": {
+			file: "server:\n  port: 9090\n",
+			want: want{port: 9090},
+		},
+		"required of elements": {
+			file: "server:\n  canaries:\n    - method: GET\n      path: /photos\n      upstream: http://canary\n    - path: /albums\n",
+			want: want{err: "missing required settings: server.canaries[1].method, server.canaries[1].upstream"},
+		},
+		"required in list": {
+			file: "chaos:\n  routes:\n    - percent: 50\n",
+			want: want{err: "missing required settings: chaos.routes[0].path"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if !assert.NoError(t, os.WriteFile(path, []byte(tt.file), 0o600)) {
+				return
+			}
+
+			v := config.NewViper()
+			v.Viper.Set("config_path", path)
+
+			cfg, err := v.BuildConfig()
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want.port, cfg.Server.Port)
+		})
+	}
+}
+
+func TestNewViper_ConfigPathDefault(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "./config.yaml", config.NewViper().Viper.GetString("config_path"))
+}
+
+func TestViper_ValidateRequired(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if !assert.NoError(t, os.WriteFile(path, []byte("chaos:\n  routes:\n    - percnt: 50\n"), 0o600)) {
+		return
+	}
+
+	v := config.NewViper()
+	v.Viper.Set("config_path", path)
+
+	_, err := v.Validate()
+	if !assert.Error(t, err) {
+		return
+	}
+
+	assert.ErrorContains(t, err, "percnt")
+	assert.ErrorContains(t, err, "missing required settings: chaos.routes[0].path")
+}
@@ -1,16 +1,33 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"sync"
 
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // Viper defines the structure for holding the viper configuration.
 type Viper struct {
 	Viper *viper.Viper
+
+	// mu serializes the reads of the configuration, as the remote configuration is reloaded while serving.
+	mu sync.Mutex
+	// remote is the last document read from the remote configuration.
+	remote []byte
+	// sections are the registered sections of the subsystems, by key.
+	sections map[string]section
+	// defaults, flags and envs are the defaults, the bound flags and the bound environment variables of the keys, to
+	// tell the source of the settings.
+	defaults map[string]any
+	flags    map[string]*pflag.Flag
+	envs     map[string][]string
 }
 
 // BindDetail defines the structure for holding flag information.
@@ -36,17 +53,32 @@ func NewViper() *Viper {
 	// The order of precedence for the configuration is:
 	// 1. overrides
 	// 2. flags
-	// 3. env. variables
-	// 4. config file
+	// 3. env. variables, then those of the env file
+	// 4. config file, then the remote configuration merged over it
 	// 5. key/value store
-	// 6. defaults
+	// 6. defaults, those of the default tags of Config and of the registered sections
+	vc := &Viper{Viper: v}
+	setTagDefaults(vc.setDefault, "", reflect.TypeOf(Config{}))
+
+	return vc
+}
+
+// setDefault sets the default of key, keeping it to tell the settings differing from their default.
+func (vc *Viper) setDefault(key string, value any) {
+	if vc.defaults == nil {
+		vc.defaults = map[string]any{}
+	}
 
-	return &Viper{Viper: v}
+	vc.defaults[key] = value
+	vc.Viper.SetDefault(key, value)
 }
 
 // BuildConfig will use the Environment variable to decide if it has to use config
 // stored in artifactory or local.
 func (vc *Viper) BuildConfig() (*Config, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
 	err := vc.readConfig()
 	if err != nil {
 		return nil, err // Early return on error
@@ -60,7 +92,21 @@ func (vc *Viper) BuildConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// readConfig reads the config file, then loads the env file, as the config file may set the strictness mode, and
+// merges the remote configuration, which the env file may hold the token of.
 func (vc *Viper) readConfig() error {
+	if err := vc.readFile(); err != nil {
+		return err
+	}
+
+	if err := vc.loadEnvFile(); err != nil {
+		return err
+	}
+
+	return vc.mergeRemote(context.Background())
+}
+
+func (vc *Viper) readFile() error {
 	configPath := vc.Viper.GetString("config_path")
 	vc.Viper.SetConfigFile(configPath)
 
@@ -73,11 +119,51 @@ func (vc *Viper) readConfig() error {
 }
 
 func (vc *Viper) unmarshall() (*Config, error) {
-	cfg := Config{}
+	settings := vc.Viper.AllSettings()
+
+	sections, err := vc.decodeSections(settings, false)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := vc.Viper.Unmarshal(&cfg); err != nil {
+	cfg := Config{sections: sections}
+
+	if err = decode(settings, &cfg, false); err != nil {
 		return nil, fmt.Errorf("error unmarshalling config: %w", err)
 	}
 
+	if err = checkRequired(&cfg); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// Validate builds the configuration as BuildConfig does, also failing on the keys of the file, environment and flags
+// which are not part of the configuration, such as misspelled ones. The errors of all the keys are joined, and the
+// configuration is returned with the valid ones unless the file cannot be read.
+func (vc *Viper) Validate() (*Config, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	if err := vc.readConfig(); err != nil {
+		return nil, err
+	}
+
+	settings := vc.Viper.AllSettings()
+	sections, serr := vc.decodeSections(settings, true)
+	cfg := Config{sections: sections}
+
+	err := decode(settings, &cfg, true)
+
+	var merr *mapstructure.Error
+	if errors.As(err, &merr) {
+		return &cfg, errors.Join(append(merr.WrappedErrors(), checkRequired(&cfg), serr)...)
+	}
+
+	if err != nil {
+		return &cfg, fmt.Errorf("error unmarshalling config: %w", err)
+	}
+
+	return &cfg, errors.Join(checkRequired(&cfg), serr)
+}
@@ -34,6 +34,7 @@ func TestViper_BuildConfig(t *testing.T) {
 					ConfigPath: "test/config.yaml",
 					LogLevel:   "info",
 					Stacktrace: true,
+					Server:     config.Server{Port: 8080},
 				},
 			},
 		},
@@ -44,6 +45,7 @@ func TestViper_BuildConfig(t *testing.T) {
 			want: want{
 				config: &config.Config{
 					ConfigPath: "test/not-existing.yaml",
+					Server:     config.Server{Port: 8080},
 				},
 			},
 		},
@@ -75,3 +77,20 @@ func TestViper_BuildConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestViper_Validate(t *testing.T) {
+	t.Parallel()
+
+	v := config.NewViper()
+	v.Viper.Set("config_path", "test/config.yaml")
+	v.Viper.Set("server.port", "http")
+
+	cfg, err := v.Validate()
+	if !assert.Error(t, err) {
+		return
+	}
+
+	assert.ErrorContains(t, err, "invalid keys: get")
+	assert.ErrorContains(t, err, "server.port")
+	assert.Equal(t, "info", cfg.LogLevel, "the valid keys are decoded")
+}
@@ -0,0 +1,90 @@
+// Package contract checks that the responses of an upstream API still match the JSON Schemas of what the service
+// expects from it, so that the upstream changing its responses is caught before the service breaks in production.
+// The schemas are kept in the repository, next to the code decoding the responses.
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/jsonschema"
+)
+
+// ErrDrift is returned when responses of the upstream violate their schemas.
+var ErrDrift = errors.New("upstream responses do not match their schemas")
+
+type getter interface {
+	Get(ctx context.Context, url string, opts ...httpclient.RequestOption) (*http.Response, error)
+}
+
+// Check is a request to the upstream whose response must match a schema.
+type Check struct {
+	Name string
+	URL  string
+	// Schema is the name of the schema of the response.
+	Schema string
+}
+
+// Result is the outcome of a Check.
+type Result struct {
+	Check      Check
+	Violations []jsonschema.Violation
+	// Err is the failure to get the response or to load the schema.
+	Err error
+}
+
+// Failed reports whether the response of the Check could not be validated or violates its schema.
+func (r *Result) Failed() bool {
+	return r.Err != nil || len(r.Violations) > 0
+}
+
+// Verify sends the requests of checks with c and validates their responses against their schemas in fsys. It returns
+// the results of all checks, and ErrDrift if any failed.
+func Verify(ctx context.Context, c getter, fsys fs.FS, checks []Check) ([]Result, error) {
+	results := make([]Result, len(checks))
+	failed := 0
+
+	for i, check := range checks {
+		results[i] = Result{Check: check}
+		results[i].Violations, results[i].Err = verify(ctx, c, fsys, check)
+
+		if results[i].Failed() {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: %d of %d checks failed", ErrDrift, failed, len(checks))
+	}
+
+	return results, nil
+}
+
+func verify(ctx context.Context, c getter, fsys fs.FS, check Check) ([]jsonschema.Violation, error) {
+	s, err := jsonschema.LoadSchema(fsys, check.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Get(ctx, check.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", check.Name, err)
+	}
+
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+
+	var doc any
+	if err = dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", check.Name, err)
+	}
+
+	return s.Validate(doc), nil
+}
@@ -0,0 +1,61 @@
+package contract_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/contract"
+	"github.com/twk/skeleton-go-api/internal/jsonschema"
+)
+
+var testSchemas = fstest.MapFS{
+	"item.schema.json": {Data: []byte(`{
+		"type": "object",
+		"required": ["id", "url"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"url": {"type": "string", "format": "uri"}
+		}
+	}`)},
+	"items.schema.json": {Data: []byte(`{"type": "array", "items": {"$ref": "item.schema.json"}}`)},
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valid":
+			_, _ = w.Write([]byte(`[{"id": 1, "url": "https://example.com/1"}]`))
+		case "/drifted":
+			_, _ = w.Write([]byte(`[{"id": "1", "url": "https://example.com/1"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	checks := []contract.Check{
+		{Name: "valid", URL: upstream.URL + "/valid", Schema: "items.schema.json"},
+		{Name: "drifted", URL: upstream.URL + "/drifted", Schema: "items.schema.json"},
+		{Name: "missing", URL: upstream.URL + "/missing", Schema: "items.schema.json"},
+	}
+
+	results, err := contract.Verify(context.Background(), client.NewClient(upstream.Client()), testSchemas, checks)
+	assert.ErrorIs(t, err, contract.ErrDrift)
+	assert.EqualError(t, err, "upstream responses do not match their schemas: 2 of 3 checks failed")
+
+	if !assert.Len(t, results, 3) {
+		return
+	}
+
+	assert.False(t, results[0].Failed())
+	assert.Equal(t, []jsonschema.Violation{{Path: "/0/id", Message: "expected integer, got string"}}, results[1].Violations)
+	assert.Error(t, results[2].Err)
+}
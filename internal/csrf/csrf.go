@@ -0,0 +1,175 @@
+// Package csrf protects state-changing endpoints used by browsers against cross-site request forgery with signed
+// double-submit cookies: a token issued in a cookie must be sent back in a header, which a forged cross-site request
+// cannot do. The token is signed with the ID of the session of the request, so that a token obtained by a sibling
+// domain, which could plant it in the cookie, is rejected outside the session it was issued to. Without a session,
+// a planted cookie is not detected.
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/secret"
+	"github.com/twk/skeleton-go-api/internal/session"
+)
+
+// Defaults of the cookie and header names.
+const (
+	DefaultCookieName = "csrf_token"
+	DefaultHeaderName = "X-CSRF-Token"
+)
+
+// tokenSize is the number of random bytes of a token.
+const tokenSize = 32
+
+// errInvalidToken is returned for state-changing requests without a valid token in both the cookie and the header.
+var errInvalidToken = errors.New("missing or invalid csrf token")
+
+// Protection issues and verifies CSRF tokens.
+type Protection struct {
+	key        []byte
+	cookieName string
+	headerName string
+	sameSite   http.SameSite
+	secure     bool
+}
+
+// New creates a Protection from cfg.
+func New(cfg *config.CSRF) (*Protection, error) {
+	if cfg.SigningKey == "" {
+		return nil, errors.New("csrf signing key is required")
+	}
+
+	if err := secret.CheckKey(cfg.SigningKey); err != nil {
+		return nil, fmt.Errorf("invalid csrf signing key: %w", err)
+	}
+
+	sameSite, err := session.ParseSameSite(cfg.SameSite)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Protection{
+		key:        []byte(cfg.SigningKey),
+		cookieName: cfg.CookieName,
+		headerName: cfg.HeaderName,
+		sameSite:   sameSite,
+		secure:     cfg.Secure,
+	}
+
+	if p.cookieName == "" {
+		p.cookieName = DefaultCookieName
+	}
+
+	if p.headerName == "" {
+		p.headerName = DefaultHeaderName
+	}
+
+	return p, nil
+}
+
+// Issue returns a handler setting a new token in the cookie and returning it in the body, for the browser application
+// to send it back in the header of its state-changing requests.
+func (p *Protection) Issue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := p.newToken(sessionID(c))
+		if err != nil {
+			c.Error(apperror.Internal(err)) //nolint:errcheck // returns the same error
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue csrf token"})
+
+			return
+		}
+
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     p.cookieName,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   p.secure,
+			SameSite: p.sameSite,
+		})
+		c.JSON(http.StatusOK, gin.H{"token": token})
+	}
+}
+
+// Middleware rejects state-changing requests with 403 unless the header carries the valid token of the cookie. Safe
+// methods are not checked, and neither are requests with an Authorization header, which browsers never add to
+// cross-site requests by themselves.
+func (p *Protection) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if safeMethod(c.Request.Method) || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(p.cookieName)
+		if err != nil || !hmac.Equal([]byte(cookie), []byte(c.GetHeader(p.headerName))) || !p.valid(cookie, sessionID(c)) {
+			c.Error(apperror.Auth(errInvalidToken)) //nolint:errcheck // returns the same error
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid csrf token"})
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// sessionID returns the ID of the session of the request, or "" without one.
+func sessionID(c *gin.Context) string {
+	if s := session.FromContext(c.Request.Context()); s != nil {
+		return s.ID
+	}
+
+	return ""
+}
+
+// newToken returns a random token signed for the session sid.
+func (p *Protection) newToken(sid string) (string, error) {
+	nonce := make([]byte, tokenSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate csrf token: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(nonce)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(p.sign(sid, encoded)), nil
+}
+
+// valid reports whether token was signed for the session sid.
+func (p *Protection) valid(token, sid string) bool {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+
+	return err == nil && hmac.Equal(got, p.sign(sid, encoded))
+}
+
+func (p *Protection) sign(sid, encoded string) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	// The session ID is base64url, so it cannot contain the separator.
+	mac.Write([]byte(sid + "." + encoded))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,228 @@
+package csrf_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/csrf"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/session"
+)
+
+func newRouter(t *testing.T, cfg *config.CSRF) *gin.Engine {
+	t.Helper()
+
+	p, err := csrf.New(cfg)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(p.Middleware())
+	router.GET("/csrf", p.Issue())
+	router.POST("/uploads", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/uploads", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	return router
+}
+
+// issue returns the cookie and the token returned by the issuance endpoint.
+func issue(t *testing.T, router *gin.Engine) (*http.Cookie, string) {
+	t.Helper()
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/csrf", http.NoBody))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+	cookies := resp.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return nil, ""
+	}
+
+	return cookies[0], body.Token
+}
+
+func TestProtection(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.CSRF{SigningKey: "secret", SameSite: "strict", Secure: true}
+	router := newRouter(t, cfg)
+	cookie, token := issue(t, router)
+
+	assert.Equal(t, csrf.DefaultCookieName, cookie.Name)
+	assert.Equal(t, token, cookie.Value)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+
+	forged, _ := issue(t, newRouter(t, &config.CSRF{SigningKey: "other"}))
+
+	type args struct {
+		method        string
+		cookie        *http.Cookie
+		header        string
+		authorization string
+	}
+
+	tests := map[string]struct {
+		args args
+		want int
+	}{
+		"valid token":             {args: args{method: http.MethodPost, cookie: cookie, header: token}, want: http.StatusOK},
+		"missing header":          {args: args{method: http.MethodPost, cookie: cookie}, want: http.StatusForbidden},
+		"missing cookie":          {args: args{method: http.MethodPost, header: token}, want: http.StatusForbidden},
+		"mismatching header":      {args: args{method: http.MethodPost, cookie: cookie, header: forged.Value}, want: http.StatusForbidden},
+		"cookie signed elsewhere": {args: args{method: http.MethodPost, cookie: forged, header: forged.Value}, want: http.StatusForbidden},
+		"safe method":             {args: args{method: http.MethodGet}, want: http.StatusOK},
+		"token authenticated":     {args: args{method: http.MethodPost, authorization: "Bearer abc"}, want: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.args.method, "/uploads", http.NoBody)
+			if tt.args.cookie != nil {
+				req.AddCookie(tt.args.cookie)
+			}
+
+			if tt.args.header != "" {
+				req.Header.Set(csrf.DefaultHeaderName, tt.args.header)
+			}
+
+			if tt.args.authorization != "" {
+				req.Header.Set("Authorization", tt.args.authorization)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
+
+func TestProtection_Session(t *testing.T) {
+	t.Parallel()
+
+	sm, err := session.New(&config.Session{}, session.NewMemoryStore(), logger.NewNop())
+	assert.NoError(t, err)
+
+	p, err := csrf.New(&config.CSRF{SigningKey: "secret"})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(sm.Middleware(), p.Middleware())
+	router.GET("/csrf", func(c *gin.Context) {
+		if _, err := sm.Start(c); err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		p.Issue()(c)
+	})
+	router.POST("/uploads", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	// login returns the session cookie and the csrf cookie and token of a new session.
+	login := func() (*http.Cookie, *http.Cookie, string) {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/csrf", http.NoBody))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body struct {
+			Token string `json:"token"`
+		}
+
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+		var sessionCookie, csrfCookie *http.Cookie
+
+		for _, c := range resp.Result().Cookies() {
+			switch c.Name {
+			case session.DefaultCookieName:
+				sessionCookie = c
+			case csrf.DefaultCookieName:
+				csrfCookie = c
+			}
+		}
+
+		return sessionCookie, csrfCookie, body.Token
+	}
+
+	victim, victimCSRF, victimToken := login()
+	attacker, attackerCSRF, attackerToken := login()
+
+	tests := map[string]struct {
+		session *http.Cookie
+		cookie  *http.Cookie
+		header  string
+		want    int
+	}{
+		"own session":              {session: victim, cookie: victimCSRF, header: victimToken, want: http.StatusOK},
+		"token of another session": {session: victim, cookie: attackerCSRF, header: attackerToken, want: http.StatusForbidden},
+		"token without session":    {cookie: attackerCSRF, header: attackerToken, want: http.StatusForbidden},
+		"attacker session":         {session: attacker, cookie: attackerCSRF, header: attackerToken, want: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/uploads", http.NoBody)
+			if tt.session != nil {
+				req.AddCookie(tt.session)
+			}
+
+			req.AddCookie(tt.cookie)
+			req.Header.Set(csrf.DefaultHeaderName, tt.header)
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg  *config.CSRF
+		want string
+	}{
+		"missing signing key": {cfg: &config.CSRF{}, want: "csrf signing key is required"},
+		"placeholder key":     {cfg: &config.CSRF{SigningKey: "change-me"}, want: `invalid csrf signing key: key is the placeholder "change-me"`},
+		"invalid same site":   {cfg: &config.CSRF{SigningKey: "secret", SameSite: "sometimes"}, want: `unsupported same site mode "sometimes"`},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := csrf.New(tt.cfg)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Package election elects a leader among the replicas of the service, so that background work, such as a scheduler or
+// an outbox relay, runs on one replica at a time. The leadership is held by a Lock, such as a Kubernetes Lease, which
+// the leader renews and another replica takes over once it expires.
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// Metrics of the election, by lock.
+const (
+	// IsLeaderMetric is 1 while the replica leads, and 0 otherwise.
+	IsLeaderMetric = "leader_election_is_leader"
+	// TransitionsMetric counts the leaderships gained and lost by the replica, by transition, "started" or "stopped".
+	TransitionsMetric = "leader_election_transitions_total"
+)
+
+// Defaults of the election timings.
+const (
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+	// releaseTimeout bounds releasing the leadership once Run is done.
+	releaseTimeout = 5 * time.Second
+)
+
+// errRenewDeadline is returned when the leader would keep leading after its lease expired.
+var errRenewDeadline = errors.New("renew deadline must be shorter than the lease duration")
+
+// Lock holds the leadership for one identity at a time.
+type Lock interface {
+	// Name describes the lock in logs and metrics.
+	Name() string
+	// TryAcquire takes the leadership for identity, or renews it when identity holds it, for ttl. It returns false
+	// while another identity holds an unexpired leadership.
+	TryAcquire(ctx context.Context, identity string, ttl time.Duration) (bool, error)
+	// Release gives the leadership up when identity holds it, so that another replica takes it over without waiting
+	// for the expiry.
+	Release(ctx context.Context, identity string) error
+}
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+	Set(name string, v float64, labels metrics.Labels)
+}
+
+// Elector campaigns for the leadership of a Lock, running the OnStartedLeading callbacks while it leads.
+type Elector struct {
+	lock          Lock
+	identity      string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+	rec           recorder
+	log           *logger.Logger
+	started       []func(ctx context.Context)
+	stopped       []func()
+	now           func() time.Time
+
+	leader atomic.Bool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option configures optional behaviour of the Elector.
+type Option func(*Elector)
+
+// OnStartedLeading registers fn to run in its own goroutine whenever the replica gains the leadership. The context of
+// fn is cancelled when the leadership is lost, and fn must return then.
+func OnStartedLeading(fn func(ctx context.Context)) Option {
+	return func(e *Elector) {
+		e.started = append(e.started, fn)
+	}
+}
+
+// OnStoppedLeading registers fn to run whenever the replica loses the leadership, once the OnStartedLeading callbacks
+// returned.
+func OnStoppedLeading(fn func()) Option {
+	return func(e *Elector) {
+		e.stopped = append(e.stopped, fn)
+	}
+}
+
+// New creates an Elector campaigning for lock with the timings and identity of cfg, recording its state with rec.
+func New(cfg *config.Election, lock Lock, rec recorder, l *logger.Logger, opts ...Option) (*Elector, error) {
+	e := &Elector{
+		lock:          lock,
+		identity:      cfg.Identity,
+		leaseDuration: orDefault(cfg.LeaseDuration, defaultLeaseDuration),
+		renewDeadline: orDefault(cfg.RenewDeadline, defaultRenewDeadline),
+		retryPeriod:   orDefault(cfg.RetryPeriod, defaultRetryPeriod),
+		rec:           rec,
+		log:           l,
+		now:           time.Now,
+	}
+
+	if e.renewDeadline >= e.leaseDuration {
+		return nil, errRenewDeadline
+	}
+
+	if e.identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get election identity: %w", err)
+		}
+
+		e.identity = hostname
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e, nil
+}
+
+// orDefault returns d, or def when d is not positive.
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d
+}
+
+// Identity returns the identity of the replica in the election.
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// IsLeader reports whether the replica currently leads.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns every retry period until ctx is done, then steps down and releases the leadership. A leader failing
+// to renew keeps leading until the renew deadline, as the lease is still its own.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.retryPeriod)
+	defer ticker.Stop()
+
+	e.rec.Set(IsLeaderMetric, 0, e.labels())
+
+	var renewed time.Time
+
+	for {
+		held, err := e.lock.TryAcquire(ctx, e.identity, e.leaseDuration)
+
+		switch {
+		case err != nil && ctx.Err() == nil:
+			e.log.Warn("Failed to acquire leadership", zap.String("lock", e.lock.Name()), zap.Error(err))
+		case held:
+			renewed = e.now()
+		}
+
+		leading := held || (err != nil && e.IsLeader() && e.now().Sub(renewed) < e.renewDeadline)
+		if leading != e.IsLeader() {
+			if leading {
+				e.start(ctx)
+			} else {
+				e.stop()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resign steps down and releases the leadership, if the replica leads.
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+
+	e.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	if err := e.lock.Release(ctx, e.identity); err != nil {
+		e.log.Warn("Failed to release leadership", zap.String("lock", e.lock.Name()), zap.Error(err))
+	}
+}
+
+func (e *Elector) start(ctx context.Context) {
+	e.log.Info("Started leading", zap.String("lock", e.lock.Name()), zap.String("identity", e.identity))
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	e.cancel = cancel
+	e.leader.Store(true)
+	e.record("started", 1)
+
+	for _, fn := range e.started {
+		e.wg.Add(1)
+
+		go func(fn func(ctx context.Context)) {
+			defer e.wg.Done()
+
+			fn(leaderCtx)
+		}(fn)
+	}
+}
+
+func (e *Elector) stop() {
+	e.log.Info("Stopped leading", zap.String("lock", e.lock.Name()), zap.String("identity", e.identity))
+
+	e.leader.Store(false)
+	e.cancel()
+	e.wg.Wait()
+	e.record("stopped", 0)
+
+	for _, fn := range e.stopped {
+		fn()
+	}
+}
+
+func (e *Elector) record(transition string, leader float64) {
+	e.rec.Set(IsLeaderMetric, leader, e.labels())
+	e.rec.Inc(TransitionsMetric, metrics.Labels{"lock": e.lock.Name(), "transition": transition})
+}
+
+func (e *Elector) labels() metrics.Labels {
+	return metrics.Labels{"lock": e.lock.Name()}
+}
@@ -0,0 +1,195 @@
+package election_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/election"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// memoryLock is a Lock held in memory, failing while failing is set.
+type memoryLock struct {
+	mu      sync.Mutex
+	holder  string
+	failing atomic.Bool
+}
+
+func (m *memoryLock) Name() string {
+	return "memory"
+}
+
+func (m *memoryLock) TryAcquire(_ context.Context, identity string, _ time.Duration) (bool, error) {
+	if m.failing.Load() {
+		return false, errors.New("unavailable")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.holder == "" {
+		m.holder = identity
+	}
+
+	return m.holder == identity, nil
+}
+
+func (m *memoryLock) Release(_ context.Context, identity string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.holder == identity {
+		m.holder = ""
+	}
+
+	return nil
+}
+
+var timings = config.Election{LeaseDuration: 200 * time.Millisecond, RenewDeadline: 100 * time.Millisecond, RetryPeriod: 5 * time.Millisecond}
+
+func newElector(t *testing.T, identity string, lock election.Lock, mr *metrics.Registry, opts ...election.Option) *election.Elector {
+	t.Helper()
+
+	cfg := timings
+	cfg.Identity = identity
+
+	e, err := election.New(&cfg, lock, mr, logger.NewNop(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func TestElector_Failover(t *testing.T) {
+	t.Parallel()
+
+	lock := &memoryLock{}
+	mr := metrics.New()
+
+	var (
+		working  atomic.Int32
+		stopped  atomic.Int32
+		startedB = make(chan struct{})
+	)
+
+	work := election.OnStartedLeading(func(ctx context.Context) {
+		working.Add(1)
+		<-ctx.Done()
+		working.Add(-1)
+	})
+
+	a := newElector(t, "a", lock, mr, work, election.OnStoppedLeading(func() { stopped.Add(1) }))
+	b := newElector(t, "b", lock, nil, work, election.OnStartedLeading(func(context.Context) { close(startedB) }))
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+
+	go func() {
+		a.Run(ctxA)
+		close(doneA)
+	}()
+
+	if !assert.Eventually(t, a.IsLeader, time.Second, time.Millisecond) {
+		cancelA()
+		return
+	}
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	go b.Run(ctxB)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, b.IsLeader())
+	assert.Equal(t, int32(1), working.Load())
+
+	cancelA()
+	<-doneA
+
+	assert.False(t, a.IsLeader())
+	assert.Equal(t, int32(1), stopped.Load())
+
+	select {
+	case <-startedB:
+	case <-time.After(time.Second):
+		t.Fatal("b did not take the leadership over")
+	}
+
+	assert.True(t, b.IsLeader())
+
+	resp := httptest.NewRecorder()
+	mr.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	assert.Contains(t, resp.Body.String(), `leader_election_is_leader{lock="memory"} 0`)
+	assert.Contains(t, resp.Body.String(), `leader_election_transitions_total{lock="memory",transition="started"} 1`)
+	assert.Contains(t, resp.Body.String(), `leader_election_transitions_total{lock="memory",transition="stopped"} 1`)
+}
+
+func TestElector_RenewDeadline(t *testing.T) {
+	t.Parallel()
+
+	lock := &memoryLock{}
+	stopped := make(chan time.Time, 1)
+	e := newElector(t, "a", lock, nil, election.OnStoppedLeading(func() { stopped <- time.Now() }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go e.Run(ctx)
+
+	if !assert.Eventually(t, e.IsLeader, time.Second, time.Millisecond) {
+		return
+	}
+
+	failedAt := time.Now()
+	lock.failing.Store(true)
+
+	select {
+	case at := <-stopped:
+		assert.GreaterOrEqual(t, at.Sub(failedAt), timings.RenewDeadline-timings.RetryPeriod)
+		assert.False(t, e.IsLeader())
+	case <-time.After(time.Second):
+		t.Fatal("leader did not step down")
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg     config.Election
+		wantErr bool
+	}{
+		"Defaults":             {cfg: config.Election{Identity: "a"}},
+		"WithoutIdentity":      {cfg: config.Election{}},
+		"RenewDeadlineTooLong": {cfg: config.Election{LeaseDuration: time.Second, RenewDeadline: time.Second}, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := election.New(&tt.cfg, &memoryLock{}, metrics.New(), logger.NewNop())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.NotEmpty(t, e.Identity())
+			}
+		})
+	}
+}
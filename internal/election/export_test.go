@@ -0,0 +1,8 @@
+package election
+
+import "time"
+
+// SetNow sets the clock of k.
+func SetNow(k *KubernetesLock, now func() time.Time) {
+	k.now = now
+}
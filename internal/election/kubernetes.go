@@ -0,0 +1,303 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Defaults of the in-cluster access to the Kubernetes API.
+const (
+	defaultAPIServer  = "https://kubernetes.default.svc"
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// microTimeLayout is the layout of the times of a Lease.
+const microTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+var (
+	// errNoLeaseName is returned when the kubernetes backend is configured without the name of the Lease.
+	errNoLeaseName = errors.New("election lease name is required")
+	// errInvalidCA is returned for a CA file without any PEM certificate.
+	errInvalidCA = errors.New("no certificate found")
+)
+
+// KubernetesLock is a Lock held in a coordination.k8s.io/v1 Lease, updated with optimistic concurrency. As the clocks
+// of the replicas may differ, a Lease expires once it has not changed for its duration since this replica observed it,
+// rather than from its renew time.
+type KubernetesLock struct {
+	hc        *httpclient.Client
+	url       string
+	namespace string
+	name      string
+	now       func() time.Time
+
+	mu         sync.Mutex
+	observed   string
+	observedAt time.Time
+}
+
+// NewKubernetesLock creates a KubernetesLock of the Lease of cfg, sending the requests to the API through a clone of
+// transport trusting the CA of the cluster.
+func NewKubernetesLock(cfg *config.KubernetesLease, transport *http.Transport) (*KubernetesLock, error) {
+	if cfg.Name == "" {
+		return nil, errNoLeaseName
+	}
+
+	namespace := cfg.Namespace
+	if namespace == "" {
+		b, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read namespace: %w", err)
+		}
+
+		namespace = strings.TrimSpace(string(b))
+	}
+
+	apiServer := cfg.APIServer
+	if apiServer == "" {
+		apiServer = defaultAPIServer
+	}
+
+	tokenFile := cfg.TokenFile
+	if tokenFile == "" {
+		tokenFile = serviceAccountDir + "/token"
+	}
+
+	rt, err := withCA(cfg, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KubernetesLock{
+		hc:        httpclient.NewClient(&http.Client{Transport: &bearerToken{next: rt, tokenFile: tokenFile}}),
+		url:       strings.TrimSuffix(apiServer, "/") + "/apis/coordination.k8s.io/v1/namespaces/" + namespace + "/leases",
+		namespace: namespace,
+		name:      cfg.Name,
+		now:       time.Now,
+	}, nil
+}
+
+// withCA returns transport trusting the CA of cfg, or of the in-cluster service account when the API is in-cluster.
+func withCA(cfg *config.KubernetesLease, transport *http.Transport) (http.RoundTripper, error) {
+	caFile := cfg.CAFile
+	if caFile == "" && cfg.APIServer == "" {
+		caFile = serviceAccountDir + "/ca.crt"
+	}
+
+	if caFile == "" {
+		return transport, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubernetes ca file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("invalid kubernetes ca file %s: %w", caFile, errInvalidCA)
+	}
+
+	t := transport.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	t.TLSClientConfig.RootCAs = pool
+
+	return t, nil
+}
+
+// Name implements Lock.
+func (k *KubernetesLock) Name() string {
+	return "kubernetes:" + k.namespace + "/" + k.name
+}
+
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string     `json:"holderIdentity"`
+	LeaseDurationSeconds int        `json:"leaseDurationSeconds"`
+	AcquireTime          *microTime `json:"acquireTime,omitempty"`
+	RenewTime            *microTime `json:"renewTime,omitempty"`
+	LeaseTransitions     int        `json:"leaseTransitions"`
+}
+
+// microTime is a time of a Lease, with microseconds.
+type microTime struct {
+	time.Time
+}
+
+func (t microTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(microTimeLayout)) //nolint:wrapcheck // a string always encodes
+}
+
+func (t *microTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("failed to decode time: %w", err)
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("failed to decode time: %w", err)
+	}
+
+	t.Time = parsed
+
+	return nil
+}
+
+// TryAcquire implements Lock, creating the Lease if it does not exist.
+func (k *KubernetesLock) TryAcquire(ctx context.Context, identity string, ttl time.Duration) (bool, error) {
+	l, err := k.get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := microTime{k.now()}
+	seconds := int(math.Ceil(ttl.Seconds()))
+
+	if l == nil {
+		l = &lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: k.name, Namespace: k.namespace},
+			Spec:       leaseSpec{HolderIdentity: identity, LeaseDurationSeconds: seconds, AcquireTime: &now, RenewTime: &now},
+		}
+
+		return k.write(ctx, http.MethodPost, k.url, l)
+	}
+
+	if l.Spec.HolderIdentity != identity {
+		if l.Spec.HolderIdentity != "" && !k.expired(l) {
+			return false, nil
+		}
+
+		l.Spec.HolderIdentity = identity
+		l.Spec.AcquireTime = &now
+		l.Spec.LeaseTransitions++
+	}
+
+	l.Spec.LeaseDurationSeconds = seconds
+	l.Spec.RenewTime = &now
+
+	return k.write(ctx, http.MethodPut, k.url+"/"+k.name, l)
+}
+
+// Release implements Lock, clearing the holder of the Lease.
+func (k *KubernetesLock) Release(ctx context.Context, identity string) error {
+	l, err := k.get(ctx)
+	if err != nil || l == nil || l.Spec.HolderIdentity != identity {
+		return err
+	}
+
+	now := microTime{k.now()}
+	l.Spec.HolderIdentity = ""
+	l.Spec.LeaseDurationSeconds = 1
+	l.Spec.RenewTime = &now
+
+	_, err = k.write(ctx, http.MethodPut, k.url+"/"+k.name, l)
+
+	return err
+}
+
+// expired reports whether l has not changed for its duration since it was first observed with its resource version.
+func (k *KubernetesLock) expired(l *lease) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if l.Metadata.ResourceVersion != k.observed {
+		k.observed = l.Metadata.ResourceVersion
+		k.observedAt = k.now()
+	}
+
+	return k.now().Sub(k.observedAt) > time.Duration(l.Spec.LeaseDurationSeconds)*time.Second
+}
+
+// get returns the Lease, or nil if it does not exist.
+func (k *KubernetesLock) get(ctx context.Context) (*lease, error) {
+	resp, err := k.hc.Get(ctx, k.url+"/"+k.name, httpclient.WithStatusCodes(http.StatusOK, http.StatusNotFound))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	var l lease
+	if err = json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return nil, fmt.Errorf("failed to decode lease: %w", err)
+	}
+
+	return &l, nil
+}
+
+// write creates or updates the Lease, returning false when another replica changed it first.
+func (k *KubernetesLock) write(ctx context.Context, method, url string, l *lease) (bool, error) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode lease: %w", err)
+	}
+
+	do := k.hc.Put
+	if method == http.MethodPost {
+		do = k.hc.Post
+	}
+
+	resp, err := do(ctx, url, "application/json", bytes.NewReader(b), httpclient.WithStatusCodes(http.StatusOK, http.StatusCreated, http.StatusConflict))
+	if err != nil {
+		return false, fmt.Errorf("failed to write lease: %w", err)
+	}
+
+	resp.Body.Close()
+
+	return resp.StatusCode != http.StatusConflict, nil
+}
+
+// bearerToken authenticates the requests to the API with the token of tokenFile, read on every request as projected
+// service account tokens are rotated.
+type bearerToken struct {
+	next      http.RoundTripper
+	tokenFile string
+}
+
+func (b *bearerToken) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(b.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubernetes token: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	return b.next.RoundTrip(req) //nolint:wrapcheck // errors of the next transport are returned as is
+}
@@ -0,0 +1,198 @@
+package election_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/election"
+)
+
+const leasePath = "/apis/coordination.k8s.io/v1/namespaces/apps/leases"
+
+// fakeAPI is the Leases API of a cluster, keeping one Lease, rejecting updates of stale versions like the API server.
+type fakeAPI struct {
+	mu      sync.Mutex
+	lease   map[string]any
+	version int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == leasePath+"/leader":
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(f.lease)
+	case r.Method == http.MethodPost && r.URL.Path == leasePath:
+		if f.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		f.store(w, r, http.StatusCreated)
+	case r.Method == http.MethodPut && r.URL.Path == leasePath+"/leader":
+		var l map[string]any
+
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &l)
+
+		if l["metadata"].(map[string]any)["resourceVersion"] != strconv.Itoa(f.version) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		f.lease = l
+		f.version++
+		f.lease["metadata"].(map[string]any)["resourceVersion"] = strconv.Itoa(f.version)
+		_ = json.NewEncoder(w).Encode(f.lease)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeAPI) store(w http.ResponseWriter, r *http.Request, status int) {
+	_ = json.NewDecoder(r.Body).Decode(&f.lease)
+	f.version++
+	f.lease["metadata"].(map[string]any)["resourceVersion"] = strconv.Itoa(f.version)
+
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(f.lease)
+}
+
+func (f *fakeAPI) spec() map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.lease["spec"].(map[string]any)
+}
+
+func newKubernetesLock(t *testing.T, url string, now *time.Time) *election.KubernetesLock {
+	t.Helper()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := election.NewKubernetesLock(&config.KubernetesLease{APIServer: url, Namespace: "apps", Name: "leader", TokenFile: tokenFile}, &http.Transport{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	election.SetNow(k, func() time.Time { return *now })
+
+	return k
+}
+
+func TestKubernetesLock(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newKubernetesLock(t, srv.URL, &now)
+	b := newKubernetesLock(t, srv.URL, &now)
+
+	assert.Equal(t, "kubernetes:apps/leader", a.Name())
+
+	held, err := a.TryAcquire(ctx, "a", 15*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, "a", api.spec()["holderIdentity"])
+	assert.Equal(t, "2024-01-01T00:00:00.000000Z", api.spec()["renewTime"])
+
+	held, err = b.TryAcquire(ctx, "b", 15*time.Second)
+	assert.NoError(t, err)
+	assert.False(t, held, "held by a")
+
+	now = now.Add(10 * time.Second)
+	held, err = a.TryAcquire(ctx, "a", 15*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, held, "renewed by a")
+
+	now = now.Add(10 * time.Second)
+	held, err = b.TryAcquire(ctx, "b", 15*time.Second)
+	assert.NoError(t, err)
+	assert.False(t, held, "renewal observed by b 0s ago")
+
+	now = now.Add(16 * time.Second)
+	held, err = b.TryAcquire(ctx, "b", 15*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, held, "expired")
+	assert.Equal(t, "b", api.spec()["holderIdentity"])
+	assert.EqualValues(t, 1, api.spec()["leaseTransitions"])
+
+	held, err = a.TryAcquire(ctx, "a", 15*time.Second)
+	assert.NoError(t, err)
+	assert.False(t, held, "held by b")
+
+	assert.NoError(t, a.Release(ctx, "a"))
+	assert.Equal(t, "b", api.spec()["holderIdentity"], "not released by a")
+
+	assert.NoError(t, b.Release(ctx, "b"))
+	assert.Equal(t, "", api.spec()["holderIdentity"])
+
+	held, err = a.TryAcquire(ctx, "a", 15*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, held, "released")
+	assert.EqualValues(t, 2, api.spec()["leaseTransitions"])
+}
+
+func TestKubernetesLock_Conflict(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	now := time.Now()
+	a := newKubernetesLock(t, srv.URL, &now)
+
+	held, err := a.TryAcquire(ctx, "a", time.Second)
+	if !assert.NoError(t, err) || !assert.True(t, held) {
+		return
+	}
+
+	// Another replica updates the Lease between the read and the write of a.
+	api.version++
+
+	held, err = a.TryAcquire(ctx, "a", time.Second)
+	assert.NoError(t, err)
+	assert.False(t, held)
+}
+
+func TestNewKubernetesLock(t *testing.T) {
+	t.Parallel()
+
+	_, err := election.NewKubernetesLock(&config.KubernetesLease{APIServer: "http://localhost", Namespace: "apps"}, &http.Transport{})
+	assert.Error(t, err, "without name")
+
+	_, err = election.NewKubernetesLock(&config.KubernetesLease{APIServer: "http://localhost", Namespace: "apps", Name: "leader", CAFile: filepath.Join(t.TempDir(), "missing")}, &http.Transport{})
+	assert.Error(t, err, "missing ca")
+}
@@ -0,0 +1,178 @@
+// Package fake generates realistic fake data shaped like the jsonplaceholder resources, for development without the
+// real upstream. Data is deterministic: the same seed always generates the same resources, and each resource only
+// depends on the seed and its ID, so it does not matter in which order resources are generated.
+package fake
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Sizes of the generated data set, the same as jsonplaceholder.
+const (
+	Users          = 10
+	AlbumsPerUser  = 10
+	PhotosPerAlbum = 50
+	Albums         = Users * AlbumsPerUser
+	Photos         = Albums * PhotosPerAlbum
+)
+
+// Base URL of the generated photo images, served by the Upstream.
+const imageBaseURL = "https://via.placeholder.com"
+
+// Resource kinds, mixed into the seed so a photo and an album of the same ID do not share random values.
+const (
+	kindUser = iota + 1
+	kindAlbum
+	kindPhoto
+)
+
+// Sizes of the generated photo images.
+const (
+	photoSize     = 600
+	thumbnailSize = 150
+)
+
+const (
+	// colors is the number of 24-bit RGB colors of the generated images.
+	colors = 1 << 24
+	// Primes spreading the seeds of resources apart.
+	seedPrime = 1_000_003
+	kindPrime = 10_000_019
+	// Bounds of the number of words of titles.
+	minTitleWords = 2
+	maxTitleWords = 8
+)
+
+// Word lists the fake values are picked from.
+const (
+	lorem         = "accusamus beatae ad facilis cum similique qui sunt reprehenderit est necessitatibus officia porro iusto nobis cupiditate officiis omnis laboriosam dolorem repellendus quidem molestiae magnam voluptate consequatur natus fugiat quo eveniet quia dolor sit amet perspiciatis et harum error tempore odit aut nulla"
+	firstNames    = "Leanne Ervin Clementine Patricia Chelsey Dennis Kurtis Nicholas Glenna Clementina Mara Theo Ines Oskar Priya"
+	lastNames     = "Graham Howell Bauch Lebsack Dietrich Schulist Weissnat Runolfsdottir Reichert DuBuque Okafor Lindqvist Moreau Tanaka"
+	decimalDigits = "0123456789"
+	domains       = "april.biz melissa.tv yesenia.net kory.org annie.ca jasper.info billy.biz rosamond.me dana.io elvis.io"
+)
+
+// Photo is a photo, shaped like the jsonplaceholder photos.
+type Photo struct {
+	AlbumID      int    `json:"albumId"`
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+}
+
+// Album is an album of photos, shaped like the jsonplaceholder albums.
+type Album struct {
+	UserID int    `json:"userId"`
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+}
+
+// User is an owner of albums, shaped like the jsonplaceholder users.
+type User struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Website  string `json:"website"`
+}
+
+// Generator generates the fake resources of a seed.
+type Generator struct {
+	seed int64
+}
+
+// NewGenerator creates a Generator for seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{seed: seed}
+}
+
+// Photo returns the photo with the given ID, in the album of its ID range like jsonplaceholder.
+func (g *Generator) Photo(id int) Photo {
+	r := g.rand(kindPhoto, id)
+	color := fmt.Sprintf("%06x", r.Intn(colors))
+
+	return Photo{
+		AlbumID:      (id-1)/PhotosPerAlbum + 1,
+		ID:           id,
+		Title:        sentence(r, minTitleWords, maxTitleWords),
+		URL:          fmt.Sprintf("%s/%d/%s", imageBaseURL, photoSize, color),
+		ThumbnailURL: fmt.Sprintf("%s/%d/%s", imageBaseURL, thumbnailSize, color),
+	}
+}
+
+// AlbumPhotos returns the photos of an album.
+func (g *Generator) AlbumPhotos(albumID int) []Photo {
+	if albumID < 1 || albumID > Albums {
+		return []Photo{}
+	}
+
+	p := make([]Photo, 0, PhotosPerAlbum)
+	for id := (albumID-1)*PhotosPerAlbum + 1; id <= albumID*PhotosPerAlbum; id++ {
+		p = append(p, g.Photo(id))
+	}
+
+	return p
+}
+
+// Album returns the album with the given ID.
+func (g *Generator) Album(id int) Album {
+	return Album{
+		UserID: (id-1)/AlbumsPerUser + 1,
+		ID:     id,
+		Title:  sentence(g.rand(kindAlbum, id), minTitleWords, maxTitleWords),
+	}
+}
+
+// User returns the user with the given ID.
+func (g *Generator) User(id int) User {
+	r := g.rand(kindUser, id)
+	first := pick(r, firstNames)
+	last := pick(r, lastNames)
+	username := fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), id)
+	domain := pick(r, domains)
+
+	return User{
+		ID:       id,
+		Name:     first + " " + last,
+		Username: username,
+		Email:    username + "@" + domain,
+		Phone:    digits(r, "1-###-###-####"),
+		Website:  strings.ToLower(last) + "." + domain,
+	}
+}
+
+func (g *Generator) rand(kind, id int) *rand.Rand {
+	return rand.New(rand.NewSource(g.seed*seedPrime + int64(kind)*kindPrime + int64(id))) //nolint:gosec // fake data, not security sensitive
+}
+
+// pick returns a random word of the space separated list.
+func pick(r *rand.Rand, list string) string {
+	words := strings.Fields(list)
+	return words[r.Intn(len(words))]
+}
+
+// digits replaces each # of pattern with a random digit.
+func digits(r *rand.Rand, pattern string) string {
+	b := []byte(pattern)
+	for i := range b {
+		if b[i] == '#' {
+			b[i] = decimalDigits[r.Intn(len(decimalDigits))]
+		}
+	}
+
+	return string(b)
+}
+
+// sentence returns between minWords and maxWords lorem ipsum words, like the titles of jsonplaceholder.
+func sentence(r *rand.Rand, minWords, maxWords int) string {
+	words := make([]string, minWords+r.Intn(maxWords-minWords+1))
+	for i := range words {
+		words[i] = pick(r, lorem)
+	}
+
+	return strings.Join(words, " ")
+}
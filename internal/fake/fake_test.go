@@ -0,0 +1,40 @@
+package fake_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/fake"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(42)
+
+	assert.Equal(t, g.Photo(7), fake.NewGenerator(42).Photo(7), "same seed, same photo")
+	assert.NotEqual(t, g.Photo(7), fake.NewGenerator(43).Photo(7), "other seed, other photo")
+	assert.NotEqual(t, g.Photo(7).Title, g.Photo(8).Title)
+
+	p := g.Photo(51)
+	assert.Equal(t, 2, p.AlbumID)
+	assert.Regexp(t, `^https://via.placeholder.com/600/[0-9a-f]{6}$`, p.URL)
+	assert.Regexp(t, `^https://via.placeholder.com/150/[0-9a-f]{6}$`, p.ThumbnailURL)
+	assert.NotEmpty(t, p.Title)
+
+	photos := g.AlbumPhotos(2)
+	assert.Len(t, photos, fake.PhotosPerAlbum)
+	assert.Equal(t, p, photos[0])
+	assert.Empty(t, g.AlbumPhotos(fake.Albums+1))
+
+	a := g.Album(11)
+	assert.Equal(t, 2, a.UserID)
+	assert.NotEmpty(t, a.Title)
+
+	u := g.User(3)
+	assert.Equal(t, 3, u.ID)
+	assert.Regexp(t, `^\w+ \w+$`, u.Name)
+	assert.Regexp(t, `^[a-z]+\.[a-z]+3@[a-z]+\.[a-z]+$`, u.Email)
+	assert.Regexp(t, `^1-\d{3}-\d{3}-\d{4}$`, u.Phone)
+	assert.Equal(t, u, fake.NewGenerator(42).User(3))
+}
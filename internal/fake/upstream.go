@@ -0,0 +1,164 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// maxImageSize bounds the side of the generated images, in pixels.
+const maxImageSize = 2000
+
+// Upstream is an http.Handler serving the generated resources at the paths of jsonplaceholder, and solid color PNG
+// images at the paths of the photo URLs.
+type Upstream struct {
+	gen *Generator
+	mux *http.ServeMux
+}
+
+// NewUpstream creates an Upstream serving the resources of g.
+func NewUpstream(g *Generator) *Upstream {
+	u := &Upstream{gen: g, mux: http.NewServeMux()}
+	u.mux.HandleFunc("GET /photos", u.listPhotos)
+	u.mux.HandleFunc("POST /photos", createPhoto)
+	u.mux.HandleFunc("GET /photos/{id}", byID(Photos, func(id int) any { return g.Photo(id) }))
+	u.mux.HandleFunc("GET /albums/{id}", byID(Albums, func(id int) any { return g.Album(id) }))
+	u.mux.HandleFunc("GET /users/{id}", byID(Users, func(id int) any { return g.User(id) }))
+	u.mux.HandleFunc("GET /{size}/{color}", u.image)
+
+	return u
+}
+
+// ServeHTTP implements http.Handler.
+func (u *Upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mux.ServeHTTP(w, r)
+}
+
+// NewTransport returns an http.RoundTripper answering every request with h in process, whatever its host, so a client
+// configured for the real upstream talks to h instead.
+func NewTransport(h http.Handler) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		resp := rec.Result()
+		resp.Request = req
+
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func byID(count int, get func(id int) any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id < 1 || id > count {
+			writeJSON(w, http.StatusNotFound, struct{}{})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, get(id))
+	}
+}
+
+// listPhotos lists the photos of the id parameters, of the albumId parameter, or all photos, paginated by the _page and _limit parameters
+// like jsonplaceholder.
+func (u *Upstream) listPhotos(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Only the photos of the page are generated.
+	var ids []int
+
+	if q.Has("id") {
+		for _, v := range q["id"] {
+			if id, err := strconv.Atoi(v); err == nil && id >= 1 && id <= Photos {
+				ids = append(ids, id)
+			}
+		}
+	} else if albumID, err := strconv.Atoi(q.Get("albumId")); err == nil {
+		if albumID >= 1 && albumID <= Albums {
+			ids = idRange((albumID-1)*PhotosPerAlbum+1, albumID*PhotosPerAlbum)
+		}
+	} else {
+		ids = idRange(1, Photos)
+	}
+
+	page, _ := strconv.Atoi(q.Get("_page"))
+	limit, _ := strconv.Atoi(q.Get("_limit"))
+
+	if page > 0 && limit > 0 {
+		start := min((page-1)*limit, len(ids))
+		ids = ids[start:min(start+limit, len(ids))]
+	}
+
+	all := make([]Photo, 0, len(ids))
+	for _, id := range ids {
+		all = append(all, u.gen.Photo(id))
+	}
+
+	writeJSON(w, http.StatusOK, all)
+}
+
+// idRange returns the IDs from first to last.
+func idRange(first, last int) []int {
+	ids := make([]int, 0, last-first+1)
+	for id := first; id <= last; id++ {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// createPhoto echoes the photo of the request with the ID following the generated ones, like jsonplaceholder which
+// accepts creations without storing them.
+func createPhoto(w http.ResponseWriter, r *http.Request) {
+	var p Photo
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeJSON(w, http.StatusBadRequest, struct{}{})
+		return
+	}
+
+	p.ID = Photos + 1
+
+	writeJSON(w, http.StatusCreated, p)
+}
+
+// image serves a square PNG of the given size and hex color.
+func (u *Upstream) image(w http.ResponseWriter, r *http.Request) {
+	size, err := strconv.Atoi(r.PathValue("size"))
+	if err != nil || size < 1 || size > maxImageSize {
+		http.NotFound(w, r)
+		return
+	}
+
+	rgb, err := strconv.ParseUint(r.PathValue("color"), 16, 32)
+	if err != nil || len(r.PathValue("color")) != len("rrggbb") {
+		http.NotFound(w, r)
+		return
+	}
+
+	c := color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{c})
+
+	w.Header().Set("Content-Type", "image/png")
+
+	if err = png.Encode(w, img); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode image: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck,errchkjson // the status is already written
+}
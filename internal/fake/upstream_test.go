@@ -0,0 +1,99 @@
+package fake_test
+
+import (
+	"context"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/mapping"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+func TestUpstream(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+
+	type want struct {
+		code int
+		body any
+	}
+
+	tests := map[string]struct {
+		path string
+		want want
+	}{
+		"photo":              {path: "/photos/3", want: want{code: http.StatusOK, body: g.Photo(3)}},
+		"photo not found":    {path: "/photos/5001", want: want{code: http.StatusNotFound, body: struct{}{}}},
+		"album":              {path: "/albums/4", want: want{code: http.StatusOK, body: g.Album(4)}},
+		"user":               {path: "/users/2", want: want{code: http.StatusOK, body: g.User(2)}},
+		"user not found":     {path: "/users/abc", want: want{code: http.StatusNotFound, body: struct{}{}}},
+		"album photos page":  {path: "/photos?albumId=2&_page=2&_limit=20", want: want{code: http.StatusOK, body: g.AlbumPhotos(2)[20:40]}},
+		"album photos last":  {path: "/photos?albumId=2&_page=3&_limit=20", want: want{code: http.StatusOK, body: g.AlbumPhotos(2)[40:]}},
+		"photos by id":       {path: "/photos?id=7&id=2&id=9999", want: want{code: http.StatusOK, body: []fake.Photo{g.Photo(7), g.Photo(2)}}},
+		"album photos after": {path: "/photos?albumId=2&_page=4&_limit=20", want: want{code: http.StatusOK, body: []photos.Photo{}}},
+	}
+
+	u := fake.NewUpstream(g)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := httptest.NewRecorder()
+			u.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			want, err := json.Marshal(tt.want.body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.JSONEq(t, string(want), resp.Body.String())
+		})
+	}
+}
+
+func TestUpstream_Image(t *testing.T) {
+	t.Parallel()
+
+	resp := httptest.NewRecorder()
+	fake.NewUpstream(fake.NewGenerator(1)).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/150/ff8000", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+
+	img, err := png.Decode(resp.Body)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 150, img.Bounds().Dx())
+
+		r, g, b, _ := img.At(10, 10).RGBA()
+		assert.Equal(t, []uint32{0xff, 0x80, 0x00}, []uint32{r >> 8, g >> 8, b >> 8})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(g))})
+	ps := photos.NewService(hc, logger.NewNop())
+
+	p, err := ps.GetPhotos(context.Background(), 12)
+	assert.NoError(t, err)
+	assert.Equal(t, toPhoto(g.Photo(12)), *p)
+
+	all, err := ps.ListAllByAlbum(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, mapping.Slice(g.AlbumPhotos(3), toPhoto), all)
+}
+
+func toPhoto(p fake.Photo) photos.Photo {
+	return photos.Photo{AlbumID: p.AlbumID, ID: p.ID, Title: p.Title, URL: p.URL, ThumbnailURL: p.ThumbnailURL}
+}
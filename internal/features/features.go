@@ -0,0 +1,142 @@
+// Package features evaluates feature flags. A flag has a configured state, which a single request can override with
+// the X-Feature-Overrides header to dark-launch a feature: the overrides only apply to authenticated consumers allowed
+// to use them, so anonymous requests always get the configured state.
+package features
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// OverridesHeader is the request header overriding flags, as a comma separated list of name=on or name=off.
+const OverridesHeader = "X-Feature-Overrides"
+
+type overridesKey struct{}
+
+// Flags holds the configured state of the feature flags.
+type Flags struct {
+	log *logger.Logger
+
+	// mu guards the configuration, updated while serving.
+	mu               sync.RWMutex
+	flags            map[string]bool
+	anyAuthenticated bool
+	allowedSubjects  []string
+}
+
+// New creates Flags from cfg.
+func New(cfg *config.Features, l *logger.Logger) *Flags {
+	f := &Flags{log: l}
+	f.Update(cfg)
+
+	return f
+}
+
+// Update replaces the configuration of the flags with cfg, e.g. once changed remotely.
+func (f *Flags) Update(cfg *config.Features) {
+	flags := make(map[string]bool, len(cfg.Flags))
+	for name, on := range cfg.Flags {
+		flags[strings.ToLower(name)] = on
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flags = flags
+	f.anyAuthenticated = cfg.Overrides.AnyAuthenticated
+	f.allowedSubjects = cfg.Overrides.AllowedSubjects
+}
+
+// Middleware attaches the overrides of the header to the request context. Only known flags can be overridden, other
+// entries are ignored. Whether the consumer may override flags is decided when they are evaluated, once the request is
+// authenticated.
+func (f *Flags) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(OverridesHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		overrides := map[string]bool{}
+
+		for _, entry := range strings.Split(header, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+
+			on, ok := parseState(strings.TrimSpace(value))
+			if !f.known(name) || !ok {
+				f.log.Debug("ignoring feature override", zap.String("entry", entry))
+				continue
+			}
+
+			overrides[name] = on
+		}
+
+		if len(overrides) > 0 {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), overridesKey{}, overrides))
+			logger.EventFromContext(c.Request.Context()).Add(zap.String("feature_overrides", header))
+		}
+
+		c.Next()
+	}
+}
+
+func (f *Flags) known(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	_, ok := f.flags[name]
+
+	return ok
+}
+
+func parseState(s string) (on, ok bool) {
+	switch strings.ToLower(s) {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		b, err := strconv.ParseBool(s)
+		return b, err == nil
+	}
+}
+
+// Enabled reports whether the flag name is enabled for the request of ctx: overridden by the header if the
+// authenticated consumer may override flags, as configured otherwise. Unknown flags are disabled.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	name = strings.ToLower(name)
+
+	f.mu.RLock()
+	on := f.flags[name]
+	f.mu.RUnlock()
+
+	overrides, _ := ctx.Value(overridesKey{}).(map[string]bool)
+
+	override, ok := overrides[name]
+	if !ok || override == on || !f.mayOverride(auth.IdentityFromContext(ctx)) {
+		return on
+	}
+
+	f.log.Info("feature flag overridden", zap.String("flag", name), zap.Bool("enabled", override))
+
+	return override
+}
+
+func (f *Flags) mayOverride(id *auth.Identity) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return id != nil && (f.anyAuthenticated || slices.Contains(f.allowedSubjects, id.Subject))
+}
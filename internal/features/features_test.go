@@ -0,0 +1,109 @@
+package features_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/features"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func TestFlags_Enabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Features{
+		Flags:     map[string]bool{"new-search": false, "legacy-cache": true},
+		Overrides: config.FeatureOverrides{AllowedSubjects: []string{"tester"}},
+	}
+
+	type args struct {
+		cfg    *config.Features
+		id     *auth.Identity
+		header string
+	}
+
+	type want struct {
+		newSearch   bool
+		legacyCache bool
+		unknown     bool
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"configured": {
+			args: args{cfg: cfg, id: &auth.Identity{Subject: "tester"}},
+			want: want{legacyCache: true},
+		},
+		"allowlisted subject": {
+			args: args{cfg: cfg, id: &auth.Identity{Subject: "tester"}, header: "new-search=on, legacy-cache=off"},
+			want: want{newSearch: true},
+		},
+		"other subject": {
+			args: args{cfg: cfg, id: &auth.Identity{Subject: "bob"}, header: "new-search=on"},
+			want: want{legacyCache: true},
+		},
+		"anonymous": {
+			args: args{cfg: &config.Features{Flags: cfg.Flags, Overrides: config.FeatureOverrides{AnyAuthenticated: true}}, header: "new-search=on"},
+			want: want{legacyCache: true},
+		},
+		"any authenticated": {
+			args: args{cfg: &config.Features{Flags: cfg.Flags, Overrides: config.FeatureOverrides{AnyAuthenticated: true}}, id: &auth.Identity{Subject: "bob"}, header: "New-Search=true"},
+			want: want{newSearch: true, legacyCache: true},
+		},
+		"unknown and malformed entries": {
+			args: args{cfg: cfg, id: &auth.Identity{Subject: "tester"}, header: "unknown=on,legacy-cache=maybe,new-search"},
+			want: want{legacyCache: true},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f := features.New(tt.args.cfg, logger.NewNop())
+
+			router := gin.New()
+			router.Use(f.Middleware())
+			router.GET("/", func(c *gin.Context) {
+				if tt.args.id != nil {
+					c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), tt.args.id))
+				}
+			}, func(c *gin.Context) {
+				ctx := c.Request.Context()
+				c.String(http.StatusOK, strconv.FormatBool(f.Enabled(ctx, "new-search"))+" "+
+					strconv.FormatBool(f.Enabled(ctx, "legacy-cache"))+" "+strconv.FormatBool(f.Enabled(ctx, "unknown")))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.args.header != "" {
+				req.Header.Set(features.OverridesHeader, tt.args.header)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			want := strconv.FormatBool(tt.want.newSearch) + " " + strconv.FormatBool(tt.want.legacyCache) + " " + strconv.FormatBool(tt.want.unknown)
+			assert.Equal(t, want, resp.Body.String())
+		})
+	}
+}
+
+func TestFlags_Update(t *testing.T) {
+	t.Parallel()
+
+	f := features.New(&config.Features{Flags: map[string]bool{"new-search": false}}, logger.NewNop())
+	f.Update(&config.Features{Flags: map[string]bool{"New-Search": true}})
+
+	assert.True(t, f.Enabled(context.Background(), "new-search"))
+}
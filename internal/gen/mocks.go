@@ -0,0 +1,238 @@
+// Package gen regenerates the generated code of the module, so that it is produced the same way by everyone and
+// checked in CI. Mocks are declared next to their interfaces with //go:generate mockgen directives and generated by
+// the mockgen binary of go.uber.org/mock.
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Markers of the mock declarations and of the generated mocks.
+const (
+	directive    = "//go:generate mockgen "
+	mockHeader   = "// Code generated by MockGen. DO NOT EDIT."
+	mocksDirName = "mocks"
+)
+
+var (
+	// ErrStale is returned by Mocks in check mode when mocks differ from their interfaces.
+	ErrStale = errors.New("mocks are stale")
+	// ErrOrphaned is returned when generated mocks are not declared by any directive.
+	ErrOrphaned = errors.New("mocks without directive")
+	// errDirective is returned for directives which are not supported.
+	errDirective = errors.New("invalid mockgen directive")
+)
+
+// Mock is a mock file generated from the interfaces of a source file. Paths are relative to the root of the module.
+type Mock struct {
+	Source      string
+	Destination string
+	// Package is the package of the mock, the one of the source with the mock_ prefix.
+	Package string
+}
+
+// Discover returns the mocks declared by the //go:generate mockgen directives of the Go files under root, as
+//
+//	//go:generate mockgen -source=photos.go -destination=mocks/photos_mock.go
+//
+// with paths relative to the file, along with the generated mocks which are not declared.
+func Discover(root string) (mocks []Mock, orphaned []string, err error) {
+	var generated []string
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+
+		declared, isMock, err := scan(root, rel)
+		if err != nil {
+			return err
+		}
+
+		if isMock {
+			generated = append(generated, rel)
+		}
+
+		mocks = append(mocks, declared...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover mocks: %w", err)
+	}
+
+	for _, g := range generated {
+		if !slices.ContainsFunc(mocks, func(m Mock) bool { return m.Destination == g }) {
+			orphaned = append(orphaned, g)
+		}
+	}
+
+	return mocks, orphaned, nil
+}
+
+// scan returns the mocks declared in the file rel of root, and whether it is a generated mock.
+func scan(root, rel string) ([]Mock, bool, error) {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil, false, err //nolint:wrapcheck // wrapped by Discover
+	}
+
+	defer f.Close()
+
+	var mocks []Mock
+
+	s := bufio.NewScanner(f)
+	for first := true; s.Scan(); first = false {
+		line := strings.TrimSpace(s.Text())
+		if first && line == mockHeader {
+			return nil, filepath.Base(filepath.Dir(rel)) == mocksDirName, nil
+		}
+
+		args, ok := strings.CutPrefix(line, directive)
+		if !ok {
+			continue
+		}
+
+		m, err := parseDirective(root, rel, strings.Fields(args))
+		if err != nil {
+			return nil, false, err
+		}
+
+		mocks = append(mocks, m)
+	}
+
+	return mocks, false, s.Err() //nolint:wrapcheck // wrapped by Discover
+}
+
+// parseDirective returns the mock declared by the args of a directive in the file rel of root.
+func parseDirective(root, rel string, args []string) (Mock, error) {
+	fset := flag.NewFlagSet("mockgen", flag.ContinueOnError)
+	fset.SetOutput(io.Discard)
+	source := fset.String("source", "", "")
+	destination := fset.String("destination", "", "")
+
+	if err := fset.Parse(args); err != nil || *source == "" || *destination == "" || fset.NArg() > 0 {
+		return Mock{}, fmt.Errorf("%w in %s: only -source and -destination are supported", errDirective, rel)
+	}
+
+	dir := filepath.Dir(rel)
+	m := Mock{Source: filepath.Join(dir, *source), Destination: filepath.Join(dir, *destination)}
+
+	f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(root, m.Source), nil, parser.PackageClauseOnly)
+	if err != nil {
+		return Mock{}, fmt.Errorf("%w in %s: %w", errDirective, rel, err)
+	}
+
+	m.Package = "mock_" + f.Name.Name
+
+	return m, nil
+}
+
+// Generator generates mocks with a mockgen binary.
+type Generator struct {
+	root    string
+	mockgen string
+}
+
+// NewGenerator creates a Generator of the mocks of the module in root, running the mockgen binary, looked up in the
+// PATH unless it is a path.
+func NewGenerator(root, mockgen string) *Generator {
+	return &Generator{root: root, mockgen: mockgen}
+}
+
+// Render returns the content of m generated from its source, with typed calls, so that the values and functions passed
+// to Return, Do and DoAndReturn are checked by the compiler. The source is passed relative to the root, as the header
+// of the mock names it.
+func (g *Generator) Render(ctx context.Context, m Mock) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, g.mockgen, "-source=./"+filepath.ToSlash(m.Source), "-package="+m.Package, "-typed")
+	cmd.Dir = g.root
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to generate %s: %w: %s", m.Destination, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Mocks generates the mocks of the module and returns the ones which differ from their files. In check mode the files
+// are left as they are and ErrStale is returned if any differ, otherwise they are written. Orphaned mocks fail with
+// ErrOrphaned in both modes, to be removed or declared.
+func (g *Generator) Mocks(ctx context.Context, check bool) ([]string, error) {
+	mocks, orphaned, err := Discover(g.root)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orphaned) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrOrphaned, strings.Join(orphaned, ", "))
+	}
+
+	var stale []string
+
+	for _, m := range mocks {
+		b, err := g.Render(ctx, m)
+		if err != nil {
+			return stale, err
+		}
+
+		path := filepath.Join(g.root, m.Destination)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, b) {
+			continue
+		}
+
+		stale = append(stale, m.Destination)
+
+		if check {
+			continue
+		}
+
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gomnd // permissions of a source tree
+			return stale, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		if err = os.WriteFile(path, b, 0o644); err != nil { //nolint:gomnd // permissions of a source file
+			return stale, fmt.Errorf("failed to write %s: %w", m.Destination, err)
+		}
+	}
+
+	if check && len(stale) > 0 {
+		return stale, fmt.Errorf("%w: %s", ErrStale, strings.Join(stale, ", "))
+	}
+
+	return stale, nil
+}
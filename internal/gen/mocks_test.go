@@ -0,0 +1,143 @@
+package gen_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/gen"
+)
+
+// Directives and headers are built so that this file is not discovered itself.
+const (
+	generate = "//go:" + "generate mockgen "
+	header   = "// Code generated by " + "MockGen. DO NOT EDIT.\n"
+)
+
+// writeFiles writes files, by path relative to a new root, and returns the root.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	return root
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		files        map[string]string
+		wantMocks    []gen.Mock
+		wantOrphaned []string
+		wantErr      bool
+	}{
+		"directives": {
+			files: map[string]string{
+				"a/a.go":            "package a\n\n" + generate + "-source=a.go -destination=mocks/a_mock.go\n",
+				"a/mocks/a_mock.go": header + "package mock_a\n",
+				"b/b.go":            "package bee\n\n" + generate + "-source=b.go -destination=mocks/b_mock.go\n",
+				".git/c.go":         "package c\n\n" + generate + "-source=c.go -destination=mocks/c_mock.go\n",
+			},
+			wantMocks: []gen.Mock{
+				{Source: "a/a.go", Destination: "a/mocks/a_mock.go", Package: "mock_a"},
+				{Source: "b/b.go", Destination: "b/mocks/b_mock.go", Package: "mock_bee"},
+			},
+		},
+		"orphaned": {
+			files: map[string]string{
+				"a/a.go":            "package a\n",
+				"a/mocks/a_mock.go": header + "package mock_a\n",
+			},
+			wantOrphaned: []string{"a/mocks/a_mock.go"},
+		},
+		"unsupported flag": {
+			files:   map[string]string{"a/a.go": "package a\n\n" + generate + "-source=a.go -destination=mocks/a_mock.go -package=m\n"},
+			wantErr: true,
+		},
+		"missing destination": {
+			files:   map[string]string{"a/a.go": "package a\n\n" + generate + "-source=a.go\n"},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mocks, orphaned, err := gen.Discover(writeFiles(t, tt.files))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.wantMocks, mocks)
+			assert.Equal(t, tt.wantOrphaned, orphaned)
+		})
+	}
+}
+
+func TestGenerator_Mocks(t *testing.T) {
+	t.Parallel()
+
+	root := writeFiles(t, map[string]string{
+		"a/a.go":            "package a\n\n" + generate + "-source=a.go -destination=mocks/a_mock.go\n",
+		"a/mocks/a_mock.go": header + "package mock_a\n// stale\n",
+		"b/b.go":            "package b\n\n" + generate + "-source=b.go -destination=mocks/b_mock.go\n",
+		// The fake mockgen prints a mock header and its arguments.
+		"mockgen.sh": "#!/bin/sh\nprintf '" + header[:len(header)-1] + "\\n%s\\n' \"$*\"\n",
+	})
+
+	mockgen := filepath.Join(root, "mockgen.sh")
+	if !assert.NoError(t, os.Chmod(mockgen, 0o700)) {
+		return
+	}
+
+	g := gen.NewGenerator(root, mockgen)
+
+	stale, err := g.Mocks(context.Background(), true)
+	assert.ErrorIs(t, err, gen.ErrStale)
+	assert.Equal(t, []string{"a/mocks/a_mock.go", "b/mocks/b_mock.go"}, stale)
+
+	stale, err = g.Mocks(context.Background(), false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, stale, 2)
+
+	b, err := os.ReadFile(filepath.Join(root, "b", "mocks", "b_mock.go"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, header+"-source=./b/b.go -package=mock_b -typed\n", string(b))
+
+	stale, err = g.Mocks(context.Background(), true)
+	assert.NoError(t, err)
+	assert.Empty(t, stale)
+}
+
+func TestGenerator_Orphaned(t *testing.T) {
+	t.Parallel()
+
+	root := writeFiles(t, map[string]string{"a/mocks/a_mock.go": header + "package mock_a\n"})
+
+	_, err := gen.NewGenerator(root, "mockgen").Mocks(context.Background(), true)
+	assert.ErrorIs(t, err, gen.ErrOrphaned)
+}
@@ -0,0 +1,385 @@
+// Package imports imports photos in bulk from CSV and NDJSON files, in background jobs whose progress is tracked
+// until they finish.
+package imports
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/safego"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// Defaults of the import configuration.
+const (
+	defaultMaxConcurrentJobs = 2
+	defaultMaxRowErrors      = 100
+	defaultRetention         = 24 * time.Hour
+)
+
+// jobIDLen is the number of random bytes of job IDs.
+const jobIDLen = 16
+
+// ErrJobNotFound is returned for unknown jobs, expired ones and the ones of other tenants.
+var ErrJobNotFound = errors.New("import job not found")
+
+// Status is the state of a Job.
+type Status string
+
+// Statuses of a Job. A completed job may have failed rows, a failed job could not read its file.
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// RowError is the reason a row of an import file was not imported. Rows are numbered from 1, not counting the header
+// of CSV files.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// Job is an import of a file and its progress.
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	Format Format `json:"format"`
+	// Size is the size of the file in bytes, and Read how much of it has been parsed.
+	Size int64 `json:"size"`
+	Read int64 `json:"read"`
+	// Rows counts the rows processed so far, Imported and Failed the ones which were and were not imported.
+	Rows     int `json:"rows"`
+	Imported int `json:"imported"`
+	Failed   int `json:"failed"`
+	// Errors are the first row errors, ErrorsTruncated is set when there were more.
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errorsTruncated,omitempty"`
+	// Error is why a failed job could not read its file.
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+
+	tenant string
+}
+
+// Done reports whether the job is finished.
+func (j *Job) Done() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed
+}
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+}
+
+type creator interface {
+	Create(ctx context.Context, p photos.Photo) (*photos.Photo, error)
+}
+
+// Manager runs import jobs in the background, a bounded number at a time, and keeps their progress in memory until
+// the retention after they finish. Jobs are lost on restart.
+type Manager struct {
+	creator  creator
+	log      *logger.Logger
+	validate *validator.Validate
+	// workers run the jobs, a bounded number at a time. Shutdown waits for them.
+	workers   *safego.Group
+	maxErrors int
+	retention time.Duration
+	now       func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// New creates a Manager importing photos with c, counting the panics of the jobs with rec.
+func New(cfg *config.Import, c creator, rec recorder, l *logger.Logger) *Manager {
+	concurrency := cfg.MaxConcurrentJobs
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrentJobs
+	}
+
+	maxErrors := cfg.MaxRowErrors
+	if maxErrors <= 0 {
+		maxErrors = defaultMaxRowErrors
+	}
+
+	retention := cfg.Retention
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		return name
+	})
+
+	return &Manager{
+		creator:   c,
+		log:       l,
+		validate:  v,
+		workers:   safego.NewGroup(l, concurrency, safego.WithName("import"), safego.WithRecorder(rec)),
+		maxErrors: maxErrors,
+		retention: retention,
+		now:       time.Now,
+		jobs:      map[string]*Job{},
+	}
+}
+
+// Start spools the file r of format f to a temporary file and enqueues its import, returning the queued job. The job
+// runs with the values of ctx, such as its tenant, but is not canceled with it.
+func (m *Manager) Start(ctx context.Context, f Format, r io.Reader) (*Job, error) {
+	spool, err := os.CreateTemp("", "photo-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+
+	size, err := io.Copy(spool, r)
+	if err != nil {
+		cleanup(spool)
+		return nil, fmt.Errorf("failed to spool import file: %w", err)
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		cleanup(spool)
+		return nil, err
+	}
+
+	j := &Job{ID: id, Status: StatusQueued, Format: f, Size: size, Errors: []RowError{}, CreatedAt: m.now().UTC(), tenant: tenant.FromContext(ctx)}
+
+	m.mu.Lock()
+	m.prune()
+	m.jobs[id] = j
+	snapshot := j.clone()
+	m.mu.Unlock()
+
+	m.workers.Go(context.WithoutCancel(ctx), func(ctx context.Context) {
+		m.run(ctx, j, spool)
+	})
+
+	return snapshot, nil
+}
+
+// Get returns the job id of the tenant of ctx.
+func (m *Manager) Get(ctx context.Context, id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok || j.tenant != tenant.FromContext(ctx) {
+		return nil, ErrJobNotFound
+	}
+
+	return j.clone(), nil
+}
+
+// Shutdown waits for the queued and running jobs to finish, until ctx is done. Jobs started meanwhile are waited for
+// as well, so it is called once no more jobs are started.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		// The panics of the jobs were logged as they happened.
+		_ = m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("import jobs still running: %w", ctx.Err())
+	}
+}
+
+func (m *Manager) run(ctx context.Context, j *Job, spool *os.File) {
+	defer cleanup(spool)
+	defer func() {
+		// The panic is recovered and logged by the workers, the job fails rather than staying running.
+		if v := recover(); v != nil {
+			m.update(j, func(j *Job) {
+				finished := m.now().UTC()
+				j.Status, j.FinishedAt, j.Error = StatusFailed, &finished, "internal error"
+			})
+
+			panic(v)
+		}
+	}()
+
+	m.update(j, func(j *Job) {
+		started := m.now().UTC()
+		j.Status, j.StartedAt = StatusRunning, &started
+	})
+
+	err := m.importFile(ctx, j, spool)
+
+	m.update(j, func(j *Job) {
+		finished := m.now().UTC()
+		j.Status, j.FinishedAt, j.Read = StatusCompleted, &finished, j.Size
+
+		if err != nil {
+			j.Status, j.Error = StatusFailed, err.Error()
+		}
+	})
+
+	fields := []zap.Field{zap.String("job_id", j.ID), zap.String("format", string(j.Format))}
+
+	snapshot := m.snapshot(j)
+	fields = append(fields, zap.Int("rows", snapshot.Rows), zap.Int("imported", snapshot.Imported), zap.Int("failed", snapshot.Failed))
+
+	if err != nil {
+		m.log.Error("Photo import failed", append(fields, zap.Error(err))...)
+		return
+	}
+
+	m.log.Info("Photo import completed", fields...)
+}
+
+// importFile imports the rows of the spooled file, recording the row errors on j. It fails when the file cannot be
+// read at all.
+func (m *Manager) importFile(ctx context.Context, j *Job, spool *os.File) error {
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+
+	counter := &countingReader{r: spool}
+
+	rr, err := newRowReader(j.Format, counter)
+	if err != nil {
+		return err
+	}
+
+	for n := 1; ; n++ {
+		r, rowErr := rr.next()
+		if errors.Is(rowErr, io.EOF) {
+			return nil
+		}
+
+		if rowErr != nil && !errors.Is(rowErr, errRow) {
+			return rowErr
+		}
+
+		if rowErr == nil {
+			rowErr = m.importRow(ctx, r)
+		}
+
+		m.update(j, func(j *Job) {
+			j.Read, j.Rows = counter.n, n
+			if rowErr == nil {
+				j.Imported++
+				return
+			}
+
+			j.Failed++
+			if len(j.Errors) < m.maxErrors {
+				j.Errors = append(j.Errors, RowError{Row: n, Error: rowErr.Error()})
+			} else {
+				j.ErrorsTruncated = true
+			}
+		})
+	}
+}
+
+func (m *Manager) importRow(ctx context.Context, r row) error {
+	if err := m.validate.Struct(r); err != nil {
+		return violations(err)
+	}
+
+	_, err := m.creator.Create(ctx, photos.Photo{AlbumID: r.AlbumID, Title: r.Title, URL: r.URL, ThumbnailURL: r.ThumbnailURL})
+
+	return err //nolint:wrapcheck // reported as is on the row
+}
+
+// violations describes the fields of a row failing validation, e.g. "invalid row: title required, url url".
+func violations(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return fmt.Errorf("%w: %w", errRow, err)
+	}
+
+	v := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		v = append(v, fe.Field()+" "+fe.Tag())
+	}
+
+	return fmt.Errorf("%w: %s", errRow, strings.Join(v, ", "))
+}
+
+func (m *Manager) update(j *Job, fn func(j *Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn(j)
+}
+
+func (m *Manager) snapshot(j *Job) *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return j.clone()
+}
+
+// prune forgets the jobs finished for longer than the retention. m.mu must be held.
+func (m *Manager) prune() {
+	now := m.now()
+
+	for id, j := range m.jobs {
+		if j.FinishedAt != nil && now.Sub(*j.FinishedAt) > m.retention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (j *Job) clone() *Job {
+	c := *j
+	c.Errors = append([]RowError{}, j.Errors...)
+
+	return &c
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, jobIDLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func cleanup(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err //nolint:wrapcheck // io.Reader errors are returned as is
+}
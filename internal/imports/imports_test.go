@@ -0,0 +1,195 @@
+package imports_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/imports"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+func TestFormatOf(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		contentType string
+		fileName    string
+		want        imports.Format
+		ok          bool
+	}{
+		"csv media type":       {contentType: "text/csv; charset=utf-8", fileName: "photos", want: imports.FormatCSV, ok: true},
+		"ndjson media type":    {contentType: "application/x-ndjson", fileName: "photos", want: imports.FormatNDJSON, ok: true},
+		"csv extension":        {contentType: "application/octet-stream", fileName: "photos.CSV", want: imports.FormatCSV, ok: true},
+		"jsonl extension":      {fileName: "photos.jsonl", want: imports.FormatNDJSON, ok: true},
+		"unsupported":          {contentType: "application/json", fileName: "photos.json"},
+		"unsupported no hints": {},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := imports.FormatOf(tt.contentType, tt.fileName)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		format imports.Format
+		file   string
+	}
+
+	type want struct {
+		status   imports.Status
+		rows     int
+		imported int
+		errors   []imports.RowError
+		err      string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"csv": {
+			args: args{format: imports.FormatCSV, file: "title,albumId,url,thumbnailUrl,extra\n" +
+				"first,1,https://example.com/1.png,https://example.com/1t.png,x\n" +
+				"second,abc,https://example.com/2.png,https://example.com/2t.png,x\n" +
+				",2,not a url,https://example.com/3t.png,x\n" +
+				"fourth,2,https://example.com/4.png\n" +
+				"fifth,3,https://example.com/5.png,https://example.com/5t.png,x\n"},
+			want: want{status: imports.StatusCompleted, rows: 5, imported: 2, errors: []imports.RowError{
+				{Row: 2, Error: `malformed row: invalid albumId "abc"`},
+				{Row: 3, Error: "malformed row: title required, url url"},
+				{Row: 4, Error: "malformed row: record on line 5: wrong number of fields"},
+			}},
+		},
+		"ndjson": {
+			args: args{format: imports.FormatNDJSON, file: `{"albumId":1,"title":"first","url":"https://example.com/1.png","thumbnailUrl":"https://example.com/1t.png"}` + "\n\n" +
+				`{"albumId":1,"title":` + "\n" +
+				`{"albumId":0,"title":"third","url":"https://example.com/3.png","thumbnailUrl":"https://example.com/3t.png"}` + "\n"},
+			want: want{status: imports.StatusCompleted, rows: 3, imported: 1, errors: []imports.RowError{
+				{Row: 2, Error: "malformed row: unexpected end of JSON input"},
+				{Row: 3, Error: "malformed row: albumId required"},
+			}},
+		},
+		"csv missing column": {
+			args: args{format: imports.FormatCSV, file: "albumId,title,url\n1,first,https://example.com/1.png\n"},
+			want: want{status: imports.StatusFailed, errors: []imports.RowError{}, err: "missing column: thumbnailUrl"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(fake.NewGenerator(1)))})
+			m := imports.New(&config.Import{}, photos.NewService(hc, logger.NewNop()), metrics.Nop(), logger.NewNop())
+			ctx := tenant.ContextWithTenant(context.Background(), "acme")
+
+			started, err := m.Start(ctx, tt.args.format, strings.NewReader(tt.args.file))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, imports.StatusQueued, started.Status)
+			assert.Equal(t, int64(len(tt.args.file)), started.Size)
+
+			var j *imports.Job
+
+			assert.Eventually(t, func() bool {
+				j, err = m.Get(ctx, started.ID)
+				return err == nil && j.Done()
+			}, time.Second, 5*time.Millisecond)
+
+			assert.Equal(t, tt.want.status, j.Status)
+			assert.Equal(t, tt.want.rows, j.Rows)
+			assert.Equal(t, tt.want.imported, j.Imported)
+			assert.Equal(t, len(tt.want.errors), j.Failed)
+			assert.Equal(t, tt.want.errors, j.Errors)
+			assert.Equal(t, tt.want.err, j.Error)
+			assert.NotNil(t, j.FinishedAt)
+
+			_, err = m.Get(tenant.ContextWithTenant(context.Background(), "globex"), started.ID)
+			assert.ErrorIs(t, err, imports.ErrJobNotFound)
+		})
+	}
+}
+
+func TestManager_MaxRowErrors(t *testing.T) {
+	t.Parallel()
+
+	m := imports.New(&config.Import{MaxRowErrors: 2}, nil, metrics.Nop(), logger.NewNop())
+
+	started, err := m.Start(context.Background(), imports.FormatNDJSON, strings.NewReader("{}\n{}\n{}\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var j *imports.Job
+
+	assert.Eventually(t, func() bool {
+		j, err = m.Get(context.Background(), started.ID)
+		return err == nil && j.Done()
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, 3, j.Failed)
+	assert.Len(t, j.Errors, 2)
+	assert.True(t, j.ErrorsTruncated)
+}
+
+// blockingCreator creates photos once released.
+type blockingCreator struct {
+	release chan struct{}
+}
+
+func (b *blockingCreator) Create(_ context.Context, p photos.Photo) (*photos.Photo, error) {
+	<-b.release
+	return &p, nil
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	bc := &blockingCreator{release: make(chan struct{})}
+	m := imports.New(&config.Import{}, bc, metrics.Nop(), logger.NewNop())
+	file := `{"albumId":1,"title":"first","url":"https://example.com/1.png","thumbnailUrl":"https://example.com/1t.png"}` + "\n"
+
+	started, err := m.Start(context.Background(), imports.FormatNDJSON, strings.NewReader(file))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, m.Shutdown(ctx), context.DeadlineExceeded, "job running")
+
+	close(bc.release)
+	assert.NoError(t, m.Shutdown(context.Background()))
+
+	j, err := m.Get(context.Background(), started.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, imports.StatusCompleted, j.Status)
+		assert.Equal(t, 1, j.Imported)
+	}
+}
@@ -0,0 +1,167 @@
+package imports
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format is the format of an import file.
+type Format string
+
+// Formats of the import files.
+const (
+	// FormatCSV is a CSV file with a header row naming the columns albumId, title, url and thumbnailUrl.
+	FormatCSV Format = "csv"
+	// FormatNDJSON is a file of one photo JSON object per line.
+	FormatNDJSON Format = "ndjson"
+)
+
+// maxLineSize bounds the lines of NDJSON files.
+const maxLineSize = 1 << 20
+
+// errMissingColumn is returned for CSV files without a required column in their header.
+var errMissingColumn = errors.New("missing column")
+
+// FormatOf returns the format of a file from its media type, or its extension when the media type is not specific.
+func FormatOf(contentType, fileName string) (Format, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/jsonl":
+		return FormatNDJSON, true
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV, true
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, true
+	}
+
+	return "", false
+}
+
+// row is a photo of an import file.
+type row struct {
+	AlbumID      int    `json:"albumId"      validate:"required,min=1"`
+	Title        string `json:"title"        validate:"required"`
+	URL          string `json:"url"          validate:"required,url"`
+	ThumbnailURL string `json:"thumbnailUrl" validate:"required,url"`
+}
+
+// rowReader reads the rows of an import file one at a time, so files are never held in memory. next returns an error
+// wrapping errRow for a malformed row, after which reading can go on, and io.EOF after the last row.
+type rowReader interface {
+	next() (row, error)
+}
+
+// errRow wraps the errors of malformed rows.
+var errRow = errors.New("malformed row")
+
+func newRowReader(f Format, r io.Reader) (rowReader, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		s := bufio.NewScanner(r)
+		s.Buffer(nil, maxLineSize)
+
+		return &ndjsonReader{scanner: s}, nil
+	default:
+		return nil, fmt.Errorf("unknown import format %q", f)
+	}
+}
+
+// csvColumns are the columns of the CSV files, by header name.
+const csvColumns = "albumId title url thumbnailUrl"
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range strings.Fields(csvColumns) {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", errMissingColumn, name)
+		}
+	}
+
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (c *csvReader) next() (row, error) {
+	record, err := c.r.Read()
+
+	var parseErr *csv.ParseError
+
+	switch {
+	case errors.As(err, &parseErr):
+		return row{}, fmt.Errorf("%w: %w", errRow, err)
+	case err != nil:
+		return row{}, err //nolint:wrapcheck // io.EOF or a read error of the file
+	}
+
+	field := func(name string) string {
+		return strings.TrimSpace(record[c.columns[name]])
+	}
+
+	r := row{Title: field("title"), URL: field("url"), ThumbnailURL: field("thumbnailUrl")}
+
+	if v := field("albumId"); v != "" {
+		if r.AlbumID, err = strconv.Atoi(v); err != nil {
+			return row{}, fmt.Errorf("%w: invalid albumId %q", errRow, v)
+		}
+	}
+
+	return r, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (n *ndjsonReader) next() (row, error) {
+	for n.scanner.Scan() {
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var r row
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return row{}, fmt.Errorf("%w: %w", errRow, err)
+		}
+
+		return r, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return row{}, fmt.Errorf("failed to read line: %w", err)
+	}
+
+	return row{}, io.EOF
+}
@@ -0,0 +1,82 @@
+// Package jsonbind binds the JSON bodies of requests with the decoding options of their route, rather than the
+// process wide settings of gin's binding package, so that the strictness of the decoding can differ per endpoint.
+package jsonbind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ErrEmptyBody is returned when binding a request without a body.
+var ErrEmptyBody = errors.New("empty request body")
+
+// Options are the decoding options of the JSON request bodies of a route.
+type Options struct {
+	// DisallowUnknownFields rejects the bodies with fields matching no field of the bound value, such as typos.
+	DisallowUnknownFields bool
+	// UseNumber decodes numbers into interface values as json.Number instead of float64, keeping large integers exact.
+	UseNumber bool
+}
+
+// Strict rejects unknown fields and keeps numbers exact.
+var Strict = Options{DisallowUnknownFields: true, UseNumber: true}
+
+type optionsKey struct{}
+
+// ContextWithOptions returns a copy of ctx carrying the decoding options o.
+func ContextWithOptions(ctx context.Context, o Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, o)
+}
+
+// FromContext returns the decoding options carried by ctx, or the ones of gin's binding package, which app.Configure
+// sets from the strictness mode.
+func FromContext(ctx context.Context) Options {
+	if o, ok := ctx.Value(optionsKey{}).(Options); ok {
+		return o
+	}
+
+	return Options{
+		DisallowUnknownFields: binding.EnableDecoderDisallowUnknownFields,
+		UseNumber:             binding.EnableDecoderUseNumber,
+	}
+}
+
+// Bind decodes the JSON body of the request of c into v with the options of its context, and validates it as
+// ShouldBindJSON does.
+func Bind(c *gin.Context, v any) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return ErrEmptyBody
+	}
+
+	o := FromContext(c.Request.Context())
+
+	dec := json.NewDecoder(c.Request.Body)
+	if o.UseNumber {
+		dec.UseNumber()
+	}
+
+	if o.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrEmptyBody
+		}
+
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	if binding.Validator == nil {
+		return nil
+	}
+
+	return binding.Validator.ValidateStruct(v) //nolint:wrapcheck // the errors name the invalid fields
+}
@@ -0,0 +1,90 @@
+package jsonbind_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/jsonbind"
+)
+
+type request struct {
+	Name  string `json:"name" binding:"required"`
+	Extra any    `json:"extra"`
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		options *jsonbind.Options
+		body    io.Reader
+	}
+
+	type want struct {
+		got request
+		err string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"default": {
+			args: args{body: strings.NewReader(`{"name":"a","nmae":"b","extra":12345678901234567890}`)},
+			want: want{got: request{Name: "a", Extra: 12345678901234567890.0}},
+		},
+		"strict": {
+			args: args{options: &jsonbind.Strict, body: strings.NewReader(`{"name":"a","extra":12345678901234567890}`)},
+			want: want{got: request{Name: "a", Extra: json.Number("12345678901234567890")}},
+		},
+		"strict unknown field": {
+			args: args{options: &jsonbind.Strict, body: strings.NewReader(`{"name":"a","nmae":"b"}`)},
+			want: want{err: `invalid json: json: unknown field "nmae"`},
+		},
+		"invalid": {
+			args: args{body: strings.NewReader(`{"name":`)},
+			want: want{err: "invalid json: unexpected EOF"},
+		},
+		"empty": {
+			args: args{body: http.NoBody},
+			want: want{err: jsonbind.ErrEmptyBody.Error()},
+		},
+		"missing required field": {
+			args: args{body: strings.NewReader(`{"extra":1}`)},
+			want: want{err: "Field validation for 'Name' failed on the 'required' tag"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", tt.args.body)
+
+			if tt.args.options != nil {
+				c.Request = c.Request.WithContext(jsonbind.ContextWithOptions(c.Request.Context(), *tt.args.options))
+			}
+
+			var got request
+
+			err := jsonbind.Bind(c, &got)
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.got, got)
+		})
+	}
+}
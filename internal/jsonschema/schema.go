@@ -0,0 +1,259 @@
+// Package jsonschema validates JSON documents against the subset of JSON Schema describing the shape of JSON APIs, to
+// detect an upstream changing its responses.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// errUnsupported is returned for schemas using keywords or formats which are not supported, so that they are not
+// silently ignored.
+var errUnsupported = errors.New("unsupported schema")
+
+// Schema is a JSON Schema supporting the keywords describing the shape of JSON APIs: type, required, properties,
+// additionalProperties, items, minItems, minimum, enum, format uri, and $ref to the other schemas of its directory.
+type Schema struct {
+	// Annotations, not validated.
+	SchemaURI   string `json:"$schema"`
+	ID          string `json:"$id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	Ref                  string             `json:"$ref"`
+	Type                 types              `json:"type"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	Minimum              *float64           `json:"minimum"`
+	Enum                 []any              `json:"enum"`
+	Format               string             `json:"format"`
+
+	ref *Schema
+}
+
+// types is the type keyword, a type or a list of types.
+type types []string
+
+func (t *types) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = types{one}
+		return nil
+	}
+
+	return json.Unmarshal(b, (*[]string)(t)) //nolint:wrapcheck // wrapped by the decoder
+}
+
+// Violation is a difference between a JSON document and its schema.
+type Violation struct {
+	// Path is the JSON pointer of the value, empty for the document.
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return "/" + strings.TrimPrefix(v.Path, "/") + ": " + v.Message
+}
+
+// LoadSchema reads the schema name of fsys, resolving its references to the other schemas of its directory.
+func LoadSchema(fsys fs.FS, name string) (*Schema, error) {
+	return loadSchema(fsys, name, map[string]*Schema{})
+}
+
+func loadSchema(fsys fs.FS, name string, loaded map[string]*Schema) (*Schema, error) {
+	if s, ok := loaded[name]; ok {
+		return s, nil
+	}
+
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	s := &Schema{}
+	if err = dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", errUnsupported, name, err)
+	}
+
+	loaded[name] = s
+
+	if err = s.resolve(fsys, path.Dir(name), loaded); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return s, nil
+}
+
+// resolve loads the references of s and of its subschemas, relative to dir.
+func (s *Schema) resolve(fsys fs.FS, dir string, loaded map[string]*Schema) error {
+	if s.Format != "" && s.Format != "uri" {
+		return fmt.Errorf("%w: format %s", errUnsupported, s.Format)
+	}
+
+	if s.Ref != "" {
+		ref, err := loadSchema(fsys, path.Join(dir, s.Ref), loaded)
+		if err != nil {
+			return err
+		}
+
+		s.ref = ref
+	}
+
+	for _, p := range s.Properties {
+		if err := p.resolve(fsys, dir, loaded); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		return s.Items.resolve(fsys, dir, loaded)
+	}
+
+	return nil
+}
+
+// Validate returns the violations of s by v, a JSON document decoded with json.Decoder.UseNumber.
+func (s *Schema) Validate(v any) []Violation {
+	var violations []Violation
+
+	s.validate("", v, &violations)
+
+	return violations
+}
+
+func (s *Schema) validate(ptr string, v any, violations *[]Violation) {
+	if s.ref != nil {
+		s.ref.validate(ptr, v, violations)
+	}
+
+	report := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Path: ptr, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(v, t) }) {
+		report("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))
+		return
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equal(e, v) }) {
+		report("%v is not one of %v", v, s.Enum)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		s.validateObject(ptr, v, report, violations)
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("expected at least %d items, got %d", *s.MinItems, len(v))
+		}
+
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s/%d", ptr, i), item, violations)
+			}
+		}
+	case json.Number:
+		if f, err := v.Float64(); err == nil && s.Minimum != nil && f < *s.Minimum {
+			report("%s is less than the minimum %v", v, *s.Minimum)
+		}
+	case string:
+		if s.Format == "uri" && !isURI(v) {
+			report("%q is not a URI", v)
+		}
+	}
+}
+
+func (s *Schema) validateObject(ptr string, v map[string]any, report func(format string, args ...any), violations *[]Violation) {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			report("missing required property %s", name)
+		}
+	}
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		p, ok := s.Properties[name]
+
+		switch {
+		case ok:
+			p.validate(ptr+"/"+name, v[name], violations)
+		case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+			report("unexpected property %s", name)
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+
+		_, err := n.Int64()
+
+		return err == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return typeOf(v) == t
+	}
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// equal compares an enum value of a schema, decoded without UseNumber, with a value of a document. Only scalars are
+// compared.
+func equal(e, v any) bool {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && e == f
+	case nil, bool, string:
+		return e == v
+	default:
+		return false
+	}
+}
+
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+}
@@ -0,0 +1,109 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/jsonschema"
+)
+
+var testSchemas = fstest.MapFS{
+	"item.schema.json": {Data: []byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["id", "url"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"url": {"type": "string", "format": "uri"},
+			"kind": {"enum": ["a", "b"]},
+			"note": {"type": ["string", "null"]}
+		}
+	}`)},
+	"items.schema.json":   {Data: []byte(`{"type": "array", "minItems": 1, "items": {"$ref": "item.schema.json"}}`)},
+	"unknown.schema.json": {Data: []byte(`{"type": "string", "pattern": "^a"}`)},
+	"format.schema.json":  {Data: []byte(`{"type": "string", "format": "email"}`)},
+}
+
+func decode(t *testing.T, doc string) any {
+	t.Helper()
+
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+
+	var v any
+	assert.NoError(t, dec.Decode(&v))
+
+	return v
+}
+
+func TestSchema_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		schema string
+		doc    string
+		want   []string
+	}{
+		"valid": {
+			schema: "items.schema.json",
+			doc:    `[{"id": 1, "url": "https://example.com/1", "kind": "a", "note": null}]`,
+		},
+		"empty array": {
+			schema: "items.schema.json",
+			doc:    `[]`,
+			want:   []string{"/: expected at least 1 items, got 0"},
+		},
+		"wrong type": {
+			schema: "items.schema.json",
+			doc:    `{}`,
+			want:   []string{"/: expected array, got object"},
+		},
+		"item violations": {
+			schema: "items.schema.json",
+			doc:    `[{"id": 1.5, "url": "not a uri", "kind": "c", "note": 1, "extra": true}, {"id": 0}]`,
+			want: []string{
+				"/0: unexpected property extra",
+				"/0/id: expected integer, got number",
+				"/0/kind: c is not one of [a b]",
+				"/0/note: expected string or null, got number",
+				`/0/url: "not a uri" is not a URI`,
+				"/1: missing required property url",
+				"/1/id: 0 is less than the minimum 1",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := jsonschema.LoadSchema(testSchemas, tt.schema)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var got []string
+			for _, v := range s.Validate(decode(t, tt.doc)) {
+				got = append(got, v.String())
+			}
+
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadSchema_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"unknown.schema.json", "format.schema.json", "missing.schema.json"} {
+		_, err := jsonschema.LoadSchema(testSchemas, name)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,138 @@
+// Package jwt parses JSON Web Tokens and validates their time claims, for the tokens minted by the service and the ID
+// tokens of OIDC providers. Signatures are verified by the callers, which know their keys and algorithms.
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+// parts is the number of parts of a JWT: header, payload and signature.
+const parts = 3
+
+// ErrMalformed is returned for tokens which are not three base64url segments of a JSON header and payload.
+var ErrMalformed = errors.New("malformed token")
+
+// Header is the JOSE header of a token.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Token is a parsed, unverified token.
+type Token struct {
+	Header Header
+	Claims Claims
+	// Signed is the signed part of the token, its header and payload segments.
+	Signed    string
+	Signature []byte
+}
+
+// Parse decodes raw, without verifying its signature or its claims.
+func Parse(raw string) (*Token, error) {
+	segments := strings.Split(raw, ".")
+	if len(segments) != parts {
+		return nil, ErrMalformed
+	}
+
+	t := &Token{Signed: segments[0] + "." + segments[1]}
+
+	if err := decodeSegment(segments[0], &t.Header); err != nil {
+		return nil, err
+	}
+
+	if err := decodeSegment(segments[1], &t.Claims); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature", ErrMalformed)
+	}
+
+	t.Signature = sig
+
+	return t, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: segment", ErrMalformed)
+	}
+
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: segment", ErrMalformed)
+	}
+
+	return nil
+}
+
+// Claims are the claims of a token.
+type Claims map[string]any
+
+// String returns the string claim name, or "".
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings returns a claim holding a list of strings, or a single one.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		s := make([]string, 0, len(v))
+		for _, e := range v {
+			if str, ok := e.(string); ok {
+				s = append(s, str)
+			}
+		}
+
+		return s
+	default:
+		return nil
+	}
+}
+
+// Time returns the NumericDate claim name, or the zero time if it is not set.
+func (c Claims) Time(name string) time.Time {
+	sec, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(sec), 0)
+}
+
+// HasAudience reports whether the aud claim, a string or a list, contains aud.
+func (c Claims) HasAudience(aud string) bool {
+	return slices.Contains(c.Strings("aud"), aud)
+}
+
+// ValidateTimes checks with v that the token has not expired and that its nbf and iat claims, if set, have been
+// reached. The exp claim is required.
+func (c Claims) ValidateTimes(v *timestamp.Validator) error {
+	exp := c.Time("exp")
+	if exp.IsZero() {
+		return fmt.Errorf("%w: exp", timestamp.ErrMissing)
+	}
+
+	if err := v.ValidateWindow(c.Time("nbf"), exp); err != nil {
+		return err //nolint:wrapcheck // the timestamp errors describe the claim
+	}
+
+	if iat := c.Time("iat"); !iat.IsZero() {
+		return v.ValidateIssuedAt(iat, 0) //nolint:wrapcheck // the timestamp errors describe the claim
+	}
+
+	return nil
+}
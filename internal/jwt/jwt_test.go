@@ -0,0 +1,101 @@
+package jwt_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/jwt"
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+func segment(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	header := segment(`{"alg":"RS256","kid":"k1"}`)
+	payload := segment(`{"sub":"alice","aud":["photos","billing"]}`)
+
+	tests := map[string]struct {
+		raw     string
+		wantErr bool
+	}{
+		"valid":          {raw: header + "." + payload + "." + segment("sig")},
+		"two parts":      {raw: header + "." + payload, wantErr: true},
+		"header base64":  {raw: "!." + payload + "." + segment("sig"), wantErr: true},
+		"payload json":   {raw: header + "." + segment("not json") + "." + segment("sig"), wantErr: true},
+		"signature":      {raw: header + "." + payload + ".!", wantErr: true},
+		"empty segments": {raw: "..", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tok, err := jwt.Parse(tt.raw)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, jwt.ErrMalformed)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, jwt.Header{Alg: "RS256", Kid: "k1"}, tok.Header)
+			assert.Equal(t, header+"."+payload, tok.Signed)
+			assert.Equal(t, []byte("sig"), tok.Signature)
+			assert.Equal(t, "alice", tok.Claims.String("sub"))
+			assert.Equal(t, []string{"photos", "billing"}, tok.Claims.Strings("aud"))
+			assert.True(t, tok.Claims.HasAudience("billing"))
+			assert.False(t, tok.Claims.HasAudience("shipping"))
+		})
+	}
+}
+
+func TestClaims_ValidateTimes(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	v := timestamp.NewValidator(time.Minute, timestamp.WithClock(func() time.Time { return now }))
+
+	unix := func(d time.Duration) float64 {
+		return float64(now.Add(d).Unix())
+	}
+
+	tests := map[string]struct {
+		claims jwt.Claims
+		want   error
+	}{
+		"valid":               {claims: jwt.Claims{"exp": unix(time.Hour), "nbf": unix(-time.Hour), "iat": unix(-time.Hour)}},
+		"no exp":              {claims: jwt.Claims{"iat": unix(0)}, want: timestamp.ErrMissing},
+		"expired":             {claims: jwt.Claims{"exp": unix(-2 * time.Minute)}, want: timestamp.ErrExpired},
+		"expired within skew": {claims: jwt.Claims{"exp": unix(-30 * time.Second)}},
+		"not yet valid":       {claims: jwt.Claims{"exp": unix(time.Hour), "nbf": unix(2 * time.Minute)}, want: timestamp.ErrNotYetValid},
+		"issued in future":    {claims: jwt.Claims{"exp": unix(time.Hour), "iat": unix(2 * time.Minute)}, want: timestamp.ErrInFuture},
+		"exp not a number":    {claims: jwt.Claims{"exp": "tomorrow"}, want: timestamp.ErrMissing},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.claims.ValidateTimes(v)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
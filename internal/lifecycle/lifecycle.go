@@ -0,0 +1,165 @@
+// Package lifecycle coordinates the shutdown of the service, as expected by Kubernetes: on SIGTERM the service first
+// reports itself not ready and keeps serving for the drain delay, while the load balancers deregister it, then stops
+// its components in the reverse order of their registration, each within its own timeout.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// defaultShutdownTimeout bounds the shutdown of a component when no timeout is configured.
+const defaultShutdownTimeout = 10 * time.Second
+
+// Lifecycle tracks the readiness of the service and shuts its components down.
+type Lifecycle struct {
+	drainDelay time.Duration
+	timeout    time.Duration
+	timeouts   map[string]time.Duration
+	log        *logger.Logger
+
+	draining   atomic.Bool
+	mu         sync.Mutex
+	components []component
+}
+
+type component struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// New creates a Lifecycle with the drain delay and shutdown timeouts of cfg.
+func New(cfg *config.Lifecycle, l *logger.Logger) *Lifecycle {
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	return &Lifecycle{drainDelay: cfg.DrainDelay, timeout: timeout, timeouts: cfg.Timeouts, log: l}
+}
+
+// Register adds the component name, stopped with stop on shutdown. Components are registered in dependency order,
+// each after the ones it depends on, and stopped in the reverse order: the HTTP server, registered last, is drained
+// first, then the workers fed by its requests. The context of stop is done once the timeout of the component elapsed.
+func (lc *Lifecycle) Register(name string, stop func(ctx context.Context) error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.components = append(lc.components, component{name: name, stop: stop})
+}
+
+// Go runs fn in its own goroutine, registered as the component name, whose context is cancelled on shutdown. The
+// component is stopped once fn returns.
+func (lc *Lifecycle) Go(name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		fn(ctx)
+	}()
+
+	lc.Register(name, func(stopCtx context.Context) error {
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err() //nolint:wrapcheck // wrapped by Shutdown
+		}
+	})
+}
+
+// Ready reports whether the service accepts new traffic, until the shutdown starts.
+func (lc *Lifecycle) Ready() bool {
+	return !lc.draining.Load()
+}
+
+// Run serves with serve until ctx is done, usually on SIGTERM, then shuts down. serve must return once the component
+// stopping it is stopped. When serve fails, the components are stopped without draining.
+func (lc *Lifecycle) Run(ctx context.Context, serve func() error) error {
+	served := make(chan error, 1)
+
+	go func() {
+		served <- serve()
+	}()
+
+	select {
+	case <-ctx.Done():
+		lc.log.Info("Shutting down")
+		err := lc.Shutdown(context.WithoutCancel(ctx))
+
+		return errors.Join(<-served, err)
+	case err := <-served:
+		lc.draining.Store(true)
+
+		return errors.Join(err, lc.stopAll(context.WithoutCancel(ctx)))
+	}
+}
+
+// Shutdown reports the service not ready, waits for the drain delay unless ctx is done, and stops the components in
+// the reverse order of their registration, returning their errors.
+func (lc *Lifecycle) Shutdown(ctx context.Context) error {
+	lc.draining.Store(true)
+
+	if lc.drainDelay > 0 {
+		lc.log.Info("Draining before shutdown", zap.Duration("delay", lc.drainDelay))
+
+		t := time.NewTimer(lc.drainDelay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+		}
+	}
+
+	return lc.stopAll(ctx)
+}
+
+func (lc *Lifecycle) stopAll(ctx context.Context) error {
+	lc.mu.Lock()
+	components := lc.components
+	lc.mu.Unlock()
+
+	var errs []error
+
+	for i := len(components) - 1; i >= 0; i-- {
+		if err := lc.stop(ctx, components[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (lc *Lifecycle) stop(ctx context.Context, c component) error {
+	timeout, ok := lc.timeouts[c.name]
+	if !ok || timeout <= 0 {
+		timeout = lc.timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	if err := c.stop(ctx); err != nil {
+		lc.log.Error("Failed to stop component", zap.String("component", c.name), zap.Duration("timeout", timeout), zap.Error(err))
+		return fmt.Errorf("failed to stop %s: %w", c.name, err)
+	}
+
+	lc.log.Info("Component stopped", zap.String("component", c.name), zap.Duration("duration", time.Since(start)))
+
+	return nil
+}
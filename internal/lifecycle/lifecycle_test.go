@@ -0,0 +1,123 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/lifecycle"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+func TestLifecycle_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	lc := lifecycle.New(&config.Lifecycle{
+		DrainDelay:      20 * time.Millisecond,
+		ShutdownTimeout: time.Second,
+		Timeouts:        map[string]time.Duration{"slow": 10 * time.Millisecond},
+	}, logger.NewNop())
+
+	var (
+		mu      sync.Mutex
+		stopped []string
+	)
+
+	record := func(name string) func(ctx context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			stopped = append(stopped, name)
+
+			return nil
+		}
+	}
+
+	workerDone := false
+
+	lc.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		workerDone = true
+	})
+	lc.Register("store", record("store"))
+	lc.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	lc.Register("server", func(ctx context.Context) error {
+		assert.False(t, lc.Ready(), "not ready once stopping")
+		return record("server")(ctx)
+	})
+
+	assert.True(t, lc.Ready())
+
+	start := time.Now()
+	err := lc.Shutdown(context.Background())
+
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond, "drain delay")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "failed to stop slow")
+	assert.Equal(t, []string{"server", "store"}, stopped)
+	assert.True(t, workerDone)
+	assert.False(t, lc.Ready())
+}
+
+func TestLifecycle_Run(t *testing.T) {
+	t.Parallel()
+
+	errServe := errors.New("address already in use")
+
+	tests := map[string]struct {
+		drainDelay time.Duration
+		serveErr   error
+	}{
+		"Signal": {drainDelay: 10 * time.Millisecond},
+		// The drain delay is skipped when the server failed.
+		"ServeError": {drainDelay: time.Hour, serveErr: errServe},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lc := lifecycle.New(&config.Lifecycle{DrainDelay: tt.drainDelay}, logger.NewNop())
+			stopped := make(chan struct{})
+
+			lc.Register("server", func(context.Context) error {
+				close(stopped)
+				return nil
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := lc.Run(ctx, func() error {
+				if tt.serveErr != nil {
+					return tt.serveErr
+				}
+
+				cancel()
+				<-stopped
+
+				return nil
+			})
+
+			assert.ErrorIs(t, err, tt.serveErr)
+			assert.False(t, lc.Ready())
+
+			select {
+			case <-stopped:
+			default:
+				t.Error("server not stopped")
+			}
+		})
+	}
+}
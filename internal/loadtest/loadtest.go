@@ -0,0 +1,148 @@
+// Package loadtest sends requests to a route at a constant rate and reports their latency and statuses, to measure the
+// overhead of changes such as new middleware.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+)
+
+// ErrInvalidOptions is returned when the rate or the duration of a load test is not positive.
+var ErrInvalidOptions = errors.New("invalid load test options")
+
+// maxDrainSize bounds how much of a response body is read, so large responses do not dominate the measured latency.
+const maxDrainSize = 1 << 20
+
+type getter interface {
+	Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error)
+}
+
+// Options configures a load test.
+type Options struct {
+	// URL is the route requested with GET.
+	URL string
+	// Rate is the number of requests started per second.
+	Rate int
+	// Duration is how long requests are started for. The test ends once the requests in flight are done.
+	Duration time.Duration
+	// MaxInFlight bounds the requests in flight. Requests due while it is reached are dropped rather than delayed, so
+	// a slow server does not lower the rate unnoticed. Zero means no bound.
+	MaxInFlight int
+}
+
+// sample is the outcome of a request: its latency, and its status or the error which prevented getting one.
+type sample struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// Run sends GET requests to opts.URL at opts.Rate per second for opts.Duration, and reports their outcome. Requests
+// are started on schedule whether or not previous ones are done, as clients of a service would. Cancelling ctx stops
+// starting requests, cancels those in flight and reports the requests done so far.
+func Run(ctx context.Context, c getter, opts Options) (*Report, error) {
+	if opts.Rate <= 0 || opts.Duration <= 0 {
+		return nil, fmt.Errorf("%w: rate %d and duration %s must be positive", ErrInvalidOptions, opts.Rate, opts.Duration)
+	}
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		dropped int
+		wg      sync.WaitGroup
+	)
+
+	var inFlight chan struct{}
+	if opts.MaxInFlight > 0 {
+		inFlight = make(chan struct{}, opts.MaxInFlight)
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.Rate))
+	defer ticker.Stop()
+
+	start := time.Now()
+	deadline := time.NewTimer(opts.Duration)
+
+	defer deadline.Stop()
+
+	send := func() {
+		s := request(ctx, c, opts.URL)
+
+		mu.Lock()
+		samples = append(samples, s)
+		mu.Unlock()
+	}
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+		}
+
+		if inFlight == nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				send()
+			}()
+
+			continue
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+			wg.Add(1)
+
+			go func() {
+				defer func() {
+					<-inFlight
+					wg.Done()
+				}()
+
+				send()
+			}()
+		default:
+			dropped++
+		}
+	}
+
+	wg.Wait()
+
+	return summarize(samples, dropped, time.Since(start)), nil
+}
+
+func request(ctx context.Context, c getter, url string) sample {
+	start := time.Now()
+
+	resp, err := c.Get(ctx, url)
+	if err != nil {
+		var httpErr *client.HTTPError
+		if errors.As(err, &httpErr) {
+			return sample{latency: time.Since(start), status: httpErr.StatusCode}
+		}
+
+		return sample{latency: time.Since(start), err: err}
+	}
+
+	_, err = io.CopyN(io.Discard, resp.Body, maxDrainSize)
+	resp.Body.Close()
+
+	s := sample{latency: time.Since(start), status: resp.StatusCode}
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.err = fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return s
+}
@@ -0,0 +1,141 @@
+package loadtest_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/loadtest"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(`{}`))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		opts        loadtest.Options
+		wantStatus  int
+		wantFailed  bool
+		wantDropped bool
+		wantErr     error
+	}{
+		"success": {
+			opts:       loadtest.Options{URL: srv.URL + "/ok", Rate: 100, Duration: 100 * time.Millisecond},
+			wantStatus: http.StatusOK,
+		},
+		"failure": {
+			opts:       loadtest.Options{URL: srv.URL + "/fail", Rate: 100, Duration: 100 * time.Millisecond},
+			wantStatus: http.StatusInternalServerError,
+			wantFailed: true,
+		},
+		"max in flight": {
+			opts:        loadtest.Options{URL: srv.URL + "/slow", Rate: 100, Duration: 100 * time.Millisecond, MaxInFlight: 1},
+			wantStatus:  http.StatusOK,
+			wantDropped: true,
+		},
+		"invalid rate": {
+			opts:    loadtest.Options{URL: srv.URL + "/ok", Duration: time.Second},
+			wantErr: loadtest.ErrInvalidOptions,
+		},
+		"invalid duration": {
+			opts:    loadtest.Options{URL: srv.URL + "/ok", Rate: 10},
+			wantErr: loadtest.ErrInvalidOptions,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := loadtest.Run(context.Background(), client.NewClient(srv.Client()), tt.opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if !assert.NoError(t, err) || !assert.Positive(t, r.Requests) {
+				return
+			}
+
+			assert.Equal(t, map[int]int{tt.wantStatus: r.Requests}, r.Statuses)
+			assert.Empty(t, r.Errors)
+			assert.Equal(t, tt.wantDropped, r.Dropped > 0)
+
+			if tt.wantFailed {
+				assert.Equal(t, r.Requests, r.Failed)
+				assert.InDelta(t, 1, r.ErrorRate(), 0)
+			} else {
+				assert.Zero(t, r.Failed)
+			}
+
+			assert.LessOrEqual(t, r.P50, r.P95)
+			assert.LessOrEqual(t, r.P95, r.P99)
+			assert.LessOrEqual(t, r.P99, r.Max)
+			assert.Positive(t, r.Max)
+		})
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	r, err := loadtest.Run(context.Background(), client.NewClient(&http.Client{}), loadtest.Options{URL: url, Rate: 50, Duration: 50 * time.Millisecond})
+	if !assert.NoError(t, err) || !assert.Positive(t, r.Requests) {
+		return
+	}
+
+	assert.Empty(t, r.Statuses)
+	assert.Len(t, r.Errors, 1)
+	assert.Equal(t, r.Requests, r.Failed)
+}
+
+func TestReport_Print(t *testing.T) {
+	t.Parallel()
+
+	r := &loadtest.Report{
+		Requests: 4,
+		Elapsed:  2 * time.Second,
+		Failed:   1,
+		Statuses: map[int]int{http.StatusOK: 3, http.StatusBadGateway: 1},
+		P50:      10 * time.Millisecond,
+		P95:      20 * time.Millisecond,
+		P99:      30 * time.Millisecond,
+		Max:      40 * time.Millisecond,
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, r.Print(&buf)) {
+		return
+	}
+
+	assert.Equal(t, `Requests    4 (2.0/s over 2s)
+Dropped     0
+Failed      1 (25.00%)
+Latency     p50 10ms  p95 20ms  p99 30ms  max 40ms
+Status 200  3
+Status 502  1
+`, buf.String())
+}
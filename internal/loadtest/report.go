@@ -0,0 +1,135 @@
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the outcome of a load test.
+type Report struct {
+	// Requests is the number of requests sent, dropped ones excluded.
+	Requests int
+	// Dropped is the number of requests not sent because Options.MaxInFlight was reached.
+	Dropped int
+	// Elapsed is the duration of the test, until the last request was done.
+	Elapsed time.Duration
+	// Failed is the number of requests which got no response or a status other than 2xx.
+	Failed int
+	// Statuses counts the requests by response status.
+	Statuses map[int]int
+	// Errors counts the requests which got no response by error message.
+	Errors map[string]int
+	// P50, P95, P99 and Max are percentiles of the latency of the requests, including failed ones.
+	P50, P95, P99, Max time.Duration
+}
+
+func summarize(samples []sample, dropped int, elapsed time.Duration) *Report {
+	r := &Report{
+		Requests: len(samples),
+		Dropped:  dropped,
+		Elapsed:  elapsed,
+		Statuses: map[int]int{},
+		Errors:   map[string]int{},
+	}
+
+	latencies := make([]time.Duration, 0, len(samples))
+
+	for _, s := range samples {
+		latencies = append(latencies, s.latency)
+
+		switch {
+		case s.err != nil:
+			r.Errors[s.err.Error()]++
+			r.Failed++
+		default:
+			r.Statuses[s.status]++
+
+			if s.status < http.StatusOK || s.status >= http.StatusMultipleChoices {
+				r.Failed++
+			}
+		}
+	}
+
+	slices.Sort(latencies)
+
+	r.P50 = percentile(latencies, 50)  //nolint:gomnd // median
+	r.P95 = percentile(latencies, 95)  //nolint:gomnd // percentile
+	r.P99 = percentile(latencies, 99)  //nolint:gomnd // percentile
+	r.Max = percentile(latencies, 100) //nolint:gomnd // maximum
+
+	return r
+}
+
+// percentile returns the nearest-rank p-th percentile of the sorted latencies, or zero if there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted)))) //nolint:gomnd // percent
+	rank = max(rank, 1)
+
+	return sorted[rank-1]
+}
+
+// Throughput returns the requests sent per second.
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// ErrorRate returns the share of the requests sent which failed, between 0 and 1.
+func (r *Report) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+
+	return float64(r.Failed) / float64(r.Requests)
+}
+
+// Print writes the report as an aligned table to w.
+func (r *Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd // padding
+
+	fmt.Fprintf(tw, "Requests\t%d (%.1f/s over %s)\n", r.Requests, r.Throughput(), r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(tw, "Dropped\t%d\n", r.Dropped)
+	fmt.Fprintf(tw, "Failed\t%d (%.2f%%)\n", r.Failed, r.ErrorRate()*100) //nolint:gomnd // percent
+	fmt.Fprintf(tw, "Latency\tp50 %s\tp95 %s\tp99 %s\tmax %s\n", r.P50.Round(time.Microsecond), r.P95.Round(time.Microsecond),
+		r.P99.Round(time.Microsecond), r.Max.Round(time.Microsecond))
+
+	statuses := make([]int, 0, len(r.Statuses))
+	for status := range r.Statuses {
+		statuses = append(statuses, status)
+	}
+
+	slices.Sort(statuses)
+
+	for _, status := range statuses {
+		fmt.Fprintf(tw, "Status %d\t%d\n", status, r.Statuses[status])
+	}
+
+	errs := make([]string, 0, len(r.Errors))
+	for msg := range r.Errors {
+		errs = append(errs, msg)
+	}
+
+	slices.Sort(errs)
+
+	for _, msg := range errs {
+		fmt.Fprintf(tw, "Error\t%d\t%s\n", r.Errors[msg], msg)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type eventKey struct{}
+
+// Event collects fields describing a single request. It is logged once as a canonical log line when the request
+// completes, so every component handling the request can contribute to one structured record.
+type Event struct {
+	mu        sync.Mutex
+	fields    []zap.Field
+	lists     map[string][]zapcore.ObjectMarshaler
+	order     []string
+	durations map[string]time.Duration
+	// durationOrder is the order of the keys of durations.
+	durationOrder []string
+}
+
+// NewEvent creates an empty Event.
+func NewEvent() *Event {
+	return &Event{lists: map[string][]zapcore.ObjectMarshaler{}, durations: map[string]time.Duration{}}
+}
+
+// ContextWithEvent returns a copy of ctx carrying e.
+func ContextWithEvent(ctx context.Context, e *Event) context.Context {
+	return context.WithValue(ctx, eventKey{}, e)
+}
+
+// EventFromContext returns the Event carried by ctx, or nil. All Event methods are safe to call on nil.
+func EventFromContext(ctx context.Context) *Event {
+	e, _ := ctx.Value(eventKey{}).(*Event)
+	return e
+}
+
+// Add adds fields to the event.
+func (e *Event) Add(fields ...zap.Field) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.fields = append(e.fields, fields...)
+}
+
+// Append appends obj to the list logged under key, for things happening several times per request.
+func (e *Event) Append(key string, obj zapcore.ObjectMarshaler) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.lists[key]; !ok {
+		e.order = append(e.order, key)
+	}
+
+	e.lists[key] = append(e.lists[key], obj)
+}
+
+// AddDuration adds d to the total logged under key, for time spent several times per request, such as on upstream
+// requests.
+func (e *Event) AddDuration(key string, d time.Duration) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.durations[key]; !ok {
+		e.durationOrder = append(e.durationOrder, key)
+	}
+
+	e.durations[key] += d
+}
+
+// Duration returns the total added under key with AddDuration.
+func (e *Event) Duration(key string) time.Duration {
+	if e == nil {
+		return 0
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.durations[key]
+}
+
+// Fields returns the fields collected so far.
+func (e *Event) Fields() []zap.Field {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fields := make([]zap.Field, 0, len(e.fields)+len(e.order)+len(e.durationOrder))
+	fields = append(fields, e.fields...)
+
+	for _, key := range e.order {
+		fields = append(fields, zap.Objects(key, e.lists[key]))
+	}
+
+	for _, key := range e.durationOrder {
+		fields = append(fields, zap.Duration(key, e.durations[key]))
+	}
+
+	return fields
+}
@@ -0,0 +1,61 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+type call struct {
+	host string
+}
+
+func (c call) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("host", c.host)
+	return nil
+}
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	e := logger.NewEvent()
+	ctx := logger.ContextWithEvent(context.Background(), e)
+
+	logger.EventFromContext(ctx).Add(zap.String("user", "alice"))
+	logger.EventFromContext(ctx).Append("upstream", call{host: "a"})
+	logger.EventFromContext(ctx).Append("upstream", call{host: "b"})
+	logger.EventFromContext(ctx).AddDuration("upstream_latency", time.Second)
+	logger.EventFromContext(ctx).AddDuration("upstream_latency", time.Millisecond)
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range e.Fields() {
+		f.AddTo(enc)
+	}
+
+	assert.Equal(t, "alice", enc.Fields["user"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"host": "a"},
+		map[string]interface{}{"host": "b"},
+	}, enc.Fields["upstream"])
+	assert.Equal(t, time.Second+time.Millisecond, enc.Fields["upstream_latency"])
+	assert.Equal(t, time.Second+time.Millisecond, e.Duration("upstream_latency"))
+}
+
+func TestEvent_Nil(t *testing.T) {
+	t.Parallel()
+
+	e := logger.EventFromContext(context.Background())
+	assert.Nil(t, e)
+
+	e.Add(zap.String("ignored", "value"))
+	e.Append("ignored", call{})
+	e.AddDuration("ignored", time.Second)
+	assert.Empty(t, e.Fields())
+	assert.Zero(t, e.Duration("ignored"))
+}
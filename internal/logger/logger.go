@@ -69,6 +69,14 @@ func NewLogger(lv *LogLevels) *Logger {
 	}
 }
 
+// Tee also writes the entries of l to core from now on, e.g. to export them. It has to be called before l is shared
+// with other goroutines.
+func (l *Logger) Tee(core zapcore.Core) {
+	l.Logger = l.Logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, core)
+	}))
+}
+
 func getLogLevelFromEnvOrDefault(lv *LogLevels) (zapcore.Level, bool) {
 	if lv != nil {
 		return lv.LogLevel, lv.AddStacktrace
@@ -0,0 +1,47 @@
+// Package mapping helps converting between the DTOs of the upstreams and the domain types of the service, the
+// anti-corruption layer keeping the shape of the upstreams out of the handlers and the storage.
+//
+// By convention, each package consuming an upstream declares the DTOs of the upstream in an upstream.go file, unexported
+// and with the JSON names and validation constraints of the upstream, along with the functions converting them to its
+// domain types. Only the domain types leave the package, so a renamed upstream field only changes its DTO and mapping.
+package mapping
+
+import "fmt"
+
+// Slice converts every element of from with convert. It never returns nil, so an empty list stays a JSON array.
+func Slice[F, T any](from []F, convert func(F) T) []T {
+	to := make([]T, len(from))
+	for i, f := range from {
+		to[i] = convert(f)
+	}
+
+	return to
+}
+
+// SliceErr converts every element of from with convert, failing with the error of the first element which cannot be
+// converted.
+func SliceErr[F, T any](from []F, convert func(F) (T, error)) ([]T, error) {
+	to := make([]T, len(from))
+
+	for i, f := range from {
+		t, err := convert(f)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+
+		to[i] = t
+	}
+
+	return to, nil
+}
+
+// Ptr converts the value of from with convert, nil staying nil.
+func Ptr[F, T any](from *F, convert func(F) T) *T {
+	if from == nil {
+		return nil
+	}
+
+	t := convert(*from)
+
+	return &t
+}
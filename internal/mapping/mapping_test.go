@@ -0,0 +1,67 @@
+package mapping_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/mapping"
+)
+
+func TestSlice(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"1", "2"}, mapping.Slice([]int{1, 2}, strconv.Itoa))
+	assert.Equal(t, []string{}, mapping.Slice(nil, strconv.Itoa))
+}
+
+func TestSliceErr(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		from    []string
+		want    []int
+		wantErr string
+	}{
+		"converted": {
+			from: []string{"1", "2"},
+			want: []int{1, 2},
+		},
+		"empty": {
+			want: []int{},
+		},
+		"invalid element": {
+			from:    []string{"1", "two"},
+			wantErr: `element 1: strconv.Atoi: parsing "two": invalid syntax`,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := mapping.SliceErr(tt.from, strconv.Atoi)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.True(t, errors.Is(err, strconv.ErrSyntax))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPtr(t *testing.T) {
+	t.Parallel()
+
+	one := 1
+
+	assert.Equal(t, "1", *mapping.Ptr(&one, strconv.Itoa))
+	assert.Nil(t, mapping.Ptr(nil, strconv.Itoa))
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Backends of Config.Backend.
+const (
+	BackendPrometheus = "prometheus"
+	BackendStatsD     = "statsd"
+)
+
+// Config holds the configuration for application metrics.
+type Config struct {
+	// Path is the route exposing the metrics in the Prometheus format. Empty disables the Prometheus backend.
+	Path string `mapstructure:"path"`
+	// ErrorExemplarInterval is how often an error of each class is logged, the others are only counted. Zero logs every
+	// error.
+	ErrorExemplarInterval time.Duration `mapstructure:"error_exemplar_interval"`
+	// MaxLabelValues bounds the values of each label of a metric, beyond which values are recorded as "other". Zero is
+	// no bound.
+	MaxLabelValues int `mapstructure:"max_label_values"`
+	// NativeHistograms records the histograms as native histograms too, for the scrapers negotiating them.
+	NativeHistograms bool `mapstructure:"native_histograms"`
+	// Backend is "prometheus" (the default) to expose the metrics on Path, or "statsd" to send them to StatsD.
+	Backend string `mapstructure:"backend"`
+	// StatsD is the DogStatsD agent of the "statsd" backend.
+	StatsD StatsDConfig `mapstructure:"statsd"`
+}
+
+// StatsDConfig holds the DogStatsD agent receiving the metrics, such as the Datadog agent.
+type StatsDConfig struct {
+	// Addr is the UDP address of the agent, e.g. 127.0.0.1:8125.
+	Addr string `mapstructure:"addr"`
+	// Prefix is prepended to the names of the metrics, e.g. "skeleton".
+	Prefix string `mapstructure:"prefix"`
+	// Tags are added to every metric, e.g. "env:prod".
+	Tags []string `mapstructure:"tags"`
+}
+
+// ConfigSection is the section of the configuration of the metrics, under metrics. It has to be registered with the
+// config.Viper building the configuration.
+var ConfigSection = config.NewSection("metrics", Config{}, validateConfig)
+
+func validateConfig(cfg *Config) error {
+	var errs []error
+
+	switch cfg.Backend {
+	case "", BackendPrometheus, BackendStatsD:
+	default:
+		errs = append(errs, fmt.Errorf("unknown metrics backend %q", cfg.Backend))
+	}
+
+	if cfg.MaxLabelValues < 0 {
+		errs = append(errs, errors.New("max_label_values must not be negative"))
+	}
+
+	if cfg.ErrorExemplarInterval < 0 {
+		errs = append(errs, errors.New("error_exemplar_interval must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
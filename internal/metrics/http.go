@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestDurationMetric observes the latency of requests in seconds, by method, route and status, with the trace ID
+// of the requests as exemplars.
+const RequestDurationMetric = "http_request_duration_seconds"
+
+// UnmatchedRoute is the route label of the requests matching no route, whose paths are not recorded.
+const UnmatchedRoute = "unmatched"
+
+// traceParentHeader carries the W3C trace context of a request: version-traceid-parentid-flags.
+const traceParentHeader = "traceparent"
+
+// DurationMiddleware records the latency of requests per route in a histogram, with the trace ID of the request as
+// exemplar.
+func DurationMiddleware(r Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		labels := Labels{"method": MethodLabel(c.Request.Method), "route": RouteLabel(c), "status": strconv.Itoa(c.Writer.Status())}
+		r.ObserveWithExemplar(RequestDurationMetric, time.Since(start).Seconds(), labels, TraceID(c.Request))
+	}
+}
+
+// RouteLabel returns the route of the request as registered, e.g. /photos/:id, so that the IDs in paths do not
+// become label values. Requests matching no route are labelled UnmatchedRoute.
+func RouteLabel(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+
+	return UnmatchedRoute
+}
+
+// MethodLabel returns method if it is a standard method, or OverflowValue, as clients may send any method.
+func MethodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return OverflowValue
+	}
+}
+
+// TraceID returns the trace ID of the traceparent header of r, or an empty string without a valid one.
+func TraceID(r *http.Request) string {
+	parts := strings.Split(r.Header.Get(traceParentHeader), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || strings.Trim(parts[1], "0") == "" || !isLowerHex(parts[1]) {
+		return ""
+	}
+
+	return parts[1]
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+func TestDurationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	r := metrics.New()
+	router := gin.New()
+	router.Use(metrics.DurationMiddleware(r))
+	router.GET("/photos/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/photos/1", http.NoBody)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/photos/2", http.NoBody))
+
+	scrape := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody)
+	scrape.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+
+	resp := httptest.NewRecorder()
+	r.Handler().ServeHTTP(resp, scrape)
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/photos/:id",status="200"} 1`)
+	assert.Contains(t, body, `# {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"}`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="other",route="unmatched",status="404"} 1`)
+}
+
+func TestTraceID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header string
+		want   string
+	}{
+		"valid":   {header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		"missing": {},
+		"zero":    {header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		"short":   {header: "00-4bf92f35-00f067aa0ba902b7-01"},
+		"upper":   {header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set("traceparent", tt.header)
+			}
+
+			assert.Equal(t, tt.want, metrics.TraceID(req))
+		})
+	}
+}
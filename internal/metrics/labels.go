@@ -0,0 +1,105 @@
+package metrics
+
+import "sync"
+
+// LabelOverflowMetric counts the recordings whose label value was replaced by OverflowValue, by metric and label.
+const LabelOverflowMetric = "metrics_label_overflow_total"
+
+// OverflowValue replaces the values of a label beyond the maximum number of values of the label.
+const OverflowValue = "other"
+
+// Option configures optional behaviour of a Recorder.
+type Option func(*options)
+
+type options struct {
+	maxLabelValues   int
+	nativeHistograms bool
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithMaxLabelValues bounds the values of each label of a metric to n, guarding against labels with unbounded values
+// such as IDs. Further values are recorded as OverflowValue and counted in LabelOverflowMetric. Zero is no bound.
+func WithMaxLabelValues(n int) Option {
+	return func(o *options) {
+		o.maxLabelValues = n
+	}
+}
+
+// WithNativeHistograms records the histograms of a Registry as native histograms on top of their buckets. Native
+// histograms are exposed in the protobuf format only, to the scrapers negotiating it.
+func WithNativeHistograms() Option {
+	return func(o *options) {
+		o.nativeHistograms = true
+	}
+}
+
+// labelBounds bounds the values of each label of a metric.
+type labelBounds struct {
+	max int
+	mu  sync.Mutex
+	// values are the values seen by metric and label.
+	values map[string]map[string]map[string]struct{}
+}
+
+func newLabelBounds(maxValues int) *labelBounds {
+	return &labelBounds{max: maxValues, values: map[string]map[string]map[string]struct{}{}}
+}
+
+// apply returns labels with the values beyond the maximum number of values of their label replaced by OverflowValue,
+// and the names of these labels.
+func (b *labelBounds) apply(name string, labels Labels) (Labels, []string) {
+	// The labels of LabelOverflowMetric are bounded by the names of the metrics and their labels.
+	if b.max <= 0 || len(labels) == 0 || name == LabelOverflowMetric {
+		return labels, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	byLabel, ok := b.values[name]
+	if !ok {
+		byLabel = map[string]map[string]struct{}{}
+		b.values[name] = byLabel
+	}
+
+	bounded := labels
+
+	var overflowed []string
+
+	for label, value := range labels {
+		seen, ok := byLabel[label]
+		if !ok {
+			seen = map[string]struct{}{}
+			byLabel[label] = seen
+		}
+
+		if _, known := seen[value]; known {
+			continue
+		}
+
+		if len(seen) < b.max {
+			seen[value] = struct{}{}
+			continue
+		}
+
+		if len(overflowed) == 0 {
+			bounded = make(Labels, len(labels))
+			for k, v := range labels {
+				bounded[k] = v
+			}
+		}
+
+		bounded[label] = OverflowValue
+		overflowed = append(overflowed, label)
+	}
+
+	return bounded, overflowed
+}
@@ -0,0 +1,187 @@
+// Package metrics provides application metrics exported in the Prometheus format. Metrics are registered on first use
+// under the given name and label names, so components record them without declaring them upfront.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Native histograms use buckets growing by 10%, merged beyond 160 buckets and reset at most hourly.
+const (
+	nativeBucketFactor    = 1.1
+	nativeMaxBuckets      = 160
+	nativeMinResetSeconds = 3600
+)
+
+// Labels are the label values of a metric. A metric has to be recorded with the same label names every time.
+type Labels map[string]string
+
+// Registry records metrics. A nil *Registry records nothing, so components can be used without metrics.
+type Registry struct {
+	reg        *prometheus.Registry
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+	buckets    map[string][]float64
+	bounds     *labelBounds
+	native     bool
+}
+
+// New creates a Registry including the Go runtime and process metrics.
+func New(opts ...Option) *Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	o := newOptions(opts)
+
+	return &Registry{
+		reg:        reg,
+		counters:   map[string]*prometheus.CounterVec{},
+		gauges:     map[string]*prometheus.GaugeVec{},
+		histograms: map[string]*prometheus.HistogramVec{},
+		buckets:    map[string][]float64{},
+		bounds:     newLabelBounds(o.maxLabelValues),
+		native:     o.nativeHistograms,
+	}
+}
+
+// Handler returns the handler exposing the metrics. Exemplars are exposed in the OpenMetrics format, to the scrapers
+// negotiating it.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.reg, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// Gather returns the metrics recorded, for exporters pushing them rather than being scraped.
+func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
+	return r.reg.Gather() //nolint:wrapcheck // the registry implements prometheus.Gatherer
+}
+
+// Inc increments the counter name by one.
+func (r *Registry) Inc(name string, labels Labels) {
+	r.Add(name, 1, labels)
+}
+
+// Add adds v to the counter name.
+func (r *Registry) Add(name string, v float64, labels Labels) {
+	if r == nil {
+		return
+	}
+
+	labels = r.bound(name, labels)
+
+	r.mu.Lock()
+	c, ok := r.counters[name]
+
+	if !ok {
+		c = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: name}, labelNames(labels))
+		r.reg.MustRegister(c)
+		r.counters[name] = c
+	}
+	r.mu.Unlock()
+
+	c.With(prometheus.Labels(labels)).Add(v)
+}
+
+// Set sets the gauge name to v.
+func (r *Registry) Set(name string, v float64, labels Labels) {
+	if r == nil {
+		return
+	}
+
+	labels = r.bound(name, labels)
+
+	r.mu.Lock()
+	g, ok := r.gauges[name]
+
+	if !ok {
+		g = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: name}, labelNames(labels))
+		r.reg.MustRegister(g)
+		r.gauges[name] = g
+	}
+	r.mu.Unlock()
+
+	g.With(prometheus.Labels(labels)).Set(v)
+}
+
+// SetBuckets sets the buckets of the histogram name. It has to be called before the histogram is first observed.
+func (r *Registry) SetBuckets(name string, buckets []float64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buckets[name] = buckets
+}
+
+// Observe records v in the histogram name. Unless set with SetBuckets, the default buckets suited to latencies in
+// seconds are used.
+func (r *Registry) Observe(name string, v float64, labels Labels) {
+	r.ObserveWithExemplar(name, v, labels, "")
+}
+
+// ObserveWithExemplar records v in the histogram name as Observe, with the trace ID of the request observed as the
+// exemplar of its bucket. An empty trace ID records no exemplar.
+func (r *Registry) ObserveWithExemplar(name string, v float64, labels Labels, traceID string) {
+	if r == nil {
+		return
+	}
+
+	labels = r.bound(name, labels)
+
+	r.mu.Lock()
+	h, ok := r.histograms[name]
+
+	if !ok {
+		opts := prometheus.HistogramOpts{Name: name, Help: name, Buckets: r.buckets[name]}
+		if r.native {
+			opts.NativeHistogramBucketFactor = nativeBucketFactor
+			opts.NativeHistogramMaxBucketNumber = nativeMaxBuckets
+			opts.NativeHistogramMinResetDuration = nativeMinResetSeconds * time.Second
+		}
+
+		h = prometheus.NewHistogramVec(opts, labelNames(labels))
+		r.reg.MustRegister(h)
+		r.histograms[name] = h
+	}
+	r.mu.Unlock()
+
+	o := h.With(prometheus.Labels(labels))
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && traceID != "" {
+		eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": traceID})
+		return
+	}
+
+	o.Observe(v)
+}
+
+// bound returns labels bounded by the label bounds, counting the overflowing labels.
+func (r *Registry) bound(name string, labels Labels) Labels {
+	bounded, overflowed := r.bounds.apply(name, labels)
+	for _, label := range overflowed {
+		r.Inc(LabelOverflowMetric, Labels{"metric": name, "label": label})
+	}
+
+	return bounded
+}
+
+func labelNames(labels Labels) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
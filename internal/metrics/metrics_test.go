@@ -0,0 +1,59 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := metrics.New()
+	r.Inc("test_requests_total", metrics.Labels{"route": "/a"})
+	r.Add("test_requests_total", 2, metrics.Labels{"route": "/a"})
+	r.Set("test_in_flight", 3, nil)
+	r.Observe("test_latency_seconds", 0.2, metrics.Labels{"route": "/a"})
+
+	resp := httptest.NewRecorder()
+	r.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `test_requests_total{route="/a"} 3`)
+	assert.Contains(t, body, `test_in_flight 3`)
+	assert.Contains(t, body, `test_latency_seconds_count{route="/a"} 1`)
+	assert.Contains(t, body, `go_goroutines`)
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	t.Parallel()
+
+	var r *metrics.Registry
+
+	assert.NotPanics(t, func() {
+		r.Inc("test_requests_total", nil)
+		r.Set("test_in_flight", 1, nil)
+		r.Observe("test_latency_seconds", 1, nil)
+	})
+}
+
+func TestRegistry_MaxLabelValues(t *testing.T) {
+	t.Parallel()
+
+	r := metrics.New(metrics.WithMaxLabelValues(2))
+	for _, id := range []string{"1", "2", "3", "4", "1"} {
+		r.Inc("test_requests_total", metrics.Labels{"id": id, "method": "GET"})
+	}
+
+	resp := httptest.NewRecorder()
+	r.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	body := resp.Body.String()
+	assert.Contains(t, body, `test_requests_total{id="1",method="GET"} 2`)
+	assert.Contains(t, body, `test_requests_total{id="2",method="GET"} 1`)
+	assert.Contains(t, body, `test_requests_total{id="other",method="GET"} 2`)
+	assert.Contains(t, body, `metrics_label_overflow_total{label="id",metric="test_requests_total"} 2`)
+}
@@ -0,0 +1,17 @@
+package metrics
+
+// Recorder records metrics in a backend: Prometheus with a Registry, or a DogStatsD agent with StatsD. Components
+// depend on the methods they use rather than on a backend.
+type Recorder interface {
+	Inc(name string, labels Labels)
+	Add(name string, v float64, labels Labels)
+	Set(name string, v float64, labels Labels)
+	SetBuckets(name string, buckets []float64)
+	Observe(name string, v float64, labels Labels)
+	ObserveWithExemplar(name string, v float64, labels Labels, traceID string)
+}
+
+// Nop returns a Recorder recording nothing, for when metrics are disabled.
+func Nop() Recorder {
+	return (*Registry)(nil)
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+const (
+	// RequestSizeMetric observes the size of request bodies in bytes, by method and route.
+	RequestSizeMetric = "http_request_size_bytes"
+	// ResponseSizeMetric observes the size of response bodies in bytes, by method and route.
+	ResponseSizeMetric = "http_response_size_bytes"
+)
+
+// sizeBuckets returns the buckets of the size histograms, from 64B to 16MiB.
+func sizeBuckets() []float64 {
+	const (
+		start  = 64
+		factor = 4
+		count  = 10
+	)
+
+	return prometheus.ExponentialBuckets(start, factor, count)
+}
+
+// SizeMiddleware records the sizes of request and response bodies per route in histograms and on the canonical
+// logger.Event of the request. The request size is the number of bytes read by the handler, or the Content-Length
+// if the body was not fully read.
+func SizeMiddleware(r Recorder) gin.HandlerFunc {
+	r.SetBuckets(RequestSizeMetric, sizeBuckets())
+	r.SetBuckets(ResponseSizeMetric, sizeBuckets())
+
+	return func(c *gin.Context) {
+		body := &countingReader{ReadCloser: c.Request.Body}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
+
+		c.Next()
+
+		requestSize := max(body.n, c.Request.ContentLength, 0)
+		responseSize := max(c.Writer.Size(), 0)
+
+		labels := Labels{"method": MethodLabel(c.Request.Method), "route": RouteLabel(c)}
+		r.Observe(RequestSizeMetric, float64(requestSize), labels)
+		r.Observe(ResponseSizeMetric, float64(responseSize), labels)
+
+		logger.EventFromContext(c.Request.Context()).Add(zap.Int64("request_size", requestSize), zap.Int("response_size", responseSize))
+	}
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+
+	return n, err //nolint:wrapcheck // io.EOF must not be wrapped
+}
@@ -0,0 +1,99 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+func TestSizeMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		path string
+		body string
+	}
+
+	type want struct {
+		metrics []string
+		fields  map[string]any
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"read body": {
+			args: args{path: "/echo", body: strings.Repeat("a", 100)},
+			want: want{
+				metrics: []string{
+					`http_request_size_bytes_bucket{method="POST",route="/echo",le="256"} 1`,
+					`http_response_size_bytes_bucket{method="POST",route="/echo",le="64"} 0`,
+					`http_response_size_bytes_sum{method="POST",route="/echo"} 100`,
+				},
+				fields: map[string]any{"request_size": int64(100), "response_size": int64(100)},
+			},
+		},
+		"unread body uses content length": {
+			args: args{path: "/ignore", body: strings.Repeat("a", 10)},
+			want: want{
+				metrics: []string{`http_request_size_bytes_sum{method="POST",route="/ignore"} 10`, `http_response_size_bytes_sum{method="POST",route="/ignore"} 0`},
+				fields:  map[string]any{"request_size": int64(10), "response_size": int64(0)},
+			},
+		},
+		"unmatched route": {
+			args: args{path: "/nope"},
+			want: want{
+				metrics: []string{`http_request_size_bytes_count{method="POST",route="unmatched"} 1`},
+				fields:  map[string]any{"request_size": int64(0), "response_size": int64(0)},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := metrics.New()
+			event := logger.NewEvent()
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(logger.ContextWithEvent(c.Request.Context(), event))
+			}, metrics.SizeMiddleware(r))
+			router.POST("/echo", func(c *gin.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				c.Data(http.StatusOK, "text/plain", b)
+			})
+			router.POST("/ignore", func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, tt.args.path, strings.NewReader(tt.args.body)))
+
+			resp := httptest.NewRecorder()
+			r.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+			for _, m := range tt.want.metrics {
+				assert.Contains(t, resp.Body.String(), m)
+			}
+
+			fields := map[string]any{}
+			for _, f := range event.Fields() {
+				fields[f.Key] = f.Integer
+			}
+
+			assert.Equal(t, tt.want.fields["request_size"], fields["request_size"])
+			assert.Equal(t, tt.want.fields["response_size"], fields["response_size"])
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var errNoStatsDAddr = errors.New("statsd address is required")
+
+// StatsD records metrics to a DogStatsD agent, such as the Datadog agent, over UDP. Labels are sent as tags, counters
+// as counts, gauges as gauges and histograms as histograms, whose buckets are computed by the agent. Metrics are sent
+// as recorded and dropped if the agent is unreachable.
+type StatsD struct {
+	conn   net.Conn
+	prefix string
+	// tags are the tags of every metric, formatted.
+	tags   []string
+	bounds *labelBounds
+}
+
+// NewStatsD creates a StatsD sending metrics to the agent of cfg. WithNativeHistograms has no effect.
+func NewStatsD(cfg *StatsDConfig, opts ...Option) (*StatsD, error) {
+	if cfg.Addr == "" {
+		return nil, errNoStatsDAddr
+	}
+
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial statsd agent: %w", err)
+	}
+
+	prefix := cfg.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	tags := make([]string, len(cfg.Tags))
+	for i, tag := range cfg.Tags {
+		tags[i] = sanitizeTag(tag)
+	}
+
+	return &StatsD{conn: conn, prefix: prefix, tags: tags, bounds: newLabelBounds(newOptions(opts).maxLabelValues)}, nil
+}
+
+// Close closes the connection to the agent.
+func (s *StatsD) Close() error {
+	if err := s.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close statsd connection: %w", err)
+	}
+
+	return nil
+}
+
+// Inc increments the counter name by one.
+func (s *StatsD) Inc(name string, labels Labels) {
+	s.Add(name, 1, labels)
+}
+
+// Add adds v to the counter name.
+func (s *StatsD) Add(name string, v float64, labels Labels) {
+	s.send(name, v, "c", labels)
+}
+
+// Set sets the gauge name to v.
+func (s *StatsD) Set(name string, v float64, labels Labels) {
+	s.send(name, v, "g", labels)
+}
+
+// SetBuckets has no effect, as the agent computes the distribution of histograms.
+func (s *StatsD) SetBuckets(string, []float64) {}
+
+// Observe records v in the histogram name.
+func (s *StatsD) Observe(name string, v float64, labels Labels) {
+	s.send(name, v, "h", labels)
+}
+
+// ObserveWithExemplar records v in the histogram name. DogStatsD has no exemplars, so the trace ID is dropped.
+func (s *StatsD) ObserveWithExemplar(name string, v float64, labels Labels, _ string) {
+	s.Observe(name, v, labels)
+}
+
+// send writes the datagram of a metric: name:value|type|#tag:value,...
+func (s *StatsD) send(name string, v float64, typ string, labels Labels) {
+	labels, overflowed := s.bounds.apply(name, labels)
+	for _, label := range overflowed {
+		s.Inc(LabelOverflowMetric, Labels{"metric": name, "label": label})
+	}
+
+	var b strings.Builder
+
+	b.WriteString(s.prefix)
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	b.WriteByte('|')
+	b.WriteString(typ)
+
+	tags := append([]string(nil), s.tags...)
+	for _, label := range labelNames(labels) {
+		tags = append(tags, sanitizeTag(label+":"+labels[label]))
+	}
+
+	if len(tags) > 0 {
+		b.WriteString("|#")
+		b.WriteString(strings.Join(tags, ","))
+	}
+
+	// Metrics are best effort: an unreachable agent must not fail the requests recording them.
+	_, _ = s.conn.Write([]byte(b.String()))
+}
+
+// sanitizeTag replaces the characters delimiting the fields and tags of a datagram.
+func sanitizeTag(tag string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_").Replace(tag)
+}
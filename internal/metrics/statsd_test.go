@@ -0,0 +1,65 @@
+package metrics_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+func TestStatsD(t *testing.T) {
+	t.Parallel()
+
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Cleanup(func() { _ = agent.Close() })
+
+	sd, err := metrics.NewStatsD(&metrics.StatsDConfig{Addr: agent.LocalAddr().String(), Prefix: "skeleton", Tags: []string{"env:test"}},
+		metrics.WithMaxLabelValues(1))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Cleanup(func() { _ = sd.Close() })
+
+	sd.Inc("requests_total", metrics.Labels{"route": "/photos/:id", "method": "GET"})
+	sd.Set("in_flight", 2.5, nil)
+	sd.ObserveWithExemplar("latency_seconds", 0.25, metrics.Labels{"route": "/photos/:id"}, "4bf92f3577b34da6a3ce929d0e0e4736")
+	sd.Observe("latency_seconds", 0.5, metrics.Labels{"route": "/albums/:id"})
+
+	want := []string{
+		"skeleton.requests_total:1|c|#env:test,method:GET,route:/photos/:id",
+		"skeleton.in_flight:2.5|g|#env:test",
+		"skeleton.latency_seconds:0.25|h|#env:test,route:/photos/:id",
+		"skeleton.metrics_label_overflow_total:1|c|#env:test,label:route,metric:latency_seconds",
+		"skeleton.latency_seconds:0.5|h|#env:test,route:other",
+	}
+
+	buf := make([]byte, 512)
+
+	for _, w := range want {
+		_ = agent.SetReadDeadline(time.Now().Add(time.Second))
+
+		var n int
+
+		n, _, err = agent.ReadFrom(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, w, string(buf[:n]))
+	}
+}
+
+func TestNewStatsD_NoAddr(t *testing.T) {
+	t.Parallel()
+
+	_, err := metrics.NewStatsD(&metrics.StatsDConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package mirror
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// ComparisonMetric counts the responses of the shadow compared to the primary ones by route and result: "match",
+// "mismatch", and "skipped" when a response body is larger than the size cap.
+const ComparisonMetric = "http_mirror_comparisons_total"
+
+// maxDiffs bounds the differences logged for a mismatch.
+const maxDiffs = 20
+
+// primaryResponse is the response of the handler, as sent to the consumer.
+type primaryResponse struct {
+	status      int
+	contentType string
+	body        []byte
+	complete    bool
+}
+
+// captureWriter keeps a copy of the response body up to the size cap.
+type captureWriter struct {
+	gin.ResponseWriter
+	limit     int64
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *captureWriter) capture(b []byte) {
+	room := w.limit - int64(w.body.Len())
+	if int64(len(b)) > room {
+		w.truncated = true
+		b = b[:max(room, 0)]
+	}
+
+	w.body.Write(b)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b) //nolint:wrapcheck // transparent writer
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s) //nolint:wrapcheck // transparent writer
+}
+
+func (w *captureWriter) response() *primaryResponse {
+	return &primaryResponse{
+		status:      w.Status(),
+		contentType: w.Header().Get("Content-Type"),
+		body:        w.body.Bytes(),
+		complete:    !w.truncated,
+	}
+}
+
+// compare compares the response of the shadow to the primary one, records the result and logs a sample of the
+// mismatches.
+func (m *Mirror) compare(req *http.Request, route string, primary *primaryResponse, resp *http.Response) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, m.maxBodySize+1))
+	if err != nil || !primary.complete || int64(len(body)) > m.maxBodySize {
+		m.rec.Inc(ComparisonMetric, metrics.Labels{"route": route, "result": "skipped"})
+		return
+	}
+
+	var diffs []string
+	if primary.status != resp.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("status: %d != %d", primary.status, resp.StatusCode))
+	}
+
+	diffs = append(diffs, m.diffBodies(primary.contentType, primary.body, resp.Header.Get("Content-Type"), body)...)
+
+	if len(diffs) == 0 {
+		m.rec.Inc(ComparisonMetric, metrics.Labels{"route": route, "result": "match"})
+		return
+	}
+
+	m.rec.Inc(ComparisonMetric, metrics.Labels{"route": route, "result": "mismatch"})
+
+	if m.rand() < m.logSample {
+		m.log.Info("shadow response mismatch",
+			zap.String("method", req.Method),
+			zap.String("route", route),
+			zap.String("path", req.URL.Path),
+			zap.Int("primary_status", primary.status),
+			zap.Int("shadow_status", resp.StatusCode),
+			zap.Strings("diffs", diffs[:min(len(diffs), maxDiffs)]),
+			zap.Int("diff_count", len(diffs)),
+		)
+	}
+}
+
+// diffBodies returns the differences between the bodies. JSON bodies are compared by value, ignoring the order of
+// object keys and the ignored and redacted fields, other bodies byte by byte.
+func (m *Mirror) diffBodies(primaryType string, primary []byte, shadowType string, shadow []byte) []string {
+	var p, s any
+	if isJSON(primaryType) && isJSON(shadowType) && json.Unmarshal(primary, &p) == nil && json.Unmarshal(shadow, &s) == nil {
+		var diffs []string
+		m.diffJSON("$", p, s, &diffs)
+
+		return diffs
+	}
+
+	if !bytes.Equal(primary, shadow) {
+		return []string{"body"}
+	}
+
+	return nil
+}
+
+func (m *Mirror) diffJSON(path string, p, s any, diffs *[]string) {
+	pm, pok := p.(map[string]any)
+	sm, sok := s.(map[string]any)
+
+	if pok && sok {
+		keys := map[string]bool{}
+		for k := range pm {
+			keys[k] = true
+		}
+
+		for k := range sm {
+			keys[k] = true
+		}
+
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			if !m.ignore[strings.ToLower(k)] && !m.redact[strings.ToLower(k)] {
+				sorted = append(sorted, k)
+			}
+		}
+
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			m.diffJSON(path+"."+k, pm[k], sm[k], diffs)
+		}
+
+		return
+	}
+
+	pa, pok := p.([]any)
+	sa, sok := s.([]any)
+
+	if pok && sok {
+		if len(pa) != len(sa) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: length %d != %d", path, len(pa), len(sa)))
+			return
+		}
+
+		for i := range pa {
+			m.diffJSON(fmt.Sprintf("%s[%d]", path, i), pa[i], sa[i], diffs)
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(p, s) {
+		*diffs = append(*diffs, path)
+	}
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
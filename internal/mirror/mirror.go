@@ -0,0 +1,326 @@
+// Package mirror copies a sample of the incoming requests to a shadow deployment, to validate a new version under real
+// traffic. Mirrored requests are sent asynchronously, so the shadow never affects the consumers, and their responses
+// are either ignored or compared to the primary ones. Credentials are removed and personal data redacted before a
+// request leaves for the shadow.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/safego"
+)
+
+// ShadowHeader marks mirrored requests, so the shadow can tell them from real traffic.
+const ShadowHeader = "X-Shadow-Request"
+
+// Metric counts the sampled requests by result: "sent", "failed", "dropped" when too many are in flight, and
+// "skipped" when their body is too large or cannot be redacted.
+const Metric = "http_mirror_requests_total"
+
+// Redacted replaces redacted values.
+const Redacted = "[REDACTED]"
+
+// Defaults of the configuration.
+const (
+	DefaultMaxBodySize = 64 << 10
+	DefaultMaxInFlight = 16
+	DefaultTimeout     = 5 * time.Second
+	// DefaultRedactFields are the JSON fields, form fields and query parameters redacted when none are configured.
+	DefaultRedactFields = "email phone password name address token secret"
+)
+
+// percent is the scale of the sampling percentage.
+const percent = 100
+
+// unmirroredHeaders are never mirrored: the credentials, and the headers changing how a single request is handled,
+// such as the faults of X-Chaos, which would make the shadow differ from the primary.
+const unmirroredHeaders = "Authorization Cookie Proxy-Authorization X-Api-Key X-Break-Glass X-Chaos X-Csrf-Token X-Feature-Overrides"
+
+// errUnredactable is returned for bodies of a media type whose personal data cannot be redacted.
+var errUnredactable = errors.New("body cannot be redacted")
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+}
+
+type doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Mirror sends samples of the requests to the shadow.
+type Mirror struct {
+	base        *url.URL
+	sample      float64
+	maxBodySize int64
+	timeout     time.Duration
+	redact      map[string]bool
+	inFlight    chan struct{}
+	client      doer
+	rec         recorder
+	log         *logger.Logger
+	rand        func() float64
+
+	// compareResponses compares the responses of the shadow to the primary ones, ignoring the ignore fields.
+	compareResponses bool
+	ignore           map[string]bool
+	logSample        float64
+}
+
+// New creates a Mirror from cfg, sending the requests with client.
+func New(cfg *config.Mirror, client doer, rec recorder, l *logger.Logger) (*Mirror, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid mirror base url %q", cfg.BaseURL)
+	}
+
+	m := &Mirror{
+		base:        base,
+		sample:      cfg.Percent / percent,
+		maxBodySize: int64(cfg.MaxBodySize),
+		timeout:     cfg.Timeout,
+		redact:      map[string]bool{},
+		ignore:      map[string]bool{},
+		client:      client,
+		rec:         rec,
+		log:         l,
+		rand:        rand.Float64, //nolint:gosec // sampling, not security sensitive
+	}
+
+	if m.maxBodySize <= 0 {
+		m.maxBodySize = DefaultMaxBodySize
+	}
+
+	if m.timeout <= 0 {
+		m.timeout = DefaultTimeout
+	}
+
+	maxInFlight := cfg.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+
+	m.inFlight = make(chan struct{}, maxInFlight)
+
+	fields := cfg.RedactFields
+	if len(fields) == 0 {
+		fields = strings.Fields(DefaultRedactFields)
+	}
+
+	for _, f := range fields {
+		m.redact[strings.ToLower(f)] = true
+	}
+
+	m.compareResponses = cfg.Compare.Enabled
+	m.logSample = cfg.Compare.LogPercent / percent
+
+	for _, f := range cfg.Compare.IgnoreFields {
+		m.ignore[strings.ToLower(f)] = true
+	}
+
+	return m, nil
+}
+
+// Middleware mirrors the sampled requests once they are handled. The body is read up to the size cap before the
+// handler runs and given back to it unchanged. When comparing, the response is copied up to the size cap as it is
+// written.
+func (m *Mirror) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(ShadowHeader) != "" || m.rand() >= m.sample {
+			c.Next()
+			return
+		}
+
+		body, complete := m.peekBody(c.Request)
+
+		var cw *captureWriter
+		if m.compareResponses {
+			cw = &captureWriter{ResponseWriter: c.Writer, limit: m.maxBodySize}
+			c.Writer = cw
+		}
+
+		c.Next()
+
+		var primary *primaryResponse
+		if cw != nil {
+			c.Writer = cw.ResponseWriter
+			primary = cw.response()
+		}
+
+		if !complete {
+			m.rec.Inc(Metric, metrics.Labels{"result": "skipped"})
+			return
+		}
+
+		req, ok := m.shadowRequest(c.Request, body)
+		if !ok {
+			m.rec.Inc(Metric, metrics.Labels{"result": "skipped"})
+			return
+		}
+
+		select {
+		case m.inFlight <- struct{}{}:
+			route := c.FullPath()
+			safego.Go(context.WithoutCancel(c.Request.Context()), m.log, func(ctx context.Context) {
+				m.send(ctx, req, route, primary)
+			}, safego.WithName("mirror"), safego.WithRecorder(m.rec))
+		default:
+			m.rec.Inc(Metric, metrics.Labels{"result": "dropped"})
+		}
+	}
+}
+
+// peekBody reads the body up to the size cap and restores it for the handler. complete is false when the body is
+// larger than the cap.
+func (m *Mirror) peekBody(r *http.Request) (body []byte, complete bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, m.maxBodySize+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	return body, err == nil && int64(len(body)) <= m.maxBodySize
+}
+
+// shadowRequest builds the sanitized copy of r for the shadow. ok is false when the body cannot be redacted.
+func (m *Mirror) shadowRequest(r *http.Request, body []byte) (*http.Request, bool) {
+	u := *m.base
+	u.Path += r.URL.Path
+	u.RawQuery = m.redactValues(r.URL.Query()).Encode()
+
+	var err error
+	if body, err = m.redactBody(r.Header.Get("Content-Type"), body); err != nil {
+		return nil, false
+	}
+
+	req, err := http.NewRequest(r.Method, u.String(), bytes.NewReader(body)) //nolint:noctx // sent with its own context
+	if err != nil {
+		return nil, false
+	}
+
+	unmirrored := strings.Fields(unmirroredHeaders)
+	for k, v := range r.Header {
+		if !containsFold(unmirrored, k) {
+			req.Header[k] = append([]string(nil), v...)
+		}
+	}
+
+	req.Header.Set(ShadowHeader, "1")
+
+	return req, true
+}
+
+// send sends req to the shadow and compares its response to primary, if not nil.
+func (m *Mirror) send(ctx context.Context, req *http.Request, route string, primary *primaryResponse) {
+	defer func() { <-m.inFlight }()
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	resp, err := m.client.Do(req.WithContext(ctx))
+	if err != nil {
+		m.log.Debug("failed to mirror request", zap.String("url", req.URL.Path), zap.Error(err))
+		m.rec.Inc(Metric, metrics.Labels{"result": "failed"})
+
+		return
+	}
+
+	m.rec.Inc(Metric, metrics.Labels{"result": "sent"})
+
+	if primary != nil {
+		m.compare(req, route, primary, resp)
+	}
+
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck // the rest of the response of the shadow is ignored
+	resp.Body.Close()
+}
+
+// redactBody redacts the fields of JSON and form bodies. Other bodies cannot be inspected and fail with
+// errUnredactable.
+func (m *Mirror) redactBody(contentType string, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return nil, err //nolint:wrapcheck // only tells the body is skipped
+		}
+
+		return json.Marshal(m.redactJSON(v)) //nolint:wrapcheck // only tells the body is skipped
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err //nolint:wrapcheck // only tells the body is skipped
+		}
+
+		return []byte(m.redactValues(values).Encode()), nil
+	default:
+		return nil, errUnredactable
+	}
+}
+
+func (m *Mirror) redactJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			if m.redact[strings.ToLower(k)] {
+				t[k] = Redacted
+			} else {
+				t[k] = m.redactJSON(e)
+			}
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = m.redactJSON(e)
+		}
+	}
+
+	return v
+}
+
+func (m *Mirror) redactValues(values url.Values) url.Values {
+	for k, v := range values {
+		if m.redact[strings.ToLower(k)] {
+			for i := range v {
+				v[i] = Redacted
+			}
+		}
+	}
+
+	return values
+}
+
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,260 @@
+package mirror_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/mirror"
+)
+
+type mirrored struct {
+	method string
+	uri    string
+	header http.Header
+	body   string
+}
+
+func TestMirror_Middleware(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		method      string
+		target      string
+		contentType string
+		header      map[string]string
+		body        string
+	}
+
+	type want struct {
+		mirrored *mirrored
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"json body redacted": {
+			args: args{
+				method:      http.MethodPost,
+				target:      "/photos?email=alice@example.com&albumId=1",
+				contentType: "application/json",
+				header:      map[string]string{"Authorization": "Bearer abc", "Cookie": "session=abc", "X-Request-Id": "r1"},
+				body:        `{"title":"sunset","owner":{"Email":"alice@example.com","tags":[{"name":"alice"}]}}`,
+			},
+			want: want{mirrored: &mirrored{
+				method: http.MethodPost,
+				uri:    "/shadow/photos?albumId=1&email=%5BREDACTED%5D",
+				header: http.Header{"X-Request-Id": {"r1"}, mirror.ShadowHeader: {"1"}},
+				body:   `{"owner":{"Email":"[REDACTED]","tags":[{"name":"[REDACTED]"}]},"title":"sunset"}`,
+			}},
+		},
+		"form body redacted": {
+			args: args{
+				method:      http.MethodPost,
+				target:      "/login",
+				contentType: "application/x-www-form-urlencoded",
+				body:        "user=alice&password=secret",
+			},
+			want: want{mirrored: &mirrored{
+				method: http.MethodPost,
+				uri:    "/shadow/login",
+				header: http.Header{mirror.ShadowHeader: {"1"}},
+				body:   "password=%5BREDACTED%5D&user=alice",
+			}},
+		},
+		"without body": {
+			args: args{method: http.MethodGet, target: "/photos/1"},
+			want: want{mirrored: &mirrored{method: http.MethodGet, uri: "/shadow/photos/1", header: http.Header{mirror.ShadowHeader: {"1"}}}},
+		},
+		"injected faults stripped": {
+			args: args{method: http.MethodGet, target: "/photos/1", header: map[string]string{"X-Chaos": "status=503", "X-Request-Id": "r2"}},
+			want: want{mirrored: &mirrored{method: http.MethodGet, uri: "/shadow/photos/1", header: http.Header{"X-Request-Id": {"r2"}, mirror.ShadowHeader: {"1"}}}},
+		},
+		"body too large": {
+			args: args{method: http.MethodPost, target: "/photos", contentType: "application/json", body: `{"title":"` + strings.Repeat("a", 128) + `"}`},
+		},
+		"body not redactable": {
+			args: args{method: http.MethodPost, target: "/photos/1/image", contentType: "image/png", body: "png"},
+		},
+		"already mirrored": {
+			args: args{method: http.MethodGet, target: "/photos/1", header: map[string]string{mirror.ShadowHeader: "1"}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make(chan mirrored, 1)
+			shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				r.Header.Del("Accept-Encoding")
+				r.Header.Del("Content-Length")
+				r.Header.Del("User-Agent")
+				got <- mirrored{method: r.Method, uri: r.URL.RequestURI(), header: r.Header, body: string(b)}
+			}))
+			t.Cleanup(shadow.Close)
+
+			m, err := mirror.New(&config.Mirror{BaseURL: shadow.URL + "/shadow", Percent: 100, MaxBodySize: 128}, shadow.Client(), metrics.New(), logger.NewNop())
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(m.Middleware())
+			router.Any("/*path", func(c *gin.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				c.String(http.StatusOK, string(b))
+			})
+
+			req := httptest.NewRequest(tt.args.method, tt.args.target, strings.NewReader(tt.args.body))
+			if tt.args.contentType != "" {
+				req.Header.Set("Content-Type", tt.args.contentType)
+			}
+
+			for k, v := range tt.args.header {
+				req.Header.Set(k, v)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			assert.Equal(t, tt.args.body, resp.Body.String(), "the handler reads the original body")
+
+			select {
+			case g := <-got:
+				if tt.want.mirrored != nil {
+					g.header.Del("Content-Type")
+					assert.Equal(t, *tt.want.mirrored, g)
+				} else {
+					assert.Fail(t, "unexpected mirrored request", g.uri)
+				}
+			case <-time.After(200 * time.Millisecond):
+				assert.Nil(t, tt.want.mirrored, "request not mirrored")
+			}
+		})
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	t.Parallel()
+
+	_, err := mirror.New(&config.Mirror{BaseURL: "shadow"}, http.DefaultClient, nil, logger.NewNop())
+	assert.EqualError(t, err, `invalid mirror base url "shadow"`)
+}
+
+// resultRecorder sends the results of the comparisons.
+type resultRecorder chan string
+
+func (r resultRecorder) Inc(name string, labels metrics.Labels) {
+	if name == mirror.ComparisonMetric {
+		r <- labels["route"] + " " + labels["result"]
+	}
+}
+
+func TestMirror_Compare(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		primaryStatus int
+		primaryBody   string
+		shadowStatus  int
+		shadowBody    string
+		contentType   string
+	}
+
+	tests := map[string]struct {
+		args args
+		want string
+	}{
+		"same json in another order": {
+			args: args{
+				primaryStatus: http.StatusOK, primaryBody: `{"id":1,"title":"a","timestamp":"2024-05-01T12:00:00Z"}`,
+				shadowStatus: http.StatusOK, shadowBody: `{"title":"a","id":1,"timestamp":"2024-05-01T12:00:01Z"}`,
+				contentType: "application/json",
+			},
+			want: "/photos/:id match",
+		},
+		"redacted field differs": {
+			args: args{
+				primaryStatus: http.StatusOK, primaryBody: `{"id":1,"owner":{"email":"alice@example.com"}}`,
+				shadowStatus: http.StatusOK, shadowBody: `{"id":1,"owner":{"email":"[REDACTED]"}}`,
+				contentType: "application/json",
+			},
+			want: "/photos/:id match",
+		},
+		"json field differs": {
+			args: args{
+				primaryStatus: http.StatusOK, primaryBody: `{"id":1,"tags":["a","b"]}`,
+				shadowStatus: http.StatusOK, shadowBody: `{"id":1,"tags":["a","c"]}`,
+				contentType: "application/json",
+			},
+			want: "/photos/:id mismatch",
+		},
+		"status differs": {
+			args: args{primaryStatus: http.StatusOK, primaryBody: `{}`, shadowStatus: http.StatusNotFound, shadowBody: `{}`, contentType: "application/json"},
+			want: "/photos/:id mismatch",
+		},
+		"same text": {
+			args: args{primaryStatus: http.StatusOK, primaryBody: "ok", shadowStatus: http.StatusOK, shadowBody: "ok", contentType: "text/plain"},
+			want: "/photos/:id match",
+		},
+		"text differs": {
+			args: args{primaryStatus: http.StatusOK, primaryBody: "ok", shadowStatus: http.StatusOK, shadowBody: "ko", contentType: "text/plain"},
+			want: "/photos/:id mismatch",
+		},
+		"body too large": {
+			args: args{primaryStatus: http.StatusOK, primaryBody: strings.Repeat("a", 129), shadowStatus: http.StatusOK, shadowBody: "a", contentType: "text/plain"},
+			want: "/photos/:id skipped",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.args.contentType)
+				w.WriteHeader(tt.args.shadowStatus)
+				io.WriteString(w, tt.args.shadowBody) //nolint:errcheck // test server
+			}))
+			t.Cleanup(shadow.Close)
+
+			results := make(resultRecorder, 1)
+			cfg := &config.Mirror{
+				BaseURL: shadow.URL, Percent: 100, MaxBodySize: 128,
+				Compare: config.MirrorCompare{Enabled: true, IgnoreFields: []string{"Timestamp"}, LogPercent: 100},
+			}
+
+			m, err := mirror.New(cfg, shadow.Client(), results, logger.NewNop())
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(m.Middleware())
+			router.GET("/photos/:id", func(c *gin.Context) {
+				c.Data(tt.args.primaryStatus, tt.args.contentType, []byte(tt.args.primaryBody))
+			})
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/photos/1", http.NoBody))
+			assert.Equal(t, tt.args.primaryBody, resp.Body.String())
+
+			select {
+			case got := <-results:
+				assert.Equal(t, tt.want, got)
+			case <-time.After(time.Second):
+				assert.Fail(t, "response not compared")
+			}
+		})
+	}
+}
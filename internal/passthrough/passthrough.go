@@ -0,0 +1,160 @@
+// Package passthrough surfaces selected upstream response headers, such as rate limit information, cache validators
+// and deprecation notices, to API consumers. Clients record upstream headers on a Collector carried by the request
+// context and the Middleware copies the ones allowed by the Policy onto the API response.
+package passthrough
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+type collectorKey struct{}
+
+// Collector accumulates the response headers of the upstream calls made while handling a request.
+type Collector struct {
+	mu     sync.Mutex
+	header http.Header
+}
+
+// ContextWithCollector returns a copy of ctx carrying a new Collector.
+func ContextWithCollector(ctx context.Context) (context.Context, *Collector) {
+	c := &Collector{header: http.Header{}}
+	return context.WithValue(ctx, collectorKey{}, c), c
+}
+
+// CollectorFromContext returns the Collector carried by ctx, or nil. All Collector methods are safe to call on nil.
+func CollectorFromContext(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Record records the headers of an upstream response. Later responses replace values of earlier ones.
+func (c *Collector) Record(h http.Header) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, v := range h {
+		c.header[k] = append([]string(nil), v...)
+	}
+}
+
+// Header returns a copy of the recorded headers.
+func (c *Collector) Header() http.Header {
+	if c == nil {
+		return http.Header{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.header.Clone()
+}
+
+// Policy decides which upstream headers are surfaced to API consumers. Everything not allowed is stripped.
+type Policy struct {
+	names    map[string]struct{}
+	prefixes []string
+}
+
+// NewPolicy creates a Policy from the configured header names. A name ending with "*" allows every header with
+// that prefix, e.g. "X-RateLimit-*".
+func NewPolicy(cfg *config.HeaderPassthrough) *Policy {
+	p := &Policy{names: map[string]struct{}{}}
+
+	for _, name := range cfg.Allow {
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			p.prefixes = append(p.prefixes, http.CanonicalHeaderKey(prefix))
+			continue
+		}
+
+		p.names[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	return p
+}
+
+// Allowed reports whether the header is surfaced to API consumers.
+func (p *Policy) Allowed(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if _, ok := p.names[name]; ok {
+		return true
+	}
+
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply copies the allowed upstream headers to dst. Headers already set on dst by the handler take precedence.
+func (p *Policy) Apply(dst, upstream http.Header) {
+	for k, v := range upstream {
+		if _, set := dst[k]; set || !p.Allowed(k) {
+			continue
+		}
+
+		dst[k] = v
+	}
+}
+
+// Middleware attaches a Collector to every request and applies the Policy to the response before it is written.
+func (p *Policy) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, collector := ContextWithCollector(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &headerWriter{ResponseWriter: c.Writer, policy: p, collector: collector}
+
+		c.Next()
+	}
+}
+
+// headerWriter applies the policy right before the status line is written, which is the last moment headers can
+// still be changed.
+type headerWriter struct {
+	gin.ResponseWriter
+	policy    *Policy
+	collector *Collector
+	applied   bool
+}
+
+func (w *headerWriter) apply() {
+	if w.applied {
+		return
+	}
+
+	w.applied = true
+	w.policy.Apply(w.Header(), w.collector.Header())
+}
+
+func (w *headerWriter) WriteHeader(code int) {
+	w.apply()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerWriter) WriteHeaderNow() {
+	w.apply()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerWriter) WriteString(s string) (int, error) {
+	w.apply()
+	return w.ResponseWriter.WriteString(s)
+}
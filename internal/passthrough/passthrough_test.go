@@ -0,0 +1,87 @@
+package passthrough_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/passthrough"
+)
+
+func TestPolicy_Allowed(t *testing.T) {
+	t.Parallel()
+
+	p := passthrough.NewPolicy(&config.HeaderPassthrough{Allow: []string{"x-ratelimit-*", "ETag"}})
+
+	tests := map[string]struct {
+		header string
+		want   bool
+	}{
+		"exact name":       {header: "ETag", want: true},
+		"case insensitive": {header: "etag", want: true},
+		"prefix":           {header: "X-Ratelimit-Remaining", want: true},
+		"not allowed":      {header: "Set-Cookie", want: false},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, p.Allowed(tt.header))
+		})
+	}
+}
+
+func TestPolicy_Middleware(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	hc := client.NewClient(upstream.Client())
+	p := passthrough.NewPolicy(&config.HeaderPassthrough{Allow: []string{"X-RateLimit-*", "Cache-Control"}})
+
+	router := gin.New()
+	router.Use(p.Middleware())
+	router.GET("/", func(c *gin.Context) {
+		resp, err := hc.Get(c.Request.Context(), upstream.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", http.NoBody)
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "41", resp.Header().Get("X-RateLimit-Remaining"))
+	assert.Empty(t, resp.Header().Get("Set-Cookie"))
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+}
+
+func TestCollector_Nil(t *testing.T) {
+	t.Parallel()
+
+	c := passthrough.CollectorFromContext(context.Background())
+	c.Record(http.Header{"A": {"b"}})
+
+	assert.Empty(t, c.Header())
+}
@@ -0,0 +1,166 @@
+package photos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/safego"
+)
+
+// errBatchUnsupported is returned when the upstream does not filter photos by several IDs.
+var errBatchUnsupported = errors.New("upstream does not support batch requests")
+
+// GetPhotosBatch gets the photos of ids, in the same order, with a single upstream request filtering by all of them
+// (?id=1&id=2). When the upstream rejects or ignores the filter, this is remembered and photos are fetched
+// concurrently one by one from then on. Photos missing from a batch response are fetched one by one, so an unknown ID
+// fails with the 404 of the upstream like GetPhotos, and a soft-deleted one with ErrDeleted.
+func (s *Service) GetPhotosBatch(ctx context.Context, ids []int) ([]Photo, error) {
+	if !s.batchUnsupported.Load() {
+		p, err := s.getPhotosBatched(ctx, ids)
+		if !errors.Is(err, errBatchUnsupported) {
+			return p, err
+		}
+
+		s.log.Info("Upstream does not support batch requests, fetching photos one by one", zap.Error(err))
+		s.batchUnsupported.Store(true)
+	}
+
+	return s.getPhotosEach(ctx, ids)
+}
+
+func (s *Service) getPhotosBatched(ctx context.Context, ids []int) ([]Photo, error) {
+	q := url.Values{}
+	for _, id := range ids {
+		q.Add("id", strconv.Itoa(id))
+	}
+
+	u := s.photosURL + "?" + q.Encode()
+
+	batch, err := httpclient.GetAs[[]upstreamPhoto](ctx, s.client, u)
+	if unsupportedBatch(err) {
+		return nil, fmt.Errorf("%w: %w", errBatchUnsupported, err)
+	}
+
+	if err != nil {
+		s.log.Error("Failed to get photos batch", zap.Error(err))
+		return nil, fmt.Errorf("failed to get photos batch: %w", err)
+	}
+
+	if batch == nil {
+		return nil, fmt.Errorf("%w: empty response", errBatchUnsupported)
+	}
+
+	byID := make(map[int]Photo, len(*batch))
+	for _, p := range *batch {
+		if !slices.Contains(ids, p.ID) {
+			return nil, fmt.Errorf("%w: unrequested photo %d returned", errBatchUnsupported, p.ID)
+		}
+
+		byID[p.ID] = toPhoto(p)
+	}
+
+	if err = s.validator.Validate(u, batch); err != nil {
+		s.log.Error("Invalid photos batch returned by upstream", zap.Error(err))
+		return nil, fmt.Errorf("failed to get photos batch: %w", err)
+	}
+
+	var missing []int
+
+	for _, id := range ids {
+		if _, ok := byID[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		var fetched []Photo
+		if fetched, err = s.getPhotosEach(ctx, missing); err != nil {
+			return nil, err
+		}
+
+		for _, p := range fetched {
+			byID[p.ID] = p
+		}
+	}
+
+	ordered := make([]Photo, 0, len(ids))
+	for _, id := range ids {
+		ordered = append(ordered, byID[id])
+	}
+
+	kept, err := s.applyDeletions(ctx, ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(kept) < len(ordered) {
+		return nil, fmt.Errorf("failed to get photos batch: %w", ErrDeleted)
+	}
+
+	return kept, nil
+}
+
+// unsupportedBatch reports whether err shows the upstream does not understand batch requests: it rejected them with
+// a client error status or answered with something other than a list of photos.
+func unsupportedBatch(err error) bool {
+	var httpErr *httpclient.HTTPError
+	if errors.As(err, &httpErr) {
+		return slices.Contains([]int{http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusUnprocessableEntity, http.StatusNotImplemented}, httpErr.StatusCode)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+
+	var syntaxErr *json.SyntaxError
+
+	return errors.As(err, &typeErr) || errors.As(err, &syntaxErr)
+}
+
+// getPhotosEach gets the photos of ids concurrently, one request per photo.
+func (s *Service) getPhotosEach(ctx context.Context, ids []int) ([]Photo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fetched := make([]Photo, len(ids))
+	errs := make([]error, len(ids))
+	g := safego.NewGroup(s.log, s.concurrency, safego.WithName("photos-batch"), safego.WithRecorder(s.rec))
+
+	for i, id := range ids {
+		g.Go(ctx, func(ctx context.Context) {
+			p, err := s.GetPhotos(ctx, id)
+			if err != nil {
+				errs[i] = err
+				// The batch fails as a whole, the other requests are not needed anymore.
+				cancel()
+
+				return
+			}
+
+			fetched[i] = *p
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	return fetched, nil
+}
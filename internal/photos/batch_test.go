@@ -0,0 +1,124 @@
+package photos_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/mapping"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+func TestGetPhotosBatch(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+	upstream := fake.NewUpstream(g)
+	photosOf := func(ids ...int) []photos.Photo {
+		return mapping.Slice(ids, func(id int) photos.Photo { return fakePhoto(g, id) })
+	}
+
+	type args struct {
+		ids []int
+	}
+
+	type fields struct {
+		// handler wraps the fake upstream to emulate upstreams without batch support.
+		handler func(next http.Handler) http.Handler
+	}
+
+	type want struct {
+		photos []photos.Photo
+		// requests are the upstream requests of a first and a second call.
+		requests [2]int64
+		notFound bool
+	}
+
+	tests := map[string]struct {
+		args   args
+		fields fields
+		want   want
+	}{
+		"batched": {
+			args:   args{ids: []int{3, 1, 2}},
+			fields: fields{handler: func(next http.Handler) http.Handler { return next }},
+			want:   want{photos: photosOf(3, 1, 2), requests: [2]int64{1, 1}},
+		},
+		"filter ignored": {
+			args: args{ids: []int{3, 1, 2}},
+			fields: fields{handler: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					q := r.URL.Query()
+					q.Del("id")
+					r.URL.RawQuery = q.Encode()
+					next.ServeHTTP(w, r)
+				})
+			}},
+			want: want{photos: photosOf(3, 1, 2), requests: [2]int64{4, 3}},
+		},
+		"batch rejected": {
+			args: args{ids: []int{3, 1, 2}},
+			fields: fields{handler: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Query().Has("id") {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+
+					next.ServeHTTP(w, r)
+				})
+			}},
+			want: want{photos: photosOf(3, 1, 2), requests: [2]int64{4, 3}},
+		},
+		"missing photo": {
+			args:   args{ids: []int{1, fake.Photos + 1}},
+			fields: fields{handler: func(next http.Handler) http.Handler { return next }},
+			want:   want{notFound: true, requests: [2]int64{2, 2}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int64
+
+			h := tt.fields.handler(upstream)
+			counting := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				h.ServeHTTP(w, r)
+			})
+			s := photos.NewService(client.NewClient(&http.Client{Transport: fake.NewTransport(counting)}), logger.NewNop())
+
+			for i, want := range tt.want.requests {
+				requests.Store(0)
+
+				got, err := s.GetPhotosBatch(context.Background(), tt.args.ids)
+
+				var httpErr *client.HTTPError
+				if tt.want.notFound {
+					assert.True(t, errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound, "call %d: %v", i, err)
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, tt.want.photos, got)
+				}
+
+				assert.Equal(t, want, requests.Load(), "requests of call %d", i)
+			}
+		})
+	}
+}
+
+// fakePhoto returns the photo id generated by g, as returned by a Service.
+func fakePhoto(g *fake.Generator, id int) photos.Photo {
+	p := g.Photo(id)
+	return photos.Photo{AlbumID: p.AlbumID, ID: p.ID, Title: p.Title, URL: p.URL, ThumbnailURL: p.ThumbnailURL}
+}
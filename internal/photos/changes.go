@@ -0,0 +1,58 @@
+package photos
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ChangeOp is the kind of a Change.
+type ChangeOp string
+
+// Kinds of changes to the photos.
+const (
+	ChangeCreated  ChangeOp = "created"
+	ChangeDeleted  ChangeOp = "deleted"
+	ChangeRestored ChangeOp = "restored"
+)
+
+// Change is a change made to a photo through the Service. The Photo is as it is after the change, with its DeletedAt
+// set once deleted.
+type Change struct {
+	Op    ChangeOp
+	Photo Photo
+}
+
+// Listener is notified of the changes made through the Service, once they succeeded, with the context of the change.
+// Changes made to the upstream directly are not seen, so listeners keeping a copy of the photos need a way to resync.
+type Listener interface {
+	PhotoChanged(ctx context.Context, c Change)
+}
+
+// WithListeners notifies ls of the changes to the photos. Listeners are called synchronously and must not block.
+func WithListeners(ls ...Listener) Option {
+	return func(s *Service) {
+		s.listeners = append(s.listeners, ls...)
+	}
+}
+
+func (s *Service) notify(ctx context.Context, op ChangeOp, p Photo) {
+	for _, l := range s.listeners {
+		l.PhotoChanged(ctx, Change{Op: op, Photo: p})
+	}
+}
+
+// notifyRestored notifies the restore of the photo id, which has to be read again as Restore does not read it.
+func (s *Service) notifyRestored(ctx context.Context, id int) {
+	if len(s.listeners) == 0 {
+		return
+	}
+
+	p, err := s.GetPhotos(withoutDeleted(ctx), id)
+	if err != nil {
+		s.log.Error("Failed to read restored photo", zap.Int("id", id), zap.Error(err))
+		return
+	}
+
+	s.notify(ctx, ChangeRestored, *p)
+}
@@ -0,0 +1,49 @@
+package photos_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+type recordingListener struct {
+	changes []photos.Change
+}
+
+func (r *recordingListener) PhotoChanged(_ context.Context, c photos.Change) {
+	r.changes = append(r.changes, c)
+}
+
+func TestService_Listeners(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(g))})
+	l := &recordingListener{}
+	s := photos.NewService(hc, logger.NewNop(), photos.WithDeletions(photos.NewMemoryDeletions()), photos.WithListeners(l))
+	ctx := context.Background()
+
+	created, err := s.Create(ctx, photos.Photo{AlbumID: 1, Title: "new", URL: "https://example.com/n", ThumbnailURL: "https://example.com/t/n"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, s.Delete(ctx, 3))
+	assert.ErrorIs(t, s.Delete(ctx, 3), photos.ErrDeleted)
+	assert.NoError(t, s.Restore(ctx, 3))
+
+	if !assert.Len(t, l.changes, 3) {
+		return
+	}
+
+	assert.Equal(t, photos.Change{Op: photos.ChangeCreated, Photo: *created}, l.changes[0])
+	assert.Equal(t, photos.ChangeDeleted, l.changes[1].Op)
+	assert.NotNil(t, l.changes[1].Photo.DeletedAt)
+	assert.Equal(t, photos.Change{Op: photos.ChangeRestored, Photo: fakePhoto(g, 3)}, l.changes[2])
+}
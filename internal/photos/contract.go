@@ -0,0 +1,73 @@
+package photos
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/contract"
+	"github.com/twk/skeleton-go-api/internal/jsonschema"
+)
+
+// schemas are the JSON Schemas of the upstream responses decoded by the Service.
+//
+//go:embed schemas
+var schemas embed.FS
+
+// Schemas returns the JSON Schemas of the upstream responses, checked by ContractChecks.
+func Schemas() fs.FS {
+	sub, err := fs.Sub(schemas, "schemas")
+	if err != nil {
+		panic(err) // the directory is embedded
+	}
+
+	return sub
+}
+
+// ResponseSchemas returns the options of the client of the upstream at baseURL, or DefaultBaseURL if empty, validating
+// the responses decoded by the Service against their schemas, so that a changed upstream fails with
+// httpclient.ErrSchemaViolation rather than as photos missing fields.
+func ResponseSchemas(baseURL string) ([]httpclient.Option, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	u, err := url.Parse(photosResource(baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid photos base url: %w", err)
+	}
+
+	photo, err := jsonschema.LoadSchema(Schemas(), "photo.schema.json")
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the error names the schema
+	}
+
+	list, err := jsonschema.LoadSchema(Schemas(), "photos.schema.json")
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the error names the schema
+	}
+
+	return []httpclient.Option{
+		httpclient.WithResponseSchema(u.Path, list),
+		httpclient.WithResponseSchema(u.Path+"/*", photo),
+	}, nil
+}
+
+// ContractChecks returns the requests the Service sends to the upstream at baseURL, or DefaultBaseURL if empty, with the
+// schemas of their responses, so that a change of the upstream breaking the decoding of the photos is caught by
+// verifying them.
+func ContractChecks(baseURL string) []contract.Check {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	photosURL := photosResource(baseURL)
+
+	return []contract.Check{
+		{Name: "photo", URL: photosURL + "/1", Schema: "photo.schema.json"},
+		{Name: "album photos", URL: fmt.Sprintf("%s?albumId=1&_page=1&_limit=%d", photosURL, albumPageSize), Schema: "photos.schema.json"},
+		{Name: "photo batch", URL: photosURL + "?id=1&id=2", Schema: "photos.schema.json"},
+	}
+}
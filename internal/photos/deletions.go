@@ -0,0 +1,266 @@
+package photos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+var (
+	// ErrDeleted is returned for photos which are soft-deleted, unless deleted photos are included.
+	ErrDeleted = errors.New("photo deleted")
+	// ErrNotDeleted is returned when restoring a photo which is not soft-deleted.
+	ErrNotDeleted = errors.New("photo not deleted")
+	// errNoDeletions is returned when deleting or restoring photos without a DeletionStore.
+	errNoDeletions = errors.New("soft delete is not enabled")
+)
+
+// DeletionStore keeps when photos were soft-deleted. The photos themselves stay in the upstream, deletion marks are
+// overlaid on them. Marks are kept per scope, the tenant of the requests.
+type DeletionStore interface {
+	// Deleted returns when the photos of ids were deleted, without the ones which are not.
+	Deleted(ctx context.Context, scope string, ids []int) (map[int]time.Time, error)
+	// MarkDeleted marks the photo id deleted at the given time.
+	MarkDeleted(ctx context.Context, scope string, id int, at time.Time) error
+	// Unmark removes the deletion mark of the photo id, reporting whether it was deleted.
+	Unmark(ctx context.Context, scope string, id int) (bool, error)
+}
+
+type includeDeletedKey struct{}
+
+// IncludeDeleted returns a context in which the photos read by a Service include the soft-deleted ones, with their
+// DeletedAt set.
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx is from IncludeDeleted.
+func IncludesDeleted(ctx context.Context) bool {
+	v, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return v
+}
+
+// WithDeletions enables soft delete: deleted photos are excluded from reads, unless IncludeDeleted, and may be
+// restored.
+func WithDeletions(d DeletionStore) Option {
+	return func(s *Service) {
+		s.deletions = d
+	}
+}
+
+// Delete soft-deletes the photo id. It fails like GetPhotos if the photo does not exist or is already deleted.
+func (s *Service) Delete(ctx context.Context, id int) error {
+	if s.deletions == nil {
+		return errNoDeletions
+	}
+
+	// Deleting a photo of another tenant or an unknown one must fail as reading it does.
+	p, err := s.GetPhotos(withoutDeleted(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	at := s.now().UTC()
+	if err = s.deletions.MarkDeleted(ctx, tenant.FromContext(ctx), id, at); err != nil {
+		s.log.Error("Failed to delete photo", zap.Int("id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete photo: %w", err)
+	}
+
+	p.DeletedAt = &at
+	s.notify(ctx, ChangeDeleted, *p)
+
+	return nil
+}
+
+// Restore undeletes the photo id, failing with ErrNotDeleted if it is not soft-deleted.
+func (s *Service) Restore(ctx context.Context, id int) error {
+	if s.deletions == nil {
+		return errNoDeletions
+	}
+
+	ok, err := s.deletions.Unmark(ctx, tenant.FromContext(ctx), id)
+	if err != nil {
+		s.log.Error("Failed to restore photo", zap.Int("id", id), zap.Error(err))
+		return fmt.Errorf("failed to restore photo: %w", err)
+	}
+
+	if !ok {
+		return fmt.Errorf("photo %d: %w", id, ErrNotDeleted)
+	}
+
+	s.notifyRestored(ctx, id)
+
+	return nil
+}
+
+// withoutDeleted returns ctx without IncludeDeleted.
+func withoutDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, false)
+}
+
+// ApplyDeletions drops the soft-deleted photos of p, or sets their DeletedAt when ctx includes deleted photos. It is
+// for photos read from elsewhere than the Service, such as a search index.
+func (s *Service) ApplyDeletions(ctx context.Context, p []Photo) ([]Photo, error) {
+	return s.applyDeletions(ctx, p)
+}
+
+// applyDeletions drops the soft-deleted photos of p, or sets their DeletedAt when deleted photos are included.
+func (s *Service) applyDeletions(ctx context.Context, p []Photo) ([]Photo, error) {
+	if s.deletions == nil || len(p) == 0 {
+		return p, nil
+	}
+
+	ids := make([]int, 0, len(p))
+	for _, photo := range p {
+		ids = append(ids, photo.ID)
+	}
+
+	deleted, err := s.deletions.Deleted(ctx, tenant.FromContext(ctx), ids)
+	if err != nil {
+		s.log.Error("Failed to get deleted photos", zap.Error(err))
+		return nil, fmt.Errorf("failed to get deleted photos: %w", err)
+	}
+
+	include := IncludesDeleted(ctx)
+	kept := make([]Photo, 0, len(p))
+
+	for _, photo := range p {
+		at, ok := deleted[photo.ID]
+
+		switch {
+		case !ok:
+			kept = append(kept, photo)
+		case include:
+			photo.DeletedAt = &at
+			kept = append(kept, photo)
+		}
+	}
+
+	return kept, nil
+}
+
+// MemoryDeletions is a DeletionStore keeping the marks in memory. Marks are lost on restart and not shared between
+// instances, use RedisDeletions when running several.
+type MemoryDeletions struct {
+	mu      sync.Mutex
+	deleted map[string]map[int]time.Time
+}
+
+// NewMemoryDeletions creates a MemoryDeletions.
+func NewMemoryDeletions() *MemoryDeletions {
+	return &MemoryDeletions{deleted: map[string]map[int]time.Time{}}
+}
+
+// Deleted implements DeletionStore.
+func (m *MemoryDeletions) Deleted(_ context.Context, scope string, ids []int) (map[int]time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make(map[int]time.Time)
+
+	for _, id := range ids {
+		if at, ok := m.deleted[scope][id]; ok {
+			deleted[id] = at
+		}
+	}
+
+	return deleted, nil
+}
+
+// MarkDeleted implements DeletionStore.
+func (m *MemoryDeletions) MarkDeleted(_ context.Context, scope string, id int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.deleted[scope] == nil {
+		m.deleted[scope] = map[int]time.Time{}
+	}
+
+	m.deleted[scope][id] = at
+
+	return nil
+}
+
+// Unmark implements DeletionStore.
+func (m *MemoryDeletions) Unmark(_ context.Context, scope string, id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.deleted[scope][id]
+	delete(m.deleted[scope], id)
+
+	return ok, nil
+}
+
+// redisDeletionsKeyPrefix namespaces the hashes of the deletion marks in Redis, one per scope.
+const redisDeletionsKeyPrefix = "photos:deleted:"
+
+// RedisDeletions is a DeletionStore keeping the marks in Redis hashes of photo IDs to deletion times, so they are
+// shared between instances and survive restarts.
+type RedisDeletions struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisDeletions creates a RedisDeletions.
+func NewRedisDeletions(rdb redis.UniversalClient) *RedisDeletions {
+	return &RedisDeletions{rdb: rdb}
+}
+
+// Deleted implements DeletionStore.
+func (r *RedisDeletions) Deleted(ctx context.Context, scope string, ids []int) (map[int]time.Time, error) {
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, strconv.Itoa(id))
+	}
+
+	values, err := r.rdb.HMGet(ctx, redisDeletionsKeyPrefix+scope, fields...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deletion marks: %w", err)
+	}
+
+	deleted := make(map[int]time.Time)
+
+	var at time.Time
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		if at, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return nil, fmt.Errorf("invalid deletion mark of photo %d: %w", ids[i], err)
+		}
+
+		deleted[ids[i]] = at
+	}
+
+	return deleted, nil
+}
+
+// MarkDeleted implements DeletionStore.
+func (r *RedisDeletions) MarkDeleted(ctx context.Context, scope string, id int, at time.Time) error {
+	if err := r.rdb.HSet(ctx, redisDeletionsKeyPrefix+scope, strconv.Itoa(id), at.Format(time.RFC3339Nano)).Err(); err != nil {
+		return fmt.Errorf("failed to save deletion mark: %w", err)
+	}
+
+	return nil
+}
+
+// Unmark implements DeletionStore.
+func (r *RedisDeletions) Unmark(ctx context.Context, scope string, id int) (bool, error) {
+	n, err := r.rdb.HDel(ctx, redisDeletionsKeyPrefix+scope, strconv.Itoa(id)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete deletion mark: %w", err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,80 @@
+package photos_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+func TestService_SoftDelete(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(g))})
+	s := photos.NewService(hc, logger.NewNop(), photos.WithDeletions(photos.NewMemoryDeletions()))
+	ctx := tenant.ContextWithTenant(context.Background(), "acme")
+	include := photos.IncludeDeleted(ctx)
+
+	assert.ErrorIs(t, s.Restore(ctx, 2), photos.ErrNotDeleted)
+	assert.NoError(t, s.Delete(ctx, 2))
+	assert.ErrorIs(t, s.Delete(ctx, 2), photos.ErrDeleted)
+
+	_, err := s.GetPhotos(ctx, 2)
+	assert.ErrorIs(t, err, photos.ErrDeleted)
+
+	p, err := s.GetPhotos(include, 2)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, p.DeletedAt)
+	}
+
+	album, err := s.ListAllByAlbum(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, album, fake.PhotosPerAlbum-1)
+	assert.NotContains(t, album, fakePhoto(g, 2))
+
+	album, err = s.ListAllByAlbum(include, 1)
+	assert.NoError(t, err)
+	assert.Len(t, album, fake.PhotosPerAlbum)
+
+	_, err = s.GetPhotosBatch(ctx, []int{1, 2})
+	assert.ErrorIs(t, err, photos.ErrDeleted)
+
+	batch, err := s.GetPhotosBatch(include, []int{1, 2})
+	if assert.NoError(t, err) && assert.Len(t, batch, 2) {
+		assert.Nil(t, batch[0].DeletedAt)
+		assert.NotNil(t, batch[1].DeletedAt)
+	}
+
+	// Deletions are scoped to the tenant.
+	p, err = s.GetPhotos(tenant.ContextWithTenant(context.Background(), "globex"), 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, fakePhoto(g, 2), *p)
+	}
+
+	assert.NoError(t, s.Restore(ctx, 2))
+
+	p, err = s.GetPhotos(ctx, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, fakePhoto(g, 2), *p)
+	}
+}
+
+func TestService_SoftDeleteUnknown(t *testing.T) {
+	t.Parallel()
+
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(fake.NewGenerator(1)))})
+	s := photos.NewService(hc, logger.NewNop(), photos.WithDeletions(photos.NewMemoryDeletions()))
+
+	var httpErr *client.HTTPError
+
+	err := s.Delete(context.Background(), fake.Photos+1)
+	assert.True(t, errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound, err)
+}
@@ -0,0 +1,64 @@
+package photos
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/mapping"
+)
+
+// exportPageSize is the number of photos requested per upstream page when exporting, and handed to the callback of
+// Export at once.
+const exportPageSize = 100
+
+// Export streams all photos to fn, a page at a time as they are fetched from the upstream, so the dataset is never held
+// in memory. Soft-deleted photos are left out unless deleted photos are included. It stops at the first error of fn.
+func (s *Service) Export(ctx context.Context, fn func(p []Photo) error) error {
+	p := httpclient.Paginate[upstreamPhoto](ctx, s.client, s.photosURL, httpclient.WithPageNumbers("_page", "_limit", exportPageSize))
+	page := make([]upstreamPhoto, 0, exportPageSize)
+
+	for more := true; more; {
+		more = p.Next()
+		if more {
+			page = append(page, p.Item())
+		}
+
+		if len(page) < exportPageSize && more {
+			continue
+		}
+
+		if err := s.exportPage(ctx, page, fn); err != nil {
+			return err
+		}
+
+		page = page[:0]
+	}
+
+	if err := p.Err(); err != nil {
+		s.log.Error("Failed to export photos", zap.Error(err))
+		return fmt.Errorf("failed to export photos: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) exportPage(ctx context.Context, page []upstreamPhoto, fn func(p []Photo) error) error {
+	if len(page) == 0 {
+		return nil
+	}
+
+	if err := s.validator.Validate(s.photosURL, page); err != nil {
+		s.log.Error("Invalid photos returned by upstream", zap.Error(err))
+		return fmt.Errorf("failed to export photos: %w", err)
+	}
+
+	kept, err := s.applyDeletions(ctx, mapping.Slice(page, toPhoto))
+	if err != nil {
+		return err
+	}
+
+	return fn(kept)
+}
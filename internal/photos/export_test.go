@@ -0,0 +1,13 @@
+package photos
+
+import "encoding/json"
+
+// MapUpstream maps a photo in the JSON of the upstream to a Photo.
+func MapUpstream(b []byte) (Photo, error) {
+	var p upstreamPhoto
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Photo{}, err
+	}
+
+	return toPhoto(p), nil
+}
@@ -0,0 +1,286 @@
+package photos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// FallbackMetric counts the upstream failures of reads by outcome: "served" when the last known photos were served
+// instead, "miss" when they were unknown or too old, and "error" when the FallbackStore failed.
+const FallbackMetric = "photos_fallback_total"
+
+// fallbackStoreTimeout bounds the FallbackStore lookups, which run after the context of the read may have expired.
+const fallbackStoreTimeout = time.Second
+
+// FallbackStore keeps the last known photos, to be served while the upstream fails. Photos are kept per scope, the
+// tenant of the requests.
+type FallbackStore interface {
+	// LastKnown returns the last known photos of ids and when they were fetched, without the ones which are unknown.
+	LastKnown(ctx context.Context, scope string, ids []int) (map[int]Photo, error)
+	// Remember keeps p, fetched at p.LastFetched, as the last known photos.
+	Remember(ctx context.Context, scope string, p []Photo) error
+}
+
+// Fallback decorates a Service, remembering the photos it reads and serving the last known ones when the upstream
+// fails, so that its transient outages do not fail the reads. Photos served this way have their LastFetched set.
+type Fallback struct {
+	*Service
+
+	store FallbackStore
+	// maxStaleness is how old a photo may be to be served, zero for any age.
+	maxStaleness time.Duration
+}
+
+// NewFallback creates the Fallback of s keeping the photos in store, serving them up to maxStaleness old, or any age
+// if zero. The fallbacks are counted in FallbackMetric with the recorder of s.
+func NewFallback(s *Service, store FallbackStore, maxStaleness time.Duration) *Fallback {
+	return &Fallback{Service: s, store: store, maxStaleness: maxStaleness}
+}
+
+// GetPhotos gets the photo id like Service.GetPhotos, or its last known version if the upstream fails.
+func (f *Fallback) GetPhotos(ctx context.Context, id int) (*Photo, error) {
+	p, err := f.Service.GetPhotos(ctx, id)
+	if err == nil {
+		f.remember(ctx, []Photo{*p})
+		return p, nil
+	}
+
+	known := f.lastKnown(ctx, []int{id}, err)
+	if known == nil {
+		return nil, err
+	}
+
+	return &known[0], nil
+}
+
+// GetPhotosBatch gets the photos of ids like Service.GetPhotosBatch, or their last known versions if the upstream
+// fails and they are all known.
+func (f *Fallback) GetPhotosBatch(ctx context.Context, ids []int) ([]Photo, error) {
+	p, err := f.Service.GetPhotosBatch(ctx, ids)
+	if err == nil {
+		f.remember(ctx, p)
+		return p, nil
+	}
+
+	known := f.lastKnown(ctx, ids, err)
+	if known == nil {
+		return nil, err
+	}
+
+	return known, nil
+}
+
+// remember keeps the photos p, without their deletion, as the last known ones.
+func (f *Fallback) remember(ctx context.Context, p []Photo) {
+	now := f.now().UTC()
+	kept := make([]Photo, 0, len(p))
+
+	for _, photo := range p {
+		if photo.DeletedAt == nil {
+			photo.LastFetched = now
+			kept = append(kept, photo)
+		}
+	}
+
+	if len(kept) == 0 {
+		return
+	}
+
+	if err := f.store.Remember(ctx, tenant.FromContext(ctx), kept); err != nil {
+		f.log.Warn("Failed to remember photos", zap.Error(err))
+	}
+}
+
+// lastKnown returns the last known photos of ids after the read failed with err, or nil if err is not an upstream
+// failure or one of the photos is unknown, too old or soft-deleted meanwhile.
+func (f *Fallback) lastKnown(ctx context.Context, ids []int, err error) []Photo {
+	if !upstreamFailure(err) {
+		return nil
+	}
+
+	// The read may have failed because its context expired, which must not prevent the lookup.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fallbackStoreTimeout)
+	defer cancel()
+
+	known, storeErr := f.store.LastKnown(ctx, tenant.FromContext(ctx), ids)
+	if storeErr != nil {
+		f.log.Warn("Failed to get last known photos", zap.Error(storeErr))
+		f.count("error")
+
+		return nil
+	}
+
+	p := make([]Photo, 0, len(ids))
+
+	for _, id := range ids {
+		photo, ok := known[id]
+		if !ok || (f.maxStaleness > 0 && f.now().Sub(photo.LastFetched) > f.maxStaleness) {
+			f.count("miss")
+			return nil
+		}
+
+		p = append(p, photo)
+	}
+
+	p, storeErr = f.applyDeletions(ctx, p)
+	if storeErr != nil || len(p) != len(ids) {
+		return nil
+	}
+
+	f.count("served")
+	f.log.Warn("Serving last known photos on upstream failure", zap.Ints("ids", ids), zap.Error(err))
+	logger.EventFromContext(ctx).Add(zap.Bool("stale", true))
+
+	return p
+}
+
+func (f *Fallback) count(result string) {
+	if f.rec != nil {
+		f.rec.Inc(FallbackMetric, metrics.Labels{"result": result})
+	}
+}
+
+// upstreamFailure reports whether err is a failure of the upstream rather than an answer: a transport error, a
+// timeout, a server error, a rate limit or an invalid response.
+func upstreamFailure(err error) bool {
+	if errors.Is(err, ErrDeleted) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var httpErr *httpclient.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// MemoryFallback is a FallbackStore keeping the photos in memory. Photos are lost on restart and not shared between
+// instances, use RedisFallback when running several.
+type MemoryFallback struct {
+	mu     sync.Mutex
+	photos map[string]map[int]Photo
+}
+
+// NewMemoryFallback creates a MemoryFallback.
+func NewMemoryFallback() *MemoryFallback {
+	return &MemoryFallback{photos: map[string]map[int]Photo{}}
+}
+
+// LastKnown implements FallbackStore.
+func (m *MemoryFallback) LastKnown(_ context.Context, scope string, ids []int) (map[int]Photo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	known := make(map[int]Photo)
+
+	for _, id := range ids {
+		if p, ok := m.photos[scope][id]; ok {
+			known[id] = p
+		}
+	}
+
+	return known, nil
+}
+
+// Remember implements FallbackStore.
+func (m *MemoryFallback) Remember(_ context.Context, scope string, p []Photo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.photos[scope] == nil {
+		m.photos[scope] = map[int]Photo{}
+	}
+
+	for _, photo := range p {
+		m.photos[scope][photo.ID] = photo
+	}
+
+	return nil
+}
+
+// redisFallbackKeyPrefix namespaces the hashes of the last known photos in Redis, one per scope.
+const redisFallbackKeyPrefix = "photos:last-known:"
+
+// redisFallbackPhoto is a photo kept by RedisFallback, with when it was fetched, which its JSON contract leaves out.
+type redisFallbackPhoto struct {
+	Photo       Photo     `json:"photo"`
+	LastFetched time.Time `json:"lastFetched"`
+}
+
+// RedisFallback is a FallbackStore keeping the photos in Redis hashes of photo IDs to JSON photos, so they are shared
+// between instances and survive restarts.
+type RedisFallback struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisFallback creates a RedisFallback.
+func NewRedisFallback(rdb redis.UniversalClient) *RedisFallback {
+	return &RedisFallback{rdb: rdb}
+}
+
+// LastKnown implements FallbackStore.
+func (r *RedisFallback) LastKnown(ctx context.Context, scope string, ids []int) (map[int]Photo, error) {
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, strconv.Itoa(id))
+	}
+
+	values, err := r.rdb.HMGet(ctx, redisFallbackKeyPrefix+scope, fields...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last known photos: %w", err)
+	}
+
+	known := make(map[int]Photo)
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		var p redisFallbackPhoto
+		if err = json.Unmarshal([]byte(s), &p); err != nil {
+			return nil, fmt.Errorf("invalid last known photo %d: %w", ids[i], err)
+		}
+
+		p.Photo.LastFetched = p.LastFetched
+		known[ids[i]] = p.Photo
+	}
+
+	return known, nil
+}
+
+// Remember implements FallbackStore.
+func (r *RedisFallback) Remember(ctx context.Context, scope string, p []Photo) error {
+	values := make([]any, 0, 2*len(p))
+
+	for _, photo := range p {
+		b, err := json.Marshal(redisFallbackPhoto{Photo: photo, LastFetched: photo.LastFetched})
+		if err != nil {
+			return fmt.Errorf("failed to encode photo: %w", err)
+		}
+
+		values = append(values, strconv.Itoa(photo.ID), string(b))
+	}
+
+	if err := r.rdb.HSet(ctx, redisFallbackKeyPrefix+scope, values...).Err(); err != nil {
+		return fmt.Errorf("failed to save last known photos: %w", err)
+	}
+
+	return nil
+}
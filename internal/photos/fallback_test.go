@@ -0,0 +1,106 @@
+package photos_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// outage fails the requests to the upstream with status while set, or with a transport error if status is -1.
+type outage struct {
+	next   http.RoundTripper
+	status atomic.Int32
+}
+
+func (o *outage) RoundTrip(r *http.Request) (*http.Response, error) {
+	switch status := int(o.status.Load()); status {
+	case 0:
+		return o.next.RoundTrip(r)
+	case -1:
+		return nil, errors.New("connection refused")
+	default:
+		return &http.Response{StatusCode: status, Body: http.NoBody, Header: http.Header{}, Request: r}, nil
+	}
+}
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+	up := &outage{next: fake.NewTransport(fake.NewUpstream(g))}
+	mr := metrics.New()
+	s := photos.NewService(client.NewClient(&http.Client{Transport: up}), logger.NewNop(), photos.WithRecorder(mr))
+	f := photos.NewFallback(s, photos.NewMemoryFallback(), time.Hour)
+	ctx := tenant.ContextWithTenant(context.Background(), "acme")
+
+	p, err := f.GetPhotos(ctx, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, p.LastFetched.IsZero())
+
+	_, err = f.GetPhotosBatch(ctx, []int{2, 3})
+	assert.NoError(t, err)
+
+	up.status.Store(-1)
+
+	p, err = f.GetPhotos(ctx, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, fakePhoto(g, 1).Title, p.Title)
+		assert.False(t, p.LastFetched.IsZero())
+	}
+
+	up.status.Store(http.StatusServiceUnavailable)
+
+	batch, err := f.GetPhotosBatch(ctx, []int{3, 2})
+	if assert.NoError(t, err) && assert.Len(t, batch, 2) {
+		assert.Equal(t, 3, batch[0].ID)
+		assert.Equal(t, 2, batch[1].ID)
+	}
+
+	// Unknown photos, those of other tenants and answers of the upstream are not served from the fallback.
+	_, err = f.GetPhotosBatch(ctx, []int{1, 4})
+	assert.Error(t, err)
+
+	_, err = f.GetPhotos(tenant.ContextWithTenant(context.Background(), "globex"), 1)
+	assert.Error(t, err)
+
+	up.status.Store(http.StatusNotFound)
+
+	_, err = f.GetPhotos(ctx, 1)
+	assert.Error(t, err)
+
+	resp := httptest.NewRecorder()
+	mr.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	assert.Contains(t, resp.Body.String(), `photos_fallback_total{result="served"} 2`)
+	assert.Contains(t, resp.Body.String(), `photos_fallback_total{result="miss"} 2`)
+}
+
+func TestFallback_MaxStaleness(t *testing.T) {
+	t.Parallel()
+
+	up := &outage{next: fake.NewTransport(fake.NewUpstream(fake.NewGenerator(1)))}
+	store := photos.NewMemoryFallback()
+	f := photos.NewFallback(photos.NewService(client.NewClient(&http.Client{Transport: up}), logger.NewNop()), store, time.Hour)
+
+	assert.NoError(t, store.Remember(context.Background(), "", []photos.Photo{{ID: 1, LastFetched: time.Now().Add(-2 * time.Hour)}}))
+
+	up.status.Store(http.StatusBadGateway)
+
+	_, err := f.GetPhotos(context.Background(), 1)
+	assert.Error(t, err)
+}
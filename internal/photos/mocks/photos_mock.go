@@ -1,15 +1,22 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: ./internal/photos/photos.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./internal/photos/photos.go -package=mock_photos -typed
+//
 
 // Package mock_photos is a generated GoMock package.
 package mock_photos
 
 import (
 	context "context"
+	io "io"
 	http "net/http"
 	reflect "reflect"
 
-	gomock "github.com/golang/mock/gomock"
+	client "github.com/twk/skeleton-go-api/internal/client"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // Mockclient is a mock of client interface.
@@ -36,16 +43,89 @@ func (m *Mockclient) EXPECT() *MockclientMockRecorder {
 }
 
 // Get mocks base method.
-func (m *Mockclient) Get(ctx context.Context, url string) (*http.Response, error) {
+func (m *Mockclient) Get(ctx context.Context, url string, opts ...client.RequestOption) (*http.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, url)
+	varargs := []any{ctx, url}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockclientMockRecorder) Get(ctx, url interface{}) *gomock.Call {
+func (mr *MockclientMockRecorder) Get(ctx, url any, opts ...any) *MockclientGetCall {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockclient)(nil).Get), ctx, url)
+	varargs := append([]any{ctx, url}, opts...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Mockclient)(nil).Get), varargs...)
+	return &MockclientGetCall{Call: call}
+}
+
+// MockclientGetCall wrap *gomock.Call
+type MockclientGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockclientGetCall) Return(arg0 *http.Response, arg1 error) *MockclientGetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockclientGetCall) Do(f func(context.Context, string, ...client.RequestOption) (*http.Response, error)) *MockclientGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockclientGetCall) DoAndReturn(f func(context.Context, string, ...client.RequestOption) (*http.Response, error)) *MockclientGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Post mocks base method.
+func (m *Mockclient) Post(ctx context.Context, url, contentType string, body io.Reader, opts ...client.RequestOption) (*http.Response, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, url, contentType, body}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Post", varargs...)
+	ret0, _ := ret[0].(*http.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockclientMockRecorder) Post(ctx, url, contentType, body any, opts ...any) *MockclientPostCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, url, contentType, body}, opts...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*Mockclient)(nil).Post), varargs...)
+	return &MockclientPostCall{Call: call}
+}
+
+// MockclientPostCall wrap *gomock.Call
+type MockclientPostCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockclientPostCall) Return(arg0 *http.Response, arg1 error) *MockclientPostCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockclientPostCall) Do(f func(context.Context, string, string, io.Reader, ...client.RequestOption) (*http.Response, error)) *MockclientPostCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockclientPostCall) DoAndReturn(f func(context.Context, string, string, io.Reader, ...client.RequestOption) (*http.Response, error)) *MockclientPostCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
 }
@@ -1,95 +1,199 @@
 // Package photos provides the operations for handling photos operations. It contains the Service struct and the GetPhotosConcurrently function.
 package photos
 
+//go:generate mockgen -source=photos.go -destination=mocks/photos_mock.go
+
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"sync"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
 	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/mapping"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/safego"
 )
 
-const photosURL = "https://jsonplaceholder.typicode.com/photos"
+// DefaultBaseURL is the base URL of the upstream when none is configured, the public jsonplaceholder API.
+const DefaultBaseURL = "https://jsonplaceholder.typicode.com"
+
+// defaultConcurrency bounds the concurrent upstream requests of GetPhotosConcurrently and of GetPhotosBatch when the
+// upstream does not support batching, by default.
+const defaultConcurrency = 8
+
+// albumPageSize is the number of photos requested per upstream page when listing an album.
+const albumPageSize = 50
+
+// errEmptyResponse is returned when the upstream answers a creation without the created photo.
+var errEmptyResponse = errors.New("empty upstream response")
 
-// Photo represents a photo object
+// Photo represents a photo object. Its JSON names are the contract of this API, mapped from the photos of the upstream
+// so that they do not change with the upstream.
 type Photo struct {
 	AlbumID      int    `json:"albumId"`
 	ID           int    `json:"id"`
 	Title        string `json:"title"`
 	URL          string `json:"url"`
 	ThumbnailURL string `json:"thumbnailUrl"`
+	// DeletedAt is when the photo was soft-deleted, only set when deleted photos are included.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// LastFetched is when a photo served by a Fallback while the upstream fails was fetched from the upstream. It is
+	// zero for photos fresh from the upstream.
+	LastFetched time.Time `json:"-"`
 }
 
-// Result represents the result of a photo operation
+// Result represents the result of a photo operation on the photo ID: the photo, or the reason it failed.
 type Result struct {
+	ID    int
 	Photo *Photo
 	Err   error
 }
 
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+}
+
 type client interface {
-	Get(ctx context.Context, url string) (*http.Response, error)
+	Get(ctx context.Context, url string, opts ...httpclient.RequestOption) (*http.Response, error)
+	Post(ctx context.Context, url, contentType string, body io.Reader, opts ...httpclient.RequestOption) (*http.Response, error)
 }
 
 // Service provides the operations for handling photos operations
 type Service struct {
 	client client
-	log    *logger.Logger
+	// photosURL is the URL of the photos resource of the upstream.
+	photosURL string
+	log       *logger.Logger
+	// validator validates the upstream responses, nil accepts them all.
+	validator *httpclient.Validator
+	// batchUnsupported is set once the upstream is found not to support batch requests.
+	batchUnsupported atomic.Bool
+	// deletions keeps the soft-deleted photos, nil disables soft delete.
+	deletions DeletionStore
+	listeners []Listener
+	// rec counts the panics of the goroutines of the service, nil disables it.
+	rec recorder
+	// concurrency bounds the concurrent upstream requests of GetPhotosConcurrently and of the batch fallback.
+	concurrency int
+	now         func() time.Time
 }
 
 // NewService creates a new Service for handling photos operations
-func NewService(c client, log *logger.Logger) *Service {
-	return &Service{
-		client: c,
-		log:    log,
+func NewService(c client, log *logger.Logger, opts ...Option) *Service {
+	s := &Service{
+		client:      c,
+		photosURL:   photosResource(DefaultBaseURL),
+		log:         log,
+		concurrency: defaultConcurrency,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
-// GetPhotosConcurrently gets photos concurrently
-func (s *Service) GetPhotosConcurrently(ctx context.Context, concurrency int) []int {
-	var wg sync.WaitGroup
+// Option configures optional behaviour of a Service.
+type Option func(*Service)
 
-	wg.Add(concurrency)
+// WithValidator validates the photos returned by the upstream with v, failing with a *httpclient.ValidationError when
+// they violate the constraints of the upstream photos.
+func WithValidator(v *httpclient.Validator) Option {
+	return func(s *Service) {
+		s.validator = v
+	}
+}
 
-	chanResult := make(chan Result)
+// WithBaseURL sends the requests to the upstream at baseURL instead of DefaultBaseURL, e.g. a mock or a gateway.
+func WithBaseURL(baseURL string) Option {
+	return func(s *Service) {
+		if baseURL != "" {
+			s.photosURL = photosResource(baseURL)
+		}
+	}
+}
 
-	for i := 1; i <= concurrency; i++ {
-		go func(id int) {
-			defer wg.Done()
+// photosResource returns the URL of the photos resource of the upstream at baseURL.
+func photosResource(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/photos"
+}
 
-			photo, err := s.GetPhotos(ctx, id)
-			chanResult <- Result{Photo: photo, Err: err}
-		}(i)
+// WithRecorder counts the panics of the goroutines of the service with rec.
+func WithRecorder(rec recorder) Option {
+	return func(s *Service) {
+		s.rec = rec
 	}
+}
 
-	// We can just use buffered channel and wg.Wait() here. Depends on how we want to handle the result
-	// Doing this way, so we are processing the result as soon as it is available
-	go func() {
-		wg.Wait()
-		close(chanResult)
-	}()
+// WithConcurrency bounds the concurrent upstream requests of GetPhotosConcurrently and of GetPhotosBatch when the
+// upstream does not support batching to n, if positive.
+func WithConcurrency(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
 
-	processedPhotos := make([]int, 0)
+// GetPhotosConcurrently gets the photos of ids, at most the concurrency of the service at a time. The results are in
+// the order of ids, each with the photo of its ID or the reason it could not be gotten. It fails when ctx is done or a
+// request panicked, the IDs not gotten then having the error as result.
+func (s *Service) GetPhotosConcurrently(ctx context.Context, ids []int) ([]Result, error) {
+	results := make([]Result, len(ids))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
 
-	for r := range chanResult {
-		if r.Err != nil {
-			s.log.Error("Failed to process photo", zap.Error(r.Err))
+	for i, id := range ids {
+		results[i].ID = id
+
+		// Go blocks while the limit is reached: the remaining IDs are not started once ctx is done.
+		if gctx.Err() != nil {
 			continue
 		}
 
-		s.log.Info("Processed photo", zap.Int("id", r.Photo.ID))
-		processedPhotos = append(processedPhotos, r.Photo.ID)
+		g.Go(func() error {
+			//nolint:wrapcheck // the *safego.PanicError is returned as is
+			return safego.Call(gctx, s.log, func(ctx context.Context) {
+				results[i].Photo, results[i].Err = s.GetPhotos(ctx, id)
+			}, safego.WithName("photos"), safego.WithRecorder(s.rec))
+		})
+	}
+
+	err := g.Wait()
+	if err == nil {
+		err = ctx.Err()
 	}
 
-	return processedPhotos
+	if err != nil {
+		for i := range results {
+			if results[i].Photo == nil && results[i].Err == nil {
+				results[i].Err = err
+			}
+		}
+
+		return results, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	return results, nil
 }
 
-// GetPhotos gets photos from the photos URL
+// GetPhotos gets photos from the photos URL. Upstream error responses are returned wrapping a *httpclient.HTTPError,
+// and soft-deleted photos fail with ErrDeleted.
 func (s *Service) GetPhotos(ctx context.Context, id int) (*Photo, error) {
-	resp, err := s.client.Get(ctx, fmt.Sprintf("%s/%d", photosURL, id))
+	u := fmt.Sprintf("%s/%d", s.photosURL, id)
+
+	resp, err := s.client.Get(ctx, u)
 	if err != nil {
 		s.log.Error("Failed to get photos", zap.Error(err))
 		return nil, fmt.Errorf("failed to get photos: %w", err)
@@ -97,12 +201,7 @@ func (s *Service) GetPhotos(ctx context.Context, id int) (*Photo, error) {
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		s.log.Error("Non-OK HTTP status received", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("received non-OK HTTP status: %d", resp.StatusCode)
-	}
-
-	var photo Photo
+	var photo upstreamPhoto
 
 	err = json.NewDecoder(resp.Body).Decode(&photo)
 	if err != nil {
@@ -110,5 +209,56 @@ func (s *Service) GetPhotos(ctx context.Context, id int) (*Photo, error) {
 		return nil, fmt.Errorf("failed to decode response body: %w", err)
 	}
 
+	if err = s.validator.Validate(u, &photo); err != nil {
+		s.log.Error("Invalid photo returned by upstream", zap.Error(err))
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	p, err := s.applyDeletions(ctx, []Photo{toPhoto(photo)})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p) == 0 {
+		return nil, fmt.Errorf("photo %d: %w", id, ErrDeleted)
+	}
+
+	return &p[0], nil
+}
+
+// Create creates p in the upstream and returns it with the ID assigned by the upstream. The ID of p is ignored.
+func (s *Service) Create(ctx context.Context, p Photo) (*Photo, error) {
+	created, err := httpclient.PostAs[upstreamPhoto](ctx, s.client, s.photosURL, toUpstreamNewPhoto(p))
+	if err != nil {
+		s.log.Error("Failed to create photo", zap.Error(err))
+		return nil, fmt.Errorf("failed to create photo: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("failed to create photo: %w", errEmptyResponse)
+	}
+
+	photo := toPhoto(*created)
+	s.notify(ctx, ChangeCreated, photo)
+
 	return &photo, nil
 }
+
+// ListAllByAlbum gets all photos of an album, following the upstream pagination until the last page. Soft-deleted
+// photos are left out.
+func (s *Service) ListAllByAlbum(ctx context.Context, albumID int) ([]Photo, error) {
+	u := fmt.Sprintf("%s?albumId=%d", s.photosURL, albumID)
+
+	all, err := httpclient.Paginate[upstreamPhoto](ctx, s.client, u, httpclient.WithPageNumbers("_page", "_limit", albumPageSize)).All()
+	if err != nil {
+		s.log.Error("Failed to list album photos", zap.Int("albumId", albumID), zap.Error(err))
+		return nil, fmt.Errorf("failed to list album photos: %w", err)
+	}
+
+	if err = s.validator.Validate(u, all); err != nil {
+		s.log.Error("Invalid album photos returned by upstream", zap.Int("albumId", albumID), zap.Error(err))
+		return nil, fmt.Errorf("failed to list album photos: %w", err)
+	}
+
+	return s.applyDeletions(ctx, mapping.Slice(all, toPhoto))
+}
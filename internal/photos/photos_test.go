@@ -3,17 +3,22 @@ package photos_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"testing"
 
-	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
 	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
 	"github.com/twk/skeleton-go-api/internal/photos"
 	mock_photos "github.com/twk/skeleton-go-api/internal/photos/mocks"
+	"github.com/twk/skeleton-go-api/internal/testutil"
+	"go.uber.org/mock/gomock"
 )
 
 func TestGetPhotos(t *testing.T) {
@@ -52,13 +57,14 @@ func TestGetPhotos(t *testing.T) {
 		"http not OK": {
 			fields: fields{
 				mockOperation: func(m *mock_photos.Mockclient) {
-					m.EXPECT().Get(context.Background(), "https://jsonplaceholder.typicode.com/photos/1").Return(&http.Response{
+					m.EXPECT().Get(context.Background(), "https://jsonplaceholder.typicode.com/photos/1").Return(nil, &client.HTTPError{
+						Method:     http.MethodGet,
+						URL:        "https://jsonplaceholder.typicode.com/photos/1",
 						StatusCode: http.StatusNotFound,
-						Body:       io.NopCloser(bytes.NewReader([]byte(``))),
-					}, nil)
+					})
 				},
 			},
-			want: want{err: errors.New("received non-OK HTTP status: 404")},
+			want: want{err: errors.New("failed to get photos: GET https://jsonplaceholder.typicode.com/photos/1: received non-OK HTTP status: 404")},
 		},
 		"invalid body": {
 			fields: fields{
@@ -75,11 +81,7 @@ func TestGetPhotos(t *testing.T) {
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
-
-			cl := mock_photos.NewMockclient(ctrl)
-			tt.fields.mockOperation(cl)
+			cl := testutil.NewMock(t, mock_photos.NewMockclient, tt.fields.mockOperation)
 
 			s := photos.NewService(cl, logger.NewNop())
 
@@ -95,8 +97,16 @@ func TestGetPhotos(t *testing.T) {
 }
 
 func TestGetPhotosConcurrently(t *testing.T) {
+	photoResponse := func(id int) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(fmt.Sprintf(`{"albumId":1,"id":%d,"title":"test","url":"test","thumbnailUrl":"test"}`, id)))),
+		}
+	}
+
 	type args struct {
-		concurrency int
+		ids      []int
+		canceled bool
 	}
 
 	type fields struct {
@@ -104,7 +114,9 @@ func TestGetPhotosConcurrently(t *testing.T) {
 	}
 
 	type want struct {
-		want []int
+		photos []int
+		errors []int
+		err    bool
 	}
 
 	tests := map[string]struct {
@@ -113,49 +125,211 @@ func TestGetPhotosConcurrently(t *testing.T) {
 		want   want
 	}{
 		"success": {
-			args: args{concurrency: 5},
+			args: args{ids: []int{1, 2, 3, 4, 5}},
 			fields: fields{
 				mockOperation: func(m *mock_photos.Mockclient) {
 					for i := 1; i <= 5; i++ {
-						m.EXPECT().Get(context.Background(), fmt.Sprintf("https://jsonplaceholder.typicode.com/photos/%d", i)).Return(&http.Response{
-							StatusCode: http.StatusOK,
-							Body:       io.NopCloser(bytes.NewReader([]byte(fmt.Sprintf(`{"albumId":1,"id":%d,"title":"test","url":"test","thumbnailUrl":"test"}`, i)))),
-						}, nil)
+						m.EXPECT().Get(gomock.Any(), fmt.Sprintf("https://jsonplaceholder.typicode.com/photos/%d", i)).Return(photoResponse(i), nil)
 					}
 				},
 			},
-			want: want{want: []int{1, 2, 3, 4, 5}},
+			want: want{photos: []int{1, 2, 3, 4, 5}},
 		},
 		"error": {
-			args: args{concurrency: 5},
+			args: args{ids: []int{1, 2, 3}},
 			fields: fields{
 				mockOperation: func(m *mock_photos.Mockclient) {
-					m.EXPECT().Get(context.Background(), "https://jsonplaceholder.typicode.com/photos/1").Return(nil, errors.New("error"))
-					for i := 2; i <= 5; i++ {
-						m.EXPECT().Get(context.Background(), fmt.Sprintf("https://jsonplaceholder.typicode.com/photos/%d", i)).Return(&http.Response{
-							StatusCode: http.StatusOK,
-							Body:       io.NopCloser(bytes.NewReader([]byte(fmt.Sprintf(`{"albumId":1,"id":%d,"title":"test","url":"test","thumbnailUrl":"test"}`, i)))),
-						}, nil)
+					m.EXPECT().Get(gomock.Any(), "https://jsonplaceholder.typicode.com/photos/1").Return(nil, errors.New("error"))
+					for i := 2; i <= 3; i++ {
+						m.EXPECT().Get(gomock.Any(), fmt.Sprintf("https://jsonplaceholder.typicode.com/photos/%d", i)).Return(photoResponse(i), nil)
 					}
 				},
 			},
-			want: want{want: []int{2, 3, 4, 5}},
+			want: want{photos: []int{2, 3}, errors: []int{1}},
+		},
+		"canceled": {
+			args:   args{ids: []int{1, 2}, canceled: true},
+			fields: fields{mockOperation: func(m *mock_photos.Mockclient) {}},
+			want:   want{errors: []int{1, 2}, err: true},
 		},
 	}
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
+			cl := testutil.NewMock(t, mock_photos.NewMockclient, tt.fields.mockOperation)
+
+			s := photos.NewService(cl, logger.NewNop(), photos.WithConcurrency(2))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.args.canceled {
+				cancel()
+			}
+
+			results, err := s.GetPhotosConcurrently(ctx, tt.args.ids)
+			if tt.want.err {
+				assert.ErrorIs(t, err, context.Canceled)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if !assert.Len(t, results, len(tt.args.ids)) {
+				return
+			}
+
+			var got, failed []int
+
+			for i, r := range results {
+				assert.Equal(t, tt.args.ids[i], r.ID)
+
+				if r.Err != nil {
+					failed = append(failed, r.ID)
+					continue
+				}
+
+				got = append(got, r.Photo.ID)
+			}
+
+			assert.Equal(t, tt.want.photos, got)
+			assert.Equal(t, tt.want.errors, failed)
+		})
+	}
+}
+
+func TestListAllByAlbum(t *testing.T) {
+	page := func(ids ...int) *http.Response {
+		ps := make([]photos.Photo, 0, len(ids))
+		for _, id := range ids {
+			ps = append(ps, photos.Photo{AlbumID: 2, ID: id})
+		}
+
+		b, _ := json.Marshal(ps)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(b))}
+	}
+
+	full := make([]int, 50)
+	for i := range full {
+		full[i] = i + 1
+	}
+
+	type fields struct {
+		mockOperation func(m *mock_photos.Mockclient)
+	}
+
+	type want struct {
+		count int
+		err   error
+	}
+
+	tests := map[string]struct {
+		fields fields
+		want   want
+	}{
+		"multiple pages": {
+			fields: fields{
+				mockOperation: func(m *mock_photos.Mockclient) {
+					gomock.InOrder(
+						m.EXPECT().Get(gomock.Any(), "https://jsonplaceholder.typicode.com/photos?_limit=50&_page=1&albumId=2").Return(page(full...), nil),
+						m.EXPECT().Get(gomock.Any(), "https://jsonplaceholder.typicode.com/photos?_limit=50&_page=2&albumId=2").Return(page(51, 52), nil),
+					)
+				},
+			},
+			want: want{count: 52},
+		},
+		"error": {
+			fields: fields{
+				mockOperation: func(m *mock_photos.Mockclient) {
+					m.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+				},
+			},
+			want: want{err: errors.New("failed to list album photos: failed to get page https://jsonplaceholder.typicode.com/photos?_limit=50&_page=1&albumId=2: error")},
+		},
+	}
 
-			cl := mock_photos.NewMockclient(ctrl)
-			tt.fields.mockOperation(cl)
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl := testutil.NewMock(t, mock_photos.NewMockclient, tt.fields.mockOperation)
 
 			s := photos.NewService(cl, logger.NewNop())
 
-			result := s.GetPhotosConcurrently(context.Background(), tt.args.concurrency)
+			result, err := s.ListAllByAlbum(context.Background(), 2)
+			if tt.want.err != nil {
+				assert.EqualError(t, err, tt.want.err.Error())
+				return
+			}
 
-			assert.ElementsMatch(t, tt.want.want, result)
+			assert.NoError(t, err)
+			assert.Len(t, result, tt.want.count)
 		})
 	}
 }
+
+func TestGetPhotos_Validation(t *testing.T) {
+	tests := map[string]struct {
+		body string
+		err  string
+	}{
+		"valid": {
+			body: `{"albumId":1,"id":1,"title":"test","url":"https://via.placeholder.com/600/92c952","thumbnailUrl":"https://via.placeholder.com/150/92c952"}`,
+		},
+		"missing title and bad url": {
+			body: `{"albumId":1,"id":1,"url":"not a url","thumbnailUrl":"https://via.placeholder.com/150/92c952"}`,
+			err:  "failed to get photos: invalid upstream response from https://jsonplaceholder.typicode.com/photos/1: title required, url url",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl := testutil.NewMock(t, mock_photos.NewMockclient)
+			cl.EXPECT().Get(context.Background(), "https://jsonplaceholder.typicode.com/photos/1").Return(&http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(tt.body))),
+			}, nil)
+
+			s := photos.NewService(cl, logger.NewNop(), photos.WithValidator(client.NewValidator(metrics.New())))
+
+			_, err := s.GetPhotos(context.Background(), 1)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.err)
+
+			var validationErr *client.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(fake.NewGenerator(1)))})
+	s := photos.NewService(hc, logger.NewNop())
+
+	p, err := s.Create(context.Background(), photos.Photo{AlbumID: 2, ID: 7, Title: "new", URL: "https://example.com/1.png", ThumbnailURL: "https://example.com/1t.png"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &photos.Photo{AlbumID: 2, ID: fake.Photos + 1, Title: "new", URL: "https://example.com/1.png", ThumbnailURL: "https://example.com/1t.png"}, p)
+}
+
+func TestWithBaseURL(t *testing.T) {
+	t.Parallel()
+
+	cl := testutil.NewMock(t, mock_photos.NewMockclient, func(m *mock_photos.Mockclient) {
+		m.EXPECT().Get(gomock.Any(), "http://gateway.local/jsonplaceholder/photos/1").Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"albumId":1,"id":1,"title":"test","url":"test","thumbnailUrl":"test"}`))),
+		}, nil)
+	})
+
+	s := photos.NewService(cl, logger.NewNop(), photos.WithBaseURL("http://gateway.local/jsonplaceholder/"))
+
+	_, err := s.GetPhotos(context.Background(), 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,64 @@
+package photos_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/contract"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// TestContractChecks_Fake checks the schemas against the fake upstream, which mimics the real one, so that the schemas
+// are valid and the fake upstream does not drift from them. The real upstream is checked by the contract tests.
+func TestContractChecks_Fake(t *testing.T) {
+	t.Parallel()
+
+	c := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(fake.NewGenerator(1)))})
+
+	results, err := contract.Verify(context.Background(), c, photos.Schemas(), photos.ContractChecks(""))
+	for _, r := range results {
+		assert.NoError(t, r.Err, r.Check.Name)
+		assert.Empty(t, r.Violations, r.Check.Name)
+	}
+
+	assert.NoError(t, err)
+}
+
+func TestResponseSchemas(t *testing.T) {
+	t.Parallel()
+
+	opts, err := photos.ResponseSchemas("")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	transport := fake.NewTransport(fake.NewUpstream(fake.NewGenerator(1)))
+	c := client.NewClient(&http.Client{Transport: transport}, opts...)
+
+	for _, check := range photos.ContractChecks("") {
+		resp, err := c.Get(context.Background(), check.URL)
+		if assert.NoError(t, err, check.Name) {
+			resp.Body.Close()
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	drifted, err := photos.ResponseSchemas(srv.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = client.NewClient(srv.Client(), drifted...).Get(context.Background(), srv.URL+"/photos/1")
+	assert.ErrorIs(t, err, client.ErrSchemaViolation)
+}
@@ -0,0 +1,67 @@
+package photos_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+func TestService_Export(t *testing.T) {
+	t.Parallel()
+
+	g := fake.NewGenerator(1)
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(g))})
+	s := photos.NewService(hc, logger.NewNop(), photos.WithDeletions(photos.NewMemoryDeletions()))
+	ctx := context.Background()
+
+	if !assert.NoError(t, s.Delete(ctx, 7)) {
+		return
+	}
+
+	var (
+		pages int
+		ids   []int
+	)
+
+	err := s.Export(ctx, func(p []photos.Photo) error {
+		pages++
+
+		for _, photo := range p {
+			ids = append(ids, photo.ID)
+		}
+
+		return nil
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, fake.Photos/100, pages)
+	assert.Len(t, ids, fake.Photos-1)
+	assert.NotContains(t, ids, 7)
+	assert.Equal(t, fake.Photos, ids[len(ids)-1])
+
+	var included int
+
+	err = s.Export(photos.IncludeDeleted(ctx), func(p []photos.Photo) error {
+		included += len(p)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fake.Photos, included)
+
+	// Export stops at the first error of the callback.
+	pages = 0
+	err = s.Export(ctx, func([]photos.Photo) error {
+		pages++
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, pages)
+}
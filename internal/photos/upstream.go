@@ -0,0 +1,40 @@
+package photos
+
+// upstreamPhoto is a photo as returned by the upstream. The validate tags declare what the upstream is expected to
+// return, checked when the Service has a validator.
+type upstreamPhoto struct {
+	AlbumID      int    `json:"albumId"      validate:"required"`
+	ID           int    `json:"id"           validate:"required"`
+	Title        string `json:"title"        validate:"required"`
+	URL          string `json:"url"          validate:"required,url"`
+	ThumbnailURL string `json:"thumbnailUrl" validate:"required,url"`
+}
+
+// toPhoto maps a photo of the upstream to a Photo.
+func toPhoto(p upstreamPhoto) Photo {
+	return Photo{
+		AlbumID:      p.AlbumID,
+		ID:           p.ID,
+		Title:        p.Title,
+		URL:          p.URL,
+		ThumbnailURL: p.ThumbnailURL,
+	}
+}
+
+// upstreamNewPhoto is a photo as created in the upstream, which assigns its ID.
+type upstreamNewPhoto struct {
+	AlbumID      int    `json:"albumId"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+}
+
+// toUpstreamNewPhoto maps a Photo to create to the upstream, without its ID.
+func toUpstreamNewPhoto(p Photo) upstreamNewPhoto {
+	return upstreamNewPhoto{
+		AlbumID:      p.AlbumID,
+		Title:        p.Title,
+		URL:          p.URL,
+		ThumbnailURL: p.ThumbnailURL,
+	}
+}
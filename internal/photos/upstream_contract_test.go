@@ -0,0 +1,34 @@
+//go:build contract
+
+package photos_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/contract"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// TestUpstreamContract checks the responses of the live upstream against the schemas. It needs network access, so it
+// only runs with the contract build tag: go test -tags contract ./internal/photos/
+func TestUpstreamContract(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results, err := contract.Verify(ctx, client.NewClient(&http.Client{}), photos.Schemas(), photos.ContractChecks(""))
+	for _, r := range results {
+		assert.NoError(t, r.Err, r.Check.Name)
+
+		for _, v := range r.Violations {
+			t.Errorf("%s: %s", r.Check.Name, v)
+		}
+	}
+
+	assert.NoError(t, err)
+}
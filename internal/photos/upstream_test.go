@@ -0,0 +1,26 @@
+package photos_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+func TestUpstreamMapping(t *testing.T) {
+	t.Parallel()
+
+	upstream := `{"albumId":1,"id":2,"title":"sunset","url":"https://example.com/600/a","thumbnailUrl":"https://example.com/150/a"}`
+
+	p, err := photos.MapUpstream([]byte(upstream))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, photos.Photo{AlbumID: 1, ID: 2, Title: "sunset", URL: "https://example.com/600/a", ThumbnailURL: "https://example.com/150/a"}, p)
+
+	b, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, upstream, string(b), "the API serves photos in the same JSON as the upstream")
+}
@@ -0,0 +1,244 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Endpoints of Cloud Profiler and of the metadata server providing the project and the credentials on Google Cloud.
+const (
+	cloudProfilerAPI   = "https://cloudprofiler.googleapis.com"
+	metadataServer     = "http://metadata.google.internal"
+	tokenExpiryLeeway  = time.Minute
+	defaultCPUDuration = 10 * time.Second
+)
+
+// cloudProfileTypes maps the profile types to those of Cloud Profiler.
+func cloudProfileTypes() map[string]string {
+	return map[string]string{CPU: "CPU", Heap: "HEAP", Goroutine: "THREADS"}
+}
+
+// cloudProfiler lets Cloud Profiler pick the profiles to collect: it long-polls for the next profile to create,
+// collects it and uploads it. The agent authenticates with the service account of the metadata server.
+type cloudProfiler struct {
+	cfg        *config.CloudProfiler
+	deployment deployment
+	profiles   []string
+	httpClient *http.Client
+	log        *logger.Logger
+	// apiURL and metadataURL are overridden by tests.
+	apiURL      string
+	metadataURL string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// cloudProfile is the Profile resource of the Cloud Profiler API.
+type cloudProfile struct {
+	Name         string            `json:"name,omitempty"`
+	ProfileType  string            `json:"profileType,omitempty"`
+	Deployment   *cloudDeployment  `json:"deployment,omitempty"`
+	Duration     string            `json:"duration,omitempty"`
+	ProfileBytes []byte            `json:"profileBytes,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+type cloudDeployment struct {
+	ProjectID string            `json:"projectId"`
+	Target    string            `json:"target"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type createProfileRequest struct {
+	Deployment  *cloudDeployment `json:"deployment"`
+	ProfileType []string         `json:"profileType"`
+}
+
+func newCloudProfiler(cfg *config.CloudProfiler, d deployment, profiles []string, httpClient *http.Client,
+	l *logger.Logger,
+) *cloudProfiler {
+	return &cloudProfiler{
+		cfg:         cfg,
+		deployment:  d,
+		profiles:    profiles,
+		httpClient:  httpClient,
+		log:         l,
+		apiURL:      cloudProfilerAPI,
+		metadataURL: metadataServer,
+	}
+}
+
+func (p *cloudProfiler) run(ctx context.Context) {
+	projectID := p.cfg.ProjectID
+
+	for ctx.Err() == nil {
+		if projectID == "" {
+			id, err := p.metadata(ctx, "/computeMetadata/v1/project/project-id")
+			if err != nil {
+				p.log.Warn("Failed to get the project of Cloud Profiler", zap.Error(err))
+				sleep(ctx, retryDelay)
+
+				continue
+			}
+
+			projectID = string(id)
+		}
+
+		if err := p.profile(ctx, projectID); err != nil && ctx.Err() == nil {
+			p.log.Warn("Cloud Profiler failed", zap.Error(err))
+			sleep(ctx, retryDelay)
+		}
+	}
+}
+
+// profile waits for the next profile requested by Cloud Profiler, then collects and uploads it.
+func (p *cloudProfiler) profile(ctx context.Context, projectID string) error {
+	types := cloudProfileTypes()
+	req := createProfileRequest{Deployment: &cloudDeployment{ProjectID: projectID, Target: p.deployment.service, Labels: p.deployment.tags}}
+
+	for _, typ := range p.profiles {
+		req.ProfileType = append(req.ProfileType, types[typ])
+	}
+
+	var prof cloudProfile
+	if err := p.call(ctx, http.MethodPost, "/v2/projects/"+projectID+"/profiles", req, &prof); err != nil {
+		return err
+	}
+
+	var typ string
+
+	for t, ct := range types {
+		if ct == prof.ProfileType {
+			typ = t
+		}
+	}
+
+	d := defaultCPUDuration
+	if parsed, err := time.ParseDuration(prof.Duration); err == nil {
+		d = parsed
+	}
+
+	data, err := collect(ctx, typ, d)
+	if err != nil {
+		return err
+	}
+
+	prof.ProfileBytes = data
+
+	uploadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), uploadTimeout)
+	defer cancel()
+
+	return p.call(uploadCtx, http.MethodPatch, "/v2/"+prof.Name, prof, nil)
+}
+
+// call sends body to the Cloud Profiler API, decoding the response into out if not nil.
+func (p *cloudProfiler) call(ctx context.Context, method, path string, body, out any) error {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create profiler request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call cloud profiler: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("cloud profiler %s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode profile: %w", err)
+	}
+
+	return nil
+}
+
+// accessToken returns the access token of the default service account, cached until it is about to expire.
+func (p *cloudProfiler) accessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Now().Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+
+	b, err := p.metadata(ctx, "/computeMetadata/v1/instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+
+	if err = json.Unmarshal(b, &tok); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+
+	p.token = tok.AccessToken
+	p.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - tokenExpiryLeeway)
+
+	return p.token, nil
+}
+
+// metadata returns the value of path on the metadata server.
+func (p *cloudProfiler) metadata(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.metadataURL+path, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata request: %w", err)
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metadata server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata server %s: status %d", path, resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,7 @@
+package profiling
+
+// WithCloudEndpoints points the Cloud Profiler backend of p to the API and metadata server at the given base URLs.
+func WithCloudEndpoints(p *Profiler, apiURL, metadataURL string) {
+	cp, _ := p.backend.(*cloudProfiler)
+	cp.apiURL, cp.metadataURL = apiURL, metadataURL
+}
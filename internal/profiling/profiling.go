@@ -0,0 +1,182 @@
+// Package profiling continuously profiles the service in production, pushing its CPU, heap and goroutine profiles to
+// Pyroscope or Google Cloud Profiler, tagged with the version and region of the deployment.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Backends of config.Profiling.Backend.
+const (
+	BackendPyroscope     = "pyroscope"
+	BackendCloudProfiler = "cloud-profiler"
+)
+
+// Profile types.
+const (
+	CPU       = "cpu"
+	Heap      = "heap"
+	Goroutine = "goroutine"
+)
+
+// Defaults of the profiling.
+const (
+	defaultService  = "skeleton-go-api"
+	defaultInterval = 15 * time.Second
+	// retryDelay is how long to wait after a failed upload or a failed request for the next profile.
+	retryDelay = time.Minute
+)
+
+var (
+	// ErrUnknownBackend is returned for a backend other than BackendPyroscope and BackendCloudProfiler.
+	ErrUnknownBackend = errors.New("unknown profiling backend")
+	// ErrUnknownProfile is returned for a profile type other than CPU, Heap and Goroutine.
+	ErrUnknownProfile = errors.New("unknown profile type")
+)
+
+// backend pushes profiles until ctx is done.
+type backend interface {
+	run(ctx context.Context)
+}
+
+// Profiler profiles the service and pushes the profiles to its backend.
+type Profiler struct {
+	backend backend
+}
+
+// New creates the profiler of cfg, pushing the profiles with httpClient. It returns nil if profiling is disabled.
+func New(cfg *config.Profiling, httpClient *http.Client, l *logger.Logger) (*Profiler, error) {
+	if cfg.Backend == "" {
+		return nil, nil
+	}
+
+	profiles := cfg.Profiles
+	if len(profiles) == 0 {
+		profiles = []string{CPU, Heap, Goroutine}
+	}
+
+	for _, p := range profiles {
+		if p != CPU && p != Heap && p != Goroutine {
+			return nil, fmt.Errorf("%w %q", ErrUnknownProfile, p)
+		}
+	}
+
+	d := deployment{service: cfg.Service, version: cfg.Version, tags: map[string]string{}}
+	if d.service == "" {
+		d.service = defaultService
+	}
+
+	if d.version == "" {
+		d.version = buildVersion()
+	}
+
+	for k, v := range cfg.Tags {
+		d.tags[k] = v
+	}
+
+	d.tags["version"] = d.version
+	if cfg.Region != "" {
+		d.tags["region"] = cfg.Region
+	}
+
+	switch cfg.Backend {
+	case BackendPyroscope:
+		b, err := newPyroscope(&cfg.Pyroscope, d, profiles, cfg.Interval, httpClient, l)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Profiler{backend: b}, nil
+	case BackendCloudProfiler:
+		return &Profiler{backend: newCloudProfiler(&cfg.CloudProfiler, d, profiles, httpClient, l)}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownBackend, cfg.Backend)
+	}
+}
+
+// Run profiles and pushes the profiles until ctx is done.
+func (p *Profiler) Run(ctx context.Context) {
+	p.backend.run(ctx)
+}
+
+// deployment identifies the profiled deployment.
+type deployment struct {
+	service string
+	version string
+	// tags are the tags of the profiles, including the version and region.
+	tags map[string]string
+}
+
+// collect returns the profile of type typ in the gzipped pprof format. The CPU profile lasts d, or until ctx is done;
+// the others are snapshots.
+func collect(ctx context.Context, typ string, d time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch typ {
+	case CPU:
+		// Fails while another CPU profile runs, such as one of /debug/pprof/profile.
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, fmt.Errorf("failed to start cpu profile: %w", err)
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+
+		pprof.StopCPUProfile()
+	case Heap, Goroutine:
+		if err := pprof.Lookup(typ).WriteTo(&buf, 0); err != nil {
+			return nil, fmt.Errorf("failed to write %s profile: %w", typ, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownProfile, typ)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// buildVersion returns the version of the main module, or its VCS revision when built from a checkout.
+func buildVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" && s.Value != "" {
+			return s.Value
+		}
+	}
+
+	if bi.Main.Version != "" {
+		return bi.Main.Version
+	}
+
+	return "unknown"
+}
+
+// sleep waits for d or until ctx is done, reporting whether ctx is still running.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
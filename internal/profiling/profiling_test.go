@@ -0,0 +1,176 @@
+package profiling_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/profiling"
+)
+
+func TestProfiler_Pyroscope(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		names   []string
+		auth    string
+		profile []byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("profile")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		b, _ := io.ReadAll(f)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		names = append(names, r.URL.Query().Get("name"))
+		auth = r.Header.Get("Authorization")
+		profile = b
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := profiling.New(&config.Profiling{
+		Backend:   profiling.BackendPyroscope,
+		Version:   "v1.2.0",
+		Region:    "eu-west-1",
+		Profiles:  []string{profiling.CPU, profiling.Goroutine},
+		Interval:  50 * time.Millisecond,
+		Pyroscope: config.Pyroscope{ServerAddress: srv.URL, AuthToken: "token"},
+	}, srv.Client(), logger.NewNop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+
+	p.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Contains(t, names, "skeleton-go-api.cpu{region=eu-west-1,version=v1.2.0}")
+	assert.Contains(t, names, "skeleton-go-api.goroutine{region=eu-west-1,version=v1.2.0}")
+	assert.Equal(t, "Bearer token", auth)
+	// Profiles are gzipped.
+	assert.Equal(t, []byte{0x1f, 0x8b}, profile[:2])
+}
+
+func TestProfiler_CloudProfiler(t *testing.T) {
+	t.Parallel()
+
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/computeMetadata/v1/project/project-id":
+			_, _ = w.Write([]byte("my-project"))
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+		}
+	}))
+	t.Cleanup(metadata.Close)
+
+	uploaded := make(chan map[string]any, 1)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/projects/my-project/profiles":
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			assert.Equal(t, []any{"HEAP"}, body["profileType"])
+
+			body["name"] = "projects/my-project/profiles/1"
+			body["profileType"] = "HEAP"
+			_ = json.NewEncoder(w).Encode(body)
+		case r.Method == http.MethodPatch && r.URL.Path == "/v2/projects/my-project/profiles/1":
+			select {
+			case uploaded <- body:
+			default:
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	p, err := profiling.New(&config.Profiling{
+		Backend:  profiling.BackendCloudProfiler,
+		Version:  "v1.2.0",
+		Region:   "eu-west-1",
+		Profiles: []string{profiling.Heap},
+	}, api.Client(), logger.NewNop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	profiling.WithCloudEndpoints(p, api.URL, metadata.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		p.Run(ctx)
+	}()
+
+	select {
+	case body := <-uploaded:
+		assert.NotEmpty(t, body["profileBytes"])
+		assert.Equal(t, map[string]any{"region": "eu-west-1", "version": "v1.2.0"}, body["deployment"].(map[string]any)["labels"])
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no profile uploaded")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg     config.Profiling
+		wantErr bool
+	}{
+		"disabled":          {},
+		"unknown backend":   {cfg: config.Profiling{Backend: "datadog"}, wantErr: true},
+		"unknown profile":   {cfg: config.Profiling{Backend: profiling.BackendCloudProfiler, Profiles: []string{"mutex"}}, wantErr: true},
+		"pyroscope address": {cfg: config.Profiling{Backend: profiling.BackendPyroscope}, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := profiling.New(&tt.cfg, http.DefaultClient, logger.NewNop())
+
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Nil(t, p)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Parameters of the uploads to Pyroscope.
+const (
+	pyroscopeSpyName = "gospy"
+	// pyroscopeSampleRate is the sampling rate of the CPU profiles of the Go runtime, in Hz.
+	pyroscopeSampleRate = 100
+	// uploadTimeout bounds the upload of the last profiles, on shutdown.
+	uploadTimeout = 10 * time.Second
+	maxErrorBody  = 512
+)
+
+var errNoPyroscopeAddr = errors.New("pyroscope server address is required")
+
+// pyroscope pushes the profiles to the /ingest endpoint of a Pyroscope server every interval. The CPU profile covers
+// the whole interval, the others are snapshots taken at its end.
+type pyroscope struct {
+	cfg        *config.Pyroscope
+	deployment deployment
+	profiles   []string
+	interval   time.Duration
+	httpClient *http.Client
+	log        *logger.Logger
+}
+
+func newPyroscope(cfg *config.Pyroscope, d deployment, profiles []string, interval time.Duration, httpClient *http.Client,
+	l *logger.Logger,
+) (*pyroscope, error) {
+	if cfg.ServerAddress == "" {
+		return nil, errNoPyroscopeAddr
+	}
+
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &pyroscope{cfg: cfg, deployment: d, profiles: profiles, interval: interval, httpClient: httpClient, log: l}, nil
+}
+
+func (p *pyroscope) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		from := time.Now()
+		profiles := map[string][]byte{}
+
+		if slices.Contains(p.profiles, CPU) {
+			data, err := collect(ctx, CPU, p.interval)
+			if err != nil {
+				p.log.Warn("Failed to profile", zap.String("profile", CPU), zap.Error(err))
+			} else {
+				profiles[CPU] = data
+			}
+		}
+
+		// Without a CPU profile, or if it failed, the interval is waited for.
+		if _, ok := profiles[CPU]; !ok && !sleep(ctx, time.Until(from.Add(p.interval))) {
+			return
+		}
+
+		until := time.Now()
+
+		for _, typ := range p.profiles {
+			if typ == CPU {
+				continue
+			}
+
+			data, err := collect(ctx, typ, 0)
+			if err != nil {
+				p.log.Warn("Failed to profile", zap.String("profile", typ), zap.Error(err))
+				continue
+			}
+
+			profiles[typ] = data
+		}
+
+		// The last profiles are uploaded on shutdown too.
+		uploadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), uploadTimeout)
+
+		for typ, data := range profiles {
+			if err := p.upload(uploadCtx, typ, from, until, data); err != nil {
+				p.log.Warn("Failed to upload profile", zap.String("profile", typ), zap.Error(err))
+			}
+		}
+
+		cancel()
+	}
+}
+
+// upload sends the profile typ of the period from until.
+func (p *pyroscope) upload(ctx context.Context, typ string, from, until time.Time, data []byte) error {
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+
+	fw, err := mw.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return fmt.Errorf("failed to create profile form: %w", err)
+	}
+
+	if _, err = fw.Write(data); err != nil {
+		return fmt.Errorf("failed to write profile form: %w", err)
+	}
+
+	if err = mw.Close(); err != nil {
+		return fmt.Errorf("failed to close profile form: %w", err)
+	}
+
+	q := url.Values{
+		"name":       {p.name(typ)},
+		"from":       {strconv.FormatInt(from.Unix(), 10)},
+		"until":      {strconv.FormatInt(until.Unix(), 10)},
+		"format":     {"pprof"},
+		"spyName":    {pyroscopeSpyName},
+		"sampleRate": {strconv.Itoa(pyroscopeSampleRate)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.cfg.ServerAddress, "/")+"/ingest?"+q.Encode(), &body)
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	if p.cfg.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.AuthToken)
+	}
+
+	if p.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", p.cfg.TenantID)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload profile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("failed to upload profile: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// name returns the application name of the profile typ with the tags of the deployment, e.g.
+// skeleton-go-api.cpu{region=eu-west-1,version=v1.2.0}.
+func (p *pyroscope) name(typ string) string {
+	keys := make([]string, 0, len(p.deployment.tags))
+	for k := range p.deployment.tags {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	tags := make([]string, len(keys))
+	for i, k := range keys {
+		tags[i] = k + "=" + p.deployment.tags[k]
+	}
+
+	return p.deployment.service + "." + typ + "{" + strings.Join(tags, ",") + "}"
+}
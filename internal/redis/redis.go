@@ -0,0 +1,192 @@
+// Package redis creates the Redis client shared by the subsystems keeping their state in Redis, such as the HTTP cache,
+// the sessions and the soft delete marks, with its health check, its command latency metrics and JSON helpers.
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// CommandDurationMetric is the histogram of the duration of the Redis commands, by command and status, "ok", "nil"
+// for missing keys, or "error". Pipelines are recorded as the "pipeline" command.
+const CommandDurationMetric = "redis_command_duration_seconds"
+
+// errInvalidCA is returned for a CA file without any PEM certificate.
+var errInvalidCA = errors.New("no certificate found")
+
+type recorder interface {
+	SetBuckets(name string, buckets []float64)
+	Observe(name string, v float64, labels metrics.Labels)
+}
+
+// New creates a client of the Redis server of cfg, recording the duration of its commands with rec, which may be nil.
+// Connections are established on first use.
+func New(cfg *config.Redis, rec recorder) (*goredis.Client, error) {
+	opts := &goredis.Options{
+		Addr:         cfg.Addr,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	if cfg.TLS.Enabled {
+		tc, err := tlsConfig(&cfg.TLS, cfg.Addr)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.TLSConfig = tc
+	}
+
+	rdb := goredis.NewClient(opts)
+	if rec != nil {
+		rec.SetBuckets(CommandDurationMetric, commandDurationBuckets())
+		rdb.AddHook(&metricsHook{rec: rec})
+	}
+
+	return rdb, nil
+}
+
+func tlsConfig(cfg *config.RedisTLS, addr string) (*tls.Config, error) {
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // opted in by configuration, for development
+	}
+
+	if tc.ServerName == "" {
+		tc.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	if cfg.CAFile == "" {
+		return tc, nil
+	}
+
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis ca file: %w", err)
+	}
+
+	tc.RootCAs = x509.NewCertPool()
+	if !tc.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("invalid redis ca file %s: %w", cfg.CAFile, errInvalidCA)
+	}
+
+	return tc, nil
+}
+
+// HealthCheck returns a check pinging the server of rdb.
+func HealthCheck(rdb goredis.UniversalClient) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("failed to ping redis: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// GetJSON gets the JSON value of key into a T, reporting whether the key exists.
+func GetJSON[T any](ctx context.Context, rdb goredis.Cmdable, key string) (*T, bool, error) {
+	b, err := rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+
+	var v T
+	if err = json.Unmarshal(b, &v); err != nil {
+		return nil, false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+
+	return &v, true, nil
+}
+
+// SetJSON sets key to the JSON of v, expiring after ttl. Zero ttl never expires.
+func SetJSON(ctx context.Context, rdb goredis.Cmdable, key string, v any, ttl time.Duration) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	if err = rdb.Set(ctx, key, b, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// commandDurationBuckets returns the buckets of CommandDurationMetric, from 100µs to 1.6s, as commands usually take
+// less than a millisecond.
+func commandDurationBuckets() []float64 {
+	const (
+		start  = 0.0001
+		factor = 2
+		count  = 15
+	)
+
+	return prometheus.ExponentialBuckets(start, factor, count)
+}
+
+// metricsHook records the duration of the commands.
+type metricsHook struct {
+	rec recorder
+}
+
+func (h *metricsHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h *metricsHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+
+		return err
+	}
+}
+
+func (h *metricsHook) observe(command string, start time.Time, err error) {
+	status := "ok"
+
+	switch {
+	case errors.Is(err, goredis.Nil):
+		status = "nil"
+	case err != nil:
+		status = "error"
+	}
+
+	h.rec.Observe(CommandDurationMetric, time.Since(start).Seconds(), metrics.Labels{"command": command, "status": status})
+}
@@ -0,0 +1,265 @@
+package redis_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/redis"
+)
+
+type value struct {
+	Name string `json:"name"`
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	rdb, err := redis.New(&config.Redis{Addr: startServer(t)}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := context.Background()
+
+	got, ok, err := redis.GetJSON[value](ctx, rdb, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, got)
+
+	if !assert.NoError(t, redis.SetJSON(ctx, rdb, "key", value{Name: "a"}, time.Minute)) {
+		return
+	}
+
+	got, ok, err = redis.GetJSON[value](ctx, rdb, "key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, &value{Name: "a"}, got)
+}
+
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		addr    func(t *testing.T) string
+		wantErr bool
+	}{
+		"Up":   {addr: startServer},
+		"Down": {addr: closedAddr, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rdb, err := redis.New(&config.Redis{Addr: tt.addr(t), DialTimeout: time.Second}, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = redis.HealthCheck(rdb)(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestNew_Metrics(t *testing.T) {
+	t.Parallel()
+
+	mr := metrics.New()
+
+	rdb, err := redis.New(&config.Redis{Addr: startServer(t)}, mr)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "key", "v", 0).Err())
+	assert.NoError(t, rdb.Get(ctx, "key").Err())
+	assert.Error(t, rdb.Get(ctx, "missing").Err())
+
+	resp := httptest.NewRecorder()
+	mr.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	for _, want := range []string{
+		`redis_command_duration_seconds_count{command="set",status="ok"} 1`,
+		`redis_command_duration_seconds_count{command="get",status="ok"} 1`,
+		`redis_command_duration_seconds_count{command="get",status="nil"} 1`,
+		`redis_command_duration_seconds_bucket{command="get",status="ok",le="0.0001"}`,
+	} {
+		assert.Contains(t, resp.Body.String(), want)
+	}
+}
+
+func TestNew_TLS(t *testing.T) {
+	t.Parallel()
+
+	invalid := filepath.Join(t.TempDir(), "ca.pem")
+	if !assert.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0o600)) {
+		return
+	}
+
+	tests := map[string]struct {
+		tls     config.RedisTLS
+		wantErr bool
+	}{
+		"WithoutCA":  {tls: config.RedisTLS{Enabled: true}},
+		"MissingCA":  {tls: config.RedisTLS{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}, wantErr: true},
+		"InvalidCA":  {tls: config.RedisTLS{Enabled: true, CAFile: invalid}, wantErr: true},
+		"CADisabled": {tls: config.RedisTLS{CAFile: invalid}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := redis.New(&config.Redis{Addr: "localhost:6379", TLS: tt.tls}, nil)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+// closedAddr returns an address nothing listens on.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ln.Addr().String()
+	ln.Close()
+
+	return addr
+}
+
+// startServer starts a server speaking enough of the RESP2 protocol for the tests, answering PING, GET and SET, and
+// returns its address. Other commands, such as the HELLO negotiating RESP3, are answered with an error.
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { ln.Close() })
+
+	var (
+		mu   sync.Mutex
+		data = map[string]string{}
+	)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+
+					mu.Lock()
+					reply := execute(data, args)
+					mu.Unlock()
+
+					if _, err = io.WriteString(conn, reply); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func execute(data map[string]string, args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		v, ok := data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		data[args[1]] = args[2]
+		return "+OK\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+var errProtocol = errors.New("protocol error")
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+
+	for i := range args {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, size+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		args[i] = string(b[:size])
+	}
+
+	return args, nil
+}
+
+func readLength(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+
+	if len(line) < 3 || line[0] != prefix {
+		return 0, errProtocol
+	}
+
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
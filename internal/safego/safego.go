@@ -0,0 +1,170 @@
+// Package safego runs background work in goroutines which cannot crash the service: their panics are recovered,
+// logged with the stack of the goroutine and counted, instead of terminating the process.
+package safego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// PanicMetric counts the recovered panics by name of the goroutine.
+const PanicMetric = "goroutine_panics_total"
+
+// defaultName names the goroutines without WithName.
+const defaultName = "background"
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+}
+
+// PanicError is a panic recovered from a goroutine.
+type PanicError struct {
+	// Name is the name of the goroutine.
+	Name string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack of the goroutine when it panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("goroutine %s panicked: %v", e.Name, e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type options struct {
+	name string
+	rec  recorder
+}
+
+// Option configures the goroutines.
+type Option func(*options)
+
+// WithName names the goroutines in the logs and metrics of their panics, e.g. "mirror".
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithRecorder counts the panics in PanicMetric with rec, which may be nil.
+func WithRecorder(rec recorder) Option {
+	return func(o *options) {
+		o.rec = rec
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{name: defaultName}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Go runs fn with ctx in a goroutine, recovering and logging its panic to l.
+func Go(ctx context.Context, l *logger.Logger, fn func(ctx context.Context), opts ...Option) {
+	o := newOptions(opts)
+
+	go func() {
+		_ = o.run(ctx, l, fn)
+	}()
+}
+
+// Call calls fn with ctx in the current goroutine, recovering and logging its panic to l and returning it as a
+// *PanicError, e.g. in the goroutines of an errgroup.Group.
+func Call(ctx context.Context, l *logger.Logger, fn func(ctx context.Context), opts ...Option) error {
+	return newOptions(opts).run(ctx, l, fn)
+}
+
+// run calls fn, returning its panic as a *PanicError once logged and counted.
+func (o options) run(ctx context.Context, l *logger.Logger, fn func(ctx context.Context)) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		pe := &PanicError{Name: o.name, Value: v, Stack: debug.Stack()}
+		l.Error("Recovered panic of a goroutine", zap.String("goroutine", o.name), zap.Any("panic", v),
+			zap.ByteString("stack", pe.Stack))
+
+		if o.rec != nil {
+			o.rec.Inc(PanicMetric, metrics.Labels{"name": o.name})
+		}
+
+		err = pe
+	}()
+
+	fn(ctx)
+
+	return nil
+}
+
+// Group runs goroutines as Go does, at most a limit of them at a time, and waits for them.
+type Group struct {
+	opts options
+	log  *logger.Logger
+	// slots bounds the goroutines running fn, nil for no bound.
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup creates a group running at most limit goroutines at a time, or any number if limit is not positive, and
+// logging their panics to l.
+func NewGroup(l *logger.Logger, limit int, opts ...Option) *Group {
+	g := &Group{opts: newOptions(opts), log: l}
+	if limit > 0 {
+		g.slots = make(chan struct{}, limit)
+	}
+
+	return g
+}
+
+// Go runs fn with ctx in a goroutine once a slot of the group is free. It does not block: the goroutine waits for the
+// slot.
+func (g *Group) Go(ctx context.Context, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		if g.slots != nil {
+			g.slots <- struct{}{}
+			defer func() { <-g.slots }()
+		}
+
+		if err := g.opts.run(ctx, g.log, fn); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for the goroutines of the group, returning their panics as *PanicError.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return errors.Join(g.errs...)
+}
@@ -0,0 +1,84 @@
+package safego_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/safego"
+)
+
+var errBoom = errors.New("boom")
+
+func TestGo(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.ErrorLevel)
+	mr := metrics.New()
+	done := make(chan struct{})
+
+	safego.Go(context.Background(), &logger.Logger{Logger: zap.New(core)}, func(context.Context) {
+		defer close(done)
+		panic(errBoom)
+	}, safego.WithName("test"), safego.WithRecorder(mr))
+
+	<-done
+
+	assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, time.Millisecond)
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "test", fields["goroutine"])
+	assert.Contains(t, fields["stack"], "safego_test.TestGo")
+
+	resp := httptest.NewRecorder()
+	mr.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	assert.Contains(t, resp.Body.String(), `goroutine_panics_total{name="test"} 1`)
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	g := safego.NewGroup(logger.NewNop(), 2)
+
+	var running, maxRunning atomic.Int32
+
+	for i := 0; i < 10; i++ {
+		g.Go(context.Background(), func(context.Context) {
+			n := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			if i == 3 {
+				panic(errBoom)
+			}
+		})
+	}
+
+	err := g.Wait()
+
+	var pe *safego.PanicError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "background", pe.Name)
+		assert.ErrorIs(t, err, errBoom)
+	}
+
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
@@ -0,0 +1,201 @@
+// Package scaffold generates the code of a new resource of the service from embedded templates: its model, service
+// and repository reading it from the upstream, its handlers, the module of its routes, and their tests and mocks,
+// following the layout of the photos.
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// modulesAnchor is the end of the list of modules of internal/app, before which the module of a resource is added.
+const modulesAnchor = ", mirrorModule, serverModule}"
+
+var (
+	// ErrInvalidName is returned for resource names which are not lower case Go package names.
+	ErrInvalidName = errors.New("resource name must be a lower case word, such as albums")
+	// ErrExists is returned when a file of the resource exists.
+	ErrExists = errors.New("file exists")
+	// ErrNoModules is returned when the list of modules of internal/app is not found, so the module is not added.
+	ErrNoModules = errors.New("modules of internal/app not found")
+	// errNoModulePath is returned when the go.mod does not declare the module path.
+	errNoModulePath = errors.New("module path not found in go.mod")
+
+	validName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+)
+
+// Resource is a resource to generate, e.g. the albums of the upstream.
+type Resource struct {
+	// Module is the path of the Go module.
+	Module string
+	// Package is the name of the package of the resource, and the path of its routes, e.g. albums.
+	Package string
+	// Singular names one item of the resource in messages and unexported names, e.g. album.
+	Singular string
+	// Type is the model type, e.g. Album.
+	Type string
+	// Plural is the exported name of several items, e.g. Albums.
+	Plural string
+	// URL is the upstream URL of the resource.
+	URL string
+}
+
+// NewResource returns the resource name of the module in dir, whose items are named singular, read from the upstream
+// at url. An empty singular trims the trailing s of name.
+func NewResource(dir, name, singular, url string) (*Resource, error) {
+	if singular == "" {
+		singular = strings.TrimSuffix(name, "s")
+	}
+
+	if !validName.MatchString(name) || !validName.MatchString(singular) || singular == name {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	module, err := modulePath(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resource{Module: module, Package: name, Singular: singular, Type: title(singular), Plural: title(name), URL: url}, nil
+}
+
+func modulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(s.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(path), `"`), nil
+		}
+	}
+
+	if err = s.Err(); err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	return "", errNoModulePath
+}
+
+func title(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+
+	return string(r)
+}
+
+// files maps the templates to the files of r, relative to the root of the module.
+func (r *Resource) files() map[string]string {
+	pkg := filepath.Join("internal", r.Package)
+
+	return map[string]string{
+		"model.go.tmpl":         filepath.Join(pkg, r.Package+".go"),
+		"model_test.go.tmpl":    filepath.Join(pkg, r.Package+"_test.go"),
+		"model_mock.go.tmpl":    filepath.Join(pkg, "mocks", r.Package+"_mock.go"),
+		"upstream.go.tmpl":      filepath.Join(pkg, "upstream.go"),
+		"upstream_test.go.tmpl": filepath.Join(pkg, "upstream_test.go"),
+		"upstream_mock.go.tmpl": filepath.Join(pkg, "mocks", "upstream_mock.go"),
+		"handler.go.tmpl":       filepath.Join("internal", "api", r.Package+".go"),
+		"handler_test.go.tmpl":  filepath.Join("internal", "api", r.Package+"_test.go"),
+		"handler_mock.go.tmpl":  filepath.Join("internal", "api", "mocks", r.Package+"_mock.go"),
+		"module.go.tmpl":        filepath.Join("internal", "app", r.Package+".go"),
+	}
+}
+
+// Generate writes the files of r under dir, the root of the module, and returns their paths. Nothing is written when
+// one of them exists.
+func Generate(dir string, r *Resource) ([]string, error) {
+	rendered := map[string][]byte{}
+
+	for name, path := range r.files() {
+		path = filepath.Join(dir, path)
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrExists, path)
+		}
+
+		b, err := render(name, r)
+		if err != nil {
+			return nil, err
+		}
+
+		rendered[path] = b
+	}
+
+	written := make([]string, 0, len(rendered))
+
+	for path, b := range rendered {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gomnd // permissions of a source tree
+			return written, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		if err := os.WriteFile(path, b, 0o644); err != nil { //nolint:gomnd // permissions of a source file
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		written = append(written, path)
+	}
+
+	slices.Sort(written)
+
+	return written, nil
+}
+
+// render executes the template name with r and formats the output.
+func render(name string, r *Resource) ([]byte, error) {
+	t, err := template.ParseFS(templates, "templates/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var b bytes.Buffer
+	if err = t.Execute(&b, r); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", name, err)
+	}
+
+	return src, nil
+}
+
+// Register adds the module of r to the modules of internal/app under dir, before the modules wrapping all the routes.
+func Register(dir string, r *Resource) error {
+	path := filepath.Join(dir, "internal", "app", "app.go")
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read modules: %w", err)
+	}
+
+	if !bytes.Contains(b, []byte(modulesAnchor)) {
+		return fmt.Errorf("%w in %s", ErrNoModules, path)
+	}
+
+	b = bytes.Replace(b, []byte(modulesAnchor), []byte(", "+r.Package+"Module"+modulesAnchor), 1)
+
+	if err = os.WriteFile(path, b, 0o644); err != nil { //nolint:gomnd // permissions of a source file
+		return fmt.Errorf("failed to write modules: %w", err)
+	}
+
+	return nil
+}
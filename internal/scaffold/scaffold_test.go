@@ -0,0 +1,126 @@
+package scaffold_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/scaffold"
+)
+
+const modules = "return []func(c *Container){coreModule, mirrorModule, serverModule}\n"
+
+// newModule creates a module named example.com/svc with the modules of internal/app.
+func newModule(t *testing.T, appGo string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/svc\n\ngo 1.22\n"), 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "internal", "app"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "internal", "app", "app.go"), []byte(appGo), 0o600))
+
+	return dir
+}
+
+func TestNewResource(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		name     string
+		singular string
+		want     *scaffold.Resource
+		wantErr  error
+	}{
+		"plural": {
+			name: "albums",
+			want: &scaffold.Resource{Module: "example.com/svc", Package: "albums", Singular: "album", Type: "Album", Plural: "Albums", URL: "u"},
+		},
+		"irregular": {
+			name:     "people",
+			singular: "person",
+			want:     &scaffold.Resource{Module: "example.com/svc", Package: "people", Singular: "person", Type: "Person", Plural: "People", URL: "u"},
+		},
+		"no plural":  {name: "data", wantErr: scaffold.ErrInvalidName},
+		"upper case": {name: "Albums", wantErr: scaffold.ErrInvalidName},
+		"separator":  {name: "photo_tags", wantErr: scaffold.ErrInvalidName},
+	}
+
+	dir := newModule(t, modules)
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := scaffold.NewResource(dir, tt.name, tt.singular, "u")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	dir := newModule(t, modules)
+
+	r, err := scaffold.NewResource(dir, "albums", "", "https://upstream/albums")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files, err := scaffold.Generate(dir, r)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, files, 10)
+
+	for _, f := range files {
+		_, err = parser.ParseFile(token.NewFileSet(), f, nil, parser.AllErrors)
+		assert.NoError(t, err, f)
+	}
+
+	assert.FileExists(t, filepath.Join(dir, "internal", "albums", "albums.go"))
+	assert.FileExists(t, filepath.Join(dir, "internal", "api", "mocks", "albums_mock.go"))
+
+	_, err = scaffold.Generate(dir, r)
+	assert.ErrorIs(t, err, scaffold.ErrExists)
+
+	if !assert.NoError(t, scaffold.Register(dir, r)) {
+		return
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "internal", "app", "app.go"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "return []func(c *Container){coreModule, albumsModule, mirrorModule, serverModule}\n", string(b))
+}
+
+func TestRegister_NoModules(t *testing.T) {
+	t.Parallel()
+
+	dir := newModule(t, "package app\n")
+
+	r, err := scaffold.NewResource(dir, "albums", "", "u")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.ErrorIs(t, scaffold.Register(dir, r), scaffold.ErrNoModules)
+}
@@ -0,0 +1,190 @@
+// Package es keeps the photos in an Elasticsearch or OpenSearch index and searches them there, as a search.Searcher.
+// The Indexer updates the documents with the changes made through photos.Service, and Reindex rebuilds them from the
+// upstream, e.g. with the reindex command. Documents are scoped to the tenant of the changes.
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// defaultIndex is the index of the photo documents when none is configured.
+const defaultIndex = "photos"
+
+var (
+	// errNoURL is returned when the backend is configured without the URL of the cluster.
+	errNoURL = errors.New("elasticsearch url is required")
+	// errBulk is returned when documents of a bulk request were rejected.
+	errBulk = errors.New("bulk indexing failed")
+)
+
+// mapping is the mapping of the photo documents, created with the index. Only the titles are analyzed.
+const mapping = `{"mappings":{"properties":{` +
+	`"tenant":{"type":"keyword"},"id":{"type":"integer"},"albumId":{"type":"integer"},"title":{"type":"text"},` +
+	`"url":{"type":"keyword","index":false},"thumbnailUrl":{"type":"keyword","index":false},"deletedAt":{"type":"date"}}}}`
+
+// Client is the client of the photo index of a cluster.
+type Client struct {
+	hc *httpclient.Client
+	// index is the URL of the index.
+	index string
+}
+
+// New creates a Client of the index of cfg, sending the requests through rt.
+func New(cfg *config.Elasticsearch, rt http.RoundTripper) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, errNoURL
+	}
+
+	index := cfg.Index
+	if index == "" {
+		index = defaultIndex
+	}
+
+	if cfg.Username != "" {
+		rt = &basicAuth{next: rt, username: cfg.Username, password: cfg.Password}
+	}
+
+	return &Client{
+		hc:    httpclient.NewClient(&http.Client{Transport: rt}),
+		index: strings.TrimSuffix(cfg.URL, "/") + "/" + index,
+	}, nil
+}
+
+// document is a photo in the index.
+type document struct {
+	Tenant       string     `json:"tenant"`
+	ID           int        `json:"id"`
+	AlbumID      int        `json:"albumId"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	ThumbnailURL string     `json:"thumbnailUrl"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+}
+
+func newDocument(tenant string, p photos.Photo) document {
+	return document{Tenant: tenant, ID: p.ID, AlbumID: p.AlbumID, Title: p.Title, URL: p.URL, ThumbnailURL: p.ThumbnailURL, DeletedAt: p.DeletedAt}
+}
+
+// docID is the ID of the document, unique across tenants.
+func (d *document) docID() string {
+	return d.Tenant + ":" + strconv.Itoa(d.ID)
+}
+
+func (d *document) photo() photos.Photo {
+	return photos.Photo{ID: d.ID, AlbumID: d.AlbumID, Title: d.Title, URL: d.URL, ThumbnailURL: d.ThumbnailURL, DeletedAt: d.DeletedAt}
+}
+
+// EnsureIndex creates the index with the mapping of the documents, unless it exists.
+func (c *Client) EnsureIndex(ctx context.Context) error {
+	resp, err := c.hc.Get(ctx, c.index, httpclient.WithStatusCodes(http.StatusOK, http.StatusNotFound))
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = c.hc.Put(ctx, c.index, "application/json", strings.NewReader(mapping))
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	resp.Body.Close()
+
+	return nil
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// Bulk indexes docs, replacing the previous version of the documents.
+func (c *Client) Bulk(ctx context.Context, docs []document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+
+	enc := json.NewEncoder(&body)
+	for i := range docs {
+		action := map[string]map[string]string{"index": {"_id": docs[i].docID()}}
+		if err := enc.Encode(action); err != nil {
+			return fmt.Errorf("failed to encode bulk action: %w", err)
+		}
+
+		if err := enc.Encode(&docs[i]); err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+	}
+
+	resp, err := c.hc.Post(ctx, c.index+"/_bulk", "application/x-ndjson", &body)
+	if err != nil {
+		return fmt.Errorf("failed to index documents: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	var br bulkResponse
+	if err = json.NewDecoder(resp.Body).Decode(&br); err != nil {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+
+	if !br.Errors {
+		return nil
+	}
+
+	failed := 0
+	first := ""
+
+	for _, item := range br.Items {
+		for _, r := range item {
+			if r.Error == nil {
+				continue
+			}
+
+			if failed++; first == "" {
+				first = fmt.Sprintf("%s: %s: %s", r.ID, r.Error.Type, r.Error.Reason)
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %d of %d documents, first %s", errBulk, failed, len(docs), first)
+}
+
+// basicAuth authenticates the requests to the cluster.
+type basicAuth struct {
+	next     http.RoundTripper
+	username string
+	password string
+}
+
+func (b *basicAuth) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(b.username, b.password)
+
+	return b.next.RoundTrip(req) //nolint:wrapcheck // errors of the next transport are returned as is
+}
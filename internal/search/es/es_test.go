@@ -0,0 +1,260 @@
+package es_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/fake"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/search"
+	"github.com/twk/skeleton-go-api/internal/search/es"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// cluster is a fake cluster keeping the documents of one index.
+type cluster struct {
+	mu      sync.Mutex
+	created bool
+	docs    map[string]map[string]any
+	// searches are the bodies of the search requests.
+	searches []map[string]any
+	// reject fails the bulk requests.
+	reject bool
+}
+
+func newCluster(t *testing.T) (*cluster, *httptest.Server) {
+	t.Helper()
+
+	c := &cluster{docs: map[string]map[string]any{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /photos", func(w http.ResponseWriter, _ *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if !c.created {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("PUT /photos", func(_ http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		assert.Equal(t, "elastic", basicUser(r))
+		c.created = true
+	})
+	mux.HandleFunc("POST /photos/_bulk", func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+
+		if c.reject {
+			w.Write([]byte(`{"errors":true,"items":[{"index":{"_id":":1","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`))
+			return
+		}
+
+		s := bufio.NewScanner(r.Body)
+		for s.Scan() {
+			var action map[string]map[string]string
+			json.Unmarshal(s.Bytes(), &action)
+
+			s.Scan()
+
+			var doc map[string]any
+			json.Unmarshal(s.Bytes(), &doc)
+			c.docs[action["index"]["_id"]] = doc
+		}
+
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	})
+	mux.HandleFunc("POST /photos/_search", func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		c.searches = append(c.searches, body)
+
+		w.Write([]byte(`{"hits":{"total":{"value":11},"hits":[` +
+			`{"_score":2.5,"_source":{"tenant":"acme","id":3,"albumId":1,"title":"a sunset","url":"https://example.com/3","thumbnailUrl":"https://example.com/t/3"},"highlight":{"title":["a <b>sunset</b>"]}}]}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return c, server
+}
+
+func (c *cluster) doc(id string) map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.docs[id]
+}
+
+func (c *cluster) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.docs)
+}
+
+func basicUser(r *http.Request) string {
+	u, _, _ := r.BasicAuth()
+	return u
+}
+
+func newClient(t *testing.T, server *httptest.Server) *es.Client {
+	t.Helper()
+
+	c, err := es.New(&config.Elasticsearch{URL: server.URL + "/", Username: "elastic", Password: "secret"}, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := es.New(&config.Elasticsearch{}, http.DefaultTransport)
+	assert.Error(t, err)
+}
+
+func TestReindex(t *testing.T) {
+	t.Parallel()
+
+	cl, server := newCluster(t)
+	c := newClient(t, server)
+
+	g := fake.NewGenerator(1)
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(g))})
+	deletions := photos.NewMemoryDeletions()
+	ps := photos.NewService(hc, logger.NewNop(), photos.WithDeletions(deletions))
+	ctx := tenant.ContextWithTenant(context.Background(), "acme")
+
+	if !assert.NoError(t, ps.Delete(ctx, 2)) {
+		return
+	}
+
+	n, err := es.Reindex(ctx, c, ps)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, fake.Photos, n)
+	assert.Equal(t, fake.Photos, cl.count())
+	assert.True(t, cl.created)
+	assert.Equal(t, map[string]any{
+		"tenant": "acme", "id": float64(1), "albumId": float64(1), "title": g.Photo(1).Title, "url": g.Photo(1).URL, "thumbnailUrl": g.Photo(1).ThumbnailURL,
+	}, cl.doc("acme:1"))
+	assert.Contains(t, cl.doc("acme:2"), "deletedAt")
+
+	cl.reject = true
+	_, err = es.Reindex(ctx, c, ps)
+	assert.ErrorContains(t, err, "1 of 100 documents, first :1: mapper_parsing_exception: failed to parse")
+}
+
+func TestIndexer(t *testing.T) {
+	t.Parallel()
+
+	cl, server := newCluster(t)
+	ix := es.NewIndexer(newClient(t, server), &config.Elasticsearch{BatchSize: 2, FlushInterval: 10 * time.Millisecond}, logger.NewNop())
+
+	g := fake.NewGenerator(1)
+	hc := client.NewClient(&http.Client{Transport: fake.NewTransport(fake.NewUpstream(g))})
+	ps := photos.NewService(hc, logger.NewNop(), photos.WithDeletions(photos.NewMemoryDeletions()), photos.WithListeners(ix))
+	ctx := tenant.ContextWithTenant(context.Background(), "acme")
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ix.Run(runCtx)
+	}()
+
+	created, err := ps.Create(ctx, photos.Photo{AlbumID: 1, Title: "new", URL: "https://example.com/n", ThumbnailURL: "https://example.com/t/n"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	id := "acme:" + strconv.Itoa(created.ID)
+
+	assert.Eventually(t, func() bool { return cl.doc(id) != nil }, time.Second, time.Millisecond)
+
+	assert.NoError(t, ps.Delete(ctx, 3))
+	assert.Eventually(t, func() bool { return cl.doc("acme:3") != nil && cl.doc("acme:3")["deletedAt"] != nil }, time.Second, time.Millisecond)
+
+	assert.NoError(t, ps.Restore(ctx, 3))
+
+	// Changes queued when Run stops are still sent.
+	cancel()
+	<-done
+
+	assert.NotContains(t, cl.doc("acme:3"), "deletedAt")
+}
+
+func TestClient_Search(t *testing.T) {
+	t.Parallel()
+
+	cl, server := newCluster(t)
+	c := newClient(t, server)
+	ctx := tenant.ContextWithTenant(context.Background(), "acme")
+
+	r, err := c.Search(ctx, search.Query{Text: "sunset", AlbumID: 1, Page: 2, Limit: 10})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &search.Results{
+		Hits: []search.Hit{{
+			Photo:     photos.Photo{ID: 3, AlbumID: 1, Title: "a sunset", URL: "https://example.com/3", ThumbnailURL: "https://example.com/t/3"},
+			Rank:      2.5,
+			Highlight: "a <b>sunset</b>",
+		}},
+		Total: 11,
+		Page:  2,
+		Limit: 10,
+	}, r)
+
+	_, err = c.Search(photos.IncludeDeleted(ctx), search.Query{Text: "sunset"})
+	assert.NoError(t, err)
+
+	_, err = c.Search(ctx, search.Query{Text: "  "})
+	assert.ErrorIs(t, err, search.ErrEmptyQuery)
+
+	if !assert.Len(t, cl.searches, 2) {
+		return
+	}
+
+	body := cl.searches[0]
+	assert.Equal(t, float64(10), body["from"])
+	assert.Equal(t, float64(10), body["size"])
+
+	query := body["query"].(map[string]any)["bool"].(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"term": map[string]any{"tenant": "acme"}},
+		map[string]any{"term": map[string]any{"albumId": float64(1)}},
+	}, query["filter"])
+	assert.Equal(t, map[string]any{"exists": map[string]any{"field": "deletedAt"}}, query["must_not"])
+
+	// Deleted photos are searched when included.
+	assert.NotContains(t, cl.searches[1]["query"].(map[string]any)["bool"], "must_not")
+}
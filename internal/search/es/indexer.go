@@ -0,0 +1,176 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// Defaults of the indexer configuration.
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 10000
+)
+
+// Retries of a failed bulk request.
+const (
+	bulkAttempts = 3
+	retryDelay   = time.Second
+)
+
+// Indexer updates the documents of the photos changed through photos.Service, as a photos.Listener. Changes are queued
+// in memory and sent in bulk by Run: the ones still queued when the process stops, or dropped as the queue is full or
+// the cluster keeps failing, are only indexed by the next Reindex.
+type Indexer struct {
+	client        *Client
+	log           *logger.Logger
+	queue         chan document
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// NewIndexer creates an Indexer sending the changes with c.
+func NewIndexer(c *Client, cfg *config.Elasticsearch, l *logger.Logger) *Indexer {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	return &Indexer{client: c, log: l, queue: make(chan document, queueSize), batchSize: batchSize, flushInterval: flushInterval}
+}
+
+// PhotoChanged implements photos.Listener, queuing the photo of c to be indexed as it is now, deleted or not.
+func (ix *Indexer) PhotoChanged(ctx context.Context, c photos.Change) {
+	select {
+	case ix.queue <- newDocument(tenant.FromContext(ctx), c.Photo):
+	default:
+		ix.log.Warn("Search index queue full, dropping change", zap.Int("id", c.Photo.ID), zap.String("op", string(c.Op)))
+	}
+}
+
+// Run sends the queued changes in batches until ctx is done, then sends the ones already queued.
+func (ix *Indexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(ix.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]document, 0, ix.batchSize)
+
+	for {
+		select {
+		case d := <-ix.queue:
+			if batch = append(batch, d); len(batch) == ix.batchSize {
+				batch = ix.flush(ctx, batch)
+			}
+		case <-ticker.C:
+			batch = ix.flush(ctx, batch)
+		case <-ctx.Done():
+			ix.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends batch and the queued changes, without waiting for more.
+func (ix *Indexer) drain(batch []document) {
+	ctx := context.Background()
+
+	for {
+		select {
+		case d := <-ix.queue:
+			if batch = append(batch, d); len(batch) == ix.batchSize {
+				batch = ix.flush(ctx, batch)
+			}
+		default:
+			ix.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+// flush sends batch, retrying on failure, and returns it emptied.
+func (ix *Indexer) flush(ctx context.Context, batch []document) []document {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= bulkAttempts; attempt++ {
+		if err = ix.client.Bulk(ctx, batch); err == nil {
+			return batch[:0]
+		}
+
+		if attempt < bulkAttempts && !sleep(ctx, retryDelay*time.Duration(attempt)) {
+			break
+		}
+	}
+
+	ix.log.Error("Failed to index photo changes", zap.Int("changes", len(batch)), zap.Error(err))
+
+	return batch[:0]
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type exporter interface {
+	Export(ctx context.Context, fn func(p []photos.Photo) error) error
+}
+
+// Reindex creates the index if needed and indexes all the photos of the tenant of ctx from src, deleted or not,
+// returning how many were indexed. Documents of photos removed from the upstream are left in the index.
+func Reindex(ctx context.Context, c *Client, src exporter) (int, error) {
+	if err := c.EnsureIndex(ctx); err != nil {
+		return 0, err
+	}
+
+	t := tenant.FromContext(ctx)
+	n := 0
+
+	err := src.Export(photos.IncludeDeleted(ctx), func(p []photos.Photo) error {
+		docs := make([]document, len(p))
+		for i, photo := range p {
+			docs[i] = newDocument(t, photo)
+		}
+
+		if err := c.Bulk(ctx, docs); err != nil {
+			return err
+		}
+
+		n += len(docs)
+
+		return nil
+	})
+	if err != nil {
+		return n, fmt.Errorf("failed to reindex photos: %w", err)
+	}
+
+	return n, nil
+}
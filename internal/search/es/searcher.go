@@ -0,0 +1,98 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/search"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Score     float64             `json:"_score"`
+			Source    document            `json:"_source"`
+			Highlight map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Search implements search.Searcher. Every word of the query must match the title, and the hits are ranked by the
+// relevance score of the cluster.
+func (c *Client) Search(ctx context.Context, q search.Query) (*search.Results, error) {
+	if strings.TrimSpace(q.Text) == "" {
+		return nil, search.ErrEmptyQuery
+	}
+
+	page, limit := q.Pagination()
+
+	b, err := json.Marshal(searchBody(ctx, q, page, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode search: %w", err)
+	}
+
+	resp, err := c.hc.Post(ctx, c.index+"/_search", "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search photos: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	var sr searchResponse
+	if err = json.NewDecoder(resp.Body).Decode(&sr); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	r := &search.Results{Hits: make([]search.Hit, 0, len(sr.Hits.Hits)), Total: sr.Hits.Total.Value, Page: page, Limit: limit}
+
+	for _, h := range sr.Hits.Hits {
+		hit := search.Hit{Photo: h.Source.photo(), Rank: h.Score}
+		if fragments := h.Highlight["title"]; len(fragments) > 0 {
+			hit.Highlight = fragments[0]
+		}
+
+		r.Hits = append(r.Hits, hit)
+	}
+
+	return r, nil
+}
+
+// searchBody is the search request of q, in the documents of the tenant of ctx, without the deleted ones unless ctx
+// includes them.
+func searchBody(ctx context.Context, q search.Query, page, limit int) map[string]any {
+	filter := []any{map[string]any{"term": map[string]any{"tenant": tenant.FromContext(ctx)}}}
+	if q.AlbumID != 0 {
+		filter = append(filter, map[string]any{"term": map[string]any{"albumId": q.AlbumID}})
+	}
+
+	boolQuery := map[string]any{
+		"must":   map[string]any{"match": map[string]any{"title": map[string]any{"query": q.Text, "operator": "and"}}},
+		"filter": filter,
+	}
+
+	if !photos.IncludesDeleted(ctx) {
+		boolQuery["must_not"] = map[string]any{"exists": map[string]any{"field": "deletedAt"}}
+	}
+
+	return map[string]any{
+		"from":             (page - 1) * limit,
+		"size":             limit,
+		"track_total_hits": true,
+		"query":            map[string]any{"bool": boolQuery},
+		"sort":             []any{"_score", map[string]any{"id": "asc"}},
+		"highlight": map[string]any{
+			"pre_tags":  []string{"<b>"},
+			"post_tags": []string{"</b>"},
+			"encoder":   "html",
+			"fields":    map[string]any{"title": map[string]any{"number_of_fragments": 0}},
+		},
+	}
+}
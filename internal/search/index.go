@@ -0,0 +1,310 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// defaultRefresh is how long an index is used before it is rebuilt, when no refresh is configured.
+const defaultRefresh = 10 * time.Minute
+
+type source interface {
+	Export(ctx context.Context, fn func(p []photos.Photo) error) error
+	ApplyDeletions(ctx context.Context, p []photos.Photo) ([]photos.Photo, error)
+}
+
+// Index is a Searcher over an inverted index of the photo titles, built in memory from all the photos of a tenant on
+// its first search and rebuilt in the background once older than the refresh, meanwhile searches use the previous one.
+// Soft deletions are applied to every search, so they do not wait for a rebuild.
+type Index struct {
+	src     source
+	log     *logger.Logger
+	refresh time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*tenantIndex
+}
+
+type tenantIndex struct {
+	latest  *snapshot
+	pending *build
+}
+
+type build struct {
+	done chan struct{}
+	snap *snapshot
+	err  error
+}
+
+// snapshot is the index of the photos of a tenant at a point in time.
+type snapshot struct {
+	builtAt  time.Time
+	docs     []document
+	postings map[string][]posting
+}
+
+type document struct {
+	photo photos.Photo
+	words int
+}
+
+// posting is the number of occurrences of a word in a document.
+type posting struct {
+	doc   int
+	count int
+}
+
+// NewIndex creates an Index of the photos of src, rebuilt every refresh. Zero refresh uses 10 minutes.
+func NewIndex(src source, l *logger.Logger, refresh time.Duration) *Index {
+	if refresh <= 0 {
+		refresh = defaultRefresh
+	}
+
+	return &Index{src: src, log: l, refresh: refresh, now: time.Now, tenants: map[string]*tenantIndex{}}
+}
+
+// Search implements Searcher. Hits are ranked by the occurrences of the query words, weighted by their rarity and
+// normalized by the length of the title.
+func (x *Index) Search(ctx context.Context, q Query) (*Results, error) {
+	words := distinct(tokenize(q.Text))
+	if len(words) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	snap, err := x.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := snap.match(words)
+	if q.AlbumID != 0 {
+		hits = slices.DeleteFunc(hits, func(h Hit) bool { return h.Photo.AlbumID != q.AlbumID })
+	}
+
+	matched := make([]photos.Photo, len(hits))
+	for i, h := range hits {
+		matched[i] = h.Photo
+	}
+
+	kept, err := x.src.ApplyDeletions(ctx, matched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search photos: %w", err)
+	}
+
+	hits = keep(hits, kept)
+	page, limit := q.Pagination()
+	r := &Results{Hits: []Hit{}, Total: len(hits), Page: page, Limit: limit}
+
+	if from := (page - 1) * limit; from < len(hits) {
+		r.Hits = hits[from:min(from+limit, len(hits))]
+	}
+
+	for i := range r.Hits {
+		r.Hits[i].Highlight = highlight(r.Hits[i].Photo.Title, words)
+	}
+
+	return r, nil
+}
+
+// match returns the documents containing all words, most relevant first.
+func (s *snapshot) match(words []string) []Hit {
+	ranks := map[int]float64{}
+
+	for i, w := range words {
+		postings := s.postings[w]
+		idf := math.Log(1 + float64(len(s.docs))/float64(max(len(postings), 1)))
+		seen := make(map[int]float64, len(postings))
+
+		for _, p := range postings {
+			if rank, ok := ranks[p.doc]; ok || i == 0 {
+				seen[p.doc] = rank + float64(p.count)*idf
+			}
+		}
+
+		ranks = seen
+	}
+
+	hits := make([]Hit, 0, len(ranks))
+	for doc, rank := range ranks {
+		hits = append(hits, Hit{Photo: s.docs[doc].photo, Rank: rank / math.Sqrt(float64(s.docs[doc].words))})
+	}
+
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Rank != b.Rank {
+			if a.Rank > b.Rank {
+				return -1
+			}
+
+			return 1
+		}
+
+		return a.Photo.ID - b.Photo.ID
+	})
+
+	return hits
+}
+
+// keep returns the hits whose photo is in kept, with the photo of kept.
+func keep(hits []Hit, kept []photos.Photo) []Hit {
+	if len(kept) == len(hits) {
+		for i := range hits {
+			hits[i].Photo = kept[i]
+		}
+
+		return hits
+	}
+
+	byID := make(map[int]photos.Photo, len(kept))
+	for _, p := range kept {
+		byID[p.ID] = p
+	}
+
+	out := hits[:0]
+
+	for _, h := range hits {
+		if p, ok := byID[h.Photo.ID]; ok {
+			h.Photo = p
+			out = append(out, h)
+		}
+	}
+
+	return out
+}
+
+// snapshot returns the index of the tenant of ctx, building it on the first search, and starting a rebuild when it is
+// older than the refresh.
+func (x *Index) snapshot(ctx context.Context) (*snapshot, error) {
+	t := tenant.FromContext(ctx)
+
+	x.mu.Lock()
+
+	ti, ok := x.tenants[t]
+	if !ok {
+		ti = &tenantIndex{}
+		x.tenants[t] = ti
+	}
+
+	if ti.pending == nil && (ti.latest == nil || x.now().Sub(ti.latest.builtAt) > x.refresh) {
+		ti.pending = &build{done: make(chan struct{})}
+		go x.build(context.WithoutCancel(ctx), ti, ti.pending)
+	}
+
+	latest, pending := ti.latest, ti.pending
+	x.mu.Unlock()
+
+	if latest != nil {
+		return latest, nil
+	}
+
+	select {
+	case <-pending.done:
+		return pending.snap, pending.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to search photos: %w", ctx.Err())
+	}
+}
+
+// build indexes all the photos of the tenant of ctx, deleted or not.
+func (x *Index) build(ctx context.Context, ti *tenantIndex, b *build) {
+	defer close(b.done)
+
+	snap := &snapshot{builtAt: x.now(), postings: map[string][]posting{}}
+
+	err := x.src.Export(photos.IncludeDeleted(ctx), func(p []photos.Photo) error {
+		for _, photo := range p {
+			photo.DeletedAt = nil
+			snap.add(photo)
+		}
+
+		return nil
+	})
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	ti.pending = nil
+
+	if err != nil {
+		x.log.Error("Failed to build search index", zap.String("tenant", tenant.FromContext(ctx)), zap.Error(err))
+		b.err = fmt.Errorf("failed to build search index: %w", err)
+
+		return
+	}
+
+	x.log.Info("Search index built", zap.String("tenant", tenant.FromContext(ctx)), zap.Int("photos", len(snap.docs)))
+	b.snap, ti.latest = snap, snap
+}
+
+func (s *snapshot) add(p photos.Photo) {
+	words := tokenize(p.Title)
+	doc := len(s.docs)
+	s.docs = append(s.docs, document{photo: p, words: max(len(words), 1)})
+
+	counts := map[string]int{}
+	for _, w := range words {
+		counts[w]++
+	}
+
+	for w, n := range counts {
+		s.postings[w] = append(s.postings[w], posting{doc: doc, count: n})
+	}
+}
+
+// tokenize splits s into lower case words of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), isSeparator)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func distinct(words []string) []string {
+	slices.Sort(words)
+	return slices.Compact(words)
+}
+
+// highlight HTML escapes title and wraps its words among words in <b> tags.
+func highlight(title string, words []string) string {
+	var b strings.Builder
+
+	for len(title) > 0 {
+		start := strings.IndexFunc(title, func(r rune) bool { return !isSeparator(r) })
+		if start < 0 {
+			start = len(title)
+		}
+
+		b.WriteString(html.EscapeString(title[:start]))
+		title = title[start:]
+
+		end := strings.IndexFunc(title, isSeparator)
+		if end < 0 {
+			end = len(title)
+		}
+
+		word := title[:end]
+		if _, found := slices.BinarySearch(words, strings.ToLower(word)); found {
+			b.WriteString("<b>" + html.EscapeString(word) + "</b>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+
+		title = title[end:]
+	}
+
+	return b.String()
+}
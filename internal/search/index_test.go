@@ -0,0 +1,196 @@
+package search_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/photos"
+	"github.com/twk/skeleton-go-api/internal/search"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+// stubSource serves photos by tenant, and soft deletes the deleted ones.
+type stubSource struct {
+	photos  map[string][]photos.Photo
+	deleted map[int]bool
+	exports atomic.Int32
+	err     error
+}
+
+func (s *stubSource) Export(ctx context.Context, fn func(p []photos.Photo) error) error {
+	s.exports.Add(1)
+
+	if s.err != nil {
+		return s.err
+	}
+
+	return fn(s.photos[tenant.FromContext(ctx)])
+}
+
+func (s *stubSource) ApplyDeletions(_ context.Context, p []photos.Photo) ([]photos.Photo, error) {
+	kept := []photos.Photo{}
+
+	for _, photo := range p {
+		if !s.deleted[photo.ID] {
+			kept = append(kept, photo)
+		}
+	}
+
+	return kept, nil
+}
+
+func TestIndex_Search(t *testing.T) {
+	t.Parallel()
+
+	src := &stubSource{
+		photos: map[string][]photos.Photo{
+			"": {
+				{ID: 1, AlbumID: 1, Title: "Sunset over the harbor"},
+				{ID: 2, Title: "sunset, sunset & <sea>"},
+				{ID: 3, Title: "Harbor at night"},
+				{ID: 4, AlbumID: 2, Title: "A very long title about a sunset seen from a boat in the harbor"},
+				{ID: 5, Title: "Sunset harbor"},
+			},
+			"acme": {{ID: 1, Title: "Acme sunset"}},
+		},
+		deleted: map[int]bool{5: true},
+	}
+	idx := search.NewIndex(src, logger.NewNop(), time.Hour)
+
+	ids := func(r *search.Results) []int {
+		out := []int{}
+		for _, h := range r.Hits {
+			out = append(out, h.Photo.ID)
+		}
+
+		return out
+	}
+
+	type want struct {
+		ids        []int
+		total      int
+		highlights []string
+		err        error
+	}
+
+	tests := map[string]struct {
+		ctx   context.Context
+		query search.Query
+		want  want
+	}{
+		"ranked": {
+			ctx:   context.Background(),
+			query: search.Query{Text: "sunset"},
+			want: want{
+				ids:   []int{2, 1, 4},
+				total: 3,
+				highlights: []string{
+					"<b>sunset</b>, <b>sunset</b> &amp; &lt;sea&gt;",
+					"<b>Sunset</b> over the harbor",
+					"A very long title about a <b>sunset</b> seen from a boat in the harbor",
+				},
+			},
+		},
+		"all words": {
+			ctx:   context.Background(),
+			query: search.Query{Text: "HARBOR sunset"},
+			want:  want{ids: []int{1, 4}, total: 2},
+		},
+		"album": {
+			ctx:   context.Background(),
+			query: search.Query{Text: "sunset", AlbumID: 2},
+			want:  want{ids: []int{4}, total: 1},
+		},
+		"paginated": {
+			ctx:   context.Background(),
+			query: search.Query{Text: "sunset", Page: 2, Limit: 2},
+			want:  want{ids: []int{4}, total: 3},
+		},
+		"past the last page": {
+			ctx:   context.Background(),
+			query: search.Query{Text: "sunset", Page: 3, Limit: 2},
+			want:  want{ids: []int{}, total: 3},
+		},
+		"no match": {
+			ctx:   context.Background(),
+			query: search.Query{Text: "mountain"},
+			want:  want{ids: []int{}},
+		},
+		"tenant": {
+			ctx:   tenant.ContextWithTenant(context.Background(), "acme"),
+			query: search.Query{Text: "sunset"},
+			want:  want{ids: []int{1}, total: 1, highlights: []string{"Acme <b>sunset</b>"}},
+		},
+		"empty": {
+			ctx:   context.Background(),
+			query: search.Query{Text: " ,. "},
+			want:  want{err: search.ErrEmptyQuery},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := idx.Search(tt.ctx, tt.query)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want.ids, ids(r))
+			assert.Equal(t, tt.want.total, r.Total)
+
+			for i, h := range tt.want.highlights {
+				assert.Equal(t, h, r.Hits[i].Highlight)
+			}
+		})
+	}
+}
+
+func TestIndex_Refresh(t *testing.T) {
+	t.Parallel()
+
+	src := &stubSource{photos: map[string][]photos.Photo{"": {{ID: 1, Title: "sunset"}}}}
+	idx := search.NewIndex(src, logger.NewNop(), time.Nanosecond)
+
+	_, err := idx.Search(context.Background(), search.Query{Text: "sunset"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), src.exports.Load())
+
+	// A stale index is still used while it is rebuilt in the background.
+	time.Sleep(time.Millisecond)
+
+	r, err := idx.Search(context.Background(), search.Query{Text: "sunset"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, r.Total)
+	}
+
+	assert.Eventually(t, func() bool { return src.exports.Load() == 2 }, time.Second, time.Millisecond)
+}
+
+func TestIndex_BuildError(t *testing.T) {
+	t.Parallel()
+
+	src := &stubSource{err: assert.AnError}
+	idx := search.NewIndex(src, logger.NewNop(), time.Hour)
+
+	_, err := idx.Search(context.Background(), search.Query{Text: "sunset"})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	// A failed build is retried by the next search.
+	_, err = idx.Search(context.Background(), search.Query{Text: "sunset"})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, int32(2), src.exports.Load())
+}
@@ -0,0 +1,64 @@
+// Package search searches the photos by the words of their titles, with ranking, highlighting and pagination. Handlers
+// depend on the Searcher interface, so the Index built in memory from the upstream can be swapped for a search engine,
+// such as Postgres full-text search or Elasticsearch, once the photos live in one.
+package search
+
+import (
+	"context"
+	"errors"
+
+	"github.com/twk/skeleton-go-api/internal/photos"
+)
+
+// Bounds of the page size of a search.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrEmptyQuery is returned for queries without any word.
+var ErrEmptyQuery = errors.New("empty search query")
+
+// Query is a full-text search. Every word of Text must match.
+type Query struct {
+	Text string
+	// AlbumID restricts the hits to the photos of an album. Zero searches all albums.
+	AlbumID int
+	// Page is the page of results, from 1. Zero is the first page.
+	Page int
+	// Limit is the page size. Zero uses DefaultLimit, and it is capped at MaxLimit.
+	Limit int
+}
+
+// Pagination returns the page and page size of q, with their defaults and bounds applied.
+func (q *Query) Pagination() (page, limit int) {
+	limit = q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	return max(q.Page, 1), min(limit, MaxLimit)
+}
+
+// Hit is a photo matching a query.
+type Hit struct {
+	Photo photos.Photo `json:"photo"`
+	// Rank orders the hits, the higher the more relevant.
+	Rank float64 `json:"rank"`
+	// Highlight is the HTML escaped title with the matching words in <b> tags.
+	Highlight string `json:"highlight"`
+}
+
+// Results is a page of the hits of a query, most relevant first.
+type Results struct {
+	Hits []Hit `json:"hits"`
+	// Total counts the hits of all pages.
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
+
+// Searcher searches the photos of the tenant of ctx, leaving out the soft-deleted ones unless they are included.
+type Searcher interface {
+	Search(ctx context.Context, q Query) (*Results, error)
+}
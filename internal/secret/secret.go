@@ -0,0 +1,60 @@
+// Package secret resolves the references to secrets of the configuration, so that credentials are kept out of the
+// configuration file: in the environment, or in files mounted by the orchestrator such as Kubernetes secrets.
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Prefixes of the references.
+const (
+	EnvPrefix  = "env:"
+	FilePrefix = "file:"
+)
+
+// Placeholder is the value of the keys of example configurations, which every deployment must replace.
+const Placeholder = "change-me"
+
+var (
+	// ErrNotFound is returned for a reference to an environment variable which is not set.
+	ErrNotFound = errors.New("secret not found")
+	// ErrPlaceholder is returned by CheckKey for a key left to Placeholder.
+	ErrPlaceholder = errors.New(`key is the placeholder "change-me"`)
+)
+
+// Resolve returns the secret of ref: the value of the environment variable NAME for "env:NAME", the content of the file
+// PATH without its trailing newline for "file:PATH", and ref itself otherwise.
+func Resolve(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, EnvPrefix):
+		name := strings.TrimPrefix(ref, EnvPrefix)
+
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %s", ErrNotFound, name)
+		}
+
+		return v, nil
+	case strings.HasPrefix(ref, FilePrefix):
+		b, err := os.ReadFile(strings.TrimPrefix(ref, FilePrefix))
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+
+		return strings.TrimRight(string(b), "\r\n"), nil
+	default:
+		return ref, nil
+	}
+}
+
+// CheckKey returns ErrPlaceholder if key is Placeholder, since anyone reading an example configuration knows it.
+func CheckKey(key string) error {
+	if key == Placeholder {
+		return ErrPlaceholder
+	}
+
+	return nil
+}
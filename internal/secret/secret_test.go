@@ -0,0 +1,53 @@
+package secret_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/secret"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("SECRET_TEST_TOKEN", "from-env")
+
+	path := filepath.Join(t.TempDir(), "token")
+	if !assert.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600)) {
+		return
+	}
+
+	tests := map[string]struct {
+		ref     string
+		want    string
+		wantErr error
+	}{
+		"literal":     {ref: "plain", want: "plain"},
+		"env":         {ref: "env:SECRET_TEST_TOKEN", want: "from-env"},
+		"missing env": {ref: "env:SECRET_TEST_MISSING", wantErr: secret.ErrNotFound},
+		"file":        {ref: "file:" + path, want: "from-file"},
+		"empty":       {ref: "", want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := secret.Resolve(tt.ref)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, secret.CheckKey("s3cret"))
+	assert.ErrorIs(t, secret.CheckKey(secret.Placeholder), secret.ErrPlaceholder)
+}
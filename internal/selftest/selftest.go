@@ -0,0 +1,129 @@
+// Package selftest runs smoke requests against the running server, to gate deployments and canary analysis on the
+// service answering as expected once started.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// ErrFailed is returned when any smoke request does not get its expected status.
+var ErrFailed = errors.New("self-test failed")
+
+// Check is a smoke request and the status it expects.
+type Check struct {
+	Name   string
+	Method string
+	Path   string
+	Status int
+}
+
+// Result is the outcome of a Check.
+type Result struct {
+	Check   Check
+	Status  int
+	Latency time.Duration
+	Err     error
+}
+
+// Passed reports whether the check got its expected status.
+func (r *Result) Passed() bool {
+	return r.Err == nil && r.Status == r.Check.Status
+}
+
+// Defaults returns the checks run when none are configured: the health endpoint and a sample photo fetch.
+func Defaults() []Check {
+	return []Check{
+		{Name: "health", Method: http.MethodGet, Path: "/", Status: http.StatusOK},
+		{Name: "sample photo", Method: http.MethodGet, Path: "/photos/1", Status: http.StatusOK},
+	}
+}
+
+// ChecksOf returns the checks of cfg, defaulting the method to GET and the status to 200, or the Defaults if none are
+// configured.
+func ChecksOf(cfg *config.SelfTest) []Check {
+	if len(cfg.Checks) == 0 {
+		return Defaults()
+	}
+
+	checks := make([]Check, 0, len(cfg.Checks))
+
+	for _, c := range cfg.Checks {
+		check := Check{Name: c.Name, Method: c.Method, Path: c.Path, Status: c.Status}
+		if check.Method == "" {
+			check.Method = http.MethodGet
+		}
+
+		if check.Status == 0 {
+			check.Status = http.StatusOK
+		}
+
+		if check.Name == "" {
+			check.Name = check.Method + " " + check.Path
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// Run sends the checks in order to the server at baseURL and logs their results. It returns the results, and
+// ErrFailed if any check failed.
+func Run(ctx context.Context, hc *http.Client, baseURL string, checks []Check, l *logger.Logger) ([]Result, error) {
+	results := make([]Result, 0, len(checks))
+	failed := 0
+
+	for _, c := range checks {
+		r := run(ctx, hc, baseURL, c)
+		results = append(results, r)
+
+		fields := []zap.Field{zap.String("check", c.Name), zap.String("method", c.Method), zap.String("path", c.Path), zap.Int("want_status", c.Status), zap.Int("status", r.Status), zap.Duration("latency", r.Latency)}
+		if r.Passed() {
+			l.Info("self-test check passed", fields...)
+			continue
+		}
+
+		failed++
+
+		l.Error("self-test check failed", append(fields, zap.Error(r.Err))...)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: %d of %d checks", ErrFailed, failed, len(checks))
+	}
+
+	return results, nil
+}
+
+func run(ctx context.Context, hc *http.Client, baseURL string, c Check) Result {
+	r := Result{Check: c}
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, c.Method, baseURL+c.Path, http.NoBody)
+	if err != nil {
+		r.Err = fmt.Errorf("failed to create request: %w", err)
+		return r
+	}
+
+	resp, err := hc.Do(req)
+	r.Latency = time.Since(start)
+
+	if err != nil {
+		r.Err = fmt.Errorf("failed to send request: %w", err)
+		return r
+	}
+
+	resp.Body.Close()
+	r.Status = resp.StatusCode
+
+	return r
+}
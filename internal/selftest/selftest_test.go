@@ -0,0 +1,93 @@
+package selftest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/selftest"
+)
+
+func TestChecksOf(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg  config.SelfTest
+		want []selftest.Check
+	}{
+		"defaults": {
+			want: selftest.Defaults(),
+		},
+		"configured": {
+			cfg: config.SelfTest{Checks: []config.SelfTestCheck{
+				{Path: "/photos/1"},
+				{Name: "login", Method: http.MethodPost, Path: "/auth/token", Status: http.StatusBadRequest},
+			}},
+			want: []selftest.Check{
+				{Name: "GET /photos/1", Method: http.MethodGet, Path: "/photos/1", Status: http.StatusOK},
+				{Name: "login", Method: http.MethodPost, Path: "/auth/token", Status: http.StatusBadRequest},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, selftest.ChecksOf(&tt.cfg))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, _ *http.Request) {})
+	mux.HandleFunc("GET /photos/1", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		checks []selftest.Check
+		want   []bool
+		err    error
+	}{
+		"passed": {
+			checks: selftest.Defaults()[:1],
+			want:   []bool{true},
+		},
+		"failed": {
+			checks: selftest.Defaults(),
+			want:   []bool{true, false},
+			err:    selftest.ErrFailed,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			results, err := selftest.Run(context.Background(), server.Client(), server.URL, tt.checks, logger.NewNop())
+			assert.ErrorIs(t, err, tt.err)
+
+			passed := make([]bool, 0, len(results))
+			for _, r := range results {
+				passed = append(passed, r.Passed())
+			}
+
+			assert.Equal(t, tt.want, passed)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Formats of config.AccessLog.Format.
+const (
+	AccessLogJSON     = "json"
+	AccessLogCombined = "combined"
+	AccessLogTemplate = "template"
+)
+
+// combinedTime is the time layout of the Apache combined log format.
+const combinedTime = "02/Jan/2006:15:04:05 -0700"
+
+// ErrAccessLogFormat is returned for an unknown access log format or an invalid template.
+var ErrAccessLogFormat = errors.New("invalid access log format")
+
+// AccessEntry is the line of the access log of a request.
+type AccessEntry struct {
+	Time time.Time `json:"time"`
+	// RemoteIP is the client IP, resolved through the trusted proxies.
+	RemoteIP string `json:"remote_ip"`
+	// User is the subject of the authenticated consumer, empty for anonymous requests.
+	User   string `json:"user,omitempty"`
+	Method string `json:"method"`
+	// Path is the path of the request with its query.
+	Path   string `json:"path"`
+	Proto  string `json:"proto"`
+	Status int    `json:"status"`
+	// Bytes is the size of the response body.
+	Bytes   int           `json:"bytes"`
+	Latency time.Duration `json:"latency"`
+	// UpstreamLatency is the time spent on upstream requests.
+	UpstreamLatency time.Duration `json:"upstream_latency"`
+	UserAgent       string        `json:"user_agent,omitempty"`
+	Referer         string        `json:"referer,omitempty"`
+}
+
+// AccessLog writes the access log of the requests in one of the formats.
+type AccessLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+	tmpl   *template.Template
+}
+
+// NewAccessLog creates an access log writing to w in format, with tmpl the template of the "template" format.
+func NewAccessLog(w io.Writer, format, tmpl string) (*AccessLog, error) {
+	a := &AccessLog{w: w, format: format}
+
+	switch format {
+	case AccessLogJSON, AccessLogCombined:
+	case AccessLogTemplate:
+		t, err := template.New("access_log").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAccessLogFormat, err)
+		}
+
+		a.tmpl = t
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrAccessLogFormat, format)
+	}
+
+	return a, nil
+}
+
+// WithAccessLog writes the access log of the requests to a, on top of the debug line of the application log.
+func WithAccessLog(a *AccessLog) Option {
+	return func(s *Server) {
+		s.accessLog = a
+	}
+}
+
+// Write writes the line of e.
+func (a *AccessLog) Write(e *AccessEntry) error {
+	var buf bytes.Buffer
+
+	switch a.format {
+	case AccessLogJSON:
+		if err := json.NewEncoder(&buf).Encode(e); err != nil {
+			return fmt.Errorf("failed to encode access log entry: %w", err)
+		}
+	case AccessLogCombined:
+		fmt.Fprintf(&buf, "%s - %s [%s] \"%s %s %s\" %d %s %s %s\n",
+			dash(e.RemoteIP), dash(e.User), e.Time.Format(combinedTime), e.Method, e.Path, e.Proto, e.Status,
+			combinedBytes(e.Bytes), strconv.Quote(dash(e.Referer)), strconv.Quote(dash(e.UserAgent)))
+	case AccessLogTemplate:
+		if err := a.tmpl.Execute(&buf, e); err != nil {
+			return fmt.Errorf("failed to execute access log template: %w", err)
+		}
+
+		if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write access log: %w", err)
+	}
+
+	return nil
+}
+
+// accessEntry returns the access log entry of the request of c, once served.
+func accessEntry(c *gin.Context, start time.Time, path string, latency time.Duration) *AccessEntry {
+	ctx := c.Request.Context()
+
+	e := &AccessEntry{
+		Time:            start,
+		RemoteIP:        clientip.FromContext(ctx),
+		Method:          c.Request.Method,
+		Path:            path,
+		Proto:           c.Request.Proto,
+		Status:          c.Writer.Status(),
+		Bytes:           max(c.Writer.Size(), 0),
+		Latency:         latency,
+		UpstreamLatency: logger.EventFromContext(ctx).Duration(client.UpstreamLatencyKey),
+		UserAgent:       c.Request.UserAgent(),
+		Referer:         c.Request.Referer(),
+	}
+
+	if e.RemoteIP == "" {
+		e.RemoteIP = c.ClientIP()
+	}
+
+	if id := auth.IdentityFromContext(ctx); id != nil {
+		e.User = id.Subject
+	}
+
+	return e
+}
+
+// dash returns s, or "-" for the empty fields of the combined format.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// combinedBytes returns the size of the response body in the combined format, "-" for none.
+func combinedBytes(n int) string {
+	if n == 0 {
+		return "-"
+	}
+
+	return strconv.Itoa(n)
+}
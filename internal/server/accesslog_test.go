@@ -0,0 +1,122 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	rp := []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: func(c *gin.Context) {
+			logger.EventFromContext(c.Request.Context()).AddDuration(client.UpstreamLatencyKey, 2*time.Second)
+			c.String(http.StatusOK, "photo")
+		}},
+	}
+
+	type args struct {
+		format string
+		tmpl   string
+	}
+
+	tests := map[string]struct {
+		args args
+		want *regexp.Regexp
+	}{
+		"combined": {
+			args: args{format: server.AccessLogCombined},
+			want: regexp.MustCompile(`^192\.0\.2\.1 - alice \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /photos/1\?size=2 HTTP/1\.1" 200 5 "https://example\.com/" "tester/1\.0"\n$`),
+		},
+		"template": {
+			args: args{format: server.AccessLogTemplate, tmpl: "{{.Method}} {{.Path}} {{.Status}} {{.Bytes}} {{.UpstreamLatency}}"},
+			want: regexp.MustCompile(`^GET /photos/1\?size=2 200 5 2s\n$`),
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			a, err := server.NewAccessLog(&buf, tt.args.format, tt.args.tmpl)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			s := server.NewServer(&config.Server{}, gin.New(), rp, logger.NewNop(),
+				server.WithAccessLog(a), server.WithAuthenticators(headerAuthenticator{}))
+
+			s.ServeHTTP(httptest.NewRecorder(), accessLogRequest())
+
+			assert.Regexp(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestAccessLog_JSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	a, err := server.NewAccessLog(&buf, server.AccessLogJSON, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := server.NewServer(&config.Server{}, gin.New(), []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: func(c *gin.Context) { c.String(http.StatusOK, "photo") }},
+	}, logger.NewNop(), server.WithAccessLog(a), server.WithAuthenticators(headerAuthenticator{}))
+
+	s.ServeHTTP(httptest.NewRecorder(), accessLogRequest())
+
+	var got map[string]any
+	if !assert.NoError(t, json.Unmarshal(buf.Bytes(), &got)) {
+		return
+	}
+
+	assert.Equal(t, "192.0.2.1", got["remote_ip"])
+	assert.Equal(t, "alice", got["user"])
+	assert.Equal(t, "/photos/1?size=2", got["path"])
+	assert.Equal(t, float64(http.StatusOK), got["status"])
+	assert.Equal(t, float64(len("photo")), got["bytes"])
+	assert.Equal(t, "tester/1.0", got["user_agent"])
+	assert.Equal(t, "https://example.com/", got["referer"])
+	assert.Contains(t, got, "upstream_latency")
+}
+
+func TestNewAccessLog_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := server.NewAccessLog(&bytes.Buffer{}, "common", "")
+	assert.ErrorIs(t, err, server.ErrAccessLogFormat)
+
+	_, err = server.NewAccessLog(&bytes.Buffer{}, server.AccessLogTemplate, "{{.Method")
+	assert.ErrorIs(t, err, server.ErrAccessLogFormat)
+}
+
+func accessLogRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/photos/1?size=2", http.NoBody)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-User", "alice")
+	req.Header.Set("User-Agent", "tester/1.0")
+	req.Header.Set("Referer", "https://example.com/")
+
+	return req
+}
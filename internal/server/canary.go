@@ -0,0 +1,169 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+const (
+	// CanaryRequestsMetric counts the requests of the routes with a canary by method, route, variant and status.
+	CanaryRequestsMetric = "http_canary_requests_total"
+	// CanaryDurationMetric observes the duration of the requests of the routes with a canary in seconds, by method,
+	// route and variant.
+	CanaryDurationMetric = "http_canary_request_duration_seconds"
+)
+
+// Variants of the requests of a route with a canary. The canary variant is named after Canary.Name when set.
+const (
+	VariantStable = "stable"
+	VariantCanary = "canary"
+)
+
+// percent is the scale of Canary.Percent.
+const percent = 100
+
+// Canary sends a share of the requests of a route to an alternate handler, such as a new implementation or a proxy to
+// another upstream, to compare the variants in the canary metrics before switching over.
+type Canary struct {
+	// Name is the variant of the requests sent to Handler. Empty is "canary".
+	Name    string
+	Handler gin.HandlerFunc
+	// Percent is the percentage of the requests sent to Handler, from 0 to 100.
+	Percent float64
+	// Header and Cookie pin a request to a variant: a request whose header or cookie is the name of a variant is sent
+	// to it whatever Percent, e.g. for testers to try the canary before it gets traffic.
+	Header string
+	Cookie string
+}
+
+// RouteCanary is a Canary of the route registered with Method and Path.
+type RouteCanary struct {
+	Method string
+	Path   string
+	Canary Canary
+}
+
+// WithCanaries sets canaries of routes, for routes registered without RouteParam.Canary such as the configured ones.
+func WithCanaries(canaries ...RouteCanary) Option {
+	return func(s *Server) {
+		s.canaries = append(s.canaries, canaries...)
+	}
+}
+
+// WithMetrics sets the recorder of the canary and rate limit metrics. By default they are not recorded.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(s *Server) {
+		s.metrics = r
+	}
+}
+
+// canary returns the canary of the route of r, if any, marking the canaries of WithCanaries which found their route.
+func (s *Server) canary(r RouteParam, found []bool) *Canary {
+	if r.Canary != nil {
+		return r.Canary
+	}
+
+	for i, rc := range s.canaries {
+		if rc.Method == r.Method && rc.Path == r.Path {
+			found[i] = true
+			return &s.canaries[i].Canary
+		}
+	}
+
+	return nil
+}
+
+// canaryHandler sends each request to the variant it is pinned to or sampled for, and records the variant.
+func (s *Server) canaryHandler(stable gin.HandlerFunc, canary *Canary) gin.HandlerFunc {
+	name := canary.Name
+	if name == "" {
+		name = VariantCanary
+	}
+
+	return func(c *gin.Context) {
+		variant, handler := VariantStable, stable
+		if s.toCanary(c, canary, name) {
+			variant, handler = name, canary.Handler
+		}
+
+		start := time.Now()
+
+		handler(c)
+
+		labels := metrics.Labels{"method": c.Request.Method, "route": c.FullPath(), "variant": variant}
+		s.metrics.ObserveWithExemplar(CanaryDurationMetric, time.Since(start).Seconds(), labels, metrics.TraceID(c.Request))
+
+		labels["status"] = strconv.Itoa(c.Writer.Status())
+		s.metrics.Inc(CanaryRequestsMetric, labels)
+
+		logger.EventFromContext(c.Request.Context()).Add(zap.String("variant", variant))
+	}
+}
+
+// toCanary reports whether the request goes to the canary: as pinned by the header or the cookie, or else sampled.
+func (s *Server) toCanary(c *gin.Context, canary *Canary, name string) bool {
+	var pin string
+
+	if canary.Header != "" {
+		pin = c.GetHeader(canary.Header)
+	}
+
+	if pin == "" && canary.Cookie != "" {
+		pin, _ = c.Cookie(canary.Cookie)
+	}
+
+	switch pin {
+	case name:
+		return true
+	case VariantStable:
+		return false
+	default:
+		return canary.Percent > 0 && s.rand()*percent < canary.Percent
+	}
+}
+
+// Proxy returns a handler forwarding requests to the same path under target, e.g. a canary deployment. Failures to
+// reach it are answered 502 Bad Gateway.
+func Proxy(target string, l *logger.Logger) (gin.HandlerFunc, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy target %q", target)
+	}
+
+	p := httputil.NewSingleHostReverseProxy(u)
+	direct := p.Director
+	p.Director = func(r *http.Request) {
+		direct(r)
+		// Send the host of the target, for targets behind virtual hosts.
+		r.Host = u.Host
+	}
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		l.Warn("Failed to proxy request", zap.String("target", target), zap.String("path", r.URL.Path), zap.Error(err))
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return func(c *gin.Context) {
+		p.ServeHTTP(proxyWriter{c.Writer}, c.Request)
+	}, nil
+}
+
+// proxyWriter hides the CloseNotify of gin writers from the proxy, as it panics when the underlying writer lacks it.
+// The proxy stops on the cancellation of the request context instead.
+type proxyWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap lets the proxy flush the underlying writer.
+func (w proxyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+func TestCanary(t *testing.T) {
+	t.Parallel()
+
+	stable := func(c *gin.Context) { c.String(http.StatusOK, "stable") }
+	beta := func(c *gin.Context) { c.String(http.StatusOK, "beta") }
+
+	type args struct {
+		canary server.Canary
+		header string
+		cookie string
+	}
+
+	tests := map[string]struct {
+		args args
+		want string
+	}{
+		"no traffic":       {args: args{canary: server.Canary{Name: "beta", Handler: beta}}, want: "stable"},
+		"all traffic":      {args: args{canary: server.Canary{Name: "beta", Handler: beta, Percent: 100}}, want: "beta"},
+		"pinned by header": {args: args{canary: server.Canary{Name: "beta", Handler: beta, Header: "X-Variant"}, header: "beta"}, want: "beta"},
+		"pinned by cookie": {args: args{canary: server.Canary{Name: "beta", Handler: beta, Cookie: "variant"}, cookie: "beta"}, want: "beta"},
+		"pinned to stable": {args: args{canary: server.Canary{Name: "beta", Handler: beta, Percent: 100, Header: "X-Variant"}, header: "stable"}, want: "stable"},
+		"unknown variant":  {args: args{canary: server.Canary{Name: "beta", Handler: beta, Header: "X-Variant"}, header: "gamma"}, want: "stable"},
+		"default name":     {args: args{canary: server.Canary{Handler: beta, Header: "X-Variant"}, header: "canary"}, want: "beta"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			canary := tt.args.canary
+			s := server.NewServer(&config.Server{}, gin.New(), []server.RouteParam{
+				{Method: http.MethodGet, Path: "/photos/:id", Handler: stable, Canary: &canary},
+			}, logger.NewNop())
+
+			req := httptest.NewRequest(http.MethodGet, "/photos/1", http.NoBody)
+			if tt.args.header != "" {
+				req.Header.Set("X-Variant", tt.args.header)
+			}
+
+			if tt.args.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "variant", Value: tt.args.cookie})
+			}
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want, resp.Body.String())
+		})
+	}
+}
+
+func TestWithCanaries(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("canary " + r.URL.Path))
+	}))
+	t.Cleanup(upstream.Close)
+
+	proxy, err := server.Proxy(upstream.URL, logger.NewNop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	mr := metrics.New()
+	s := server.NewServer(&config.Server{}, gin.New(), []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: func(c *gin.Context) { c.String(http.StatusOK, "stable") }},
+	}, logger.NewNop(),
+		server.WithCanaries(server.RouteCanary{Method: http.MethodGet, Path: "/photos/:id", Canary: server.Canary{Handler: proxy, Header: "X-Variant"}}),
+		server.WithMetrics(mr),
+	)
+
+	for _, variant := range []string{"canary", "stable", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/photos/1", http.NoBody)
+		req.Header.Set("X-Variant", variant)
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/photos/2", http.NoBody)
+	req.Header.Set("X-Variant", "canary")
+	s.ServeHTTP(resp, req)
+
+	assert.Equal(t, "canary /photos/2", resp.Body.String())
+
+	scrape := httptest.NewRecorder()
+	mr.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	body, _ := io.ReadAll(scrape.Body)
+	assert.Contains(t, string(body), `http_canary_requests_total{method="GET",route="/photos/:id",status="200",variant="canary"} 2`)
+	assert.Contains(t, string(body), `http_canary_requests_total{method="GET",route="/photos/:id",status="200",variant="stable"} 2`)
+	assert.Contains(t, string(body), `http_canary_request_duration_seconds_count{method="GET",route="/photos/:id",variant="canary"} 2`)
+}
+
+func TestProxy_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := server.Proxy("/relative", logger.NewNop())
+	assert.Error(t, err)
+
+	proxy, err := server.Proxy("http://127.0.0.1:1", logger.NewNop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	router := gin.New()
+	router.GET("/", proxy)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	assert.Equal(t, http.StatusBadGateway, resp.Code)
+}
@@ -0,0 +1,21 @@
+package server
+
+import "time"
+
+// NewRateLimiter creates the limiter of the route buckets of limit, holding the buckets of size clients at most.
+func NewRateLimiter(limit RateLimit, size int) *rateLimiter { //nolint:revive // the limiter is only used by tests
+	return newRateLimiter(limit, size)
+}
+
+// Allow reports whether a request of the client key is allowed at now.
+func (l *rateLimiter) Allow(key string, now time.Time) bool {
+	return l.limiter(key).AllowN(now, 1)
+}
+
+// Len returns the number of client buckets held.
+func (l *rateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lru.Len()
+}
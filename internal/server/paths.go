@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Modes of config.Paths.TrailingSlash.
+const (
+	TrailingSlashRedirect = "redirect"
+	TrailingSlashRewrite  = "rewrite"
+)
+
+// ValidatePaths checks the modes of the path normalization.
+func ValidatePaths(cfg *config.Paths) error {
+	switch cfg.TrailingSlash {
+	case "", TrailingSlashRedirect, TrailingSlashRewrite:
+		return nil
+	default:
+		return fmt.Errorf("unknown trailing slash mode %q", cfg.TrailingSlash)
+	}
+}
+
+// normalizePaths returns next behind the path normalization of the configuration, which runs before routing. Paths
+// ending with a slash are redirected or rewritten to the path without it, and the static segments of paths are
+// rewritten to the case of the route they match.
+func (s *Server) normalizePaths(next http.Handler) http.Handler {
+	cfg := s.config.Paths
+	if cfg.TrailingSlash == "" && !cfg.CaseInsensitive {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+
+		trimmed := cfg.TrailingSlash != "" && len(path) > 1 && strings.HasSuffix(path, "/")
+		if trimmed {
+			path = "/" + strings.Trim(path, "/")
+		}
+
+		if cfg.CaseInsensitive {
+			path = s.routeCase(path)
+		}
+
+		if path == r.URL.Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u := *r.URL
+		u.Path, u.RawPath = path, ""
+
+		if trimmed && cfg.TrailingSlash == TrailingSlashRedirect {
+			// Permanent redirects keep the method and body of other methods only with 308.
+			code := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				code = http.StatusMovedPermanently
+			}
+
+			http.Redirect(w, r, u.RequestURI(), code)
+
+			return
+		}
+
+		rewritten := r.Clone(r.Context())
+		rewritten.URL = &u
+		next.ServeHTTP(w, rewritten)
+	})
+}
+
+// routeCase returns path with its static segments in the case of the route matching it regardless of case, or path
+// itself if a route matches it as is or none does.
+func (s *Server) routeCase(path string) string {
+	for _, r := range s.routes {
+		if matchPath(r.Path, path, sameSegment) {
+			return path
+		}
+	}
+
+	for _, r := range s.routes {
+		if matchPath(r.Path, path, strings.EqualFold) {
+			return withCaseOf(r.Path, path)
+		}
+	}
+
+	return path
+}
+
+// withCaseOf returns path with the static segments of pattern, which it matches regardless of case.
+func withCaseOf(pattern, path string) string {
+	patternSegs := strings.Split(pattern, "/")
+	pathSegs := strings.Split(path, "/")
+
+	for i, seg := range patternSegs {
+		if strings.HasPrefix(seg, "*") {
+			break
+		}
+
+		if !strings.HasPrefix(seg, ":") {
+			pathSegs[i] = seg
+		}
+	}
+
+	return strings.Join(pathSegs, "/")
+}
@@ -0,0 +1,110 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+func TestServer_Paths(t *testing.T) {
+	t.Parallel()
+
+	echo := func(c *gin.Context) { c.String(http.StatusOK, c.Request.URL.Path+" "+c.Param("id")) }
+	rp := []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: echo},
+		{Method: http.MethodPost, Path: "/photos", Handler: echo},
+		{Method: http.MethodGet, Path: "/Albums/:id", Handler: echo},
+	}
+
+	type args struct {
+		paths  config.Paths
+		method string
+		target string
+	}
+
+	type want struct {
+		status   int
+		body     string
+		location string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"as registered": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRewrite, CaseInsensitive: true}, method: http.MethodGet, target: "/photos/Ab1"},
+			want: want{status: http.StatusOK, body: "/photos/Ab1 Ab1"},
+		},
+		"rewrite trailing slash": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRewrite}, method: http.MethodPost, target: "/photos/"},
+			want: want{status: http.StatusOK, body: "/photos "},
+		},
+		"redirect trailing slash": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRedirect}, method: http.MethodGet, target: "/photos/1/?size=2"},
+			want: want{status: http.StatusMovedPermanently, location: "/photos/1?size=2"},
+		},
+		"redirect trailing slash keeping the method": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRedirect}, method: http.MethodPost, target: "/photos//"},
+			want: want{status: http.StatusPermanentRedirect, location: "/photos"},
+		},
+		"case insensitive": {
+			args: args{paths: config.Paths{CaseInsensitive: true}, method: http.MethodGet, target: "/PHOTOS/Ab1"},
+			want: want{status: http.StatusOK, body: "/photos/Ab1 Ab1"},
+		},
+		"case of the route": {
+			args: args{paths: config.Paths{CaseInsensitive: true}, method: http.MethodGet, target: "/albums/1"},
+			want: want{status: http.StatusOK, body: "/Albums/1 1"},
+		},
+		"case and trailing slash": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRewrite, CaseInsensitive: true}, method: http.MethodGet, target: "/Photos/1/"},
+			want: want{status: http.StatusOK, body: "/photos/1 1"},
+		},
+		"redirect to the case of the route": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRedirect, CaseInsensitive: true}, method: http.MethodGet, target: "/Photos/1/"},
+			want: want{status: http.StatusMovedPermanently, location: "/photos/1"},
+		},
+		"case sensitive": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRewrite}, method: http.MethodGet, target: "/Photos/1"},
+			want: want{status: http.StatusNotFound, body: `{"message":"Not Found"}`},
+		},
+		"root": {
+			args: args{paths: config.Paths{TrailingSlash: server.TrailingSlashRedirect}, method: http.MethodGet, target: "/"},
+			want: want{status: http.StatusOK, body: "ok"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := server.NewServer(&config.Server{Paths: tt.args.paths}, gin.New(), rp, logger.NewNop())
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, httptest.NewRequest(tt.args.method, tt.args.target, http.NoBody))
+
+			assert.Equal(t, tt.want.status, resp.Code)
+			assert.Equal(t, tt.want.location, resp.Header().Get("Location"))
+
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestValidatePaths(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, server.ValidatePaths(&config.Paths{TrailingSlash: server.TrailingSlashRedirect}))
+	assert.Error(t, server.ValidatePaths(&config.Paths{TrailingSlash: "strip"}))
+}
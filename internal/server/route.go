@@ -0,0 +1,186 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/clientip"
+	"github.com/twk/skeleton-go-api/internal/jsonbind"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// RateLimitedMetric counts the requests rejected by the rate limit of their route, by method and route.
+const RateLimitedMetric = "http_rate_limited_total"
+
+// maxClientLimiters bounds the number of client limiters of a route, the least recently used are evicted.
+const maxClientLimiters = 10000
+
+// RateLimit limits the rate of the requests of a route with a token bucket. Requests over the limit are answered
+// 429 Too Many Requests with a Retry-After header.
+type RateLimit struct {
+	// RPS is the sustained rate of requests per second.
+	RPS float64
+	// Burst is the number of requests allowed at once, at least 1.
+	Burst int
+	// PerClient limits each client IP separately rather than all the requests of the route together.
+	PerClient bool
+}
+
+// rateLimiter enforces a RateLimit.
+type rateLimiter struct {
+	limit RateLimit
+	size  int
+	mu    sync.Mutex
+	// limiters are the elements of lru by client IP, or the single one of the route under the empty key.
+	limiters map[string]*list.Element
+	// lru holds the clientLimiters, the most recently used first.
+	lru *list.List
+}
+
+// clientLimiter is the bucket of a client.
+type clientLimiter struct {
+	key     string
+	limiter *rate.Limiter
+}
+
+// newRateLimiter creates the rateLimiter of limit, holding the buckets of size clients at most.
+func newRateLimiter(limit RateLimit, size int) *rateLimiter {
+	return &rateLimiter{limit: limit, size: size, limiters: map[string]*list.Element{}, lru: list.New()}
+}
+
+func (l *rateLimiter) limiter(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.limiters[key]; ok {
+		l.lru.MoveToFront(e)
+		return e.Value.(*clientLimiter).limiter //nolint:forcetypeassert // lru holds clientLimiters
+	}
+
+	// The bucket of the least recently seen client is forgotten. It has most likely refilled since, and a full bucket
+	// behaves as a new one.
+	if l.lru.Len() >= l.size {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.limiters, oldest.Value.(*clientLimiter).key) //nolint:forcetypeassert // lru holds clientLimiters
+	}
+
+	lim := rate.NewLimiter(rate.Limit(l.limit.RPS), max(l.limit.Burst, 1))
+	l.limiters[key] = l.lru.PushFront(&clientLimiter{key: key, limiter: lim})
+
+	return lim
+}
+
+// rateLimitMiddleware rejects the requests over the rate limit of the route.
+func (s *Server) rateLimitMiddleware(limit RateLimit) gin.HandlerFunc {
+	l := newRateLimiter(limit, maxClientLimiters)
+
+	return func(c *gin.Context) {
+		var key string
+
+		if limit.PerClient {
+			key = clientip.FromContext(c.Request.Context())
+			if key == "" {
+				key = c.RemoteIP()
+			}
+		}
+
+		now := time.Now()
+
+		// The burst is at least 1, so a reservation of one token is always possible, possibly in the future.
+		r := l.limiter(key).ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+
+			s.metrics.Inc(RateLimitedMetric, metrics.Labels{"method": c.Request.Method, "route": c.FullPath()})
+			c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(delay.Seconds())), 1)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limited"})
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// timeoutMiddleware cancels the request context once timeout elapses, and answers 503 Service Unavailable if the
+// handlers did not answer by then. Handlers ignoring their context still run to completion.
+func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "request timed out"})
+		}
+	}
+}
+
+// jsonMiddleware sets the decoding options of the JSON request bodies in the request context, for jsonbind.Bind.
+func jsonMiddleware(o jsonbind.Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(jsonbind.ContextWithOptions(c.Request.Context(), o))
+
+		c.Next()
+	}
+}
+
+// jsonOptions returns the decoding options of the JSON request bodies of r: the ones of its server.json_routes entry,
+// marked found, or of r. Nil uses the ones of the strictness mode.
+func (s *Server) jsonOptions(r RouteParam, found []bool) *jsonbind.Options {
+	for i, jr := range s.config.JSONRoutes {
+		if strings.EqualFold(jr.Method, r.Method) && jr.Path == r.Path {
+			found[i] = true
+			return &jsonbind.Options{DisallowUnknownFields: jr.DisallowUnknownFields, UseNumber: jr.UseNumber}
+		}
+	}
+
+	return r.JSON
+}
+
+// routeHandlers returns the handlers of r: its JSON decoding, rate limit and timeout, authentication and
+// authorization, the route middleware of the server and its own, and its handler or canary, if not nil.
+func (s *Server) routeHandlers(r RouteParam, canary *Canary, json *jsonbind.Options) []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+
+	if json != nil {
+		handlers = append(handlers, jsonMiddleware(*json))
+	}
+
+	if r.RateLimit != nil {
+		handlers = append(handlers, s.rateLimitMiddleware(*r.RateLimit))
+	}
+
+	if r.Timeout > 0 {
+		handlers = append(handlers, timeoutMiddleware(r.Timeout))
+	}
+
+	handlers = append(handlers, auth.Middleware(r.Auth, s.authenticators...))
+	if r.Policy != nil {
+		handlers = append(handlers, s.authorizer.Middleware(r.Policy))
+	}
+
+	handlers = append(handlers, s.routeMW...)
+	handlers = append(handlers, r.Middleware...)
+
+	if canary != nil {
+		return append(handlers, s.canaryHandler(r.Handler, canary))
+	}
+
+	return append(handlers, r.Handler)
+}
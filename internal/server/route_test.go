@@ -0,0 +1,211 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/jsonbind"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+func TestRouteParam_Options(t *testing.T) {
+	t.Parallel()
+
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	slow := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+			c.String(http.StatusOK, "late")
+		}
+	}
+	tag := func(c *gin.Context) { c.Header("X-Route", "admin") }
+
+	rp := []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: ok, Auth: auth.ModeNone, RateLimit: &server.RateLimit{RPS: 0.001, Burst: 2, PerClient: true}},
+		{Method: http.MethodGet, Path: "/admin/photos", Handler: ok, Auth: auth.ModeRequired, Middleware: []gin.HandlerFunc{tag}},
+		{Method: http.MethodGet, Path: "/slow", Handler: slow, Timeout: 20 * time.Millisecond},
+	}
+
+	type request struct {
+		path       string
+		user       string
+		remoteAddr string
+	}
+
+	type want struct {
+		status     int
+		route      string
+		retryAfter bool
+	}
+
+	tests := map[string]struct {
+		requests []request
+		want     want
+	}{
+		"public route": {
+			requests: []request{{path: "/photos/1"}},
+			want:     want{status: http.StatusOK},
+		},
+		"rate limited": {
+			requests: []request{{path: "/photos/1"}, {path: "/photos/2"}, {path: "/photos/3"}},
+			want:     want{status: http.StatusTooManyRequests, retryAfter: true},
+		},
+		"rate limited per client": {
+			requests: []request{{path: "/photos/1"}, {path: "/photos/2"}, {path: "/photos/3", remoteAddr: "192.0.2.2:1234"}},
+			want:     want{status: http.StatusOK},
+		},
+		"authenticated route": {
+			requests: []request{{path: "/admin/photos", user: "alice"}},
+			want:     want{status: http.StatusOK, route: "admin"},
+		},
+		"authenticated route anonymously": {
+			requests: []request{{path: "/admin/photos"}},
+			want:     want{status: http.StatusUnauthorized},
+		},
+		"timed out": {
+			requests: []request{{path: "/slow"}},
+			want:     want{status: http.StatusServiceUnavailable},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := server.NewServer(&config.Server{}, gin.New(), rp, logger.NewNop(), server.WithAuthenticators(headerAuthenticator{}))
+
+			var resp *httptest.ResponseRecorder
+
+			for _, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodGet, r.path, http.NoBody)
+				if r.user != "" {
+					req.Header.Set("X-User", r.user)
+				}
+
+				if r.remoteAddr != "" {
+					req.RemoteAddr = r.remoteAddr
+				}
+
+				resp = httptest.NewRecorder()
+				s.ServeHTTP(resp, req)
+			}
+
+			assert.Equal(t, tt.want.status, resp.Code)
+			assert.Equal(t, tt.want.route, resp.Header().Get("X-Route"))
+			assert.Equal(t, tt.want.retryAfter, resp.Header().Get("Retry-After") != "")
+		})
+	}
+}
+
+func TestRouteParam_JSON(t *testing.T) {
+	t.Parallel()
+
+	type body struct {
+		ID any `json:"id"`
+	}
+
+	bind := func(c *gin.Context) {
+		var b body
+		if err := jsonbind.Bind(c, &b); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		c.String(http.StatusOK, "%T", b.ID)
+	}
+
+	cfg := &config.Server{JSONRoutes: []config.JSONRoute{
+		{Method: "post", Path: "/lenient", UseNumber: true},
+		{Method: http.MethodPost, Path: "/missing", DisallowUnknownFields: true},
+	}}
+	rp := []server.RouteParam{
+		{Method: http.MethodPost, Path: "/strict", Handler: bind, JSON: &jsonbind.Strict},
+		{Method: http.MethodPost, Path: "/lenient", Handler: bind, JSON: &jsonbind.Strict},
+		{Method: http.MethodPost, Path: "/default", Handler: bind},
+	}
+
+	type want struct {
+		status int
+		body   string
+	}
+
+	tests := map[string]struct {
+		path string
+		body string
+		want want
+	}{
+		"strict": {
+			path: "/strict",
+			body: `{"id":12345678901234567890}`,
+			want: want{status: http.StatusOK, body: "json.Number"},
+		},
+		"strict unknown field": {
+			path: "/strict",
+			body: `{"id":1,"name":"a"}`,
+			want: want{status: http.StatusBadRequest, body: `invalid json: json: unknown field "name"`},
+		},
+		"configured": {
+			path: "/lenient",
+			body: `{"id":1,"name":"a"}`,
+			want: want{status: http.StatusOK, body: "json.Number"},
+		},
+		"default": {
+			path: "/default",
+			body: `{"id":1,"name":"a"}`,
+			want: want{status: http.StatusOK, body: "float64"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := server.NewServer(cfg, gin.New(), rp, logger.NewNop())
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.want.status, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
+
+func TestRateLimiter_Bounded(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	l := server.NewRateLimiter(server.RateLimit{RPS: 0.001, Burst: 1, PerClient: true}, 3)
+
+	// Clients rotating their keys, each spending its token, never hold more buckets than the bound.
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		assert.True(t, l.Allow(key, now), key)
+	}
+
+	assert.Equal(t, 3, l.Len())
+
+	// The recently seen clients keep their spent buckets, the least recently seen ones start over.
+	assert.False(t, l.Allow("e", now))
+	assert.False(t, l.Allow("c", now))
+	assert.True(t, l.Allow("a", now))
+	assert.Equal(t, 3, l.Len())
+
+	// Using a bucket makes it recent: e, seen before c, is evicted rather than c.
+	assert.True(t, l.Allow("f", now))
+	assert.False(t, l.Allow("c", now))
+	assert.True(t, l.Allow("e", now))
+}
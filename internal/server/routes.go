@@ -0,0 +1,93 @@
+package server
+
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+)
+
+// Authentication requirements of RouteInfo.Auth.
+const (
+	RouteAuthOptional = "optional"
+	RouteAuthRequired = "required"
+	RouteAuthNone     = "none"
+)
+
+// funcSuffix matches the suffix of the names of the closures and method values, e.g. .func1.2, .1 once inlined or -fm.
+var funcSuffix = regexp.MustCompile(`(\.(func)?\d+)+$|-fm$`)
+
+// RouteInfo describes a registered route, to audit the surface of the API.
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Auth is RouteAuthOptional, RouteAuthRequired or RouteAuthNone. The routes of the server itself are never
+	// authenticated.
+	Auth string `json:"auth"`
+	// Policy, Roles and Scopes are the authorization policy of the route, empty for none.
+	Policy string   `json:"policy,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+	// Middleware are the functions run before the handler, in order, the ones of every route first.
+	Middleware []string `json:"middleware"`
+	Handler    string   `json:"handler"`
+	// Canary is the variant the route sends a share of its requests to, empty for none.
+	Canary string `json:"canary,omitempty"`
+}
+
+// Routes returns the registered routes, sorted by path and method.
+func (s *Server) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(s.routes))
+	copy(routes, s.routes)
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// routeInfo returns the description of the contributed route r, served by handlers.
+func routeInfo(r RouteParam, canary *Canary) RouteInfo {
+	info := RouteInfo{Method: r.Method, Path: r.Path, Auth: RouteAuthOptional, Handler: funcName(r.Handler)}
+
+	switch r.Auth {
+	case auth.ModeRequired:
+		info.Auth = RouteAuthRequired
+	case auth.ModeNone:
+		info.Auth = RouteAuthNone
+	case auth.ModeOptional:
+	}
+
+	if r.Policy != nil {
+		info.Policy, info.Roles, info.Scopes = r.Policy.Name, r.Policy.Roles, r.Policy.Scopes
+	}
+
+	if canary != nil {
+		info.Canary = canary.Name
+		if info.Canary == "" {
+			info.Canary = "canary"
+		}
+	}
+
+	return info
+}
+
+// funcName returns the name of h without its import path and closure suffix, e.g. api.Photos.
+func funcName(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return funcSuffix.ReplaceAllString(name, "")
+}
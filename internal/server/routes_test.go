@@ -0,0 +1,54 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+func listPhotos(c *gin.Context) {
+	c.String(http.StatusOK, "photos")
+}
+
+func TestServer_Routes(t *testing.T) {
+	t.Parallel()
+
+	rp := []server.RouteParam{
+		{Method: http.MethodPost, Path: "/photos", Handler: listPhotos, Auth: auth.ModeRequired, Timeout: time.Second,
+			Policy: &auth.Policy{Name: "photos:write", Roles: []string{"editor"}, Scopes: []string{"photos.write"}}},
+		{Method: http.MethodGet, Path: "/photos", Handler: listPhotos},
+	}
+	s := server.NewServer(&config.Server{}, gin.New(), rp, logger.NewNop())
+
+	routes := s.Routes()
+	if !assert.Len(t, routes, 5) {
+		return
+	}
+
+	assert.Equal(t, server.RouteInfo{
+		Method: http.MethodGet, Path: "/", Auth: server.RouteAuthNone,
+		Middleware: []string{"server.(*Server).LoggerMiddleware"}, Handler: "server.root",
+	}, routes[0])
+	assert.Equal(t, server.RouteInfo{
+		Method: http.MethodGet, Path: "/photos", Auth: server.RouteAuthOptional,
+		Middleware: []string{"server.(*Server).LoggerMiddleware", "auth.Middleware"}, Handler: "server_test.listPhotos",
+	}, routes[2])
+	assert.Equal(t, server.RouteInfo{
+		Method: http.MethodPost, Path: "/photos", Auth: server.RouteAuthRequired,
+		Policy: "photos:write", Roles: []string{"editor"}, Scopes: []string{"photos.write"},
+		Middleware: []string{
+			"server.(*Server).LoggerMiddleware", "server.timeoutMiddleware", "auth.Middleware",
+			"auth.(*Authorizer).Middleware",
+		},
+		Handler: "server_test.listPhotos",
+	}, routes[3])
+	assert.Equal(t, "server.(*Server).readiness", routes[4].Handler)
+}
@@ -2,15 +2,33 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/twk/skeleton-go-api/internal/auth"
 	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/jsonbind"
 	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+// Timeouts of the server started by ServeUntil.
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+	// healthTimeout bounds the health checks of a /health request.
+	healthTimeout = 2 * time.Second
 )
 
 // RouteParam holds the each service that is required for the routes.
@@ -18,6 +36,28 @@ type RouteParam struct {
 	Method  string
 	Path    string
 	Handler gin.HandlerFunc
+	// Auth is the authentication requirement of the route, optional by default.
+	Auth auth.Mode
+	// Policy is the authorization policy of the route, nil for none.
+	Policy *auth.Policy
+	// Middleware runs for this route only, after the route middleware of the server.
+	Middleware []gin.HandlerFunc
+	// Timeout cancels the request context once elapsed, and answers 503 if the handler did not answer. Zero for none.
+	Timeout time.Duration
+	// RateLimit limits the rate of the requests, before they are authenticated. Nil for none.
+	RateLimit *RateLimit
+	// Canary sends a share of the requests to an alternate handler, nil for none.
+	Canary *Canary
+	// JSON is the decoding of the JSON request bodies bound by jsonbind.Bind, e.g. &jsonbind.Strict for a route
+	// whose payloads carry identifiers beyond the precision of float64. Nil for the one of the strictness mode.
+	// The server.json_routes of the configuration take precedence.
+	JSON *jsonbind.Options
+}
+
+// HealthCheck checks a dependency of the server for the /health endpoint.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
 }
 
 type httpRouter interface {
@@ -26,82 +66,349 @@ type httpRouter interface {
 	PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	NoRoute(handlers ...gin.HandlerFunc)
+	NoMethod(handlers ...gin.HandlerFunc)
 	Use(middleware ...gin.HandlerFunc) gin.IRoutes
-	Run(addr ...string) error
 	ServeHTTP(w http.ResponseWriter, req *http.Request)
 }
 
 // Server represents the HTTP server.
 type Server struct {
-	config *config.Server
-	router httpRouter
-	log    *logger.Logger
+	config         *config.Server
+	router         httpRouter
+	log            *logger.Logger
+	middleware     []gin.HandlerFunc
+	routeMW        []gin.HandlerFunc
+	authenticators []auth.Authenticator
+	authorizer     *auth.Authorizer
+	healthChecks   []HealthCheck
+	ready          func() bool
+	canaries       []RouteCanary
+	// routes are the registered routes, to list the methods allowed for a path and to audit the API.
+	routes  []RouteInfo
+	metrics metrics.Recorder
+	rand    func() float64
+	// accessLog writes the access log, nil for none.
+	accessLog *AccessLog
+	// handler serves the requests: the router behind the path normalization.
+	handler http.Handler
+	// srv serves the handler once started.
+	srv *http.Server
+}
+
+// Option configures optional behaviour of the Server.
+type Option func(*Server)
+
+// WithMiddleware registers middleware applied to every route, after the logger middleware.
+func WithMiddleware(middleware ...gin.HandlerFunc) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// WithRouteMiddleware registers middleware applied to every route after its authentication and authorization, for
+// middleware depending on the identity of the consumer.
+func WithRouteMiddleware(middleware ...gin.HandlerFunc) Option {
+	return func(s *Server) {
+		s.routeMW = append(s.routeMW, middleware...)
+	}
+}
+
+// WithAuthenticators sets the authenticators of the routes, applied according to RouteParam.Auth.
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithAuthorizer sets the authorizer enforcing RouteParam.Policy. By default denials are not explained to consumers.
+func WithAuthorizer(a *auth.Authorizer) Option {
+	return func(s *Server) {
+		s.authorizer = a
+	}
+}
+
+// WithHealthChecks registers checks run by the /health endpoint, which reports the server unavailable when one fails.
+func WithHealthChecks(checks ...HealthCheck) Option {
+	return func(s *Server) {
+		s.healthChecks = append(s.healthChecks, checks...)
+	}
+}
+
+// WithReadiness sets the readiness of the server reported by the /ready endpoint, e.g. lifecycle.Lifecycle.Ready, so
+// that load balancers stop sending traffic before it shuts down. By default it is always ready.
+func WithReadiness(ready func() bool) Option {
+	return func(s *Server) {
+		s.ready = ready
+	}
 }
 
 // NewServer creates a new server instance.
-func NewServer(cfg *config.Server, r httpRouter, rp []RouteParam, log *logger.Logger) *Server {
+func NewServer(cfg *config.Server, r httpRouter, rp []RouteParam, log *logger.Logger, opts ...Option) *Server {
 	server := &Server{
-		config: cfg,
-		router: r,
-		log:    log,
+		config:     cfg,
+		router:     r,
+		log:        log,
+		authorizer: auth.NewAuthorizer(log, false),
+		ready:      func() bool { return true },
+		metrics:    metrics.Nop(),
+		rand:       rand.Float64, //nolint:gosec // sampling canaries does not need a secure source
+	}
+
+	// Requests for a path registered under other methods are answered 405 by the NoMethod handler.
+	if e, ok := r.(*gin.Engine); ok {
+		e.HandleMethodNotAllowed = true
+	}
+
+	for _, opt := range opts {
+		opt(server)
 	}
+
 	server.registerMiddleware()
 	server.registerRoutes(rp)
 
+	server.handler = server.normalizePaths(r)
+	server.srv = &http.Server{Addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), Handler: server.handler, ReadHeaderTimeout: readHeaderTimeout}
+
 	return server
 }
 
-// Start starts the HTTP server.
+// Start serves until the server is shut down.
 func (s *Server) Start() error {
-	err := s.router.Run(fmt.Sprintf("%s:%d", s.config.Host, s.config.Port))
-	if err != nil {
+	if err := s.srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 
 	return nil
 }
 
+// Shutdown stops accepting connections and waits for the requests in flight until ctx is done, after which their
+// connections are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.srv.Close()
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	return nil
+}
+
+// ServeUntil binds the configured address and serves until fn returns, then shuts the server down and returns the
+// error of fn. fn gets the base URL of the server, on the loopback address when bound to all interfaces.
+func (s *Server) ServeUntil(ctx context.Context, fn func(ctx context.Context, baseURL string) error) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.config.Host, s.config.Port))
+	if err != nil {
+		return fmt.Errorf("failed to bind server: %w", err)
+	}
+
+	srv := &http.Server{Handler: s.handler, ReadHeaderTimeout: readHeaderTimeout}
+	served := make(chan error, 1)
+
+	go func() {
+		served <- srv.Serve(ln)
+	}()
+
+	addr, _ := ln.Addr().(*net.TCPAddr)
+	if addr.IP.IsUnspecified() {
+		addr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: addr.Port}
+	}
+
+	fnErr := fn(ctx, "http://"+addr.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		return errors.Join(fnErr, fmt.Errorf("failed to shut down server: %w", err))
+	}
+
+	if err = <-served; !errors.Is(err, http.ErrServerClosed) {
+		return errors.Join(fnErr, fmt.Errorf("failed to serve: %w", err))
+	}
+
+	return fnErr
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, r)
 }
 
 func (s *Server) registerRoutes(rp []RouteParam) {
-	s.router.GET("/", func(c *gin.Context) {
-		c.String(http.StatusOK, "ok")
-	})
+	s.handle(RouteInfo{Method: http.MethodGet, Path: "/", Auth: RouteAuthNone}, root)
+	s.handle(RouteInfo{Method: http.MethodGet, Path: "/health", Auth: RouteAuthNone}, s.health)
+	s.handle(RouteInfo{Method: http.MethodGet, Path: "/ready", Auth: RouteAuthNone}, s.readiness)
+
+	found := make([]bool, len(s.canaries))
+	foundJSON := make([]bool, len(s.config.JSONRoutes))
 
 	for _, r := range rp {
-		switch r.Method {
-		case http.MethodGet:
-			s.router.GET(r.Path, r.Handler)
-		case http.MethodPost:
-			s.router.POST(r.Path, r.Handler)
-		case http.MethodPut:
-			s.router.PUT(r.Path, r.Handler)
-		case http.MethodDelete:
-			s.router.DELETE(r.Path, r.Handler)
+		canary := s.canary(r, found)
+		s.handle(routeInfo(r, canary), s.routeHandlers(r, canary, s.jsonOptions(r, foundJSON))...)
+	}
+
+	for i, rc := range s.canaries {
+		if !found[i] {
+			s.log.Warn("Canary route not found", zap.String("method", rc.Method), zap.String("path", rc.Path))
+		}
+	}
+
+	for i, jr := range s.config.JSONRoutes {
+		if !foundJSON[i] {
+			s.log.Warn("JSON route not found", zap.String("method", jr.Method), zap.String("path", jr.Path))
 		}
 	}
 
 	s.router.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Not Found"})
 	})
+	s.router.NoMethod(s.methodNotAllowed)
+}
 
-	// Register middlewares
-	s.router.Use(s.LoggerMiddleware())
+// handle registers the route of info, served by handlers, the last of which is the handler of the route unless
+// info names it.
+func (s *Server) handle(info RouteInfo, handlers ...gin.HandlerFunc) {
+	switch info.Method {
+	case http.MethodGet:
+		s.router.GET(info.Path, handlers...)
+	case http.MethodPost:
+		s.router.POST(info.Path, handlers...)
+	case http.MethodPut:
+		s.router.PUT(info.Path, handlers...)
+	case http.MethodDelete:
+		s.router.DELETE(info.Path, handlers...)
+	default:
+		return
+	}
+
+	info.Middleware = []string{funcName(s.LoggerMiddleware())}
+	for _, h := range s.middleware {
+		info.Middleware = append(info.Middleware, funcName(h))
+	}
+
+	for _, h := range handlers[:len(handlers)-1] {
+		info.Middleware = append(info.Middleware, funcName(h))
+	}
+
+	if info.Handler == "" {
+		info.Handler = funcName(handlers[len(handlers)-1])
+	}
+
+	s.routes = append(s.routes, info)
+}
+
+// methodNotAllowed answers requests for a path registered under other methods with 405 Method Not Allowed, listing
+// these methods in the Allow header.
+func (s *Server) methodNotAllowed(c *gin.Context) {
+	var allowed []string
+
+	for _, r := range s.routes {
+		if matchPath(r.Path, c.Request.URL.Path, sameSegment) && !slices.Contains(allowed, r.Method) {
+			allowed = append(allowed, r.Method)
+		}
+	}
+
+	slices.Sort(allowed)
+
+	c.Header("Allow", strings.Join(allowed, ", "))
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "method " + c.Request.Method + " not allowed"})
+}
+
+// matchPath reports whether path matches the route pattern, with :name parameters matching a segment and a *name
+// wildcard the rest of the path. Static segments are compared with equal.
+func matchPath(pattern, path string, equal func(a, b string) bool) bool {
+	patternSegs := strings.Split(pattern, "/")
+	pathSegs := strings.Split(path, "/")
+
+	for i, seg := range patternSegs {
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			return true
+		case i >= len(pathSegs):
+			return false
+		case strings.HasPrefix(seg, ":"):
+			if pathSegs[i] == "" {
+				return false
+			}
+		case !equal(seg, pathSegs[i]):
+			return false
+		}
+	}
+
+	return len(patternSegs) == len(pathSegs)
+}
+
+func sameSegment(a, b string) bool {
+	return a == b
+}
+
+func root(c *gin.Context) {
+	c.String(http.StatusOK, "ok")
+}
+
+// readiness responds 503 Service Unavailable once the server is shutting down.
+func (s *Server) readiness(c *gin.Context) {
+	if !s.ready() {
+		c.String(http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+
+	c.String(http.StatusOK, "ok")
+}
+
+// health runs the health checks concurrently, responding 503 Service Unavailable when one fails, with the status of
+// every check. The errors are logged, not returned, as they may reveal the infrastructure.
+func (s *Server) health(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthTimeout)
+	defer cancel()
+
+	errs := make([]error, len(s.healthChecks))
+
+	var wg sync.WaitGroup
+
+	for i, hc := range s.healthChecks {
+		wg.Add(1)
+
+		go func(i int, hc HealthCheck) {
+			defer wg.Done()
+
+			errs[i] = hc.Check(ctx)
+		}(i, hc)
+	}
+
+	wg.Wait()
+
+	status := http.StatusOK
+	checks := make(map[string]string, len(s.healthChecks))
+
+	for i, hc := range s.healthChecks {
+		checks[hc.Name] = "ok"
+
+		if errs[i] != nil {
+			status = http.StatusServiceUnavailable
+			checks[hc.Name] = "error"
+
+			s.log.Warn("Health check failed", zap.String("check", hc.Name), zap.Error(errs[i]))
+		}
+	}
+
+	c.JSON(status, gin.H{"status": http.StatusText(status), "checks": checks})
 }
 
 func (s *Server) registerMiddleware() {
 	s.router.Use(s.LoggerMiddleware())
+	s.router.Use(s.middleware...)
 }
 
-// LoggerMiddleware instances a Logger middleware for Gin.
+// LoggerMiddleware instances a Logger middleware for Gin. It logs one canonical line per request, including the
+// fields added to the request's logger.Event by handlers and clients, and writes the access log if any.
 func (s *Server) LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		raw := c.Request.URL.RawQuery
 
+		event := logger.NewEvent()
+		c.Request = c.Request.WithContext(logger.ContextWithEvent(c.Request.Context(), event))
+
 		c.Next()
 
 		end := time.Now()
@@ -113,6 +420,13 @@ func (s *Server) LoggerMiddleware() gin.HandlerFunc {
 			path = fmt.Sprintf("%s?%s", path, raw)
 		}
 
-		s.log.Debug("http request", zap.String("method", method), zap.String("path", path), zap.Int("status", statusCode), zap.Duration("latency", latency))
+		fields := []zap.Field{zap.String("method", method), zap.String("path", path), zap.Int("status", statusCode), zap.Duration("latency", latency)}
+		s.log.Debug("http request", append(fields, event.Fields()...)...)
+
+		if s.accessLog != nil {
+			if err := s.accessLog.Write(accessEntry(c, start, path, latency)); err != nil {
+				s.log.Warn("Failed to write access log", zap.Error(err))
+			}
+		}
 	}
 }
@@ -2,12 +2,17 @@ package server_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
 	"github.com/twk/skeleton-go-api/internal/config"
 	"github.com/twk/skeleton-go-api/internal/logger"
 	"github.com/twk/skeleton-go-api/internal/server"
@@ -70,3 +75,295 @@ func TestLoggerMiddleware(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
+
+func TestLoggerMiddleware_CanonicalEvent(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := &logger.Logger{Logger: zap.New(core)}
+	rp := []server.RouteParam{
+		{Method: http.MethodGet, Path: "/event", Handler: func(c *gin.Context) {
+			logger.EventFromContext(c.Request.Context()).Add(zap.String("photo", "1"))
+			c.Status(http.StatusNoContent)
+		}},
+	}
+	s := server.NewServer(&config.Server{Port: 8080}, gin.New(), rp, l)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/event", http.NoBody)
+	assert.NoError(t, err)
+
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("http request").AllUntimed()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "1", entries[0].ContextMap()["photo"])
+	assert.Equal(t, int64(http.StatusNoContent), entries[0].ContextMap()["status"])
+}
+
+func TestWithMiddleware(t *testing.T) {
+	l := logger.NewNop()
+	router := gin.New()
+	s := server.NewServer(&config.Server{Port: 8080}, router, []server.RouteParam{}, l, server.WithMiddleware(func(c *gin.Context) {
+		c.Header("X-Middleware", "applied")
+	}))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", http.NoBody)
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	s.ServeHTTP(resp, req)
+
+	assert.Equal(t, "applied", resp.Header().Get("X-Middleware"))
+}
+
+type headerAuthenticator struct{}
+
+func (headerAuthenticator) Authenticate(r *http.Request) (*auth.Identity, error) {
+	if u := r.Header.Get("X-User"); u != "" {
+		return &auth.Identity{Subject: u}, nil
+	}
+
+	return nil, auth.ErrNoCredentials
+}
+
+func TestRouteAuth(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		path string
+		user string
+	}
+
+	type want struct {
+		status int
+		body   string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"required anonymous":    {args: args{path: "/required"}, want: want{status: http.StatusUnauthorized, body: `{"error":"unauthorized"}`}},
+		"required identified":   {args: args{path: "/required", user: "alice"}, want: want{status: http.StatusOK, body: "hello alice"}},
+		"optional anonymous":    {args: args{path: "/optional"}, want: want{status: http.StatusOK, body: "hello anonymous"}},
+		"optional identified":   {args: args{path: "/optional", user: "alice"}, want: want{status: http.StatusOK, body: "hello alice"}},
+		"none ignores identity": {args: args{path: "/none", user: "alice"}, want: want{status: http.StatusOK, body: "hello anonymous"}},
+	}
+
+	hello := func(c *gin.Context) {
+		name := "anonymous"
+		if id := auth.IdentityFromContext(c.Request.Context()); id != nil {
+			name = id.Subject
+		}
+
+		c.String(http.StatusOK, "hello "+name)
+	}
+	rp := []server.RouteParam{
+		{Method: http.MethodGet, Path: "/required", Handler: hello, Auth: auth.ModeRequired},
+		{Method: http.MethodGet, Path: "/optional", Handler: hello},
+		{Method: http.MethodGet, Path: "/none", Handler: hello, Auth: auth.ModeNone},
+	}
+	s := server.NewServer(&config.Server{Port: 8080}, gin.New(), rp, logger.NewNop(), server.WithAuthenticators(headerAuthenticator{}))
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.args.path, http.NoBody)
+			if tt.args.user != "" {
+				req.Header.Set("X-User", tt.args.user)
+			}
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.status, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
+
+func TestServer_ServeUntil(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(&config.Server{Host: "127.0.0.1"}, gin.New(), []server.RouteParam{}, logger.NewNop())
+
+	err := s.ServeUntil(context.Background(), func(ctx context.Context, baseURL string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/", http.NoBody)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestServer_Health(t *testing.T) {
+	t.Parallel()
+
+	ok := server.HealthCheck{Name: "ok", Check: func(context.Context) error { return nil }}
+	failing := server.HealthCheck{Name: "failing", Check: func(context.Context) error { return errors.New("connection refused") }}
+
+	tests := map[string]struct {
+		checks     []server.HealthCheck
+		wantStatus int
+		wantBody   string
+	}{
+		"NoChecks": {wantStatus: http.StatusOK, wantBody: `{"status":"OK","checks":{}}`},
+		"Healthy":  {checks: []server.HealthCheck{ok}, wantStatus: http.StatusOK, wantBody: `{"status":"OK","checks":{"ok":"ok"}}`},
+		"Failing": {
+			checks:     []server.HealthCheck{ok, failing},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"Service Unavailable","checks":{"ok":"ok","failing":"error"}}`,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := server.NewServer(&config.Server{Port: 8080}, gin.New(), nil, logger.NewNop(), server.WithHealthChecks(tt.checks...))
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/health", http.NoBody)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+			assert.JSONEq(t, tt.wantBody, resp.Body.String())
+		})
+	}
+}
+
+func TestServer_Ready(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		opts       []server.Option
+		wantStatus int
+	}{
+		"Default":  {wantStatus: http.StatusOK},
+		"Ready":    {opts: []server.Option{server.WithReadiness(func() bool { return true })}, wantStatus: http.StatusOK},
+		"Draining": {opts: []server.Option{server.WithReadiness(func() bool { return false })}, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := server.NewServer(&config.Server{Port: 8080}, gin.New(), nil, logger.NewNop(), tt.opts...)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", http.NoBody)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+		})
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(&config.Server{Host: "127.0.0.1", Port: 0}, gin.New(), nil, logger.NewNop())
+	started := make(chan error, 1)
+
+	go func() {
+		started <- s.Start()
+	}()
+
+	// Shutdown before or while serving makes Start return without error.
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.NoError(t, <-started)
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	s := server.NewServer(&config.Server{}, gin.New(), []server.RouteParam{
+		{Method: http.MethodGet, Path: "/photos/:id", Handler: ok},
+		{Method: http.MethodPut, Path: "/photos/:id", Handler: ok},
+		{Method: http.MethodDelete, Path: "/photos/:id", Handler: ok},
+		{Method: http.MethodPost, Path: "/photos", Handler: ok},
+		{Method: http.MethodGet, Path: "/files/*path", Handler: ok},
+	}, logger.NewNop())
+
+	type want struct {
+		status int
+		allow  string
+		body   string
+	}
+
+	tests := map[string]struct {
+		method string
+		path   string
+		want   want
+	}{
+		"other methods of a route": {
+			method: http.MethodPost,
+			path:   "/photos/1",
+			want:   want{status: http.StatusMethodNotAllowed, allow: "DELETE, GET, PUT", body: `{"error":"method POST not allowed"}`},
+		},
+		"static route": {
+			method: http.MethodGet,
+			path:   "/photos",
+			want:   want{status: http.StatusMethodNotAllowed, allow: "POST", body: `{"error":"method GET not allowed"}`},
+		},
+		"built-in route": {
+			method: http.MethodPatch,
+			path:   "/health",
+			want:   want{status: http.StatusMethodNotAllowed, allow: "GET", body: `{"error":"method PATCH not allowed"}`},
+		},
+		"wildcard route": {
+			method: http.MethodDelete,
+			path:   "/files/a/b",
+			want:   want{status: http.StatusMethodNotAllowed, allow: "GET", body: `{"error":"method DELETE not allowed"}`},
+		},
+		"allowed method": {
+			method: http.MethodPut,
+			path:   "/photos/1",
+			want:   want{status: http.StatusOK},
+		},
+		"unknown path": {
+			method: http.MethodPost,
+			path:   "/albums/1",
+			want:   want{status: http.StatusNotFound, body: `{"message":"Not Found"}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := httptest.NewRecorder()
+			s.ServeHTTP(resp, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+
+			assert.Equal(t, tt.want.status, resp.Code)
+			assert.Equal(t, tt.want.allow, resp.Header().Get("Allow"))
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package session
+
+import "time"
+
+// SetClock overrides the clock used for the timeouts of the sessions.
+func SetClock(m *Manager, now func() time.Time) {
+	m.now = now
+}
@@ -0,0 +1,286 @@
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/secret"
+)
+
+// Defaults of the remember-me configuration.
+const (
+	DefaultRememberCookieName = "remember_me"
+	DefaultRememberTTL        = 30 * 24 * time.Hour
+)
+
+// rotationGrace is how long the previous token of a family stays accepted after a rotation, for the concurrent
+// requests the user agent sent before it got the new token.
+const rotationGrace = 10 * time.Second
+
+// errInvalidRememberToken is returned for remember-me cookies which cannot be decrypted.
+var errInvalidRememberToken = errors.New("invalid remember-me token")
+
+// Family is the server-side state of the remember-me tokens of one login. Every use of the token exchanges it for a
+// new one, so a token used twice was stolen: the whole family is then revoked, logging out both the user and the
+// thief.
+type Family struct {
+	ID       string         `json:"id"`
+	Identity *auth.Identity `json:"identity"`
+	// Token is the only token of the family which logs the user in.
+	Token string `json:"token"`
+	// Previous is the token replaced by Token, still accepted for a grace period after RotatedAt.
+	Previous  string    `json:"previous,omitempty"`
+	RotatedAt time.Time `json:"rotated_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (f *Family) clone() Family {
+	c := *f
+
+	if f.Identity != nil {
+		id := *f.Identity
+		c.Identity = &id
+	}
+
+	return c
+}
+
+// FamilyStore persists remember-me families by ID. Families expire from the store after the ttl they were saved with.
+type FamilyStore interface {
+	GetFamily(ctx context.Context, id string) (*Family, error)
+	SaveFamily(ctx context.Context, f *Family, ttl time.Duration) error
+	DeleteFamily(ctx context.Context, id string) error
+}
+
+// remember issues and redeems the remember-me cookies, encrypted with AES-GCM so they disclose nothing.
+type remember struct {
+	store      FamilyStore
+	aead       cipher.AEAD
+	cookieName string
+	ttl        time.Duration
+}
+
+func newRemember(cfg *config.RememberMe, store Store) (*remember, error) {
+	if err := secret.CheckKey(cfg.Key); err != nil {
+		return nil, fmt.Errorf("invalid remember-me key: %w", err)
+	}
+
+	fs, ok := store.(FamilyStore)
+	if !ok {
+		return nil, errors.New("the session store does not support remember-me tokens")
+	}
+
+	key := sha256.Sum256([]byte(cfg.Key))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remember-me cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remember-me cipher: %w", err)
+	}
+
+	r := &remember{store: fs, aead: aead, cookieName: cfg.CookieName, ttl: cfg.TTL}
+
+	if r.cookieName == "" {
+		r.cookieName = DefaultRememberCookieName
+	}
+
+	if r.ttl <= 0 {
+		r.ttl = DefaultRememberTTL
+	}
+
+	return r, nil
+}
+
+// seal returns the cookie value of token of family.
+func (r *remember) seal(family, token string) (string, error) {
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := r.aead.Seal(nonce, nonce, []byte(family+"."+token), []byte(r.cookieName))
+
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open returns the family and token of a cookie value.
+func (r *remember) open(value string) (family, token string, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) < r.aead.NonceSize() {
+		return "", "", errInvalidRememberToken
+	}
+
+	plain, err := r.aead.Open(nil, b[:r.aead.NonceSize()], b[r.aead.NonceSize():], []byte(r.cookieName))
+	if err != nil {
+		return "", "", errInvalidRememberToken
+	}
+
+	family, token, ok := strings.Cut(string(plain), ".")
+	if !ok {
+		return "", "", errInvalidRememberToken
+	}
+
+	return family, token, nil
+}
+
+// Remember issues a remember-me token for the user logged in the Session of the request, which logs them back in
+// once the session expired, until the remember-me lifetime is over. Call it after Login when the user asked to be
+// remembered.
+func (m *Manager) Remember(c *gin.Context) error {
+	if m.remember == nil {
+		return errors.New("remember-me tokens are disabled")
+	}
+
+	s := FromContext(c.Request.Context())
+	if s == nil || s.Identity == nil {
+		return errors.New("no user logged in to remember")
+	}
+
+	familyID, err := randomID()
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	f := &Family{ID: familyID, Identity: s.Identity, CreatedAt: now}
+
+	return m.rotateToken(c, f)
+}
+
+// restore logs the user of a valid remember-me cookie in with a new Session, rotating the token. A token already
+// rotated is a stolen one, unless it is used within the grace period, and revokes its family.
+func (m *Manager) restore(c *gin.Context) {
+	r := m.remember
+
+	value, err := c.Cookie(r.cookieName)
+	if err != nil || value == "" {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	familyID, token, err := r.open(value)
+	if err != nil {
+		m.clearRememberCookie(c)
+		return
+	}
+
+	f, err := r.store.GetFamily(ctx, familyID)
+	if errors.Is(err, ErrNotFound) {
+		m.clearRememberCookie(c)
+		return
+	}
+
+	if err != nil {
+		m.log.Error("Failed to load remember-me family", zap.Error(err))
+		return
+	}
+
+	switch {
+	case subtle.ConstantTimeCompare([]byte(token), []byte(f.Token)) == 1:
+		if err = m.rotateToken(c, f); err != nil {
+			m.log.Error("Failed to rotate remember-me token", zap.Error(err))
+			return
+		}
+	case f.Previous != "" && subtle.ConstantTimeCompare([]byte(token), []byte(f.Previous)) == 1 && m.now().Sub(f.RotatedAt) < rotationGrace:
+	default:
+		m.log.Warn("Remember-me token reused, revoking its family", zap.String("subject", f.Identity.Subject), zap.String("family", f.ID))
+
+		if err = r.store.DeleteFamily(ctx, f.ID); err != nil {
+			m.log.Error("Failed to revoke remember-me family", zap.Error(err))
+		}
+
+		m.clearRememberCookie(c)
+
+		return
+	}
+
+	if _, err = m.Login(c, f.Identity); err != nil {
+		m.log.Error("Failed to restore remembered session", zap.Error(err))
+	}
+}
+
+// rotateToken replaces the token of f with a new one, saves f and sets the cookie of the new token.
+func (m *Manager) rotateToken(c *gin.Context, f *Family) error {
+	token, err := randomID()
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	if f.Token != "" {
+		f.Previous, f.RotatedAt = f.Token, now
+	}
+
+	f.Token = token
+
+	ttl := f.CreatedAt.Add(m.remember.ttl).Sub(now)
+	if err = m.remember.store.SaveFamily(c.Request.Context(), f, ttl); err != nil {
+		return fmt.Errorf("failed to save remember-me family: %w", err)
+	}
+
+	value, err := m.remember.seal(f.ID, token)
+	if err != nil {
+		return err
+	}
+
+	m.setRememberCookie(c, value, int(ttl.Seconds()))
+
+	return nil
+}
+
+// forget revokes the family of the remember-me cookie of the request, if any, and removes the cookie.
+func (m *Manager) forget(c *gin.Context) error {
+	if m.remember == nil {
+		return nil
+	}
+
+	value, err := c.Cookie(m.remember.cookieName)
+	if err != nil || value == "" {
+		return nil
+	}
+
+	m.clearRememberCookie(c)
+
+	familyID, _, err := m.remember.open(value)
+	if err != nil {
+		return nil
+	}
+
+	return m.remember.store.DeleteFamily(c.Request.Context(), familyID) //nolint:wrapcheck // errors of the stores are already wrapped
+}
+
+func (m *Manager) clearRememberCookie(c *gin.Context) {
+	m.setRememberCookie(c, "", -1)
+}
+
+func (m *Manager) setRememberCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     m.remember.cookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   m.secure,
+		SameSite: m.sameSite,
+	})
+}
@@ -0,0 +1,146 @@
+package session_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/session"
+)
+
+// newRememberRouter serves the routes of newRouter and /remember issuing a remember-me token.
+func newRememberRouter(t *testing.T, clk *clock) *gin.Engine {
+	t.Helper()
+
+	cfg := &config.Session{IdleTimeout: 10 * time.Minute, AbsoluteTimeout: time.Hour, RememberMe: config.RememberMe{Key: "secret", TTL: 24 * time.Hour}}
+
+	m, err := session.New(cfg, session.NewMemoryStore(), logger.NewNop())
+	assert.NoError(t, err)
+
+	session.SetClock(m, clk.Now)
+
+	router := newRouter(t, m)
+	router.POST("/remember", func(c *gin.Context) {
+		if err := m.Remember(c); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+
+	return router
+}
+
+// serveCookies sends a request with cookies and returns the response and the cookies it sets by name.
+func serveCookies(router http.Handler, method, target string, cookies ...*http.Cookie) (*httptest.ResponseRecorder, map[string]*http.Cookie) {
+	req := httptest.NewRequest(method, target, http.NoBody)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	set := map[string]*http.Cookie{}
+	for _, c := range resp.Result().Cookies() {
+		set[c.Name] = c
+	}
+
+	return resp, set
+}
+
+func TestManager_RememberMe(t *testing.T) {
+	t.Parallel()
+
+	clk := &clock{now: time.Now()}
+	router := newRememberRouter(t, clk)
+
+	_, set := serveCookies(router, http.MethodPost, "/login?user=alice")
+	loggedIn := set[session.DefaultCookieName]
+
+	if !assert.NotNil(t, loggedIn) {
+		return
+	}
+
+	_, set = serveCookies(router, http.MethodPost, "/remember", loggedIn)
+	first := set[session.DefaultRememberCookieName]
+
+	if !assert.NotNil(t, first) {
+		return
+	}
+
+	assert.True(t, first.HttpOnly)
+	assert.Equal(t, int((24 * time.Hour).Seconds()), first.MaxAge)
+
+	clk.Advance(15 * time.Minute)
+
+	resp, _ := serveCookies(router, http.MethodGet, "/me", loggedIn)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "the session expired")
+
+	resp, set = serveCookies(router, http.MethodGet, "/me", loggedIn, first)
+	assert.Equal(t, http.StatusOK, resp.Code, "the token logs the user back in")
+	assert.Equal(t, "alice session", resp.Body.String())
+	assert.NotNil(t, set[session.DefaultCookieName], "with a new session")
+
+	second := set[session.DefaultRememberCookieName]
+	if !assert.NotNil(t, second) {
+		return
+	}
+
+	assert.NotEqual(t, first.Value, second.Value, "the token is rotated on use")
+
+	resp, _ = serveCookies(router, http.MethodGet, "/me", first)
+	assert.Equal(t, http.StatusOK, resp.Code, "the previous token is accepted during the grace period")
+
+	clk.Advance(time.Minute)
+
+	resp, set = serveCookies(router, http.MethodGet, "/me", first)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "the reused token is rejected")
+
+	if cleared := set[session.DefaultRememberCookieName]; assert.NotNil(t, cleared) {
+		assert.Negative(t, cleared.MaxAge)
+	}
+
+	resp, _ = serveCookies(router, http.MethodGet, "/me", second)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "the family of the reused token is revoked")
+}
+
+func TestManager_RememberMeLogout(t *testing.T) {
+	t.Parallel()
+
+	router := newRememberRouter(t, &clock{now: time.Now()})
+
+	_, set := serveCookies(router, http.MethodPost, "/login?user=alice")
+	loggedIn := set[session.DefaultCookieName]
+
+	_, set = serveCookies(router, http.MethodPost, "/remember", loggedIn)
+	remembered := set[session.DefaultRememberCookieName]
+
+	if !assert.NotNil(t, remembered) {
+		return
+	}
+
+	_, set = serveCookies(router, http.MethodPost, "/logout", loggedIn, remembered)
+	if cleared := set[session.DefaultRememberCookieName]; assert.NotNil(t, cleared) {
+		assert.Negative(t, cleared.MaxAge)
+	}
+
+	resp, _ := serveCookies(router, http.MethodGet, "/me", remembered)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "logout revokes the token")
+}
+
+func TestManager_RememberMeTampered(t *testing.T) {
+	t.Parallel()
+
+	router := newRememberRouter(t, &clock{now: time.Now()})
+
+	resp, set := serveCookies(router, http.MethodGet, "/me", &http.Cookie{Name: session.DefaultRememberCookieName, Value: "forged"})
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	if cleared := set[session.DefaultRememberCookieName]; assert.NotNil(t, cleared) {
+		assert.Negative(t, cleared.MaxAge)
+	}
+}
@@ -0,0 +1,343 @@
+// Package session maintains server-side sessions of interactive users, such as an admin UI or an OIDC login flow,
+// identified by a cookie. Sessions expire after an idle timeout, renewed by every request, and an absolute timeout
+// counted from login, and get a new ID whenever their privileges change so a fixated or leaked ID becomes useless.
+// Users asking to be remembered get a long-lived remember-me token, logging them back in once their session expired.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Defaults of the configuration.
+const (
+	DefaultCookieName      = "session"
+	DefaultIdleTimeout     = 30 * time.Minute
+	DefaultAbsoluteTimeout = 12 * time.Hour
+)
+
+// idSize is the number of random bytes of a session ID.
+const idSize = 32
+
+// MethodSession is the authentication method of identities authenticated by a session.
+const MethodSession = "session"
+
+// Session is the server-side state of a user agent.
+type Session struct {
+	ID string `json:"id"`
+	// Identity is the user logged in, nil for an anonymous session.
+	Identity *auth.Identity `json:"identity,omitempty"`
+	// Values holds application state, e.g. the state parameter of an OIDC login.
+	Values    map[string]string `json:"values,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	LastSeen  time.Time         `json:"last_seen"`
+}
+
+func (s *Session) clone() Session {
+	c := *s
+	c.Values = maps.Clone(s.Values)
+
+	if s.Identity != nil {
+		id := *s.Identity
+		c.Identity = &id
+	}
+
+	return c
+}
+
+type sessionKey struct{}
+
+func contextWithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the Session of the request carried by ctx, or nil if it has none.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// Manager loads, creates, rotates and ends sessions.
+type Manager struct {
+	store      Store
+	log        *logger.Logger
+	cookieName string
+	idle       time.Duration
+	absolute   time.Duration
+	sameSite   http.SameSite
+	secure     bool
+	// remember issues the remember-me tokens, nil when they are disabled.
+	remember *remember
+	now      func() time.Time
+}
+
+// New creates a Manager keeping the sessions in store.
+func New(cfg *config.Session, store Store, l *logger.Logger) (*Manager, error) {
+	sameSite, err := ParseSameSite(cfg.SameSite)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		store:      store,
+		log:        l,
+		cookieName: cfg.CookieName,
+		idle:       cfg.IdleTimeout,
+		absolute:   cfg.AbsoluteTimeout,
+		sameSite:   sameSite,
+		secure:     cfg.Secure,
+		now:        time.Now,
+	}
+
+	if m.cookieName == "" {
+		m.cookieName = DefaultCookieName
+	}
+
+	if m.idle <= 0 {
+		m.idle = DefaultIdleTimeout
+	}
+
+	if m.absolute <= 0 {
+		m.absolute = DefaultAbsoluteTimeout
+	}
+
+	if cfg.RememberMe.Key != "" {
+		if m.remember, err = newRemember(&cfg.RememberMe, store); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ParseSameSite returns the SameSite attribute of a cookie configured as s: "lax", "strict" or "none". Empty is lax.
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unsupported same site mode %q", s)
+	}
+}
+
+// Middleware attaches the Session of the cookie to the request context and renews its idle timeout. Unknown and
+// expired sessions are removed along with their cookie, and the request continues without a session, unless a
+// remember-me token logs the user back in with a new one.
+func (m *Manager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := m.load(c)
+		if s != nil {
+			c.Request = c.Request.WithContext(contextWithSession(c.Request.Context(), s))
+		}
+
+		if m.remember != nil && (s == nil || s.Identity == nil) {
+			m.restore(c)
+		}
+
+		c.Next()
+	}
+}
+
+func (m *Manager) load(c *gin.Context) *Session {
+	id, err := c.Cookie(m.cookieName)
+	if err != nil || id == "" {
+		return nil
+	}
+
+	ctx := c.Request.Context()
+
+	s, err := m.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		m.clearCookie(c)
+		return nil
+	}
+
+	if err != nil {
+		m.log.Error("Failed to load session", zap.Error(err))
+		return nil
+	}
+
+	now := m.now()
+	if now.Sub(s.LastSeen) >= m.idle || now.Sub(s.CreatedAt) >= m.absolute {
+		if err = m.store.Delete(ctx, s.ID); err != nil {
+			m.log.Warn("Failed to delete expired session", zap.Error(err))
+		}
+
+		m.clearCookie(c)
+
+		return nil
+	}
+
+	s.LastSeen = now
+	if err = m.store.Save(ctx, s, m.ttl(s)); err != nil {
+		m.log.Warn("Failed to renew session", zap.Error(err))
+	}
+
+	return s
+}
+
+// Start returns the Session of the request, creating an anonymous one if it has none, e.g. to keep state before
+// login.
+func (m *Manager) Start(c *gin.Context) (*Session, error) {
+	if s := FromContext(c.Request.Context()); s != nil {
+		return s, nil
+	}
+
+	now := m.now()
+
+	return m.issue(c, &Session{CreatedAt: now, LastSeen: now})
+}
+
+// Login attaches id to the Session of the request under a new session ID, keeping its values, and restarts the
+// absolute timeout. Call it once the user is authenticated.
+func (m *Manager) Login(c *gin.Context, id *auth.Identity) (*Session, error) {
+	now := m.now()
+
+	s := &Session{CreatedAt: now, LastSeen: now}
+	if old := FromContext(c.Request.Context()); old != nil {
+		s.Values = old.Values
+	}
+
+	s.Identity = id
+
+	return m.rotate(c, s)
+}
+
+// Rotate moves the Session of the request to a new session ID. Call it whenever the privileges of the user change,
+// e.g. once their roles are updated or they elevate their access.
+func (m *Manager) Rotate(c *gin.Context) (*Session, error) {
+	old := FromContext(c.Request.Context())
+	if old == nil {
+		return m.Start(c)
+	}
+
+	s := old.clone()
+	s.LastSeen = m.now()
+
+	return m.rotate(c, &s)
+}
+
+// Save persists the changes to the values of s.
+func (m *Manager) Save(ctx context.Context, s *Session) error {
+	return m.store.Save(ctx, s, m.ttl(s)) //nolint:wrapcheck // errors of the stores are already wrapped
+}
+
+// Logout ends the Session of the request and revokes its remember-me token, removing their cookies.
+func (m *Manager) Logout(c *gin.Context) error {
+	m.clearCookie(c)
+
+	if err := m.forget(c); err != nil {
+		return err
+	}
+
+	s := FromContext(c.Request.Context())
+	if s == nil {
+		return nil
+	}
+
+	c.Request = c.Request.WithContext(contextWithSession(c.Request.Context(), nil))
+
+	return m.store.Delete(c.Request.Context(), s.ID) //nolint:wrapcheck // errors of the stores are already wrapped
+}
+
+// Authenticate implements auth.Authenticator with the Identity of the Session attached by the Middleware, which must
+// run before the authentication middleware. Anonymous sessions carry no credentials.
+func (m *Manager) Authenticate(r *http.Request) (*auth.Identity, error) {
+	s := FromContext(r.Context())
+	if s == nil || s.Identity == nil {
+		return nil, auth.ErrNoCredentials
+	}
+
+	id := *s.Identity
+	id.Method = MethodSession
+
+	return &id, nil
+}
+
+// rotate issues s under a new ID and deletes the previous Session of the request.
+func (m *Manager) rotate(c *gin.Context, s *Session) (*Session, error) {
+	old := FromContext(c.Request.Context())
+
+	s, err := m.issue(c, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if old != nil {
+		if err = m.store.Delete(c.Request.Context(), old.ID); err != nil {
+			m.log.Warn("Failed to delete rotated session", zap.Error(err))
+		}
+	}
+
+	return s, nil
+}
+
+// issue saves s under a new ID, sets its cookie and attaches it to the request context.
+func (m *Manager) issue(c *gin.Context, s *Session) (*Session, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.ID = id
+
+	if err := m.Save(c.Request.Context(), s); err != nil {
+		return nil, err
+	}
+
+	m.setCookie(c, s.ID, int(m.absolute.Seconds()))
+	c.Request = c.Request.WithContext(contextWithSession(c.Request.Context(), s))
+
+	return s, nil
+}
+
+// randomID returns a random ID for a session, a remember-me family or token.
+func randomID() (string, error) {
+	b := make([]byte, idSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ttl is the time until s expires, by its idle or absolute timeout.
+func (m *Manager) ttl(s *Session) time.Duration {
+	now := m.now()
+
+	return min(s.LastSeen.Add(m.idle).Sub(now), s.CreatedAt.Add(m.absolute).Sub(now))
+}
+
+func (m *Manager) clearCookie(c *gin.Context) {
+	m.setCookie(c, "", -1)
+}
+
+func (m *Manager) setCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     m.cookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   m.secure,
+		SameSite: m.sameSite,
+	})
+}
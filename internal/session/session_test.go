@@ -0,0 +1,252 @@
+package session_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/session"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// newRouter serves /login logging in the user of the user parameter, /me returning the subject of the session,
+// /elevate rotating the session, /logout ending it and /state keeping a value in an anonymous session.
+func newRouter(t *testing.T, m *session.Manager) *gin.Engine {
+	t.Helper()
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.POST("/login", func(c *gin.Context) {
+		if _, err := m.Login(c, &auth.Identity{Subject: c.Query("user")}); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+	router.GET("/me", auth.Middleware(auth.ModeRequired, m), func(c *gin.Context) {
+		id := auth.IdentityFromContext(c.Request.Context())
+		c.String(http.StatusOK, id.Subject+" "+id.Method)
+	})
+	router.POST("/elevate", func(c *gin.Context) {
+		if _, err := m.Rotate(c); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+	router.POST("/logout", func(c *gin.Context) {
+		if err := m.Logout(c); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+	router.POST("/state", func(c *gin.Context) {
+		s, err := m.Start(c)
+		if err == nil {
+			s.Values = map[string]string{"state": c.Query("state")}
+			err = m.Save(c.Request.Context(), s)
+		}
+
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+	router.GET("/state", func(c *gin.Context) {
+		if s := session.FromContext(c.Request.Context()); s != nil {
+			c.String(http.StatusOK, s.Values["state"])
+		}
+	})
+
+	return router
+}
+
+// serve sends a request with cookie, if any, and returns the response and the session cookie it sets, if any.
+func serve(router http.Handler, method, target string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	req := httptest.NewRequest(method, target, http.NoBody)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	for _, c := range resp.Result().Cookies() {
+		if c.Name == session.DefaultCookieName {
+			return resp, c
+		}
+	}
+
+	return resp, nil
+}
+
+func newManager(t *testing.T, clk *clock) *session.Manager {
+	t.Helper()
+
+	m, err := session.New(&config.Session{IdleTimeout: 10 * time.Minute, AbsoluteTimeout: time.Hour}, session.NewMemoryStore(), logger.NewNop())
+	assert.NoError(t, err)
+
+	session.SetClock(m, clk.Now)
+
+	return m
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg  *config.Session
+		want string
+	}{
+		"invalid same site":        {cfg: &config.Session{SameSite: "sometimes"}, want: `unsupported same site mode "sometimes"`},
+		"placeholder remember key": {cfg: &config.Session{RememberMe: config.RememberMe{Key: "change-me"}}, want: `invalid remember-me key: key is the placeholder "change-me"`},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := session.New(tt.cfg, session.NewMemoryStore(), logger.NewNop())
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestManager_Login(t *testing.T) {
+	t.Parallel()
+
+	clk := &clock{now: time.Now()}
+	router := newRouter(t, newManager(t, clk))
+
+	resp, _ := serve(router, http.MethodGet, "/me", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	_, cookie := serve(router, http.MethodPost, "/login?user=alice", nil)
+	if !assert.NotNil(t, cookie) {
+		return
+	}
+
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+
+	resp, _ = serve(router, http.MethodGet, "/me", cookie)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "alice session", resp.Body.String())
+
+	_, cleared := serve(router, http.MethodPost, "/logout", cookie)
+	if assert.NotNil(t, cleared) {
+		assert.Negative(t, cleared.MaxAge)
+	}
+
+	resp, _ = serve(router, http.MethodGet, "/me", cookie)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestManager_Rotation(t *testing.T) {
+	t.Parallel()
+
+	clk := &clock{now: time.Now()}
+	router := newRouter(t, newManager(t, clk))
+
+	_, anonymous := serve(router, http.MethodPost, "/state?state=xyz", nil)
+	if !assert.NotNil(t, anonymous) {
+		return
+	}
+
+	_, loggedIn := serve(router, http.MethodPost, "/login?user=alice", anonymous)
+	if !assert.NotNil(t, loggedIn) {
+		return
+	}
+
+	assert.NotEqual(t, anonymous.Value, loggedIn.Value)
+
+	resp, _ := serve(router, http.MethodGet, "/state", loggedIn)
+	assert.Equal(t, "xyz", resp.Body.String(), "values are kept on login")
+
+	resp, _ = serve(router, http.MethodGet, "/state", anonymous)
+	assert.Empty(t, resp.Body.String(), "the session before login is gone")
+
+	_, elevated := serve(router, http.MethodPost, "/elevate", loggedIn)
+	if !assert.NotNil(t, elevated) {
+		return
+	}
+
+	assert.NotEqual(t, loggedIn.Value, elevated.Value)
+
+	resp, _ = serve(router, http.MethodGet, "/me", elevated)
+	assert.Equal(t, "alice session", resp.Body.String())
+
+	resp, _ = serve(router, http.MethodGet, "/me", loggedIn)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code, "the session before rotation is gone")
+}
+
+func TestManager_Timeouts(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		code int
+	}
+
+	tests := map[string]struct {
+		activity []time.Duration
+		want     want
+	}{
+		"active": {
+			activity: []time.Duration{5 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+			want:     want{code: http.StatusOK},
+		},
+		"idle": {
+			activity: []time.Duration{5 * time.Minute, 10 * time.Minute},
+			want:     want{code: http.StatusUnauthorized},
+		},
+		"absolute": {
+			activity: []time.Duration{9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute, 9 * time.Minute},
+			want:     want{code: http.StatusUnauthorized},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			clk := &clock{now: time.Now()}
+			router := newRouter(t, newManager(t, clk))
+
+			_, cookie := serve(router, http.MethodPost, "/login?user=alice", nil)
+			if !assert.NotNil(t, cookie) {
+				return
+			}
+
+			var resp *httptest.ResponseRecorder
+			for _, d := range tt.activity {
+				clk.Advance(d)
+				resp, _ = serve(router, http.MethodGet, "/me", cookie)
+			}
+
+			assert.Equal(t, tt.want.code, resp.Code)
+		})
+	}
+}
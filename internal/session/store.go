@@ -0,0 +1,220 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	redishelper "github.com/twk/skeleton-go-api/internal/redis"
+)
+
+// ErrNotFound is returned by a Store for unknown or expired sessions.
+var ErrNotFound = errors.New("session not found")
+
+// Store persists sessions by ID. Sessions expire from the store after the ttl they were saved with.
+type Store interface {
+	Get(ctx context.Context, id string) (*Session, error)
+	Save(ctx context.Context, s *Session, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store keeping sessions in memory. Sessions are lost on restart and not shared between instances,
+// use a RedisStore when running several.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	families map[string]memoryFamily
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	session Session
+	expires time.Time
+}
+
+type memoryFamily struct {
+	family  Family
+	expires time.Time
+}
+
+// NewMemoryStore creates a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]memoryEntry{}, families: map[string]memoryFamily{}, now: time.Now}
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if !m.now().Before(e.expires) {
+		delete(m.sessions, id)
+		return nil, ErrNotFound
+	}
+
+	s := e.session.clone()
+
+	return &s, nil
+}
+
+// Save implements Store.
+func (m *MemoryStore) Save(_ context.Context, s *Session, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	// Expired sessions are removed as new ones are saved, so abandoned sessions do not accumulate.
+	for id, e := range m.sessions {
+		if !now.Before(e.expires) {
+			delete(m.sessions, id)
+		}
+	}
+
+	m.sessions[s.ID] = memoryEntry{session: s.clone(), expires: now.Add(ttl)}
+
+	return nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, id)
+
+	return nil
+}
+
+// GetFamily implements FamilyStore.
+func (m *MemoryStore) GetFamily(_ context.Context, id string) (*Family, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.families[id]
+	if !ok || !m.now().Before(e.expires) {
+		delete(m.families, id)
+		return nil, ErrNotFound
+	}
+
+	f := e.family.clone()
+
+	return &f, nil
+}
+
+// SaveFamily implements FamilyStore.
+func (m *MemoryStore) SaveFamily(_ context.Context, f *Family, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	for id, e := range m.families {
+		if !now.Before(e.expires) {
+			delete(m.families, id)
+		}
+	}
+
+	m.families[f.ID] = memoryFamily{family: f.clone(), expires: now.Add(ttl)}
+
+	return nil
+}
+
+// DeleteFamily implements FamilyStore.
+func (m *MemoryStore) DeleteFamily(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.families, id)
+
+	return nil
+}
+
+// Key prefixes namespacing the sessions and remember-me families in Redis.
+const (
+	redisKeyPrefix       = "session:"
+	redisFamilyKeyPrefix = "remember:"
+)
+
+// RedisStore is a Store keeping sessions in Redis as JSON, so they are shared between instances and survive restarts.
+type RedisStore struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisStore creates a RedisStore.
+func NewRedisStore(rdb redis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+// Get implements Store.
+func (r *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	s, ok, err := redishelper.GetJSON[Session](ctx, r.rdb, redisKeyPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return s, nil
+}
+
+// Save implements Store.
+func (r *RedisStore) Save(ctx context.Context, s *Session, ttl time.Duration) error {
+	if err := redishelper.SetJSON(ctx, r.rdb, redisKeyPrefix+s.ID, s, ttl); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implements Store.
+func (r *RedisStore) Delete(ctx context.Context, id string) error {
+	if err := r.rdb.Del(ctx, redisKeyPrefix+id).Err(); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// GetFamily implements FamilyStore.
+func (r *RedisStore) GetFamily(ctx context.Context, id string) (*Family, error) {
+	f, ok, err := redishelper.GetJSON[Family](ctx, r.rdb, redisFamilyKeyPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remember-me family: %w", err)
+	}
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return f, nil
+}
+
+// SaveFamily implements FamilyStore.
+func (r *RedisStore) SaveFamily(ctx context.Context, f *Family, ttl time.Duration) error {
+	if err := redishelper.SetJSON(ctx, r.rdb, redisFamilyKeyPrefix+f.ID, f, ttl); err != nil {
+		return fmt.Errorf("failed to save remember-me family: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFamily implements FamilyStore.
+func (r *RedisStore) DeleteFamily(ctx context.Context, id string) error {
+	if err := r.rdb.Del(ctx, redisFamilyKeyPrefix+id).Err(); err != nil {
+		return fmt.Errorf("failed to delete remember-me family: %w", err)
+	}
+
+	return nil
+}
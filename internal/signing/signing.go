@@ -0,0 +1,180 @@
+// Package signing signs the requests between services with HMAC and verifies them, so that a service authenticates
+// its calls with a shared key instead of obtaining OAuth tokens. A signature covers the method and the path of the
+// request, its Date, the Digest of its body and any other headers listed, e.g.
+//
+//	Signature: keyId="billing",algorithm="hmac-sha256",headers="(request-target) date digest",signature="..."
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Algorithm is the only algorithm of the signatures.
+const Algorithm = "hmac-sha256"
+
+// Headers of the signed requests.
+const (
+	SignatureHeader = "Signature"
+	DigestHeader    = "Digest"
+	DateHeader      = "Date"
+)
+
+// RequestTarget is the pseudo header of the signed method and path, as in (request-target) of the headers of a
+// signature.
+const RequestTarget = "(request-target)"
+
+// digestPrefix prefixes the SHA-256 digests of the bodies.
+const digestPrefix = "SHA-256="
+
+var (
+	errMissingHeader = errors.New("signed header missing")
+	errBodyTooLarge  = errors.New("body exceeds")
+)
+
+// Signer signs requests with a key.
+type Signer struct {
+	keyID   string
+	secret  []byte
+	headers []string
+}
+
+// NewSigner creates a Signer with the key keyID of secret, whose signatures also cover headers, e.g. X-Request-ID.
+func NewSigner(keyID string, secret []byte, headers ...string) *Signer {
+	return &Signer{keyID: keyID, secret: secret, headers: signedHeaders(headers)}
+}
+
+// Sign sets the Date, unless already set, the Digest and the Signature headers of req. The body is read and
+// replaced, so the request is sent as usual.
+func (s *Signer) Sign(req *http.Request) error {
+	if req.Header.Get(DateHeader) == "" {
+		req.Header.Set(DateHeader, time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	b, err := readBody(req, 0)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(DigestHeader, digest(b))
+
+	payload, err := signingString(req, s.headers)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(SignatureHeader, fmt.Sprintf(`keyId=%q,algorithm=%q,headers=%q,signature=%q`,
+		s.keyID, Algorithm, strings.Join(s.headers, " "), sign(s.secret, payload)))
+
+	return nil
+}
+
+// Transport is an http.RoundTripper signing the requests with a Signer.
+type Transport struct {
+	signer *Signer
+	next   http.RoundTripper
+}
+
+// NewTransport creates a Transport signing with s and delegating to next.
+func NewTransport(s *Signer, next http.RoundTripper) *Transport {
+	return &Transport{signer: s, next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request of its caller.
+	req = req.Clone(req.Context())
+
+	if err := t.signer.Sign(req); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	return t.next.RoundTrip(req) //nolint:wrapcheck // the transport is transparent
+}
+
+// readBody returns the body of req, reading and replacing it. A positive limit fails with errBodyTooLarge for a
+// larger body, without reading more than limit+1 bytes of it.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body := req.Body
+	defer body.Close()
+
+	var r io.Reader = body
+	if limit > 0 {
+		r = io.LimitReader(body, limit+1)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	if limit > 0 && int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w %d bytes", errBodyTooLarge, limit)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(b))
+
+	return b, nil
+}
+
+// digest returns the Digest header of body b.
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+
+	return digestPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// signedHeaders returns the headers covered by a signature: the request target, the date, the digest and headers.
+func signedHeaders(headers []string) []string {
+	return append([]string{RequestTarget, "date", "digest"}, lower(headers)...)
+}
+
+// signingString returns the string signed for the headers of req, a line "name: value" per header.
+func signingString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, 0, len(headers))
+
+	for _, h := range headers {
+		if h == RequestTarget {
+			lines = append(lines, fmt.Sprintf("%s: %s %s", RequestTarget, strings.ToLower(req.Method), req.URL.RequestURI()))
+			continue
+		}
+
+		values := req.Header.Values(h)
+		if len(values) == 0 {
+			return "", fmt.Errorf("%w: %s", errMissingHeader, h)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s", h, strings.Join(values, ", ")))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// sign returns the base64 HMAC-SHA256 of payload with secret.
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func lower(headers []string) []string {
+	l := make([]string, 0, len(headers))
+	for _, h := range headers {
+		l = append(l, strings.ToLower(h))
+	}
+
+	return l
+}
@@ -0,0 +1,241 @@
+package signing_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/signing"
+)
+
+func TestTransport_Verifier(t *testing.T) {
+	t.Parallel()
+
+	keys := signing.StaticKeys(signing.Key{ID: "billing", Secret: []byte("s3cret"), Groups: []string{"services"}})
+
+	type want struct {
+		id   *auth.Identity
+		body string
+		err  string
+	}
+
+	tests := map[string]struct {
+		signer  *signing.Signer
+		date    time.Time
+		tamper  func(r *http.Request)
+		headers []string
+		maxBody int64
+		want    want
+	}{
+		"valid": {
+			signer: signing.NewSigner("billing", []byte("s3cret"), "X-Request-ID"),
+			want: want{
+				id:   &auth.Identity{Subject: "billing", Groups: []string{"services"}, Method: signing.MethodHMAC},
+				body: `{"amount":1}`,
+			},
+		},
+		"required header": {
+			signer:  signing.NewSigner("billing", []byte("s3cret"), "X-Request-ID"),
+			headers: []string{"X-Request-ID"},
+			want: want{
+				id:   &auth.Identity{Subject: "billing", Groups: []string{"services"}, Method: signing.MethodHMAC},
+				body: `{"amount":1}`,
+			},
+		},
+		"required header not signed": {
+			signer:  signing.NewSigner("billing", []byte("s3cret")),
+			headers: []string{"X-Request-ID"},
+			want:    want{err: "invalid signature: x-request-id not signed"},
+		},
+		"unknown key": {
+			signer: signing.NewSigner("shipping", []byte("s3cret")),
+			want:   want{err: `invalid signature: unknown signing key "shipping"`},
+		},
+		"wrong secret": {
+			signer: signing.NewSigner("billing", []byte("guess")),
+			want:   want{err: "invalid signature: signature mismatch"},
+		},
+		"body tampered": {
+			signer: signing.NewSigner("billing", []byte("s3cret")),
+			tamper: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"amount":1000}`))
+			},
+			want: want{err: "invalid signature: digest does not match body"},
+		},
+		"body too large": {
+			signer:  signing.NewSigner("billing", []byte("s3cret")),
+			maxBody: 8,
+			want:    want{err: "invalid signature: body exceeds 8 bytes"},
+		},
+		"signed header tampered": {
+			signer: signing.NewSigner("billing", []byte("s3cret"), "X-Request-ID"),
+			tamper: func(r *http.Request) {
+				r.Header.Set("X-Request-ID", "other")
+			},
+			want: want{err: "invalid signature: signature mismatch"},
+		},
+		"path tampered": {
+			signer: signing.NewSigner("billing", []byte("s3cret")),
+			tamper: func(r *http.Request) {
+				r.URL.Path = "/refunds"
+			},
+			want: want{err: "invalid signature: signature mismatch"},
+		},
+		"date within skew": {
+			signer: signing.NewSigner("billing", []byte("s3cret")),
+			date:   time.Now().Add(-time.Minute),
+			want: want{
+				id:   &auth.Identity{Subject: "billing", Groups: []string{"services"}, Method: signing.MethodHMAC},
+				body: `{"amount":1}`,
+			},
+		},
+		"date too old": {
+			signer: signing.NewSigner("billing", []byte("s3cret")),
+			date:   time.Now().Add(-10 * time.Minute),
+			want:   want{err: "invalid signature: timestamp expired"},
+		},
+		"date in future": {
+			signer: signing.NewSigner("billing", []byte("s3cret")),
+			date:   time.Now().Add(10 * time.Minute),
+			want:   want{err: "invalid signature: timestamp not yet valid"},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v := signing.NewVerifier(keys, 5*time.Minute, tt.maxBody, tt.headers...)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.tamper != nil {
+					tt.tamper(r)
+				}
+
+				id, err := v.Authenticate(r)
+				if tt.want.err != "" {
+					assert.ErrorContains(t, err, tt.want.err)
+					assert.ErrorIs(t, err, signing.ErrInvalidSignature)
+					return
+				}
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.want.id, id)
+
+				// The body is still readable by the handler.
+				b, _ := io.ReadAll(r.Body)
+				assert.Equal(t, tt.want.body, string(b))
+			}))
+			defer server.Close()
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/payments?dry_run=true", strings.NewReader(`{"amount":1}`))
+			req.Header.Set("X-Request-ID", "abc")
+
+			if !tt.date.IsZero() {
+				req.Header.Set("Date", tt.date.UTC().Format(http.TimeFormat))
+			}
+
+			hc := &http.Client{Transport: signing.NewTransport(tt.signer, http.DefaultTransport)}
+
+			resp, err := hc.Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			resp.Body.Close()
+
+			// The request of the caller is not modified.
+			assert.Empty(t, req.Header.Get(signing.SignatureHeader))
+		})
+	}
+}
+
+func TestVerifier_Authenticate_NoCredentials(t *testing.T) {
+	t.Parallel()
+
+	v := signing.NewVerifier(signing.StaticKeys(), 0, 0)
+
+	_, err := v.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, err, auth.ErrNoCredentials)
+}
+
+func TestVerifier_Verify_Malformed(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		signature string
+		want      string
+	}{
+		"no key id": {
+			signature: `algorithm="hmac-sha256",headers="(request-target) date digest",signature="abc"`,
+			want:      "invalid signature: key id or signature missing",
+		},
+		"malformed": {
+			signature: `keyId`,
+			want:      `invalid signature: malformed parameter "keyId"`,
+		},
+		"algorithm": {
+			signature: `keyId="billing",algorithm="rsa-sha256",headers="(request-target) date digest",signature="abc"`,
+			want:      `invalid signature: unsupported algorithm "rsa-sha256"`,
+		},
+		"date not signed": {
+			signature: `keyId="billing",algorithm="hmac-sha256",headers="(request-target) digest",signature="abc"`,
+			want:      "invalid signature: date not signed",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(signing.SignatureHeader, tt.signature)
+			r.Header.Set(signing.DateHeader, time.Now().UTC().Format(http.TimeFormat))
+
+			_, err := signing.NewVerifier(signing.StaticKeys(), 0, 0).Verify(r)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+// unreadBody fails the test when read.
+type unreadBody struct {
+	t *testing.T
+}
+
+func (b unreadBody) Read([]byte) (int, error) {
+	b.t.Error("body read before the signature verified")
+	return 0, io.EOF
+}
+
+func (unreadBody) Close() error { return nil }
+
+func TestVerifier_Verify_BodyReadAfterSignature(t *testing.T) {
+	t.Parallel()
+
+	signed := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{"amount":1}`))
+	if err := signing.NewSigner("billing", []byte("guess")).Sign(signed); !assert.NoError(t, err) {
+		return
+	}
+
+	// A signature of another secret, on a body that must not be read.
+	r := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	r.Header = signed.Header
+	r.Body = unreadBody{t: t}
+
+	_, err := signing.NewVerifier(signing.StaticKeys(signing.Key{ID: "billing", Secret: []byte("s3cret")}), 0, 0).Verify(r)
+	assert.EqualError(t, err, "invalid signature: signature mismatch")
+}
@@ -0,0 +1,179 @@
+package signing
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+// MethodHMAC is the Identity.Method of services authenticated by a signature.
+const MethodHMAC = "hmac"
+
+const (
+	// DefaultMaxSkew is the tolerance of a Verifier created with no skew.
+	DefaultMaxSkew = 5 * time.Minute
+	// DefaultMaxBodySize bounds the bodies of the requests of a Verifier created with no size.
+	DefaultMaxBodySize = 10 << 20
+)
+
+var (
+	// ErrUnknownKey is returned by a KeyLookup without the key of a signature.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrInvalidSignature is matched by the errors of requests whose signature does not verify.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Key is the key shared with a service.
+type Key struct {
+	ID     string
+	Secret []byte
+	// Groups are the groups of the service, used as its roles.
+	Groups []string
+}
+
+// KeyLookup returns the key of id, or ErrUnknownKey.
+type KeyLookup func(ctx context.Context, id string) (*Key, error)
+
+// StaticKeys returns a KeyLookup of keys.
+func StaticKeys(keys ...Key) KeyLookup {
+	byID := make(map[string]*Key, len(keys))
+	for i := range keys {
+		byID[keys[i].ID] = &keys[i]
+	}
+
+	return func(_ context.Context, id string) (*Key, error) {
+		k, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+		}
+
+		return k, nil
+	}
+}
+
+// Verifier verifies the signatures of requests. It is an auth.Authenticator, so signed requests are authenticated by
+// the auth middleware of their route like any other consumer.
+type Verifier struct {
+	keys        KeyLookup
+	headers     []string
+	validate    *timestamp.Validator
+	maxBodySize int64
+}
+
+// NewVerifier creates a Verifier of the keys of lookup, accepting the requests whose Date is within maxSkew of the
+// current time, whose body is at most maxBodySize bytes, and whose signature covers headers besides the request
+// target, the date and the digest. Zero maxSkew uses DefaultMaxSkew, zero maxBodySize DefaultMaxBodySize.
+func NewVerifier(lookup KeyLookup, maxSkew time.Duration, maxBodySize int64, headers ...string) *Verifier {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	return &Verifier{
+		keys:        lookup,
+		headers:     signedHeaders(headers),
+		validate:    timestamp.NewValidator(maxSkew),
+		maxBodySize: maxBodySize,
+	}
+}
+
+// Verify returns the key of the signature of r if it verifies. The body of r is read and replaced, once the signature
+// of its headers verifies, so that unauthenticated requests are not buffered.
+func (v *Verifier) Verify(r *http.Request) (*Key, error) {
+	params, err := parseSignature(r.Header.Get(SignatureHeader))
+	if err != nil {
+		return nil, err
+	}
+
+	if params["algorithm"] != Algorithm {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, params["algorithm"])
+	}
+
+	headers := strings.Fields(params["headers"])
+	for _, h := range v.headers {
+		if !slices.Contains(headers, h) {
+			return nil, fmt.Errorf("%w: %s not signed", ErrInvalidSignature, h)
+		}
+	}
+
+	date, err := http.ParseTime(r.Header.Get(DateHeader))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date: %w", ErrInvalidSignature, err)
+	}
+
+	// The window of a single instant accepts a Date within the skew of the current time on either side.
+	if err := v.validate.ValidateWindow(date, date); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	key, err := v.keys(r.Context(), params["keyId"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	payload, err := signingString(r, headers)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if !hmac.Equal([]byte(sign(key.Secret, payload)), []byte(params["signature"])) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+
+	// The signature covers the Digest header, which is left to match the body.
+	b, err := readBody(r, v.maxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if !hmac.Equal([]byte(digest(b)), []byte(r.Header.Get(DigestHeader))) {
+		return nil, fmt.Errorf("%w: digest does not match body", ErrInvalidSignature)
+	}
+
+	return key, nil
+}
+
+// Authenticate implements auth.Authenticator. The subject is the ID of the key.
+func (v *Verifier) Authenticate(r *http.Request) (*auth.Identity, error) {
+	if r.Header.Get(SignatureHeader) == "" {
+		return nil, auth.ErrNoCredentials
+	}
+
+	key, err := v.Verify(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Identity{Subject: key.ID, Groups: key.Groups, Method: MethodHMAC}, nil
+}
+
+// parseSignature returns the parameters of a Signature header, failing without a key ID or a signature.
+func parseSignature(h string) (map[string]string, error) {
+	params := make(map[string]string)
+
+	for _, p := range strings.Split(h, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidSignature, p)
+		}
+
+		params[name] = strings.Trim(value, `"`)
+	}
+
+	if params["keyId"] == "" || params["signature"] == "" {
+		return nil, fmt.Errorf("%w: key id or signature missing", ErrInvalidSignature)
+	}
+
+	return params, nil
+}
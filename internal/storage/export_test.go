@@ -0,0 +1,13 @@
+package storage
+
+import "time"
+
+// SetS3Clock overrides the clock used for signing, so signatures can be compared against known values.
+func SetS3Clock(s *S3, now func() time.Time) {
+	s.now = now
+}
+
+// SetLocalClock overrides the clock used for presigning and verifying URLs.
+func SetLocalClock(l *Local, now func() time.Time) {
+	l.now = now
+}
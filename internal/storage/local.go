@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/secret"
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+const (
+	contentTypeSuffix = ".content-type"
+	dirPerm           = 0o750
+	filePerm          = 0o600
+)
+
+// Local is a BlobStore keeping objects on the local filesystem. Presigned URLs point to its ServeHTTP handler,
+// which has to be mounted under the configured base URL.
+type Local struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	validator  *timestamp.Validator
+	now        func() time.Time
+}
+
+// NewLocal creates a new Local BlobStore.
+func NewLocal(cfg *config.LocalStorage) (*Local, error) {
+	if cfg.Root == "" {
+		return nil, errors.New("local storage root is required")
+	}
+
+	if cfg.SigningKey == "" {
+		return nil, errors.New("local storage signing key is required")
+	}
+
+	if err := secret.CheckKey(cfg.SigningKey); err != nil {
+		return nil, fmt.Errorf("invalid local storage signing key: %w", err)
+	}
+
+	l := &Local{
+		root:       cfg.Root,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		signingKey: []byte(cfg.SigningKey),
+		now:        time.Now,
+	}
+	l.validator = timestamp.NewValidator(0, timestamp.WithClock(func() time.Time { return l.now() }))
+
+	return l, nil
+}
+
+// Put writes the object to disk. The file is written to a temporary location first so readers never see partial data.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	p := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), dirPerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := writeFileAtomic(p, r); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(p+contentTypeSuffix, []byte(contentType), filePerm); err != nil {
+		return fmt.Errorf("failed to write content type: %w", err)
+	}
+
+	return nil
+}
+
+// Get opens the object from disk.
+func (l *Local) Get(_ context.Context, key string) (*Object, error) {
+	p := l.path(key)
+
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	contentType, err := os.ReadFile(p + contentTypeSuffix)
+	if err != nil {
+		contentType = []byte("application/octet-stream")
+	}
+
+	return &Object{Body: f, ContentType: string(contentType), ContentLength: info.Size()}, nil
+}
+
+// Delete removes the object from disk.
+func (l *Local) Delete(_ context.Context, key string) error {
+	p := l.path(key)
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	os.Remove(p + contentTypeSuffix)
+
+	return nil
+}
+
+// Prefix returns the URL path below which ServeHTTP has to be mounted.
+func (l *Local) Prefix() string {
+	u, err := url.Parse(l.baseURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Path
+}
+
+// PresignGet returns a URL to ServeHTTP carrying an expiry and an HMAC signature over the key and expiry.
+func (l *Local) PresignGet(_ context.Context, key string, ttl time.Duration) (string, error) {
+	key = cleanKey(key)
+	expires := strconv.FormatInt(l.now().Add(ttl).Unix(), 10)
+
+	return fmt.Sprintf("%s/%s?expires=%s&signature=%s", l.baseURL, key, expires, l.sign(key, expires)), nil
+}
+
+// ServeHTTP serves objects for presigned URLs. The request path is the object key.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := cleanKey(r.URL.Path)
+
+	if err := l.verify(key, r.URL.Query().Get("expires"), r.URL.Query().Get("signature")); err != nil {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	obj, err := l.Get(r.Context(), key)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	defer obj.Body.Close()
+
+	w.Header().Set("Content-Type", obj.ContentType)
+
+	if rs, ok := obj.Body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", time.Time{}, rs)
+		return
+	}
+
+	io.Copy(w, obj.Body)
+}
+
+func (l *Local) verify(key, expires, signature string) error {
+	exp, err := timestamp.ParseUnix(expires)
+	if err != nil {
+		return err
+	}
+
+	if err := l.validator.ValidateExpiry(exp); err != nil {
+		return fmt.Errorf("presigned url: %w", err)
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(l.sign(key, expires))) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+func (l *Local) sign(key, expires string) string {
+	h := hmac.New(sha256.New, l.signingKey)
+	h.Write([]byte(key + "\n" + expires))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path maps a key to a file below root. Keys are cleaned so they cannot escape root.
+func (l *Local) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(cleanKey(key)))
+}
+
+func cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+func writeFileAtomic(p string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("failed to store object: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/storage"
+)
+
+func newLocal(t *testing.T) *storage.Local {
+	t.Helper()
+
+	l, err := storage.NewLocal(&config.LocalStorage{
+		Root:       t.TempDir(),
+		BaseURL:    "http://localhost:8080/storage/",
+		SigningKey: "secret",
+	})
+	assert.NoError(t, err)
+
+	return l
+}
+
+func TestLocal_Objects(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	l := newLocal(t)
+
+	err := l.Put(ctx, "photos/1/image", strings.NewReader("png"), 3, "image/png")
+	assert.NoError(t, err)
+
+	obj, err := l.Get(ctx, "photos/1/image")
+	assert.NoError(t, err)
+
+	b, _ := io.ReadAll(obj.Body)
+	obj.Body.Close()
+	assert.Equal(t, "png", string(b))
+	assert.Equal(t, "image/png", obj.ContentType)
+	assert.Equal(t, int64(3), obj.ContentLength)
+
+	assert.NoError(t, l.Delete(ctx, "photos/1/image"))
+	assert.NoError(t, l.Delete(ctx, "photos/1/image"))
+
+	_, err = l.Get(ctx, "photos/1/image")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestLocal_KeyCannotEscapeRoot(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	l, err := storage.NewLocal(&config.LocalStorage{Root: filepath.Join(root, "blobs"), SigningKey: "secret"})
+	assert.NoError(t, err)
+
+	err = l.Put(context.Background(), "../../escaped", strings.NewReader("x"), 1, "text/plain")
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(root, "blobs", "escaped"))
+	assert.NoError(t, err)
+}
+
+func TestLocal_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		query func(u *url.URL)
+		age   time.Duration
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"valid signature":   {args: args{query: func(*url.URL) {}}, want: want{code: http.StatusOK, body: "png"}},
+		"expired":           {args: args{query: func(*url.URL) {}, age: 2 * time.Minute}, want: want{code: http.StatusForbidden}},
+		"tampered key":      {args: args{query: func(u *url.URL) { u.Path = "/storage/photos/2/image" }}, want: want{code: http.StatusForbidden}},
+		"missing signature": {args: args{query: func(u *url.URL) { u.RawQuery = "" }}, want: want{code: http.StatusForbidden}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newLocal(t)
+			assert.Equal(t, "/storage", l.Prefix())
+
+			err := l.Put(context.Background(), "photos/1/image", strings.NewReader("png"), 3, "image/png")
+			assert.NoError(t, err)
+
+			signed, err := l.PresignGet(context.Background(), "photos/1/image", time.Minute)
+			assert.NoError(t, err)
+
+			storage.SetLocalClock(l, func() time.Time { return time.Now().Add(tt.args.age) })
+
+			u, err := url.Parse(signed)
+			assert.NoError(t, err)
+			tt.args.query(u)
+
+			req := httptest.NewRequest(http.MethodGet, u.String(), http.NoBody)
+			resp := httptest.NewRecorder()
+
+			http.StripPrefix(l.Prefix(), l).ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, resp.Body.String())
+				assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	bs, err := storage.New(&config.Storage{Backend: "local", Local: config.LocalStorage{Root: t.TempDir(), SigningKey: "k"}}, http.DefaultClient)
+	assert.NoError(t, err)
+	assert.IsType(t, &storage.Local{}, bs)
+
+	bs, err = storage.New(&config.Storage{Backend: "s3", S3: config.S3Storage{Endpoint: "http://localhost:9000", Bucket: "b"}}, http.DefaultClient)
+	assert.NoError(t, err)
+	assert.IsType(t, &storage.S3{}, bs)
+
+	_, err = storage.New(&config.Storage{Backend: "ftp"}, http.DefaultClient)
+	assert.EqualError(t, err, `unsupported storage backend "ftp"`)
+
+	_, err = storage.NewLocal(&config.LocalStorage{})
+	assert.EqualError(t, err, "local storage root is required")
+
+	_, err = storage.NewLocal(&config.LocalStorage{Root: "x"})
+	assert.EqualError(t, err, "local storage signing key is required")
+
+	_, err = storage.NewLocal(&config.LocalStorage{Root: "x", SigningKey: "change-me"})
+	assert.EqualError(t, err, `invalid local storage signing key: key is the placeholder "change-me"`)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// S3 is a BlobStore backed by an S3 compatible object store such as AWS S3 or MinIO.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	signer    *sigV4
+	client    httpClient
+	now       func() time.Time
+}
+
+// NewS3 creates a new S3 BlobStore.
+func NewS3(cfg *config.S3Storage, c httpClient) (*S3, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+
+	return &S3{
+		endpoint:  endpoint,
+		bucket:    cfg.Bucket,
+		pathStyle: cfg.UsePathStyle,
+		signer: &sigV4{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			region:          cfg.Region,
+		},
+		client: c,
+		now:    time.Now,
+	}, nil
+}
+
+// Put uploads the content of r to the bucket. The body is streamed, size must be the exact content length.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	return nil
+}
+
+// Get downloads an object from the bucket.
+func (s *S3) Get(ctx context.Context, key string) (*Object, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Body:          resp.Body,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+	}, nil
+}
+
+// Delete removes an object from the bucket.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	return nil
+}
+
+// PresignGet returns a SigV4 query-signed URL for downloading the object.
+func (s *S3) PresignGet(_ context.Context, key string, ttl time.Duration) (string, error) {
+	return s.signer.presign(http.MethodGet, s.objectURL(key), s.now(), ttl).String(), nil
+}
+
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	s.signer.signHeader(req, s.now())
+
+	return req, nil
+}
+
+// do sends the request, translating non-2xx responses into errors.
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	return nil, fmt.Errorf("s3 %s %s: unexpected status %d", req.Method, req.URL.Path, resp.StatusCode)
+}
+
+// objectURL returns the URL of the object, using path-style or virtual-hosted-style addressing.
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	p := "/" + strings.TrimPrefix(key, "/")
+
+	if s.pathStyle {
+		p = "/" + s.bucket + p
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+
+	u.Path = strings.TrimSuffix(s.endpoint.Path, "/") + p
+	u.RawPath = canonicalURI(&u)
+
+	return &u
+}
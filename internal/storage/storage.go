@@ -0,0 +1,52 @@
+// Package storage provides blob storage backends for binary assets such as photo images.
+// It contains the BlobStore interface with S3 compatible and local filesystem implementations.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// ErrNotFound is returned when the requested object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Object is a blob read from the store. The caller is responsible for closing Body.
+type Object struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+}
+
+// BlobStore stores binary objects addressed by key.
+type BlobStore interface {
+	// Put stores the content of r under key. size is the exact length of the content.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the object stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL granting read access to key for the given duration without further credentials.
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// PhotoImageKey returns the key under which the image of the photo with the given ID is stored.
+func PhotoImageKey(id int) string {
+	return fmt.Sprintf("photos/%d/image", id)
+}
+
+// New creates the BlobStore selected by the configured backend.
+func New(cfg *config.Storage, c httpClient) (BlobStore, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocal(&cfg.Local)
+	case "s3":
+		return NewS3(&cfg.S3, c)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.Backend)
+	}
+}
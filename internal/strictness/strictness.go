@@ -0,0 +1,52 @@
+// Package strictness resolves the strictness mode of an environment into the behaviors it toggles, so development is
+// forgiving and production safe without setting each behavior on its own.
+package strictness
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownMode is returned for modes other than dev, strict and prod.
+var ErrUnknownMode = errors.New("unknown strictness mode")
+
+// Mode is the strictness of an environment.
+type Mode string
+
+// Modes of strictness. Strict is meant for CI, as safe as prod but with the diagnostics of dev.
+const (
+	ModeDev    Mode = "dev"
+	ModeStrict Mode = "strict"
+	ModeProd   Mode = "prod"
+)
+
+// Settings are the behaviors toggled by a Mode.
+type Settings struct {
+	Mode Mode
+	// StrictJSON rejects JSON request bodies with unknown fields.
+	StrictJSON bool
+	// ValidateResponses validates the upstream responses, like client.validate_responses.
+	ValidateResponses bool
+	// VerboseErrors adds the cause of errors to the error responses.
+	VerboseErrors bool
+	// DebugEndpoints serves the profiling endpoints under /debug/pprof.
+	DebugEndpoints bool
+	// FakeData allows answering upstream requests with fake data, with client.mock_upstream.
+	FakeData bool
+	// FaultInjection allows injecting faults into requests, with chaos.
+	FaultInjection bool
+}
+
+// New returns the settings of mode. Empty is prod, so deployments are safe unless told otherwise.
+func New(mode string) (Settings, error) {
+	switch Mode(mode) {
+	case ModeDev:
+		return Settings{Mode: ModeDev, VerboseErrors: true, DebugEndpoints: true, FakeData: true, FaultInjection: true}, nil
+	case ModeStrict:
+		return Settings{Mode: ModeStrict, StrictJSON: true, ValidateResponses: true, VerboseErrors: true, FakeData: true, FaultInjection: true}, nil
+	case ModeProd, "":
+		return Settings{Mode: ModeProd, StrictJSON: true, ValidateResponses: true}, nil
+	default:
+		return Settings{}, fmt.Errorf("%w %q", ErrUnknownMode, mode)
+	}
+}
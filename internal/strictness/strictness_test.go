@@ -0,0 +1,62 @@
+package strictness_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/strictness"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		settings strictness.Settings
+		err      error
+	}
+
+	tests := map[string]struct {
+		mode string
+		want want
+	}{
+		"dev": {
+			mode: "dev",
+			want: want{settings: strictness.Settings{Mode: strictness.ModeDev, VerboseErrors: true, DebugEndpoints: true, FakeData: true, FaultInjection: true}},
+		},
+		"strict": {
+			mode: "strict",
+			want: want{settings: strictness.Settings{Mode: strictness.ModeStrict, StrictJSON: true, ValidateResponses: true, VerboseErrors: true, FakeData: true, FaultInjection: true}},
+		},
+		"prod": {
+			mode: "prod",
+			want: want{settings: strictness.Settings{Mode: strictness.ModeProd, StrictJSON: true, ValidateResponses: true}},
+		},
+		"empty is prod": {
+			mode: "",
+			want: want{settings: strictness.Settings{Mode: strictness.ModeProd, StrictJSON: true, ValidateResponses: true}},
+		},
+		"unknown": {
+			mode: "staging",
+			want: want{err: strictness.ErrUnknownMode},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := strictness.New(tt.mode)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want.settings, s)
+			}
+		})
+	}
+}
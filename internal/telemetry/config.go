@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Config holds the export of the metrics and logs to an OpenTelemetry collector.
+type Config struct {
+	// Endpoint is the base URL of the OTLP/HTTP receiver, e.g. http://otel-collector:4318. Empty disables the export.
+	Endpoint string `mapstructure:"endpoint"`
+	// Headers are sent with every export, e.g. the API key of a vendor.
+	Headers map[string]string `mapstructure:"headers" redact:"true"`
+	// Timeout bounds each export. Zero uses 10s.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// ServiceName is the service.name resource attribute. Empty uses skeleton-go-api.
+	ServiceName string `mapstructure:"service_name"`
+	// ResourceAttributes are added to the resource of every export, e.g. deployment.environment.
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes"`
+	// Sampling is the share of the log entries below warn level exported, between 0 and 1. Warnings and errors are
+	// always exported. Zero exports every entry.
+	Sampling float64 `mapstructure:"sampling"`
+	// Metrics exports the metrics of the Prometheus backend.
+	Metrics Signal `mapstructure:"metrics"`
+	// Logs exports the log entries.
+	Logs Signal `mapstructure:"logs"`
+}
+
+// Signal holds the export of metrics or logs.
+type Signal struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the period of the exports. Zero uses 30s for metrics and 5s for logs.
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize is the number of queued log entries which triggers an export before the interval. Zero uses 512.
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// ConfigSection is the section of the configuration of the export, under telemetry. It has to be registered with the
+// config.Viper building the configuration.
+var ConfigSection = config.NewSection("telemetry", Config{}, validateConfig)
+
+func validateConfig(cfg *Config) error {
+	var errs []error
+
+	if cfg.Sampling < 0 || cfg.Sampling > 1 {
+		errs = append(errs, fmt.Errorf("sampling %g is not between 0 and 1", cfg.Sampling))
+	}
+
+	if cfg.Timeout < 0 || cfg.Metrics.Interval < 0 || cfg.Logs.Interval < 0 {
+		errs = append(errs, errors.New("timeout and intervals must not be negative"))
+	}
+
+	if cfg.Logs.BatchSize < 0 {
+		errs = append(errs, errors.New("logs.batch_size must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
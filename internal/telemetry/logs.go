@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type exportLogsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type logRecord struct {
+	TimeUnixNano   string     `json:"timeUnixNano"`
+	SeverityNumber int        `json:"severityNumber"`
+	SeverityText   string     `json:"severityText"`
+	Body           anyValue   `json:"body"`
+	Attributes     []keyValue `json:"attributes"`
+}
+
+// Severity numbers of the OTLP log data model for the zap levels.
+const (
+	severityDebug = 5
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+	severityFatal = 21
+)
+
+// logCore is the zapcore.Core queuing the log entries for export.
+type logCore struct {
+	zapcore.LevelEnabler
+	t      *Telemetry
+	fields []zapcore.Field
+}
+
+// Core returns the core exporting the log entries of the levels enabled by enab, to tee with the core of the logger,
+// or nil if logs are not exported. Entries below warn level are sampled by the configured ratio.
+func (t *Telemetry) Core(enab zapcore.LevelEnabler) zapcore.Core {
+	if !t.cfg.Logs.Enabled {
+		return nil
+	}
+
+	return &logCore{LevelEnabler: enab, t: t}
+}
+
+func (c *logCore) With(fields []zapcore.Field) zapcore.Core {
+	return &logCore{LevelEnabler: c.LevelEnabler, t: c.t, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+func (c *logCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+
+	return ce
+}
+
+func (c *logCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	if e.Level < zapcore.WarnLevel && !c.t.sampled() {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	if e.LoggerName != "" {
+		enc.Fields["logger"] = e.LoggerName
+	}
+
+	if e.Caller.Defined {
+		enc.Fields["caller"] = e.Caller.TrimmedPath()
+	}
+
+	c.t.enqueue(logRecord{
+		TimeUnixNano:   unixNano(e.Time),
+		SeverityNumber: severity(e.Level),
+		SeverityText:   e.Level.CapitalString(),
+		Body:           stringValue(e.Message),
+		Attributes:     attributes(enc.Fields, value),
+	})
+
+	return nil
+}
+
+// Sync is a no-op: the queued entries are exported in the background and flushed on shutdown.
+func (c *logCore) Sync() error {
+	return nil
+}
+
+// exportLogs sends the queued log records.
+func (t *Telemetry) exportLogs(ctx context.Context, records []logRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return t.client.post(ctx, "/v1/logs", exportLogsRequest{ResourceLogs: []resourceLogs{{
+		Resource:  t.resource,
+		ScopeLogs: []scopeLogs{{Scope: scope{Name: scopeName}, LogRecords: records}},
+	}}})
+}
+
+func severity(l zapcore.Level) int {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return severityDebug
+	case l == zapcore.InfoLevel:
+		return severityInfo
+	case l == zapcore.WarnLevel:
+		return severityWarn
+	case l == zapcore.ErrorLevel:
+		return severityError
+	default:
+		return severityFatal
+	}
+}
@@ -0,0 +1,333 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// cumulative is the aggregation temporality of the Prometheus metrics: their values accumulate since the start.
+const cumulative = 2
+
+// errUnsupportedFamily is returned for the metric families without an OTLP counterpart, which are not exported.
+var errUnsupportedFamily = errors.New("unsupported metric family")
+
+// gatherer gathers the metrics to export, such as a metrics.Registry.
+type gatherer interface {
+	Gather() ([]*dto.MetricFamily, error)
+}
+
+type exportMetricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name                 string                `json:"name"`
+	Gauge                *gauge                `json:"gauge,omitempty"`
+	Sum                  *sum                  `json:"sum,omitempty"`
+	Histogram            *histogram            `json:"histogram,omitempty"`
+	ExponentialHistogram *exponentialHistogram `json:"exponentialHistogram,omitempty"`
+	Summary              *summary              `json:"summary,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type exponentialHistogram struct {
+	DataPoints             []exponentialHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                             `json:"aggregationTemporality"`
+}
+
+type summary struct {
+	DataPoints []summaryDataPoint `json:"dataPoints"`
+}
+
+type numberDataPoint struct {
+	Attributes        []keyValue `json:"attributes"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	AsDouble          float64    `json:"asDouble"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	Count             string     `json:"count"`
+	Sum               float64    `json:"sum"`
+	BucketCounts      []string   `json:"bucketCounts"`
+	ExplicitBounds    []float64  `json:"explicitBounds"`
+}
+
+type exponentialHistogramDataPoint struct {
+	Attributes        []keyValue `json:"attributes"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	TimeUnixNano      string     `json:"timeUnixNano"`
+	Count             string     `json:"count"`
+	Sum               float64    `json:"sum"`
+	Scale             int32      `json:"scale"`
+	ZeroCount         string     `json:"zeroCount"`
+	ZeroThreshold     float64    `json:"zeroThreshold"`
+	Positive          buckets    `json:"positive"`
+	Negative          buckets    `json:"negative"`
+}
+
+// buckets are the counts of consecutive exponential buckets, the first one of index Offset.
+type buckets struct {
+	Offset       int32    `json:"offset"`
+	BucketCounts []string `json:"bucketCounts,omitempty"`
+}
+
+type summaryDataPoint struct {
+	Attributes        []keyValue      `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	QuantileValues    []quantileValue `json:"quantileValues"`
+}
+
+type quantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// exportMetrics sends the gathered metrics, cumulative since start. The families which cannot be converted are left
+// out, and reported the first time.
+func (t *Telemetry) exportMetrics(ctx context.Context) error {
+	families, err := t.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	metrics := make([]metric, 0, len(families))
+	for _, mf := range families {
+		m, err := convertFamily(mf, t.start, time.Now())
+		if err != nil {
+			t.reject(mf.GetName(), err)
+			continue
+		}
+
+		metrics = append(metrics, m)
+	}
+
+	return t.client.post(ctx, "/v1/metrics", exportMetricsRequest{ResourceMetrics: []resourceMetrics{{
+		Resource:     t.resource,
+		ScopeMetrics: []scopeMetrics{{Scope: scope{Name: scopeName}, Metrics: metrics}},
+	}}})
+}
+
+// reject reports the metric family name left out of the exports for err, once.
+func (t *Telemetry) reject(name string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rejected[name] {
+		return
+	}
+
+	t.rejected[name] = true
+	t.log.Warn("Metric not exported", zap.String("metric", name), zap.Error(err))
+}
+
+// convertFamily returns the OTLP metric of a Prometheus metric family. Counters are monotonic cumulative sums,
+// gauges and untyped metrics are gauges, histograms keep their buckets, and native histograms without classic
+// buckets become exponential histograms. Gauge histograms, whose counts may go down, have no OTLP counterpart and
+// are rejected.
+func convertFamily(mf *dto.MetricFamily, start, now time.Time) (metric, error) {
+	m := metric{Name: mf.GetName()}
+	startNano, nowNano := unixNano(start), unixNano(now)
+
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		m.Sum = &sum{AggregationTemporality: cumulative, IsMonotonic: true}
+		for _, pm := range mf.GetMetric() {
+			m.Sum.DataPoints = append(m.Sum.DataPoints, numberDataPoint{Attributes: labels(pm), StartTimeUnixNano: startNano,
+				TimeUnixNano: nowNano, AsDouble: pm.GetCounter().GetValue()})
+		}
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		m.Gauge = &gauge{}
+		for _, pm := range mf.GetMetric() {
+			v := pm.GetGauge().GetValue()
+			if mf.GetType() == dto.MetricType_UNTYPED {
+				v = pm.GetUntyped().GetValue()
+			}
+
+			m.Gauge.DataPoints = append(m.Gauge.DataPoints, numberDataPoint{Attributes: labels(pm), TimeUnixNano: nowNano, AsDouble: v})
+		}
+	case dto.MetricType_HISTOGRAM:
+		if err := convertHistogram(&m, mf, startNano, nowNano); err != nil {
+			return m, err
+		}
+	case dto.MetricType_SUMMARY:
+		m.Summary = &summary{}
+		for _, pm := range mf.GetMetric() {
+			s := pm.GetSummary()
+			dp := summaryDataPoint{Attributes: labels(pm), StartTimeUnixNano: startNano, TimeUnixNano: nowNano,
+				Count: strconv.FormatUint(s.GetSampleCount(), 10), Sum: s.GetSampleSum()}
+
+			for _, q := range s.GetQuantile() {
+				dp.QuantileValues = append(dp.QuantileValues, quantileValue{Quantile: q.GetQuantile(), Value: q.GetValue()})
+			}
+
+			m.Summary.DataPoints = append(m.Summary.DataPoints, dp)
+		}
+	default:
+		return m, fmt.Errorf("%w: %s", errUnsupportedFamily, mf.GetType())
+	}
+
+	return m, nil
+}
+
+// convertHistogram sets the histogram of m from the histograms of mf, as an exponential histogram if they are native
+// histograms without classic buckets.
+func convertHistogram(m *metric, mf *dto.MetricFamily, startNano, nowNano string) error {
+	for _, pm := range mf.GetMetric() {
+		h := pm.GetHistogram()
+		if len(h.GetBucket()) > 0 || h.Schema == nil {
+			if m.ExponentialHistogram != nil {
+				return fmt.Errorf("%w: classic and native histograms mixed", errUnsupportedFamily)
+			}
+
+			if m.Histogram == nil {
+				m.Histogram = &histogram{AggregationTemporality: cumulative}
+			}
+
+			m.Histogram.DataPoints = append(m.Histogram.DataPoints, histogramPoint(pm, startNano, nowNano))
+
+			continue
+		}
+
+		if m.Histogram != nil {
+			return fmt.Errorf("%w: classic and native histograms mixed", errUnsupportedFamily)
+		}
+
+		dp, err := exponentialHistogramPoint(pm, startNano, nowNano)
+		if err != nil {
+			return err
+		}
+
+		if m.ExponentialHistogram == nil {
+			m.ExponentialHistogram = &exponentialHistogram{AggregationTemporality: cumulative}
+		}
+
+		m.ExponentialHistogram.DataPoints = append(m.ExponentialHistogram.DataPoints, dp)
+	}
+
+	if m.Histogram == nil && m.ExponentialHistogram == nil {
+		m.Histogram = &histogram{AggregationTemporality: cumulative}
+	}
+
+	return nil
+}
+
+// histogramPoint returns the data point of a Prometheus histogram, whose cumulative bucket counts become the counts
+// of each bucket, the last one above the highest bound.
+func histogramPoint(pm *dto.Metric, startNano, nowNano string) histogramDataPoint {
+	h := pm.GetHistogram()
+	dp := histogramDataPoint{Attributes: labels(pm), StartTimeUnixNano: startNano, TimeUnixNano: nowNano,
+		Count: strconv.FormatUint(h.GetSampleCount(), 10), Sum: h.GetSampleSum()}
+
+	var below uint64
+
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.GetUpperBound())
+		dp.BucketCounts = append(dp.BucketCounts, strconv.FormatUint(b.GetCumulativeCount()-below, 10))
+		below = b.GetCumulativeCount()
+	}
+
+	dp.BucketCounts = append(dp.BucketCounts, strconv.FormatUint(h.GetSampleCount()-below, 10))
+
+	return dp
+}
+
+// exponentialHistogramPoint returns the data point of a Prometheus native histogram. Its schema is the OTLP scale,
+// but the bucket of index i of a native histogram is the one of index i-1 in OTLP, both bounded by base^i.
+func exponentialHistogramPoint(pm *dto.Metric, startNano, nowNano string) (exponentialHistogramDataPoint, error) {
+	h := pm.GetHistogram()
+	if h.GetSampleCountFloat() > 0 || h.GetZeroCountFloat() > 0 || len(h.GetPositiveCount()) > 0 || len(h.GetNegativeCount()) > 0 {
+		return exponentialHistogramDataPoint{}, fmt.Errorf("%w: float native histogram", errUnsupportedFamily)
+	}
+
+	dp := exponentialHistogramDataPoint{Attributes: labels(pm), StartTimeUnixNano: startNano, TimeUnixNano: nowNano,
+		Count: strconv.FormatUint(h.GetSampleCount(), 10), Sum: h.GetSampleSum(), Scale: h.GetSchema(),
+		ZeroCount: strconv.FormatUint(h.GetZeroCount(), 10), ZeroThreshold: h.GetZeroThreshold()}
+
+	dp.Positive = nativeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta())
+	dp.Negative = nativeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta())
+
+	return dp, nil
+}
+
+// nativeBuckets returns the consecutive buckets of the spans and delta encoded counts of a native histogram, the
+// buckets between the spans being empty.
+func nativeBuckets(spans []*dto.BucketSpan, deltas []int64) buckets {
+	var (
+		b           buckets
+		index, next int32
+		count       int64
+	)
+
+	for i, span := range spans {
+		index += span.GetOffset()
+		if i == 0 {
+			b.Offset = index - 1
+		} else {
+			for ; next < index; next++ {
+				b.BucketCounts = append(b.BucketCounts, "0")
+			}
+		}
+
+		for j := uint32(0); j < span.GetLength() && len(deltas) > 0; j++ {
+			count += deltas[0]
+			deltas = deltas[1:]
+			b.BucketCounts = append(b.BucketCounts, strconv.FormatInt(count, 10))
+		}
+
+		index += int32(span.GetLength())
+		next = index
+	}
+
+	return b
+}
+
+func labels(pm *dto.Metric) []keyValue {
+	kvs := make([]keyValue, 0, len(pm.GetLabel()))
+	for _, lp := range pm.GetLabel() {
+		kvs = append(kvs, keyValue{Key: lp.GetName(), Value: stringValue(lp.GetValue())})
+	}
+
+	return kvs
+}
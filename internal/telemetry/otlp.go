@@ -0,0 +1,142 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody bounds the body of a rejected export kept in the error.
+const maxErrorBody = 512
+
+// The types below are the subset of the OTLP/HTTP JSON encoding sent by the exporters. As in the protobuf JSON
+// mapping, 64-bit integers are encoded as strings.
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// client posts the export requests to the OTLP/HTTP endpoint of a collector.
+type client struct {
+	endpoint   string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// post sends body to the path of the signal, e.g. /v1/metrics.
+func (c *client) post(ctx context.Context, path string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode export request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export to %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("failed to export to %s: status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+func stringValue(s string) anyValue {
+	return anyValue{StringValue: &s}
+}
+
+// value returns the attribute value of v, a value encoded by a zapcore.MapObjectEncoder. Values without an OTLP
+// counterpart are sent as their JSON or their string.
+func value(v any) anyValue {
+	switch v := v.(type) {
+	case string:
+		return stringValue(v)
+	case bool:
+		return anyValue{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case int32:
+		return intValue(int64(v))
+	case uint64:
+		return stringValue(strconv.FormatUint(v, 10))
+	case float64:
+		return anyValue{DoubleValue: &v}
+	case float32:
+		f := float64(v)
+		return anyValue{DoubleValue: &f}
+	case time.Duration:
+		return stringValue(v.String())
+	case time.Time:
+		return stringValue(v.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return stringValue(v.String())
+	}
+
+	if b, err := json.Marshal(v); err == nil {
+		return stringValue(string(b))
+	}
+
+	return stringValue(fmt.Sprint(v))
+}
+
+func intValue(i int64) anyValue {
+	s := strconv.FormatInt(i, 10)
+	return anyValue{IntValue: &s}
+}
+
+// attributes returns the attributes of m, sorted by key.
+func attributes[V any](m map[string]V, conv func(V) anyValue) []keyValue {
+	kvs := make([]keyValue, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, keyValue{Key: k, Value: conv(v)})
+	}
+
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+
+	return kvs
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
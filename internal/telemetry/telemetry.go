@@ -0,0 +1,232 @@
+// Package telemetry exports the metrics and logs of the service to an OpenTelemetry collector, over OTLP/HTTP with
+// the JSON encoding. Metrics are gathered from the Prometheus registry and sent as cumulative sums, gauges and
+// histograms at an interval, native histograms as exponential histograms. Log entries are queued by a zapcore.Core
+// teed with the core of the logger and sent in batches. Both are flushed on shutdown.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+)
+
+// Defaults of the export.
+const (
+	defaultTimeout         = 10 * time.Second
+	defaultMetricsInterval = 30 * time.Second
+	defaultLogsInterval    = 5 * time.Second
+	defaultBatchSize       = 512
+	defaultServiceName     = "skeleton-go-api"
+	// queueBatches is the number of batches of log entries queued while the collector is unreachable, beyond which
+	// entries are dropped.
+	queueBatches = 4
+)
+
+// scopeName is the instrumentation scope of the exported metrics and logs.
+const scopeName = "github.com/twk/skeleton-go-api"
+
+var (
+	// ErrNoGatherer is returned when metrics are exported without a Prometheus registry to gather them from.
+	ErrNoGatherer = errors.New("telemetry metrics require the prometheus metrics backend")
+	errNoEndpoint = errors.New("telemetry endpoint is required")
+)
+
+// Telemetry exports the metrics and logs.
+type Telemetry struct {
+	cfg      *Config
+	client   *client
+	resource resource
+	gatherer gatherer
+	// log reports the failed exports. It must not be teed with the core of the logs.
+	log   *logger.Logger
+	start time.Time
+	rand  func() float64
+
+	mu      sync.Mutex
+	queue   []logRecord
+	dropped int
+	// rejected holds the names of the metric families which cannot be exported, reported once.
+	rejected map[string]bool
+	// full is signalled once a batch of log entries is queued.
+	full chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates the exporter of cfg, gathering the metrics from g, which may be nil if metrics are not exported, and
+// starts exporting. Failed exports are logged to l, which must not be teed with Core.
+func New(cfg *Config, g gatherer, l *logger.Logger) (*Telemetry, error) {
+	if cfg.Endpoint == "" {
+		return nil, errNoEndpoint
+	}
+
+	if cfg.Metrics.Enabled && g == nil {
+		return nil, ErrNoGatherer
+	}
+
+	if cfg.Sampling < 0 || cfg.Sampling > 1 {
+		return nil, fmt.Errorf("telemetry sampling %g is not between 0 and 1", cfg.Sampling)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	attrs := map[string]string{"service.name": serviceName}
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+
+	t := &Telemetry{
+		cfg:      cfg,
+		client:   &client{endpoint: strings.TrimSuffix(cfg.Endpoint, "/"), headers: cfg.Headers, httpClient: &http.Client{Timeout: timeout}},
+		resource: resource{Attributes: attributes(attrs, stringValue)},
+		gatherer: g,
+		log:      l,
+		start:    time.Now(),
+		rand:     rand.Float64, //nolint:gosec // sampling logs does not need a secure source
+		rejected: map[string]bool{},
+		full:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Shutdown stops the exports and flushes the metrics and the queued log entries until ctx is done.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	close(t.stop)
+
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // wrapped by the lifecycle
+	}
+
+	var errs []error
+
+	if t.cfg.Metrics.Enabled {
+		errs = append(errs, t.exportMetrics(ctx))
+	}
+
+	errs = append(errs, t.exportLogs(ctx, t.takeQueue()))
+
+	return errors.Join(errs...)
+}
+
+// run exports the metrics and the logs at their intervals, and the logs once a batch is queued, until stopped.
+func (t *Telemetry) run() {
+	defer close(t.done)
+
+	metricsTick := ticker(t.cfg.Metrics, defaultMetricsInterval)
+	logsTick := ticker(t.cfg.Logs, defaultLogsInterval)
+
+	defer metricsTick.Stop()
+	defer logsTick.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-metricsTick.C:
+			t.export("metrics", t.exportMetrics)
+		case <-logsTick.C:
+			t.export("logs", t.flushLogs)
+		case <-t.full:
+			t.export("logs", t.flushLogs)
+		}
+	}
+}
+
+// ticker returns the ticker of the exports of sig, which never ticks if sig is disabled.
+func ticker(sig Signal, def time.Duration) *time.Ticker {
+	interval := sig.Interval
+	if interval <= 0 {
+		interval = def
+	}
+
+	tk := time.NewTicker(interval)
+	if !sig.Enabled {
+		tk.Stop()
+	}
+
+	return tk
+}
+
+func (t *Telemetry) export(signal string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.client.httpClient.Timeout)
+	defer cancel()
+
+	if err := fn(ctx); err != nil {
+		t.log.Warn("Telemetry export failed", zap.String("signal", signal), zap.Error(err))
+	}
+}
+
+func (t *Telemetry) flushLogs(ctx context.Context) error {
+	return t.exportLogs(ctx, t.takeQueue())
+}
+
+// enqueue queues a log entry for export, dropping it if the queue is full.
+func (t *Telemetry) enqueue(r logRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.queue) >= queueBatches*t.batchSize() {
+		t.dropped++
+		return
+	}
+
+	t.queue = append(t.queue, r)
+
+	if len(t.queue) >= t.batchSize() {
+		select {
+		case t.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// takeQueue returns the queued log entries, emptying the queue, and logs the entries dropped since the last export.
+func (t *Telemetry) takeQueue() []logRecord {
+	t.mu.Lock()
+	records, dropped := t.queue, t.dropped
+	t.queue, t.dropped = nil, 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		t.log.Warn("Telemetry log entries dropped", zap.Int("dropped", dropped))
+	}
+
+	return records
+}
+
+func (t *Telemetry) batchSize() int {
+	if t.cfg.Logs.BatchSize > 0 {
+		return t.cfg.Logs.BatchSize
+	}
+
+	return defaultBatchSize
+}
+
+// sampled reports whether to export a log entry below warn level.
+func (t *Telemetry) sampled() bool {
+	return t.cfg.Sampling == 0 || t.cfg.Sampling == 1 || t.rand() < t.cfg.Sampling
+}
@@ -0,0 +1,230 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/telemetry"
+)
+
+// collector records the export requests by path.
+type collector struct {
+	mu       sync.Mutex
+	requests map[string][]map[string]any
+	headers  http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[r.URL.Path] = append(c.requests[r.URL.Path], body)
+	c.headers = r.Header.Clone()
+}
+
+func (c *collector) last(path string) map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if reqs := c.requests[path]; len(reqs) > 0 {
+		return reqs[len(reqs)-1]
+	}
+
+	return nil
+}
+
+func TestTelemetry(t *testing.T) {
+	t.Parallel()
+
+	col := &collector{requests: map[string][]map[string]any{}}
+	srv := httptest.NewServer(col)
+	t.Cleanup(srv.Close)
+
+	mr := metrics.New()
+	mr.Inc("test_requests_total", metrics.Labels{"route": "/photos/:id"})
+	mr.SetBuckets("test_latency_seconds", []float64{0.1, 1})
+	mr.Observe("test_latency_seconds", 0.05, nil)
+	mr.Observe("test_latency_seconds", 5, nil)
+
+	cfg := &telemetry.Config{
+		Endpoint:           srv.URL,
+		Headers:            map[string]string{"X-Api-Key": "secret"},
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+		Metrics:            telemetry.Signal{Enabled: true, Interval: time.Hour},
+		Logs:               telemetry.Signal{Enabled: true, Interval: time.Hour},
+	}
+
+	tel, err := telemetry.New(cfg, mr, logger.NewNop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	l := logger.NewNop()
+	l.Tee(tel.Core(zapcore.DebugLevel))
+	l.With(zap.String("component", "test")).Warn("Upstream slow", zap.Int("attempt", 2))
+
+	assert.NoError(t, tel.Shutdown(context.Background()))
+
+	assert.Equal(t, "secret", col.headers.Get("X-Api-Key"))
+
+	rm := col.last("/v1/metrics")["resourceMetrics"].([]any)[0].(map[string]any)
+	assert.Contains(t, rm["resource"].(map[string]any)["attributes"], map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "skeleton-go-api"}})
+	assert.Contains(t, rm["resource"].(map[string]any)["attributes"], map[string]any{"key": "deployment.environment", "value": map[string]any{"stringValue": "test"}})
+
+	byName := map[string]map[string]any{}
+	for _, m := range rm["scopeMetrics"].([]any)[0].(map[string]any)["metrics"].([]any) {
+		byName[m.(map[string]any)["name"].(string)] = m.(map[string]any)
+	}
+
+	counter := byName["test_requests_total"]["sum"].(map[string]any)
+	assert.Equal(t, true, counter["isMonotonic"])
+	assert.Equal(t, 1.0, counter["dataPoints"].([]any)[0].(map[string]any)["asDouble"])
+
+	hist := byName["test_latency_seconds"]["histogram"].(map[string]any)["dataPoints"].([]any)[0].(map[string]any)
+	assert.Equal(t, "2", hist["count"])
+	assert.Equal(t, []any{"1", "0", "1"}, hist["bucketCounts"])
+	assert.Equal(t, []any{0.1, 1.0}, hist["explicitBounds"])
+
+	rl := col.last("/v1/logs")["resourceLogs"].([]any)[0].(map[string]any)
+	record := rl["scopeLogs"].([]any)[0].(map[string]any)["logRecords"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{"stringValue": "Upstream slow"}, record["body"])
+	assert.Equal(t, 13.0, record["severityNumber"])
+	assert.Equal(t, "WARN", record["severityText"])
+	assert.Equal(t, []any{
+		map[string]any{"key": "attempt", "value": map[string]any{"intValue": "2"}},
+		map[string]any{"key": "component", "value": map[string]any{"stringValue": "test"}},
+	}, record["attributes"])
+}
+
+// withFamilies gathers the metrics of a registry along with extra families.
+type withFamilies struct {
+	*metrics.Registry
+	extra []*dto.MetricFamily
+}
+
+func (g withFamilies) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.Registry.Gather()
+	return append(mfs, g.extra...), err
+}
+
+func TestTelemetry_Histograms(t *testing.T) {
+	t.Parallel()
+
+	col := &collector{requests: map[string][]map[string]any{}}
+	srv := httptest.NewServer(col)
+	t.Cleanup(srv.Close)
+
+	mr := metrics.New(metrics.WithNativeHistograms())
+	for _, v := range []float64{0, 1, 2} {
+		mr.Observe("test_native_seconds", v, nil)
+	}
+
+	gaugeHistogram := dto.MetricType_GAUGE_HISTOGRAM
+	name := "test_queue_age_seconds"
+	g := withFamilies{Registry: mr, extra: []*dto.MetricFamily{{Name: &name, Type: &gaugeHistogram, Metric: []*dto.Metric{{Histogram: &dto.Histogram{}}}}}}
+
+	core, logs := observer.New(zap.DebugLevel)
+	cfg := &telemetry.Config{Endpoint: srv.URL, Metrics: telemetry.Signal{Enabled: true, Interval: 10 * time.Millisecond}}
+
+	tel, err := telemetry.New(cfg, g, &logger.Logger{Logger: zap.New(core)})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Eventually(t, func() bool {
+		col.mu.Lock()
+		defer col.mu.Unlock()
+
+		return len(col.requests["/v1/metrics"]) > 1
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, tel.Shutdown(context.Background()))
+
+	byName := map[string]map[string]any{}
+	rm := col.last("/v1/metrics")["resourceMetrics"].([]any)[0].(map[string]any)
+	for _, m := range rm["scopeMetrics"].([]any)[0].(map[string]any)["metrics"].([]any) {
+		byName[m.(map[string]any)["name"].(string)] = m.(map[string]any)
+	}
+
+	// The gauge histogram is left out and reported once, however many exports ran.
+	assert.NotContains(t, byName, name)
+
+	rejected := logs.FilterMessage("Metric not exported").AllUntimed()
+	if assert.Len(t, rejected, 1) {
+		assert.Equal(t, name, rejected[0].ContextMap()["metric"])
+	}
+
+	// Schema 3 has 8 buckets per power of two: 1 falls in the native bucket 0 and 2 in the bucket 8, which are the
+	// OTLP buckets -1 and 7.
+	exp := byName["test_native_seconds"]["exponentialHistogram"].(map[string]any)
+	assert.Equal(t, 2.0, exp["aggregationTemporality"])
+
+	dp := exp["dataPoints"].([]any)[0].(map[string]any)
+	assert.Equal(t, "3", dp["count"])
+	assert.Equal(t, 3.0, dp["sum"])
+	assert.Equal(t, 3.0, dp["scale"])
+	assert.Equal(t, "1", dp["zeroCount"])
+	assert.Equal(t, map[string]any{"offset": -1.0, "bucketCounts": []any{"1", "0", "0", "0", "0", "0", "0", "0", "1"}}, dp["positive"])
+	assert.Equal(t, map[string]any{"offset": 0.0}, dp["negative"])
+}
+
+func TestTelemetry_Batch(t *testing.T) {
+	t.Parallel()
+
+	col := &collector{requests: map[string][]map[string]any{}}
+	srv := httptest.NewServer(col)
+	t.Cleanup(srv.Close)
+
+	tel, err := telemetry.New(&telemetry.Config{Endpoint: srv.URL, Logs: telemetry.Signal{Enabled: true, Interval: time.Hour, BatchSize: 2}}, nil, logger.NewNop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Cleanup(func() { _ = tel.Shutdown(context.Background()) })
+
+	l := logger.NewNop()
+	l.Tee(tel.Core(zapcore.DebugLevel))
+	l.Info("first")
+	l.Info("second")
+
+	assert.Eventually(t, func() bool { return col.last("/v1/logs") != nil }, time.Second, 10*time.Millisecond)
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]*telemetry.Config{
+		"no endpoint":              {Logs: telemetry.Signal{Enabled: true}},
+		"metrics without registry": {Endpoint: "http://127.0.0.1:4318", Metrics: telemetry.Signal{Enabled: true}},
+		"invalid sampling":         {Endpoint: "http://127.0.0.1:4318", Sampling: 2},
+	}
+
+	for name, cfg := range tests {
+		cfg := cfg
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := telemetry.New(cfg, nil, logger.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/storage"
+)
+
+// BlobStore scopes a storage.BlobStore to the tenant of the context: the keys of each tenant are stored under
+// "tenants/<id>/", so a tenant can never read or overwrite the objects of another one.
+type BlobStore struct {
+	next storage.BlobStore
+}
+
+// NewBlobStore creates a BlobStore scoping next.
+func NewBlobStore(next storage.BlobStore) *BlobStore {
+	return &BlobStore{next: next}
+}
+
+func scopedKey(ctx context.Context, key string) (string, error) {
+	id, err := Require(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return "tenants/" + id + "/" + key, nil
+}
+
+// Put implements storage.BlobStore.
+func (b *BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	k, err := scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return b.next.Put(ctx, k, r, size, contentType) //nolint:wrapcheck // the scope is transparent
+}
+
+// Get implements storage.BlobStore.
+func (b *BlobStore) Get(ctx context.Context, key string) (*storage.Object, error) {
+	k, err := scopedKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.next.Get(ctx, k) //nolint:wrapcheck // the scope is transparent
+}
+
+// Delete implements storage.BlobStore.
+func (b *BlobStore) Delete(ctx context.Context, key string) error {
+	k, err := scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return b.next.Delete(ctx, k) //nolint:wrapcheck // the scope is transparent
+}
+
+// PresignGet implements storage.BlobStore.
+func (b *BlobStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	k, err := scopedKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return b.next.PresignGet(ctx, k, ttl) //nolint:wrapcheck // the scope is transparent
+}
+
+// Transport is an http.RoundTripper scoping the upstream requests to the tenant of their context, sent in a header
+// for the upstream to filter its data by. Requests without a tenant fail with ErrMissing.
+type Transport struct {
+	header string
+	next   http.RoundTripper
+}
+
+// NewTransport creates a Transport sending the tenant in header and delegating to next.
+func NewTransport(header string, next http.RoundTripper) *Transport {
+	return &Transport{header: header, next: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, err := Require(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("upstream request %s: %w", req.URL.Path, err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, id)
+
+	return t.next.RoundTrip(req) //nolint:wrapcheck // the scope is transparent
+}
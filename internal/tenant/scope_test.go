@@ -0,0 +1,79 @@
+package tenant_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/storage"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+func TestBlobStore(t *testing.T) {
+	t.Parallel()
+
+	local, err := storage.NewLocal(&config.LocalStorage{Root: t.TempDir(), BaseURL: "http://localhost:8080", SigningKey: "secret"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	bs := tenant.NewBlobStore(local)
+	acme := tenant.ContextWithTenant(context.Background(), "acme")
+	globex := tenant.ContextWithTenant(context.Background(), "globex")
+
+	assert.NoError(t, bs.Put(acme, "photos/1/image", strings.NewReader("png"), 3, "image/png"))
+
+	obj, err := local.Get(context.Background(), "tenants/acme/photos/1/image")
+	if assert.NoError(t, err, "stored under the prefix of the tenant") {
+		obj.Body.Close()
+	}
+
+	obj, err = bs.Get(acme, "photos/1/image")
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(obj.Body)
+		obj.Body.Close()
+		assert.Equal(t, "png", string(b))
+	}
+
+	_, err = bs.Get(globex, "photos/1/image")
+	assert.ErrorIs(t, err, storage.ErrNotFound, "other tenants do not see the object")
+
+	_, err = bs.Get(context.Background(), "photos/1/image")
+	assert.ErrorIs(t, err, tenant.ErrMissing)
+
+	_, err = bs.PresignGet(context.Background(), "photos/1/image", 0)
+	assert.ErrorIs(t, err, tenant.ErrMissing)
+
+	assert.ErrorIs(t, bs.Delete(context.Background(), "photos/1/image"), tenant.ErrMissing)
+	assert.NoError(t, bs.Delete(acme, "photos/1/image"))
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Tenant")) //nolint:errcheck // test server
+	}))
+	t.Cleanup(upstream.Close)
+
+	c := &http.Client{Transport: tenant.NewTransport("X-Tenant", upstream.Client().Transport)}
+
+	req, _ := http.NewRequestWithContext(tenant.ContextWithTenant(context.Background(), "acme"), http.MethodGet, upstream.URL, http.NoBody)
+
+	resp, err := c.Do(req)
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "acme", string(b))
+	}
+
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, upstream.URL, http.NoBody)
+
+	_, err = c.Do(req)
+	assert.ErrorIs(t, err, tenant.ErrMissing)
+}
@@ -0,0 +1,257 @@
+// Package tenant resolves the tenant of each request and scopes the data access to it. In single-tenant mode every
+// request belongs to the default tenant. In multi-tenant mode the tenant is resolved from a header, the subdomain or a
+// claim of the token of the consumer, and the data layers fail with ErrMissing when a request has none, so that no
+// data is ever read or written outside of a tenant.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/twk/skeleton-go-api/internal/apperror"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Modes of the configuration.
+const (
+	ModeSingle = "single"
+	ModeMulti  = "multi"
+)
+
+// Sources of the tenant in multi-tenant mode.
+const (
+	SourceHeader    = "header"
+	SourceSubdomain = "subdomain"
+	SourceClaim     = "claim"
+)
+
+// Defaults of the configuration.
+const (
+	DefaultTenant = "default"
+	DefaultHeader = "X-Tenant-ID"
+	DefaultClaim  = "tenant"
+)
+
+// maxIDLength bounds the length of tenant IDs, which must fit a DNS label.
+const maxIDLength = 63
+
+var (
+	// ErrMissing is returned by the data layers for requests without a tenant in multi-tenant mode.
+	ErrMissing = errors.New("tenant required")
+	// ErrInvalid is returned for tenant IDs which are not lowercase letters, digits and dashes.
+	ErrInvalid = errors.New("invalid tenant")
+	// ErrForbidden is returned for tenants which are not allowed, or when the sources of a request disagree.
+	ErrForbidden = errors.New("tenant not allowed")
+)
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx belonging to tenant id.
+func ContextWithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant of ctx, empty if none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+// Require returns the tenant of ctx, or ErrMissing.
+func Require(ctx context.Context) (string, error) {
+	id := FromContext(ctx)
+	if id == "" {
+		return "", ErrMissing
+	}
+
+	return id, nil
+}
+
+// Resolver resolves the tenant of the requests.
+type Resolver struct {
+	multi         bool
+	defaultTenant string
+	sources       []string
+	header        string
+	domain        string
+	claim         string
+	tenants       []string
+}
+
+// New creates a Resolver from cfg.
+func New(cfg *config.Tenancy) (*Resolver, error) {
+	r := &Resolver{
+		defaultTenant: cfg.DefaultTenant,
+		sources:       cfg.Sources,
+		header:        cfg.Header,
+		domain:        strings.ToLower(strings.Trim(cfg.Domain, ".")),
+		claim:         cfg.Claim,
+		tenants:       cfg.Tenants,
+	}
+
+	switch cfg.Mode {
+	case "", ModeSingle:
+	case ModeMulti:
+		r.multi = true
+	default:
+		return nil, fmt.Errorf("unknown tenancy mode %q", cfg.Mode)
+	}
+
+	if r.defaultTenant == "" {
+		r.defaultTenant = DefaultTenant
+	}
+
+	if r.header == "" {
+		r.header = DefaultHeader
+	}
+
+	if r.claim == "" {
+		r.claim = DefaultClaim
+	}
+
+	for _, s := range r.sources {
+		switch s {
+		case SourceHeader, SourceClaim:
+		case SourceSubdomain:
+			if r.domain == "" {
+				return nil, errors.New("the subdomain tenant source requires a domain")
+			}
+		default:
+			return nil, fmt.Errorf("unknown tenant source %q", s)
+		}
+	}
+
+	if r.multi && len(r.sources) == 0 {
+		return nil, errors.New("multi-tenant mode requires tenant sources")
+	}
+
+	return r, nil
+}
+
+// Multi reports whether the Resolver runs in multi-tenant mode.
+func (r *Resolver) Multi() bool {
+	return r.multi
+}
+
+// Default returns the tenant of every request in single-tenant mode.
+func (r *Resolver) Default() string {
+	return r.defaultTenant
+}
+
+// Header returns the request header carrying the tenant.
+func (r *Resolver) Header() string {
+	return r.header
+}
+
+// Middleware attaches the tenant of the request to its context. It runs after the authentication of the route, for
+// the claim source. Requests whose sources disagree, or naming a tenant which is not allowed, are rejected with 403
+// and malformed tenants with 400. Requests without a tenant are served, the data layers rejecting them if they access
+// data.
+func (r *Resolver) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := r.resolve(c.Request)
+		if err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrInvalid) {
+				status = http.StatusBadRequest
+			}
+
+			c.Error(apperror.Validation(err)) //nolint:errcheck // returns the same error
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		if id != "" {
+			c.Request = c.Request.WithContext(ContextWithTenant(c.Request.Context(), id))
+		}
+
+		c.Next()
+	}
+}
+
+// resolve returns the tenant of req, empty if none. Every source present must name the same tenant.
+func (r *Resolver) resolve(req *http.Request) (string, error) {
+	if !r.multi {
+		return r.defaultTenant, nil
+	}
+
+	id := ""
+
+	for _, s := range r.sources {
+		v := r.source(req, s)
+		if v == "" {
+			continue
+		}
+
+		if !valid(v) {
+			return "", ErrInvalid
+		}
+
+		if id != "" && v != id {
+			return "", ErrForbidden
+		}
+
+		id = v
+	}
+
+	if id != "" && len(r.tenants) > 0 && !slices.Contains(r.tenants, id) {
+		return "", ErrForbidden
+	}
+
+	return id, nil
+}
+
+func (r *Resolver) source(req *http.Request, s string) string {
+	switch s {
+	case SourceHeader:
+		return req.Header.Get(r.header)
+	case SourceSubdomain:
+		host, _, err := net.SplitHostPort(req.Host)
+		if err != nil {
+			host = req.Host
+		}
+
+		sub, ok := strings.CutSuffix(strings.ToLower(host), "."+r.domain)
+		if !ok || strings.Contains(sub, ".") {
+			return ""
+		}
+
+		return sub
+	case SourceClaim:
+		id := auth.IdentityFromContext(req.Context())
+		if id == nil {
+			return ""
+		}
+
+		v, _ := id.Claims[r.claim].(string)
+
+		return v
+	default:
+		return ""
+	}
+}
+
+// valid reports whether id is a valid tenant ID: lowercase letters, digits and dashes, starting with a letter or a
+// digit, like a DNS label.
+func valid(id string) bool {
+	if len(id) > maxIDLength || id[0] == '-' {
+		return false
+	}
+
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+package tenant_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/tenant"
+)
+
+func TestResolver_Middleware(t *testing.T) {
+	t.Parallel()
+
+	multi := config.Tenancy{
+		Mode:    tenant.ModeMulti,
+		Sources: []string{tenant.SourceHeader, tenant.SourceSubdomain, tenant.SourceClaim},
+		Domain:  "api.example.com",
+		Tenants: []string{"acme", "globex"},
+	}
+
+	type args struct {
+		cfg    config.Tenancy
+		host   string
+		header string
+		claims map[string]any
+	}
+
+	type want struct {
+		code int
+		body string
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"single tenant": {
+			args: args{cfg: config.Tenancy{}, header: "acme"},
+			want: want{code: http.StatusOK, body: "default"},
+		},
+		"header": {
+			args: args{cfg: multi, header: "acme"},
+			want: want{code: http.StatusOK, body: "acme"},
+		},
+		"subdomain": {
+			args: args{cfg: multi, host: "globex.api.example.com:8080"},
+			want: want{code: http.StatusOK, body: "globex"},
+		},
+		"claim": {
+			args: args{cfg: multi, claims: map[string]any{"tenant": "acme"}},
+			want: want{code: http.StatusOK, body: "acme"},
+		},
+		"sources agree": {
+			args: args{cfg: multi, host: "acme.api.example.com", header: "acme", claims: map[string]any{"tenant": "acme"}},
+			want: want{code: http.StatusOK, body: "acme"},
+		},
+		"header disagrees with claim": {
+			args: args{cfg: multi, header: "globex", claims: map[string]any{"tenant": "acme"}},
+			want: want{code: http.StatusForbidden, body: `{"error":"tenant not allowed"}`},
+		},
+		"not allowed": {
+			args: args{cfg: multi, header: "initech"},
+			want: want{code: http.StatusForbidden, body: `{"error":"tenant not allowed"}`},
+		},
+		"invalid": {
+			args: args{cfg: multi, header: "../acme"},
+			want: want{code: http.StatusBadRequest, body: `{"error":"invalid tenant"}`},
+		},
+		"none": {
+			args: args{cfg: multi, host: "api.example.com"},
+			want: want{code: http.StatusOK, body: ""},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := tenant.New(&tt.args.cfg)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			router := gin.New()
+			router.GET("/photos", func(c *gin.Context) {
+				if tt.args.claims != nil {
+					c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), &auth.Identity{Subject: "alice", Claims: tt.args.claims}))
+				}
+			}, r.Middleware(), func(c *gin.Context) {
+				c.String(http.StatusOK, tenant.FromContext(c.Request.Context()))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/photos", http.NoBody)
+			if tt.args.host != "" {
+				req.Host = tt.args.host
+			}
+
+			if tt.args.header != "" {
+				req.Header.Set(tenant.DefaultHeader, tt.args.header)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want.code, resp.Code)
+			assert.Equal(t, tt.want.body, resp.Body.String())
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg     config.Tenancy
+		wantErr string
+	}{
+		"unknown mode": {
+			cfg:     config.Tenancy{Mode: "many"},
+			wantErr: `unknown tenancy mode "many"`,
+		},
+		"multi without sources": {
+			cfg:     config.Tenancy{Mode: tenant.ModeMulti},
+			wantErr: "multi-tenant mode requires tenant sources",
+		},
+		"subdomain without domain": {
+			cfg:     config.Tenancy{Mode: tenant.ModeMulti, Sources: []string{tenant.SourceSubdomain}},
+			wantErr: "the subdomain tenant source requires a domain",
+		},
+		"unknown source": {
+			cfg:     config.Tenancy{Mode: tenant.ModeMulti, Sources: []string{"cookie"}},
+			wantErr: `unknown tenant source "cookie"`,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tenant.New(&tt.cfg)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
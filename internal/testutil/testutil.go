@@ -0,0 +1,21 @@
+// Package testutil provides helpers shared by the tests of the other packages.
+package testutil
+
+import (
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+// NewMock creates a mock with newMock, such as mock_api.NewMockphotoService, and sets its expectations with expect.
+// The expectations are verified once t and its subtests finish.
+func NewMock[M any](t *testing.T, newMock func(ctrl *gomock.Controller) M, expect ...func(m M)) M {
+	t.Helper()
+
+	m := newMock(gomock.NewController(t))
+	for _, e := range expect {
+		e(m)
+	}
+
+	return m
+}
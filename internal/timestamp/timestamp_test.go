@@ -0,0 +1,123 @@
+package timestamp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+func fixedClock() time.Time {
+	return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func TestValidator_ValidateIssuedAt(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		issuedAt time.Time
+		maxAge   time.Duration
+	}
+
+	type want struct {
+		err error
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"now":                {args: args{issuedAt: fixedClock(), maxAge: time.Minute}},
+		"future within skew": {args: args{issuedAt: fixedClock().Add(20 * time.Second), maxAge: time.Minute}},
+		"future beyond skew": {args: args{issuedAt: fixedClock().Add(time.Minute), maxAge: time.Minute}, want: want{err: timestamp.ErrInFuture}},
+		"old within skew":    {args: args{issuedAt: fixedClock().Add(-80 * time.Second), maxAge: time.Minute}},
+		"old beyond skew":    {args: args{issuedAt: fixedClock().Add(-2 * time.Minute), maxAge: time.Minute}, want: want{err: timestamp.ErrTooOld}},
+		"no max age":         {args: args{issuedAt: fixedClock().Add(-24 * time.Hour)}},
+		"missing":            {args: args{}, want: want{err: timestamp.ErrMissing}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v := timestamp.NewValidator(30*time.Second, timestamp.WithClock(fixedClock))
+
+			err := v.ValidateIssuedAt(tt.args.issuedAt, tt.args.maxAge)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidator_MonotonicClock(t *testing.T) {
+	t.Parallel()
+
+	v := timestamp.NewValidator(0)
+	issued := time.Unix(time.Now().Unix(), 0)
+
+	assert.NoError(t, v.ValidateIssuedAt(issued, time.Minute))
+	assert.NoError(t, v.ValidateExpiry(time.Now().Add(time.Minute).Round(0)))
+}
+
+func TestValidator_ValidateWindow(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		notBefore time.Time
+		expiresAt time.Time
+	}
+
+	type want struct {
+		err error
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"inside window":          {args: args{notBefore: fixedClock().Add(-time.Minute), expiresAt: fixedClock().Add(time.Minute)}},
+		"no bounds":              {args: args{}},
+		"not before within skew": {args: args{notBefore: fixedClock().Add(10 * time.Second)}},
+		"not before beyond skew": {args: args{notBefore: fixedClock().Add(time.Minute)}, want: want{err: timestamp.ErrNotYetValid}},
+		"expired within skew":    {args: args{expiresAt: fixedClock().Add(-10 * time.Second)}},
+		"expired beyond skew":    {args: args{expiresAt: fixedClock().Add(-time.Minute)}, want: want{err: timestamp.ErrExpired}},
+		"expires exactly at end": {args: args{expiresAt: fixedClock().Add(-30 * time.Second)}, want: want{err: timestamp.ErrExpired}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v := timestamp.NewValidator(30*time.Second, timestamp.WithClock(fixedClock))
+
+			err := v.ValidateWindow(tt.args.notBefore, tt.args.expiresAt)
+			if tt.want.err != nil {
+				assert.ErrorIs(t, err, tt.want.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseUnix(t *testing.T) {
+	t.Parallel()
+
+	ts, err := timestamp.ParseUnix("1709294400")
+	assert.NoError(t, err)
+	assert.True(t, fixedClock().Equal(ts))
+
+	_, err = timestamp.ParseUnix("yesterday")
+	assert.ErrorContains(t, err, `invalid unix timestamp "yesterday"`)
+}
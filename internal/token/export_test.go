@@ -0,0 +1,8 @@
+package token
+
+import "time"
+
+// SetClock overrides the clock used for issuing and expiring tokens.
+func SetClock(s *Service, now func() time.Time) {
+	s.now = now
+}
@@ -0,0 +1,376 @@
+// Package token mints and verifies short-lived service JWTs, so the service can act as its own identity provider in
+// development: clients exchange their API key or client credentials for a token, which then authenticates their
+// requests as a Bearer token. Tokens are signed with HS256 and a shared key, or with RS256 and a private key whose
+// public key is published as a JWKS.
+package token
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/jwt"
+	"github.com/twk/skeleton-go-api/internal/secret"
+	"github.com/twk/skeleton-go-api/internal/timestamp"
+)
+
+// Signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// MethodJWT is the authentication method of identities authenticated by a token of the Service.
+const MethodJWT = "jwt"
+
+// DefaultTTL is the lifetime of tokens when none is configured.
+const DefaultTTL = 15 * time.Minute
+
+const (
+	// idSize is the number of random bytes of a token ID.
+	idSize = 16
+	// kidSize is the number of bytes of the public key hash identifying an RS256 key.
+	kidSize = 12
+)
+
+var (
+	// ErrInvalidClient is returned by Exchange for unknown clients and wrong secrets.
+	ErrInvalidClient = errors.New("invalid client")
+	// ErrInvalidScope is returned by Exchange when scopes not granted to the client are requested.
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrInvalidToken is returned by Verify for tokens which are malformed, not signed by the Service, issued for
+	// another audience or outside their validity.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Credentials authenticate a client exchanging them for a token, either an API key or a client ID and secret.
+type Credentials struct {
+	APIKey       string
+	ClientID     string
+	ClientSecret string
+}
+
+// Token is a minted token.
+type Token struct {
+	// Subject is the ID of the client the token was issued to.
+	Subject     string
+	AccessToken string
+	ExpiresAt   time.Time
+	Scopes      []string
+}
+
+// Service mints and verifies tokens.
+type Service struct {
+	alg      string
+	key      []byte
+	private  *rsa.PrivateKey
+	kid      string
+	issuer   string
+	audience string
+	ttl      time.Duration
+	clients  []auth.TokenClient
+	now      func() time.Time
+	validate *timestamp.Validator
+}
+
+// New creates a Service from cfg.
+func New(cfg *auth.TokenConfig) (*Service, error) {
+	s := &Service{
+		alg:      strings.ToUpper(cfg.Algorithm),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.TTL,
+		clients:  cfg.Clients,
+		now:      time.Now,
+	}
+	s.validate = timestamp.NewValidator(cfg.ClockSkew, timestamp.WithClock(func() time.Time { return s.now() }))
+
+	if s.ttl <= 0 {
+		s.ttl = DefaultTTL
+	}
+
+	switch s.alg {
+	case "", HS256:
+		if cfg.SigningKey == "" {
+			return nil, errors.New("HS256 tokens require a signing key")
+		}
+
+		if err := secret.CheckKey(cfg.SigningKey); err != nil {
+			return nil, fmt.Errorf("invalid token signing key: %w", err)
+		}
+
+		s.alg, s.key = HS256, []byte(cfg.SigningKey)
+	case RS256:
+		private, err := loadPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(private.N.Bytes())
+		s.private, s.kid = private, base64.RawURLEncoding.EncodeToString(sum[:kidSize])
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q", cfg.Algorithm)
+	}
+
+	return s, nil
+}
+
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key in %s", path)
+	}
+
+	pkcs1, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err == nil {
+		return pkcs1, nil
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	private, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s is not an RSA key", path)
+	}
+
+	return private, nil
+}
+
+// Exchange mints a token for the client authenticated by creds, with the requested scopes or all the scopes of the
+// client if none are requested.
+func (s *Service) Exchange(creds Credentials, scopes []string) (*Token, error) {
+	cl := s.client(creds)
+	if cl == nil {
+		return nil, ErrInvalidClient
+	}
+
+	if len(scopes) == 0 {
+		scopes = cl.Scopes
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(cl.Scopes, scope) {
+			return nil, fmt.Errorf("%w: %s is not granted to %s", ErrInvalidScope, scope, cl.ID)
+		}
+	}
+
+	claims := maps.Clone(cl.Claims)
+	if claims == nil {
+		claims = map[string]any{}
+	}
+
+	if len(scopes) > 0 {
+		claims["scope"] = strings.Join(scopes, " ")
+	}
+
+	if len(cl.Groups) > 0 {
+		claims["groups"] = cl.Groups
+	}
+
+	raw, expiresAt, err := s.Mint(cl.ID, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{Subject: cl.ID, AccessToken: raw, ExpiresAt: expiresAt, Scopes: scopes}, nil
+}
+
+// client returns the configured client of creds, or nil.
+func (s *Service) client(creds Credentials) *auth.TokenClient {
+	for i := range s.clients {
+		cl := &s.clients[i]
+
+		switch {
+		case creds.APIKey != "" && cl.APIKey != "":
+			if subtle.ConstantTimeCompare([]byte(creds.APIKey), []byte(cl.APIKey)) == 1 {
+				return cl
+			}
+		case creds.ClientID != "" && creds.ClientID == cl.ID && cl.Secret != "":
+			if subtle.ConstantTimeCompare([]byte(creds.ClientSecret), []byte(cl.Secret)) == 1 {
+				return cl
+			}
+		}
+	}
+
+	return nil
+}
+
+// Mint returns a token for subject with the given extra claims, and its expiry. The registered claims iss, sub, aud,
+// iat, exp and jti are set by the Service.
+func (s *Service) Mint(subject string, extra map[string]any) (string, time.Time, error) {
+	id := make([]byte, idSize)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	now := s.now()
+	expiresAt := now.Add(s.ttl)
+
+	claims := maps.Clone(extra)
+	if claims == nil {
+		claims = map[string]any{}
+	}
+
+	claims["iss"] = s.issuer
+	claims["sub"] = subject
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	claims["jti"] = base64.RawURLEncoding.EncodeToString(id)
+
+	if s.audience != "" {
+		claims["aud"] = s.audience
+	}
+
+	header := map[string]string{"alg": s.alg, "typ": "JWT"}
+	if s.kid != "" {
+		header["kid"] = s.kid
+	}
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to marshal token header: %w", err)
+	}
+
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to marshal token claims: %w", err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+
+	sig, err := s.sign(signed)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), expiresAt.UTC(), nil
+}
+
+func (s *Service) sign(signed string) ([]byte, error) {
+	if s.alg == HS256 {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write([]byte(signed))
+
+		return mac.Sum(nil), nil
+	}
+
+	digest := sha256.Sum256([]byte(signed))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.private, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return sig, nil
+}
+
+// Verify returns the claims of a token minted by the Service, for its audience if it has one, within the validity of
+// its exp, nbf and iat claims.
+func (s *Service) Verify(raw string) (map[string]any, error) {
+	t, err := jwt.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if t.Header.Alg != s.alg || !s.validSignature(t.Signed, t.Signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	if iss := t.Claims.String("iss"); iss != s.issuer {
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidToken, iss)
+	}
+
+	if s.audience != "" && !t.Claims.HasAudience(s.audience) {
+		return nil, fmt.Errorf("%w: not issued for this audience", ErrInvalidToken)
+	}
+
+	if err := t.Claims.ValidateTimes(s.validate); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return t.Claims, nil
+}
+
+func (s *Service) validSignature(signed string, sig []byte) bool {
+	if s.alg == HS256 {
+		want, _ := s.sign(signed)
+		return hmac.Equal(sig, want)
+	}
+
+	digest := sha256.Sum256([]byte(signed))
+
+	return rsa.VerifyPKCS1v15(&s.private.PublicKey, crypto.SHA256, digest[:], sig) == nil
+}
+
+// Authenticate implements auth.Authenticator with Bearer tokens minted by the Service. The groups and scopes of the
+// Identity are those of the token.
+func (s *Service) Authenticate(r *http.Request) (*auth.Identity, error) {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, auth.ErrNoCredentials
+	}
+
+	claims, err := s.Verify(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &auth.Identity{Method: MethodJWT, Claims: claims}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+
+	if scope, _ := claims["scope"].(string); scope != "" {
+		id.Scopes = strings.Fields(scope)
+	}
+
+	groups, _ := claims["groups"].([]any)
+	for _, g := range groups {
+		if group, ok := g.(string); ok {
+			id.Groups = append(id.Groups, group)
+		}
+	}
+
+	return id, nil
+}
+
+// JWKS returns the JSON Web Key Set of the public key verifying RS256 tokens, or nil for HS256 tokens whose key is
+// secret.
+func (s *Service) JWKS() map[string]any {
+	if s.private == nil {
+		return nil
+	}
+
+	return map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": RS256,
+		"kid": s.kid,
+		"n":   base64.RawURLEncoding.EncodeToString(s.private.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.private.E)).Bytes()),
+	}}}
+}
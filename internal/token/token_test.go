@@ -0,0 +1,278 @@
+package token_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/token"
+)
+
+var clients = []auth.TokenClient{
+	{ID: "reporting", Secret: "s3cret", Scopes: []string{"photos:read", "photos:write"}, Groups: []string{"services"}, Claims: map[string]any{"email": "reporting@example.com"}},
+	{ID: "dashboard", APIKey: "key-1", Scopes: []string{"photos:read"}},
+}
+
+func privateKeyFile(t *testing.T) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600))
+
+	return path
+}
+
+func TestService_Exchange(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		creds  token.Credentials
+		scopes []string
+	}
+
+	type want struct {
+		id  *auth.Identity
+		err error
+	}
+
+	tests := map[string]struct {
+		args args
+		want want
+	}{
+		"client credentials": {
+			args: args{creds: token.Credentials{ClientID: "reporting", ClientSecret: "s3cret"}},
+			want: want{id: &auth.Identity{Subject: "reporting", Email: "reporting@example.com", Groups: []string{"services"}, Scopes: []string{"photos:read", "photos:write"}, Method: token.MethodJWT}},
+		},
+		"narrowed scopes": {
+			args: args{creds: token.Credentials{ClientID: "reporting", ClientSecret: "s3cret"}, scopes: []string{"photos:read"}},
+			want: want{id: &auth.Identity{Subject: "reporting", Email: "reporting@example.com", Groups: []string{"services"}, Scopes: []string{"photos:read"}, Method: token.MethodJWT}},
+		},
+		"api key": {
+			args: args{creds: token.Credentials{APIKey: "key-1"}},
+			want: want{id: &auth.Identity{Subject: "dashboard", Scopes: []string{"photos:read"}, Method: token.MethodJWT}},
+		},
+		"wrong secret": {
+			args: args{creds: token.Credentials{ClientID: "reporting", ClientSecret: "guess"}},
+			want: want{err: token.ErrInvalidClient},
+		},
+		"unknown api key": {
+			args: args{creds: token.Credentials{APIKey: "key-2"}},
+			want: want{err: token.ErrInvalidClient},
+		},
+		"scope not granted": {
+			args: args{creds: token.Credentials{APIKey: "key-1"}, scopes: []string{"photos:write"}},
+			want: want{err: token.ErrInvalidScope},
+		},
+	}
+
+	for _, alg := range []string{token.HS256, token.RS256} {
+		s, err := token.New(&auth.TokenConfig{Algorithm: alg, SigningKey: "secret", PrivateKeyFile: privateKeyFile(t), Issuer: "skeleton", Clients: clients})
+		assert.NoError(t, err)
+
+		for name, tt := range tests {
+			tt := tt
+
+			t.Run(alg+" "+name, func(t *testing.T) {
+				t.Parallel()
+
+				tok, err := s.Exchange(tt.args.creds, tt.args.scopes)
+				if tt.want.err != nil {
+					assert.ErrorIs(t, err, tt.want.err)
+					return
+				}
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.WithinDuration(t, time.Now().Add(token.DefaultTTL), tok.ExpiresAt, time.Minute)
+
+				req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+				req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+
+				id, err := s.Authenticate(req)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.want.id.Subject, id.Claims["sub"], "the identity carries the claims of the token")
+
+				id.Claims = nil
+				assert.Equal(t, tt.want.id, id)
+			})
+		}
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	s, err := token.New(&auth.TokenConfig{SigningKey: "secret", Issuer: "skeleton", TTL: time.Minute, Clients: clients})
+	assert.NoError(t, err)
+	token.SetClock(s, func() time.Time { return now })
+
+	other, err := token.New(&auth.TokenConfig{SigningKey: "other", Issuer: "skeleton", Clients: clients})
+	assert.NoError(t, err)
+
+	valid, _, err := s.Mint("reporting", nil)
+	assert.NoError(t, err)
+
+	forged, _, err := other.Mint("reporting", nil)
+	assert.NoError(t, err)
+
+	expired, _, err := s.Mint("reporting", nil)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		header string
+		at     time.Time
+		err    error
+	}{
+		"valid":        {header: "Bearer " + valid, at: now},
+		"no token":     {err: auth.ErrNoCredentials},
+		"basic auth":   {header: "Basic cmVwb3J0aW5nOnMzY3JldA==", err: auth.ErrNoCredentials},
+		"malformed":    {header: "Bearer abc", err: token.ErrInvalidToken},
+		"other key":    {header: "Bearer " + forged, at: now, err: token.ErrInvalidToken},
+		"after expiry": {header: "Bearer " + expired, at: now.Add(time.Minute), err: token.ErrInvalidToken},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			token.SetClock(s, func() time.Time { return tt.at })
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			_, err := s.Authenticate(req)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cfg := auth.TokenConfig{SigningKey: "secret", Issuer: "skeleton", Audience: "photos", TTL: time.Minute, ClockSkew: 10 * time.Second}
+
+	tests := map[string]struct {
+		audience string
+		extra    map[string]any
+		mintedAt time.Time
+		at       time.Time
+		err      string
+	}{
+		"valid":                  {mintedAt: now, at: now},
+		"expired within skew":    {mintedAt: now, at: now.Add(time.Minute + 5*time.Second)},
+		"expired":                {mintedAt: now, at: now.Add(time.Minute + 15*time.Second), err: "invalid token: timestamp expired"},
+		"other audience":         {audience: "billing", mintedAt: now, at: now, err: "invalid token: not issued for this audience"},
+		"not yet valid":          {extra: map[string]any{"nbf": now.Add(30 * time.Second).Unix()}, mintedAt: now, at: now, err: "invalid token: timestamp not yet valid"},
+		"not before within skew": {extra: map[string]any{"nbf": now.Add(5 * time.Second).Unix()}, mintedAt: now, at: now},
+		"issued in the future":   {mintedAt: now.Add(30 * time.Second), at: now, err: "invalid token: timestamp in the future"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			minter := cfg
+			if tt.audience != "" {
+				minter.Audience = tt.audience
+			}
+
+			m, err := token.New(&minter)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			token.SetClock(m, func() time.Time { return tt.mintedAt })
+
+			raw, _, err := m.Mint("reporting", tt.extra)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			s, err := token.New(&cfg)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			token.SetClock(s, func() time.Time { return tt.at })
+
+			claims, err := s.Verify(raw)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				assert.ErrorIs(t, err, token.ErrInvalidToken)
+
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, "reporting", claims["sub"])
+			}
+		})
+	}
+}
+
+func TestService_JWKS(t *testing.T) {
+	t.Parallel()
+
+	hs, err := token.New(&auth.TokenConfig{SigningKey: "secret"})
+	assert.NoError(t, err)
+	assert.Nil(t, hs.JWKS())
+
+	rs, err := token.New(&auth.TokenConfig{Algorithm: token.RS256, PrivateKeyFile: privateKeyFile(t)})
+	assert.NoError(t, err)
+
+	keys, _ := rs.JWKS()["keys"].([]map[string]string)
+	if !assert.Len(t, keys, 1) {
+		return
+	}
+
+	assert.Equal(t, "RSA", keys[0]["kty"])
+	assert.Equal(t, token.RS256, keys[0]["alg"])
+	assert.NotEmpty(t, keys[0]["kid"])
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg *auth.TokenConfig
+		err string
+	}{
+		"hs256 without key": {cfg: &auth.TokenConfig{}, err: "HS256 tokens require a signing key"},
+		"hs256 placeholder": {cfg: &auth.TokenConfig{SigningKey: "change-me"}, err: `invalid token signing key: key is the placeholder "change-me"`},
+		"rs256 without key": {cfg: &auth.TokenConfig{Algorithm: "rs256", PrivateKeyFile: "missing.pem"}, err: "failed to read private key: open missing.pem: no such file or directory"},
+		"unknown algorithm": {cfg: &auth.TokenConfig{Algorithm: "none"}, err: `unsupported token algorithm "none"`},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := token.New(tt.cfg)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
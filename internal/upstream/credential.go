@@ -0,0 +1,59 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/secret"
+)
+
+// refCredential is the credential of a credential_ref, resolved again when the upstream rejects it, so that a
+// rotated secret file or environment is picked up without a restart.
+type refCredential struct {
+	ref   string
+	mu    sync.Mutex
+	value string
+}
+
+// newRefCredential resolves ref.
+func newRefCredential(ref string) (*refCredential, error) {
+	v, err := secret.Resolve(ref)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving credential: %w", err)
+	}
+
+	return &refCredential{ref: ref, value: v}, nil
+}
+
+// Credential implements client.CredentialProvider.
+func (c *refCredential) Credential(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.value, nil
+}
+
+// Refresh implements client.CredentialProvider, failing if the secret of the reference did not change.
+func (c *refCredential) Refresh(_ context.Context, rejected string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.value != rejected {
+		return nil
+	}
+
+	v, err := secret.Resolve(c.ref)
+	if err != nil {
+		return fmt.Errorf("error resolving credential: %w", err)
+	}
+
+	if v == rejected {
+		return client.ErrNotRefreshable
+	}
+
+	c.value = v
+
+	return nil
+}
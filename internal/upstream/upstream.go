@@ -0,0 +1,279 @@
+// Package upstream builds the clients of the upstream APIs of the configuration, by name, so that an integration gets
+// its pre-configured client with Get instead of wiring the base URL, authentication, retries, rate limit and circuit
+// breaker itself.
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/secret"
+	"github.com/twk/skeleton-go-api/internal/signing"
+)
+
+// ErrUnknown is returned by Get for an upstream which is not configured.
+var ErrUnknown = errors.New("unknown upstream")
+
+var (
+	errNoBaseURL      = errors.New("base url is required")
+	errDialHostShared = errors.New("upstreams with different dial settings must not share a host")
+)
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+	Observe(name string, v float64, labels metrics.Labels)
+	Set(name string, v float64, labels metrics.Labels)
+}
+
+// Registry holds the clients of the upstreams.
+type Registry struct {
+	upstreams map[string]*upstream
+}
+
+type upstream struct {
+	baseURL string
+	client  *client.Client
+}
+
+// Option configures the clients of a Registry.
+type Option func(*options)
+
+type options struct {
+	clientOpts  map[string][]client.Option
+	credentials map[string]client.CredentialProvider
+}
+
+// WithClientOptions configures the client of the upstream name with opts, e.g. to validate its responses.
+func WithClientOptions(name string, opts ...client.Option) Option {
+	return func(o *options) {
+		o.clientOpts[name] = append(o.clientOpts[name], opts...)
+	}
+}
+
+// WithCredentials authenticates the requests to the upstream name with the credential of p instead of its
+// credential_ref, e.g. a token obtained from an identity provider and renewed when the upstream rejects it.
+func WithCredentials(name string, p client.CredentialProvider) Option {
+	return func(o *options) {
+		o.credentials[name] = p
+	}
+}
+
+// New creates the clients of the upstreams of cfgs, by name. Each sends its requests through base, after
+// authenticating them and bounding them with the timeout of its upstream, retrying them, failing them fast while
+// the circuit of the upstream is open and limiting their rate, as configured.
+func New(cfgs map[string]config.Upstream, base *http.Client, rec recorder, opts ...Option) (*Registry, error) {
+	r := &Registry{upstreams: make(map[string]*upstream, len(cfgs))}
+
+	o := &options{clientOpts: map[string][]client.Option{}, credentials: map[string]client.CredentialProvider{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := checkDialHosts(cfgs); err != nil {
+		return nil, err
+	}
+
+	for name, cfg := range cfgs {
+		cfg := cfg
+
+		rt, err := transport(&cfg, o.credentials[name], base.Transport, rec)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", name, err)
+		}
+
+		hc := *base
+		hc.Transport = rt
+
+		copts := append([]client.Option{
+			client.WithMaxResponseSize(int64(cfg.MaxResponseSize)),
+			client.WithTimingMetrics(rec, name),
+		}, o.clientOpts[name]...)
+
+		r.upstreams[name] = &upstream{baseURL: cfg.BaseURL, client: client.NewClient(&hc, copts...)}
+	}
+
+	return r, nil
+}
+
+// checkDialHosts fails when upstreams with different dial settings share a host. The connections are pooled by host
+// by the transport at the bottom of the chain, so one upstream would reuse the connections dialed for the other.
+func checkDialHosts(cfgs map[string]config.Upstream) error {
+	names := make([]string, 0, len(cfgs))
+	for name := range cfgs {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	byHost := map[string]string{}
+
+	for _, name := range names {
+		cfg := cfgs[name]
+
+		u, err := url.Parse(cfg.BaseURL)
+		if err != nil || u.Host == "" {
+			// An invalid base URL has no host to share.
+			continue
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+
+		host := net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+
+		other, ok := byHost[host]
+		if !ok {
+			byHost[host] = name
+			continue
+		}
+
+		if cfgs[other].Dial != cfg.Dial {
+			return fmt.Errorf("%w: %s and %s share %s", errDialHostShared, other, name, host)
+		}
+	}
+
+	return nil
+}
+
+// transport returns the chain of round trippers of the upstream of cfg, authenticated with credentials or, if nil, its
+// workload identity or credential_ref, delegating to next.
+func transport(cfg *config.Upstream, credentials client.CredentialProvider, next http.RoundTripper, rec recorder) (http.RoundTripper, error) {
+	if cfg.BaseURL == "" {
+		return nil, errNoBaseURL
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	rt := next
+
+	if cfg.Dial != (config.Dial{}) {
+		dial, err := client.NewDialTransport(&cfg.Dial, rt)
+		if err != nil {
+			return nil, fmt.Errorf("error creating dial transport: %w", err)
+		}
+
+		rt = dial
+	}
+
+	if cfg.Proxy.URL != "" {
+		credential, err := secret.Resolve(cfg.Proxy.CredentialRef)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving proxy credential: %w", err)
+		}
+
+		proxy, err := client.NewProxyTransport(&cfg.Proxy, credential, rt)
+		if err != nil {
+			return nil, fmt.Errorf("error creating proxy transport: %w", err)
+		}
+
+		rt = proxy
+	}
+
+	// Each attempt is signed with its own date.
+	if cfg.Signing.KeyID != "" {
+		key, err := secret.Resolve(cfg.Signing.SecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving signing key: %w", err)
+		}
+
+		rt = signing.NewTransport(signing.NewSigner(cfg.Signing.KeyID, []byte(key), cfg.Signing.Headers...), rt)
+	}
+
+	if cfg.RateLimit.RPS > 0 {
+		limit := cfg.RateLimit
+		limit.BaseURL = cfg.BaseURL
+
+		limiter, err := client.NewRateLimiter([]config.RateLimit{limit}, rt, rec)
+		if err != nil {
+			return nil, fmt.Errorf("error creating rate limiter: %w", err)
+		}
+
+		rt = limiter
+	}
+
+	// Each attempt counts against the rate limit and the circuit.
+	if cfg.CircuitBreaker.Failures > 0 {
+		breaker, err := client.NewCircuitBreaker(cfg.BaseURL, &cfg.CircuitBreaker, rt, rec)
+		if err != nil {
+			return nil, fmt.Errorf("error creating circuit breaker: %w", err)
+		}
+
+		rt = breaker
+	}
+
+	if retries(cfg) {
+		rt = client.NewRetrier(cfg.BaseURL, &cfg.Retry, rt, rec)
+	}
+
+	switch {
+	case credentials != nil:
+	case cfg.Auth == client.AuthGCP || cfg.Auth == client.AuthAzure:
+		wi, err := client.NewWorkloadIdentity(cfg.Auth, &cfg.WorkloadIdentity, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating workload identity: %w", err)
+		}
+
+		credentials = wi
+	case cfg.CredentialRef != "":
+		ref, err := newRefCredential(cfg.CredentialRef)
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = ref
+	}
+
+	// The timeout covers the retries.
+	t, err := client.NewUpstreamTransport(cfg, credentials, rt)
+	if err != nil {
+		return nil, fmt.Errorf("error creating upstream transport: %w", err)
+	}
+
+	return t, nil
+}
+
+// Get returns the client of the upstream name.
+func (r *Registry) Get(name string) (*client.Client, error) {
+	u, ok := r.upstreams[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+
+	return u.client, nil
+}
+
+// BaseURL returns the base URL of the upstream name, empty if it is not configured.
+func (r *Registry) BaseURL(name string) string {
+	if u, ok := r.upstreams[name]; ok {
+		return u.baseURL
+	}
+
+	return ""
+}
+
+// retries reports whether some requests to the upstream of cfg are retried, by default or for their endpoint.
+func retries(cfg *config.Upstream) bool {
+	if cfg.Retry.MaxAttempts > 1 {
+		return true
+	}
+
+	for _, e := range cfg.Endpoints {
+		if e.MaxAttempts > 1 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,235 @@
+package upstream_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/client"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/upstream"
+)
+
+func TestRegistry_Get(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(srv.Close)
+
+	r, err := upstream.New(map[string]config.Upstream{
+		"photos": {
+			BaseURL:        srv.URL,
+			Auth:           client.AuthBearer,
+			CredentialRef:  "token",
+			Retry:          config.Retry{MaxAttempts: 2, Backoff: time.Millisecond},
+			RateLimit:      config.RateLimit{RPS: 100, Burst: 10},
+			CircuitBreaker: config.CircuitBreaker{Failures: 5},
+		},
+	}, &http.Client{}, metrics.Nop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, srv.URL, r.BaseURL("photos"))
+
+	c, err := r.Get("photos")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp, err := c.Get(context.Background(), srv.URL+"/photos/1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	assert.Equal(t, "Bearer token", string(body[:n]))
+	assert.Equal(t, int32(2), calls.Load())
+
+	_, err = r.Get("albums")
+	assert.ErrorIs(t, err, upstream.ErrUnknown)
+	assert.Empty(t, r.BaseURL("albums"))
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]config.Upstream{
+		"no base url":            {},
+		"missing credential":     {BaseURL: "http://upstream", Auth: client.AuthBearer, CredentialRef: "env:SKELETON_UPSTREAM_TEST_UNSET"},
+		"invalid rate limit":     {BaseURL: "http://upstream", RateLimit: config.RateLimit{RPS: 1}},
+		"unknown authentication": {BaseURL: "http://upstream", Auth: "digest", CredentialRef: "token"},
+	}
+
+	for name, cfg := range tests {
+		cfg := cfg
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := upstream.New(map[string]config.Upstream{"photos": cfg}, &http.Client{}, metrics.Nop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNew_DialHosts(t *testing.T) {
+	t.Parallel()
+
+	ipv6 := config.Dial{IPFamily: client.IPFamilyIPv6}
+
+	tests := map[string]struct {
+		cfgs map[string]config.Upstream
+		err  string
+	}{
+		"same host, same settings": {
+			cfgs: map[string]config.Upstream{
+				"photos": {BaseURL: "https://api.example.com/photos", Dial: ipv6},
+				"albums": {BaseURL: "https://API.example.com:443/albums", Dial: ipv6},
+			},
+		},
+		"other hosts": {
+			cfgs: map[string]config.Upstream{
+				"photos": {BaseURL: "https://photos.example.com", Dial: ipv6},
+				"albums": {BaseURL: "https://albums.example.com"},
+			},
+		},
+		"other ports": {
+			cfgs: map[string]config.Upstream{
+				"photos": {BaseURL: "https://api.example.com", Dial: ipv6},
+				"albums": {BaseURL: "http://api.example.com"},
+			},
+		},
+		"same host, other settings": {
+			cfgs: map[string]config.Upstream{
+				"photos": {BaseURL: "https://api.example.com/photos", Dial: ipv6},
+				"albums": {BaseURL: "https://api.example.com/albums"},
+			},
+			err: "upstreams with different dial settings must not share a host: albums and photos share api.example.com:443",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := upstream.New(tt.cfgs, &http.Client{}, metrics.Nop())
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestRegistry_RotatedCredential(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "token")
+	if !assert.NoError(t, os.WriteFile(path, []byte("old\n"), 0o600)) {
+		return
+	}
+
+	var token atomic.Value
+	token.Store("old")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	r, err := upstream.New(map[string]config.Upstream{
+		"photos": {BaseURL: srv.URL, Auth: client.AuthBearer, CredentialRef: "file:" + path},
+	}, &http.Client{}, metrics.Nop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c, _ := r.Get("photos")
+
+	get := func() error {
+		resp, err := c.Get(context.Background(), srv.URL+"/photos/1")
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		return err
+	}
+
+	assert.NoError(t, get())
+
+	// The upstream rotates the token before the secret is updated, then after.
+	token.Store("new")
+	assert.Error(t, get())
+
+	if !assert.NoError(t, os.WriteFile(path, []byte("new\n"), 0o600)) {
+		return
+	}
+
+	assert.NoError(t, get())
+}
+
+func TestRegistry_WorkloadIdentity(t *testing.T) {
+	t.Parallel()
+
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token":"workload","expires_on":"` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `"}`))
+	}))
+	t.Cleanup(metadata.Close)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(srv.Close)
+
+	r, err := upstream.New(map[string]config.Upstream{
+		"photos": {
+			BaseURL:          srv.URL,
+			Auth:             client.AuthAzure,
+			WorkloadIdentity: config.WorkloadIdentity{Audience: "api://photos", MetadataURL: metadata.URL},
+		},
+	}, &http.Client{}, metrics.Nop())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c, _ := r.Get("photos")
+
+	resp, err := c.Get(context.Background(), srv.URL+"/photos/1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "Bearer workload", string(body))
+}
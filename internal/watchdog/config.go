@@ -0,0 +1,49 @@
+package watchdog
+
+import (
+	"errors"
+	"time"
+
+	"github.com/twk/skeleton-go-api/internal/config"
+)
+
+// Config holds the sampling of the goroutines, open files and heap of the service, to detect leaks.
+type Config struct {
+	// Enabled samples the resources and records them as metrics.
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the period of the samples. Zero uses 30s.
+	Interval time.Duration `mapstructure:"interval"`
+	// MaxGoroutines is the threshold of the goroutines. Zero disables it.
+	MaxGoroutines int `mapstructure:"max_goroutines"`
+	// MaxOpenFiles is the threshold of the open file descriptors, sampled on Linux only. Zero disables it.
+	MaxOpenFiles int `mapstructure:"max_open_files"`
+	// MaxHeapBytes is the threshold of the heap objects, e.g. 1GiB. Zero disables it.
+	MaxHeapBytes config.ByteSize `mapstructure:"max_heap_bytes"`
+	// DumpDir is the directory the goroutines are dumped to when a threshold is exceeded. Empty disables the dumps.
+	DumpDir string `mapstructure:"dump_dir"`
+	// DumpCooldown is the minimum time between two dumps. Zero uses 10m.
+	DumpCooldown time.Duration `mapstructure:"dump_cooldown"`
+}
+
+// ConfigSection is the section of the configuration of the watchdog, under watchdog. It has to be registered with the
+// config.Viper building the configuration.
+var ConfigSection = config.NewSection("watchdog", Config{Interval: defaultInterval, DumpCooldown: defaultDumpCooldown},
+	validateConfig)
+
+func validateConfig(cfg *Config) error {
+	var errs []error
+
+	if cfg.Interval < 0 {
+		errs = append(errs, errors.New("interval must not be negative"))
+	}
+
+	if cfg.MaxGoroutines < 0 || cfg.MaxOpenFiles < 0 {
+		errs = append(errs, errors.New("thresholds must not be negative"))
+	}
+
+	if cfg.DumpCooldown < 0 {
+		errs = append(errs, errors.New("dump_cooldown must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
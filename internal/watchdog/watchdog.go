@@ -0,0 +1,195 @@
+// Package watchdog samples the goroutines, open file descriptors and heap of the service at an interval, to detect
+// leaks before they exhaust the process. A resource over its threshold is logged and counted, and can trigger a dump
+// of the goroutines to disk for postmortem analysis.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	rtmetrics "runtime/metrics"
+	"runtime/pprof"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+)
+
+const (
+	// GoroutinesMetric is the number of goroutines.
+	GoroutinesMetric = "watchdog_goroutines"
+	// OpenFilesMetric is the number of open file descriptors, on Linux.
+	OpenFilesMetric = "watchdog_open_files"
+	// HeapMetric is the size of the live and unswept heap objects in bytes.
+	HeapMetric = "watchdog_heap_bytes"
+	// ExceededMetric counts the times a resource went over its threshold, by resource.
+	ExceededMetric = "watchdog_threshold_exceeded_total"
+)
+
+// Resources sampled by the watchdog.
+const (
+	Goroutines = "goroutines"
+	OpenFiles  = "open_files"
+	Heap       = "heap"
+)
+
+// Defaults of the watchdog.
+const (
+	defaultInterval     = 30 * time.Second
+	defaultDumpCooldown = 10 * time.Minute
+	// heapMetric is the runtime metric of the heap, read without stopping the world unlike runtime.ReadMemStats.
+	heapMetric = "/memory/classes/heap/objects:bytes"
+	// dumpDebug writes the goroutines with their full stacks, as in a panic.
+	dumpDebug = 2
+)
+
+type recorder interface {
+	Inc(name string, labels metrics.Labels)
+	Set(name string, v float64, labels metrics.Labels)
+}
+
+// Sample is the usage of the resources at a point in time. OpenFiles is -1 where it cannot be read.
+type Sample struct {
+	Goroutines int
+	OpenFiles  int
+	HeapBytes  uint64
+}
+
+// Watchdog samples the resources against their thresholds.
+type Watchdog struct {
+	cfg *Config
+	rec recorder
+	log *logger.Logger
+	// exceeded are the resources over their threshold at the last sample.
+	exceeded map[string]bool
+	lastDump time.Time
+}
+
+// New creates the watchdog of cfg, recording the samples with rec.
+func New(cfg *Config, rec recorder, l *logger.Logger) *Watchdog {
+	return &Watchdog{cfg: cfg, rec: rec, log: l, exceeded: map[string]bool{}}
+}
+
+// Run samples the resources every interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context) {
+	interval := w.cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check samples the resources and compares them to their thresholds: a resource going over its threshold is logged
+// and counted, and dumps the goroutines if configured.
+func (w *Watchdog) Check(now time.Time) Sample {
+	s := Take()
+
+	w.rec.Set(GoroutinesMetric, float64(s.Goroutines), nil)
+	w.rec.Set(HeapMetric, float64(s.HeapBytes), nil)
+
+	if s.OpenFiles >= 0 {
+		w.rec.Set(OpenFilesMetric, float64(s.OpenFiles), nil)
+	}
+
+	over := map[string]bool{
+		Goroutines: w.cfg.MaxGoroutines > 0 && s.Goroutines > w.cfg.MaxGoroutines,
+		OpenFiles:  w.cfg.MaxOpenFiles > 0 && s.OpenFiles > w.cfg.MaxOpenFiles,
+		Heap:       w.cfg.MaxHeapBytes > 0 && s.HeapBytes > uint64(w.cfg.MaxHeapBytes),
+	}
+
+	var dump bool
+
+	for _, resource := range []string{Goroutines, OpenFiles, Heap} {
+		switch {
+		case over[resource] && !w.exceeded[resource]:
+			w.rec.Inc(ExceededMetric, metrics.Labels{"resource": resource})
+			w.log.Warn("Resource over its threshold", zap.String("resource", resource), zap.Int("goroutines", s.Goroutines),
+				zap.Int("open_files", s.OpenFiles), zap.Uint64("heap_bytes", s.HeapBytes))
+
+			dump = true
+		case !over[resource] && w.exceeded[resource]:
+			w.log.Info("Resource back under its threshold", zap.String("resource", resource))
+		}
+
+		w.exceeded[resource] = over[resource]
+	}
+
+	if dump && w.cfg.DumpDir != "" {
+		w.dump(now)
+	}
+
+	return s
+}
+
+// dump writes the goroutines to a file of the dump directory, at most once per cooldown.
+func (w *Watchdog) dump(now time.Time) {
+	cooldown := w.cfg.DumpCooldown
+	if cooldown <= 0 {
+		cooldown = defaultDumpCooldown
+	}
+
+	if !w.lastDump.IsZero() && now.Sub(w.lastDump) < cooldown {
+		return
+	}
+
+	w.lastDump = now
+
+	path := filepath.Join(w.cfg.DumpDir, fmt.Sprintf("goroutines-%s.txt", now.UTC().Format("20060102T150405Z")))
+	if err := writeDump(path); err != nil {
+		w.log.Warn("Failed to dump goroutines", zap.Error(err))
+		return
+	}
+
+	w.log.Warn("Goroutines dumped", zap.String("path", path))
+}
+
+func writeDump(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create goroutine dump: %w", err)
+	}
+
+	if err = pprof.Lookup("goroutine").WriteTo(f, dumpDebug); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write goroutine dump: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to close goroutine dump: %w", err)
+	}
+
+	return nil
+}
+
+// Take samples the resources of the process.
+func Take() Sample {
+	heap := []rtmetrics.Sample{{Name: heapMetric}}
+	rtmetrics.Read(heap)
+
+	s := Sample{Goroutines: runtime.NumGoroutine(), OpenFiles: -1}
+	if heap[0].Value.Kind() == rtmetrics.KindUint64 {
+		s.HeapBytes = heap[0].Value.Uint64()
+	}
+
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		s.OpenFiles = len(fds)
+	}
+
+	return s
+}
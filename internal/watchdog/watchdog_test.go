@@ -0,0 +1,74 @@
+package watchdog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/metrics"
+	"github.com/twk/skeleton-go-api/internal/watchdog"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	core, logs := observer.New(zap.InfoLevel)
+	mr := metrics.New()
+	w := watchdog.New(&watchdog.Config{MaxGoroutines: 1, MaxHeapBytes: 1 << 40, DumpDir: dir}, mr, &logger.Logger{Logger: zap.New(core)})
+
+	now := time.Now()
+	s := w.Check(now)
+	assert.Greater(t, s.Goroutines, 1)
+	assert.Positive(t, s.HeapBytes)
+
+	// The goroutines are still over their threshold: neither logged nor dumped again.
+	w.Check(now.Add(time.Minute))
+
+	warnings := logs.FilterMessage("Resource over its threshold").All()
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, watchdog.Goroutines, warnings[0].ContextMap()["resource"])
+	}
+
+	dumps, err := os.ReadDir(dir)
+	if !assert.NoError(t, err) || !assert.Len(t, dumps, 1) {
+		return
+	}
+
+	b, err := os.ReadFile(dir + "/" + dumps[0].Name())
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "TestWatchdog_Check")
+	}
+
+	resp := httptest.NewRecorder()
+	mr.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	assert.Contains(t, resp.Body.String(), `watchdog_threshold_exceeded_total{resource="goroutines"} 1`)
+	assert.Contains(t, resp.Body.String(), "watchdog_heap_bytes ")
+}
+
+func TestWatchdog_Run(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+	w := watchdog.New(&watchdog.Config{Interval: time.Millisecond, MaxGoroutines: 1}, metrics.Nop(), &logger.Logger{Logger: zap.New(core)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return logs.FilterMessage("Resource over its threshold").Len() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
@@ -0,0 +1,197 @@
+// Package app builds the service of the skeleton as a library, so that other projects serve their own routes and
+// middleware with its configuration, authentication, observability and lifecycle rather than forking it:
+//
+//	err := app.New().
+//		WithConfigFile("config.yaml").
+//		WithRoutes(app.Route{Method: http.MethodGet, Path: "/hello", Handler: hello, Auth: app.AuthRequired}).
+//		WithMiddleware(requestID).
+//		Run(ctx)
+//
+// The routes are served next to the ones of the enabled subsystems, behind the same middleware, and the middleware
+// is applied to all of them after the one of the subsystems.
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	wiring "github.com/twk/skeleton-go-api/internal/app"
+	"github.com/twk/skeleton-go-api/internal/auth"
+	"github.com/twk/skeleton-go-api/internal/config"
+	"github.com/twk/skeleton-go-api/internal/jsonbind"
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/internal/server"
+)
+
+// defaultConfigFile is the configuration read when none is set, as by the command line.
+const defaultConfigFile = "./config.yaml"
+
+type (
+	// Config is the configuration of the service, as read from the config.yaml of the command line.
+	Config = config.Config
+	// Route is a route served by the service.
+	Route = server.RouteParam
+	// AuthMode is the authentication requirement of a Route.
+	AuthMode = auth.Mode
+	// Policy is the authorization policy of a Route.
+	Policy = auth.Policy
+	// RateLimit limits the rate of the requests of a Route.
+	RateLimit = server.RateLimit
+	// Canary sends a share of the requests of a Route to an alternate handler.
+	Canary = server.Canary
+	// JSONOptions are the decoding options of the JSON request bodies of a Route, bound with BindJSON.
+	JSONOptions = jsonbind.Options
+	// Identity is the authenticated consumer of a request, returned by IdentityFromContext.
+	Identity = auth.Identity
+	// Logger is the logger of the service.
+	Logger = logger.Logger
+)
+
+// Authentication requirements of a Route.
+const (
+	// AuthOptional authenticates the requests carrying credentials and lets anonymous requests through.
+	AuthOptional = auth.ModeOptional
+	// AuthRequired rejects anonymous requests with 401.
+	AuthRequired = auth.ModeRequired
+	// AuthNone does not authenticate, credentials are ignored.
+	AuthNone = auth.ModeNone
+)
+
+// IdentityFromContext returns the authenticated consumer of the request of ctx, nil when anonymous.
+func IdentityFromContext(ctx context.Context) *Identity {
+	return auth.IdentityFromContext(ctx)
+}
+
+// BindJSON decodes the JSON body of the request of c into v with the JSONOptions of its Route, or the ones of the
+// strictness mode, and validates it.
+func BindJSON(c *gin.Context, v any) error {
+	return jsonbind.Bind(c, v) //nolint:wrapcheck // the errors describe the body
+}
+
+// App builds the service. The With methods are called before Handler or Run, which build it once.
+type App struct {
+	cfg        *config.Config
+	configFile string
+	log        *logger.Logger
+	routes     []server.RouteParam
+	middleware []gin.HandlerFunc
+
+	once      sync.Once
+	container *wiring.Container
+	err       error
+}
+
+// New creates an App reading ./config.yaml, logging with the default logger.
+func New() *App {
+	return &App{configFile: defaultConfigFile}
+}
+
+// WithConfig sets the configuration of the service, instead of reading a file.
+func (a *App) WithConfig(cfg *Config) *App {
+	a.cfg = cfg
+	return a
+}
+
+// WithConfigFile sets the YAML configuration file of the service. A missing file leaves the configuration empty.
+func (a *App) WithConfigFile(path string) *App {
+	a.configFile = path
+	return a
+}
+
+// WithLogger sets the logger of the service.
+func (a *App) WithLogger(l *Logger) *App {
+	a.log = l
+	return a
+}
+
+// WithRoutes adds routes to the service.
+func (a *App) WithRoutes(routes ...Route) *App {
+	a.routes = append(a.routes, routes...)
+	return a
+}
+
+// WithMiddleware adds middleware applied to every route, after the middleware of the service.
+func (a *App) WithMiddleware(middleware ...gin.HandlerFunc) *App {
+	a.middleware = append(a.middleware, middleware...)
+	return a
+}
+
+// Handler builds the service and returns its handler, without starting its background work, e.g. for tests.
+func (a *App) Handler() (http.Handler, error) {
+	c, err := a.build()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := wiring.Get[*server.Server](c)
+	if err != nil {
+		return nil, fmt.Errorf("error creating server: %w", err)
+	}
+
+	return s, nil
+}
+
+// Run builds the service, then serves until ctx is done and shuts it down gracefully.
+func (a *App) Run(ctx context.Context) error {
+	c, err := a.build()
+	if err != nil {
+		return err
+	}
+
+	if err = wiring.Run(ctx, c); err != nil {
+		return fmt.Errorf("error running server: %w", err)
+	}
+
+	return nil
+}
+
+func (a *App) build() (*wiring.Container, error) {
+	a.once.Do(func() {
+		a.container, a.err = a.newContainer()
+	})
+
+	return a.container, a.err
+}
+
+func (a *App) newContainer() (*wiring.Container, error) {
+	if a.log == nil {
+		a.log = logger.NewLogger(nil)
+	}
+
+	var v *config.Viper
+
+	if a.cfg == nil {
+		v = config.NewViper()
+		wiring.RegisterSections(v)
+		v.Viper.Set("config_path", a.configFile)
+
+		cfg, err := v.BuildConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error building config: %w", err)
+		}
+
+		a.cfg = cfg
+	}
+
+	st, err := wiring.Configure(a.cfg)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by Configure
+	}
+
+	c := wiring.New(a.cfg, st, a.log)
+	if v != nil {
+		wiring.Supply(c, v)
+	}
+
+	routes, middleware := a.routes, a.middleware
+	wiring.Contribute(c, func(*wiring.Container) ([]server.RouteParam, error) { return routes, nil })
+	wiring.Contribute(c, func(*wiring.Container) ([]server.Option, error) {
+		return []server.Option{server.WithMiddleware(middleware...)}, nil
+	})
+
+	return c, nil
+}
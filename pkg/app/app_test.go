@@ -0,0 +1,92 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/twk/skeleton-go-api/internal/logger"
+	"github.com/twk/skeleton-go-api/pkg/app"
+)
+
+func TestApp_Handler(t *testing.T) {
+	t.Parallel()
+
+	cfg := &app.Config{Strictness: "dev"}
+	cfg.Client.MockUpstream.Enabled = true
+
+	a := app.New().
+		WithConfig(cfg).
+		WithLogger(logger.NewNop()).
+		WithRoutes(
+			app.Route{Method: http.MethodGet, Path: "/hello", Handler: func(c *gin.Context) { c.String(http.StatusOK, "hello") }},
+			app.Route{Method: http.MethodGet, Path: "/private", Handler: func(c *gin.Context) { c.Status(http.StatusOK) }, Auth: app.AuthRequired},
+		).
+		WithMiddleware(func(c *gin.Context) { c.Header("X-Custom", "yes") })
+
+	h, err := a.Handler()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := map[string]struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		"custom route":        {path: "/hello", wantStatus: http.StatusOK, wantBody: "hello"},
+		"authenticated route": {path: "/private", wantStatus: http.StatusUnauthorized},
+		"service route":       {path: "/photos/1", wantStatus: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "yes", w.Header().Get("X-Custom"))
+
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestApp_Errors(t *testing.T) {
+	t.Parallel()
+
+	mockUpstream := &app.Config{Strictness: "prod"}
+	mockUpstream.Client.MockUpstream.Enabled = true
+
+	faults := &app.Config{Strictness: "prod"}
+	faults.Chaos.Enabled = true
+
+	tests := map[string]struct {
+		cfg     *app.Config
+		wantErr string
+	}{
+		"mock upstream in prod": {cfg: mockUpstream, wantErr: "client.mock_upstream is not allowed in prod mode"},
+		"chaos in prod":         {cfg: faults, wantErr: "chaos is not allowed in prod mode"},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := app.New().WithConfig(tt.cfg).WithLogger(logger.NewNop()).Handler()
+
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}